@@ -2,9 +2,330 @@ package activities
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"temporal-ai-agent/activities/llm"
+	"temporal-ai-agent/analytics"
+	"temporal-ai-agent/billing"
+	"temporal-ai-agent/blobstore"
+	"temporal-ai-agent/channels"
+	"temporal-ai-agent/chunking"
+	"temporal-ai-agent/deadletter"
+	"temporal-ai-agent/digest"
+	"temporal-ai-agent/evals"
+	"temporal-ai-agent/experiments"
+	"temporal-ai-agent/extract"
+	"temporal-ai-agent/fewshot"
+	"temporal-ai-agent/goals"
+	"temporal-ai-agent/knowledge"
+	"temporal-ai-agent/operator"
+	"temporal-ai-agent/outbox"
+	"temporal-ai-agent/personas"
+	"temporal-ai-agent/quota"
+	"temporal-ai-agent/search"
+	"temporal-ai-agent/semcache"
+	"temporal-ai-agent/shadow"
+	"temporal-ai-agent/store"
+	"temporal-ai-agent/streaming"
+	"temporal-ai-agent/templates"
+	"temporal-ai-agent/tokens"
+	"temporal-ai-agent/tools"
+	"temporal-ai-agent/websearch"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
 )
 
-func Greet(ctx context.Context, name string) (string, error) {
-	return fmt.Sprintf("Hello %s", name), nil
+// maxTitleLength caps generated conversation titles, in characters
+const maxTitleLength = 60
+
+// Activities holds the dependencies shared by the agent activities
+type Activities struct {
+	LLM llm.Provider
+	// TitleModel is the (cheap) model used to summarize conversations into titles
+	TitleModel string
+	// HedgedLLM answers completions of the HedgeGoals (all goals when empty) by racing a
+	// backup provider against LLM; nil disables hedging
+	HedgedLLM  llm.Provider
+	HedgeGoals []string
+	// TenantLLM answers the turns of tenants who brought their own API key with it, instead
+	// of LLM; nil answers every turn with LLM
+	TenantLLM *llm.Tenants
+	// SemanticCache answers near-duplicate questions of the SemanticCacheGoals without a
+	// completion when their embeddings reach SemanticCacheThreshold; answers older than
+	// SemanticCacheTTL (0 keeps them) are ignored. nil disables it.
+	SemanticCache          semcache.Cache
+	SemanticCacheGoals     []string
+	SemanticCacheThreshold float64
+	SemanticCacheTTL       time.Duration
+	// EmbedBatchSize caps the texts sent in one embedding request (0 sends them all at once),
+	// and EmbedConcurrency the batches embedded at the same time
+	EmbedBatchSize   int
+	EmbedConcurrency int
+	// Experiments are the prompt/model experiments conversations can be assigned to
+	Experiments []experiments.Experiment
+	// Shadows records the turns answered by the candidates of shadow experiments; nil
+	// disables shadow completions
+	Shadows shadow.Store
+	// Examples holds curated few-shot examples; nil disables them
+	Examples fewshot.Store
+	// ExamplesLimit is the maximum number of examples injected per turn
+	ExamplesLimit int
+	// ExamplesUseEmbeddings selects examples by embedding similarity when the provider supports it
+	ExamplesUseEmbeddings bool
+	// Personas are the voices conversations can be configured with
+	Personas personas.Catalog
+	// Templates are the guided flows conversations can be started from
+	Templates templates.Catalog
+	// MaxContextTokens is the prompt budget of completion requests, allocated across the
+	// prompt's sections by ContextPolicy (the default policy when zero); 0 disables trimming
+	MaxContextTokens int
+	ContextPolicy    tokens.Policy
+	// Tools are the tools the agent can call
+	Tools *tools.Registry
+	// ToolBreakers short-circuit calls to failing tools; nil disables them
+	ToolBreakers *tools.Breakers
+	// ToolResultLimits bound the size of tool results, for tools without their own limits.
+	// Oversized results are compressed to the passages relevant to the request when
+	// ExtractToolResults is set, summarized by the title model when SummarizeToolResults is
+	// set, and truncated otherwise.
+	ToolResultLimits     tools.Limits
+	ExtractToolResults   bool
+	SummarizeToolResults bool
+	// Goals are the goals conversations can be started for, restricting their tools; nil
+	// accepts any goal with every tool
+	Goals *goals.Catalog
+	// ToolPolicies restrict the tools per environment and goal
+	ToolPolicies tools.Policies
+	// RiskPolicy sets the confirmation tool calls require from the tools' risk levels; nil
+	// uses the default policy
+	RiskPolicy tools.RiskPolicy
+	// Environment selects the tool policies that apply (e.g. dev, staging, prod)
+	Environment string
+	// KnowledgeBase is the knowledge base searched by the retrieval tool; nil when no
+	// knowledge file is configured
+	KnowledgeBase *knowledge.Base
+	// Knowledge stores the passages of ingested documents; nil disables ingestion
+	Knowledge knowledge.Store
+	// IngestSource is the bucket whose documents are ingested on a schedule; nil disables it
+	IngestSource blobstore.Lister
+	// Chunkers split ingested documents into passages, by document type
+	Chunkers *chunking.Selector
+	// OCR recognizes the text of scanned documents and images; nil rejects them
+	OCR extract.OCR
+	// Searcher runs web searches for the research agent
+	Searcher websearch.Searcher
+	// Operators receives handoff notifications for human operators
+	Operators operator.Notifier
+	// Digests delivers daily digests to users
+	Digests digest.Deliverer
+	// Channels deliver the agent's messages to the channels conversations are continued on
+	Channels channels.Adapters
+	// Outbox records operator notifications until they are delivered
+	Outbox outbox.Store
+	// Search indexes transcripts for full-text search; nil disables indexing
+	Search search.Index
+	// Usage records the usage counted against quotas; nil disables recording
+	Usage quota.Store
+	// QuotaPlans names the plan of each account on billing statements
+	QuotaPlans quota.Plans
+	// Billing delivers billing statements; nil disables billing exports
+	Billing billing.Exporter
+	// Analytics receives conversation analytics events; nil disables them
+	Analytics analytics.Sink
+	// Streams receives the replies of conversations as they are generated; nil disables streaming
+	Streams *streaming.Bridge
+	// Conversations persists conversations beyond Temporal's history retention; nil disables it
+	Conversations store.Store
+	// Evaluations stores the judge's scores of finished conversations; nil disables evaluation
+	Evaluations evals.Store
+	// DeadLetters keeps the turns that failed for admins to retry; nil disables recording
+	DeadLetters deadletter.Store
+	// EvalModel is the judge model; empty uses the provider's default
+	EvalModel string
+	// GroundingMode checks answers written from retrieved passages against them, flagging
+	// or regenerating answers with unsupported claims; empty disables it. GroundingModel is
+	// the verifier model; empty uses the provider's default.
+	GroundingMode  string
+	GroundingModel string
+}
+
+// Complete sends the conversation to the configured LLM provider and returns its reply,
+// which is either a message or a tool call
+func (a *Activities) Complete(ctx context.Context, req llm.Request) (llm.Response, error) {
+	counter := tokens.ForModel(req.Model)
+	if a.MaxContextTokens > 0 {
+		req.Messages = a.ContextPolicy.Fit(counter, req.Messages, a.MaxContextTokens)
+	}
+
+	// Completions outlasting a turn's latency budget are cancelled through heartbeats
+	defer keepHeartbeating(ctx)()
+
+	provider, err := a.provider(ctx, req.Tenant)
+	if err != nil {
+		return llm.Response{}, err
+	}
+	// Hedging races the platform's providers; tenants' own keys are never hedged
+	if provider == a.LLM && a.HedgedLLM != nil && (len(a.HedgeGoals) == 0 || slices.Contains(a.HedgeGoals, req.Goal)) {
+		provider = a.HedgedLLM
+	}
+
+	resp, err := a.streamComplete(ctx, provider, req)
+	var rejected *llm.StatusError
+	if errors.As(err, &rejected) && !rejected.Retryable() {
+		return llm.Response{}, temporal.NewNonRetryableApplicationError(err.Error(), "LLMRequestRejected", err)
+	}
+	if err != nil {
+		return llm.Response{}, err
+	}
+
+	// Count tokens ourselves for providers that do not report usage
+	if resp.Usage.Total() == 0 {
+		resp.Usage = llm.Usage{
+			PromptTokens:     counter.CountMessages(req.Messages),
+			CompletionTokens: counter.Count(resp.Content),
+		}
+	}
+	activity.GetLogger(ctx).Info("LLM usage",
+		"model", resp.Model,
+		"prompt_tokens", resp.Usage.PromptTokens,
+		"completion_tokens", resp.Usage.CompletionTokens)
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.String("gen_ai.response.model", resp.Model),
+		attribute.Int("gen_ai.usage.input_tokens", resp.Usage.PromptTokens),
+		attribute.Int("gen_ai.usage.output_tokens", resp.Usage.CompletionTokens))
+	return resp, nil
+}
+
+// provider returns the provider of a tenant's completions: the tenant's own when it brought
+// an API key, so the spend lands on its account, and the configured one otherwise. Every
+// LLM call made for a conversation goes through it.
+func (a *Activities) provider(ctx context.Context, tenant string) (llm.Provider, error) {
+	if a.TenantLLM == nil || tenant == "" {
+		return a.LLM, nil
+	}
+	own, err := a.TenantLLM.Provider(ctx, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("reading the tenant's LLM key: %w", err)
+	}
+	if own == nil {
+		return a.LLM, nil
+	}
+	return own, nil
+}
+
+// keepHeartbeating records heartbeats at a third of the activity's heartbeat timeout until
+// the returned function is called. Activities calling an LLM provider or the web run it
+// around calls that can take minutes; activities without a heartbeat timeout do not
+// heartbeat.
+func keepHeartbeating(ctx context.Context) func() {
+	timeout := activity.GetInfo(ctx).HeartbeatTimeout
+	if timeout <= 0 {
+		return func() {}
+	}
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(timeout / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				activity.RecordHeartbeat(ctx)
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// embedder returns the provider's embedder, which splits large requests into batches, or
+// false when the provider cannot embed
+func (a *Activities) embedder() (llm.Embedder, bool) {
+	e, ok := a.LLM.(llm.Embedder)
+	if !ok {
+		return nil, false
+	}
+	return llm.BatchedEmbedder{Embedder: e, BatchSize: a.EmbedBatchSize, Concurrency: a.EmbedConcurrency}, true
+}
+
+// streamComplete runs a completion, streaming the reply to the conversation's stream when a
+// bridge is configured and the provider can stream. Publishing is best effort.
+func (a *Activities) streamComplete(ctx context.Context, provider llm.Provider, req llm.Request) (llm.Response, error) {
+	streamer, ok := provider.(llm.Streamer)
+	if a.Streams == nil || !ok {
+		return provider.Complete(ctx, req)
+	}
+
+	info := activity.GetInfo(ctx)
+	workflowID := info.WorkflowExecution.ID
+	publish := func(kind, data string) {
+		if err := a.Streams.Publish(ctx, workflowID, kind, data); err != nil {
+			activity.GetLogger(ctx).Warn("Unable to publish to the stream", "error", err)
+		}
+	}
+	if info.Attempt > 1 {
+		publish(streaming.KindReset, "")
+	}
+	return streamer.Stream(ctx, req, func(chunk string) {
+		publish(streaming.KindToken, chunk)
+	})
+}
+
+// GenerateTitleRequest is the input of the GenerateTitle activity
+type GenerateTitleRequest struct {
+	Messages []llm.Message `json:"messages"`
+	// Tenant is the quota account of the conversation, whose own LLM key is used if it has one
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// GenerateTitle asks the LLM for a short title summarizing the conversation so far
+func (a *Activities) GenerateTitle(ctx context.Context, req GenerateTitleRequest) (string, error) {
+	defer keepHeartbeating(ctx)()
+	prompt := []llm.Message{{
+		Role:    llm.RoleSystem,
+		Content: "Summarize the following conversation as a title of at most six words. Reply with the title only.",
+	}}
+	prompt = append(prompt, req.Messages...)
+
+	provider, err := a.provider(ctx, req.Tenant)
+	if err != nil {
+		return "", err
+	}
+	resp, err := provider.Complete(ctx, llm.Request{Model: a.TitleModel, Messages: prompt})
+	if err != nil {
+		return "", err
+	}
+
+	title := strings.Trim(strings.TrimSpace(resp.Content), `"`)
+	// Cut on characters, not bytes, so a title is never left with half a character
+	if runes := []rune(title); len(runes) > maxTitleLength {
+		title = strings.TrimSpace(string(runes[:maxTitleLength])) + "…"
+	}
+	return title, nil
+}
+
+// AssignVariant assigns the conversation to a variant of the active experiment, and to a
+// candidate of the active shadow experiment. The zero Assignment is returned when no
+// experiment is active.
+func (a *Activities) AssignVariant(ctx context.Context, workflowID string) (experiments.Assignment, error) {
+	var assignment experiments.Assignment
+	if exp, ok := experiments.Active(a.Experiments); ok {
+		assignment.Experiment = exp.Name
+		assignment.Variant = exp.Assign(workflowID)
+	}
+	if exp, ok := experiments.ActiveShadow(a.Experiments); ok {
+		assignment.Shadow = &experiments.Assignment{
+			Experiment: exp.Name,
+			Variant:    exp.Assign(workflowID),
+		}
+	}
+	return assignment, nil
 }
@@ -0,0 +1,14 @@
+package activities
+
+import (
+	"context"
+	"temporal-ai-agent/analytics"
+)
+
+// TrackEvents sends conversation analytics events to the configured sink
+func (a *Activities) TrackEvents(ctx context.Context, events []analytics.Event) error {
+	if a.Analytics == nil {
+		return nil
+	}
+	return a.Analytics.Send(ctx, events)
+}
@@ -0,0 +1,21 @@
+package activities
+
+import (
+	"context"
+)
+
+// RunBackgroundTaskRequest is the input of the RunBackgroundTask activity
+type RunBackgroundTaskRequest struct {
+	Description string `json:"description"`
+	// Tenant is the quota account of the conversation that started the task, whose own LLM
+	// key is used if it has one
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// RunBackgroundTask performs one run of a background task and returns a short report
+func (a *Activities) RunBackgroundTask(ctx context.Context, req RunBackgroundTaskRequest) (string, error) {
+	defer keepHeartbeating(ctx)()
+	return a.complete(ctx, req.Tenant,
+		"You are running a recurring background task for a user. Perform it and report the findings in one or two sentences.",
+		req.Description)
+}
@@ -0,0 +1,61 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"temporal-ai-agent/billing"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+)
+
+// ExportBillingRequest is the input of the ExportBilling activity
+type ExportBillingRequest struct {
+	Statement billing.Statement `json:"statement"`
+	Format    string            `json:"format"`
+}
+
+// BillingStatement aggregates the quota usage of a billing month ("2025-10") per account
+func (a *Activities) BillingStatement(ctx context.Context, period string) (billing.Statement, error) {
+	month, err := time.Parse("2006-01", period)
+	if err != nil {
+		return billing.Statement{}, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("invalid billing period %q", period), "InvalidPeriod", err)
+	}
+	if a.Usage == nil {
+		return billing.Statement{}, temporal.NewNonRetryableApplicationError(
+			"no usage store is configured", "UsageUnavailable", nil)
+	}
+
+	usage, err := a.Usage.MonthUsage(ctx, month)
+	if err != nil {
+		return billing.Statement{}, err
+	}
+
+	statement := billing.Statement{Period: period, GeneratedAt: time.Now().UTC(), Lines: []billing.Line{}}
+	for _, u := range usage {
+		statement.Lines = append(statement.Lines, billing.Line{
+			Account:  u.Account,
+			Plan:     a.QuotaPlans.PlanOf(u.Account),
+			Messages: u.Messages,
+			Tokens:   u.Tokens,
+		})
+	}
+	return statement, nil
+}
+
+// ExportBilling delivers a billing statement to the configured exporter and returns its location
+func (a *Activities) ExportBilling(ctx context.Context, req ExportBillingRequest) (string, error) {
+	if a.Billing == nil {
+		return "", temporal.NewNonRetryableApplicationError(
+			"no billing exporter is configured", "BillingUnavailable", nil)
+	}
+	location, err := a.Billing.Export(ctx, req.Statement, req.Format)
+	if err != nil {
+		return "", err
+	}
+	activity.GetLogger(ctx).Info("Exported billing statement",
+		"period", req.Statement.Period, "accounts", len(req.Statement.Lines), "location", location)
+	return location, nil
+}
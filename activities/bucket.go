@@ -0,0 +1,128 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"temporal-ai-agent/blobstore"
+	"temporal-ai-agent/extract"
+	"temporal-ai-agent/knowledge"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+// BucketChangesRequest is the input of the ListBucketChanges activity
+type BucketChangesRequest struct {
+	Namespace string `json:"namespace"`
+}
+
+// BucketChanges are the differences between a bucket and the documents ingested from it
+type BucketChanges struct {
+	// Listed is the number of objects in the bucket
+	Listed int `json:"listed"`
+	// Changed are the new objects and those whose ETag differs from the ingested version
+	Changed []blobstore.Object `json:"changed,omitempty"`
+	// Removed are the documents whose object is no longer in the bucket
+	Removed []string `json:"removed,omitempty"`
+}
+
+// IngestObjectRequest is the input of the IngestObject activity
+type IngestObjectRequest struct {
+	Namespace string           `json:"namespace"`
+	Object    blobstore.Object `json:"object"`
+}
+
+// RemoveDocumentsRequest is the input of the RemoveDocuments activity
+type RemoveDocumentsRequest struct {
+	Namespace   string   `json:"namespace"`
+	DocumentIDs []string `json:"document_ids"`
+}
+
+// ListBucketChanges lists the ingestion bucket and compares the objects' ETags with the
+// versions of the documents ingested from it, sorted by key
+func (a *Activities) ListBucketChanges(ctx context.Context, req BucketChangesRequest) (BucketChanges, error) {
+	if a.IngestSource == nil || a.Knowledge == nil {
+		return BucketChanges{}, temporal.NewNonRetryableApplicationError("bucket ingestion is not configured", "IngestionDisabled", nil)
+	}
+	objects, err := a.IngestSource.List(ctx)
+	if err != nil {
+		return BucketChanges{}, fmt.Errorf("listing bucket: %w", err)
+	}
+	versions, err := a.Knowledge.Versions(ctx, req.Namespace, a.IngestSource.URL(""))
+	if err != nil {
+		return BucketChanges{}, fmt.Errorf("reading ingested versions: %w", err)
+	}
+
+	changes := BucketChanges{Listed: len(objects)}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	for _, obj := range objects {
+		id := a.IngestSource.URL(obj.Key)
+		if version, ok := versions[id]; !ok || version != obj.ETag {
+			changes.Changed = append(changes.Changed, obj)
+		}
+		delete(versions, id)
+	}
+	for id := range versions {
+		changes.Removed = append(changes.Removed, id)
+	}
+	sort.Strings(changes.Removed)
+	return changes, nil
+}
+
+// IngestObject downloads an object of the ingestion bucket, extracts its text and stores
+// its passages under the object's ETag. Objects that cannot be read fail without retries;
+// their ETag is recorded all the same, so they are skipped until they change.
+func (a *Activities) IngestObject(ctx context.Context, req IngestObjectRequest) (int, error) {
+	if a.IngestSource == nil || a.Knowledge == nil {
+		return 0, temporal.NewNonRetryableApplicationError("bucket ingestion is not configured", "IngestionDisabled", nil)
+	}
+	if req.Object.Size > maxIngestBytes {
+		err := fmt.Errorf("%s is larger than %d bytes", req.Object.Key, maxIngestBytes)
+		return 0, temporal.NewNonRetryableApplicationError(err.Error(), "DocumentTooLarge", err)
+	}
+	data, err := a.IngestSource.Get(ctx, req.Object.Key)
+	if errors.Is(err, blobstore.ErrNotFound) {
+		// Deleted since the listing; the next run removes its document
+		return 0, temporal.NewNonRetryableApplicationError(err.Error(), "DocumentUnavailable", err)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	id := a.IngestSource.URL(req.Object.Key)
+	extractor := extract.Extractor{OCR: a.OCR}
+	res, err := extractor.Extract(ctx, req.Object.Key, "", data)
+	if errors.Is(err, extract.ErrUnsupported) || errors.Is(err, extract.ErrNoText) {
+		// Record the version without passages, so the object is not downloaded again
+		// until it changes
+		if err := a.Knowledge.Replace(ctx, req.Namespace, id, req.Object.ETag, nil); err != nil {
+			return 0, fmt.Errorf("storing passages: %w", err)
+		}
+		return 0, temporal.NewNonRetryableApplicationError(err.Error(), "UnreadableDocument", err)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	title := res.Title
+	if title == "" {
+		title = path.Base(req.Object.Key)
+	}
+	doc := knowledge.Document{ID: id, Title: title, Content: res.Text, Type: res.Type}
+	return a.ingest(ctx, req.Namespace, doc, req.Object.ETag, id)
+}
+
+// RemoveDocuments deletes ingested documents and their passages
+func (a *Activities) RemoveDocuments(ctx context.Context, req RemoveDocumentsRequest) error {
+	if a.Knowledge == nil {
+		return temporal.NewNonRetryableApplicationError("knowledge ingestion is not configured", "IngestionDisabled", nil)
+	}
+	for _, id := range req.DocumentIDs {
+		if err := a.Knowledge.Remove(ctx, req.Namespace, id); err != nil {
+			return fmt.Errorf("removing %s: %w", id, err)
+		}
+	}
+	return nil
+}
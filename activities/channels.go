@@ -0,0 +1,19 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"temporal-ai-agent/channels"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+// DeliverToChannel sends an agent message to a channel the conversation is continued on.
+// Retried deliveries keep their ID, which webhook receivers use to drop duplicates.
+func (a *Activities) DeliverToChannel(ctx context.Context, d channels.Delivery) error {
+	err := a.Channels.Deliver(ctx, d)
+	if errors.Is(err, channels.ErrUnknownChannel) {
+		return temporal.NewNonRetryableApplicationError(err.Error(), "UnknownChannel", err)
+	}
+	return err
+}
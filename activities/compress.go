@@ -0,0 +1,150 @@
+package activities
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"temporal-ai-agent/tokens"
+	"temporal-ai-agent/tools"
+	"unicode"
+)
+
+// maxExtractPassages bounds the passages an oversized tool result is split into for
+// extraction; results with more are split into fewer, longer passages
+const maxExtractPassages = 2000
+
+// extractStopWords are frequent words that say nothing about what is relevant
+var extractStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "are": true, "was": true, "with": true, "that": true,
+	"this": true, "what": true, "which": true, "who": true, "how": true, "can": true, "you": true,
+	"your": true, "from": true, "have": true, "has": true, "not": true, "but": true, "all": true,
+	"any": true, "about": true, "there": true, "their": true, "them": true, "they": true,
+	"will": true, "would": true, "could": true, "should": true, "does": true, "did": true,
+	"please": true, "tell": true, "show": true, "give": true, "into": true, "out": true,
+}
+
+// queryTerms returns the distinct words of the texts worth matching
+func queryTerms(texts ...string) []string {
+	var terms []string
+	for _, text := range texts {
+		for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			if len([]rune(word)) >= 3 && !extractStopWords[word] && !slices.Contains(terms, word) {
+				terms = append(terms, word)
+			}
+		}
+	}
+	return terms
+}
+
+// splitPassages splits text into paragraphs, or into lines when it has few paragraphs, and
+// merges neighbours when there are more than maxExtractPassages
+func splitPassages(text string) ([]string, string) {
+	sep := "\n\n"
+	passages := strings.Split(text, sep)
+	if len(passages) < 3 {
+		sep = "\n"
+		passages = strings.Split(text, sep)
+	}
+	if n := len(passages); n > maxExtractPassages {
+		group := (n + maxExtractPassages - 1) / maxExtractPassages
+		merged := make([]string, 0, maxExtractPassages)
+		for start := 0; start < n; start += group {
+			merged = append(merged, strings.Join(passages[start:min(start+group, n)], sep))
+		}
+		passages = merged
+	}
+	return passages, sep
+}
+
+// extractRelevant compresses a tool result by keeping the passages sharing the most words
+// with the query (the user's question and the call's arguments), in their original order,
+// within the limits. The first passage, often a title or table header, is kept when it
+// fits; dropped passages are marked with [...]. It reports false when the query matches
+// nothing, leaving the result to the next compression step.
+func extractRelevant(counter *tokens.Counter, result string, terms []string, limits tools.Limits) (string, bool) {
+	if len(terms) == 0 {
+		return "", false
+	}
+	passages, sep := splitPassages(result)
+	if len(passages) < 2 {
+		return "", false
+	}
+
+	type scored struct {
+		index int
+		score float64
+	}
+	ranked := make([]scored, 0, len(passages))
+	for i, p := range passages {
+		lower := strings.ToLower(p)
+		var score float64
+		for _, term := range terms {
+			if n := strings.Count(lower, term); n > 0 {
+				// Matching more of the terms beats repeating one
+				score += 1 + 0.1*float64(min(n, 10))
+			}
+		}
+		if score > 0 || i == 0 {
+			ranked = append(ranked, scored{index: i, score: score})
+		}
+	}
+	if len(ranked) == 0 || (len(ranked) == 1 && ranked[0].score == 0) {
+		return "", false
+	}
+	slices.SortStableFunc(ranked, func(a, b scored) int {
+		// The first passage goes first, then the best matches
+		if a.index == 0 || b.index == 0 {
+			return a.index - b.index
+		}
+		if a.score != b.score {
+			if a.score > b.score {
+				return -1
+			}
+			return 1
+		}
+		return a.index - b.index
+	})
+
+	// Leave room for the note and the markers of dropped passages
+	budget := tools.Limits{MaxBytes: limits.MaxBytes * 9 / 10, MaxTokens: limits.MaxTokens * 9 / 10}
+	var bytes, count int
+	var kept []int
+	for _, r := range ranked {
+		size := len(passages[r.index]) + len(sep) + len("[...]")
+		if budget.MaxBytes > 0 && bytes+size > budget.MaxBytes {
+			continue
+		}
+		var n int
+		if budget.MaxTokens > 0 {
+			n = counter.Count(passages[r.index]) + 2
+			if count+n > budget.MaxTokens {
+				continue
+			}
+		}
+		bytes += size
+		count += n
+		kept = append(kept, r.index)
+	}
+	if len(kept) == 0 || (len(kept) == 1 && kept[0] == 0 && ranked[0].score == 0) {
+		return "", false
+	}
+	slices.Sort(kept)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[extracted: %d of %d passages relevant to the request]", len(kept), len(passages))
+	previous := -1
+	for _, i := range kept {
+		b.WriteString(sep)
+		if i > previous+1 {
+			b.WriteString("[...]" + sep)
+		}
+		b.WriteString(passages[i])
+		previous = i
+	}
+	if previous < len(passages)-1 {
+		b.WriteString(sep + "[...]")
+	}
+	return b.String(), true
+}
@@ -0,0 +1,23 @@
+package activities
+
+import (
+	"context"
+	"temporal-ai-agent/deadletter"
+	"time"
+)
+
+// RecordDeadLetter records a failed turn in the dead-letter store
+func (a *Activities) RecordDeadLetter(ctx context.Context, entry deadletter.Entry) error {
+	if a.DeadLetters == nil {
+		return nil
+	}
+	return a.DeadLetters.Add(ctx, entry)
+}
+
+// ResolveDeadLetter marks a failed turn as successfully retried
+func (a *Activities) ResolveDeadLetter(ctx context.Context, id string) error {
+	if a.DeadLetters == nil {
+		return nil
+	}
+	return a.DeadLetters.Resolve(ctx, id, time.Now())
+}
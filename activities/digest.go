@@ -0,0 +1,46 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"temporal-ai-agent/digest"
+)
+
+// DigestSection is the result of one of the tools a digest covers
+type DigestSection struct {
+	Tool   string `json:"tool"`
+	Result string `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ComposeDigestRequest is the input of the ComposeDigest activity
+type ComposeDigestRequest struct {
+	Date     string          `json:"date"`
+	Sections []DigestSection `json:"sections"`
+}
+
+// ComposeDigest asks the LLM to turn the tool results into a short digest. Sources that
+// failed are mentioned so the reader knows the digest is incomplete.
+func (a *Activities) ComposeDigest(ctx context.Context, req ComposeDigestRequest) (string, error) {
+	defer keepHeartbeating(ctx)()
+	var b strings.Builder
+	fmt.Fprintf(&b, "Date: %s\n", req.Date)
+	for _, section := range req.Sections {
+		if section.Error != "" {
+			fmt.Fprintf(&b, "\n[%s] unavailable: %s\n", section.Tool, section.Error)
+			continue
+		}
+		fmt.Fprintf(&b, "\n[%s]\n%s\n", section.Tool, section.Result)
+	}
+	// Digests are scheduled by operators for no tenant, so they use the configured provider
+	return a.complete(ctx, "",
+		"You write a user's daily digest from the data of their tools. Start with what needs attention today, "+
+			"then summarize the rest in a few short bullet points. Mention sources that were unavailable in one line at the end.",
+		b.String())
+}
+
+// DeliverDigest sends a digest to the configured channel
+func (a *Activities) DeliverDigest(ctx context.Context, d digest.Digest) error {
+	return a.Digests.Deliver(ctx, d)
+}
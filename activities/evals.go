@@ -0,0 +1,53 @@
+package activities
+
+import (
+	"context"
+	"temporal-ai-agent/activities/llm"
+	"temporal-ai-agent/evals"
+	"time"
+)
+
+// EvaluateRequest is the input of the EvaluateConversation activity
+type EvaluateRequest struct {
+	WorkflowID    string        `json:"workflow_id"`
+	Goal          string        `json:"goal"`
+	PromptVersion string        `json:"prompt_version,omitempty"`
+	Messages      []llm.Message `json:"messages"`
+	// Tenant is the quota account of the conversation, whose own LLM key is used if it has one
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// EvaluateConversation asks the judge model to score a finished conversation and stores the
+// scores. It returns nil when evaluation is disabled.
+func (a *Activities) EvaluateConversation(ctx context.Context, req EvaluateRequest) (*evals.Scores, error) {
+	defer keepHeartbeating(ctx)()
+	if a.Evaluations == nil {
+		return nil, nil
+	}
+
+	provider, err := a.provider(ctx, req.Tenant)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := provider.Complete(ctx, evals.JudgeRequest(a.EvalModel, req.Messages))
+	if err != nil {
+		return nil, err
+	}
+	// An unreadable judgement is retried like a failed call; judges usually comply the next time
+	scores, err := evals.ParseScores(resp.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.Evaluations.Save(ctx, evals.Evaluation{
+		WorkflowID:    req.WorkflowID,
+		Goal:          req.Goal,
+		PromptVersion: req.PromptVersion,
+		Scores:        scores,
+		EvaluatedAt:   time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &scores, nil
+}
@@ -0,0 +1,27 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"temporal-ai-agent/extract"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+// ExtractTextRequest is the input of the ExtractText activity
+type ExtractTextRequest struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type,omitempty"`
+	Data        []byte `json:"data"`
+}
+
+// ExtractText returns the text of a document. Unsupported documents, and documents without
+// a text layer when no OCR backend is configured, fail without retries.
+func (a *Activities) ExtractText(ctx context.Context, req ExtractTextRequest) (extract.Result, error) {
+	extractor := extract.Extractor{OCR: a.OCR}
+	res, err := extractor.Extract(ctx, req.Name, req.ContentType, req.Data)
+	if errors.Is(err, extract.ErrUnsupported) || errors.Is(err, extract.ErrNoText) {
+		return extract.Result{}, temporal.NewNonRetryableApplicationError(err.Error(), "UnreadableDocument", err)
+	}
+	return res, err
+}
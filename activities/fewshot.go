@@ -0,0 +1,31 @@
+package activities
+
+import (
+	"context"
+	"temporal-ai-agent/fewshot"
+)
+
+// SelectExamplesRequest is the input of the SelectExamples activity
+type SelectExamplesRequest struct {
+	Goal  string `json:"goal"`
+	Query string `json:"query"`
+}
+
+// SelectExamples picks the curated few-shot examples of a goal that best match the user's query
+func (a *Activities) SelectExamples(ctx context.Context, req SelectExamplesRequest) ([]fewshot.Example, error) {
+	defer keepHeartbeating(ctx)()
+	if a.Examples == nil || a.ExamplesLimit <= 0 {
+		return nil, nil
+	}
+
+	examples, err := a.Examples.List(req.Goal)
+	if err != nil {
+		return nil, err
+	}
+
+	var embedder fewshot.Embedder
+	if e, ok := a.embedder(); ok && a.ExamplesUseEmbeddings {
+		embedder = e
+	}
+	return fewshot.Select(ctx, examples, req.Query, a.ExamplesLimit, embedder)
+}
@@ -0,0 +1,21 @@
+package activities
+
+import (
+	"context"
+	"temporal-ai-agent/goals"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+// ResolveGoal looks up the goal a conversation is started for. Unknown goals are
+// configuration errors, so they are not retried.
+func (a *Activities) ResolveGoal(ctx context.Context, name string) (goals.Goal, error) {
+	if a.Goals == nil {
+		return goals.Goal{Name: name}, nil
+	}
+	g, err := a.Goals.Find(name)
+	if err != nil {
+		return goals.Goal{}, temporal.NewNonRetryableApplicationError(err.Error(), "UnknownGoal", err)
+	}
+	return g, nil
+}
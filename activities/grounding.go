@@ -0,0 +1,46 @@
+package activities
+
+import (
+	"context"
+	"temporal-ai-agent/grounding"
+)
+
+// VerifyGroundingRequest is the input of the VerifyGrounding activity
+type VerifyGroundingRequest struct {
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+	// Sources are the retrieval tool's results the answer was written from
+	Sources []string `json:"sources"`
+	// Tenant is the quota account of the conversation, whose own LLM key is used if it has one
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// GroundingResult is the outcome of the VerifyGrounding activity
+type GroundingResult struct {
+	// Mode is the configured grounding mode; empty when verification is disabled
+	Mode    string            `json:"mode,omitempty"`
+	Verdict grounding.Verdict `json:"verdict"`
+}
+
+// VerifyGrounding asks the verifier model which claims of an answer the retrieved passages
+// support. It returns an empty mode when verification is disabled.
+func (a *Activities) VerifyGrounding(ctx context.Context, req VerifyGroundingRequest) (GroundingResult, error) {
+	defer keepHeartbeating(ctx)()
+	if a.GroundingMode == grounding.ModeOff {
+		return GroundingResult{}, nil
+	}
+	provider, err := a.provider(ctx, req.Tenant)
+	if err != nil {
+		return GroundingResult{}, err
+	}
+	resp, err := provider.Complete(ctx, grounding.Request(a.GroundingModel, req.Question, req.Answer, req.Sources))
+	if err != nil {
+		return GroundingResult{}, err
+	}
+	// An unreadable verdict is retried like a failed call
+	verdict, err := grounding.Parse(resp.Content)
+	if err != nil {
+		return GroundingResult{}, err
+	}
+	return GroundingResult{Mode: a.GroundingMode, Verdict: verdict}, nil
+}
@@ -0,0 +1,42 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"log"
+	"temporal-ai-agent/outbox"
+	"time"
+)
+
+// EnqueueNotification records a notification in the outbox. Recording a dedup key again,
+// as a retried activity does, is a no-op.
+func (a *Activities) EnqueueNotification(ctx context.Context, entry outbox.Entry) error {
+	_, err := a.Outbox.Add(ctx, entry)
+	return err
+}
+
+// DeliverNotification sends an outbox entry to the operator channel and records the attempt.
+// Entries already delivered are skipped; an entry whose attempt could not be recorded may be
+// sent again, which receivers detect through the notification ID.
+func (a *Activities) DeliverNotification(ctx context.Context, entry outbox.Entry) error {
+	stored, err := a.Outbox.Get(ctx, entry.DedupKey)
+	if err == nil && stored.DeliveredAt != nil {
+		return nil
+	}
+	if err != nil && !errors.Is(err, outbox.ErrNotFound) {
+		log.Printf("Warning: unable to read outbox entry %s: %v", entry.DedupKey, err)
+	}
+
+	n := entry.Notification
+	n.ID = entry.DedupKey
+	notifyErr := a.Operators.Notify(ctx, n)
+
+	errMsg := ""
+	if notifyErr != nil {
+		errMsg = notifyErr.Error()
+	}
+	if err := a.Outbox.RecordAttempt(ctx, entry.DedupKey, errMsg, time.Now().UTC()); err != nil {
+		log.Printf("Warning: unable to record delivery of %s: %v", entry.DedupKey, err)
+	}
+	return notifyErr
+}
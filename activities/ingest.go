@@ -0,0 +1,119 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"temporal-ai-agent/extract"
+	"temporal-ai-agent/httpclient"
+	"temporal-ai-agent/knowledge"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+// maxIngestBytes bounds the size of the documents downloaded for ingestion
+const maxIngestBytes = 20 << 20
+
+// ingestClient downloads documents for ingestion
+var ingestClient = httpclient.New(time.Minute, httpclient.Options{})
+
+// IngestURLRequest is the input of the IngestURL activity
+type IngestURLRequest struct {
+	Namespace string `json:"namespace"`
+	URL       string `json:"url"`
+}
+
+// IngestURLResult describes an ingested page
+type IngestURLResult struct {
+	// URL is the address of the page after redirects, which identifies its document
+	URL    string `json:"url"`
+	Title  string `json:"title,omitempty"`
+	Chunks int    `json:"chunks"`
+	// Links are the pages an HTML page links to
+	Links []string `json:"links,omitempty"`
+}
+
+// IngestURL downloads a page, extracts its text and stores its passages in a knowledge
+// namespace, replacing those of an earlier ingestion. Pages that cannot be read fail
+// without retries.
+func (a *Activities) IngestURL(ctx context.Context, req IngestURLRequest) (IngestURLResult, error) {
+	if a.Knowledge == nil {
+		return IngestURLResult{}, temporal.NewNonRetryableApplicationError("knowledge ingestion is not configured", "IngestionDisabled", nil)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, req.URL, nil)
+	if err != nil {
+		return IngestURLResult{}, temporal.NewNonRetryableApplicationError(err.Error(), "InvalidURL", err)
+	}
+	httpReq.Header.Set("User-Agent", "temporal-ai-agent/1.0")
+
+	resp, err := ingestClient.Do(httpReq)
+	if err != nil {
+		return IngestURLResult{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("fetching %s: %s", req.URL, resp.Status)
+		// Missing and forbidden pages stay so; throttling and server errors may not
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			return IngestURLResult{}, temporal.NewNonRetryableApplicationError(err.Error(), "PageUnavailable", err)
+		}
+		return IngestURLResult{}, err
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxIngestBytes))
+	if err != nil {
+		return IngestURLResult{}, err
+	}
+
+	pageURL := resp.Request.URL
+	extractor := extract.Extractor{OCR: a.OCR}
+	res, err := extractor.Extract(ctx, pageURL.Path, resp.Header.Get("Content-Type"), data)
+	if errors.Is(err, extract.ErrUnsupported) || errors.Is(err, extract.ErrNoText) {
+		return IngestURLResult{}, temporal.NewNonRetryableApplicationError(err.Error(), "UnreadableDocument", err)
+	}
+	if err != nil {
+		return IngestURLResult{}, err
+	}
+
+	result := IngestURLResult{URL: pageURL.String(), Title: res.Title}
+	if result.Title == "" {
+		result.Title = pageURL.String()
+	}
+	doc := knowledge.Document{ID: result.URL, Title: result.Title, Content: res.Text, Type: res.Type}
+	if result.Chunks, err = a.ingest(ctx, req.Namespace, doc, "", result.URL); err != nil {
+		return IngestURLResult{}, err
+	}
+	if res.Format == extract.FormatHTML {
+		result.Links = extract.Links(data, pageURL)
+	}
+	return result, nil
+}
+
+// ingest chunks a document, embeds its passages when the provider supports embeddings and
+// stores them in the namespace under the source's version. It returns the number of
+// passages stored.
+func (a *Activities) ingest(ctx context.Context, namespace string, doc knowledge.Document, version, source string) (int, error) {
+	chunks := knowledge.Split(doc, a.Chunkers)
+	for i := range chunks {
+		chunks[i].Source = source
+	}
+	if embedder, ok := a.embedder(); ok && len(chunks) > 0 {
+		texts := make([]string, len(chunks))
+		for i, c := range chunks {
+			texts[i] = c.Title + "\n" + c.Content
+		}
+		vectors, err := embedder.Embed(ctx, texts)
+		if err != nil {
+			return 0, fmt.Errorf("embedding passages: %w", err)
+		}
+		for i := range chunks {
+			chunks[i].Embedding = vectors[i]
+		}
+	}
+	if err := a.Knowledge.Replace(ctx, namespace, doc.ID, version, chunks); err != nil {
+		return 0, fmt.Errorf("storing passages: %w", err)
+	}
+	return len(chunks), nil
+}
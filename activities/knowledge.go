@@ -0,0 +1,41 @@
+package activities
+
+import (
+	"context"
+	"sort"
+	"temporal-ai-agent/knowledge"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+// DebugRetrievalRequest is the input of the DebugRetrieval activity
+type DebugRetrievalRequest struct {
+	Query string `json:"query"`
+	// Goal applies the goal's namespace bindings, as in its conversations; without a goal,
+	// Namespace (or every namespace when empty) is searched
+	Goal      string `json:"goal,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// DebugRetrieval runs a query through the retrieval tool's pipeline and returns its trace
+func (a *Activities) DebugRetrieval(ctx context.Context, req DebugRetrievalRequest) (knowledge.Retrieval, error) {
+	if a.KnowledgeBase == nil {
+		return knowledge.Retrieval{}, temporal.NewNonRetryableApplicationError("the knowledge base is not configured", "KnowledgeDisabled", nil)
+	}
+	var namespaces []string
+	switch {
+	case req.Goal != "":
+		var err error
+		if namespaces, err = a.KnowledgeBase.Resolve(req.Goal, req.Namespace); err != nil {
+			return knowledge.Retrieval{}, temporal.NewNonRetryableApplicationError(err.Error(), "NamespaceUnavailable", err)
+		}
+	case req.Namespace != "":
+		namespaces = []string{req.Namespace}
+	default:
+		for ns := range a.KnowledgeBase.Namespaces {
+			namespaces = append(namespaces, ns)
+		}
+		sort.Strings(namespaces)
+	}
+	return a.KnowledgeBase.Retrieve(ctx, namespaces, req.Query)
+}
@@ -0,0 +1,69 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// BatchedEmbedder splits large embedding requests into batches of at most BatchSize texts,
+// the most provider batch APIs accept in one request, and sends up to Concurrency batches
+// at a time. Vectors are returned in the order of the texts.
+type BatchedEmbedder struct {
+	Embedder    Embedder
+	BatchSize   int
+	Concurrency int
+}
+
+// Embed embeds the texts batch by batch, failing if any batch fails
+func (b BatchedEmbedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	if b.BatchSize <= 0 || len(texts) <= b.BatchSize {
+		return b.Embedder.Embed(ctx, texts)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	vectors := make([][]float64, len(texts))
+	slots := make(chan struct{}, max(b.Concurrency, 1))
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for start := 0; start < len(texts); start += b.BatchSize {
+		end := min(start+b.BatchSize, len(texts))
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			batch, err := b.Embedder.Embed(ctx, texts[start:end])
+			if err == nil && len(batch) != end-start {
+				err = fmt.Errorf("embedding batch returned %d vectors for %d texts", len(batch), end-start)
+			}
+			if err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			copy(vectors[start:end], batch)
+		}(start, end)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return vectors, nil
+}
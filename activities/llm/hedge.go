@@ -0,0 +1,84 @@
+package llm
+
+import (
+	"context"
+	"time"
+)
+
+// Hedged sends a request to a backup provider when the primary has not answered within
+// Delay and returns whichever answer comes first, cancelling the other request
+type Hedged struct {
+	Primary Provider
+	Backup  Provider
+	// BackupModel overrides the model of hedged requests; empty keeps the request's model
+	BackupModel string
+	// Delay is how long the primary has before the backup is sent; zero disables hedging,
+	// which would otherwise send every request twice
+	Delay time.Duration
+}
+
+// hedgeResult is the outcome of one of the hedged requests
+type hedgeResult struct {
+	resp Response
+	err  error
+}
+
+// Complete runs the hedged request. If the primary fails before the delay, the backup is
+// started immediately; an error is only returned when both requests fail, and it is the
+// first of the two.
+func (h *Hedged) Complete(ctx context.Context, req Request) (Response, error) {
+	if h.Delay <= 0 {
+		return h.Primary.Complete(ctx, req)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan hedgeResult, 2)
+	call := func(p Provider, req Request) {
+		resp, err := p.Complete(ctx, req)
+		results <- hedgeResult{resp, err}
+	}
+	go call(h.Primary, req)
+	pending := 1
+
+	backupStarted := false
+	startBackup := func() {
+		backupStarted = true
+		pending++
+		backupReq := req
+		if h.BackupModel != "" {
+			backupReq.Model = h.BackupModel
+		}
+		go call(h.Backup, backupReq)
+	}
+
+	timer := time.NewTimer(h.Delay)
+	defer timer.Stop()
+
+	var firstErr error
+	for {
+		select {
+		case <-timer.C:
+			if !backupStarted {
+				startBackup()
+			}
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				return r.resp, nil
+			}
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			if !backupStarted {
+				startBackup()
+				continue
+			}
+			if pending == 0 {
+				return Response{}, firstErr
+			}
+		case <-ctx.Done():
+			return Response{}, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProvider answers after a delay, or with an error, and counts its calls
+type fakeProvider struct {
+	after time.Duration
+	resp  Response
+	err   error
+	calls atomic.Int32
+	model atomic.Value
+}
+
+func (f *fakeProvider) Complete(ctx context.Context, req Request) (Response, error) {
+	f.calls.Add(1)
+	f.model.Store(req.Model)
+	select {
+	case <-time.After(f.after):
+	case <-ctx.Done():
+		return Response{}, ctx.Err()
+	}
+	if f.err != nil {
+		return Response{}, f.err
+	}
+	return f.resp, nil
+}
+
+func (f *fakeProvider) Stream(ctx context.Context, req Request, onChunk func(string)) (Response, error) {
+	return f.Complete(ctx, req)
+}
+
+func TestHedgedPrimaryWins(t *testing.T) {
+	primary := &fakeProvider{resp: Response{Content: "primary"}}
+	backup := &fakeProvider{resp: Response{Content: "backup"}}
+	h := &Hedged{Primary: primary, Backup: backup, Delay: time.Second}
+
+	resp, err := h.Complete(context.Background(), Request{})
+	require.NoError(t, err)
+	require.Equal(t, "primary", resp.Content)
+	require.EqualValues(t, 0, backup.calls.Load(), "no backup before the delay")
+}
+
+func TestHedgedBackupAfterDelay(t *testing.T) {
+	primary := &fakeProvider{after: time.Minute, resp: Response{Content: "primary"}}
+	backup := &fakeProvider{resp: Response{Content: "backup"}}
+	h := &Hedged{Primary: primary, Backup: backup, BackupModel: "small", Delay: 10 * time.Millisecond}
+
+	start := time.Now()
+	resp, err := h.Complete(context.Background(), Request{Model: "large"})
+	require.NoError(t, err)
+	require.Equal(t, "backup", resp.Content)
+	require.GreaterOrEqual(t, time.Since(start), 10*time.Millisecond)
+	require.Equal(t, "small", backup.model.Load())
+}
+
+func TestHedgedPrimaryFailsFast(t *testing.T) {
+	primary := &fakeProvider{err: errors.New("rate limited")}
+	backup := &fakeProvider{resp: Response{Content: "backup"}}
+	h := &Hedged{Primary: primary, Backup: backup, Delay: time.Minute}
+
+	start := time.Now()
+	resp, err := h.Complete(context.Background(), Request{})
+	require.NoError(t, err)
+	require.Equal(t, "backup", resp.Content)
+	require.Less(t, time.Since(start), time.Second, "the backup does not wait for the delay")
+}
+
+func TestHedgedBothFail(t *testing.T) {
+	first := errors.New("primary failed")
+	primary := &fakeProvider{err: first}
+	backup := &fakeProvider{after: 10 * time.Millisecond, err: errors.New("backup failed")}
+	h := &Hedged{Primary: primary, Backup: backup, Delay: time.Minute}
+
+	_, err := h.Complete(context.Background(), Request{})
+	require.ErrorIs(t, err, first)
+	require.EqualValues(t, 1, backup.calls.Load())
+}
+
+func TestHedgedWithoutDelayIsDisabled(t *testing.T) {
+	primary := &fakeProvider{err: errors.New("primary failed")}
+	backup := &fakeProvider{resp: Response{Content: "backup"}}
+	h := &Hedged{Primary: primary, Backup: backup}
+
+	_, err := h.Complete(context.Background(), Request{})
+	require.Error(t, err)
+	require.EqualValues(t, 0, backup.calls.Load())
+}
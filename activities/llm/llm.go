@@ -0,0 +1,131 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"temporal-ai-agent/httpclient"
+	"temporal-ai-agent/tools"
+	"time"
+)
+
+// Roles used in chat messages
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool"
+)
+
+// Message is a single chat message sent to or received from a model
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+	// ToolCall is set on assistant messages that request a tool call
+	ToolCall *tools.Call `json:"tool_call,omitempty"`
+	// ToolCallID links a tool result message to the call it answers
+	ToolCallID string `json:"tool_call_id,omitempty"`
+	// Section is the part of the prompt a request message belongs to when the context
+	// budget is allocated (see tokens.Policy); empty uses the message's role
+	Section string `json:"section,omitempty"`
+}
+
+// Request is a chat completion request
+type Request struct {
+	Model    string    `json:"model,omitempty"`
+	Messages []Message `json:"messages"`
+	// Temperature and MaxTokens override the provider's sampling temperature and reply
+	// length when set
+	Temperature *float64 `json:"temperature,omitempty"`
+	MaxTokens   int      `json:"max_tokens,omitempty"`
+	// Tools are the tools the model may call; none disables tool calling
+	Tools []tools.Definition `json:"tools,omitempty"`
+	// Goal is the goal of the conversation, used to route premium goals
+	Goal string `json:"goal,omitempty"`
+	// Tenant is the quota account the completion is made for; tenants who brought their own
+	// API key are answered with it (see Tenants)
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// Response is a chat completion response
+type Response struct {
+	Content string `json:"content"`
+	Model   string `json:"model,omitempty"`
+	// ToolCall is set when the model asks to call a tool instead of replying
+	ToolCall *tools.Call `json:"tool_call,omitempty"`
+	Usage    Usage       `json:"usage"`
+}
+
+// Usage is the number of tokens a completion consumed
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// Total returns the number of prompt and completion tokens
+func (u Usage) Total() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// Provider is implemented by every LLM backend. A completion offering the model tools is a
+// Complete call whose request lists them in Tools; there is no separate CompleteWithTools,
+// so the wrappers of providers, such as Hedged and Tenants, route both alike.
+type Provider interface {
+	Complete(ctx context.Context, req Request) (Response, error)
+}
+
+// Streamer is implemented by providers that can deliver a reply while it is generated.
+// onChunk receives consecutive pieces of the reply's content; the full response is
+// returned as with Complete.
+type Streamer interface {
+	Stream(ctx context.Context, req Request, onChunk func(chunk string)) (Response, error)
+}
+
+// Embedder is implemented by providers that can turn texts into embedding vectors
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+// Options configure the providers that call remote APIs
+type Options struct {
+	OpenAIAPIKey         string
+	OpenAIBaseURL        string
+	OpenAIModel          string
+	OpenAIEmbeddingModel string
+	OllamaBaseURL        string
+	OllamaModel          string
+	OllamaEmbeddingModel string
+	// HTTP tunes the connection pool of the provider's client
+	HTTP httpclient.Options
+}
+
+// New returns the provider registered under the given name: mock, which echoes messages
+// and needs no API key, openai, or ollama, which runs models locally
+func New(name string, opts Options) (Provider, error) {
+	switch name {
+	case "":
+		return nil, fmt.Errorf("LLM_PROVIDER is required: mock, openai or ollama")
+	case "mock":
+		return &Mock{}, nil
+	case "openai":
+		if opts.OpenAIAPIKey == "" {
+			return nil, fmt.Errorf("OPENAI_API_KEY is required for the openai provider")
+		}
+		return &OpenAI{
+			APIKey:         opts.OpenAIAPIKey,
+			BaseURL:        opts.OpenAIBaseURL,
+			Model:          opts.OpenAIModel,
+			EmbeddingModel: opts.OpenAIEmbeddingModel,
+			Client:         httpclient.New(2*time.Minute, opts.HTTP),
+		}, nil
+	case "ollama":
+		// Local models can take a while to load and generate
+		return &Ollama{
+			BaseURL:        opts.OllamaBaseURL,
+			Model:          opts.OllamaModel,
+			EmbeddingModel: opts.OllamaEmbeddingModel,
+			Client:         httpclient.New(5*time.Minute, opts.HTTP),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q", name)
+	}
+}
@@ -0,0 +1,91 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"temporal-ai-agent/tools"
+)
+
+// Mock is a deterministic provider for local development that needs no API keys.
+// A user message of the form `/tool_name {"arg": "value"}` makes it call that tool.
+type Mock struct{}
+
+// Complete echoes the last user message back, reports the last tool result, or calls a tool
+func (m *Mock) Complete(ctx context.Context, req Request) (Response, error) {
+	if n := len(req.Messages); n > 0 && req.Messages[n-1].Role == RoleTool {
+		return Response{
+			Content: fmt.Sprintf("(mock) Tool result: %s", req.Messages[n-1].Content),
+			Model:   "mock",
+		}, nil
+	}
+
+	var last string
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		if req.Messages[i].Role == RoleUser {
+			last = req.Messages[i].Content
+			break
+		}
+	}
+	if call, ok := mockToolCall(last, req.Tools); ok {
+		return Response{Model: "mock", ToolCall: &call}, nil
+	}
+	return Response{
+		Content: fmt.Sprintf("(mock) You said: %s", last),
+		Model:   "mock",
+	}, nil
+}
+
+// Stream delivers the reply of Complete word by word
+func (m *Mock) Stream(ctx context.Context, req Request, onChunk func(chunk string)) (Response, error) {
+	resp, err := m.Complete(ctx, req)
+	if err != nil {
+		return Response{}, err
+	}
+	words := strings.SplitAfter(resp.Content, " ")
+	for _, word := range words {
+		if word != "" {
+			onChunk(word)
+		}
+	}
+	return resp, nil
+}
+
+// mockToolCall parses a `/tool_name {json args}` message into a call of an available tool
+func mockToolCall(message string, defs []tools.Definition) (tools.Call, bool) {
+	if !strings.HasPrefix(message, "/") {
+		return tools.Call{}, false
+	}
+	name, rawArgs, _ := strings.Cut(strings.TrimPrefix(message, "/"), " ")
+	if _, ok := tools.Find(defs, name); !ok {
+		return tools.Call{}, false
+	}
+
+	call := tools.Call{Name: name, Args: map[string]interface{}{}}
+	if rawArgs = strings.TrimSpace(rawArgs); rawArgs != "" {
+		if err := json.Unmarshal([]byte(rawArgs), &call.Args); err != nil {
+			return tools.Call{}, false
+		}
+	}
+	return call, true
+}
+
+// mockEmbeddingSize is the dimension of the vectors returned by Mock.Embed
+const mockEmbeddingSize = 64
+
+// Embed returns bag-of-words vectors built by hashing each word into a fixed number of buckets
+func (m *Mock) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vector := make([]float64, mockEmbeddingSize)
+		for _, word := range strings.Fields(strings.ToLower(text)) {
+			h := fnv.New32a()
+			h.Write([]byte(word))
+			vector[h.Sum32()%mockEmbeddingSize]++
+		}
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
@@ -0,0 +1,247 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"temporal-ai-agent/tools"
+)
+
+// Ollama calls the chat and embedding APIs of an Ollama server, to run the agent on local
+// models without an API key
+type Ollama struct {
+	// BaseURL is the server root, e.g. http://localhost:11434
+	BaseURL string
+	// Model is used by requests that do not name one; EmbeddingModel embeds texts
+	Model          string
+	EmbeddingModel string
+	Client         *http.Client
+}
+
+// ollamaMessage is a chat message in the Ollama format
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+}
+
+// ollamaToolCall is a function call requested by the model. Unlike OpenAI, Ollama sends
+// the arguments as an object and each call whole, even when streaming.
+type ollamaToolCall struct {
+	Function struct {
+		Name      string                 `json:"name"`
+		Arguments map[string]interface{} `json:"arguments"`
+	} `json:"function"`
+}
+
+// ollamaChatResponse is the answer of a chat request, or one line of a streamed answer
+type ollamaChatResponse struct {
+	Model   string        `json:"model"`
+	Message ollamaMessage `json:"message"`
+	Done    bool          `json:"done"`
+	// PromptEvalCount and EvalCount are the prompt and completion tokens, set when done
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
+	Error           string `json:"error"`
+}
+
+// chatRequest builds the body of a chat request
+func (o *Ollama) chatRequest(req Request, stream bool) map[string]interface{} {
+	messages := make([]ollamaMessage, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		msg := ollamaMessage{Role: m.Role, Content: m.Content}
+		if m.ToolCall != nil {
+			var call ollamaToolCall
+			call.Function.Name = m.ToolCall.Name
+			call.Function.Arguments = m.ToolCall.Args
+			msg.ToolCalls = []ollamaToolCall{call}
+		}
+		messages = append(messages, msg)
+	}
+
+	body := map[string]interface{}{
+		"model":    o.model(req.Model),
+		"messages": messages,
+		"stream":   stream,
+	}
+	options := map[string]interface{}{}
+	if req.Temperature != nil {
+		options["temperature"] = *req.Temperature
+	}
+	if req.MaxTokens > 0 {
+		options["num_predict"] = req.MaxTokens
+	}
+	if len(options) > 0 {
+		body["options"] = options
+	}
+	if len(req.Tools) > 0 {
+		functions := make([]map[string]interface{}, len(req.Tools))
+		for i, def := range req.Tools {
+			parameters := def.Parameters
+			if parameters == nil {
+				parameters = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+			}
+			functions[i] = map[string]interface{}{
+				"type": "function",
+				"function": map[string]interface{}{
+					"name":        def.Name,
+					"description": def.Description,
+					"parameters":  parameters,
+				},
+			}
+		}
+		body["tools"] = functions
+	}
+	return body
+}
+
+// model returns the model of a request, or the default one
+func (o *Ollama) model(model string) string {
+	if model != "" {
+		return model
+	}
+	return o.Model
+}
+
+// response converts a final chat answer into a response with the given content
+func (r ollamaChatResponse) response(content string) Response {
+	return Response{
+		Content: content,
+		Model:   r.Model,
+		Usage:   Usage{PromptTokens: r.PromptEvalCount, CompletionTokens: r.EvalCount},
+	}
+}
+
+// ollamaCall converts the first function call of the model into a tool call
+func ollamaCall(calls []ollamaToolCall) *tools.Call {
+	if len(calls) == 0 {
+		return nil
+	}
+	call := tools.Call{Name: calls[0].Function.Name, Args: calls[0].Function.Arguments}
+	if call.Args == nil {
+		call.Args = map[string]interface{}{}
+	}
+	return &call
+}
+
+// Complete sends a chat request. The model either replies or calls one of the request's
+// tools.
+func (o *Ollama) Complete(ctx context.Context, req Request) (Response, error) {
+	resp, err := o.post(ctx, "/api/chat", o.chatRequest(req, false))
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+
+	var result ollamaChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return Response{}, fmt.Errorf("decoding chat response: %w", err)
+	}
+	out := result.response(result.Message.Content)
+	out.ToolCall = ollamaCall(result.Message.ToolCalls)
+	return out, nil
+}
+
+// Stream sends a streamed chat request, passing the content to onChunk as it arrives. The
+// answer is a JSON object per line; the last one carries the token counts.
+func (o *Ollama) Stream(ctx context.Context, req Request, onChunk func(chunk string)) (Response, error) {
+	resp, err := o.post(ctx, "/api/chat", o.chatRequest(req, true))
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+
+	var content strings.Builder
+	var calls []ollamaToolCall
+	var last ollamaChatResponse
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var chunk ollamaChatResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return Response{}, fmt.Errorf("decoding chat response chunk: %w", err)
+		}
+		// Errors after the answer started are reported in the stream
+		if chunk.Error != "" {
+			return Response{}, fmt.Errorf("chat stream failed: %s", chunk.Error)
+		}
+		if chunk.Message.Content != "" {
+			content.WriteString(chunk.Message.Content)
+			onChunk(chunk.Message.Content)
+		}
+		calls = append(calls, chunk.Message.ToolCalls...)
+		last = chunk
+		if chunk.Done {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Response{}, fmt.Errorf("reading chat stream: %w", err)
+	}
+	if !last.Done {
+		return Response{}, fmt.Errorf("chat stream ended before the answer was done")
+	}
+
+	out := last.response(content.String())
+	out.ToolCall = ollamaCall(calls)
+	return out, nil
+}
+
+// Embed returns the embedding vectors of the texts, in order
+func (o *Ollama) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	resp, err := o.post(ctx, "/api/embed", map[string]interface{}{"model": o.EmbeddingModel, "input": texts})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Embeddings [][]float64 `json:"embeddings"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decoding embeddings: %w", err)
+	}
+	if len(result.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("got %d embeddings for %d texts", len(result.Embeddings), len(texts))
+	}
+	return result.Embeddings, nil
+}
+
+// post sends a JSON request to the server and returns the response of a successful call
+func (o *Ollama) post(ctx context.Context, path string, body interface{}) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(o.BaseURL, "/")+path, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := o.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var failure struct {
+			Error string `json:"error"`
+		}
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		message := string(bytes.TrimSpace(raw))
+		if json.Unmarshal(raw, &failure) == nil && failure.Error != "" {
+			message = failure.Error
+		}
+		return nil, &StatusError{StatusCode: resp.StatusCode, Message: message}
+	}
+	return resp, nil
+}
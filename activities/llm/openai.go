@@ -0,0 +1,324 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"temporal-ai-agent/tools"
+)
+
+// OpenAI calls the OpenAI chat completions and embeddings APIs, or any service compatible
+// with them (Azure OpenAI, vLLM, LiteLLM, ...) through BaseURL
+type OpenAI struct {
+	APIKey string
+	// BaseURL is the API root, e.g. https://api.openai.com/v1
+	BaseURL string
+	// Model is used by requests that do not name one; EmbeddingModel embeds texts
+	Model          string
+	EmbeddingModel string
+	Client         *http.Client
+}
+
+// StatusError is an error answer of a provider's API. Requests it rejected as invalid or
+// unauthorized fail the same way when retried.
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("LLM API returned %d: %s", e.StatusCode, e.Message)
+}
+
+// Retryable reports whether the request may succeed later: rate limits, timeouts and
+// server errors
+func (e *StatusError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode == http.StatusRequestTimeout || e.StatusCode >= 500
+}
+
+// openAIMessage is a chat message in the OpenAI format
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    *string          `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+// openAIToolCall is a function call requested by the model. In streamed replies, Index
+// identifies the call the fragments belong to.
+type openAIToolCall struct {
+	Index    int    `json:"index,omitempty"`
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Function struct {
+		Name      string `json:"name,omitempty"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// openAIUsage is the token usage reported by the API
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// chatRequest builds the body of a chat completion request
+func (o *OpenAI) chatRequest(req Request, stream bool) map[string]interface{} {
+	messages := make([]openAIMessage, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		content := m.Content
+		msg := openAIMessage{Role: m.Role, Content: &content, ToolCallID: m.ToolCallID}
+		if m.ToolCall != nil {
+			args, _ := json.Marshal(m.ToolCall.Args)
+			call := openAIToolCall{ID: m.ToolCall.ID, Type: "function"}
+			call.Function.Name = m.ToolCall.Name
+			call.Function.Arguments = string(args)
+			msg.ToolCalls = []openAIToolCall{call}
+			if content == "" {
+				msg.Content = nil
+			}
+		}
+		messages = append(messages, msg)
+	}
+
+	body := map[string]interface{}{
+		"model":    o.model(req.Model),
+		"messages": messages,
+	}
+	if req.Temperature != nil {
+		body["temperature"] = *req.Temperature
+	}
+	if req.MaxTokens > 0 {
+		body["max_tokens"] = req.MaxTokens
+	}
+	if len(req.Tools) > 0 {
+		functions := make([]map[string]interface{}, len(req.Tools))
+		for i, def := range req.Tools {
+			parameters := def.Parameters
+			if parameters == nil {
+				parameters = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+			}
+			functions[i] = map[string]interface{}{
+				"type": "function",
+				"function": map[string]interface{}{
+					"name":        def.Name,
+					"description": def.Description,
+					"parameters":  parameters,
+				},
+			}
+		}
+		body["tools"] = functions
+		// The agent runs one tool per step
+		body["parallel_tool_calls"] = false
+	}
+	if stream {
+		body["stream"] = true
+		body["stream_options"] = map[string]interface{}{"include_usage": true}
+	}
+	return body
+}
+
+// model returns the model of a request, or the default one
+func (o *OpenAI) model(model string) string {
+	if model != "" {
+		return model
+	}
+	return o.Model
+}
+
+// Complete sends a chat completion request. The model either replies or calls one of the
+// request's tools.
+func (o *OpenAI) Complete(ctx context.Context, req Request) (Response, error) {
+	resp, err := o.post(ctx, "/chat/completions", o.chatRequest(req, false))
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Model   string `json:"model"`
+		Choices []struct {
+			Message openAIMessage `json:"message"`
+		} `json:"choices"`
+		Usage openAIUsage `json:"usage"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return Response{}, fmt.Errorf("decoding chat completion: %w", err)
+	}
+	if len(result.Choices) == 0 {
+		return Response{}, fmt.Errorf("chat completion has no choices")
+	}
+
+	message := result.Choices[0].Message
+	out := Response{
+		Model: result.Model,
+		Usage: Usage{PromptTokens: result.Usage.PromptTokens, CompletionTokens: result.Usage.CompletionTokens},
+	}
+	if message.Content != nil {
+		out.Content = *message.Content
+	}
+	if len(message.ToolCalls) > 0 {
+		call, err := toolCall(message.ToolCalls[0])
+		if err != nil {
+			return Response{}, err
+		}
+		out.ToolCall = &call
+	}
+	return out, nil
+}
+
+// Stream sends a streamed chat completion request, passing the content to onChunk as it
+// arrives. Tool calls are assembled from their fragments and returned at the end.
+func (o *OpenAI) Stream(ctx context.Context, req Request, onChunk func(chunk string)) (Response, error) {
+	resp, err := o.post(ctx, "/chat/completions", o.chatRequest(req, true))
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+
+	var out Response
+	var content strings.Builder
+	var call *openAIToolCall
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk struct {
+			Model   string `json:"model"`
+			Choices []struct {
+				Delta struct {
+					Content   string           `json:"content"`
+					ToolCalls []openAIToolCall `json:"tool_calls"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Usage *openAIUsage `json:"usage"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return Response{}, fmt.Errorf("decoding chat completion chunk: %w", err)
+		}
+		if chunk.Model != "" {
+			out.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			out.Usage = Usage{PromptTokens: chunk.Usage.PromptTokens, CompletionTokens: chunk.Usage.CompletionTokens}
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content != "" {
+				content.WriteString(choice.Delta.Content)
+				onChunk(choice.Delta.Content)
+			}
+			for _, fragment := range choice.Delta.ToolCalls {
+				// Only the first call is kept, as with Complete
+				if call == nil {
+					call = &fragment
+					continue
+				}
+				if fragment.Index == call.Index {
+					call.Function.Name += fragment.Function.Name
+					call.Function.Arguments += fragment.Function.Arguments
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Response{}, fmt.Errorf("reading chat completion stream: %w", err)
+	}
+
+	out.Content = content.String()
+	if call != nil {
+		parsed, err := toolCall(*call)
+		if err != nil {
+			return Response{}, err
+		}
+		out.ToolCall = &parsed
+	}
+	return out, nil
+}
+
+// toolCall converts a function call of the model into a tool call
+func toolCall(c openAIToolCall) (tools.Call, error) {
+	call := tools.Call{ID: c.ID, Name: c.Function.Name, Args: map[string]interface{}{}}
+	if args := strings.TrimSpace(c.Function.Arguments); args != "" {
+		if err := json.Unmarshal([]byte(args), &call.Args); err != nil {
+			return tools.Call{}, fmt.Errorf("decoding arguments of %s: %w", call.Name, err)
+		}
+	}
+	return call, nil
+}
+
+// Embed returns the embedding vectors of the texts, in order
+func (o *OpenAI) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	resp, err := o.post(ctx, "/embeddings", map[string]interface{}{"model": o.EmbeddingModel, "input": texts})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decoding embeddings: %w", err)
+	}
+	vectors := make([][]float64, len(texts))
+	for _, d := range result.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding index %d out of range", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	for i, v := range vectors {
+		if v == nil {
+			return nil, fmt.Errorf("no embedding returned for text %d", i)
+		}
+	}
+	return vectors, nil
+}
+
+// post sends a JSON request to the API and returns the response of a successful call
+func (o *OpenAI) post(ctx context.Context, path string, body interface{}) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(o.BaseURL, "/")+path, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+o.APIKey)
+	resp, err := o.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var failure struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		message := string(bytes.TrimSpace(raw))
+		if json.Unmarshal(raw, &failure) == nil && failure.Error.Message != "" {
+			message = failure.Error.Message
+		}
+		return nil, &StatusError{StatusCode: resp.StatusCode, Message: message}
+	}
+	return resp, nil
+}
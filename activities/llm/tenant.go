@@ -0,0 +1,107 @@
+package llm
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"sync"
+	"temporal-ai-agent/secrets"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultTenantKeyTTL is how long a tenant's key is used before it is read again, so
+// rotated and removed keys take effect without restarting workers
+const DefaultTenantKeyTTL = 5 * time.Minute
+
+// TenantKeySecret returns the name of the secret holding a tenant's LLM API key
+func TenantKeySecret(tenant string) string {
+	return "tenants/" + tenant + "/llm-api-key"
+}
+
+// Tenants resolves the providers of tenants who bring their own API key: their completions
+// are sent with the openai provider and their key, so the spend lands on their account
+type Tenants struct {
+	Secrets secrets.Store
+	// Options configure the tenants' providers; the API key is replaced by the tenant's
+	Options Options
+	// TTL is how long a key is cached (DefaultTenantKeyTTL when zero)
+	TTL time.Duration
+
+	mu      sync.Mutex
+	cached  map[string]tenantProvider
+	lookups singleflight.Group
+}
+
+// tenantKeyErrorTTL is how long a failed read of a tenant's key is cached, so the turns of
+// a tenant do not each wait on the secrets store while it is failing
+const tenantKeyErrorTTL = 10 * time.Second
+
+// tenantProvider is a tenant's cached provider; provider is nil for tenants without a key,
+// and err is set while a failed read of the key is cached
+type tenantProvider struct {
+	key      string
+	provider Provider
+	err      error
+	expires  time.Time
+}
+
+// Provider returns the provider of a tenant, or nil if the tenant has no key of its own
+func (t *Tenants) Provider(ctx context.Context, tenant string) (Provider, error) {
+	t.mu.Lock()
+	cached, ok := t.cached[tenant]
+	t.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.get()
+	}
+
+	// Concurrent misses for a tenant share one read of its key
+	v, _, _ := t.lookups.Do(tenant, func() (interface{}, error) {
+		return t.refresh(ctx, tenant, cached), nil
+	})
+	return v.(tenantProvider).get()
+}
+
+func (p tenantProvider) get() (Provider, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.provider, nil
+}
+
+// refresh reads a tenant's key and caches its provider, or the error for tenantKeyErrorTTL
+func (t *Tenants) refresh(ctx context.Context, tenant string, previous tenantProvider) tenantProvider {
+	cached := previous
+	cached.err = nil
+	key, err := t.Secrets.Get(ctx, TenantKeySecret(tenant))
+	switch {
+	case err != nil && !errors.Is(err, secrets.ErrNotFound):
+		cached.err = err
+	// Keep the provider while the key is unchanged, and with it its pooled connections
+	case key != previous.key || previous.provider == nil && key != "":
+		cached = tenantProvider{key: key}
+		if key != "" {
+			opts := t.Options
+			opts.OpenAIAPIKey = key
+			cached.provider, cached.err = New("openai", opts)
+		}
+	}
+	if cached.err != nil {
+		// A caller that gave up says nothing about the store, so its error is not cached
+		if ctx.Err() != nil {
+			return cached
+		}
+		cached.expires = time.Now().Add(tenantKeyErrorTTL)
+	} else {
+		cached.expires = time.Now().Add(cmp.Or(t.TTL, DefaultTenantKeyTTL))
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.cached == nil {
+		t.cached = map[string]tenantProvider{}
+	}
+	t.cached[tenant] = cached
+	return cached
+}
@@ -0,0 +1,93 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"temporal-ai-agent/secrets"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// countingStore serves one tenant key, counting reads; reads wait for release when it is set
+type countingStore struct {
+	secrets.Store
+	key     string
+	err     error
+	reads   atomic.Int32
+	release chan struct{}
+}
+
+func (s *countingStore) Get(ctx context.Context, name string) (string, error) {
+	s.reads.Add(1)
+	if s.release != nil {
+		<-s.release
+	}
+	if s.err != nil {
+		return "", s.err
+	}
+	return s.key, nil
+}
+
+func TestTenantsShareConcurrentLookups(t *testing.T) {
+	store := &countingStore{key: "sk-tenant", release: make(chan struct{})}
+	tenants := &Tenants{Secrets: store}
+
+	var wg sync.WaitGroup
+	providers := make([]Provider, 8)
+	for i := range providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p, err := tenants.Provider(context.Background(), "acme")
+			require.NoError(t, err)
+			providers[i] = p
+		}()
+	}
+	require.Eventually(t, func() bool { return store.reads.Load() == 1 }, time.Second, time.Millisecond)
+	close(store.release)
+	wg.Wait()
+
+	require.EqualValues(t, 1, store.reads.Load(), "one read of the key for concurrent misses")
+	for _, p := range providers {
+		require.NotNil(t, p)
+		require.Same(t, providers[0], p)
+	}
+}
+
+func TestTenantsCacheFailedLookups(t *testing.T) {
+	outage := errors.New("vault unavailable")
+	store := &countingStore{err: outage}
+	tenants := &Tenants{Secrets: store}
+
+	for i := 0; i < 3; i++ {
+		_, err := tenants.Provider(context.Background(), "acme")
+		require.ErrorIs(t, err, outage)
+	}
+	require.EqualValues(t, 1, store.reads.Load(), "the failure is cached")
+
+	// Once the failure expires the key is read again
+	tenants.mu.Lock()
+	cached := tenants.cached["acme"]
+	cached.expires = time.Now().Add(-time.Second)
+	tenants.cached["acme"] = cached
+	tenants.mu.Unlock()
+	store.err = nil
+	store.key = "sk-tenant"
+	p, err := tenants.Provider(context.Background(), "acme")
+	require.NoError(t, err)
+	require.NotNil(t, p)
+	require.EqualValues(t, 2, store.reads.Load())
+}
+
+func TestTenantsWithoutKey(t *testing.T) {
+	store := &countingStore{err: secrets.ErrNotFound}
+	tenants := &Tenants{Secrets: store}
+
+	p, err := tenants.Provider(context.Background(), "acme")
+	require.NoError(t, err)
+	require.Nil(t, p)
+}
@@ -0,0 +1,24 @@
+package activities
+
+import (
+	"context"
+	"temporal-ai-agent/personas"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+// ResolvePersonaRequest is the input of the ResolvePersona activity
+type ResolvePersonaRequest struct {
+	Name string `json:"name,omitempty"`
+	Goal string `json:"goal"`
+}
+
+// ResolvePersona looks up the persona a conversation should speak with.
+// Unknown personas are configuration errors, so they are not retried.
+func (a *Activities) ResolvePersona(ctx context.Context, req ResolvePersonaRequest) (personas.Persona, error) {
+	p, err := a.Personas.Resolve(req.Name, req.Goal)
+	if err != nil {
+		return personas.Persona{}, temporal.NewNonRetryableApplicationError(err.Error(), "UnknownPersona", err)
+	}
+	return p, nil
+}
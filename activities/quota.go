@@ -0,0 +1,14 @@
+package activities
+
+import (
+	"context"
+	"temporal-ai-agent/quota"
+)
+
+// RecordUsage adds the messages and tokens of a turn to the account's quota usage
+func (a *Activities) RecordUsage(ctx context.Context, record quota.Record) error {
+	if a.Usage == nil {
+		return nil
+	}
+	return a.Usage.Add(ctx, record)
+}
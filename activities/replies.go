@@ -0,0 +1,80 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"temporal-ai-agent/activities/llm"
+)
+
+// Bounds of the replies suggested to the user
+const (
+	maxSuggestedReplies = 3
+	maxReplyLength      = 60
+	// replyContext is the number of recent messages suggestions are based on
+	replyContext = 6
+)
+
+// listMarker matches the bullet or number models put before list items
+var listMarker = regexp.MustCompile(`^\s*(?:[-*•]|\d+[.)])\s*`)
+
+// SuggestRepliesRequest is the input of the SuggestReplies activity
+type SuggestRepliesRequest struct {
+	Messages []llm.Message `json:"messages"`
+	// Tenant is the quota account of the conversation, whose own LLM key is used if it has one
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// SuggestReplies asks the title model for short replies the user might send next. Only
+// the latest messages are sent, to keep the call cheap.
+func (a *Activities) SuggestReplies(ctx context.Context, req SuggestRepliesRequest) ([]string, error) {
+	defer keepHeartbeating(ctx)()
+	var transcript []string
+	for _, m := range req.Messages {
+		if (m.Role == llm.RoleUser || m.Role == llm.RoleAssistant) && m.ToolCall == nil && m.Content != "" {
+			transcript = append(transcript, fmt.Sprintf("%s: %s", m.Role, m.Content))
+		}
+	}
+	if len(transcript) > replyContext {
+		transcript = transcript[len(transcript)-replyContext:]
+	}
+
+	provider, err := a.provider(ctx, req.Tenant)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := provider.Complete(ctx, llm.Request{Model: a.TitleModel, Messages: []llm.Message{
+		{
+			Role: llm.RoleSystem,
+			Content: fmt.Sprintf("Suggest %d short replies the user could send next in the following conversation, in the user's language. "+
+				"Each reply is at most %d characters, written as the user. Reply with one suggestion per line and nothing else.",
+				maxSuggestedReplies, maxReplyLength),
+		},
+		{Role: llm.RoleUser, Content: strings.Join(transcript, "\n")},
+	}})
+	if err != nil {
+		return nil, err
+	}
+	return parseReplies(resp.Content), nil
+}
+
+// parseReplies extracts the suggestions from the model's answer, skipping duplicates and
+// lines too long to fit a button
+func parseReplies(content string) []string {
+	var replies []string
+	seen := map[string]bool{}
+	for _, line := range strings.Split(content, "\n") {
+		reply := strings.Trim(strings.TrimSpace(listMarker.ReplaceAllString(line, "")), `"“”`)
+		key := strings.ToLower(reply)
+		if reply == "" || len([]rune(reply)) > maxReplyLength || seen[key] {
+			continue
+		}
+		seen[key] = true
+		replies = append(replies, reply)
+		if len(replies) == maxSuggestedReplies {
+			break
+		}
+	}
+	return replies
+}
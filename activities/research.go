@@ -0,0 +1,175 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"temporal-ai-agent/activities/llm"
+	"temporal-ai-agent/httpclient"
+	"temporal-ai-agent/websearch"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+)
+
+// maxPageBytes bounds the page text handed to the LLM when reading a source
+const maxPageBytes = 20000
+
+// fetchClient downloads pages for research
+var fetchClient = httpclient.New(30*time.Second, httpclient.Options{})
+
+// Source is a web page the research agent read, with the notes it took
+type Source struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+	Notes string `json:"notes"`
+}
+
+// WebSearchRequest is the input of the WebSearch activity
+type WebSearchRequest struct {
+	Query string `json:"query"`
+	Limit int    `json:"limit"`
+}
+
+// PlanResearchRequest is the input of the PlanResearch activity
+type PlanResearchRequest struct {
+	Question string `json:"question"`
+	// Tenant is the quota account that started the research, whose own LLM key is used if it has one
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// ReadSourceRequest is the input of the ReadSource activity
+type ReadSourceRequest struct {
+	Question string           `json:"question"`
+	Result   websearch.Result `json:"result"`
+	// Tenant is the quota account that started the research, whose own LLM key is used if it has one
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// RefineResearchRequest is the input of the RefineResearch activity
+type RefineResearchRequest struct {
+	Question string   `json:"question"`
+	Asked    []string `json:"asked"`
+	Notes    []string `json:"notes"`
+	Limit    int      `json:"limit"`
+	// Tenant is the quota account that started the research, whose own LLM key is used if it has one
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// SynthesizeReportRequest is the input of the SynthesizeReport activity
+type SynthesizeReportRequest struct {
+	Question string   `json:"question"`
+	Sources  []Source `json:"sources"`
+	// Tenant is the quota account that started the research, whose own LLM key is used if it has one
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// PlanResearch breaks a research question into initial search queries
+func (a *Activities) PlanResearch(ctx context.Context, req PlanResearchRequest) ([]string, error) {
+	defer keepHeartbeating(ctx)()
+	reply, err := a.complete(ctx, req.Tenant,
+		"You plan web research. List up to 4 search queries that together answer the question, one per line, without commentary.",
+		req.Question)
+	if err != nil {
+		return nil, err
+	}
+	return parseLines(reply, 4), nil
+}
+
+// WebSearch runs a web search query
+func (a *Activities) WebSearch(ctx context.Context, req WebSearchRequest) ([]websearch.Result, error) {
+	defer keepHeartbeating(ctx)()
+	return a.Searcher.Search(ctx, req.Query, req.Limit)
+}
+
+// ReadSource fetches a search result and extracts the notes relevant to the question.
+// If the page cannot be fetched the search snippet is used instead.
+func (a *Activities) ReadSource(ctx context.Context, req ReadSourceRequest) (string, error) {
+	defer keepHeartbeating(ctx)()
+	text, err := websearch.FetchText(ctx, fetchClient, req.Result.URL, maxPageBytes)
+	if err != nil {
+		activity.GetLogger(ctx).Warn("Unable to fetch source, using snippet", "url", req.Result.URL, "error", err)
+		text = req.Result.Snippet
+	}
+
+	return a.complete(ctx, req.Tenant,
+		"Extract the facts from the page that help answer the question, as concise bullet points. Reply NONE if nothing is relevant.",
+		fmt.Sprintf("Question: %s\n\nPage (%s):\n%s", req.Question, req.Result.Title, text))
+}
+
+// RefineResearch decides which follow-up queries are still needed. An empty result means
+// the notes are sufficient to answer the question.
+func (a *Activities) RefineResearch(ctx context.Context, req RefineResearchRequest) ([]string, error) {
+	defer keepHeartbeating(ctx)()
+	reply, err := a.complete(ctx, req.Tenant,
+		fmt.Sprintf("You review research notes. If they fully answer the question reply DONE. "+
+			"Otherwise list up to %d new search queries for the missing information, one per line.", req.Limit),
+		fmt.Sprintf("Question: %s\n\nQueries already run:\n%s\n\nNotes:\n%s",
+			req.Question, strings.Join(req.Asked, "\n"), strings.Join(req.Notes, "\n\n")))
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(strings.TrimSpace(reply), "DONE") {
+		return nil, nil
+	}
+	return parseLines(reply, req.Limit), nil
+}
+
+// SynthesizeReport writes the final answer, citing sources as [n]
+func (a *Activities) SynthesizeReport(ctx context.Context, req SynthesizeReportRequest) (string, error) {
+	defer keepHeartbeating(ctx)()
+	var sources strings.Builder
+	for _, s := range req.Sources {
+		fmt.Fprintf(&sources, "[%d] %s (%s)\n%s\n\n", s.ID, s.Title, s.URL, s.Notes)
+	}
+
+	report, err := a.complete(ctx, req.Tenant,
+		"Write a well-structured answer to the question using only the numbered sources. Cite every claim as [n].",
+		fmt.Sprintf("Question: %s\n\nSources:\n%s", req.Question, sources.String()))
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString(report)
+	b.WriteString("\n\nSources:\n")
+	for _, s := range req.Sources {
+		fmt.Fprintf(&b, "[%d] %s — %s\n", s.ID, s.Title, s.URL)
+	}
+	return b.String(), nil
+}
+
+// complete runs a single system + user prompt completion for a tenant
+func (a *Activities) complete(ctx context.Context, tenant, system, user string) (string, error) {
+	provider, err := a.provider(ctx, tenant)
+	if err != nil {
+		return "", err
+	}
+	resp, err := provider.Complete(ctx, llm.Request{Messages: []llm.Message{
+		{Role: llm.RoleSystem, Content: system},
+		{Role: llm.RoleUser, Content: user},
+	}})
+	if err != nil {
+		return "", err
+	}
+	return resp.Content, nil
+}
+
+// parseLines splits a model reply into list items, dropping bullets and numbering
+func parseLines(reply string, limit int) []string {
+	var items []string
+	for _, line := range strings.Split(reply, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimLeft(line, "-*•0123456789.) ")
+		line = strings.Trim(line, `"`)
+		if line == "" || strings.EqualFold(line, "DONE") {
+			continue
+		}
+		items = append(items, line)
+		if len(items) == limit {
+			break
+		}
+	}
+	return items
+}
@@ -0,0 +1,14 @@
+package activities
+
+import (
+	"context"
+	"temporal-ai-agent/search"
+)
+
+// IndexTranscript stores the conversation transcript in the search index, if one is configured
+func (a *Activities) IndexTranscript(ctx context.Context, transcript search.Transcript) error {
+	if a.Search == nil {
+		return nil
+	}
+	return a.Search.Index(ctx, transcript)
+}
@@ -0,0 +1,81 @@
+package activities
+
+import (
+	"context"
+	"slices"
+	"temporal-ai-agent/semcache"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+)
+
+// CachedAnswerRequest is the input of the LookupCachedAnswer and CacheAnswer activities
+type CachedAnswerRequest struct {
+	Scope    semcache.Scope `json:"scope"`
+	Question string         `json:"question"`
+	// Answer is the reply to cache (CacheAnswer only)
+	Answer string `json:"answer,omitempty"`
+}
+
+// CachedAnswer is the result of the LookupCachedAnswer activity
+type CachedAnswer struct {
+	// Enabled is false when the goal does not use the semantic cache, so the conversation
+	// can stop looking up answers
+	Enabled bool `json:"enabled"`
+	// Match is nil on a cache miss
+	Match *semcache.Match `json:"match,omitempty"`
+}
+
+// LookupCachedAnswer returns the cached answer to a near-duplicate of the question, if the
+// goal uses the semantic cache and one is found
+func (a *Activities) LookupCachedAnswer(ctx context.Context, req CachedAnswerRequest) (CachedAnswer, error) {
+	vector, ok, err := a.cacheEmbedding(ctx, req)
+	if !ok || err != nil {
+		return CachedAnswer{Enabled: ok}, err
+	}
+	since := time.Time{}
+	if a.SemanticCacheTTL > 0 {
+		since = time.Now().Add(-a.SemanticCacheTTL)
+	}
+	match, found, err := a.SemanticCache.Lookup(ctx, req.Scope, vector, a.SemanticCacheThreshold, since)
+	if err != nil || !found {
+		return CachedAnswer{Enabled: true}, err
+	}
+	activity.GetLogger(ctx).Info("Semantic cache hit", "goal", req.Scope.Goal, "similarity", match.Similarity)
+	// The workflow only needs the answer, so the vector stays out of its history
+	match.Vector = nil
+	return CachedAnswer{Enabled: true, Match: &match}, nil
+}
+
+// CacheAnswer stores a validated answer for later near-duplicate questions, if the goal uses
+// the semantic cache
+func (a *Activities) CacheAnswer(ctx context.Context, req CachedAnswerRequest) error {
+	vector, ok, err := a.cacheEmbedding(ctx, req)
+	if !ok || err != nil {
+		return err
+	}
+	return a.SemanticCache.Add(ctx, semcache.Entry{
+		Scope:     req.Scope,
+		Question:  req.Question,
+		Answer:    req.Answer,
+		Vector:    vector,
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+// cacheEmbedding embeds the question when the goal uses the semantic cache and the provider
+// supports embeddings. It reports false when the cache does not apply.
+func (a *Activities) cacheEmbedding(ctx context.Context, req CachedAnswerRequest) ([]float64, bool, error) {
+	if a.SemanticCache == nil || !slices.Contains(a.SemanticCacheGoals, req.Scope.Goal) {
+		return nil, false, nil
+	}
+	embedder, ok := a.embedder()
+	if !ok {
+		return nil, false, nil
+	}
+	vectors, err := embedder.Embed(ctx, []string{req.Question})
+	if err != nil {
+		return nil, false, err
+	}
+	return vectors[0], true, nil
+}
@@ -0,0 +1,58 @@
+package activities
+
+import (
+	"cmp"
+	"context"
+	"temporal-ai-agent/activities/llm"
+	"temporal-ai-agent/shadow"
+	"temporal-ai-agent/tokens"
+	"time"
+)
+
+// ShadowTurnRequest is the input of the ShadowTurn activity
+type ShadowTurnRequest struct {
+	// Turn holds the conversation's own answer
+	Turn shadow.Turn `json:"turn"`
+	// Request is the turn's completion request for the shadow candidate
+	Request llm.Request `json:"request"`
+}
+
+// ShadowTurn has the shadow candidate complete the request and records its answer next to
+// the conversation's. A failed completion is recorded rather than retried, so shadow traffic
+// never costs more than one call per turn. It does nothing when no shadow store is configured.
+func (a *Activities) ShadowTurn(ctx context.Context, req ShadowTurnRequest) error {
+	defer keepHeartbeating(ctx)()
+	if a.Shadows == nil {
+		return nil
+	}
+
+	completion := req.Request
+	counter := tokens.ForModel(completion.Model)
+	if a.MaxContextTokens > 0 {
+		completion.Messages = a.ContextPolicy.Fit(counter, completion.Messages, a.MaxContextTokens)
+	}
+
+	turn := req.Turn
+	started := time.Now()
+	provider, err := a.provider(ctx, completion.Tenant)
+	if err != nil {
+		return err
+	}
+	resp, err := provider.Complete(ctx, completion)
+	turn.ShadowLatencyMs = time.Since(started).Milliseconds()
+	turn.ShadowModel = cmp.Or(resp.Model, completion.Model)
+	if err != nil {
+		turn.Error = err.Error()
+	} else {
+		turn.ShadowReply = resp.Content
+		if resp.ToolCall != nil {
+			turn.ShadowTool = resp.ToolCall.Name
+		}
+		// Counted like Complete does for providers that do not report usage
+		turn.ShadowTokens = resp.Usage.Total()
+		if turn.ShadowTokens == 0 {
+			turn.ShadowTokens = counter.CountMessages(completion.Messages) + counter.Count(resp.Content)
+		}
+	}
+	return a.Shadows.Save(ctx, turn)
+}
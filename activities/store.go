@@ -0,0 +1,30 @@
+package activities
+
+import (
+	"context"
+	"temporal-ai-agent/store"
+)
+
+// SaveConversationRequest is the input of the SaveConversation activity
+type SaveConversationRequest struct {
+	Conversation store.Conversation `json:"conversation"`
+	// From is the position of the first message; stored messages from there on are replaced
+	From     int             `json:"from"`
+	Messages []store.Message `json:"messages"`
+}
+
+// SaveConversation persists a conversation and its new messages, if a store is configured
+func (a *Activities) SaveConversation(ctx context.Context, req SaveConversationRequest) error {
+	if a.Conversations == nil {
+		return nil
+	}
+	return a.Conversations.SaveConversation(ctx, req.Conversation, req.From, req.Messages)
+}
+
+// SaveFeedback persists a user's rating of a conversation, if a store is configured
+func (a *Activities) SaveFeedback(ctx context.Context, feedback store.Feedback) error {
+	if a.Conversations == nil {
+		return nil
+	}
+	return a.Conversations.AddFeedback(ctx, feedback)
+}
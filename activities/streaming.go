@@ -0,0 +1,14 @@
+package activities
+
+import (
+	"context"
+	"temporal-ai-agent/streaming"
+)
+
+// PublishEvent appends a JSON-encoded progress event to the conversation's stream
+func (a *Activities) PublishEvent(ctx context.Context, workflowID, event string) error {
+	if a.Streams == nil {
+		return nil
+	}
+	return a.Streams.Publish(ctx, workflowID, streaming.KindEvent, event)
+}
@@ -0,0 +1,18 @@
+package activities
+
+import (
+	"context"
+	"temporal-ai-agent/templates"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+// ResolveTemplate looks up the template a conversation is started from. Unknown templates
+// are configuration errors, so they are not retried.
+func (a *Activities) ResolveTemplate(ctx context.Context, name string) (templates.Template, error) {
+	t, err := a.Templates.Find(name)
+	if err != nil {
+		return templates.Template{}, temporal.NewNonRetryableApplicationError(err.Error(), "UnknownTemplate", err)
+	}
+	return t, nil
+}
@@ -0,0 +1,183 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"temporal-ai-agent/activities/llm"
+	"temporal-ai-agent/tokens"
+	"temporal-ai-agent/tools"
+	"unicode/utf8"
+
+	"go.temporal.io/sdk/activity"
+)
+
+// maxSummaryInputBytes caps the part of an oversized tool result summarized by one request;
+// longer results are summarized part by part, up to maxSummaryParts parts
+const (
+	maxSummaryInputBytes = 100_000
+	maxSummaryParts      = 8
+	summaryConcurrency   = 4
+)
+
+// fitToolResult shrinks a tool result that exceeds the tool's size limits, so it neither
+// blows the model's context nor bloats the workflow history. The passages relevant to the
+// request are extracted when ExtractToolResults is set, then the result is summarized by the
+// title model when SummarizeToolResults is set, and truncated if it still does not fit.
+func (a *Activities) fitToolResult(ctx context.Context, def tools.Definition, result string, question string, args map[string]interface{}, tenant string) string {
+	limits := def.ResultLimits(a.ToolResultLimits)
+	counter := tokens.ForModel(a.TitleModel)
+	if fits(counter, result, limits) {
+		return result
+	}
+	activity.GetLogger(ctx).Info("Tool result exceeds its limits", "tool", def.Name, "bytes", len(result))
+
+	if a.ExtractToolResults {
+		if extracted, ok := extractRelevant(counter, result, queryTerms(question, formatArgValues(args)), limits); ok {
+			if fits(counter, extracted, limits) {
+				return extracted
+			}
+		}
+	}
+	if a.SummarizeToolResults {
+		provider, err := a.provider(ctx, tenant)
+		var summary string
+		if err == nil {
+			summary, err = a.summarizeToolResult(ctx, provider, def, result, limits)
+		}
+		if err == nil && fits(counter, summary, limits) {
+			return summary
+		}
+		if err != nil {
+			activity.GetLogger(ctx).Warn("Unable to summarize tool result, truncating it", "tool", def.Name, "error", err)
+		}
+	}
+	return truncateToLimits(counter, result, limits)
+}
+
+// formatArgValues joins the string values of a tool call's arguments
+func formatArgValues(args map[string]interface{}) string {
+	var values []string
+	for _, v := range args {
+		if s, ok := v.(string); ok {
+			values = append(values, s)
+		}
+	}
+	return strings.Join(values, " ")
+}
+
+// summarizeToolResult asks the title model to condense a tool result within the limits.
+// Results too long for one request are split in parts that are summarized in parallel
+// (map), and the summaries are condensed again if together they still exceed the limits
+// (reduce).
+func (a *Activities) summarizeToolResult(ctx context.Context, provider llm.Provider, def tools.Definition, result string, limits tools.Limits) (string, error) {
+	parts := splitText(result, maxSummaryInputBytes)
+	if len(parts) == 1 {
+		summary, err := a.condense(ctx, provider, def, parts[0], limits, "")
+		if err != nil {
+			return "", err
+		}
+		return "[summarized] " + summary, nil
+	}
+	if len(parts) > maxSummaryParts {
+		activity.GetLogger(ctx).Warn("Tool result too long to summarize whole, dropping its end",
+			"tool", def.Name, "parts", len(parts))
+		parts = parts[:maxSummaryParts]
+	}
+
+	partLimits := tools.Limits{MaxBytes: limits.MaxBytes / len(parts), MaxTokens: limits.MaxTokens / len(parts)}
+	summaries := make([]string, len(parts))
+	errs := make([]error, len(parts))
+	slots := make(chan struct{}, summaryConcurrency)
+	var wg sync.WaitGroup
+	for i, part := range parts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			summaries[i], errs[i] = a.condense(ctx, provider, def, part, partLimits, fmt.Sprintf("part %d of %d of the ", i+1, len(parts)))
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return "", err
+	}
+
+	counter := tokens.ForModel(a.TitleModel)
+	joined := strings.Join(summaries, "\n\n")
+	if !fits(counter, joined, limits) {
+		reduced, err := a.condense(ctx, provider, def, tools.Truncate(joined, maxSummaryInputBytes), limits, "summaries of the parts of the ")
+		if err != nil {
+			return "", err
+		}
+		joined = reduced
+	}
+	return "[summarized] " + joined, nil
+}
+
+// condense asks the title model to condense text, the output of a tool or the given part
+// of it, within the limits
+func (a *Activities) condense(ctx context.Context, provider llm.Provider, def tools.Definition, text string, limits tools.Limits, part string) (string, error) {
+	instructions := fmt.Sprintf("Condense the following %soutput of the %s tool, keeping every fact, number and identifier "+
+		"an assistant may need to answer the user. Reply with the condensed output only.", part, def.Name)
+	if limits.MaxBytes > 0 {
+		instructions += fmt.Sprintf(" Stay under %d characters.", limits.MaxBytes)
+	}
+	if limits.MaxTokens > 0 {
+		instructions += fmt.Sprintf(" Stay under %d tokens.", limits.MaxTokens)
+	}
+
+	resp, err := provider.Complete(ctx, llm.Request{Model: a.TitleModel, Messages: []llm.Message{
+		{Role: llm.RoleSystem, Content: instructions},
+		{Role: llm.RoleUser, Content: text},
+	}})
+	if err != nil {
+		return "", err
+	}
+	return resp.Content, nil
+}
+
+// splitText splits text into parts of at most size bytes, cutting at line ends when it can
+// and never inside a character
+func splitText(text string, size int) []string {
+	var parts []string
+	for len(text) > size {
+		cut := strings.LastIndexByte(text[:size], '\n') + 1
+		if cut <= 0 {
+			cut = size
+			for cut > 1 && !utf8.RuneStart(text[cut]) {
+				cut--
+			}
+		}
+		parts = append(parts, text[:cut])
+		text = text[cut:]
+	}
+	return append(parts, text)
+}
+
+// fits reports whether text is within the limits
+func fits(counter *tokens.Counter, text string, limits tools.Limits) bool {
+	if limits.MaxBytes > 0 && len(text) > limits.MaxBytes {
+		return false
+	}
+	return limits.MaxTokens <= 0 || counter.Count(text) <= limits.MaxTokens
+}
+
+// truncateToLimits truncates text to the byte limit, then further until it fits the token
+// limit, estimating the bytes to keep from the tokens counted
+func truncateToLimits(counter *tokens.Counter, text string, limits tools.Limits) string {
+	truncated := tools.Truncate(text, limits.MaxBytes)
+	maxBytes := len(truncated)
+	for !fits(counter, truncated, limits) {
+		next := maxBytes * limits.MaxTokens / counter.Count(truncated) * 9 / 10
+		if next <= 0 || next >= maxBytes {
+			break
+		}
+		maxBytes = next
+		truncated = tools.Truncate(text, maxBytes)
+	}
+	return truncated
+}
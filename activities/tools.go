@@ -0,0 +1,152 @@
+package activities
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"temporal-ai-agent/tools"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+)
+
+// ListTools returns the definitions of the tools the agent can call for a goal: the goal's
+// tools, filtered by the tool policy of the worker's environment, with the confirmation
+// their risk requires. Goals outside the catalog, such as the digest's, get every tool.
+func (a *Activities) ListTools(ctx context.Context, goal string) ([]tools.Definition, error) {
+	defs := a.Tools.Definitions()
+	if a.Goals != nil {
+		if g, err := a.Goals.Find(goal); err == nil {
+			defs = slices.DeleteFunc(defs, func(def tools.Definition) bool { return !g.Permits(def.Name) })
+		}
+	}
+	policy := a.ToolPolicies.Resolve(a.Environment, goal)
+	return a.RiskPolicy.Apply(policy.Filter(defs)), nil
+}
+
+// ExecuteToolRequest is the input of the ExecuteTool activity
+type ExecuteToolRequest struct {
+	Call tools.Call `json:"call"`
+	// Goal is the goal of the conversation, which tools can restrict what they do by
+	Goal string `json:"goal"`
+	// Context holds the context values of the conversation, for tools acting on its records
+	Context map[string]string `json:"context,omitempty"`
+	// Question is the user's latest message, which guides what is kept of an oversized result
+	Question string `json:"question,omitempty"`
+	// PageToken designates the page ExecuteToolPage fetches, the first when empty
+	PageToken string `json:"page_token,omitempty"`
+	// Tenant is the quota account of the conversation, which owns the files the tool writes
+	// and whose own LLM key summarizes oversized results if it has one
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// ExecuteTool runs a tool call and returns its result. Calls to a tool whose circuit
+// breaker is open fail immediately, without retries, with an error the agent can act on;
+// so do calls the tool rejects as permanent failures. Calls to Async tools stay open until
+// the system finishing them completes the activity; their result is used as is.
+func (a *Activities) ExecuteTool(ctx context.Context, req ExecuteToolRequest) (string, error) {
+	result, err := a.executeTool(ctx, req, func(toolCtx context.Context) (string, error) {
+		return a.Tools.Execute(toolCtx, req.Call)
+	})
+	if err != nil {
+		return "", err
+	}
+	def, _ := tools.Find(a.Tools.Definitions(), req.Call.Name)
+	return a.fitToolResult(ctx, def, result, req.Question, req.Call.Args, req.Tenant), nil
+}
+
+// ExecuteToolPage fetches one page of the results of a call to a paginated tool, as is:
+// the workflow fetches the pages one activity at a time, so a failed page is retried alone,
+// and fits the merged result with FitToolResult.
+func (a *Activities) ExecuteToolPage(ctx context.Context, req ExecuteToolRequest) (tools.Page, error) {
+	var page tools.Page
+	_, err := a.executeTool(ctx, req, func(toolCtx context.Context) (string, error) {
+		var err error
+		page, err = a.Tools.ExecutePage(toolCtx, req.Call, req.PageToken)
+		return page.Result, err
+	})
+	if err != nil {
+		return tools.Page{}, err
+	}
+	return page, nil
+}
+
+// FitToolResultRequest is the input of the FitToolResult activity
+type FitToolResultRequest struct {
+	Call     tools.Call `json:"call"`
+	Result   string     `json:"result"`
+	Question string     `json:"question,omitempty"`
+	// Tenant is the quota account of the conversation, whose own LLM key is used if it has one
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// FitToolResult shrinks the merged pages of a paginated tool's result to the tool's limits,
+// as ExecuteTool does for the results of other tools
+func (a *Activities) FitToolResult(ctx context.Context, req FitToolResultRequest) (string, error) {
+	defer keepHeartbeating(ctx)()
+	def, _ := tools.Find(a.Tools.Definitions(), req.Call.Name)
+	return a.fitToolResult(ctx, def, req.Result, req.Question, req.Call.Args, req.Tenant), nil
+}
+
+// executeTool runs a tool call through the tool's circuit breaker
+func (a *Activities) executeTool(ctx context.Context, req ExecuteToolRequest, run func(context.Context) (string, error)) (string, error) {
+	call := req.Call
+	activity.GetLogger(ctx).Info("Executing tool", "tool", call.Name, "call_id", call.ID, "page_token", req.PageToken)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("tool.name", call.Name), attribute.String("tool.call_id", call.ID))
+
+	if ok, wait := a.ToolBreakers.Allow(call.Name, time.Now()); !ok {
+		return "", temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("The %s tool is temporarily unavailable after repeated failures. Try again in about %s, or continue without it.",
+				call.Name, wait.Round(time.Second)),
+			"ToolUnavailable", nil)
+	}
+
+	toolCtx := tools.WithGoal(tools.WithCheckpoint(ctx, heartbeatCheckpoint{ctx}), req.Goal)
+	toolCtx = tools.WithConversationContext(toolCtx, req.Context)
+	toolCtx = tools.WithAccount(toolCtx, req.Tenant)
+	toolCtx = tools.WithCompletionToken(toolCtx, base64.URLEncoding.EncodeToString(activity.GetInfo(ctx).TaskToken))
+	// Every call records its outcome, even a panicking one, so a half-open trial is always
+	// released. Calls handed off or rejected as wrong show the tool works.
+	failed := true
+	defer func() { a.ToolBreakers.Record(call.Name, failed, time.Now()) }()
+	result, err := run(toolCtx)
+	failed = err != nil && !errors.Is(err, tools.ErrPending) && !tools.IsPermanent(err)
+	if errors.Is(err, tools.ErrPending) {
+		// The system the tool handed the call to completes the activity through the API
+		activity.GetLogger(ctx).Info("Tool call completes asynchronously", "tool", call.Name, "call_id", call.ID)
+		return "", activity.ErrResultPending
+	}
+	if tools.IsPermanent(err) {
+		// The tool works, the call is wrong
+		return "", temporal.NewNonRetryableApplicationError(err.Error(), "ToolRejected", nil)
+	}
+	if err != nil {
+		return "", err
+	}
+	return result, nil
+}
+
+// heartbeatCheckpoint keeps tool progress in the activity heartbeat details, which Temporal
+// hands to the next attempt when the call is retried
+type heartbeatCheckpoint struct {
+	ctx context.Context
+}
+
+func (h heartbeatCheckpoint) Load(v interface{}) (bool, error) {
+	if !activity.HasHeartbeatDetails(h.ctx) {
+		return false, nil
+	}
+	if err := activity.GetHeartbeatDetails(h.ctx, v); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (h heartbeatCheckpoint) Save(v interface{}) {
+	activity.RecordHeartbeat(h.ctx, v)
+}
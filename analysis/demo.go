@@ -0,0 +1,76 @@
+package analysis
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// demoSchema is the sales table of the demo database
+const demoSchema = `
+CREATE TABLE sales (
+	id         INTEGER PRIMARY KEY,
+	month      TEXT NOT NULL,
+	region     TEXT NOT NULL,
+	product    TEXT NOT NULL,
+	units      INTEGER NOT NULL,
+	revenue    REAL NOT NULL
+)`
+
+// Dimensions of the demo sales, with the unit price of each product
+var (
+	demoRegions  = []string{"North America", "Europe", "Asia Pacific", "Latin America"}
+	demoProducts = []struct {
+		name  string
+		price float64
+	}{{"Starter plan", 29}, {"Team plan", 99}, {"Enterprise plan", 499}}
+)
+
+// openDemo returns an in-memory database holding a year of monthly sales per region and
+// product. The numbers follow a fixed pattern, so the same questions get the same answers.
+func openDemo(ctx context.Context) (*Database, error) {
+	db, err := sql.Open("sqlite3", "file::memory:")
+	if err != nil {
+		return nil, err
+	}
+	// Every connection to :memory: opens a new database, so keep the one holding the data
+	db.SetMaxOpenConns(1)
+	db.SetConnMaxLifetime(0)
+	db.SetConnMaxIdleTime(0)
+	if _, err := db.ExecContext(ctx, demoSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	id := 0
+	start := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for m := 0; m < 12; m++ {
+		month := start.AddDate(0, m, 0).Format("2006-01")
+		for r, region := range demoRegions {
+			for p, product := range demoProducts {
+				id++
+				// Steady growth, a summer dip in Europe and a seasonal peak in December
+				units := (40-12*p)*(4-r) + 3*m*(3-p) + (id*7)%11
+				if region == "Europe" && (m == 6 || m == 7) {
+					units = units * 2 / 3
+				}
+				if m == 11 {
+					units = units * 5 / 4
+				}
+				_, err := db.ExecContext(ctx, `INSERT INTO sales VALUES ($1, $2, $3, $4, $5, $6)`,
+					id, month, region, product.name, units, float64(units)*product.price)
+				if err != nil {
+					db.Close()
+					return nil, fmt.Errorf("seeding demo database: %w", err)
+				}
+			}
+		}
+	}
+
+	if _, err := db.ExecContext(ctx, `PRAGMA query_only = ON`); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Database{db: db, Demo: true}, nil
+}
@@ -0,0 +1,122 @@
+package analysis
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"temporal-ai-agent/httpclient"
+	"time"
+)
+
+// Program is code sent to the code interpreter with its input files
+type Program struct {
+	Code string
+	// Files are written to the working directory before the code runs, e.g. data.csv
+	Files map[string]string
+}
+
+// Output is what a program printed and the files it wrote
+type Output struct {
+	Stdout string
+	Files  []File
+}
+
+// File is a file written by a program, e.g. a chart
+type File struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// ProgramError is a program that failed, e.g. with a Python exception. The model can fix
+// the code and run it again.
+type ProgramError struct {
+	Stderr string
+}
+
+func (e *ProgramError) Error() string {
+	return "the code failed: " + e.Stderr
+}
+
+// Interpreter runs Python programs in a sandbox
+type Interpreter interface {
+	Run(ctx context.Context, p Program) (Output, error)
+}
+
+// NewInterpreter returns the interpreter registered under the given name: mock, which
+// summarizes and plots data.csv whatever the code, or http, a sandbox service at url. opts
+// tunes the connection pool of the service's client.
+func NewInterpreter(name, url string, opts httpclient.Options) (Interpreter, error) {
+	switch name {
+	case "mock":
+		return Mock{}, nil
+	case "http":
+		if url == "" {
+			return nil, fmt.Errorf("CODE_INTERPRETER_URL is required for the http code interpreter")
+		}
+		return &HTTPInterpreter{URL: url, Client: httpclient.New(2*time.Minute, opts)}, nil
+	default:
+		return nil, fmt.Errorf("unknown code interpreter %q", name)
+	}
+}
+
+// maxOutputBytes bounds the response of the sandbox service, charts included
+const maxOutputBytes = 16 << 20
+
+// HTTPInterpreter posts programs to a sandbox service, which runs them in an isolated
+// container and answers with their output:
+//
+//	request:  {"language": "python", "code": "...", "files": {"data.csv": "..."}}
+//	response: {"stdout": "...", "stderr": "...", "exit_code": 0,
+//	           "files": [{"name": "chart.png", "content_type": "image/png", "data": "<base64>"}]}
+type HTTPInterpreter struct {
+	URL    string
+	Client *http.Client
+}
+
+// Run sends the program to the sandbox service
+func (h *HTTPInterpreter) Run(ctx context.Context, p Program) (Output, error) {
+	body, err := json.Marshal(map[string]interface{}{"language": "python", "code": p.Code, "files": p.Files})
+	if err != nil {
+		return Output{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return Output{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.Client.Do(req)
+	if err != nil {
+		return Output{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return Output{}, fmt.Errorf("code interpreter returned %s: %s", resp.Status, bytes.TrimSpace(data))
+	}
+
+	var result struct {
+		Stdout   string `json:"stdout"`
+		Stderr   string `json:"stderr"`
+		ExitCode int    `json:"exit_code"`
+		Files    []struct {
+			Name        string `json:"name"`
+			ContentType string `json:"content_type"`
+			Data        []byte `json:"data"`
+		} `json:"files"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxOutputBytes)).Decode(&result); err != nil {
+		return Output{}, fmt.Errorf("decoding code interpreter response: %w", err)
+	}
+	if result.ExitCode != 0 {
+		return Output{}, &ProgramError{Stderr: result.Stderr}
+	}
+	out := Output{Stdout: result.Stdout}
+	for _, f := range result.Files {
+		out.Files = append(out.Files, File{Name: f.Name, ContentType: f.ContentType, Data: f.Data})
+	}
+	return out, nil
+}
@@ -0,0 +1,137 @@
+package analysis
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Mock stands in for a sandbox without running any code: it prints summary statistics of
+// the numeric columns of data.csv and, when the code plots, draws a bar chart of the last
+// numeric column per value of the first column
+type Mock struct{}
+
+// plots matches code drawing a chart
+var plots = regexp.MustCompile(`\b(plot|bar|savefig|chart)\b`)
+
+// Run summarizes and plots data.csv
+func (Mock) Run(ctx context.Context, p Program) (Output, error) {
+	data, ok := p.Files["data.csv"]
+	if !ok {
+		return Output{}, &ProgramError{Stderr: "FileNotFoundError: data.csv (the mock interpreter only works on the data argument)"}
+	}
+	records, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		return Output{}, &ProgramError{Stderr: "reading data.csv: " + err.Error()}
+	}
+	if len(records) < 2 {
+		return Output{}, &ProgramError{Stderr: "data.csv has no rows"}
+	}
+	header, rows := records[0], records[1:]
+
+	var numeric []int
+	for col := range header {
+		if isNumericColumn(rows, col) {
+			numeric = append(numeric, col)
+		}
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "rows: %d\n", len(rows))
+	for _, col := range numeric {
+		sum, lo, hi := 0.0, 0.0, 0.0
+		for i, r := range rows {
+			v, _ := strconv.ParseFloat(r[col], 64)
+			sum += v
+			if i == 0 || v < lo {
+				lo = v
+			}
+			if i == 0 || v > hi {
+				hi = v
+			}
+		}
+		fmt.Fprintf(&b, "%s: sum=%.2f mean=%.2f min=%.2f max=%.2f\n", header[col], sum, sum/float64(len(rows)), lo, hi)
+	}
+	out := Output{Stdout: b.String()}
+
+	if plots.MatchString(p.Code) && len(numeric) > 0 {
+		labels, values := groupBy(rows, 0, numeric[len(numeric)-1])
+		chart, err := barChart(values)
+		if err != nil {
+			return Output{}, err
+		}
+		out.Files = append(out.Files, File{Name: "chart.png", ContentType: "image/png", Data: chart})
+		fmt.Fprintf(&b, "plotted %s by %s (%s)\n", header[numeric[len(numeric)-1]], header[0], strings.Join(labels, ", "))
+		out.Stdout = b.String()
+	}
+	return out, nil
+}
+
+// isNumericColumn reports whether every value of a column is a number
+func isNumericColumn(rows [][]string, col int) bool {
+	for _, r := range rows {
+		if col >= len(r) {
+			return false
+		}
+		if _, err := strconv.ParseFloat(r[col], 64); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// groupBy sums a value column per label, in the order labels first appear
+func groupBy(rows [][]string, labelCol, valueCol int) ([]string, []float64) {
+	var labels []string
+	var values []float64
+	index := map[string]int{}
+	for _, r := range rows {
+		v, _ := strconv.ParseFloat(r[valueCol], 64)
+		i, ok := index[r[labelCol]]
+		if !ok {
+			i = len(labels)
+			index[r[labelCol]] = i
+			labels = append(labels, r[labelCol])
+			values = append(values, 0)
+		}
+		values[i] += v
+	}
+	return labels, values
+}
+
+// barChart draws the values as bars on a 640x400 PNG
+func barChart(values []float64) ([]byte, error) {
+	const width, height, margin = 640, 400, 40
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	axis := image.NewUniform(color.Gray{Y: 80})
+	draw.Draw(img, image.Rect(margin, height-margin, width-margin, height-margin+2), axis, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(margin-2, margin, margin, height-margin+2), axis, image.Point{}, draw.Src)
+
+	top := 0.0
+	for _, v := range values {
+		top = max(top, v)
+	}
+	if top > 0 {
+		bar := image.NewUniform(color.RGBA{R: 66, G: 133, B: 244, A: 255})
+		slot := (width - 2*margin) / len(values)
+		for i, v := range values {
+			h := int(max(v, 0) / top * float64(height-2*margin))
+			x := margin + i*slot + slot/8
+			draw.Draw(img, image.Rect(x, height-margin-h, x+max(slot*3/4, 1), height-margin), bar, image.Point{}, draw.Src)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,185 @@
+package analysis
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Bounds of the queries run for the model
+const (
+	maxRows      = 200
+	queryTimeout = 30 * time.Second
+)
+
+// Database is the database the agent queries, read-only
+type Database struct {
+	db *sql.DB
+	// postgres selects the catalog queries; SQLite is assumed otherwise
+	postgres bool
+	// Demo marks the built-in sample database
+	Demo bool
+}
+
+// Open connects to the database at url: a postgres:// URL, a sqlite: path opened
+// read-only, or the in-memory demo database when url is empty
+func Open(ctx context.Context, url string) (*Database, error) {
+	switch {
+	case url == "":
+		return openDemo(ctx)
+	case strings.HasPrefix(url, "postgres://"), strings.HasPrefix(url, "postgresql://"):
+		db, err := sql.Open("postgres", url)
+		if err != nil {
+			return nil, err
+		}
+		if err := db.PingContext(ctx); err != nil {
+			db.Close()
+			return nil, err
+		}
+		return &Database{db: db, postgres: true}, nil
+	case strings.HasPrefix(url, "sqlite:"):
+		db, err := sql.Open("sqlite3", "file:"+strings.TrimPrefix(url, "sqlite:")+"?mode=ro&_query_only=on")
+		if err != nil {
+			return nil, err
+		}
+		if err := db.PingContext(ctx); err != nil {
+			db.Close()
+			return nil, err
+		}
+		return &Database{db: db}, nil
+	default:
+		return nil, fmt.Errorf("data source %q is neither a postgres:// URL nor a sqlite: path", url)
+	}
+}
+
+// Close closes the database connection
+func (d *Database) Close() error {
+	return d.db.Close()
+}
+
+// ErrNotReadOnly is returned for statements other than a single query
+var ErrNotReadOnly = errors.New("only a single SELECT (or WITH) query is allowed")
+
+// selectStatement matches statements starting as queries
+var selectStatement = regexp.MustCompile(`(?is)^\s*(select|with)\b`)
+
+// Query runs a single read-only query and returns its rows as CSV with a header, at most
+// maxRows of them. Truncated reports whether more rows were left out.
+func (d *Database) Query(ctx context.Context, query string) (result string, truncated bool, err error) {
+	query = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(query), ";"))
+	if !selectStatement.MatchString(query) || strings.Contains(query, ";") {
+		return "", false, ErrNotReadOnly
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+	// The transaction is read-only too, so a query with side effects (e.g. a function
+	// call) still cannot change anything
+	tx, err := d.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return "", false, err
+	}
+	defer tx.Rollback()
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return "", false, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", false, err
+	}
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.Write(columns)
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	record := make([]string, len(columns))
+	for n := 0; rows.Next(); n++ {
+		if n == maxRows {
+			truncated = true
+			break
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return "", false, err
+		}
+		for i, v := range values {
+			record[i] = formatValue(v)
+		}
+		w.Write(record)
+	}
+	if err := rows.Err(); err != nil {
+		return "", false, err
+	}
+	w.Flush()
+	return b.String(), truncated, w.Error()
+}
+
+// formatValue renders a column value for the CSV result
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case time.Time:
+		if v.Hour() == 0 && v.Minute() == 0 && v.Second() == 0 && v.Nanosecond() == 0 {
+			return v.Format(time.DateOnly)
+		}
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Table describes a table the agent can query
+type Table struct {
+	Name    string
+	Columns []string
+}
+
+// Tables lists the tables of the database with their columns and types
+func (d *Database) Tables(ctx context.Context) ([]Table, error) {
+	query := `
+		SELECT m.name, p.name || ' ' || lower(p.type)
+		FROM sqlite_master m JOIN pragma_table_info(m.name) p
+		WHERE m.type IN ('table', 'view') AND m.name NOT LIKE 'sqlite_%'
+		ORDER BY m.name, p.cid`
+	if d.postgres {
+		query = `
+			SELECT table_name, column_name || ' ' || data_type
+			FROM information_schema.columns
+			WHERE table_schema = current_schema()
+			ORDER BY table_name, ordinal_position`
+	}
+	rows, err := d.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []Table
+	for rows.Next() {
+		var name, column string
+		if err := rows.Scan(&name, &column); err != nil {
+			return nil, err
+		}
+		if len(tables) == 0 || tables[len(tables)-1].Name != name {
+			tables = append(tables, Table{Name: name})
+		}
+		tables[len(tables)-1].Columns = append(tables[len(tables)-1].Columns, column)
+	}
+	return tables, rows.Err()
+}
@@ -0,0 +1,204 @@
+package analysis
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"path"
+	"regexp"
+	"strings"
+	"temporal-ai-agent/blobstore"
+	"temporal-ai-agent/tools"
+)
+
+// maxDataBytes bounds the data handed to the code interpreter
+const maxDataBytes = 1 << 20
+
+// Artifacts stores the files written by programs, e.g. charts, and links them for users
+type Artifacts struct {
+	Store blobstore.Store
+	// BaseURL is where the API server serves the artifacts, e.g. https://agent.example.com/artifacts
+	BaseURL string
+}
+
+// ArtifactKey matches the keys artifacts are stored under: the SHA-256 of their content
+// and the extension of their type
+var ArtifactKey = regexp.MustCompile(`^[0-9a-f]{64}\.[a-z0-9]{1,8}$`)
+
+// ArtifactPath returns where an account's artifact is stored: below the account, so a link
+// only serves the account whose conversation wrote the file
+func ArtifactPath(account, key string) string {
+	if account == "" {
+		return key
+	}
+	return account + "/" + key
+}
+
+// extension matches the file extensions kept in artifact keys
+var extension = regexp.MustCompile(`^[a-z0-9]{1,8}$`)
+
+// save stores an artifact and returns its link
+func (a Artifacts) save(ctx context.Context, f File) (string, error) {
+	sum := sha256.Sum256(f.Data)
+	ext := strings.TrimPrefix(strings.ToLower(path.Ext(f.Name)), ".")
+	if !extension.MatchString(ext) {
+		ext = "bin"
+	}
+	key := hex.EncodeToString(sum[:]) + "." + ext
+	if err := a.Store.Put(ctx, ArtifactPath(tools.AccountFrom(ctx), key), f.Data); err != nil {
+		return "", fmt.Errorf("storing %s: %w", f.Name, err)
+	}
+	return strings.TrimSuffix(a.BaseURL, "/") + "/" + key, nil
+}
+
+// ContentType returns the content type an artifact is served with
+func ContentType(key string) string {
+	if t := mime.TypeByExtension(path.Ext(key)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// Tools returns the tools of the data-analysis goal: listing the tables of the database,
+// querying it read-only and running code over query results. Files the code writes are
+// stored as artifacts and returned as links.
+func Tools(db *Database, interpreter Interpreter, artifacts Artifacts) []tools.Tool {
+	t := &toolset{db: db, interpreter: interpreter, artifacts: artifacts}
+	_, mockInterpreter := interpreter.(Mock)
+	return []tools.Tool{
+		{
+			Definition: tools.Definition{
+				Name:        "list_tables",
+				Description: "Lists the tables of the analytics database with their columns and types.",
+				Parameters:  map[string]interface{}{"type": "object", "properties": map[string]interface{}{}, "required": []string{}},
+				Mock:        db.Demo,
+				Risk:        tools.RiskReadOnly,
+				Summary:     "List the tables of the analytics database",
+			},
+			Handler: t.listTables,
+		},
+		{
+			Definition: tools.Definition{
+				Name: "query_sql",
+				Description: fmt.Sprintf("Runs a single read-only SQL query (SELECT or WITH) on the analytics database and returns the rows as CSV, at most %d. "+
+					"Aggregate in SQL where possible rather than fetching raw rows.", maxRows),
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"sql": map[string]interface{}{"type": "string", "description": "The query"},
+					},
+					"required": []string{"sql"},
+				},
+				Mock:    db.Demo,
+				Risk:    tools.RiskReadOnly,
+				Summary: "Query the analytics database",
+			},
+			Handler: t.querySQL,
+		},
+		{
+			Definition: tools.Definition{
+				Name: "run_code",
+				Description: "Runs Python code in a sandbox to compute statistics or draw charts with pandas and matplotlib. " +
+					"The data argument, usually the CSV returned by query_sql, is available as data.csv. " +
+					"Print the results; save charts as PNG files in the working directory, they are returned as links.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"code": map[string]interface{}{"type": "string", "description": "Python code"},
+						"data": map[string]interface{}{"type": "string", "description": "CSV data saved as data.csv"},
+					},
+					"required": []string{"code"},
+				},
+				Mock: mockInterpreter,
+				// The code runs in a sandbox with no access to the agent's systems
+				Risk:    tools.RiskReadOnly,
+				Summary: "Run code over the data",
+			},
+			Handler: t.runCode,
+		},
+	}
+}
+
+// toolset holds what the tool handlers share
+type toolset struct {
+	db          *Database
+	interpreter Interpreter
+	artifacts   Artifacts
+}
+
+// listTables describes the tables of the database
+func (t *toolset) listTables(ctx context.Context, args map[string]interface{}) (string, error) {
+	tables, err := t.db.Tables(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(tables) == 0 {
+		return "The database has no tables.", nil
+	}
+	var b strings.Builder
+	for _, table := range tables {
+		fmt.Fprintf(&b, "%s(%s)\n", table.Name, strings.Join(table.Columns, ", "))
+	}
+	return b.String(), nil
+}
+
+// querySQL runs a read-only query. Invalid queries fail permanently, so the model rewrites
+// them rather than waiting for retries.
+func (t *toolset) querySQL(ctx context.Context, args map[string]interface{}) (string, error) {
+	query, err := tools.StringArg(args, "sql")
+	if err != nil {
+		return "", err
+	}
+	result, truncated, err := t.db.Query(ctx, query)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "", tools.Permanent(fmt.Errorf("the query took longer than %s; aggregate or filter more", queryTimeout))
+	}
+	if err != nil {
+		return "", tools.Permanent(fmt.Errorf("query failed: %w", err))
+	}
+	if truncated {
+		result += fmt.Sprintf("(only the first %d rows are shown)\n", maxRows)
+	}
+	return result, nil
+}
+
+// runCode runs code in the interpreter and links the files it wrote
+func (t *toolset) runCode(ctx context.Context, args map[string]interface{}) (string, error) {
+	code, err := tools.StringArg(args, "code")
+	if err != nil {
+		return "", err
+	}
+	program := Program{Code: code, Files: map[string]string{}}
+	if data, _ := args["data"].(string); data != "" {
+		if len(data) > maxDataBytes {
+			return "", tools.Permanent(fmt.Errorf("data is larger than %d bytes; aggregate it in SQL first", maxDataBytes))
+		}
+		program.Files["data.csv"] = data
+	}
+
+	out, err := t.interpreter.Run(ctx, program)
+	var failed *ProgramError
+	if errors.As(err, &failed) {
+		return "", tools.Permanent(err)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString(out.Stdout)
+	for _, f := range out.Files {
+		link, err := t.artifacts.save(ctx, f)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "\n%s: %s", f.Name, link)
+	}
+	if b.Len() == 0 {
+		return "The code printed nothing.", nil
+	}
+	return strings.TrimSpace(b.String()), nil
+}
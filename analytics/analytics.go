@@ -0,0 +1,194 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// Event names
+const (
+	EventTurnCompleted = "turn_completed"
+	EventToolUsed      = "tool_used"
+	EventGoalCompleted = "goal_completed"
+	EventUserSatisfied = "user_satisfied"
+)
+
+// Sink kinds
+const (
+	SinkFile    = "file"
+	SinkSegment = "segment"
+	SinkKafka   = "kafka"
+)
+
+// Event is a product analytics event emitted by a conversation
+type Event struct {
+	// ID is unique per event and stable across retries, so sinks can drop duplicates
+	ID             string                 `json:"id"`
+	Name           string                 `json:"event"`
+	ConversationID string                 `json:"conversation_id"`
+	Goal           string                 `json:"goal,omitempty"`
+	Properties     map[string]interface{} `json:"properties,omitempty"`
+	Time           time.Time              `json:"time"`
+}
+
+// Sink delivers analytics events
+type Sink interface {
+	Send(ctx context.Context, events []Event) error
+}
+
+// Config selects and configures the sink
+type Config struct {
+	// Sink is the kind of sink; empty disables analytics
+	Sink            string
+	File            string
+	SegmentWriteKey string
+	KafkaRESTURL    string
+	KafkaTopic      string
+}
+
+// New returns the configured sink, or nil when analytics are disabled
+func New(cfg Config) (Sink, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	switch cfg.Sink {
+	case "":
+		return nil, nil
+	case SinkFile:
+		return &File{Path: cfg.File}, nil
+	case SinkSegment:
+		if cfg.SegmentWriteKey == "" {
+			return nil, fmt.Errorf("the segment analytics sink requires a write key")
+		}
+		return &Segment{URL: segmentBatchURL, WriteKey: cfg.SegmentWriteKey, Client: client}, nil
+	case SinkKafka:
+		if cfg.KafkaRESTURL == "" || cfg.KafkaTopic == "" {
+			return nil, fmt.Errorf("the kafka analytics sink requires a REST proxy URL and a topic")
+		}
+		return &Kafka{URL: cfg.KafkaRESTURL, Topic: cfg.KafkaTopic, Client: client}, nil
+	default:
+		return nil, fmt.Errorf("unknown analytics sink %q", cfg.Sink)
+	}
+}
+
+// File appends events to a file as JSON lines
+type File struct {
+	Path string
+	mu   sync.Mutex
+}
+
+// Send appends the events
+func (f *File) Send(ctx context.Context, events []Event) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, event := range events {
+		if err := enc.Encode(event); err != nil {
+			return err
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file, err := os.OpenFile(f.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// segmentBatchURL is Segment's HTTP tracking API batch endpoint
+const segmentBatchURL = "https://api.segment.io/v1/batch"
+
+// Segment sends events as track calls to Segment. The conversation is the anonymous
+// user, and the event ID is the message ID Segment deduplicates on.
+type Segment struct {
+	URL      string
+	WriteKey string
+	Client   *http.Client
+}
+
+// Send posts the events in one batch
+func (s *Segment) Send(ctx context.Context, events []Event) error {
+	batch := make([]map[string]interface{}, 0, len(events))
+	for _, event := range events {
+		properties := map[string]interface{}{"goal": event.Goal}
+		for k, v := range event.Properties {
+			properties[k] = v
+		}
+		batch = append(batch, map[string]interface{}{
+			"type":        "track",
+			"messageId":   event.ID,
+			"anonymousId": event.ConversationID,
+			"event":       event.Name,
+			"properties":  properties,
+			"timestamp":   event.Time,
+		})
+	}
+	body, err := json.Marshal(map[string]interface{}{"batch": batch})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(s.WriteKey, "")
+	return send(s.Client, req, "segment")
+}
+
+// Kafka produces events to a topic through a Kafka REST proxy, keyed by conversation so
+// the events of a conversation stay ordered
+type Kafka struct {
+	URL    string
+	Topic  string
+	Client *http.Client
+}
+
+// Send produces the events
+func (k *Kafka) Send(ctx context.Context, events []Event) error {
+	records := make([]map[string]interface{}, 0, len(events))
+	for _, event := range events {
+		records = append(records, map[string]interface{}{"key": event.ConversationID, "value": event})
+	}
+	body, err := json.Marshal(map[string]interface{}{"records": records})
+	if err != nil {
+		return err
+	}
+
+	endpoint, err := url.JoinPath(k.URL, "topics", k.Topic)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	return send(k.Client, req, "kafka REST proxy")
+}
+
+// send performs a request and turns unsuccessful responses into errors
+func send(client *http.Client, req *http.Request, name string) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s returned %s: %s", name, resp.Status, msg)
+	}
+	return nil
+}
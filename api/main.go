@@ -2,285 +2,227 @@ package main
 
 import (
 	"context"
-	"crypto/tls"
-	"encoding/json"
-	"fmt"
+	"flag"
 	"log"
 	"net/http"
-	"os"
-	"strconv"
-	"temporal-ai-agent/workflows"
-	"time"
+	"temporal-ai-agent/config"
+	"temporal-ai-agent/deadletter"
+	"temporal-ai-agent/evals"
+	"temporal-ai-agent/experiments"
+	"temporal-ai-agent/failover"
+	"temporal-ai-agent/fewshot"
+	"temporal-ai-agent/goals"
+	"temporal-ai-agent/metrics"
+	"temporal-ai-agent/personas"
+	"temporal-ai-agent/plugins"
+	"temporal-ai-agent/quota"
+	"temporal-ai-agent/search"
+	"temporal-ai-agent/selftest"
+	"temporal-ai-agent/server"
+	"temporal-ai-agent/shadow"
+	"temporal-ai-agent/shedding"
+	"temporal-ai-agent/store"
+	"temporal-ai-agent/streaming"
+	"temporal-ai-agent/templates"
+	"temporal-ai-agent/tracing"
 
-	"github.com/gorilla/mux"
-	"github.com/joho/godotenv"
 	"go.temporal.io/sdk/client"
+	"google.golang.org/grpc"
 )
 
-// ChatRequest represents the request body for the /start-workflow endpoint
-type ChatRequest struct {
-	Message string `json:"message"`
-}
-
-// ChatResponse represents the response from the /start-workflow endpoint
-type ChatResponse struct {
-	WorkflowID string `json:"workflow_id"`
-	RunID      string `json:"run_id"`
-	Result     string `json:"result,omitempty"`
-	Error      string `json:"error,omitempty"`
-}
-
-// SignalRequest represents the request body for signal endpoints
-type SignalRequest struct {
-	WorkflowID string `json:"workflow_id"`
-	RunID      string `json:"run_id,omitempty"`
-	Message    string `json:"message"`
-}
-
-// SignalResponse represents the response from signal endpoints
-type SignalResponse struct {
-	Success bool   `json:"success"`
-	Error   string `json:"error,omitempty"`
-}
-
-// Server holds the HTTP server dependencies
-type Server struct {
-	temporalClient client.Client
-	taskQueue      string
-}
-
 func main() {
-	// Load environment variables from .env file
-	err := godotenv.Load()
-	if err != nil {
+	selfTest := flag.Bool("selftest", false, "check that the dependencies are reachable, print a report and exit")
+	flag.Parse()
+
+	// Load configuration from .env file and environment variables
+	cfg, found := config.Load()
+	if !found {
 		log.Println("Warning: .env file not found, using system environment variables")
 	}
 
-	// Get configuration from environment variables
-	hostPort := getEnv("TEMPORAL_HOST_PORT", "localhost:7233")
-	namespace := getEnv("TEMPORAL_NAMESPACE", "default")
-	apiKey := getEnv("TEMPORAL_API_KEY", "")
-	taskQueue := getEnv("TEMPORAL_TASK_QUEUE", "my-task-queue")
-	tlsEnabled := getEnvBool("TEMPORAL_TLS_ENABLED", false)
-	serverPort := getEnv("SERVER_PORT", "3000")
-
 	// Validate required environment variables
-	if apiKey == "" {
-		log.Fatal("TEMPORAL_API_KEY environment variable is required")
+	if err := cfg.Validate(); err != nil {
+		log.Fatal(err)
 	}
-
-	// Configure client options
-	clientOptions := client.Options{
-		HostPort:  hostPort,
-		Namespace: namespace,
+	if *selfTest {
+		selftest.Main(selftest.API(cfg))
 	}
 
-	// Configure TLS if enabled
-	if tlsEnabled {
-		clientOptions.ConnectionOptions = client.ConnectionOptions{TLS: &tls.Config{}}
-	}
-
-	// Configure credentials
-	clientOptions.Credentials = client.NewAPIKeyStaticCredentials(apiKey)
-
-	c, err := client.Dial(clientOptions)
+	// Traces are exported to the collector, if any, and flushed on exit
+	shutdownTracing, err := tracing.Setup(context.Background(), "temporal-ai-agent-api", cfg.OTLPEndpoint)
+	if err != nil {
+		log.Fatalln("Unable to set up tracing", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	// The SDK's metrics are served by /metrics with the API's; every client the failover
+	// dials records them through the same handler, and its calls through the load shedder
+	// and the tracing interceptor
+	registry := metrics.NewRegistry()
+	temporalMetrics := metrics.NewTemporal(registry)
+	shedder := shedding.New(cfg.SheddingOptions())
+	fc, err := failover.Dial(cfg.HostPort, cfg.SecondaryHostPort, func(hostPort string) client.Options {
+		options := cfg.ClientOptionsFor(hostPort)
+		options.MetricsHandler = temporalMetrics
+		options.Interceptors = append(options.Interceptors, tracing.Interceptor())
+		options.ConnectionOptions.DialOptions = append(options.ConnectionOptions.DialOptions,
+			grpc.WithChainUnaryInterceptor(shedder.Interceptor()))
+		return options
+	})
 	if err != nil {
 		log.Fatalln("Unable to create client", err)
 	}
-	defer c.Close()
+	defer fc.Close()
 
-	// Create server instance
-	server := &Server{
-		temporalClient: c,
-		taskQueue:      taskQueue,
+	exps, err := experiments.Load(cfg.ExperimentsFile)
+	if err != nil {
+		log.Fatalln("Unable to load experiments", err)
 	}
 
-	// Setup routes
-	r := mux.NewRouter()
-	r.HandleFunc("/start-workflow", server.handleStartWorkflow).Methods("POST")
-	r.HandleFunc("/signal/user-prompt", server.handleUserPromptSignal).Methods("POST")
-	r.HandleFunc("/signal/confirm", server.handleConfirmSignal).Methods("POST")
-	r.HandleFunc("/signal/end-chat", server.handleEndChatSignal).Methods("POST")
-	r.HandleFunc("/health", server.handleHealth).Methods("GET")
-
-	// Start HTTP server
-	log.Printf("Starting API server on port %s", serverPort)
-	log.Fatal(http.ListenAndServe(":"+serverPort, r))
-}
-
-// handleStartWorkflow handles POST /start-workflow requests
-func (s *Server) handleStartWorkflow(w http.ResponseWriter, r *http.Request) {
-	var req ChatRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
-		return
+	// Create server instance
+	catalog, err := personas.Load(cfg.PersonasFile)
+	if err != nil {
+		log.Fatalln("Unable to load personas", err)
 	}
 
-	if req.Message == "" {
-		http.Error(w, "Message is required", http.StatusBadRequest)
-		return
+	// Only the manifests are read: the goals list the tools plugins add, which run on the worker
+	manifests, err := plugins.LoadManifests(cfg.PluginDir)
+	if err != nil {
+		log.Fatalln("Unable to load plugin manifests", err)
 	}
-
-	// Start workflow
-	options := client.StartWorkflowOptions{
-		ID:        fmt.Sprintf("chat-workflow-%d", time.Now().UnixNano()),
-		TaskQueue: s.taskQueue,
+	goalCatalog := goals.Builtin()
+	if err := plugins.Grant(goalCatalog, manifests); err != nil {
+		log.Fatalln("Invalid plugin manifests", err)
 	}
 
-	we, err := s.temporalClient.ExecuteWorkflow(context.Background(), options, workflows.SayHelloWorkflow, req.Message)
+	flows, err := templates.Load(cfg.TemplatesFile)
 	if err != nil {
-		log.Printf("Unable to execute workflow: %v", err)
-		response := ChatResponse{
-			Error: err.Error(),
+		log.Fatalln("Unable to load templates", err)
+	}
+	if err := flows.Validate(goalCatalog, catalog); err != nil {
+		log.Fatalln("Invalid templates", err)
+	}
+
+	opts := server.Options{
+		TaskQueue:               cfg.TaskQueue,
+		PremiumTaskQueue:        cfg.PremiumTaskQueue,
+		Experiments:             exps,
+		AdminAPIKey:             cfg.AdminAPIKey,
+		OperatorAPIKey:          cfg.OperatorAPIKey,
+		IDReusePolicy:           cfg.WorkflowIDReusePolicy,
+		IDConflictPolicy:        cfg.WorkflowIDConflictPolicy,
+		ExecutionTimeout:        cfg.WorkflowExecutionTimeout,
+		StickySessions:          cfg.StickySessions,
+		AllowedModels:           cfg.AllowedModels,
+		MaxConversationsPerUser: cfg.MaxConversationsPerUser,
+		FallbackMessage:         cfg.LLMFallbackMessage,
+		TurnLatencyBudget:       cfg.TurnLatencyBudget,
+		TurnFallbackModel:       cfg.TurnFallbackModel,
+		ConfirmationTimeout:     cfg.ConfirmationTimeout,
+		ChatIdleTimeout:         cfg.ChatIdleTimeout,
+		ReceiptRenotifyAfter:    cfg.ReceiptRenotifyAfter,
+		StreamPollInterval:      cfg.StreamPollInterval,
+		Personas:                catalog,
+		Goals:                   goalCatalog,
+		Templates:               flows,
+		Metrics:                 registry,
+		Shedder:                 shedder,
+	}
+	if cfg.FewShotFile != "" {
+		opts.Examples = fewshot.NewFileStore(cfg.FewShotFile)
+	}
+	if cfg.DatabaseURL != "" {
+		index, err := search.NewPostgresIndex(context.Background(), cfg.DatabaseURL)
+		if err != nil {
+			log.Fatalln("Unable to connect to search database", err)
 		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response)
-		return
+		defer index.Close()
+		opts.Search = index
 	}
-
-	log.Printf("Started workflow: WorkflowID=%s, RunID=%s", we.GetID(), we.GetRunID())
-
-	// Get workflow result
-	var result string
-	err = we.Get(context.Background(), &result)
+	opts.Quotas, err = quota.Load(cfg.QuotaFile)
 	if err != nil {
-		log.Printf("Unable to get workflow result: %v", err)
-		response := ChatResponse{
-			WorkflowID: we.GetID(),
-			RunID:      we.GetRunID(),
-			Error:      err.Error(),
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response)
-		return
+		log.Fatalln("Unable to load quotas", err)
 	}
-
-	// Return successful response
-	response := ChatResponse{
-		WorkflowID: we.GetID(),
-		RunID:      we.GetRunID(),
-		Result:     result,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
-}
-
-// handleUserPromptSignal handles POST /signal/user-prompt requests
-func (s *Server) handleUserPromptSignal(w http.ResponseWriter, r *http.Request) {
-	var req SignalRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
-		return
-	}
-
-	if req.WorkflowID == "" {
-		http.Error(w, "WorkflowID is required", http.StatusBadRequest)
-		return
+	if opts.Quotas.Enabled() {
+		// Usage is recorded by the worker, so it must be shared through the database
+		if cfg.DatabaseURL == "" {
+			log.Fatalln("QUOTA_FILE requires DATABASE_URL to share usage with the worker")
+		}
+		usage, err := quota.NewPostgresStore(context.Background(), cfg.DatabaseURL)
+		if err != nil {
+			log.Fatalln("Unable to connect to quota database", err)
+		}
+		defer usage.Close()
+		opts.Usage = usage
 	}
-
-	err := s.temporalClient.SignalWorkflow(context.Background(), req.WorkflowID, req.RunID, "user_prompt", req.Message)
-	if err != nil {
-		log.Printf("Error sending user_prompt signal: %v", err)
-		response := SignalResponse{
-			Success: false,
-			Error:   err.Error(),
+	if cfg.DatabaseURL != "" {
+		conversations, err := store.NewPostgresStore(context.Background(), cfg.DatabaseURL)
+		if err != nil {
+			log.Fatalln("Unable to connect to conversation database", err)
 		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response)
-		return
+		defer conversations.Close()
+		opts.Conversations = conversations
+	} else if cfg.SQLitePath != "" {
+		conversations, err := store.NewSQLiteStore(context.Background(), cfg.SQLitePath)
+		if err != nil {
+			log.Fatalln("Unable to open conversation database", err)
+		}
+		defer conversations.Close()
+		opts.Conversations = conversations
 	}
+	if cfg.DatabaseURL != "" {
+		deadLetters, err := deadletter.NewPostgresStore(context.Background(), cfg.DatabaseURL)
+		if err != nil {
+			log.Fatalln("Unable to connect to dead-letter database", err)
+		}
+		defer deadLetters.Close()
+		opts.DeadLetters = deadLetters
 
-	response := SignalResponse{Success: true}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
-}
-
-// handleConfirmSignal handles POST /signal/confirm requests
-func (s *Server) handleConfirmSignal(w http.ResponseWriter, r *http.Request) {
-	var req SignalRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
-		return
+		shadows, err := shadow.NewPostgresStore(context.Background(), cfg.DatabaseURL)
+		if err != nil {
+			log.Fatalln("Unable to connect to shadow database", err)
+		}
+		defer shadows.Close()
+		opts.Shadows = shadows
 	}
-
-	if req.WorkflowID == "" {
-		http.Error(w, "WorkflowID is required", http.StatusBadRequest)
-		return
+	if cfg.EvalEnabled && cfg.DatabaseURL != "" {
+		evaluations, err := evals.NewPostgresStore(context.Background(), cfg.DatabaseURL)
+		if err != nil {
+			log.Fatalln("Unable to connect to evaluations database", err)
+		}
+		defer evaluations.Close()
+		opts.Evaluations = evaluations
 	}
-
-	err := s.temporalClient.SignalWorkflow(context.Background(), req.WorkflowID, req.RunID, "confirm", req.Message)
-	if err != nil {
-		log.Printf("Error sending confirm signal: %v", err)
-		response := SignalResponse{
-			Success: false,
-			Error:   err.Error(),
+	if cfg.RedisURL != "" {
+		streams, err := streaming.NewBridge(cfg.RedisURL)
+		if err != nil {
+			log.Fatalln("Unable to connect to Redis", err)
 		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response)
-		return
+		defer streams.Close()
+		opts.Streams = streams
 	}
-
-	response := SignalResponse{Success: true}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
-}
-
-// handleEndChatSignal handles POST /signal/end-chat requests
-func (s *Server) handleEndChatSignal(w http.ResponseWriter, r *http.Request) {
-	var req SignalRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
-		return
+	opts.Artifacts, err = cfg.Artifacts()
+	if err != nil {
+		log.Fatalln("Unable to create artifact store", err)
 	}
-
-	if req.WorkflowID == "" {
-		http.Error(w, "WorkflowID is required", http.StatusBadRequest)
-		return
+	opts.TenantKeys, err = cfg.Secrets()
+	if err != nil {
+		log.Fatalln("Unable to create secrets backend", err)
 	}
-
-	err := s.temporalClient.SignalWorkflow(context.Background(), req.WorkflowID, req.RunID, "end_chat", req.Message)
+	adapters, err := cfg.Channels()
 	if err != nil {
-		log.Printf("Error sending end_chat signal: %v", err)
-		response := SignalResponse{
-			Success: false,
-			Error:   err.Error(),
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response)
-		return
+		log.Fatalln("Unable to create channel adapters", err)
 	}
-
-	response := SignalResponse{Success: true}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
-}
-
-// handleHealth handles GET /health requests
-func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"status": "healthy"})
-}
-
-// getEnv gets an environment variable with a fallback default value
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+	opts.Channels = adapters.Names()
+	opts.ChannelSecrets, err = cfg.ChannelSigningSecrets()
+	if err != nil {
+		log.Fatalln("Unable to read channel secrets", err)
 	}
-	return defaultValue
-}
+	s := server.New(fc.Current(), opts)
+	fc.OnFailover(s.SetClient)
+	go fc.Watch(context.Background(), cfg.FailoverCheckInterval)
 
-// getEnvBool gets a boolean environment variable with a fallback default value
-func getEnvBool(key string, defaultValue bool) bool {
-	if value := os.Getenv(key); value != "" {
-		if parsed, err := strconv.ParseBool(value); err == nil {
-			return parsed
-		}
-	}
-	return defaultValue
+	// Start HTTP server
+	log.Printf("Starting API server on port %s", cfg.ServerPort)
+	log.Fatal(http.ListenAndServe(":"+cfg.ServerPort, s.Router()))
 }
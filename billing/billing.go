@@ -0,0 +1,145 @@
+package billing
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Export formats
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// Line is the usage of one account over a billing period
+type Line struct {
+	Account string `json:"account"`
+	// Plan is the quota plan of the account; empty when its API key was removed
+	Plan     string `json:"plan,omitempty"`
+	Messages int    `json:"messages"`
+	Tokens   int    `json:"tokens"`
+}
+
+// Statement is the usage of every account over a billing period
+type Statement struct {
+	// Period is the billing month, e.g. "2025-10"
+	Period      string    `json:"period"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Lines       []Line    `json:"lines"`
+}
+
+// Encode renders the statement in the given format
+func (s Statement) Encode(format string) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		return json.MarshalIndent(s, "", "  ")
+	case FormatCSV:
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		w.Write([]string{"period", "account", "plan", "messages", "tokens"})
+		for _, line := range s.Lines {
+			w.Write([]string{s.Period, line.Account, line.Plan, strconv.Itoa(line.Messages), strconv.Itoa(line.Tokens)})
+		}
+		w.Flush()
+		return buf.Bytes(), w.Error()
+	default:
+		return nil, fmt.Errorf("unknown billing export format %q", format)
+	}
+}
+
+// Exporter delivers billing statements and returns where they were delivered
+type Exporter interface {
+	Export(ctx context.Context, statement Statement, format string) (string, error)
+}
+
+// New returns an exporter posting to the billing API when a URL is configured, and one
+// writing to the export directory otherwise. It returns nil when neither is configured.
+func New(dir, apiURL, apiKey string) Exporter {
+	switch {
+	case apiURL != "":
+		return &API{URL: apiURL, APIKey: apiKey, Client: &http.Client{Timeout: 30 * time.Second}}
+	case dir != "":
+		return &Directory{Path: dir}
+	default:
+		return nil
+	}
+}
+
+// Directory writes statements as files named after their period, e.g. into a
+// mounted object storage bucket
+type Directory struct {
+	Path string
+}
+
+// Export writes the statement to <dir>/usage-<period>.<format>, replacing an earlier export
+func (d *Directory) Export(ctx context.Context, statement Statement, format string) (string, error) {
+	data, err := statement.Encode(format)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(d.Path, 0o755); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(d.Path, fmt.Sprintf("usage-%s.%s", statement.Period, format))
+	// Write to a temporary file first so readers never see a partial export
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return "", err
+	}
+	return path, os.Rename(tmp, path)
+}
+
+// API posts statements to a billing API
+type API struct {
+	URL    string
+	APIKey string
+	Client *http.Client
+}
+
+// Export posts the statement. The period is sent as an idempotency key so the billing
+// API can ignore retried or repeated exports.
+func (a *API) Export(ctx context.Context, statement Statement, format string) (string, error) {
+	data, err := statement.Encode(format)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.URL, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", contentType(format))
+	req.Header.Set("Idempotency-Key", "usage-"+statement.Period)
+	if a.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+a.APIKey)
+	}
+
+	resp, err := a.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("billing API returned %s: %s", resp.Status, msg)
+	}
+	return a.URL, nil
+}
+
+// contentType returns the MIME type of an export format
+func contentType(format string) string {
+	if format == FormatCSV {
+		return "text/csv"
+	}
+	return "application/json"
+}
@@ -0,0 +1,94 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNotFound is returned for keys the store does not have
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps blobs by key. Keys are content hashes, so writing a key again stores the
+// same bytes.
+type Store interface {
+	Put(ctx context.Context, key string, data []byte) error
+	// Get returns the blob stored under key, or ErrNotFound
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// Object describes a stored blob
+type Object struct {
+	// Key is relative to the store's prefix
+	Key string `json:"key"`
+	// ETag changes whenever the blob's content changes
+	ETag         string    `json:"etag"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// Lister is a Store that can list its blobs, to ingest a bucket's documents
+type Lister interface {
+	Store
+	// List returns every blob below the store's prefix
+	List(ctx context.Context) ([]Object, error)
+	// URL identifies a blob across stores, e.g. s3://bucket/prefix/key
+	URL(key string) string
+}
+
+// NewLister returns the store registered under the given name, like New
+func NewLister(name string, opts Options) (Lister, error) {
+	store, err := New(name, opts)
+	if err != nil {
+		return nil, err
+	}
+	return store.(Lister), nil
+}
+
+// Options locate the bucket (or directory, for the file store) holding the blobs
+type Options struct {
+	Bucket string
+	// Prefix is prepended to every key, e.g. "payloads/"
+	Prefix string
+	// Endpoint overrides the object storage endpoint, e.g. for MinIO; empty uses the provider's
+	Endpoint  string
+	Region    string
+	AccessKey string
+	SecretKey string
+	// SessionToken comes with temporary credentials, e.g. those of an assumed role
+	SessionToken string
+}
+
+// New returns the store registered under the given name: s3, gcs (through its
+// S3-compatible XML API and HMAC keys) or file
+func New(name string, opts Options) (Store, error) {
+	if opts.Bucket == "" {
+		return nil, fmt.Errorf("a bucket is required for the %s blob store", name)
+	}
+	switch name {
+	case "s3":
+		if opts.Region == "" {
+			opts.Region = "us-east-1"
+		}
+	case "gcs":
+		if opts.Region == "" {
+			opts.Region = "auto"
+		}
+		if opts.Endpoint == "" {
+			opts.Endpoint = "https://storage.googleapis.com"
+		}
+	case "file":
+		return &FileStore{Dir: opts.Bucket, Prefix: opts.Prefix}, nil
+	default:
+		return nil, fmt.Errorf("unknown blob store %q", name)
+	}
+	if (opts.AccessKey == "") != (opts.SecretKey == "") {
+		return nil, fmt.Errorf("the %s blob store needs both an access key and a secret key", name)
+	}
+	// GCS has no credential chain the SDK knows
+	if name == "gcs" && opts.AccessKey == "" {
+		return nil, fmt.Errorf("HMAC keys are required for the gcs blob store")
+	}
+	return newS3Store(name, opts)
+}
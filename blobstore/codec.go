@@ -0,0 +1,89 @@
+package blobstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/converter"
+	"google.golang.org/protobuf/proto"
+)
+
+// encodingRef marks payloads replaced by a reference to the blob holding them
+const encodingRef = "binary/blob-ref"
+
+// codecTimeout bounds a blob upload or download, which Temporal's payload codecs run
+// without a context
+const codecTimeout = 30 * time.Second
+
+// Codec is a Temporal payload codec that moves payloads larger than Threshold bytes (long
+// tool results, attachments, conversation histories) to a blob store. The event history
+// only keeps a reference to the blob, named after the hash of its content, and the
+// payload is downloaded again when the history is read.
+type Codec struct {
+	Store     Store
+	Threshold int
+}
+
+// DataConverter returns the default data converter offloading payloads through the codec
+func (c *Codec) DataConverter() converter.DataConverter {
+	return converter.NewCodecDataConverter(converter.GetDefaultDataConverter(), c)
+}
+
+// Encode uploads the oversized payloads and replaces them with references
+func (c *Codec) Encode(payloads []*commonpb.Payload) ([]*commonpb.Payload, error) {
+	result := make([]*commonpb.Payload, len(payloads))
+	for i, p := range payloads {
+		if proto.Size(p) <= c.Threshold {
+			result[i] = p
+			continue
+		}
+		data, err := proto.Marshal(p)
+		if err != nil {
+			return payloads, err
+		}
+		key := hashHex(data)
+		ctx, cancel := context.WithTimeout(context.Background(), codecTimeout)
+		err = c.Store.Put(ctx, key, data)
+		cancel()
+		if err != nil {
+			return payloads, fmt.Errorf("unable to offload payload: %w", err)
+		}
+		result[i] = &commonpb.Payload{
+			Metadata: map[string][]byte{converter.MetadataEncoding: []byte(encodingRef)},
+			Data:     []byte(key),
+		}
+	}
+	return result, nil
+}
+
+// Decode downloads the payloads that were replaced by references
+func (c *Codec) Decode(payloads []*commonpb.Payload) ([]*commonpb.Payload, error) {
+	result := make([]*commonpb.Payload, len(payloads))
+	for i, p := range payloads {
+		if string(p.Metadata[converter.MetadataEncoding]) != encodingRef {
+			result[i] = p
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), codecTimeout)
+		data, err := c.Store.Get(ctx, string(p.Data))
+		cancel()
+		if err != nil {
+			return payloads, fmt.Errorf("unable to load offloaded payload %s: %w", p.Data, err)
+		}
+		result[i] = &commonpb.Payload{}
+		if err := proto.Unmarshal(data, result[i]); err != nil {
+			return payloads, err
+		}
+	}
+	return result, nil
+}
+
+// hashHex returns the SHA-256 of the data, which names its blob
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
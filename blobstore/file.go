@@ -0,0 +1,88 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileStore keeps blobs as files in a directory. Workers and API servers must share the
+// directory (e.g. a mounted volume), so it mostly suits single-node deployments.
+type FileStore struct {
+	Dir    string
+	Prefix string
+}
+
+// Put writes the blob through a temporary file, so readers never see a partial one
+func (f *FileStore) Put(ctx context.Context, key string, data []byte) error {
+	path := f.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".blob-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Get reads the blob stored under key
+func (f *FileStore) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(f.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// List walks the directory below the prefix. ETags combine the modification time and size
+// of the files, which change whenever a file is rewritten.
+func (f *FileStore) List(ctx context.Context) ([]Object, error) {
+	var objects []Object
+	root := filepath.Join(f.Dir, filepath.FromSlash(f.Prefix))
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && path == root {
+			return filepath.SkipDir
+		}
+		if err != nil || d.IsDir() || strings.HasPrefix(d.Name(), ".blob-") {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, Object{
+			Key:          filepath.ToSlash(rel),
+			ETag:         fmt.Sprintf("%x-%x", info.ModTime().UnixNano(), info.Size()),
+			Size:         info.Size(),
+			LastModified: info.ModTime().UTC(),
+		})
+		return nil
+	})
+	return objects, err
+}
+
+// URL identifies a blob as file://dir/prefix/key
+func (f *FileStore) URL(key string) string {
+	return "file://" + filepath.ToSlash(f.path(key))
+}
+
+func (f *FileStore) path(key string) string {
+	return filepath.Join(f.Dir, filepath.FromSlash(f.Prefix+key))
+}
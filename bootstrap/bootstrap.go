@@ -0,0 +1,156 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"temporal-ai-agent/config"
+	"temporal-ai-agent/workflows"
+
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/operatorservice/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+)
+
+// searchAttributes are the custom search attributes the workflows and the API rely on
+var searchAttributes = map[string]enumspb.IndexedValueType{
+	workflows.HandoffStatusKey.GetName(): enumspb.INDEXED_VALUE_TYPE_KEYWORD,
+	workflows.UserIDKey.GetName():        enumspb.INDEXED_VALUE_TYPE_KEYWORD,
+	workflows.AccountKey.GetName():       enumspb.INDEXED_VALUE_TYPE_KEYWORD,
+}
+
+// Run provisions what a fresh namespace needs before the agent can run: the custom search
+// attributes and the schedules. Existing attributes and schedules are left untouched, so
+// it is safe to run on every startup. Both steps are attempted even if one fails.
+func Run(ctx context.Context, c client.Client, cfg config.Config) error {
+	return errors.Join(
+		ensureSearchAttributes(ctx, c, cfg.Namespace),
+		ensureBillingSchedule(ctx, c, cfg),
+		ensureDigestSchedule(ctx, c, cfg),
+		ensureBucketIngestSchedule(ctx, c, cfg),
+	)
+}
+
+// ensureSearchAttributes registers the missing custom search attributes. Temporal Cloud
+// does not allow this with namespace API keys; create them with tcld there instead.
+func ensureSearchAttributes(ctx context.Context, c client.Client, namespace string) error {
+	existing, err := c.OperatorService().ListSearchAttributes(ctx, &operatorservice.ListSearchAttributesRequest{
+		Namespace: namespace,
+	})
+	if err != nil {
+		return fmt.Errorf("listing search attributes: %w", err)
+	}
+
+	missing := map[string]enumspb.IndexedValueType{}
+	for name, valueType := range searchAttributes {
+		current, ok := existing.CustomAttributes[name]
+		if !ok {
+			missing[name] = valueType
+			continue
+		}
+		if current != valueType {
+			return fmt.Errorf("search attribute %s has type %s, expected %s", name, current, valueType)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	_, err = c.OperatorService().AddSearchAttributes(ctx, &operatorservice.AddSearchAttributesRequest{
+		Namespace:        namespace,
+		SearchAttributes: missing,
+	})
+	if err != nil {
+		return fmt.Errorf("adding search attributes: %w", err)
+	}
+	for name := range missing {
+		log.Printf("Created search attribute %s", name)
+	}
+	return nil
+}
+
+// ensureBillingSchedule creates the schedule exporting billing statements when a billing
+// exporter is configured
+func ensureBillingSchedule(ctx context.Context, c client.Client, cfg config.Config) error {
+	if cfg.BillingExportDir == "" && cfg.BillingAPIURL == "" {
+		return nil
+	}
+	_, err := c.ScheduleClient().Create(ctx, client.ScheduleOptions{
+		ID: workflows.BillingScheduleID,
+		Spec: client.ScheduleSpec{
+			CronExpressions: []string{cfg.BillingSchedule},
+		},
+		Action: &client.ScheduleWorkflowAction{
+			ID:        workflows.BillingScheduleID,
+			Workflow:  workflows.BillingExportWorkflow,
+			TaskQueue: cfg.TaskQueue,
+			Args:      []interface{}{workflows.BillingExportRequest{Format: cfg.BillingExportFormat}},
+		},
+	})
+	if errors.Is(err, temporal.ErrScheduleAlreadyRunning) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("creating billing schedule: %w", err)
+	}
+	log.Printf("Created schedule %s", workflows.BillingScheduleID)
+	return nil
+}
+
+// ensureDigestSchedule creates the schedule composing the daily digest when a digest
+// schedule is configured
+func ensureDigestSchedule(ctx context.Context, c client.Client, cfg config.Config) error {
+	if cfg.DigestSchedule == "" {
+		return nil
+	}
+	_, err := c.ScheduleClient().Create(ctx, client.ScheduleOptions{
+		ID: workflows.DigestScheduleID,
+		Spec: client.ScheduleSpec{
+			CronExpressions: []string{cfg.DigestSchedule},
+		},
+		Action: &client.ScheduleWorkflowAction{
+			ID:        workflows.DigestScheduleID,
+			Workflow:  workflows.DailyDigestWorkflow,
+			TaskQueue: cfg.TaskQueue,
+			Args:      []interface{}{workflows.DigestRequest{Tools: cfg.DigestTools}},
+		},
+	})
+	if errors.Is(err, temporal.ErrScheduleAlreadyRunning) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("creating digest schedule: %w", err)
+	}
+	log.Printf("Created schedule %s", workflows.DigestScheduleID)
+	return nil
+}
+
+// ensureBucketIngestSchedule creates the schedule ingesting the documents of a bucket when
+// bucket ingestion is configured
+func ensureBucketIngestSchedule(ctx context.Context, c client.Client, cfg config.Config) error {
+	if cfg.IngestStore == "" {
+		return nil
+	}
+	_, err := c.ScheduleClient().Create(ctx, client.ScheduleOptions{
+		ID: workflows.BucketIngestScheduleID,
+		Spec: client.ScheduleSpec{
+			CronExpressions: []string{cfg.IngestSchedule},
+		},
+		Action: &client.ScheduleWorkflowAction{
+			ID:        workflows.BucketIngestScheduleID,
+			Workflow:  workflows.BucketIngestWorkflow,
+			TaskQueue: cfg.TaskQueue,
+			Args:      []interface{}{workflows.BucketIngestRequest{Namespace: cfg.IngestNamespace}},
+		},
+	})
+	if errors.Is(err, temporal.ErrScheduleAlreadyRunning) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("creating bucket ingestion schedule: %w", err)
+	}
+	log.Printf("Created schedule %s", workflows.BucketIngestScheduleID)
+	return nil
+}
@@ -0,0 +1,134 @@
+// Package channels delivers the agent's messages to the messaging channels a conversation
+// continues on besides the API's own clients, such as a Slack thread. Conversations are
+// keyed by user, so a user who started on the web and writes from Slack joins the same
+// conversation, with its full history, and gets the replies in their Slack thread.
+package channels
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Names of the built-in channels. Web is the API's own clients, which read the replies from
+// the API and have no adapter; Slack is delivered by the Slack adapter.
+const (
+	Web   = "web"
+	Slack = "slack"
+)
+
+// ErrUnknownChannel is returned for deliveries to a channel without an adapter
+var ErrUnknownChannel = errors.New("no adapter is configured for the channel")
+
+// namePattern is what channel names look like, as they appear in API paths
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,31}$`)
+
+// Delivery is an agent message sent to a channel a conversation is linked to
+type Delivery struct {
+	// ID stays the same when delivery is retried, so receivers can drop duplicates
+	ID         string `json:"id"`
+	WorkflowID string `json:"workflow_id"`
+	Channel    string `json:"channel"`
+	// Address is where the channel delivers, as given by the channel's inbound messages,
+	// e.g. a Slack channel and thread as "C0123456789/1700000000.000100"
+	Address string `json:"address"`
+	Message string `json:"message"`
+	// MessageIndex is the position of the message in the conversation's history, which
+	// bridges acknowledge delivery and reading with
+	MessageIndex int `json:"message_index"`
+}
+
+// Adapter delivers messages on one channel
+type Adapter interface {
+	Deliver(ctx context.Context, d Delivery) error
+}
+
+// Adapters are the configured adapters by channel name
+type Adapters map[string]Adapter
+
+// New returns the adapters of the configured channels: slack when a bot token is set, and
+// a webhook adapter for each name=url item, e.g. whatsapp=https://bridge.example.com/send
+func New(slackBotToken string, webhooks []string) (Adapters, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	adapters := Adapters{}
+	if slackBotToken != "" {
+		adapters[Slack] = &SlackAdapter{Token: slackBotToken, Client: client}
+	}
+	for _, item := range webhooks {
+		name, url, ok := strings.Cut(item, "=")
+		if !ok || url == "" {
+			return nil, fmt.Errorf("invalid channel webhook %q, expected name=url", item)
+		}
+		if !namePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid channel name %q", name)
+		}
+		if name == Web {
+			return nil, fmt.Errorf("channel name %s is reserved for the API's own clients", Web)
+		}
+		if _, ok := adapters[name]; ok {
+			return nil, fmt.Errorf("channel %s is configured twice", name)
+		}
+		adapters[name] = &Webhook{URL: url, Client: client}
+	}
+	return adapters, nil
+}
+
+// Names returns the names of the configured channels, sorted
+func (a Adapters) Names() []string {
+	names := make([]string, 0, len(a))
+	for name := range a {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Deliver sends a message through the adapter of its channel
+func (a Adapters) Deliver(ctx context.Context, d Delivery) error {
+	adapter, ok := a[d.Channel]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownChannel, d.Channel)
+	}
+	return adapter.Deliver(ctx, d)
+}
+
+// Webhook posts deliveries as JSON to an HTTP endpoint bridging to the channel, with the
+// delivery ID as the Idempotency-Key header
+type Webhook struct {
+	URL    string
+	Client *http.Client
+}
+
+// Deliver posts the delivery to the endpoint
+func (h *Webhook) Deliver(ctx context.Context, d Delivery) error {
+	body, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", d.ID)
+
+	resp, err := h.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s webhook returned %s: %s", d.Channel, resp.Status, msg)
+	}
+	return nil
+}
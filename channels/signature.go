@@ -0,0 +1,69 @@
+package channels
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers of the messages bridges post to the API. The signature is "v1=" followed by the
+// hex HMAC-SHA256, keyed with the bridge's secret, of "v1:<timestamp>:<body>", where the
+// timestamp is the Unix time of the request in seconds.
+const (
+	SignatureHeader = "X-Channel-Signature"
+	TimestampHeader = "X-Channel-Timestamp"
+)
+
+// maxSignatureAge is how old a signed message may be, which bounds the replay of one
+const maxSignatureAge = 5 * time.Minute
+
+// ErrBadSignature is returned for messages whose signature does not verify
+var ErrBadSignature = errors.New("invalid channel signature")
+
+// ParseSecrets reads name=secret items into the shared secrets of the channels' bridges
+func ParseSecrets(items []string) (map[string]string, error) {
+	secrets := map[string]string{}
+	for _, item := range items {
+		name, secret, ok := strings.Cut(item, "=")
+		if !ok || secret == "" {
+			return nil, fmt.Errorf("invalid channel secret for %q, expected name=secret", name)
+		}
+		if !namePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid channel name %q", name)
+		}
+		if _, ok := secrets[name]; ok {
+			return nil, fmt.Errorf("channel %s has two secrets", name)
+		}
+		secrets[name] = secret
+	}
+	return secrets, nil
+}
+
+// Sign returns the signature of a message a bridge posts at the given time
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v1:%d:", timestamp.Unix())
+	mac.Write(body)
+	return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature and timestamp headers of a message posted by a bridge
+func Verify(secret, timestamp, signature string, body []byte, now time.Time) error {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: missing or invalid timestamp", ErrBadSignature)
+	}
+	at := time.Unix(seconds, 0)
+	if age := now.Sub(at); age > maxSignatureAge || age < -maxSignatureAge {
+		return fmt.Errorf("%w: timestamp is too far from now", ErrBadSignature)
+	}
+	if !hmac.Equal([]byte(signature), []byte(Sign(secret, at, body))) {
+		return ErrBadSignature
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package channels
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// slackAPIURL is the base URL of the Slack Web API
+const slackAPIURL = "https://slack.com/api"
+
+// SlackAdapter posts messages with a Slack app's bot token. Addresses are a channel ID,
+// optionally followed by a slash and the timestamp of the thread to reply in.
+type SlackAdapter struct {
+	Token  string
+	Client *http.Client
+	// BaseURL overrides the Slack Web API URL, for tests
+	BaseURL string
+}
+
+// Deliver posts the message to the channel or thread of the address
+func (s *SlackAdapter) Deliver(ctx context.Context, d Delivery) error {
+	channel, thread, _ := strings.Cut(d.Address, "/")
+	if channel == "" {
+		return fmt.Errorf("slack address %q has no channel", d.Address)
+	}
+	body, err := json.Marshal(map[string]string{
+		"channel":   channel,
+		"thread_ts": thread,
+		"text":      d.Message,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cmp.Or(s.BaseURL, slackAPIURL)+"/chat.postMessage", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+s.Token)
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack chat.postMessage returned %s", resp.Status)
+	}
+	// Slack reports errors in the body of 200 responses
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("reading slack response: %w", err)
+	}
+	if !result.OK {
+		return fmt.Errorf("slack chat.postMessage failed: %s", result.Error)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+// Package chunking splits documents into the passages indexed for retrieval. Each document
+// type gets the strategy that keeps its passages meaningful: prose breaks between sentences,
+// markdown between sections and code between declarations.
+package chunking
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Strategies
+const (
+	// Fixed cuts windows of a fixed size, at whitespace when possible
+	Fixed = "fixed"
+	// Sentence packs whole sentences
+	Sentence = "sentence"
+	// Markdown packs sections, prefixing each passage with the headings it falls under
+	Markdown = "markdown"
+	// Code packs top-level declarations
+	Code = "code"
+)
+
+// Document types
+const (
+	TypeText     = "text"
+	TypeMarkdown = "markdown"
+	TypeCode     = "code"
+)
+
+// Options size the passages of every strategy
+type Options struct {
+	// Size is the maximum length of a passage in characters
+	Size int
+	// Overlap is how many characters of a passage are repeated at the start of the next,
+	// so facts cut at a boundary stay retrievable
+	Overlap int
+}
+
+// Chunker splits a document into passages
+type Chunker interface {
+	Split(text string) []string
+}
+
+// New returns the chunker of a strategy
+func New(strategy string, opts Options) (Chunker, error) {
+	if opts.Size <= 0 {
+		return nil, fmt.Errorf("chunk size must be positive")
+	}
+	if opts.Overlap < 0 || opts.Overlap >= opts.Size {
+		return nil, fmt.Errorf("chunk overlap must be between 0 and the chunk size")
+	}
+	switch strategy {
+	case Fixed:
+		return fixed{opts}, nil
+	case Sentence:
+		return sentence{opts}, nil
+	case Markdown:
+		return markdown{opts}, nil
+	case Code:
+		return code{opts}, nil
+	default:
+		return nil, fmt.Errorf("unknown chunking strategy %q", strategy)
+	}
+}
+
+// DefaultStrategies maps the document types to the strategy suiting them
+func DefaultStrategies() map[string]string {
+	return map[string]string{
+		TypeText:     Sentence,
+		TypeMarkdown: Markdown,
+		TypeCode:     Code,
+	}
+}
+
+// ParseStrategies parses type=strategy overrides on top of the default strategies
+func ParseStrategies(overrides []string) (map[string]string, error) {
+	strategies := DefaultStrategies()
+	for _, item := range overrides {
+		docType, strategy, ok := strings.Cut(item, "=")
+		docType, strategy = strings.TrimSpace(docType), strings.TrimSpace(strategy)
+		if !ok || docType == "" || strategy == "" {
+			return nil, fmt.Errorf("invalid chunking strategy %q, expected type=strategy", item)
+		}
+		strategies[docType] = strategy
+	}
+	return strategies, nil
+}
+
+// codeExtensions are the file extensions of source code
+var codeExtensions = map[string]bool{
+	".go": true, ".py": true, ".js": true, ".ts": true, ".java": true, ".rb": true, ".rs": true,
+	".c": true, ".h": true, ".cpp": true, ".cs": true, ".kt": true, ".swift": true, ".php": true,
+	".sh": true, ".sql": true,
+}
+
+// TypeOf infers the document type from a file name, defaulting to text
+func TypeOf(name string) string {
+	ext := strings.ToLower(path.Ext(name))
+	switch {
+	case ext == ".md" || ext == ".markdown":
+		return TypeMarkdown
+	case codeExtensions[ext]:
+		return TypeCode
+	default:
+		return TypeText
+	}
+}
+
+// Selector picks the chunker of each document type
+type Selector struct {
+	byType   map[string]Chunker
+	fallback Chunker
+}
+
+// NewSelector builds the chunkers of a type-to-strategy mapping. Types without a strategy
+// are cut into fixed-size windows.
+func NewSelector(strategies map[string]string, opts Options) (*Selector, error) {
+	fallback, err := New(Fixed, opts)
+	if err != nil {
+		return nil, err
+	}
+	s := &Selector{byType: map[string]Chunker{}, fallback: fallback}
+	for docType, strategy := range strategies {
+		chunker, err := New(strategy, opts)
+		if err != nil {
+			return nil, fmt.Errorf("document type %q: %w", docType, err)
+		}
+		s.byType[docType] = chunker
+	}
+	return s, nil
+}
+
+// For returns the chunker of a document type
+func (s *Selector) For(docType string) Chunker {
+	if chunker, ok := s.byType[docType]; ok {
+		return chunker
+	}
+	return s.fallback
+}
@@ -0,0 +1,71 @@
+package chunking
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+)
+
+func split(t *testing.T, strategy string, opts Options, text string) []string {
+	c, err := New(strategy, opts)
+	require.NoError(t, err)
+	return c.Split(text)
+}
+
+func TestFixedBreaksAtWhitespace(t *testing.T) {
+	text := "aaaa bbbb cccc dddd"
+	require.Equal(t, []string{"aaaa bbbb", "cccc dddd"}, split(t, Fixed, Options{Size: 10}, text))
+	require.Equal(t, []string{"aaaa bbbb", "bbbb cccc", "cccc dddd"},
+		split(t, Fixed, Options{Size: 10, Overlap: 5}, text), "the overlap starts at a word")
+	require.Equal(t, []string{"abcde", "fghij", "k"}, split(t, Fixed, Options{Size: 5}, "abcdefghijk"),
+		"words longer than a passage are cut")
+}
+
+func TestSentencesAreNotCut(t *testing.T) {
+	text := "One two. Three four. Five six."
+	require.Equal(t, []string{"One two. Three four.", "Five six."}, split(t, Sentence, Options{Size: 20}, text))
+	require.Equal(t, []string{"One two. Three four.", "Three four. Five six."},
+		split(t, Sentence, Options{Size: 22, Overlap: 11}, text), "whole sentences overlap")
+
+	long := "Short. " + strings.Repeat("word ", 10) + "end."
+	chunks := split(t, Sentence, Options{Size: 20}, long)
+	require.Equal(t, "Short.", chunks[0], "a sentence longer than a passage is cut on its own")
+	for _, chunk := range chunks[1:] {
+		require.LessOrEqual(t, utf8.RuneCountInString(chunk), 20)
+	}
+}
+
+func TestMarkdownSectionsKeepHeadings(t *testing.T) {
+	text := "# Guide\n\nIntro text.\n\n## Install\n\n```\ngo get x\n\ngo run x\n```\n\nDone."
+	require.Equal(t, []string{
+		"Guide\n\nIntro text.",
+		"Guide > Install\n\n```\ngo get x\n\ngo run x\n```\n\nDone.",
+	}, split(t, Markdown, Options{Size: 100}, text), "blank lines in a fence do not end its block")
+}
+
+func TestCodeBreaksBetweenDeclarations(t *testing.T) {
+	text := "package x\n\nfunc a() {\n\tx := 1\n\n\treturn x\n}\n\nfunc b() {\n}\n"
+	require.Equal(t, []string{"package x", "func a() {\n\tx := 1\n\n\treturn x\n}", "func b() {\n}"},
+		split(t, Code, Options{Size: 40}, text), "blank lines inside a declaration do not end it")
+}
+
+func TestPassagesFitTheSize(t *testing.T) {
+	text := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 20)
+	for _, strategy := range []string{Fixed, Sentence, Code} {
+		for _, chunk := range split(t, strategy, Options{Size: 64, Overlap: 16}, text) {
+			require.LessOrEqual(t, utf8.RuneCountInString(chunk), 64, strategy)
+			require.NotEmpty(t, strings.TrimSpace(chunk), strategy)
+		}
+	}
+}
+
+func TestInvalidOptions(t *testing.T) {
+	_, err := New(Fixed, Options{Size: 0})
+	require.Error(t, err)
+	_, err = New(Fixed, Options{Size: 10, Overlap: 10})
+	require.Error(t, err)
+	_, err = New("paragraph", Options{Size: 10})
+	require.Error(t, err)
+}
@@ -0,0 +1,349 @@
+package chunking
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// fixed cuts windows of the chunk size
+type fixed struct{ Options }
+
+func (c fixed) Split(text string) []string {
+	return window(text, c.Options)
+}
+
+// sentence packs whole sentences, cutting only sentences longer than a passage
+type sentence struct{ Options }
+
+func (c sentence) Split(text string) []string {
+	return packSentences(text, c.Options)
+}
+
+// markdown packs the blocks of each section under the headings leading to it. Fenced code
+// blocks are never cut while they fit in a passage.
+type markdown struct{ Options }
+
+func (c markdown) Split(text string) []string {
+	var chunks []string
+	for _, s := range sections(text) {
+		prefix := strings.Join(s.headings, " > ")
+		opts := c.Options
+		if prefix != "" {
+			// The headings take room in each passage, but never more than half of it
+			opts.Size = max(c.Size-utf8.RuneCountInString(prefix)-2, c.Size/2)
+			opts.Overlap = min(c.Overlap, opts.Size-1)
+		}
+		p := packer{opts: opts, sep: "\n\n", split: func(block string) []string {
+			if isFence(block) {
+				return packLines(block, opts)
+			}
+			return packSentences(block, opts)
+		}}
+		for _, block := range s.blocks {
+			p.add(block)
+		}
+		for _, chunk := range p.finish() {
+			if prefix != "" {
+				chunk = prefix + "\n\n" + chunk
+			}
+			chunks = append(chunks, chunk)
+		}
+	}
+	return chunks
+}
+
+// code packs top-level declarations, cutting long ones between lines
+type code struct{ Options }
+
+func (c code) Split(text string) []string {
+	p := packer{opts: c.Options, sep: "\n\n", split: func(block string) []string {
+		return packLines(block, c.Options)
+	}}
+	for _, block := range declarations(text) {
+		p.add(block)
+	}
+	return p.finish()
+}
+
+// packSentences packs the sentences of a text
+func packSentences(text string, opts Options) []string {
+	p := packer{opts: opts, sep: " ", split: func(s string) []string { return window(s, opts) }}
+	for _, s := range sentences(text) {
+		p.add(s)
+	}
+	return p.finish()
+}
+
+// packLines packs the lines of a text
+func packLines(text string, opts Options) []string {
+	p := packer{opts: opts, sep: "\n", split: func(s string) []string { return window(s, opts) }}
+	for _, line := range strings.Split(strings.Trim(text, "\n"), "\n") {
+		p.add(line)
+	}
+	return p.finish()
+}
+
+// packer greedily joins pieces into passages of at most the chunk size, starting each
+// passage with the trailing pieces of the previous one that fit in the overlap
+type packer struct {
+	opts Options
+	sep  string
+	// split cuts a piece longer than a passage into passages
+	split func(string) []string
+
+	chunks  []string
+	current []string
+}
+
+// add appends a piece, closing the current passage when the piece does not fit
+func (p *packer) add(piece string) {
+	if strings.TrimSpace(piece) == "" {
+		return
+	}
+	n := utf8.RuneCountInString(piece)
+	if n > p.opts.Size {
+		p.flush(false)
+		p.chunks = append(p.chunks, p.split(piece)...)
+		return
+	}
+	if len(p.current) > 0 && p.length()+len(p.sep)+n > p.opts.Size {
+		p.flush(true)
+		for len(p.current) > 0 && p.length()+len(p.sep)+n > p.opts.Size {
+			p.current = p.current[1:]
+		}
+	}
+	p.current = append(p.current, piece)
+}
+
+// flush closes the current passage, carrying its overlapping tail over when asked to
+func (p *packer) flush(carry bool) {
+	if len(p.current) == 0 {
+		return
+	}
+	p.chunks = append(p.chunks, strings.Join(p.current, p.sep))
+	if !carry {
+		p.current = nil
+		return
+	}
+	keep, total := len(p.current), 0
+	for keep > 1 && total+utf8.RuneCountInString(p.current[keep-1]) <= p.opts.Overlap {
+		total += utf8.RuneCountInString(p.current[keep-1])
+		keep--
+	}
+	if keep == len(p.current) {
+		p.current = nil
+		return
+	}
+	p.current = append([]string(nil), p.current[keep:]...)
+}
+
+// length is the length of the current passage
+func (p *packer) length() int {
+	n := 0
+	for i, piece := range p.current {
+		if i > 0 {
+			n += len(p.sep)
+		}
+		n += utf8.RuneCountInString(piece)
+	}
+	return n
+}
+
+// finish closes the last passage and returns them all
+func (p *packer) finish() []string {
+	p.flush(false)
+	return p.chunks
+}
+
+// window cuts text into windows of the chunk size overlapping by at most the chunk
+// overlap, breaking at whitespace when there is some past the overlap
+func window(text string, opts Options) []string {
+	runes := []rune(strings.TrimSpace(text))
+	var chunks []string
+	for start := 0; start < len(runes); {
+		end := min(start+opts.Size, len(runes))
+		if end < len(runes) {
+			for i := end; i > start+opts.Overlap; i-- {
+				if unicode.IsSpace(runes[i]) {
+					end = i
+					break
+				}
+			}
+		}
+		if chunk := strings.TrimSpace(string(runes[start:end])); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		if end == len(runes) {
+			break
+		}
+		next := max(end-opts.Overlap, start+1)
+		// Start the overlap at a word boundary
+		for i := next; i < end; i++ {
+			if unicode.IsSpace(runes[i-1]) {
+				next = i
+				break
+			}
+		}
+		start = next
+	}
+	return chunks
+}
+
+// sentences splits text into sentences, normalizing whitespace. Paragraph ends always end
+// a sentence.
+func sentences(text string) []string {
+	var out []string
+	for _, para := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		var words []string
+		for _, w := range strings.Fields(para) {
+			words = append(words, w)
+			if strings.ContainsAny(lastRune(strings.TrimRight(w, `"')]`)), ".!?") {
+				out = append(out, strings.Join(words, " "))
+				words = nil
+			}
+		}
+		if len(words) > 0 {
+			out = append(out, strings.Join(words, " "))
+		}
+	}
+	return out
+}
+
+// section is a markdown section: the headings leading to it and its blocks
+type section struct {
+	headings []string
+	blocks   []string
+}
+
+// sections splits markdown into sections at headings outside fenced code blocks, and each
+// section into blocks separated by blank lines
+func sections(text string) []section {
+	var (
+		out      []section
+		headings []string
+		current  section
+		block    []string
+		fenced   bool
+	)
+	endBlock := func() {
+		if len(block) > 0 {
+			current.blocks = append(current.blocks, strings.Join(block, "\n"))
+			block = nil
+		}
+	}
+	endSection := func() {
+		endBlock()
+		if len(current.blocks) > 0 {
+			out = append(out, current)
+		}
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			if !fenced {
+				endBlock()
+			}
+			block = append(block, line)
+			if fenced {
+				endBlock()
+			}
+			fenced = !fenced
+		case fenced:
+			block = append(block, line)
+		case headingLevel(trimmed) > 0:
+			endSection()
+			level := headingLevel(trimmed)
+			if len(headings) >= level {
+				headings = headings[:level-1]
+			}
+			for len(headings) < level-1 {
+				headings = append(headings, "")
+			}
+			headings = append(headings, strings.TrimSpace(strings.TrimLeft(trimmed, "#")))
+			current = section{headings: nonEmpty(headings)}
+		case trimmed == "":
+			endBlock()
+		default:
+			block = append(block, line)
+		}
+	}
+	endSection()
+	return out
+}
+
+// headingLevel is the level of an ATX heading line, or 0 for other lines
+func headingLevel(line string) int {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || (level < len(line) && line[level] != ' ') {
+		return 0
+	}
+	return level
+}
+
+// isFence reports whether a markdown block is a fenced code block
+func isFence(block string) bool {
+	trimmed := strings.TrimSpace(block)
+	return strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")
+}
+
+// declarations splits source code at blank lines followed by an unindented line, which
+// start a top-level declaration (with its leading comment) in most languages
+func declarations(text string) []string {
+	var (
+		out   []string
+		block []string
+		blank bool
+	)
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			blank = true
+			continue
+		}
+		if blank && len(block) > 0 {
+			if startsUnindented(line) {
+				out = append(out, strings.Join(block, "\n"))
+				block = nil
+			} else {
+				block = append(block, "")
+			}
+		}
+		blank = false
+		block = append(block, line)
+	}
+	if len(block) > 0 {
+		out = append(out, strings.Join(block, "\n"))
+	}
+	return out
+}
+
+// startsUnindented reports whether a line starts at the first column with something other
+// than a closing bracket
+func startsUnindented(line string) bool {
+	r, _ := utf8.DecodeRuneInString(line)
+	return !unicode.IsSpace(r) && !strings.ContainsRune(")]}", r)
+}
+
+// lastRune returns the last character of s as a string
+func lastRune(s string) string {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	if r == utf8.RuneError {
+		return ""
+	}
+	return string(r)
+}
+
+// nonEmpty returns the non-empty strings of a list
+func nonEmpty(list []string) []string {
+	var out []string
+	for _, s := range list {
+		if s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"log"
+	"temporal-ai-agent/bootstrap"
+	"temporal-ai-agent/config"
+
+	"go.temporal.io/sdk/client"
+)
+
+// The bootstrap binary provisions the search attributes and schedules the agent needs in
+// the configured namespace, e.g. as a deployment step, and exits.
+func main() {
+	cfg, found := config.Load()
+	if !found {
+		log.Println("Warning: .env file not found, using system environment variables")
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	c, err := client.Dial(cfg.ClientOptions())
+	if err != nil {
+		log.Fatalln("Unable to create client", err)
+	}
+	defer c.Close()
+
+	if err := bootstrap.Run(context.Background(), c, cfg); err != nil {
+		log.Fatalln("Namespace bootstrap failed:", err)
+	}
+	log.Printf("Namespace %s is ready", cfg.Namespace)
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"temporal-ai-agent/bootstrap"
+	"temporal-ai-agent/config"
+	"temporal-ai-agent/metrics"
+	"temporal-ai-agent/quota"
+	"temporal-ai-agent/registry"
+	"temporal-ai-agent/secrets"
+	"temporal-ai-agent/server"
+	"temporal-ai-agent/shedding"
+	"temporal-ai-agent/tracing"
+
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/worker"
+	"google.golang.org/grpc"
+)
+
+// The dev binary runs the worker and the API server in one process against a
+// local Temporal dev server (`temporal server start-dev`). It does not read the
+// .env file so cloud credentials never leak into local runs, and it uses the
+// mock LLM unless LLM_PROVIDER is set explicitly.
+func main() {
+	cfg := config.FromEnv()
+	if cfg.LLMProvider == "" {
+		cfg.LLMProvider = "mock"
+	}
+
+	acts, err := registry.NewActivities(cfg)
+	if err != nil {
+		log.Fatalln("Unable to create activities", err)
+	}
+	if err := registry.ConfigureWorkflows(cfg); err != nil {
+		log.Fatalln("Unable to configure workflows", err)
+	}
+
+	// Dev mode skips Validate, but a misconfigured blob store would silently keep large payloads
+	if _, err := cfg.PayloadCodec(); err != nil {
+		log.Fatalln("Invalid blob store", err)
+	}
+
+	// Traces are exported to the collector, if any, and flushed on exit
+	shutdownTracing, err := tracing.Setup(context.Background(), "temporal-ai-agent-dev", cfg.OTLPEndpoint)
+	if err != nil {
+		log.Fatalln("Unable to set up tracing", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	// The worker's and the API server's Temporal metrics, and the backlog of the task queues,
+	// are served by /metrics with the API's. The client is shared, so the worker's calls count
+	// toward the error rate of the load shedder too.
+	metricsRegistry := metrics.NewRegistry()
+	shedder := shedding.New(cfg.SheddingOptions())
+	options := cfg.ClientOptions()
+	options.MetricsHandler = metrics.NewTemporal(metricsRegistry)
+	options.Interceptors = append(options.Interceptors, tracing.Interceptor())
+	options.ConnectionOptions.DialOptions = append(options.ConnectionOptions.DialOptions,
+		grpc.WithChainUnaryInterceptor(shedder.Interceptor()))
+	c, err := client.Dial(options)
+	if err != nil {
+		log.Fatalln("Unable to create client (is `temporal server start-dev` running?)", err)
+	}
+	defer c.Close()
+
+	plans, err := quota.Load(cfg.QuotaFile)
+	if err != nil {
+		log.Fatalln("Unable to load quotas", err)
+	}
+
+	// Provision the namespace on first use; the agent still runs without search attributes,
+	// only the features listing conversations by them fail
+	if cfg.BootstrapNamespace {
+		if err := bootstrap.Run(context.Background(), c, cfg); err != nil {
+			log.Printf("Warning: namespace bootstrap incomplete: %v", err)
+		}
+	}
+
+	// Pay the providers' cold start before the first turn does
+	if cfg.LLMWarmup {
+		if err := registry.Warmup(context.Background(), acts, cfg.LLMWarmupTimeout); err != nil {
+			log.Printf("Warning: LLM warmup incomplete: %v", err)
+		}
+	}
+
+	lanes := registry.Lanes(cfg)
+	workers, err := registry.Start(c, lanes, acts)
+	if err != nil {
+		log.Fatalln("Unable to start worker", err)
+	}
+	defer registry.Stop(workers)
+	metricsRegistry.MustRegister(metrics.NewBacklog(cfg.Namespace, func() client.Client { return c }, registry.TaskQueues(lanes)...))
+
+	artifacts, err := cfg.Artifacts()
+	if err != nil {
+		log.Fatalln("Unable to create artifact store", err)
+	}
+	channelSecrets, err := cfg.ChannelSigningSecrets()
+	if err != nil {
+		log.Fatalln("Unable to read channel secrets", err)
+	}
+	// The API server stores the keys tenants bring where the worker reads them
+	var tenantKeys secrets.Store
+	if acts.TenantLLM != nil {
+		tenantKeys = acts.TenantLLM.Secrets
+	}
+	s := server.New(c, server.Options{
+		TaskQueue:               cfg.TaskQueue,
+		PremiumTaskQueue:        cfg.PremiumTaskQueue,
+		Experiments:             acts.Experiments,
+		Examples:                acts.Examples,
+		AdminAPIKey:             cfg.AdminAPIKey,
+		OperatorAPIKey:          cfg.OperatorAPIKey,
+		IDReusePolicy:           cfg.WorkflowIDReusePolicy,
+		IDConflictPolicy:        cfg.WorkflowIDConflictPolicy,
+		ExecutionTimeout:        cfg.WorkflowExecutionTimeout,
+		StickySessions:          cfg.StickySessions,
+		AllowedModels:           cfg.AllowedModels,
+		MaxConversationsPerUser: cfg.MaxConversationsPerUser,
+		FallbackMessage:         cfg.LLMFallbackMessage,
+		TurnLatencyBudget:       cfg.TurnLatencyBudget,
+		TurnFallbackModel:       cfg.TurnFallbackModel,
+		ConfirmationTimeout:     cfg.ConfirmationTimeout,
+		ChatIdleTimeout:         cfg.ChatIdleTimeout,
+		ReceiptRenotifyAfter:    cfg.ReceiptRenotifyAfter,
+		StreamPollInterval:      cfg.StreamPollInterval,
+		Personas:                acts.Personas,
+		Goals:                   acts.Goals,
+		Templates:               acts.Templates,
+		Search:                  acts.Search,
+		Quotas:                  plans,
+		Usage:                   acts.Usage,
+		Evaluations:             acts.Evaluations,
+		Streams:                 acts.Streams,
+		Conversations:           acts.Conversations,
+		DeadLetters:             acts.DeadLetters,
+		Shadows:                 acts.Shadows,
+		TenantKeys:              tenantKeys,
+		Channels:                acts.Channels.Names(),
+		ChannelSecrets:          channelSecrets,
+		Artifacts:               artifacts,
+		Metrics:                 metricsRegistry,
+		Shedder:                 shedder,
+	})
+	httpServer := &http.Server{Addr: ":" + cfg.ServerPort, Handler: s.Router()}
+
+	go func() {
+		<-worker.InterruptCh()
+		httpServer.Shutdown(context.Background())
+	}()
+
+	log.Printf("Dev mode: Temporal=%s namespace=%s llm=%s", cfg.HostPort, cfg.Namespace, cfg.LLMProvider)
+	log.Printf("Starting API server on port %s", cfg.ServerPort)
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatalln("Unable to start API server", err)
+	}
+}
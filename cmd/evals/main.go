@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"temporal-ai-agent/activities"
+	"temporal-ai-agent/config"
+	"temporal-ai-agent/evals"
+	"temporal-ai-agent/experiments"
+	"temporal-ai-agent/models"
+	"temporal-ai-agent/registry"
+	"temporal-ai-agent/tools"
+	"temporal-ai-agent/workflows"
+	"time"
+
+	sdklog "go.temporal.io/sdk/log"
+	"go.temporal.io/sdk/testsuite"
+)
+
+// caseTimeout bounds the real time spent replaying one case
+const caseTimeout = 5 * time.Minute
+
+// The evals binary replays a corpus of recorded conversations through the agent workflow,
+// in process and without a Temporal server, and reports answer-quality metrics. Tools are
+// restricted to mock tools. Compare against the report of the current model and prompt
+// to catch regressions before rolling out a change.
+func main() {
+	corpus := flag.String("corpus", "", "JSON file of the conversations to replay (required)")
+	model := flag.String("model", "", "model answering the conversations (default: the provider's default)")
+	promptFile := flag.String("prompt", "", "file holding a system prompt replacing the current template")
+	judgeModel := flag.String("judge-model", "", "model scoring the conversations (default: EVAL_MODEL)")
+	out := flag.String("out", "", "file the JSON report is written to")
+	baseline := flag.String("baseline", "", "report of an earlier run to compare against")
+	tolerance := flag.Float64("tolerance", 0.25, "largest drop of an average score that is not a regression")
+	flag.Parse()
+	if *corpus == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cases, err := evals.LoadCorpus(*corpus)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cfg, _ := config.Load()
+	// Replays must not touch shared databases, streams or analytics
+	cfg.DatabaseURL = ""
+	cfg.AnalyticsSink = ""
+	cfg.RedisURL = ""
+	cfg.EvalEnabled = true
+	if *judgeModel != "" {
+		cfg.EvalModel = *judgeModel
+	}
+	acts, err := registry.NewActivities(cfg)
+	if err != nil {
+		log.Fatalln("Unable to create activities", err)
+	}
+	acts.ToolPolicies = tools.Policies{cfg.Environment: {tools.AnyGoal: {MockOnly: true}}}
+
+	// The candidate model and prompt are applied as the only variant of an experiment
+	variant := experiments.Variant{Name: "candidate", Weight: 1, Model: *model}
+	if *promptFile != "" {
+		prompt, err := os.ReadFile(*promptFile)
+		if err != nil {
+			log.Fatalln("Unable to read prompt", err)
+		}
+		variant.SystemPrompt = string(prompt)
+	}
+	acts.Experiments = []experiments.Experiment{{Name: "evals", Active: true, Variants: []experiments.Variant{variant}}}
+
+	results := make([]evals.CaseResult, 0, len(cases))
+	for _, c := range cases {
+		result := runCase(acts, c)
+		results = append(results, result)
+		printResult(result)
+	}
+	report := evals.NewReport(*model, results)
+	s := report.Summary
+	fmt.Printf("\n%d cases, %.0f%% passed, helpfulness %.2f, goal completion %.2f, safety %.2f (%d scored)\n",
+		s.Cases, s.PassRate*100, s.Helpfulness, s.GoalCompletion, s.Safety, s.Scored)
+
+	if *out != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(*out, data, 0o644); err != nil {
+			log.Fatalln("Unable to write report", err)
+		}
+	}
+
+	if *baseline != "" {
+		before, err := evals.LoadReport(*baseline)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if regressions := evals.Compare(before, report, *tolerance); len(regressions) > 0 {
+			fmt.Println("\nRegressions:")
+			for _, r := range regressions {
+				fmt.Println("  -", r)
+			}
+			os.Exit(1)
+		}
+		fmt.Println("No regressions against the baseline")
+	}
+}
+
+// runCase replays a case through the conversation workflow in a test environment. User
+// turns are a minute apart in workflow time; tool calls awaiting confirmation are approved.
+func runCase(acts *activities.Activities, c evals.Case) evals.CaseResult {
+	var suite testsuite.WorkflowTestSuite
+	suite.SetLogger(sdklog.NewStructuredLogger(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))))
+	env := suite.NewTestWorkflowEnvironment()
+	env.SetTestTimeout(caseTimeout)
+	registry.Register(env, acts)
+
+	for i, turn := range c.Turns {
+		at := time.Duration(i+1) * time.Minute
+		env.RegisterDelayedCallback(func() {
+			env.SignalWorkflow(workflows.SignalUserPrompt, workflows.UserPrompt{Message: turn})
+		}, at)
+		env.RegisterDelayedCallback(func() { approvePending(env) }, at+30*time.Second)
+	}
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow("end_chat", "evals")
+	}, time.Duration(len(c.Turns)+1)*time.Minute)
+
+	env.ExecuteWorkflow(workflows.AgentGoalWorkflow, workflows.ConversationInput{AgentInput: models.AgentInput{
+		Goal:    c.Goal,
+		Context: c.Context,
+	}})
+	if err := env.GetWorkflowError(); err != nil {
+		return evals.CaseResult{Name: c.Name, Error: err.Error()}
+	}
+
+	var transcript workflows.Transcript
+	value, err := env.QueryWorkflow(workflows.QueryTranscript)
+	if err == nil {
+		err = value.Get(&transcript)
+	}
+	if err != nil {
+		return evals.CaseResult{Name: c.Name, Error: err.Error()}
+	}
+	result := evals.Check(c, transcript.Messages)
+	result.Scores = transcript.Evaluation
+	return result
+}
+
+// approvePending approves the tool call awaiting confirmation, if any, typing its
+// confirmation phrase when it needs one
+func approvePending(env *testsuite.TestWorkflowEnvironment) {
+	value, err := env.QueryWorkflow(workflows.QueryPendingConfirmation)
+	if err != nil {
+		return
+	}
+	var pending *workflows.PendingConfirmation
+	if value.Get(&pending) == nil && pending != nil {
+		env.SignalWorkflow(workflows.SignalConfirm, workflows.ConfirmRequest{
+			Decision: workflows.DecisionApprove,
+			Phrase:   pending.Phrase,
+		})
+	}
+}
+
+// printResult prints one line per case
+func printResult(r evals.CaseResult) {
+	status := "PASS"
+	if !r.Passed() {
+		status = "FAIL"
+	}
+	line := fmt.Sprintf("%s %s", status, r.Name)
+	if r.Scores != nil {
+		line += fmt.Sprintf(" (helpfulness %g, goal completion %g, safety %g)", r.Scores.Helpfulness, r.Scores.GoalCompletion, r.Scores.Safety)
+	}
+	fmt.Println(line)
+	if r.Error != "" {
+		fmt.Println("  error:", r.Error)
+	}
+	for _, f := range r.Failures {
+		fmt.Println("  -", f)
+	}
+}
@@ -0,0 +1,710 @@
+package config
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"temporal-ai-agent/activities/llm"
+	"temporal-ai-agent/blobstore"
+	"temporal-ai-agent/channels"
+	"temporal-ai-agent/httpclient"
+	"temporal-ai-agent/secrets"
+	"temporal-ai-agent/shedding"
+	"time"
+
+	"github.com/joho/godotenv"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/client"
+)
+
+// Config holds the configuration shared by the API server and the worker
+type Config struct {
+	HostPort    string
+	Namespace   string
+	APIKey      string
+	TaskQueue   string
+	TLSEnabled  bool
+	ServerPort  string
+	LLMProvider string
+	// SecondaryHostPort is the Temporal endpoint to fail over to (another region or replica); empty disables failover
+	SecondaryHostPort string
+	// FailoverCheckInterval is how often the active Temporal endpoint is health checked
+	FailoverCheckInterval time.Duration
+	// APIMaxInFlight bounds the starts and updates the API server handles at once, and
+	// APIShedErrorRate is the share of failed Temporal calls past which it sheds low-priority
+	// ones; zero disables either
+	APIMaxInFlight   int
+	APIShedErrorRate float64
+	// WorkerMetricsPort is the port the worker serves /metrics on; empty disables it
+	WorkerMetricsPort string
+	// OTLPEndpoint is the OTLP/gRPC collector traces are exported to; empty disables tracing
+	OTLPEndpoint string
+	// PremiumTaskQueue is the task queue of the conversations of premium plans, polled by
+	// workers of its own; empty runs them on TaskQueue with the others
+	PremiumTaskQueue string
+	// WorkerMaxActivities bounds the activities a worker runs at once, and
+	// WorkerActivityRate the activities started per second across the task queue; zero
+	// keeps the SDK's defaults. The premium ones apply to PremiumTaskQueue.
+	WorkerMaxActivities  int
+	WorkerActivityRate   float64
+	PremiumMaxActivities int
+	PremiumActivityRate  float64
+	// OpenAIAPIKey, OpenAIBaseURL, OpenAIModel and OpenAIEmbeddingModel configure the openai
+	// provider; the base URL can point at any compatible API
+	OpenAIAPIKey         string
+	OpenAIBaseURL        string
+	OpenAIModel          string
+	OpenAIEmbeddingModel string
+	// OllamaBaseURL, OllamaModel and OllamaEmbeddingModel configure the ollama provider
+	OllamaBaseURL        string
+	OllamaModel          string
+	OllamaEmbeddingModel string
+	// LLMTitleModel is the cheap model used for conversation titles; empty uses the provider default
+	LLMTitleModel string
+	// LLMMaxContextTokens is the prompt token budget; prompts are trimmed beyond it (0 disables)
+	LLMMaxContextTokens int
+	// ContextShares allocate the prompt budget across its sections, as section=share items,
+	// and ContextPriority orders the sections from the most important
+	ContextShares   []string
+	ContextPriority []string
+	// LLMFallbackMessage is the reply sent when the LLM is still unavailable after retries; empty uses a built-in message
+	LLMFallbackMessage string
+	// TurnLatencyBudget is how long a turn's completions may take before TurnFallbackModel, a
+	// faster model, answers instead; zero or no fallback model disables the budget
+	TurnLatencyBudget time.Duration
+	TurnFallbackModel string
+	// ConfirmationTimeout is how long a tool call waits for the user's confirmation before it
+	// is given up; zero waits indefinitely
+	ConfirmationTimeout time.Duration
+	// ChatIdleTimeout closes conversations nothing happened in for that long; zero keeps
+	// them open until the user ends them
+	ChatIdleTimeout time.Duration
+	// ReceiptRenotifyAfter is how long an important agent message may stay unread before it
+	// is sent again; zero disables reminders
+	ReceiptRenotifyAfter time.Duration
+	// LLMHedgeProvider is the backup provider raced against slow completions; empty disables hedging
+	LLMHedgeProvider string
+	// LLMHedgeModel is the model used for hedged requests; empty keeps the conversation's model
+	LLMHedgeModel string
+	// LLMHedgeDelay is how long a completion may take before the hedged request is sent
+	LLMHedgeDelay time.Duration
+	// LLMHedgeGoals lists the (premium) goals whose completions are hedged; empty hedges all goals
+	LLMHedgeGoals []string
+	// SecretsBackend keeps the LLM API keys tenants bring: vault or memory; empty disables
+	// tenant keys. TenantKeyTTL is how long workers reuse a tenant's key before reading it again.
+	SecretsBackend string
+	TenantKeyTTL   time.Duration
+	// VaultAddr and VaultToken reach the Vault server of the vault backend, whose KV version 2
+	// engine is mounted at VaultMount; secrets are stored below VaultPrefix
+	VaultAddr   string
+	VaultToken  string
+	VaultMount  string
+	VaultPrefix string
+	// HTTPMaxIdleConnsPerHost, HTTPMaxConnsPerHost and HTTPIdleConnTimeout tune the connection
+	// pools of the LLM and tool provider clients
+	HTTPMaxIdleConnsPerHost int
+	HTTPMaxConnsPerHost     int
+	HTTPIdleConnTimeout     time.Duration
+	// LLMWarmup sends a tiny completion and embedding to the providers at worker start, so the
+	// first turn does not pay their cold start; LLMWarmupTimeout bounds it
+	LLMWarmup        bool
+	LLMWarmupTimeout time.Duration
+	// EmbedBatchSize caps the texts of one embedding request (0 disables batching), and
+	// EmbedConcurrency the batches embedded at the same time
+	EmbedBatchSize   int
+	EmbedConcurrency int
+	// ExperimentsFile is the JSON file defining prompt/model experiments; empty disables them
+	ExperimentsFile string
+	// FewShotFile is the JSON file holding curated few-shot examples; empty disables them
+	FewShotFile string
+	// FewShotLimit is the maximum number of examples injected per turn
+	FewShotLimit int
+	// FewShotUseEmbeddings selects examples by embedding similarity instead of word overlap
+	FewShotUseEmbeddings bool
+	// PersonasFile is the JSON file defining agent personas; empty uses the built-in default
+	PersonasFile string
+	// TemplatesFile is the JSON file defining conversation templates, added to the built-in ones
+	TemplatesFile string
+	// DatabaseURL is the Postgres connection string used for transcript search; empty disables it
+	DatabaseURL string
+	// SQLitePath is the SQLite file storing conversations when DatabaseURL is empty; empty disables it
+	SQLitePath string
+	// Environment names the deployment (e.g. dev, staging, prod) for environment-scoped tool policies
+	Environment string
+	// ToolPolicyFile is the JSON file restricting tools per environment and goal; empty allows all tools
+	ToolPolicyFile string
+	// ToolConfirmation sets the confirmation tool calls require by risk level, as risk=level
+	// items (none, approve or phrase) overriding the defaults
+	ToolConfirmation []string
+	// RetryPolicyFile is the JSON file overriding the retry presets of activity categories; empty uses the built-in presets
+	RetryPolicyFile string
+	// ToolResultMaxBytes and ToolResultMaxTokens bound the size of tool results kept in the
+	// history, for tools without their own limits; 0 leaves that dimension unbounded
+	ToolResultMaxBytes  int
+	ToolResultMaxTokens int
+	// ToolResultExtract compresses oversized tool results to the passages relevant to the request
+	ToolResultExtract bool
+	// ToolResultSummarize summarizes oversized tool results with the title model instead of truncating them
+	ToolResultSummarize bool
+	// PluginDir holds the manifests, WebAssembly modules and commands of custom tools; empty
+	// disables plugins
+	PluginDir string
+	// KnowledgeFile is the JSON knowledge base searched by the retrieval tool; empty disables the tool
+	KnowledgeFile string
+	// ChunkSize and ChunkOverlap size the passages knowledge documents are split into, in characters
+	ChunkSize    int
+	ChunkOverlap int
+	// ChunkStrategies override the chunking strategy of document types, as type=strategy items
+	ChunkStrategies []string
+	// OCRBackend recognizes the text of scanned documents and images: http; empty rejects them
+	OCRBackend string
+	// OCREndpoint is the OCR service documents are posted to by the http backend
+	OCREndpoint string
+	// IngestStore is the object storage whose documents are ingested into the knowledge
+	// base on IngestSchedule (cron, UTC): s3, gcs or file; empty disables bucket ingestion.
+	// It shares the endpoint, region and keys of the blob store.
+	IngestStore string
+	// IngestBucket and IngestPrefix select the documents ingested into IngestNamespace
+	IngestBucket    string
+	IngestPrefix    string
+	IngestNamespace string
+	IngestSchedule  string
+	// ToolBreakerFailureRate opens a tool's circuit breaker at this share of failed recent calls (0 disables)
+	ToolBreakerFailureRate float64
+	// ToolBreakerCooldown is how long an open circuit breaker rejects calls
+	ToolBreakerCooldown time.Duration
+	// WebSearchProvider is the web search backend used by the research agent
+	WebSearchProvider string
+	// WebSearchAPIKey authenticates against the web search backend
+	WebSearchAPIKey string
+	// K8sCluster is the cluster of the Kubernetes goal: mock, an in-memory demo shop, or api
+	K8sCluster string
+	// K8sAPIURL, K8sTokenFile and K8sCAFile reach the API server of the api cluster; empty
+	// uses the worker pod's in-cluster address and service account
+	K8sAPIURL    string
+	K8sTokenFile string
+	K8sCAFile    string
+	// K8sReadNamespaces and K8sWriteNamespaces are the namespaces the agent may read and
+	// restart or scale workloads in; "*" stands for every namespace
+	K8sReadNamespaces  []string
+	K8sWriteNamespaces []string
+	// K8sMaxReplicas bounds the replicas a deployment can be scaled to
+	K8sMaxReplicas int
+	// DataSourceURL is the database of the analysis goal: a postgres:// URL or a sqlite:
+	// path; empty uses an in-memory demo sales database
+	DataSourceURL string
+	// CodeInterpreter runs the analysis goal's code: mock, which only summarizes and plots
+	// its data, or http, a sandbox service at CodeInterpreterURL
+	CodeInterpreter    string
+	CodeInterpreterURL string
+	// ArtifactStore keeps the files written by code, e.g. charts: s3, gcs or file. It shares
+	// the endpoint, region and keys of the blob store.
+	ArtifactStore  string
+	ArtifactBucket string
+	// ArtifactBaseURL is where the API server serves artifacts, as linked for users
+	ArtifactBaseURL string
+	// OperatorSlackWebhookURL receives human handoff notifications; empty logs them instead
+	OperatorSlackWebhookURL string
+	// OperatorWebhookURL receives handoff notifications as JSON when no Slack webhook is set
+	OperatorWebhookURL string
+	// WorkflowIDReusePolicy and WorkflowIDConflictPolicy are the default policies for new
+	// conversations, by shorthand name (e.g. "AllowDuplicate", "UseExisting"); empty uses the server default
+	WorkflowIDReusePolicy    string
+	WorkflowIDConflictPolicy string
+	// WorkflowExecutionTimeout bounds how long a conversation may run; 0 means unlimited
+	WorkflowExecutionTimeout time.Duration
+	// StickySessions derives conversation workflow IDs from user IDs so each user keeps one conversation
+	StickySessions bool
+	// MaxConversationsPerUser caps the running conversations started for one user; 0 means unlimited
+	MaxConversationsPerUser int
+	// AllowedModels are the models clients may pick when starting a conversation; empty
+	// accepts any model
+	AllowedModels []string
+	// OperatorAPIKey protects the operator console endpoints; they are disabled when it is empty
+	OperatorAPIKey string
+	// QuotaFile is the JSON file assigning API keys to quota plans; empty disables quotas
+	QuotaFile string
+	// BillingExportDir receives monthly billing statements as files, e.g. a mounted bucket
+	BillingExportDir string
+	// BillingAPIURL receives monthly billing statements by POST; it takes precedence over BillingExportDir
+	BillingAPIURL string
+	BillingAPIKey string
+	// BillingExportFormat is the statement format, csv or json
+	BillingExportFormat string
+	// BillingSchedule is the cron expression (UTC) of the billing export
+	BillingSchedule string
+	// DigestSchedule is the cron expression (UTC) of the daily digest; empty disables it
+	DigestSchedule string
+	// DigestTools are the tools the daily digest covers; empty uses the built-in sources
+	DigestTools []string
+	// DigestChannel delivers digests: log, slack or webhook
+	DigestChannel         string
+	DigestSlackWebhookURL string
+	DigestWebhookURL      string
+	// SlackBotToken lets conversations continue in Slack threads, replying through the
+	// Slack app's bot; ChannelWebhooks are name=url items delivering the replies of other
+	// channels through webhooks
+	SlackBotToken   string
+	ChannelWebhooks []string
+	// ChannelSecrets are name=secret items: the secrets the bridges of the channels sign the
+	// messages they post to the API with. Messages of channels without one are rejected.
+	ChannelSecrets []string
+	// AnalyticsSink receives conversation analytics events: file, segment or kafka; empty disables them
+	AnalyticsSink string
+	// AnalyticsFile is the JSON lines file of the file sink
+	AnalyticsFile string
+	// AnalyticsSegmentWriteKey authenticates the segment sink
+	AnalyticsSegmentWriteKey string
+	// AnalyticsKafkaRESTURL and AnalyticsKafkaTopic locate the Kafka REST proxy and topic of the kafka sink
+	AnalyticsKafkaRESTURL string
+	AnalyticsKafkaTopic   string
+	// RedisURL is the Redis server streaming conversation output to API clients; empty
+	// streams progress events by querying the workflow every StreamPollInterval instead
+	RedisURL           string
+	StreamPollInterval time.Duration
+	// BlobStore offloads large Temporal payloads to object storage: s3, gcs or file; empty
+	// keeps every payload in the event history
+	BlobStore string
+	// BlobBucket is the bucket (the directory, for the file store) of offloaded payloads, stored under BlobPrefix
+	BlobBucket string
+	BlobPrefix string
+	// BlobEndpoint overrides the object storage endpoint (e.g. MinIO); empty uses the provider's
+	BlobEndpoint string
+	BlobRegion   string
+	// BlobAccessKey and BlobSecretKey authenticate against the object storage (HMAC keys for gcs).
+	// BlobSessionToken comes with temporary keys; s3 uses the AWS credential chain without keys.
+	BlobAccessKey    string
+	BlobSecretKey    string
+	BlobSessionToken string
+	// BlobOffloadBytes is the payload size above which payloads are offloaded
+	BlobOffloadBytes int
+	// SemanticCacheGoals are the FAQ-style goals whose answers are reused for near-duplicate
+	// questions; empty disables the semantic cache
+	SemanticCacheGoals []string
+	// SemanticCacheThreshold is the embedding similarity from which a cached answer is reused
+	SemanticCacheThreshold float64
+	// SemanticCacheTTL is how long a cached answer is reused; 0 keeps answers forever
+	SemanticCacheTTL time.Duration
+	// EvalEnabled has the judge model score every conversation once it ends
+	EvalEnabled bool
+	// EvalModel is the judge model; empty uses the provider's default
+	EvalModel string
+	// GroundingMode verifies answers written from the knowledge base: flag or regenerate;
+	// empty disables it
+	GroundingMode string
+	// GroundingModel is the verifier model; empty uses the provider's default
+	GroundingModel string
+	// BootstrapNamespace creates missing search attributes and schedules when the worker starts
+	BootstrapNamespace bool
+	// AdminAPIKey protects the admin endpoints; they are disabled when it is empty
+	AdminAPIKey string
+}
+
+// Load loads the .env file (if present) and reads the configuration from the environment.
+// The returned bool reports whether a .env file was found.
+func Load() (Config, bool) {
+	found := godotenv.Load() == nil
+	return FromEnv(), found
+}
+
+// FromEnv reads the configuration from environment variables only
+func FromEnv() Config {
+	return Config{
+		HostPort:                 GetEnv("TEMPORAL_HOST_PORT", "localhost:7233"),
+		SecondaryHostPort:        GetEnv("TEMPORAL_SECONDARY_HOST_PORT", ""),
+		FailoverCheckInterval:    GetEnvDuration("TEMPORAL_FAILOVER_CHECK_INTERVAL", 10*time.Second),
+		Namespace:                GetEnv("TEMPORAL_NAMESPACE", "default"),
+		APIKey:                   GetEnv("TEMPORAL_API_KEY", ""),
+		TaskQueue:                GetEnv("TEMPORAL_TASK_QUEUE", "my-task-queue"),
+		PremiumTaskQueue:         GetEnv("PREMIUM_TASK_QUEUE", ""),
+		WorkerMaxActivities:      GetEnvInt("WORKER_MAX_CONCURRENT_ACTIVITIES", 0),
+		WorkerActivityRate:       GetEnvFloat("WORKER_ACTIVITIES_PER_SECOND", 0),
+		PremiumMaxActivities:     GetEnvInt("PREMIUM_WORKER_MAX_CONCURRENT_ACTIVITIES", 0),
+		PremiumActivityRate:      GetEnvFloat("PREMIUM_WORKER_ACTIVITIES_PER_SECOND", 0),
+		TLSEnabled:               GetEnvBool("TEMPORAL_TLS_ENABLED", false),
+		ServerPort:               GetEnv("SERVER_PORT", "3000"),
+		WorkerMetricsPort:        GetEnv("WORKER_METRICS_PORT", "9090"),
+		OTLPEndpoint:             GetEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		APIMaxInFlight:           GetEnvInt("API_MAX_IN_FLIGHT", 0),
+		APIShedErrorRate:         GetEnvFloat("API_SHED_ERROR_RATE", 0.5),
+		LLMProvider:              GetEnv("LLM_PROVIDER", ""),
+		OpenAIAPIKey:             GetEnv("OPENAI_API_KEY", ""),
+		OpenAIBaseURL:            GetEnv("OPENAI_BASE_URL", "https://api.openai.com/v1"),
+		OpenAIModel:              GetEnv("OPENAI_MODEL", "gpt-4o-mini"),
+		OpenAIEmbeddingModel:     GetEnv("OPENAI_EMBEDDING_MODEL", "text-embedding-3-small"),
+		OllamaBaseURL:            GetEnv("OLLAMA_BASE_URL", "http://localhost:11434"),
+		OllamaModel:              GetEnv("OLLAMA_MODEL", "llama3.1"),
+		OllamaEmbeddingModel:     GetEnv("OLLAMA_EMBEDDING_MODEL", "nomic-embed-text"),
+		LLMTitleModel:            GetEnv("LLM_TITLE_MODEL", ""),
+		LLMMaxContextTokens:      GetEnvInt("LLM_MAX_CONTEXT_TOKENS", 0),
+		ContextShares:            GetEnvList("CONTEXT_SHARES"),
+		ContextPriority:          GetEnvList("CONTEXT_PRIORITY"),
+		LLMFallbackMessage:       GetEnv("LLM_FALLBACK_MESSAGE", ""),
+		TurnLatencyBudget:        GetEnvDuration("TURN_LATENCY_BUDGET", 0),
+		TurnFallbackModel:        GetEnv("TURN_FALLBACK_MODEL", ""),
+		ConfirmationTimeout:      GetEnvDuration("CONFIRMATION_TIMEOUT", 0),
+		ChatIdleTimeout:          GetEnvDuration("CHAT_IDLE_TIMEOUT", 0),
+		ReceiptRenotifyAfter:     GetEnvDuration("RECEIPT_RENOTIFY_AFTER", 0),
+		LLMHedgeProvider:         GetEnv("LLM_HEDGE_PROVIDER", ""),
+		LLMHedgeModel:            GetEnv("LLM_HEDGE_MODEL", ""),
+		LLMHedgeDelay:            GetEnvDuration("LLM_HEDGE_DELAY", 2*time.Second),
+		LLMHedgeGoals:            GetEnvList("LLM_HEDGE_GOALS"),
+		SecretsBackend:           GetEnv("SECRETS_BACKEND", ""),
+		TenantKeyTTL:             GetEnvDuration("TENANT_KEY_TTL", llm.DefaultTenantKeyTTL),
+		VaultAddr:                GetEnv("VAULT_ADDR", ""),
+		VaultToken:               GetEnv("VAULT_TOKEN", ""),
+		VaultMount:               GetEnv("VAULT_MOUNT", "secret"),
+		VaultPrefix:              GetEnv("VAULT_PREFIX", "temporal-ai-agent"),
+		HTTPMaxIdleConnsPerHost:  GetEnvInt("HTTP_MAX_IDLE_CONNS_PER_HOST", httpclient.DefaultMaxIdleConnsPerHost),
+		HTTPMaxConnsPerHost:      GetEnvInt("HTTP_MAX_CONNS_PER_HOST", 0),
+		HTTPIdleConnTimeout:      GetEnvDuration("HTTP_IDLE_CONN_TIMEOUT", httpclient.DefaultIdleConnTimeout),
+		LLMWarmup:                GetEnvBool("LLM_WARMUP", false),
+		LLMWarmupTimeout:         GetEnvDuration("LLM_WARMUP_TIMEOUT", 30*time.Second),
+		EmbedBatchSize:           GetEnvInt("EMBED_BATCH_SIZE", 100),
+		EmbedConcurrency:         GetEnvInt("EMBED_CONCURRENCY", 4),
+		ExperimentsFile:          GetEnv("EXPERIMENTS_FILE", ""),
+		FewShotFile:              GetEnv("FEWSHOT_FILE", ""),
+		FewShotLimit:             GetEnvInt("FEWSHOT_LIMIT", 3),
+		FewShotUseEmbeddings:     GetEnvBool("FEWSHOT_USE_EMBEDDINGS", false),
+		PersonasFile:             GetEnv("PERSONAS_FILE", ""),
+		TemplatesFile:            GetEnv("TEMPLATES_FILE", ""),
+		DatabaseURL:              GetEnv("DATABASE_URL", ""),
+		SQLitePath:               GetEnv("SQLITE_PATH", ""),
+		Environment:              GetEnv("AGENT_ENVIRONMENT", "dev"),
+		ToolPolicyFile:           GetEnv("TOOL_POLICY_FILE", ""),
+		ToolConfirmation:         GetEnvList("TOOL_CONFIRMATION"),
+		KnowledgeFile:            GetEnv("KNOWLEDGE_FILE", ""),
+		PluginDir:                GetEnv("PLUGIN_DIR", ""),
+		ChunkSize:                GetEnvInt("CHUNK_SIZE", 1000),
+		ChunkOverlap:             GetEnvInt("CHUNK_OVERLAP", 100),
+		ChunkStrategies:          GetEnvList("CHUNK_STRATEGIES"),
+		OCRBackend:               GetEnv("OCR_BACKEND", ""),
+		OCREndpoint:              GetEnv("OCR_ENDPOINT", ""),
+		IngestStore:              GetEnv("INGEST_STORE", ""),
+		IngestBucket:             GetEnv("INGEST_BUCKET", ""),
+		IngestPrefix:             GetEnv("INGEST_PREFIX", ""),
+		IngestNamespace:          GetEnv("INGEST_NAMESPACE", "docs"),
+		IngestSchedule:           GetEnv("INGEST_SCHEDULE", "*/15 * * * *"),
+		RetryPolicyFile:          GetEnv("RETRY_POLICY_FILE", ""),
+		ToolResultMaxBytes:       GetEnvInt("TOOL_RESULT_MAX_BYTES", 16000),
+		ToolResultMaxTokens:      GetEnvInt("TOOL_RESULT_MAX_TOKENS", 0),
+		ToolResultExtract:        GetEnvBool("TOOL_RESULT_EXTRACT", false),
+		ToolResultSummarize:      GetEnvBool("TOOL_RESULT_SUMMARIZE", false),
+		ToolBreakerFailureRate:   GetEnvFloat("TOOL_BREAKER_FAILURE_RATE", 0.5),
+		ToolBreakerCooldown:      GetEnvDuration("TOOL_BREAKER_COOLDOWN", 30*time.Second),
+		WebSearchProvider:        GetEnv("WEB_SEARCH_PROVIDER", "mock"),
+		WebSearchAPIKey:          GetEnv("WEB_SEARCH_API_KEY", ""),
+		K8sCluster:               GetEnv("K8S_CLUSTER", "mock"),
+		K8sAPIURL:                GetEnv("K8S_API_URL", ""),
+		K8sTokenFile:             GetEnv("K8S_TOKEN_FILE", ""),
+		K8sCAFile:                GetEnv("K8S_CA_FILE", ""),
+		K8sReadNamespaces:        GetEnvList("K8S_READ_NAMESPACES"),
+		K8sWriteNamespaces:       GetEnvList("K8S_WRITE_NAMESPACES"),
+		K8sMaxReplicas:           GetEnvInt("K8S_MAX_REPLICAS", 20),
+		DataSourceURL:            GetEnv("DATA_SOURCE_URL", ""),
+		CodeInterpreter:          GetEnv("CODE_INTERPRETER", "mock"),
+		CodeInterpreterURL:       GetEnv("CODE_INTERPRETER_URL", ""),
+		ArtifactStore:            GetEnv("ARTIFACT_STORE", "file"),
+		ArtifactBucket:           GetEnv("ARTIFACT_BUCKET", "artifacts"),
+		ArtifactBaseURL:          GetEnv("ARTIFACT_BASE_URL", "http://localhost:3000/artifacts"),
+		OperatorSlackWebhookURL:  GetEnv("OPERATOR_SLACK_WEBHOOK_URL", ""),
+		OperatorWebhookURL:       GetEnv("OPERATOR_WEBHOOK_URL", ""),
+		WorkflowIDReusePolicy:    GetEnv("WORKFLOW_ID_REUSE_POLICY", ""),
+		WorkflowIDConflictPolicy: GetEnv("WORKFLOW_ID_CONFLICT_POLICY", ""),
+		WorkflowExecutionTimeout: GetEnvDuration("WORKFLOW_EXECUTION_TIMEOUT", 0),
+		StickySessions:           GetEnvBool("STICKY_SESSIONS", false),
+		MaxConversationsPerUser:  GetEnvInt("MAX_CONVERSATIONS_PER_USER", 0),
+		AllowedModels:            GetEnvList("ALLOWED_MODELS"),
+		OperatorAPIKey:           GetEnv("OPERATOR_API_KEY", ""),
+		QuotaFile:                GetEnv("QUOTA_FILE", ""),
+		BillingExportDir:         GetEnv("BILLING_EXPORT_DIR", ""),
+		BillingAPIURL:            GetEnv("BILLING_API_URL", ""),
+		BillingAPIKey:            GetEnv("BILLING_API_KEY", ""),
+		BillingExportFormat:      GetEnv("BILLING_EXPORT_FORMAT", "csv"),
+		BillingSchedule:          GetEnv("BILLING_SCHEDULE", "0 1 1 * *"),
+		DigestSchedule:           GetEnv("DIGEST_SCHEDULE", ""),
+		DigestTools:              GetEnvList("DIGEST_TOOLS"),
+		DigestChannel:            GetEnv("DIGEST_CHANNEL", "log"),
+		DigestSlackWebhookURL:    GetEnv("DIGEST_SLACK_WEBHOOK_URL", ""),
+		DigestWebhookURL:         GetEnv("DIGEST_WEBHOOK_URL", ""),
+		SlackBotToken:            GetEnv("SLACK_BOT_TOKEN", ""),
+		ChannelWebhooks:          GetEnvList("CHANNEL_WEBHOOKS"),
+		ChannelSecrets:           GetEnvList("CHANNEL_SECRETS"),
+		AnalyticsSink:            GetEnv("ANALYTICS_SINK", ""),
+		AnalyticsFile:            GetEnv("ANALYTICS_FILE", "analytics.jsonl"),
+		AnalyticsSegmentWriteKey: GetEnv("ANALYTICS_SEGMENT_WRITE_KEY", ""),
+		AnalyticsKafkaRESTURL:    GetEnv("ANALYTICS_KAFKA_REST_URL", ""),
+		AnalyticsKafkaTopic:      GetEnv("ANALYTICS_KAFKA_TOPIC", "agent-analytics"),
+		RedisURL:                 GetEnv("REDIS_URL", ""),
+		StreamPollInterval:       GetEnvDuration("STREAM_POLL_INTERVAL", time.Second),
+		BlobStore:                GetEnv("BLOB_STORE", ""),
+		BlobBucket:               GetEnv("BLOB_BUCKET", ""),
+		BlobPrefix:               GetEnv("BLOB_PREFIX", "payloads/"),
+		BlobEndpoint:             GetEnv("BLOB_ENDPOINT", ""),
+		BlobRegion:               GetEnv("BLOB_REGION", ""),
+		BlobAccessKey:            GetEnv("BLOB_ACCESS_KEY", os.Getenv("AWS_ACCESS_KEY_ID")),
+		BlobSecretKey:            GetEnv("BLOB_SECRET_KEY", os.Getenv("AWS_SECRET_ACCESS_KEY")),
+		BlobSessionToken:         GetEnv("BLOB_SESSION_TOKEN", os.Getenv("AWS_SESSION_TOKEN")),
+		BlobOffloadBytes:         GetEnvInt("BLOB_OFFLOAD_BYTES", 128000),
+		SemanticCacheGoals:       GetEnvList("SEMANTIC_CACHE_GOALS"),
+		SemanticCacheThreshold:   GetEnvFloat("SEMANTIC_CACHE_THRESHOLD", 0.95),
+		SemanticCacheTTL:         GetEnvDuration("SEMANTIC_CACHE_TTL", 24*time.Hour),
+		EvalEnabled:              GetEnvBool("EVAL_ENABLED", false),
+		EvalModel:                GetEnv("EVAL_MODEL", ""),
+		GroundingMode:            GetEnv("GROUNDING_MODE", ""),
+		GroundingModel:           GetEnv("GROUNDING_MODEL", ""),
+		BootstrapNamespace:       GetEnvBool("BOOTSTRAP_NAMESPACE", true),
+		AdminAPIKey:              GetEnv("ADMIN_API_KEY", ""),
+	}
+}
+
+// Validate checks that the required configuration is present
+func (c Config) Validate() error {
+	if c.APIKey == "" {
+		return errors.New("TEMPORAL_API_KEY environment variable is required")
+	}
+	if c.APIShedErrorRate < 0 || c.APIShedErrorRate > 1 {
+		return fmt.Errorf("API_SHED_ERROR_RATE must be between 0 and 1, got %g", c.APIShedErrorRate)
+	}
+	if c.LLMHedgeProvider != "" && c.LLMHedgeDelay <= 0 {
+		return fmt.Errorf("LLM_HEDGE_DELAY must be positive, got %s", c.LLMHedgeDelay)
+	}
+	if c.PremiumTaskQueue != "" && c.PremiumTaskQueue == c.TaskQueue {
+		return errors.New("PREMIUM_TASK_QUEUE must differ from TEMPORAL_TASK_QUEUE")
+	}
+	if _, err := ParseIDReusePolicy(c.WorkflowIDReusePolicy); err != nil {
+		return fmt.Errorf("WORKFLOW_ID_REUSE_POLICY: %w", err)
+	}
+	if _, err := ParseIDConflictPolicy(c.WorkflowIDConflictPolicy); err != nil {
+		return fmt.Errorf("WORKFLOW_ID_CONFLICT_POLICY: %w", err)
+	}
+	if c.BillingExportFormat != "csv" && c.BillingExportFormat != "json" {
+		return fmt.Errorf("BILLING_EXPORT_FORMAT must be csv or json, got %q", c.BillingExportFormat)
+	}
+	if _, err := c.PayloadCodec(); err != nil {
+		return fmt.Errorf("BLOB_STORE: %w", err)
+	}
+	if _, err := c.IngestSource(); err != nil {
+		return fmt.Errorf("INGEST_STORE: %w", err)
+	}
+	if _, err := c.Secrets(); err != nil {
+		return fmt.Errorf("SECRETS_BACKEND: %w", err)
+	}
+	if _, err := c.Channels(); err != nil {
+		return fmt.Errorf("CHANNEL_WEBHOOKS: %w", err)
+	}
+	if _, err := c.ChannelSigningSecrets(); err != nil {
+		return fmt.Errorf("CHANNEL_SECRETS: %w", err)
+	}
+	return nil
+}
+
+// ClientOptions builds the Temporal client options for this configuration
+func (c Config) ClientOptions() client.Options {
+	return c.ClientOptionsFor(c.HostPort)
+}
+
+// ClientOptionsFor builds the Temporal client options for one of the configured endpoints
+func (c Config) ClientOptionsFor(hostPort string) client.Options {
+	clientOptions := client.Options{
+		HostPort:  hostPort,
+		Namespace: c.Namespace,
+	}
+
+	// Configure TLS if enabled
+	if c.TLSEnabled {
+		clientOptions.ConnectionOptions = client.ConnectionOptions{TLS: &tls.Config{}}
+	}
+
+	// Configure credentials (the local dev server runs without them)
+	if c.APIKey != "" {
+		clientOptions.Credentials = client.NewAPIKeyStaticCredentials(c.APIKey)
+	}
+
+	// Offload large payloads; a misconfigured blob store is reported by Validate
+	if codec, err := c.PayloadCodec(); err == nil && codec != nil {
+		clientOptions.DataConverter = codec.DataConverter()
+	}
+
+	return clientOptions
+}
+
+// PayloadCodec returns the codec offloading large payloads to the blob store, or nil when
+// no blob store is configured
+func (c Config) PayloadCodec() (*blobstore.Codec, error) {
+	if c.BlobStore == "" {
+		return nil, nil
+	}
+	store, err := blobstore.New(c.BlobStore, blobstore.Options{
+		Bucket:       c.BlobBucket,
+		Prefix:       c.BlobPrefix,
+		Endpoint:     c.BlobEndpoint,
+		Region:       c.BlobRegion,
+		AccessKey:    c.BlobAccessKey,
+		SecretKey:    c.BlobSecretKey,
+		SessionToken: c.BlobSessionToken,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &blobstore.Codec{Store: store, Threshold: c.BlobOffloadBytes}, nil
+}
+
+// IngestSource returns the bucket whose documents are ingested into the knowledge base, or
+// nil when bucket ingestion is disabled
+func (c Config) IngestSource() (blobstore.Lister, error) {
+	if c.IngestStore == "" {
+		return nil, nil
+	}
+	return blobstore.NewLister(c.IngestStore, blobstore.Options{
+		Bucket:       c.IngestBucket,
+		Prefix:       c.IngestPrefix,
+		Endpoint:     c.BlobEndpoint,
+		Region:       c.BlobRegion,
+		AccessKey:    c.BlobAccessKey,
+		SecretKey:    c.BlobSecretKey,
+		SessionToken: c.BlobSessionToken,
+	})
+}
+
+// Artifacts returns the store of the files written by the analysis goal's code
+func (c Config) Artifacts() (blobstore.Store, error) {
+	return blobstore.New(c.ArtifactStore, blobstore.Options{
+		Bucket:       c.ArtifactBucket,
+		Endpoint:     c.BlobEndpoint,
+		Region:       c.BlobRegion,
+		AccessKey:    c.BlobAccessKey,
+		SecretKey:    c.BlobSecretKey,
+		SessionToken: c.BlobSessionToken,
+	})
+}
+
+// Secrets returns the store of the LLM API keys tenants bring, or nil when tenant keys are
+// disabled
+func (c Config) Secrets() (secrets.Store, error) {
+	if c.SecretsBackend == "" {
+		return nil, nil
+	}
+	return secrets.New(c.SecretsBackend, secrets.Options{
+		Address: c.VaultAddr,
+		Token:   c.VaultToken,
+		Mount:   c.VaultMount,
+		Prefix:  c.VaultPrefix,
+	})
+}
+
+// Channels returns the adapters of the channels conversations can continue on
+func (c Config) Channels() (channels.Adapters, error) {
+	return channels.New(c.SlackBotToken, c.ChannelWebhooks)
+}
+
+// ChannelSigningSecrets returns the secrets of the channels' bridges by channel name
+func (c Config) ChannelSigningSecrets() (map[string]string, error) {
+	return channels.ParseSecrets(c.ChannelSecrets)
+}
+
+// LLMOptions returns the settings of the LLM providers
+func (c Config) LLMOptions() llm.Options {
+	return llm.Options{
+		OpenAIAPIKey:         c.OpenAIAPIKey,
+		OpenAIBaseURL:        c.OpenAIBaseURL,
+		OpenAIModel:          c.OpenAIModel,
+		OpenAIEmbeddingModel: c.OpenAIEmbeddingModel,
+		OllamaBaseURL:        c.OllamaBaseURL,
+		OllamaModel:          c.OllamaModel,
+		OllamaEmbeddingModel: c.OllamaEmbeddingModel,
+		HTTP:                 c.HTTPOptions(),
+	}
+}
+
+// SheddingOptions returns the load shedding limits of the API server
+func (c Config) SheddingOptions() shedding.Options {
+	return shedding.Options{MaxInFlight: c.APIMaxInFlight, ErrorRate: c.APIShedErrorRate}
+}
+
+// HTTPOptions returns the connection pool settings of the provider clients
+func (c Config) HTTPOptions() httpclient.Options {
+	return httpclient.Options{
+		MaxIdleConnsPerHost: c.HTTPMaxIdleConnsPerHost,
+		MaxConnsPerHost:     c.HTTPMaxConnsPerHost,
+		IdleConnTimeout:     c.HTTPIdleConnTimeout,
+	}
+}
+
+// GetEnv gets an environment variable with a fallback default value
+func GetEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// GetEnvBool gets a boolean environment variable with a fallback default value
+func GetEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// GetEnvInt gets an integer environment variable with a fallback default value
+func GetEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// GetEnvFloat gets a floating-point environment variable with a fallback default value
+func GetEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// GetEnvDuration gets a duration environment variable (e.g. "30m") with a fallback default value
+func GetEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// GetEnvList gets a comma-separated environment variable as a list, skipping empty items
+func GetEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// ParseIDReusePolicy parses a workflow ID reuse policy by name; empty returns the unspecified policy
+func ParseIDReusePolicy(name string) (enumspb.WorkflowIdReusePolicy, error) {
+	if name == "" {
+		return enumspb.WORKFLOW_ID_REUSE_POLICY_UNSPECIFIED, nil
+	}
+	return enumspb.WorkflowIdReusePolicyFromString(name)
+}
+
+// ParseIDConflictPolicy parses a workflow ID conflict policy by name; empty returns the unspecified policy
+func ParseIDConflictPolicy(name string) (enumspb.WorkflowIdConflictPolicy, error) {
+	if name == "" {
+		return enumspb.WORKFLOW_ID_CONFLICT_POLICY_UNSPECIFIED, nil
+	}
+	return enumspb.WorkflowIdConflictPolicyFromString(name)
+}
@@ -0,0 +1,125 @@
+// Package deadletter keeps the conversation turns that failed, so they can be inspected and
+// retried once the cause is fixed instead of only showing up in the worker logs.
+package deadletter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"temporal-ai-agent/activities/llm"
+	"time"
+)
+
+// ErrNotFound is returned for IDs the store does not have
+var ErrNotFound = errors.New("dead letter not found")
+
+// Kinds of failed turns
+const (
+	// KindFailed turns ended with an error, leaving the user without a reply
+	KindFailed = "failed"
+	// KindDegraded turns were answered with the fallback message because the LLM was unavailable
+	KindDegraded = "degraded"
+)
+
+// Entry is a failed turn, with the context needed to understand and retry it
+type Entry struct {
+	// ID identifies the turn: the conversation and the position of its user message
+	ID            string `json:"id"`
+	WorkflowID    string `json:"workflow_id"`
+	RunID         string `json:"run_id"`
+	Goal          string `json:"goal"`
+	PromptVersion string `json:"prompt_version,omitempty"`
+	Model         string `json:"model,omitempty"`
+	Kind          string `json:"kind"`
+	// MessageIndex is the position in the history of the turn's user message
+	MessageIndex int `json:"message_index"`
+	// Messages is the conversation history when the turn failed
+	Messages []llm.Message `json:"messages"`
+	Error    string        `json:"error"`
+	// Failures counts the times the turn failed, retries included
+	Failures int       `json:"failures"`
+	FailedAt time.Time `json:"failed_at"`
+	// ResolvedAt is nil until a retry of the turn succeeds
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+// ID returns the ID of the turn of a conversation whose user message is at the given position
+func ID(workflowID string, messageIndex int) string {
+	return fmt.Sprintf("%s:%d", workflowID, messageIndex)
+}
+
+// Store persists failed turns
+type Store interface {
+	// Add records a failed turn. A turn failing again replaces its context and error,
+	// counts the failure and is unresolved again.
+	Add(ctx context.Context, entry Entry) error
+	// Get returns the entry with the given ID, or ErrNotFound
+	Get(ctx context.Context, id string) (Entry, error)
+	// List returns the entries, most recent failure first; resolved ones only when asked
+	List(ctx context.Context, includeResolved bool) ([]Entry, error)
+	// Resolve marks the turn with the given ID as successfully retried. Turns that never
+	// failed are ignored.
+	Resolve(ctx context.Context, id string, at time.Time) error
+}
+
+// MemoryStore is a Store kept in process memory. It only works when the API server and
+// the worker run in the same process, as in dev mode.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]Entry
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: map[string]Entry{}}
+}
+
+// Add records a failed turn
+func (m *MemoryStore) Add(ctx context.Context, entry Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry.Failures = m.entries[entry.ID].Failures + 1
+	entry.ResolvedAt = nil
+	m.entries[entry.ID] = entry
+	return nil
+}
+
+// Get returns the entry with the given ID
+func (m *MemoryStore) Get(ctx context.Context, id string) (Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[id]
+	if !ok {
+		return Entry{}, ErrNotFound
+	}
+	return entry, nil
+}
+
+// List returns the entries, most recent failure first
+func (m *MemoryStore) List(ctx context.Context, includeResolved bool) ([]Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entries := []Entry{}
+	for _, entry := range m.entries {
+		if includeResolved || entry.ResolvedAt == nil {
+			entries = append(entries, entry)
+		}
+	}
+	slices.SortFunc(entries, func(a, b Entry) int { return b.FailedAt.Compare(a.FailedAt) })
+	return entries, nil
+}
+
+// Resolve marks the turn with the given ID as successfully retried
+func (m *MemoryStore) Resolve(ctx context.Context, id string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[id]
+	if !ok {
+		return nil
+	}
+	entry.ResolvedAt = &at
+	m.entries[id] = entry
+	return nil
+}
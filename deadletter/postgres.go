@@ -0,0 +1,128 @@
+package deadletter
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS dead_letter_turns (
+	id             TEXT PRIMARY KEY,
+	workflow_id    TEXT NOT NULL,
+	run_id         TEXT NOT NULL,
+	goal           TEXT NOT NULL DEFAULT '',
+	prompt_version TEXT NOT NULL DEFAULT '',
+	model          TEXT NOT NULL DEFAULT '',
+	kind           TEXT NOT NULL,
+	message_index  INTEGER NOT NULL,
+	messages       JSONB NOT NULL,
+	error          TEXT NOT NULL,
+	failures       INTEGER NOT NULL DEFAULT 1,
+	failed_at      TIMESTAMPTZ NOT NULL,
+	resolved_at    TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS dead_letter_turns_unresolved_idx
+	ON dead_letter_turns (failed_at) WHERE resolved_at IS NULL;
+`
+
+// columns are the columns scanned into an Entry, in scanEntry's order
+const columns = `id, workflow_id, run_id, goal, prompt_version, model, kind, message_index, messages,
+	error, failures, failed_at, resolved_at`
+
+// PostgresStore is a Store shared by every API server and worker using the same database
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore connects to Postgres and creates the dead-letter table if needed
+func NewPostgresStore(ctx context.Context, databaseURL string) (*PostgresStore, error) {
+	db, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &PostgresStore{db: db}, nil
+}
+
+// Close closes the database connection
+func (p *PostgresStore) Close() error {
+	return p.db.Close()
+}
+
+// Add records a failed turn
+func (p *PostgresStore) Add(ctx context.Context, e Entry) error {
+	messages, err := json.Marshal(e.Messages)
+	if err != nil {
+		return err
+	}
+	_, err = p.db.ExecContext(ctx, `
+		INSERT INTO dead_letter_turns
+			(id, workflow_id, run_id, goal, prompt_version, model, kind, message_index, messages, error, failed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (id) DO UPDATE
+		SET run_id = EXCLUDED.run_id, goal = EXCLUDED.goal, prompt_version = EXCLUDED.prompt_version,
+			model = EXCLUDED.model, kind = EXCLUDED.kind, messages = EXCLUDED.messages,
+			error = EXCLUDED.error, failed_at = EXCLUDED.failed_at,
+			failures = dead_letter_turns.failures + 1, resolved_at = NULL`,
+		e.ID, e.WorkflowID, e.RunID, e.Goal, e.PromptVersion, e.Model, e.Kind, e.MessageIndex,
+		string(messages), e.Error, e.FailedAt)
+	return err
+}
+
+// Get returns the entry with the given ID
+func (p *PostgresStore) Get(ctx context.Context, id string) (Entry, error) {
+	entry, err := scanEntry(p.db.QueryRowContext(ctx, `SELECT `+columns+` FROM dead_letter_turns WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Entry{}, ErrNotFound
+	}
+	return entry, err
+}
+
+// List returns the entries, most recent failure first
+func (p *PostgresStore) List(ctx context.Context, includeResolved bool) ([]Entry, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT `+columns+` FROM dead_letter_turns
+		WHERE $1 OR resolved_at IS NULL
+		ORDER BY failed_at DESC`, includeResolved)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		entry, err := scanEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// Resolve marks the turn with the given ID as successfully retried
+func (p *PostgresStore) Resolve(ctx context.Context, id string, at time.Time) error {
+	_, err := p.db.ExecContext(ctx, `
+		UPDATE dead_letter_turns SET resolved_at = $2
+		WHERE id = $1 AND resolved_at IS NULL`, id, at)
+	return err
+}
+
+// scanEntry reads an entry from a row of the selected columns
+func scanEntry(row interface{ Scan(...any) error }) (Entry, error) {
+	var e Entry
+	var messages []byte
+	if err := row.Scan(&e.ID, &e.WorkflowID, &e.RunID, &e.Goal, &e.PromptVersion, &e.Model, &e.Kind,
+		&e.MessageIndex, &messages, &e.Error, &e.Failures, &e.FailedAt, &e.ResolvedAt); err != nil {
+		return Entry{}, err
+	}
+	return e, json.Unmarshal(messages, &e.Messages)
+}
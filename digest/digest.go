@@ -0,0 +1,110 @@
+package digest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Digest is a composed summary delivered to a user
+type Digest struct {
+	// ID stays the same when delivery is retried, so receivers can drop duplicates
+	ID     string `json:"id"`
+	UserID string `json:"user_id,omitempty"`
+	// Date is the day the digest covers (YYYY-MM-DD)
+	Date string `json:"date"`
+	Text string `json:"text"`
+}
+
+// Deliverer sends digests to the channel users read them on
+type Deliverer interface {
+	Deliver(ctx context.Context, d Digest) error
+}
+
+// New returns the deliverer of the given channel: log, slack or webhook
+func New(channel, slackWebhookURL, webhookURL string) (Deliverer, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	switch channel {
+	case "log":
+		return Log{}, nil
+	case "slack":
+		if slackWebhookURL == "" {
+			return nil, fmt.Errorf("DIGEST_SLACK_WEBHOOK_URL is required for the slack digest channel")
+		}
+		return &Slack{WebhookURL: slackWebhookURL, Client: client}, nil
+	case "webhook":
+		if webhookURL == "" {
+			return nil, fmt.Errorf("DIGEST_WEBHOOK_URL is required for the webhook digest channel")
+		}
+		return &Webhook{URL: webhookURL, Client: client}, nil
+	default:
+		return nil, fmt.Errorf("unknown digest channel %q", channel)
+	}
+}
+
+// Log writes digests to the worker log, for local development
+type Log struct{}
+
+// Deliver logs the digest
+func (Log) Deliver(ctx context.Context, d Digest) error {
+	log.Printf("Digest for %s:\n%s", d.Date, d.Text)
+	return nil
+}
+
+// Slack posts digests to a Slack incoming webhook
+type Slack struct {
+	WebhookURL string
+	Client     *http.Client
+}
+
+// Deliver posts the digest text to the webhook
+func (s *Slack) Deliver(ctx context.Context, d Digest) error {
+	return post(ctx, s.Client, s.WebhookURL, "", map[string]string{
+		"text": fmt.Sprintf("*Daily digest for %s*\n%s", d.Date, d.Text),
+	})
+}
+
+// Webhook posts digests as JSON to an HTTP endpoint, with the digest ID as the
+// Idempotency-Key header
+type Webhook struct {
+	URL    string
+	Client *http.Client
+}
+
+// Deliver posts the digest to the endpoint
+func (h *Webhook) Deliver(ctx context.Context, d Digest) error {
+	return post(ctx, h.Client, h.URL, d.ID, d)
+}
+
+// post sends a JSON body and fails on non-2xx responses
+func post(ctx context.Context, client *http.Client, url, idempotencyKey string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("digest delivery to %s returned %s: %s", url, resp.Status, msg)
+	}
+	return nil
+}
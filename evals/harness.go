@@ -0,0 +1,173 @@
+package evals
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"temporal-ai-agent/activities/llm"
+)
+
+// Case is a recorded conversation replayed by the offline harness
+type Case struct {
+	Name string `json:"name"`
+	// Goal and Context start the conversation as the API would; an empty goal is the default
+	Goal    string            `json:"goal,omitempty"`
+	Context map[string]string `json:"context,omitempty"`
+	// Turns are the user messages, sent one turn at a time
+	Turns []string `json:"turns"`
+	// ExpectTools are tools the agent must call during the conversation
+	ExpectTools []string `json:"expect_tools,omitempty"`
+	// ExpectReplies are phrases the agent's replies must contain (case-insensitive)
+	ExpectReplies []string `json:"expect_replies,omitempty"`
+}
+
+// LoadCorpus reads the cases of a corpus from a JSON file
+func LoadCorpus(path string) ([]Case, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading corpus: %w", err)
+	}
+	var cases []Case
+	if err := json.Unmarshal(data, &cases); err != nil {
+		return nil, fmt.Errorf("parsing corpus: %w", err)
+	}
+	for i, c := range cases {
+		if c.Name == "" || len(c.Turns) == 0 {
+			return nil, fmt.Errorf("corpus case %d needs a name and at least one turn", i)
+		}
+	}
+	return cases, nil
+}
+
+// CaseResult is the outcome of replaying a case
+type CaseResult struct {
+	Name  string   `json:"name"`
+	Tools []string `json:"tools,omitempty"`
+	// Scores are the judge's scores; nil when the judge failed
+	Scores *Scores `json:"scores,omitempty"`
+	// Failures are the unmet expectations
+	Failures []string `json:"failures,omitempty"`
+	// Error is set when the conversation itself failed
+	Error string `json:"error,omitempty"`
+}
+
+// Passed reports whether the conversation ran and met every expectation
+func (r CaseResult) Passed() bool {
+	return r.Error == "" && len(r.Failures) == 0
+}
+
+// Check replays the expectations of a case against the conversation history
+func Check(c Case, messages []llm.Message) CaseResult {
+	result := CaseResult{Name: c.Name}
+	var replies strings.Builder
+	for _, m := range messages {
+		if m.ToolCall != nil {
+			result.Tools = append(result.Tools, m.ToolCall.Name)
+		} else if m.Role == llm.RoleAssistant {
+			replies.WriteString(strings.ToLower(m.Content) + "\n")
+		}
+	}
+	for _, tool := range c.ExpectTools {
+		if !slices.Contains(result.Tools, tool) {
+			result.Failures = append(result.Failures, fmt.Sprintf("tool %s was not called", tool))
+		}
+	}
+	for _, phrase := range c.ExpectReplies {
+		if !strings.Contains(replies.String(), strings.ToLower(phrase)) {
+			result.Failures = append(result.Failures, fmt.Sprintf("no reply mentions %q", phrase))
+		}
+	}
+	return result
+}
+
+// Summary holds the regression metrics of a run
+type Summary struct {
+	Cases    int     `json:"cases"`
+	PassRate float64 `json:"pass_rate"`
+	// Scored is the number of cases the judge scored; the averages cover those only
+	Scored         int     `json:"scored"`
+	Helpfulness    float64 `json:"helpfulness"`
+	GoalCompletion float64 `json:"goal_completion"`
+	Safety         float64 `json:"safety"`
+}
+
+// Report is the result of replaying a corpus
+type Report struct {
+	Model   string       `json:"model,omitempty"`
+	Summary Summary      `json:"summary"`
+	Results []CaseResult `json:"results"`
+}
+
+// NewReport computes the summary of the case results
+func NewReport(model string, results []CaseResult) Report {
+	s := Summary{Cases: len(results)}
+	passed := 0
+	for _, r := range results {
+		if r.Passed() {
+			passed++
+		}
+		if r.Scores != nil {
+			s.Scored++
+			s.Helpfulness += r.Scores.Helpfulness
+			s.GoalCompletion += r.Scores.GoalCompletion
+			s.Safety += r.Scores.Safety
+		}
+	}
+	if s.Cases > 0 {
+		s.PassRate = float64(passed) / float64(s.Cases)
+	}
+	if s.Scored > 0 {
+		n := float64(s.Scored)
+		s.Helpfulness /= n
+		s.GoalCompletion /= n
+		s.Safety /= n
+	}
+	return Report{Model: model, Summary: s, Results: results}
+}
+
+// LoadReport reads a report written by an earlier run
+func LoadReport(path string) (Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Report{}, fmt.Errorf("reading report: %w", err)
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return Report{}, fmt.Errorf("parsing report: %w", err)
+	}
+	return report, nil
+}
+
+// Compare lists the regressions of a run against a baseline: cases that passed and now
+// fail, and average scores that dropped by more than the tolerance
+func Compare(baseline, current Report, tolerance float64) []string {
+	var regressions []string
+	passed := map[string]bool{}
+	for _, r := range baseline.Results {
+		passed[r.Name] = r.Passed()
+	}
+	for _, r := range current.Results {
+		if passed[r.Name] && !r.Passed() {
+			regressions = append(regressions, fmt.Sprintf("case %s no longer passes", r.Name))
+		}
+	}
+
+	if baseline.Summary.Scored == 0 || current.Summary.Scored == 0 {
+		return regressions
+	}
+	for _, score := range []struct {
+		name          string
+		before, after float64
+	}{
+		{"helpfulness", baseline.Summary.Helpfulness, current.Summary.Helpfulness},
+		{"goal_completion", baseline.Summary.GoalCompletion, current.Summary.GoalCompletion},
+		{"safety", baseline.Summary.Safety, current.Summary.Safety},
+	} {
+		if score.before-score.after > tolerance {
+			regressions = append(regressions, fmt.Sprintf("%s dropped from %.2f to %.2f", score.name, score.before, score.after))
+		}
+	}
+	return regressions
+}
@@ -0,0 +1,84 @@
+package evals
+
+import (
+	"context"
+	"database/sql"
+
+	_ "github.com/lib/pq"
+)
+
+// The table sits next to the search index's conversation_transcripts, keyed the same way
+const schema = `
+CREATE TABLE IF NOT EXISTS conversation_evaluations (
+	workflow_id     TEXT PRIMARY KEY,
+	goal            TEXT NOT NULL,
+	prompt_version  TEXT NOT NULL DEFAULT '',
+	helpfulness     DOUBLE PRECISION NOT NULL,
+	goal_completion DOUBLE PRECISION NOT NULL,
+	safety          DOUBLE PRECISION NOT NULL,
+	rationale       TEXT NOT NULL DEFAULT '',
+	evaluated_at    TIMESTAMPTZ NOT NULL
+);
+`
+
+// PostgresStore is a Store shared by every API server and worker using the same database
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore connects to Postgres and creates the evaluations table if needed
+func NewPostgresStore(ctx context.Context, databaseURL string) (*PostgresStore, error) {
+	db, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &PostgresStore{db: db}, nil
+}
+
+// Close closes the database connection
+func (p *PostgresStore) Close() error {
+	return p.db.Close()
+}
+
+// Save inserts or replaces the evaluation of a conversation
+func (p *PostgresStore) Save(ctx context.Context, e Evaluation) error {
+	_, err := p.db.ExecContext(ctx, `
+		INSERT INTO conversation_evaluations
+			(workflow_id, goal, prompt_version, helpfulness, goal_completion, safety, rationale, evaluated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (workflow_id) DO UPDATE
+		SET goal = EXCLUDED.goal, prompt_version = EXCLUDED.prompt_version,
+			helpfulness = EXCLUDED.helpfulness, goal_completion = EXCLUDED.goal_completion,
+			safety = EXCLUDED.safety, rationale = EXCLUDED.rationale, evaluated_at = EXCLUDED.evaluated_at`,
+		e.WorkflowID, e.Goal, e.PromptVersion, e.Scores.Helpfulness, e.Scores.GoalCompletion,
+		e.Scores.Safety, e.Scores.Rationale, e.EvaluatedAt)
+	return err
+}
+
+// Aggregate averages the selected evaluations per goal and prompt version
+func (p *PostgresStore) Aggregate(ctx context.Context, filter Filter) ([]Aggregate, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT goal, prompt_version, COUNT(*), AVG(helpfulness), AVG(goal_completion), AVG(safety)
+		FROM conversation_evaluations
+		WHERE ($1 = '' OR goal = $1) AND evaluated_at >= $2
+		GROUP BY goal, prompt_version
+		ORDER BY goal, prompt_version`, filter.Goal, filter.Since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aggregates := []Aggregate{}
+	for rows.Next() {
+		var a Aggregate
+		if err := rows.Scan(&a.Goal, &a.PromptVersion, &a.Conversations, &a.Helpfulness, &a.GoalCompletion, &a.Safety); err != nil {
+			return nil, err
+		}
+		aggregates = append(aggregates, a)
+	}
+	return aggregates, rows.Err()
+}
@@ -0,0 +1,150 @@
+package experiments
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+// Search attributes recording the experiment a conversation takes part in.
+// Both must exist as Keyword attributes in the namespace when experiments are enabled.
+var (
+	ExperimentKey = temporal.NewSearchAttributeKeyKeyword("AgentExperiment")
+	VariantKey    = temporal.NewSearchAttributeKeyKeyword("AgentVariant")
+)
+
+// Variant is one arm of an experiment. Empty fields keep the deployment defaults.
+type Variant struct {
+	Name         string `json:"name"`
+	Weight       int    `json:"weight"`
+	Model        string `json:"model,omitempty"`
+	SystemPrompt string `json:"system_prompt,omitempty"`
+}
+
+// Experiment splits conversations between prompt/model variants
+type Experiment struct {
+	Name   string `json:"name"`
+	Active bool   `json:"active"`
+	// Shadow experiments never change what users see: the variant a conversation is
+	// assigned to answers its turns alongside the conversation's model, and its replies
+	// are only recorded (see package shadow)
+	Shadow   bool      `json:"shadow,omitempty"`
+	Variants []Variant `json:"variants"`
+}
+
+// Assignment is the variant a conversation was assigned to
+type Assignment struct {
+	Experiment string  `json:"experiment,omitempty"`
+	Variant    Variant `json:"variant"`
+	// Shadow is the candidate of the active shadow experiment shadowing the conversation
+	Shadow *Assignment `json:"shadow,omitempty"`
+}
+
+// Load reads experiment definitions from a JSON file. An empty path disables experiments.
+func Load(path string) ([]Experiment, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading experiments file: %w", err)
+	}
+
+	var exps []Experiment
+	if err := json.Unmarshal(data, &exps); err != nil {
+		return nil, fmt.Errorf("parsing experiments file: %w", err)
+	}
+
+	active, shadows := 0, 0
+	for _, exp := range exps {
+		if err := exp.validate(); err != nil {
+			return nil, err
+		}
+		switch {
+		case exp.Active && exp.Shadow:
+			shadows++
+		case exp.Active:
+			active++
+		}
+	}
+	if active > 1 {
+		return nil, fmt.Errorf("at most one experiment can be active, found %d", active)
+	}
+	if shadows > 1 {
+		return nil, fmt.Errorf("at most one shadow experiment can be active, found %d", shadows)
+	}
+	return exps, nil
+}
+
+// Active returns the active experiment, if any; shadow experiments are left out
+func Active(exps []Experiment) (Experiment, bool) {
+	for _, exp := range exps {
+		if exp.Active && !exp.Shadow {
+			return exp, true
+		}
+	}
+	return Experiment{}, false
+}
+
+// ActiveShadow returns the active shadow experiment, if any
+func ActiveShadow(exps []Experiment) (Experiment, bool) {
+	for _, exp := range exps {
+		if exp.Active && exp.Shadow {
+			return exp, true
+		}
+	}
+	return Experiment{}, false
+}
+
+// Find returns the experiment with the given name
+func Find(exps []Experiment, name string) (Experiment, bool) {
+	for _, exp := range exps {
+		if exp.Name == name {
+			return exp, true
+		}
+	}
+	return Experiment{}, false
+}
+
+// Assign deterministically picks a variant for the workflow ID, honouring variant weights
+func (e Experiment) Assign(workflowID string) Variant {
+	total := 0
+	for _, v := range e.Variants {
+		total += v.Weight
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(e.Name + "/" + workflowID))
+	bucket := int(h.Sum32() % uint32(total))
+
+	for _, v := range e.Variants {
+		if bucket < v.Weight {
+			return v
+		}
+		bucket -= v.Weight
+	}
+	return e.Variants[len(e.Variants)-1]
+}
+
+// validate checks that an experiment can assign conversations
+func (e Experiment) validate() error {
+	if e.Name == "" {
+		return fmt.Errorf("experiment name is required")
+	}
+	if len(e.Variants) == 0 {
+		return fmt.Errorf("experiment %q has no variants", e.Name)
+	}
+	for _, v := range e.Variants {
+		if v.Name == "" {
+			return fmt.Errorf("experiment %q has a variant without a name", e.Name)
+		}
+		if v.Weight <= 0 {
+			return fmt.Errorf("variant %q of experiment %q must have a positive weight", v.Name, e.Name)
+		}
+	}
+	return nil
+}
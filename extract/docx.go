@@ -0,0 +1,122 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"temporal-ai-agent/chunking"
+)
+
+// maxDOCXPart bounds the uncompressed size of the parts read from a DOCX archive
+const maxDOCXPart = 64 << 20
+
+// DOCX returns the text of a Word document, one paragraph per block. Paragraphs styled
+// as headings become markdown headings.
+func DOCX(data []byte) (Result, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return Result{}, fmt.Errorf("reading docx: %w", err)
+	}
+	body, err := readPart(archive, "word/document.xml")
+	if err != nil {
+		return Result{}, err
+	}
+
+	var (
+		paragraphs []string
+		para       strings.Builder
+		heading    int
+		inText     bool
+	)
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Result{}, fmt.Errorf("parsing docx: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				para.Reset()
+				heading = 0
+			case "pStyle":
+				heading = headingStyle(attr(t, "val"))
+			case "t":
+				inText = true
+			case "tab":
+				para.WriteString("\t")
+			case "br", "cr":
+				para.WriteString("\n")
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				text := strings.TrimSpace(para.String())
+				if text == "" {
+					continue
+				}
+				if heading > 0 {
+					text = strings.Repeat("#", heading) + " " + collapse(text)
+				}
+				paragraphs = append(paragraphs, text)
+			}
+		case xml.CharData:
+			if inText {
+				para.Write(t)
+			}
+		}
+	}
+
+	res := Result{Text: strings.Join(paragraphs, "\n\n"), Type: chunking.TypeMarkdown}
+	if core, err := readPart(archive, "docProps/core.xml"); err == nil {
+		var props struct {
+			Title string `xml:"title"`
+		}
+		if xml.Unmarshal(core, &props) == nil {
+			res.Title = strings.TrimSpace(props.Title)
+		}
+	}
+	return res, nil
+}
+
+// readPart reads a part of an Office archive
+func readPart(archive *zip.Reader, name string) ([]byte, error) {
+	f, err := archive.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("reading docx part %s: %w", name, err)
+	}
+	defer f.Close()
+	return io.ReadAll(io.LimitReader(f, maxDOCXPart))
+}
+
+// headingStyle returns the level of a heading paragraph style (Heading1, Title, ...), or 0
+func headingStyle(style string) int {
+	switch {
+	case style == "Title":
+		return 1
+	case strings.HasPrefix(style, "Heading") && len(style) == len("Heading")+1:
+		if level := int(style[len(style)-1] - '0'); level >= 1 && level <= 6 {
+			return level
+		}
+	}
+	return 0
+}
+
+// attr returns the value of an attribute by local name
+func attr(el xml.StartElement, name string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
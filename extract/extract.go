@@ -0,0 +1,162 @@
+// Package extract turns documents (HTML, PDF, DOCX, plain text and images) into plain text
+// for indexing. Documents without a text layer, such as scans and images, are handed to an
+// OCR backend when one is configured.
+package extract
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"temporal-ai-agent/chunking"
+	"unicode/utf8"
+)
+
+// Formats
+const (
+	FormatText     = "text"
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+	FormatPDF      = "pdf"
+	FormatDOCX     = "docx"
+	FormatImage    = "image"
+)
+
+// ErrUnsupported is returned for documents of an unknown format
+var ErrUnsupported = errors.New("unsupported document format")
+
+// ErrNoText is returned for documents without a text layer when no OCR backend is configured
+var ErrNoText = errors.New("document has no extractable text")
+
+// Result is the text of a document
+type Result struct {
+	// Title is the title found in the document, if any
+	Title string `json:"title,omitempty"`
+	Text  string `json:"text"`
+	// Format is the detected format of the document
+	Format string `json:"format"`
+	// Type is the chunking type of the text; HTML and DOCX headings become markdown headings
+	Type string `json:"type"`
+	// OCR reports whether the text was recognized by the OCR backend
+	OCR bool `json:"ocr,omitempty"`
+}
+
+// Extractor extracts the text of documents
+type Extractor struct {
+	// OCR recognizes documents without a text layer; nil fails them with ErrNoText
+	OCR OCR
+}
+
+// Extract returns the text of a document. The format is detected from the content type,
+// the file name and finally the content itself.
+func (e *Extractor) Extract(ctx context.Context, name, contentType string, data []byte) (Result, error) {
+	format := Detect(name, contentType, data)
+	var (
+		res Result
+		err error
+	)
+	switch format {
+	case FormatText:
+		res = Result{Text: string(data), Type: chunking.TypeOf(name)}
+	case FormatMarkdown:
+		res = Result{Text: string(data), Type: chunking.TypeMarkdown}
+	case FormatHTML:
+		res, err = HTML(data)
+	case FormatPDF:
+		res, err = PDF(data)
+	case FormatDOCX:
+		res, err = DOCX(data)
+	case FormatImage:
+		err = ErrNoText
+	default:
+		return Result{}, fmt.Errorf("%w: %s", ErrUnsupported, describe(name, contentType))
+	}
+	res.Format = format
+
+	if errors.Is(err, ErrNoText) && e.OCR != nil {
+		text, ocrErr := e.OCR.Recognize(ctx, data, mediaType(format, contentType))
+		if ocrErr != nil {
+			return Result{}, fmt.Errorf("recognizing text: %w", ocrErr)
+		}
+		res.Text, res.Type, res.OCR, err = text, chunking.TypeText, true, nil
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	if strings.TrimSpace(res.Text) == "" {
+		return Result{}, ErrNoText
+	}
+	return res, nil
+}
+
+// Detect returns the format of a document
+func Detect(name, contentType string, data []byte) string {
+	if mt, _, err := mime.ParseMediaType(contentType); err == nil {
+		switch {
+		case mt == "text/html" || mt == "application/xhtml+xml":
+			return FormatHTML
+		case mt == "application/pdf":
+			return FormatPDF
+		case mt == "application/vnd.openxmlformats-officedocument.wordprocessingml.document":
+			return FormatDOCX
+		case mt == "text/markdown":
+			return FormatMarkdown
+		case strings.HasPrefix(mt, "image/"):
+			return FormatImage
+		case strings.HasPrefix(mt, "text/"):
+			return FormatText
+		}
+	}
+	switch strings.ToLower(path.Ext(name)) {
+	case ".html", ".htm":
+		return FormatHTML
+	case ".pdf":
+		return FormatPDF
+	case ".docx":
+		return FormatDOCX
+	case ".md", ".markdown":
+		return FormatMarkdown
+	case ".png", ".jpg", ".jpeg", ".tif", ".tiff", ".gif", ".webp":
+		return FormatImage
+	}
+	// Sniff the content of documents served or named generically
+	switch sniffed := http.DetectContentType(data); {
+	case bytes.HasPrefix(data, []byte("%PDF-")):
+		return FormatPDF
+	case strings.HasPrefix(sniffed, "text/html"):
+		return FormatHTML
+	case strings.HasPrefix(sniffed, "image/"):
+		return FormatImage
+	case strings.HasPrefix(sniffed, "text/plain") && utf8.Valid(data):
+		return FormatText
+	}
+	return ""
+}
+
+// mediaType is the content type sent to the OCR backend
+func mediaType(format, contentType string) string {
+	switch {
+	case contentType != "":
+		return contentType
+	case format == FormatPDF:
+		return "application/pdf"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// describe names a document in errors
+func describe(name, contentType string) string {
+	switch {
+	case contentType != "" && name != "":
+		return fmt.Sprintf("%s (%s)", name, contentType)
+	case contentType != "":
+		return contentType
+	default:
+		return name
+	}
+}
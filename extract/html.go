@@ -0,0 +1,159 @@
+package extract
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+	"temporal-ai-agent/chunking"
+
+	"golang.org/x/net/html"
+)
+
+// blockElements end the line of text they are part of
+var blockElements = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "main": true, "header": true,
+	"blockquote": true, "ul": true, "ol": true, "li": true, "table": true, "tr": true,
+	"dl": true, "dt": true, "dd": true, "br": true, "hr": true, "figure": true, "pre": true,
+}
+
+// HTML returns the visible text of an HTML page as markdown: headings become markdown
+// headings and list items bullets, so the page is chunked by section. Scripts, styles and
+// navigation are skipped.
+func HTML(data []byte) (Result, error) {
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return Result{}, err
+	}
+
+	var (
+		title string
+		b     strings.Builder
+	)
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "title":
+				if title == "" {
+					title = collapse(textOf(n))
+				}
+				return
+			case "script", "style", "noscript", "nav", "footer", "template", "svg":
+				return
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				if text := collapse(textOf(n)); text != "" {
+					b.WriteString("\n\n" + strings.Repeat("#", int(n.Data[1]-'0')) + " " + text + "\n\n")
+				}
+				return
+			case "pre":
+				b.WriteString("\n\n```\n" + strings.Trim(textOf(n), "\n") + "\n```\n\n")
+				return
+			case "li":
+				b.WriteString("\n- ")
+			}
+		}
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+		if n.Type == html.ElementNode && blockElements[n.Data] {
+			b.WriteString("\n\n")
+		}
+	}
+	walk(doc)
+
+	return Result{Title: title, Text: tidy(b.String()), Type: chunking.TypeMarkdown}, nil
+}
+
+// textOf returns the raw text below a node
+func textOf(n *html.Node) string {
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+// collapse normalizes the whitespace of a line
+func collapse(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// tidy collapses the whitespace of each line outside fenced code blocks, and the blank
+// lines between them, into paragraphs separated by a single blank line
+func tidy(text string) string {
+	var (
+		lines  []string
+		fenced bool
+		blank  bool
+	)
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, "```") {
+			fenced = !fenced
+		} else if !fenced {
+			line = collapse(line)
+		}
+		if line == "" && !fenced {
+			blank = len(lines) > 0
+			continue
+		}
+		if line == "-" {
+			// A list item without inline text
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+		}
+		blank = false
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Links returns the http(s) links of an HTML page resolved against its URL, without their
+// fragment and in page order. Links marked nofollow are left out.
+func Links(data []byte, base *url.URL) []string {
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	var links []string
+	seen := map[string]bool{}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "a" && !strings.Contains(htmlAttr(n, "rel"), "nofollow") {
+			if ref, err := url.Parse(strings.TrimSpace(htmlAttr(n, "href"))); err == nil {
+				link := base.ResolveReference(ref)
+				link.Fragment = ""
+				if (link.Scheme == "http" || link.Scheme == "https") && !seen[link.String()] {
+					seen[link.String()] = true
+					links = append(links, link.String())
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+	return links
+}
+
+// htmlAttr returns the value of an attribute of an element
+func htmlAttr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,63 @@
+package extract
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"temporal-ai-agent/httpclient"
+	"time"
+)
+
+// OCR recognizes the text of documents without a text layer
+type OCR interface {
+	Recognize(ctx context.Context, data []byte, contentType string) (string, error)
+}
+
+// NewOCR returns the OCR backend registered under the given name; an empty name disables OCR.
+// opts tunes the connection pool of the backend's client.
+func NewOCR(name, endpoint string, opts httpclient.Options) (OCR, error) {
+	switch name {
+	case "":
+		return nil, nil
+	case "http":
+		if endpoint == "" {
+			return nil, fmt.Errorf("OCR_ENDPOINT is required for the http OCR backend")
+		}
+		return &HTTPOCR{Endpoint: endpoint, Client: httpclient.New(5*time.Minute, opts)}, nil
+	default:
+		return nil, fmt.Errorf("unknown OCR backend %q", name)
+	}
+}
+
+// HTTPOCR posts documents to an OCR service (e.g. a Tesseract or cloud OCR wrapper) that
+// answers with their text as plain text
+type HTTPOCR struct {
+	Endpoint string
+	Client   *http.Client
+}
+
+// Recognize posts the document with its content type and returns the response body
+func (o *HTTPOCR) Recognize(ctx context.Context, data []byte, contentType string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.Endpoint, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Accept", "text/plain")
+
+	resp, err := o.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("OCR service returned %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	return string(body), nil
+}
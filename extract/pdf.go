@@ -0,0 +1,72 @@
+package extract
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"temporal-ai-agent/chunking"
+	"unicode"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// PDF returns the text of a PDF's pages, read with github.com/ledongthuc/pdf: cross-reference
+// streams, object streams, font encodings and ToUnicode maps are supported, password-protected
+// PDFs are not. PDFs without a text layer, such as scans, and those whose fonts map to no
+// Unicode text return ErrNoText so they can go through OCR.
+func PDF(data []byte) (res Result, err error) {
+	// The reader panics on some malformed files rather than returning an error
+	defer func() {
+		if r := recover(); r != nil {
+			res, err = Result{}, fmt.Errorf("reading PDF: %v", r)
+		}
+	}()
+
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return Result{}, fmt.Errorf("reading PDF: %w", err)
+	}
+	var b strings.Builder
+	for i := 1; i <= r.NumPage(); i++ {
+		// A page that cannot be read is skipped rather than failing the document
+		text, err := r.Page(i).GetPlainText(nil)
+		if err != nil {
+			continue
+		}
+		b.WriteString(text)
+		b.WriteString("\n")
+	}
+
+	text := tidyPDF(b.String())
+	if !readable(text) {
+		return Result{}, ErrNoText
+	}
+	return Result{Text: text, Type: chunking.TypeText}, nil
+}
+
+// tidyPDF collapses the whitespace of each line and drops empty lines
+func tidyPDF(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = collapse(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// readable reports whether extracted text is mostly printable letters, digits and
+// punctuation; text drawn with fonts lacking a Unicode mapping decodes to control characters
+func readable(text string) bool {
+	if strings.TrimSpace(text) == "" {
+		return false
+	}
+	printable, total := 0, 0
+	for _, r := range text {
+		total++
+		if unicode.IsPrint(r) || unicode.IsSpace(r) {
+			printable++
+		}
+	}
+	return float64(printable) >= 0.9*float64(total)
+}
@@ -0,0 +1,36 @@
+package extract
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPDF(t *testing.T) {
+	data, err := os.ReadFile("testdata/text.pdf")
+	require.NoError(t, err)
+
+	res, err := PDF(data)
+	require.NoError(t, err)
+	require.Contains(t, res.Text, "Refund policy")
+	require.Contains(t, res.Text, "Orders can be returned within 30 days.")
+	require.Contains(t, res.Text, "Contact support for damaged items.")
+}
+
+// A page drawn without text goes to OCR
+func TestPDFWithoutText(t *testing.T) {
+	data, err := os.ReadFile("testdata/scan.pdf")
+	require.NoError(t, err)
+
+	_, err = PDF(data)
+	require.ErrorIs(t, err, ErrNoText)
+}
+
+func TestPDFMalformed(t *testing.T) {
+	for _, data := range [][]byte{nil, []byte("not a pdf"), []byte("%PDF-1.4\n1 0 obj\n<< /Type /Catalog")} {
+		_, err := PDF(data)
+		require.Error(t, err)
+		require.NotErrorIs(t, err, ErrNoText)
+	}
+}
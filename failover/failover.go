@@ -0,0 +1,154 @@
+package failover
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"go.temporal.io/sdk/client"
+)
+
+// Health check settings
+const (
+	checkTimeout = 5 * time.Second
+	// failureThreshold is the number of consecutive failed health checks that trigger a failover
+	failureThreshold = 3
+)
+
+// Client keeps a Temporal client connected to whichever of the primary and secondary
+// endpoints is healthy. Components holding the client register with OnFailover to pick
+// up the new client after a failover.
+type Client struct {
+	mu         sync.RWMutex
+	current    client.Client
+	endpoints  []string
+	active     int
+	options    func(hostPort string) client.Options
+	onFailover []func(client.Client)
+}
+
+// Dial connects to the primary endpoint, or to the secondary one if the primary is
+// unreachable. An empty secondary disables failover.
+func Dial(primary, secondary string, options func(hostPort string) client.Options) (*Client, error) {
+	fc := &Client{endpoints: []string{primary}, options: options}
+	if secondary != "" {
+		fc.endpoints = append(fc.endpoints, secondary)
+	}
+
+	var errs []error
+	for i, endpoint := range fc.endpoints {
+		c, err := dial(endpoint, options)
+		if err == nil {
+			fc.current, fc.active = c, i
+			return fc, nil
+		}
+		log.Printf("Unable to connect to Temporal at %s: %v", endpoint, err)
+		errs = append(errs, err)
+	}
+	return nil, fmt.Errorf("no Temporal endpoint is reachable: %v", errs)
+}
+
+// dial connects to an endpoint and checks that it serves requests
+func dial(endpoint string, options func(hostPort string) client.Options) (client.Client, error) {
+	c, err := client.Dial(options(endpoint))
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+	if _, err := c.CheckHealth(ctx, &client.CheckHealthRequest{}); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Current returns the client of the active endpoint
+func (fc *Client) Current() client.Client {
+	fc.mu.RLock()
+	defer fc.mu.RUnlock()
+	return fc.current
+}
+
+// Endpoint returns the address of the active endpoint
+func (fc *Client) Endpoint() string {
+	fc.mu.RLock()
+	defer fc.mu.RUnlock()
+	return fc.endpoints[fc.active]
+}
+
+// OnFailover registers a function called with the new client after a failover, before
+// the previous client is closed
+func (fc *Client) OnFailover(fn func(client.Client)) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	fc.onFailover = append(fc.onFailover, fn)
+}
+
+// Watch checks the active endpoint every interval until ctx is done, and re-dials the
+// other endpoint once the active one failed failureThreshold checks in a row. It returns
+// immediately when no secondary endpoint is configured.
+func (fc *Client) Watch(ctx context.Context, interval time.Duration) {
+	if len(fc.endpoints) < 2 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		_, err := fc.Current().CheckHealth(checkCtx, &client.CheckHealthRequest{})
+		cancel()
+		if err == nil {
+			failures = 0
+			continue
+		}
+
+		failures++
+		log.Printf("Temporal health check at %s failed (%d/%d): %v", fc.Endpoint(), failures, failureThreshold, err)
+		if failures >= failureThreshold && fc.failover() {
+			failures = 0
+		}
+	}
+}
+
+// failover switches to the other endpoint if it is healthy, and reports whether it did
+func (fc *Client) failover() bool {
+	fc.mu.RLock()
+	next := (fc.active + 1) % len(fc.endpoints)
+	fc.mu.RUnlock()
+
+	c, err := dial(fc.endpoints[next], fc.options)
+	if err != nil {
+		log.Printf("Unable to fail over to Temporal at %s: %v", fc.endpoints[next], err)
+		return false
+	}
+
+	fc.mu.Lock()
+	previous := fc.current
+	fc.current, fc.active = c, next
+	callbacks := fc.onFailover
+	fc.mu.Unlock()
+
+	log.Printf("Failed over to Temporal at %s", fc.endpoints[next])
+	for _, fn := range callbacks {
+		fn(c)
+	}
+	previous.Close()
+	return true
+}
+
+// Close closes the client of the active endpoint
+func (fc *Client) Close() {
+	fc.Current().Close()
+}
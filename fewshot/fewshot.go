@@ -0,0 +1,230 @@
+package fewshot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned when an example does not exist
+var ErrNotFound = errors.New("example not found")
+
+// Example is a curated user/assistant exchange shown to the model for a goal
+type Example struct {
+	ID        string    `json:"id"`
+	Goal      string    `json:"goal"`
+	User      string    `json:"user"`
+	Assistant string    `json:"assistant"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Store persists few-shot examples
+type Store interface {
+	List(goal string) ([]Example, error)
+	Add(example Example) (Example, error)
+	Remove(id string) error
+}
+
+// Embedder turns texts into vectors for similarity-based selection
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+// FileStore keeps examples in a JSON file so the API and the worker can share them
+type FileStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileStore creates a store backed by the file at path, created on first write
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// List returns the examples of a goal, or all examples when goal is empty
+func (s *FileStore) List(goal string) ([]Example, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	if goal == "" {
+		return all, nil
+	}
+
+	examples := []Example{}
+	for _, e := range all {
+		if e.Goal == goal {
+			examples = append(examples, e)
+		}
+	}
+	return examples, nil
+}
+
+// Add stores a new example and returns it with its assigned ID
+func (s *FileStore) Add(example Example) (Example, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all, err := s.read()
+	if err != nil {
+		return Example{}, err
+	}
+
+	example.CreatedAt = time.Now().UTC()
+	example.ID = fmt.Sprintf("ex-%d", example.CreatedAt.UnixNano())
+	all = append(all, example)
+	return example, s.write(all)
+}
+
+// Remove deletes the example with the given ID
+func (s *FileStore) Remove(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all, err := s.read()
+	if err != nil {
+		return err
+	}
+	for i, e := range all {
+		if e.ID == id {
+			return s.write(append(all[:i], all[i+1:]...))
+		}
+	}
+	return ErrNotFound
+}
+
+// read loads all examples, treating a missing file as empty
+func (s *FileStore) read() ([]Example, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Example{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var examples []Example
+	if err := json.Unmarshal(data, &examples); err != nil {
+		return nil, fmt.Errorf("parsing examples file: %w", err)
+	}
+	return examples, nil
+}
+
+// write atomically replaces the examples file
+func (s *FileStore) write(examples []Example) error {
+	data, err := json.MarshalIndent(examples, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".examples-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// Select returns up to limit examples most similar to the query. Embedding similarity is
+// used when an embedder is given; otherwise examples are ranked by word overlap.
+func Select(ctx context.Context, examples []Example, query string, limit int, embedder Embedder) ([]Example, error) {
+	if len(examples) <= limit {
+		return examples, nil
+	}
+
+	scores := make([]float64, len(examples))
+	if embedder != nil {
+		texts := []string{query}
+		for _, e := range examples {
+			texts = append(texts, e.User)
+		}
+		vectors, err := embedder.Embed(ctx, texts)
+		if err != nil {
+			return nil, err
+		}
+		for i := range examples {
+			scores[i] = cosine(vectors[0], vectors[i+1])
+		}
+	} else {
+		for i, e := range examples {
+			scores[i] = overlap(query, e.User)
+		}
+	}
+
+	indexes := make([]int, len(examples))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(a, b int) bool {
+		return scores[indexes[a]] > scores[indexes[b]]
+	})
+
+	selected := make([]Example, 0, limit)
+	for _, i := range indexes[:limit] {
+		selected = append(selected, examples[i])
+	}
+	return selected, nil
+}
+
+// overlap is the Jaccard similarity of the lower-cased word sets of a and b
+func overlap(a, b string) float64 {
+	wordsA := wordSet(a)
+	wordsB := wordSet(b)
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for w := range wordsA {
+		if wordsB[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(wordsA)+len(wordsB)-shared)
+}
+
+// wordSet splits text into a set of lower-cased words
+func wordSet(text string) map[string]bool {
+	words := map[string]bool{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !('a' <= r && r <= 'z' || '0' <= r && r <= '9')
+	}) {
+		words[w] = true
+	}
+	return words
+}
+
+// cosine is the cosine similarity of two vectors
+func cosine(a, b []float64) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		if i >= len(b) {
+			break
+		}
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,15 @@
+package goals
+
+// analysis is the data-analysis goal: it explores the analytics database with SQL and
+// computes statistics and charts over the results with the code interpreter
+var analysis = Goal{
+	Name:        "analysis",
+	Description: "Data analyst: answers questions about the analytics database with SQL, statistics and charts.",
+	Prompt: `You are a data analyst answering questions about the analytics database.
+- Call list_tables before writing your first query, and only use the tables and columns it lists.
+- Query with query_sql, aggregating in SQL (GROUP BY, SUM, AVG) so results stay small.
+- Use run_code for what SQL does poorly, such as growth rates, correlations or charts. Pass the CSV returned by query_sql as its data argument; it is available as data.csv. Save charts as PNG files.
+- Base every number you give on a tool result, and mention the query behind it. Show charts as links to the files run_code returns.
+- When a query or the code fails, read the error, fix it and try again rather than guessing the answer.`,
+	Tools: []string{"current_time", "list_tables", "query_sql", "run_code"},
+}
@@ -0,0 +1,76 @@
+package goals
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Default is the goal of conversations started without one
+const Default = "default"
+
+// Goal is a use case conversations are started for: the instructions added to the agent's
+// system prompt and the tools it may call
+type Goal struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Prompt is added to the system prompt of the goal's conversations
+	Prompt string `json:"prompt,omitempty"`
+	// Tools lists the tools the goal's conversations may call, within the tool policy; tools
+	// the worker does not have, such as search_knowledge without a knowledge file, are skipped
+	Tools []string `json:"tools"`
+}
+
+// Permits reports whether conversations of the goal may call a tool
+func (g Goal) Permits(tool string) bool {
+	return slices.Contains(g.Tools, tool)
+}
+
+// Catalog holds the goals conversations can be started for
+type Catalog struct {
+	goals []Goal
+}
+
+// Builtin returns the catalog of the goals shipped with the agent
+func Builtin() *Catalog {
+	return &Catalog{goals: []Goal{
+		{
+			Name:        Default,
+			Description: "Travel and productivity assistant: flights, calendar, tickets and inbox.",
+			Tools: []string{
+				"current_time", "search_flights", "book_flight", "list_calendar_events",
+				"list_tickets", "list_inbox", "search_knowledge",
+			},
+		},
+		support,
+		kubernetes,
+		analysis,
+	}}
+}
+
+// Find returns the named goal
+func (c *Catalog) Find(name string) (Goal, error) {
+	for _, g := range c.goals {
+		if g.Name == name {
+			return g, nil
+		}
+	}
+	return Goal{}, fmt.Errorf("unknown goal %q", name)
+}
+
+// List returns the goals in catalog order
+func (c *Catalog) List() []Goal {
+	return slices.Clone(c.goals)
+}
+
+// Grant lets the conversations of a goal call a tool, e.g. one added by a plugin
+func (c *Catalog) Grant(goal, tool string) error {
+	for i := range c.goals {
+		if c.goals[i].Name == goal {
+			if !c.goals[i].Permits(tool) {
+				c.goals[i].Tools = append(slices.Clone(c.goals[i].Tools), tool)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown goal %q", goal)
+}
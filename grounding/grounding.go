@@ -0,0 +1,112 @@
+package grounding
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"temporal-ai-agent/activities/llm"
+)
+
+// Modes decide what happens to answers with unsupported claims
+const (
+	// ModeOff skips verification
+	ModeOff = ""
+	// ModeFlag records the unsupported claims and keeps the answer
+	ModeFlag = "flag"
+	// ModeRegenerate asks the model once for an answer without the unsupported claims
+	ModeRegenerate = "regenerate"
+)
+
+// ParseMode validates a grounding mode
+func ParseMode(mode string) (string, error) {
+	switch mode {
+	case ModeOff, ModeFlag, ModeRegenerate:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown grounding mode %q, expected %s or %s", mode, ModeFlag, ModeRegenerate)
+	}
+}
+
+// Claim is a factual statement of an answer, with whether the sources support it
+type Claim struct {
+	Text      string `json:"claim"`
+	Supported bool   `json:"supported"`
+	// Sources cite the passages supporting the claim, e.g. docs/refunds.md#0
+	Sources []string `json:"sources,omitempty"`
+}
+
+// Verdict is the verifier's judgement of an answer
+type Verdict struct {
+	Claims []Claim `json:"claims"`
+}
+
+// Unsupported returns the claims the sources do not support
+func (v Verdict) Unsupported() []string {
+	var claims []string
+	for _, c := range v.Claims {
+		if !c.Supported {
+			claims = append(claims, c.Text)
+		}
+	}
+	return claims
+}
+
+// Grounded reports whether every claim is supported; an answer without claims is
+func (v Verdict) Grounded() bool {
+	return len(v.Unsupported()) == 0
+}
+
+// verifierPrompt instructs the verifier model
+const verifierPrompt = `You check whether an AI assistant's answer is supported by the documents it retrieved.
+Split the answer into its factual claims, leaving out greetings, questions and offers to help.
+For each claim, decide whether the documents state or directly imply it, and cite the
+[namespace/document#index] labels of the passages supporting it.
+Reply with a JSON object only, e.g. {"claims": [{"claim": "Refunds take 14 days.", "supported": true, "sources": ["docs/refunds.md#0"]}]}.`
+
+// Request builds the completion request asking the verifier model to check an answer to
+// a question against the retrieved passages
+func Request(model, question, answer string, sources []string) llm.Request {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Question: %s\n\nDocuments:\n", question)
+	for _, s := range sources {
+		b.WriteString(s + "\n")
+	}
+	fmt.Fprintf(&b, "\nAnswer: %s", answer)
+	return llm.Request{
+		Model: model,
+		Messages: []llm.Message{
+			{Role: llm.RoleSystem, Content: verifierPrompt},
+			{Role: llm.RoleUser, Content: b.String()},
+		},
+	}
+}
+
+// Parse reads the verifier's reply, tolerating text or code fences around the JSON object
+func Parse(reply string) (Verdict, error) {
+	start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return Verdict{}, fmt.Errorf("verifier reply has no JSON object: %q", reply)
+	}
+	var verdict Verdict
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &verdict); err != nil {
+		return Verdict{}, fmt.Errorf("parsing verifier reply: %w", err)
+	}
+	for _, c := range verdict.Claims {
+		if strings.TrimSpace(c.Text) == "" {
+			return Verdict{}, fmt.Errorf("verifier reply has an empty claim")
+		}
+	}
+	return verdict, nil
+}
+
+// Revision is the instruction asking the model to rewrite an answer without its
+// unsupported claims
+func Revision(unsupported []string) string {
+	var b strings.Builder
+	b.WriteString("Your answer makes claims the retrieved documents do not support:\n")
+	for _, claim := range unsupported {
+		b.WriteString("- " + claim + "\n")
+	}
+	b.WriteString("Rewrite your answer using only what the documents say. If they do not answer part of the question, say so instead of guessing.")
+	return b.String()
+}
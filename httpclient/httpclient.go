@@ -0,0 +1,63 @@
+// Package httpclient builds the HTTP clients of the providers the worker calls over and
+// over (LLM APIs, search, sandboxes). Each provider gets one client, created at startup and
+// shared by all activity invocations, whose transport keeps enough idle connections per
+// host to reuse them under load instead of paying a TLS handshake per request. Requests are
+// traced as part of the activity sending them.
+package httpclient
+
+import (
+	"net"
+	"net/http"
+	"temporal-ai-agent/tracing"
+	"time"
+)
+
+// Defaults used for the Options left at zero
+const (
+	DefaultMaxIdleConnsPerHost = 32
+	DefaultIdleConnTimeout     = 90 * time.Second
+)
+
+// Options tune the connection pool of a client
+type Options struct {
+	// MaxIdleConnsPerHost is how many idle connections to a host are kept for reuse
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost caps the connections to a host, requests over it wait; 0 is unlimited
+	MaxConnsPerHost int
+	// IdleConnTimeout is how long an idle connection is kept open
+	IdleConnTimeout time.Duration
+}
+
+// Transport returns a transport with the options' connection pool. It keeps connections
+// alive and negotiates HTTP/2 with the servers supporting it, which multiplexes requests
+// over a single connection.
+func Transport(opts Options) *http.Transport {
+	perHost := opts.MaxIdleConnsPerHost
+	if perHost <= 0 {
+		perHost = DefaultMaxIdleConnsPerHost
+	}
+	idleTimeout := opts.IdleConnTimeout
+	if idleTimeout <= 0 {
+		idleTimeout = DefaultIdleConnTimeout
+	}
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          4 * perHost,
+		MaxIdleConnsPerHost:   perHost,
+		MaxConnsPerHost:       opts.MaxConnsPerHost,
+		IdleConnTimeout:       idleTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+}
+
+// New returns a client with its own tuned, traced transport, whose requests time out after
+// timeout
+func New(timeout time.Duration, opts Options) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: tracing.Transport(Transport(opts))}
+}
@@ -0,0 +1,233 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// maxLogBytes bounds the logs read from the API server
+const maxLogBytes = 256 << 10
+
+// API reaches a cluster through its API server with client-go
+type API struct {
+	Client kubernetes.Interface
+}
+
+// newAPI returns an API client. Without an API URL it uses client-go's in-cluster
+// configuration, i.e. the pod's service account; the token file is read again as it is
+// rotated.
+func newAPI(opts Options) (*API, error) {
+	config := &rest.Config{
+		Host:            opts.APIURL,
+		BearerTokenFile: serviceAccountToken,
+		TLSClientConfig: rest.TLSClientConfig{CAFile: serviceAccountCA},
+	}
+	if opts.APIURL == "" {
+		var err error
+		config, err = rest.InClusterConfig()
+		if errors.Is(err, rest.ErrNotInCluster) {
+			return nil, fmt.Errorf("K8S_API_URL is required outside a cluster")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("configuring the in-cluster client: %w", err)
+		}
+	}
+	if opts.TokenFile != "" {
+		config.BearerToken, config.BearerTokenFile = "", opts.TokenFile
+	}
+	if opts.CAFile != "" {
+		config.TLSClientConfig = rest.TLSClientConfig{CAFile: opts.CAFile}
+	}
+	config.Timeout = 30 * time.Second
+
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("creating the cluster client: %w", err)
+	}
+	return &API{Client: client}, nil
+}
+
+// apiError names what was requested in the error of an API call
+func apiError(what string, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case apierrors.IsNotFound(err):
+		return fmt.Errorf("%s: %w", what, ErrNotFound)
+	case apierrors.IsForbidden(err):
+		return fmt.Errorf("%s: forbidden by the cluster's RBAC", what)
+	default:
+		return fmt.Errorf("%s: %w", what, err)
+	}
+}
+
+// pod converts a pod of the API
+func pod(p *corev1.Pod) Pod {
+	out := Pod{
+		Name:      p.Name,
+		Namespace: p.Namespace,
+		Phase:     string(p.Status.Phase),
+		Node:      p.Spec.NodeName,
+	}
+	if p.Status.StartTime != nil {
+		out.Started = p.Status.StartTime.Time
+	}
+	for _, c := range p.Status.Conditions {
+		out.Conditions = append(out.Conditions, Condition{
+			Type: string(c.Type), Status: string(c.Status), Reason: c.Reason, Message: c.Message,
+		})
+	}
+	for _, spec := range p.Spec.Containers {
+		c := Container{Name: spec.Name, Image: spec.Image, State: "pending"}
+		for _, status := range p.Status.ContainerStatuses {
+			if status.Name != spec.Name {
+				continue
+			}
+			c.Ready = status.Ready
+			c.Restarts = int(status.RestartCount)
+			switch {
+			case status.State.Running != nil:
+				c.State = "running"
+			case status.State.Waiting != nil:
+				c.State = status.State.Waiting.Reason
+			case status.State.Terminated != nil:
+				c.State = status.State.Terminated.Reason
+			}
+		}
+		out.Containers = append(out.Containers, c)
+	}
+	return out
+}
+
+// Pods lists the pods of a namespace matching a label selector
+func (a *API) Pods(ctx context.Context, namespace, selector string) ([]Pod, error) {
+	list, err := a.Client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, apiError("listing pods in "+namespace, err)
+	}
+	pods := make([]Pod, len(list.Items))
+	for i := range list.Items {
+		pods[i] = pod(&list.Items[i])
+	}
+	return pods, nil
+}
+
+// Pod returns a pod
+func (a *API) Pod(ctx context.Context, namespace, name string) (Pod, error) {
+	p, err := a.Client.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return Pod{}, apiError(fmt.Sprintf("pod %s/%s", namespace, name), err)
+	}
+	return pod(p), nil
+}
+
+// Deployment returns a deployment
+func (a *API) Deployment(ctx context.Context, namespace, name string) (Deployment, error) {
+	d, err := a.Client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return Deployment{}, apiError(fmt.Sprintf("deployment %s/%s", namespace, name), err)
+	}
+	var labels []string
+	if d.Spec.Selector != nil {
+		for k, v := range d.Spec.Selector.MatchLabels {
+			labels = append(labels, k+"="+v)
+		}
+	}
+	sort.Strings(labels)
+	deployment := Deployment{
+		Name:      d.Name,
+		Namespace: d.Namespace,
+		Selector:  strings.Join(labels, ","),
+		Replicas:  1,
+		Updated:   int(d.Status.UpdatedReplicas),
+		Ready:     int(d.Status.ReadyReplicas),
+		Available: int(d.Status.AvailableReplicas),
+	}
+	if d.Spec.Replicas != nil {
+		deployment.Replicas = int(*d.Spec.Replicas)
+	}
+	for _, c := range d.Status.Conditions {
+		deployment.Conditions = append(deployment.Conditions, Condition{
+			Type: string(c.Type), Status: string(c.Status), Reason: c.Reason, Message: c.Message,
+		})
+	}
+	for _, c := range d.Spec.Template.Spec.Containers {
+		deployment.Images = append(deployment.Images, c.Image)
+	}
+	return deployment, nil
+}
+
+// Events returns the events about an object of a namespace, oldest first
+func (a *API) Events(ctx context.Context, namespace, name string) ([]Event, error) {
+	list, err := a.Client.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("involvedObject.name", name).String(),
+	})
+	if err != nil {
+		return nil, apiError(fmt.Sprintf("events of %s/%s", namespace, name), err)
+	}
+	events := make([]Event, len(list.Items))
+	for i, e := range list.Items {
+		last := e.LastTimestamp.Time
+		if last.IsZero() {
+			last = e.EventTime.Time
+		}
+		if last.IsZero() {
+			last = e.FirstTimestamp.Time
+		}
+		events[i] = Event{Type: e.Type, Reason: e.Reason, Message: e.Message, Count: max(int(e.Count), 1), Last: last}
+	}
+	slices.SortStableFunc(events, func(x, y Event) int { return x.Last.Compare(y.Last) })
+	return events, nil
+}
+
+// Logs returns the last lines of the logs of a container
+func (a *API) Logs(ctx context.Context, namespace, pod, container string, lines int) (string, error) {
+	tail, limit := int64(lines), int64(maxLogBytes)
+	what := fmt.Sprintf("logs of pod %s/%s", namespace, pod)
+	stream, err := a.Client.CoreV1().Pods(namespace).GetLogs(pod, &corev1.PodLogOptions{
+		Container:  container,
+		TailLines:  &tail,
+		LimitBytes: &limit,
+	}).Stream(ctx)
+	if err != nil {
+		return "", apiError(what, err)
+	}
+	defer stream.Close()
+	data, err := io.ReadAll(io.LimitReader(stream, maxLogBytes))
+	if err != nil {
+		return "", apiError(what, err)
+	}
+	return string(data), nil
+}
+
+// Restart sets the restartedAt annotation of the pod template, as kubectl rollout restart
+// does, so the deployment replaces its pods
+func (a *API) Restart(ctx context.Context, namespace, name string, at time.Time) error {
+	patch := fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{"kubectl.kubernetes.io/restartedAt":%q}}}}}`,
+		at.UTC().Format(time.RFC3339))
+	_, err := a.Client.AppsV1().Deployments(namespace).Patch(ctx, name, types.StrategicMergePatchType,
+		[]byte(patch), metav1.PatchOptions{})
+	return apiError(fmt.Sprintf("restarting deployment %s/%s", namespace, name), err)
+}
+
+// Scale sets the replicas of a deployment through its scale subresource
+func (a *API) Scale(ctx context.Context, namespace, name string, replicas int) error {
+	patch := fmt.Sprintf(`{"spec":{"replicas":%d}}`, replicas)
+	_, err := a.Client.AppsV1().Deployments(namespace).Patch(ctx, name, types.MergePatchType,
+		[]byte(patch), metav1.PatchOptions{}, "scale")
+	return apiError(fmt.Sprintf("scaling deployment %s/%s", namespace, name), err)
+}
@@ -0,0 +1,238 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Demo is an in-memory cluster running a small online shop, where one checkout pod is
+// crash-looping, so the Kubernetes goal can be tried without a cluster. Restarts and scaling
+// change its state until the worker restarts.
+type Demo struct {
+	mu          sync.Mutex
+	deployments map[string]*demoDeployment
+	events      map[string][]Event
+	// generation makes the names of replaced pods unique
+	generation int
+}
+
+// demoDeployment is a deployment of the demo cluster with its pods
+type demoDeployment struct {
+	Deployment
+	logs string
+	pods []Pod
+}
+
+// demoStart is when the pods of the demo cluster started
+var demoStart = time.Date(2025, 11, 3, 8, 0, 0, 0, time.UTC)
+
+// NewDemo returns the demo cluster
+func NewDemo() *Demo {
+	d := &Demo{deployments: map[string]*demoDeployment{}, events: map[string][]Event{}}
+	d.add("shop", "frontend", "shop/frontend:2.3.0", 2,
+		`GET /products 200 12ms
+GET /cart 200 8ms
+GET /checkout 502 30012ms upstream=checkout`)
+	d.add("shop", "cart", "shop/cart:1.8.1", 2,
+		`cart service listening on :8080
+redis connected addr=redis:6379`)
+	d.add("shop", "checkout", "shop/checkout:1.14.2", 3,
+		`checkout service listening on :8080
+payment provider configured
+order 88412 charged in 412ms`)
+	d.add("monitoring", "prometheus", "prom/prometheus:v2.53.0", 1,
+		`Server is ready to receive web requests.
+Completed loading of configuration file`)
+
+	// The last checkout pod cannot reach its database
+	checkout := d.deployments["shop/checkout"]
+	broken := &checkout.pods[len(checkout.pods)-1]
+	broken.Phase = "Running"
+	broken.Containers[0] = Container{Name: "checkout", Image: checkout.Images[0], State: "CrashLoopBackOff", Restarts: 37}
+	broken.Conditions = []Condition{{Type: "Ready", Status: "False", Reason: "ContainersNotReady", Message: "containers with unready status: [checkout]"}}
+	checkout.Ready, checkout.Available = 2, 2
+	checkout.Conditions = []Condition{
+		{Type: "Available", Status: "True", Reason: "MinimumReplicasAvailable", Message: "Deployment has minimum availability."},
+		{Type: "Progressing", Status: "True", Reason: "NewReplicaSetAvailable"},
+	}
+	d.events["shop/"+broken.Name] = []Event{
+		{Type: "Warning", Reason: "Unhealthy", Message: "Readiness probe failed: HTTP probe failed with statuscode: 503", Count: 112, Last: demoStart.Add(5 * time.Hour)},
+		{Type: "Warning", Reason: "BackOff", Message: "Back-off restarting failed container checkout in pod " + broken.Name, Count: 214, Last: demoStart.Add(6 * time.Hour)},
+	}
+	return d
+}
+
+// add creates a healthy deployment with its pods
+func (d *Demo) add(namespace, name, image string, replicas int, logs string) {
+	dep := &demoDeployment{
+		Deployment: Deployment{
+			Name:      name,
+			Namespace: namespace,
+			Selector:  "app=" + name,
+			Images:    []string{image},
+			Conditions: []Condition{
+				{Type: "Available", Status: "True", Reason: "MinimumReplicasAvailable", Message: "Deployment has minimum availability."},
+				{Type: "Progressing", Status: "True", Reason: "NewReplicaSetAvailable"},
+			},
+		},
+		logs: logs,
+	}
+	d.deployments[namespace+"/"+name] = dep
+	d.resize(dep, replicas, demoStart)
+}
+
+// resize adds or removes healthy pods until the deployment has the given replicas
+func (d *Demo) resize(dep *demoDeployment, replicas int, at time.Time) {
+	for len(dep.pods) > replicas {
+		dep.pods = dep.pods[:len(dep.pods)-1]
+	}
+	for len(dep.pods) < replicas {
+		d.generation++
+		h := fnv.New32a()
+		fmt.Fprintf(h, "%s/%s/%d", dep.Namespace, dep.Name, d.generation)
+		dep.pods = append(dep.pods, Pod{
+			Name:       fmt.Sprintf("%s-%05x", dep.Name, h.Sum32()&0xfffff),
+			Namespace:  dep.Namespace,
+			Phase:      "Running",
+			Node:       fmt.Sprintf("node-%d", d.generation%3+1),
+			Started:    at,
+			Containers: []Container{{Name: dep.Name, Image: dep.Images[0], Ready: true, State: "running"}},
+			Conditions: []Condition{{Type: "Ready", Status: "True"}},
+		})
+	}
+	dep.Replicas = replicas
+	dep.Updated, dep.Ready, dep.Available = replicas, 0, 0
+	for _, p := range dep.pods {
+		if p.Ready() == len(p.Containers) {
+			dep.Ready++
+			dep.Available++
+		}
+	}
+}
+
+// deployment returns a deployment of the demo cluster; the caller holds the lock
+func (d *Demo) deployment(namespace, name string) (*demoDeployment, error) {
+	dep, ok := d.deployments[namespace+"/"+name]
+	if !ok {
+		return nil, fmt.Errorf("deployment %s/%s: %w", namespace, name, ErrNotFound)
+	}
+	return dep, nil
+}
+
+// pod returns a pod of the demo cluster with its deployment; the caller holds the lock
+func (d *Demo) pod(namespace, name string) (Pod, *demoDeployment, error) {
+	for _, dep := range d.deployments {
+		if dep.Namespace != namespace {
+			continue
+		}
+		for _, p := range dep.pods {
+			if p.Name == name {
+				return p, dep, nil
+			}
+		}
+	}
+	return Pod{}, nil, fmt.Errorf("pod %s/%s: %w", namespace, name, ErrNotFound)
+}
+
+// Pods lists the pods of a namespace matching an app=name selector, or all of them
+func (d *Demo) Pods(ctx context.Context, namespace, selector string) ([]Pod, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var pods []Pod
+	for _, dep := range d.deployments {
+		if dep.Namespace == namespace && (selector == "" || selector == dep.Selector) {
+			pods = append(pods, dep.pods...)
+		}
+	}
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+	return pods, nil
+}
+
+// Pod returns a pod
+func (d *Demo) Pod(ctx context.Context, namespace, name string) (Pod, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	p, _, err := d.pod(namespace, name)
+	return p, err
+}
+
+// Deployment returns a deployment
+func (d *Demo) Deployment(ctx context.Context, namespace, name string) (Deployment, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dep, err := d.deployment(namespace, name)
+	if err != nil {
+		return Deployment{}, err
+	}
+	return dep.Deployment, nil
+}
+
+// Events returns the events about an object
+func (d *Demo) Events(ctx context.Context, namespace, name string) ([]Event, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.Clone(d.events[namespace+"/"+name]), nil
+}
+
+// Logs returns the canned logs of a pod; crash-looping pods fail to reach their database
+func (d *Demo) Logs(ctx context.Context, namespace, pod, container string, lines int) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	p, dep, err := d.pod(namespace, pod)
+	if err != nil {
+		return "", err
+	}
+	if container != "" && container != p.Containers[0].Name {
+		return "", fmt.Errorf("container %s of pod %s/%s: %w", container, namespace, pod, ErrNotFound)
+	}
+	logs := strings.Split(dep.logs, "\n")
+	if p.Containers[0].State == "CrashLoopBackOff" {
+		logs = []string{
+			"checkout service starting version=1.14.2",
+			"connecting to database host=payments-db port=5432",
+			"error: dial tcp 10.4.2.17:5432: connect: connection refused",
+			"fatal: database unavailable after 5 attempts, exiting",
+		}
+	}
+	if len(logs) > lines {
+		logs = logs[len(logs)-lines:]
+	}
+	return strings.Join(logs, "\n"), nil
+}
+
+// Restart replaces the pods of a deployment with healthy ones
+func (d *Demo) Restart(ctx context.Context, namespace, name string, at time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dep, err := d.deployment(namespace, name)
+	if err != nil {
+		return err
+	}
+	replicas := dep.Replicas
+	dep.pods = nil
+	d.resize(dep, replicas, at)
+	d.events[namespace+"/"+name] = append(d.events[namespace+"/"+name],
+		Event{Type: "Normal", Reason: "ScalingReplicaSet", Message: fmt.Sprintf("Rolled %d pods after a restart", replicas), Count: 1, Last: at})
+	return nil
+}
+
+// Scale adds or removes pods of a deployment
+func (d *Demo) Scale(ctx context.Context, namespace, name string, replicas int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dep, err := d.deployment(namespace, name)
+	if err != nil {
+		return err
+	}
+	previous := dep.Replicas
+	d.resize(dep, replicas, time.Now())
+	d.events[namespace+"/"+name] = append(d.events[namespace+"/"+name],
+		Event{Type: "Normal", Reason: "ScalingReplicaSet", Message: fmt.Sprintf("Scaled from %d to %d", previous, replicas), Count: 1, Last: time.Now()})
+	return nil
+}
@@ -0,0 +1,147 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Pod is the state of a pod
+type Pod struct {
+	Name       string
+	Namespace  string
+	Phase      string
+	Node       string
+	Started    time.Time
+	Containers []Container
+	Conditions []Condition
+}
+
+// Container is the state of a container of a pod
+type Container struct {
+	Name  string
+	Image string
+	Ready bool
+	// State is running, or the reason a container is waiting or terminated (e.g. CrashLoopBackOff)
+	State    string
+	Restarts int
+}
+
+// Ready returns the number of ready containers of the pod
+func (p Pod) Ready() int {
+	ready := 0
+	for _, c := range p.Containers {
+		if c.Ready {
+			ready++
+		}
+	}
+	return ready
+}
+
+// Restarts returns the restarts of all the containers of the pod
+func (p Pod) Restarts() int {
+	restarts := 0
+	for _, c := range p.Containers {
+		restarts += c.Restarts
+	}
+	return restarts
+}
+
+// Deployment is the state of a deployment
+type Deployment struct {
+	Name      string
+	Namespace string
+	Selector  string
+	// Replicas is the desired number of pods; Updated, Ready and Available count the actual ones
+	Replicas   int
+	Updated    int
+	Ready      int
+	Available  int
+	Images     []string
+	Conditions []Condition
+}
+
+// Condition is a condition of a pod or deployment
+type Condition struct {
+	Type    string
+	Status  string
+	Reason  string
+	Message string
+}
+
+// Event is an event about an object
+type Event struct {
+	Type    string
+	Reason  string
+	Message string
+	Count   int
+	Last    time.Time
+}
+
+// Cluster reads and changes the workloads of a Kubernetes cluster. Objects that do not
+// exist return an error wrapping ErrNotFound.
+type Cluster interface {
+	Pods(ctx context.Context, namespace, selector string) ([]Pod, error)
+	Pod(ctx context.Context, namespace, name string) (Pod, error)
+	Deployment(ctx context.Context, namespace, name string) (Deployment, error)
+	// Events returns the events about the named object, oldest first
+	Events(ctx context.Context, namespace, name string) ([]Event, error)
+	// Logs returns the last lines of the logs of a container; an empty container is the pod's only one
+	Logs(ctx context.Context, namespace, pod, container string, lines int) (string, error)
+	// Restart rolls the pods of a deployment, like kubectl rollout restart
+	Restart(ctx context.Context, namespace, name string, at time.Time) error
+	Scale(ctx context.Context, namespace, name string, replicas int) error
+}
+
+// ErrNotFound is wrapped by the errors of objects that do not exist
+var ErrNotFound = errors.New("not found")
+
+// Options configure the connection to a cluster
+type Options struct {
+	// APIURL is the URL of the API server; empty uses the in-cluster service address
+	APIURL string
+	// TokenFile and CAFile are the bearer token and CA certificate files; empty uses the
+	// pod's service account
+	TokenFile string
+	CAFile    string
+}
+
+// Files of the service account mounted into pods
+const (
+	serviceAccountToken = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	serviceAccountCA    = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+)
+
+// New returns the cluster backend registered under the given name: mock, a demo cluster
+// kept in memory, or api, a real cluster reached through its API server
+func New(name string, opts Options) (Cluster, error) {
+	switch name {
+	case "mock":
+		return NewDemo(), nil
+	case "api":
+		return newAPI(opts)
+	default:
+		return nil, fmt.Errorf("unknown Kubernetes cluster backend %q", name)
+	}
+}
+
+// Access is the agent's own RBAC: the namespaces it may read and change, on top of what
+// the cluster's RBAC allows its credentials. "*" stands for every namespace.
+type Access struct {
+	Read  []string
+	Write []string
+	// MaxReplicas bounds the replicas a deployment can be scaled to
+	MaxReplicas int
+}
+
+// CanRead reports whether the agent may read the workloads of a namespace
+func (a Access) CanRead(namespace string) bool {
+	return slices.Contains(a.Read, "*") || slices.Contains(a.Read, namespace)
+}
+
+// CanWrite reports whether the agent may change the workloads of a namespace
+func (a Access) CanWrite(namespace string) bool {
+	return slices.Contains(a.Write, "*") || slices.Contains(a.Write, namespace)
+}
@@ -0,0 +1,332 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"temporal-ai-agent/tools"
+	"time"
+)
+
+// Log lines returned by the logs tool
+const (
+	defaultLogLines = 100
+	maxLogLines     = 500
+)
+
+// Tools returns the kubectl-style tools of the Kubernetes goal. Reading is limited to the
+// namespaces access allows reading; restarting and scaling, which need confirmation, to
+// those it allows changing. Mock marks the tools of the demo cluster.
+func Tools(cluster Cluster, access Access, mock bool) []tools.Tool {
+	t := &toolset{cluster: cluster, access: access}
+	namespace := stringSchema("Namespace of the workload")
+	return []tools.Tool{
+		{
+			Definition: tools.Definition{
+				Name:        "k8s_get_pods",
+				Description: "Lists the pods of a namespace with their readiness, status, restarts and node, like kubectl get pods.",
+				Parameters: objectSchema([]string{"namespace"}, map[string]interface{}{
+					"namespace": namespace,
+					"selector":  stringSchema("Label selector, e.g. app=checkout"),
+				}),
+				Mock:    mock,
+				Risk:    tools.RiskReadOnly,
+				Summary: "List the pods in {namespace}",
+			},
+			Handler: t.getPods,
+		},
+		{
+			Definition: tools.Definition{
+				Name:        "k8s_pod_logs",
+				Description: "Returns the last lines of the logs of a pod, like kubectl logs --tail.",
+				Parameters: objectSchema([]string{"namespace", "pod"}, map[string]interface{}{
+					"namespace": namespace,
+					"pod":       stringSchema("Pod name"),
+					"container": stringSchema("Container name; required when the pod has several"),
+					"lines": map[string]interface{}{
+						"type":        "integer",
+						"description": fmt.Sprintf("Number of lines (default %d, at most %d)", defaultLogLines, maxLogLines),
+					},
+				}),
+				Mock:    mock,
+				Risk:    tools.RiskReadOnly,
+				Summary: "Read the logs of pod {pod} in {namespace}",
+			},
+			Handler: t.podLogs,
+		},
+		{
+			Definition: tools.Definition{
+				Name:        "k8s_describe",
+				Description: "Describes a pod or deployment with its containers, conditions and recent events, like kubectl describe.",
+				Parameters: objectSchema([]string{"namespace", "kind", "name"}, map[string]interface{}{
+					"namespace": namespace,
+					"kind": map[string]interface{}{
+						"type": "string",
+						"enum": []string{"pod", "deployment"},
+					},
+					"name": stringSchema("Name of the pod or deployment"),
+				}),
+				Mock:    mock,
+				Risk:    tools.RiskReadOnly,
+				Summary: "Describe {kind} {name} in {namespace}",
+			},
+			Handler: t.describe,
+		},
+		{
+			Definition: tools.Definition{
+				Name:        "k8s_restart_deployment",
+				Description: "Replaces all the pods of a deployment, like kubectl rollout restart. Running requests on the old pods may fail.",
+				Parameters: objectSchema([]string{"namespace", "name"}, map[string]interface{}{
+					"namespace": namespace,
+					"name":      stringSchema("Deployment name"),
+				}),
+				RequiresConfirmation: true,
+				Mock:                 mock,
+				Risk:                 tools.RiskIrreversible,
+				Summary:              "Restart deployment {name} in {namespace}",
+			},
+			Handler: t.restart,
+		},
+		{
+			Definition: tools.Definition{
+				Name:        "k8s_scale_deployment",
+				Description: "Sets the number of replicas of a deployment, like kubectl scale.",
+				Parameters: objectSchema([]string{"namespace", "name", "replicas"}, map[string]interface{}{
+					"namespace": namespace,
+					"name":      stringSchema("Deployment name"),
+					"replicas": map[string]interface{}{
+						"type":        "integer",
+						"description": fmt.Sprintf("Desired number of replicas, at most %d", access.MaxReplicas),
+					},
+				}),
+				RequiresConfirmation: true,
+				Mock:                 mock,
+				Risk:                 tools.RiskReversible,
+				Summary:              "Scale deployment {name} in {namespace} to {replicas} replicas",
+			},
+			Handler: t.scale,
+		},
+	}
+}
+
+// toolset holds what the tool handlers share
+type toolset struct {
+	cluster Cluster
+	access  Access
+}
+
+// namespaceArg returns the namespace of a call, checking that the agent may read it, or
+// change it when write is set
+func (t *toolset) namespaceArg(args map[string]interface{}, write bool) (string, error) {
+	namespace, err := tools.StringArg(args, "namespace")
+	if err != nil {
+		return "", err
+	}
+	if write && !t.access.CanWrite(namespace) {
+		return "", tools.Permanent(fmt.Errorf("the agent may not change workloads in namespace %s", namespace))
+	}
+	if !write && !t.access.CanRead(namespace) {
+		return "", tools.Permanent(fmt.Errorf("the agent may not read namespace %s", namespace))
+	}
+	return namespace, nil
+}
+
+// clusterError marks objects that do not exist as permanent failures, so the model hears
+// about them straight away
+func clusterError(err error) error {
+	if errors.Is(err, ErrNotFound) {
+		return tools.Permanent(err)
+	}
+	return err
+}
+
+// getPods lists pods like kubectl get pods
+func (t *toolset) getPods(ctx context.Context, args map[string]interface{}) (string, error) {
+	namespace, err := t.namespaceArg(args, false)
+	if err != nil {
+		return "", err
+	}
+	selector, _ := args["selector"].(string)
+	pods, err := t.cluster.Pods(ctx, namespace, selector)
+	if err != nil {
+		return "", clusterError(err)
+	}
+	if len(pods) == 0 {
+		return fmt.Sprintf("No pods found in namespace %s.", namespace), nil
+	}
+
+	var b strings.Builder
+	b.WriteString("NAME\tREADY\tSTATUS\tRESTARTS\tNODE")
+	for _, p := range pods {
+		fmt.Fprintf(&b, "\n%s\t%d/%d\t%s\t%d\t%s", p.Name, p.Ready(), len(p.Containers), podStatus(p), p.Restarts(), p.Node)
+	}
+	return b.String(), nil
+}
+
+// podStatus is the status kubectl shows for a pod: the reason a container is not running,
+// or the pod's phase
+func podStatus(p Pod) string {
+	for _, c := range p.Containers {
+		if c.State != "" && c.State != "running" {
+			return c.State
+		}
+	}
+	return p.Phase
+}
+
+// podLogs returns the last lines of a pod's logs
+func (t *toolset) podLogs(ctx context.Context, args map[string]interface{}) (string, error) {
+	namespace, err := t.namespaceArg(args, false)
+	if err != nil {
+		return "", err
+	}
+	pod, err := tools.StringArg(args, "pod")
+	if err != nil {
+		return "", err
+	}
+	container, _ := args["container"].(string)
+	lines, err := tools.IntArg(args, "lines", defaultLogLines)
+	if err != nil {
+		return "", err
+	}
+	lines = min(max(lines, 1), maxLogLines)
+
+	logs, err := t.cluster.Logs(ctx, namespace, pod, container, lines)
+	if err != nil {
+		return "", clusterError(err)
+	}
+	if strings.TrimSpace(logs) == "" {
+		return fmt.Sprintf("Pod %s has no logs.", pod), nil
+	}
+	return logs, nil
+}
+
+// describe describes a pod or deployment like kubectl describe
+func (t *toolset) describe(ctx context.Context, args map[string]interface{}) (string, error) {
+	namespace, err := t.namespaceArg(args, false)
+	if err != nil {
+		return "", err
+	}
+	kind, err := tools.StringArg(args, "kind")
+	if err != nil {
+		return "", err
+	}
+	name, err := tools.StringArg(args, "name")
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	switch strings.ToLower(kind) {
+	case "pod":
+		p, err := t.cluster.Pod(ctx, namespace, name)
+		if err != nil {
+			return "", clusterError(err)
+		}
+		fmt.Fprintf(&b, "Pod: %s\nNamespace: %s\nNode: %s\nStatus: %s\n", p.Name, p.Namespace, p.Node, podStatus(p))
+		if !p.Started.IsZero() {
+			fmt.Fprintf(&b, "Started: %s\n", p.Started.Format(time.RFC3339))
+		}
+		b.WriteString("Containers:\n")
+		for _, c := range p.Containers {
+			fmt.Fprintf(&b, "  %s: image %s, state %s, ready %t, restarts %d\n", c.Name, c.Image, c.State, c.Ready, c.Restarts)
+		}
+		writeConditions(&b, p.Conditions)
+	case "deployment":
+		d, err := t.cluster.Deployment(ctx, namespace, name)
+		if err != nil {
+			return "", clusterError(err)
+		}
+		fmt.Fprintf(&b, "Deployment: %s\nNamespace: %s\nSelector: %s\nImages: %s\n", d.Name, d.Namespace, d.Selector, strings.Join(d.Images, ", "))
+		fmt.Fprintf(&b, "Replicas: %d desired, %d updated, %d ready, %d available\n", d.Replicas, d.Updated, d.Ready, d.Available)
+		writeConditions(&b, d.Conditions)
+	default:
+		return "", tools.Permanent(fmt.Errorf("kind must be pod or deployment, not %q", kind))
+	}
+
+	events, err := t.cluster.Events(ctx, namespace, name)
+	if err != nil {
+		return "", clusterError(err)
+	}
+	b.WriteString("Events:")
+	if len(events) == 0 {
+		b.WriteString(" none")
+	}
+	for _, e := range events {
+		fmt.Fprintf(&b, "\n  %s %s %s (x%d, last %s)", e.Type, e.Reason, e.Message, e.Count, e.Last.Format(time.RFC3339))
+	}
+	return b.String(), nil
+}
+
+// writeConditions appends the conditions of an object
+func writeConditions(b *strings.Builder, conditions []Condition) {
+	if len(conditions) == 0 {
+		return
+	}
+	b.WriteString("Conditions:\n")
+	for _, c := range conditions {
+		fmt.Fprintf(b, "  %s=%s", c.Type, c.Status)
+		if c.Reason != "" {
+			fmt.Fprintf(b, " (%s)", c.Reason)
+		}
+		if c.Message != "" {
+			fmt.Fprintf(b, ": %s", c.Message)
+		}
+		b.WriteString("\n")
+	}
+}
+
+// restart restarts a deployment
+func (t *toolset) restart(ctx context.Context, args map[string]interface{}) (string, error) {
+	namespace, err := t.namespaceArg(args, true)
+	if err != nil {
+		return "", err
+	}
+	name, err := tools.StringArg(args, "name")
+	if err != nil {
+		return "", err
+	}
+	if err := t.cluster.Restart(ctx, namespace, name, time.Now()); err != nil {
+		return "", clusterError(err)
+	}
+	return fmt.Sprintf("Restarted deployment %s in %s; its pods are being replaced.", name, namespace), nil
+}
+
+// scale scales a deployment within the replica limit
+func (t *toolset) scale(ctx context.Context, args map[string]interface{}) (string, error) {
+	namespace, err := t.namespaceArg(args, true)
+	if err != nil {
+		return "", err
+	}
+	name, err := tools.StringArg(args, "name")
+	if err != nil {
+		return "", err
+	}
+	replicas, err := tools.IntArg(args, "replicas", -1)
+	if err != nil {
+		return "", tools.Permanent(err)
+	}
+	if replicas < 0 || replicas > t.access.MaxReplicas {
+		return "", tools.Permanent(fmt.Errorf("replicas must be between 0 and %d", t.access.MaxReplicas))
+	}
+
+	before, err := t.cluster.Deployment(ctx, namespace, name)
+	if err != nil {
+		return "", clusterError(err)
+	}
+	if err := t.cluster.Scale(ctx, namespace, name, replicas); err != nil {
+		return "", clusterError(err)
+	}
+	return fmt.Sprintf("Scaled deployment %s in %s from %d to %d replicas.", name, namespace, before.Replicas, replicas), nil
+}
+
+// objectSchema builds the JSON schema of an object with the given properties
+func objectSchema(required []string, properties map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"type": "object", "properties": properties, "required": required}
+}
+
+// schema builds the JSON schema of a string property
+func stringSchema(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "description": description}
+}
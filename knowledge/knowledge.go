@@ -0,0 +1,373 @@
+package knowledge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"temporal-ai-agent/chunking"
+	"temporal-ai-agent/tools"
+)
+
+// AnyGoal is the goal key of a binding applying to every goal without its own entry
+const AnyGoal = "*"
+
+// ToolName is the name of the retrieval tool
+const ToolName = "search_knowledge"
+
+// maxResults is the number of passages the retrieval tool returns
+const maxResults = 3
+
+// NoResults is the retrieval tool's result when no passage matches
+const NoResults = "No documents found."
+
+// Embedder embeds texts for reranking; LLM providers supporting embeddings satisfy it
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+// Document is an entry of a knowledge namespace
+type Document struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Content string `json:"content"`
+	// Type selects how the document is chunked (text, markdown, code, ...); empty infers it
+	// from the extension of the ID
+	Type string `json:"type,omitempty"`
+}
+
+// Chunk is a passage of a document, the unit ranked by searches
+type Chunk struct {
+	DocumentID string `json:"document_id"`
+	Title      string `json:"title"`
+	// Index is the position of the passage in its document
+	Index   int    `json:"index"`
+	Content string `json:"content"`
+	// Source is where an ingested document was read from, e.g. the URL of a crawled page
+	Source string `json:"source,omitempty"`
+	// Embedding is the embedding of the passage, when the LLM provider supports embeddings
+	Embedding []float64 `json:"-"`
+}
+
+// Result is a passage matching a query
+type Result struct {
+	Namespace string `json:"namespace"`
+	Chunk
+	Score float64 `json:"score"`
+}
+
+// Candidate is a passage matched by words, as reranked by a retrieval
+type Candidate struct {
+	Result
+	// Similarity is the cosine similarity of the passage's embedding with the query's
+	Similarity float64 `json:"similarity,omitempty"`
+	// Rank is the position of the passage after reranking, from 1
+	Rank int `json:"rank"`
+}
+
+// Retrieval traces how the retrieval tool answered a query: the passages sharing words
+// with the query, their order after reranking, and the context handed to the model
+type Retrieval struct {
+	Query      string   `json:"query"`
+	Namespaces []string `json:"namespaces"`
+	// Candidates are the passages matched by words, by decreasing word score
+	Candidates []Candidate `json:"candidates"`
+	// Reranked tells whether the candidates were reranked by embedding similarity; when
+	// not, RerankError says why if embedding failed, and ranks follow the word scores
+	Reranked    bool   `json:"reranked"`
+	RerankError string `json:"rerank_error,omitempty"`
+	// Results are the passages kept, best first
+	Results []Result `json:"results"`
+	// Context is the tool result the model reads
+	Context string `json:"context"`
+}
+
+// Base is a knowledge base split into namespaces, with the namespaces each goal may query
+type Base struct {
+	Namespaces map[string][]Document `json:"namespaces"`
+	// Goals binds goals to the namespaces their retrieval tool may query; goals without an
+	// entry use the AnyGoal entry, and may query nothing without one
+	Goals map[string][]string `json:"goals"`
+
+	// Ingested holds the passages of ingested documents, searched along with the file's;
+	// nil searches the file only
+	Ingested Store
+	// Embedder reranks the passages matched by words by their similarity with the query;
+	// nil keeps the word ranking
+	Embedder Embedder
+
+	// chunks are the passages of each namespace
+	chunks map[string][]Chunk
+}
+
+// Load reads a knowledge base from a JSON file and chunks its documents with the chunker
+// of their type. An empty path returns nil.
+func Load(path string, chunkers *chunking.Selector) (*Base, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading knowledge file: %w", err)
+	}
+	var base Base
+	if err := json.Unmarshal(data, &base); err != nil {
+		return nil, fmt.Errorf("parsing knowledge file: %w", err)
+	}
+	for goal, namespaces := range base.Goals {
+		for _, ns := range namespaces {
+			if _, ok := base.Namespaces[ns]; !ok {
+				return nil, fmt.Errorf("goal %q is bound to unknown knowledge namespace %q", goal, ns)
+			}
+		}
+	}
+	base.chunks = map[string][]Chunk{}
+	for ns, docs := range base.Namespaces {
+		for _, doc := range docs {
+			base.chunks[ns] = append(base.chunks[ns], Split(doc, chunkers)...)
+		}
+	}
+	return &base, nil
+}
+
+// Split chunks a document with the chunker of its type
+func Split(doc Document, chunkers *chunking.Selector) []Chunk {
+	docType := doc.Type
+	if docType == "" {
+		docType = chunking.TypeOf(doc.ID)
+	}
+	var chunks []Chunk
+	for i, text := range chunkers.For(docType).Split(doc.Content) {
+		chunks = append(chunks, Chunk{DocumentID: doc.ID, Title: doc.Title, Index: i, Content: text})
+	}
+	return chunks
+}
+
+// Allowed returns the namespaces a goal may query
+func (b *Base) Allowed(goal string) []string {
+	if namespaces, ok := b.Goals[goal]; ok {
+		return namespaces
+	}
+	return b.Goals[AnyGoal]
+}
+
+// Search returns up to limit passages of the namespaces that share the most words with
+// the query, best first. Passages sharing no word are left out.
+func (b *Base) Search(namespaces []string, query string, limit int) []Result {
+	queryWords := wordSet(query)
+	var results []Result
+	for _, ns := range namespaces {
+		results = append(results, score(ns, b.chunks[ns], queryWords)...)
+	}
+	return best(results, limit)
+}
+
+// score scores the passages of a namespace by the share of query words they contain,
+// leaving out passages sharing no word
+func score(namespace string, chunks []Chunk, queryWords map[string]bool) []Result {
+	var results []Result
+	for _, chunk := range chunks {
+		if s := overlap(queryWords, wordSet(chunk.Title+" "+chunk.Content)); s > 0 {
+			results = append(results, Result{Namespace: namespace, Chunk: chunk, Score: s})
+		}
+	}
+	return results
+}
+
+// best returns up to limit results, best first
+func best(results []Result, limit int) []Result {
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// Tool returns the retrieval tool over the knowledge base. Each call may only query the
+// namespaces bound to the goal of the conversation making it.
+func (b *Base) Tool() tools.Tool {
+	return tools.Tool{
+		Definition: tools.Definition{
+			Name:        ToolName,
+			Description: "Searches the knowledge base for documents answering a question.",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query": map[string]interface{}{"type": "string", "description": "What to look for"},
+					"namespace": map[string]interface{}{
+						"type":        "string",
+						"description": "Knowledge namespace to search; omit to search every namespace available",
+					},
+				},
+				"required": []string{"query"},
+			},
+			Risk:    tools.RiskReadOnly,
+			Summary: "Search the knowledge base for {query}",
+		},
+		Handler: b.search,
+	}
+}
+
+// search is the handler of the retrieval tool
+func (b *Base) search(ctx context.Context, args map[string]interface{}) (string, error) {
+	query, err := tools.StringArg(args, "query")
+	if err != nil {
+		return "", err
+	}
+	ns, _ := args["namespace"].(string)
+	namespaces, err := b.Resolve(tools.GoalFrom(ctx), ns)
+	if err != nil {
+		return "", tools.Permanent(err)
+	}
+	retrieval, err := b.Retrieve(ctx, namespaces, query)
+	if err != nil {
+		return "", err
+	}
+	return retrieval.Context, nil
+}
+
+// Resolve returns the namespaces a goal's query may search: the given namespace, which
+// must be bound to the goal, or all of the goal's when empty
+func (b *Base) Resolve(goal, namespace string) ([]string, error) {
+	allowed := b.Allowed(goal)
+	if len(allowed) == 0 {
+		return nil, fmt.Errorf("no knowledge base is available in this conversation")
+	}
+	if namespace == "" {
+		return allowed, nil
+	}
+	if !slices.Contains(allowed, namespace) {
+		return nil, fmt.Errorf("knowledge namespace %q is not available in this conversation; available: %s",
+			namespace, strings.Join(allowed, ", "))
+	}
+	return []string{namespace}, nil
+}
+
+// Retrieve answers a query the way the retrieval tool does. The passages of the file and
+// the ingested documents sharing the most words with the query are candidates; they are
+// reranked by embedding similarity with the query when an Embedder is set, and the best
+// are formatted into the tool's result. Reranking failures fall back to the word ranking.
+func (b *Base) Retrieve(ctx context.Context, namespaces []string, query string) (Retrieval, error) {
+	retrieval := Retrieval{Query: query, Namespaces: namespaces}
+	limit := maxResults * candidatesPerResult
+	results := b.Search(namespaces, query, limit)
+	if b.Ingested != nil {
+		ingested, err := b.Ingested.Search(ctx, namespaces, query, limit)
+		if err != nil {
+			return Retrieval{}, fmt.Errorf("searching ingested documents: %w", err)
+		}
+		results = best(append(results, ingested...), limit)
+	}
+
+	retrieval.Candidates = make([]Candidate, len(results))
+	for i, r := range results {
+		retrieval.Candidates[i] = Candidate{Result: r, Rank: i + 1}
+	}
+	if b.Embedder != nil && len(results) > 0 {
+		if err := b.rerank(ctx, query, retrieval.Candidates); err != nil {
+			retrieval.RerankError = err.Error()
+		} else {
+			retrieval.Reranked = true
+		}
+	}
+
+	ranked := slices.Clone(retrieval.Candidates)
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Rank < ranked[j].Rank })
+	var sb strings.Builder
+	for _, c := range ranked[:min(len(ranked), maxResults)] {
+		retrieval.Results = append(retrieval.Results, c.Result)
+		fmt.Fprintf(&sb, "[%s/%s#%d] %s: %s\n", c.Namespace, c.DocumentID, c.Index, c.Title, c.Content)
+	}
+	retrieval.Context = strings.TrimSpace(sb.String())
+	if retrieval.Context == "" {
+		retrieval.Context = NoResults
+	}
+	return retrieval, nil
+}
+
+// rerank ranks the candidates by the similarity of their embeddings with the query's,
+// embedding the query along with the passages that have no stored embedding
+func (b *Base) rerank(ctx context.Context, query string, candidates []Candidate) error {
+	texts := []string{query}
+	var missing []int
+	for i, c := range candidates {
+		if c.Embedding == nil {
+			texts = append(texts, c.Title+"\n"+c.Content)
+			missing = append(missing, i)
+		}
+	}
+	vectors, err := b.Embedder.Embed(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("embedding query: %w", err)
+	}
+	if len(vectors) != len(texts) {
+		return fmt.Errorf("embedding query: got %d embeddings for %d texts", len(vectors), len(texts))
+	}
+	embeddings := make([][]float64, len(candidates))
+	for i, c := range candidates {
+		embeddings[i] = c.Embedding
+	}
+	for j, i := range missing {
+		embeddings[i] = vectors[j+1]
+	}
+
+	order := make([]int, len(candidates))
+	for i := range candidates {
+		candidates[i].Similarity = cosine(vectors[0], embeddings[i])
+		order[i] = i
+	}
+	sort.SliceStable(order, func(x, y int) bool {
+		return candidates[order[x]].Similarity > candidates[order[y]].Similarity
+	})
+	for rank, i := range order {
+		candidates[i].Rank = rank + 1
+	}
+	return nil
+}
+
+// cosine is the cosine similarity of two vectors; vectors of different dimensions, e.g.
+// embedded by another model, are not similar
+func cosine(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// overlap is the share of query words found in the document
+func overlap(query, doc map[string]bool) float64 {
+	if len(query) == 0 {
+		return 0
+	}
+	shared := 0
+	for w := range query {
+		if doc[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(query))
+}
+
+// wordSet splits text into a set of lower-cased words
+func wordSet(text string) map[string]bool {
+	words := map[string]bool{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !('a' <= r && r <= 'z' || '0' <= r && r <= '9')
+	}) {
+		words[w] = true
+	}
+	return words
+}
@@ -0,0 +1,171 @@
+package knowledge
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// candidatesPerResult is how many full-text candidates are scored per result returned
+const candidatesPerResult = 10
+
+const schema = `
+CREATE TABLE IF NOT EXISTS knowledge_chunks (
+	namespace   TEXT NOT NULL,
+	document_id TEXT NOT NULL,
+	idx         INTEGER NOT NULL,
+	title       TEXT NOT NULL DEFAULT '',
+	content     TEXT NOT NULL,
+	source      TEXT NOT NULL DEFAULT '',
+	embedding   DOUBLE PRECISION[],
+	updated_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+	document    TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', title || ' ' || content)) STORED,
+	PRIMARY KEY (namespace, document_id, idx)
+);
+CREATE INDEX IF NOT EXISTS knowledge_chunks_document_idx
+	ON knowledge_chunks USING GIN (document);
+CREATE TABLE IF NOT EXISTS knowledge_documents (
+	namespace   TEXT NOT NULL,
+	document_id TEXT NOT NULL,
+	version     TEXT NOT NULL DEFAULT '',
+	updated_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+	PRIMARY KEY (namespace, document_id)
+);
+`
+
+// PostgresStore is a Store backed by Postgres, shared by all workers. Full-text search
+// preselects candidates, which are then scored like the knowledge file's passages.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore connects to Postgres and creates the passages table if needed
+func NewPostgresStore(ctx context.Context, databaseURL string) (*PostgresStore, error) {
+	db, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &PostgresStore{db: db}, nil
+}
+
+// Close closes the database connection
+func (p *PostgresStore) Close() error {
+	return p.db.Close()
+}
+
+// Replace swaps the passages of a document in one transaction
+func (p *PostgresStore) Replace(ctx context.Context, namespace, documentID, version string, chunks []Chunk) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM knowledge_chunks WHERE namespace = $1 AND document_id = $2`,
+		namespace, documentID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO knowledge_documents (namespace, document_id, version)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (namespace, document_id) DO UPDATE SET version = $3, updated_at = now()`,
+		namespace, documentID, version); err != nil {
+		return err
+	}
+	for _, c := range chunks {
+		var embedding interface{}
+		if c.Embedding != nil {
+			embedding = pq.Array(c.Embedding)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO knowledge_chunks (namespace, document_id, idx, title, content, source, embedding)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			namespace, documentID, c.Index, c.Title, c.Content, c.Source, embedding); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Remove deletes a document and its passages in one transaction
+func (p *PostgresStore) Remove(ctx context.Context, namespace, documentID string) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"knowledge_chunks", "knowledge_documents"} {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE namespace = $1 AND document_id = $2`,
+			namespace, documentID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Versions returns the versions of the matching documents
+func (p *PostgresStore) Versions(ctx context.Context, namespace, prefix string) (map[string]string, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT document_id, version FROM knowledge_documents
+		WHERE namespace = $1 AND starts_with(document_id, $2)`, namespace, prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := map[string]string{}
+	for rows.Next() {
+		var id, version string
+		if err := rows.Scan(&id, &version); err != nil {
+			return nil, err
+		}
+		versions[id] = version
+	}
+	return versions, rows.Err()
+}
+
+// Search scores the passages of the namespaces that contain any of the query's words
+func (p *PostgresStore) Search(ctx context.Context, namespaces []string, query string, limit int) ([]Result, error) {
+	queryWords := wordSet(query)
+	if len(queryWords) == 0 || len(namespaces) == 0 {
+		return nil, nil
+	}
+	terms := make([]string, 0, len(queryWords))
+	for w := range queryWords {
+		terms = append(terms, w)
+	}
+
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT namespace, document_id, idx, title, content, source, embedding
+		FROM knowledge_chunks, to_tsquery('simple', $2) q
+		WHERE namespace = ANY($1) AND document @@ q
+		ORDER BY ts_rank(document, q) DESC
+		LIMIT $3`, pq.Array(namespaces), strings.Join(terms, " | "), limit*candidatesPerResult)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []Result
+	for rows.Next() {
+		var (
+			ns string
+			c  Chunk
+		)
+		if err := rows.Scan(&ns, &c.DocumentID, &c.Index, &c.Title, &c.Content, &c.Source, pq.Array(&c.Embedding)); err != nil {
+			return nil, err
+		}
+		results = append(results, score(ns, []Chunk{c}, queryWords)...)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return best(results, limit), nil
+}
@@ -0,0 +1,89 @@
+package knowledge
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// Store holds the passages of ingested documents
+type Store interface {
+	// Replace swaps the passages of a document for new ones, so a re-ingested document
+	// does not keep passages it no longer contains. The version (e.g. an ETag) records
+	// which revision of the source the passages come from.
+	Replace(ctx context.Context, namespace, documentID, version string, chunks []Chunk) error
+	// Remove deletes a document and its passages
+	Remove(ctx context.Context, namespace, documentID string) error
+	// Versions returns the versions of the namespace's documents whose ID starts with the
+	// prefix, by document ID
+	Versions(ctx context.Context, namespace, prefix string) (map[string]string, error)
+	// Search returns up to limit passages of the namespaces sharing the most words with
+	// the query, best first
+	Search(ctx context.Context, namespaces []string, query string, limit int) ([]Result, error)
+}
+
+// MemoryStore is a Store kept in process memory. Each worker has its own, so documents are
+// only found by conversations running on the worker that ingested them, and are lost when
+// it restarts.
+type MemoryStore struct {
+	mu sync.Mutex
+	// docs holds the documents of each namespace by ID
+	docs map[string]map[string]storedDocument
+}
+
+// storedDocument is a document of a MemoryStore
+type storedDocument struct {
+	version string
+	chunks  []Chunk
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{docs: map[string]map[string]storedDocument{}}
+}
+
+// Replace swaps the passages of a document
+func (m *MemoryStore) Replace(ctx context.Context, namespace, documentID, version string, chunks []Chunk) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.docs[namespace] == nil {
+		m.docs[namespace] = map[string]storedDocument{}
+	}
+	m.docs[namespace][documentID] = storedDocument{version: version, chunks: chunks}
+	return nil
+}
+
+// Remove deletes a document
+func (m *MemoryStore) Remove(ctx context.Context, namespace, documentID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.docs[namespace], documentID)
+	return nil
+}
+
+// Versions returns the versions of the matching documents
+func (m *MemoryStore) Versions(ctx context.Context, namespace, prefix string) (map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	versions := map[string]string{}
+	for id, doc := range m.docs[namespace] {
+		if strings.HasPrefix(id, prefix) {
+			versions[id] = doc.version
+		}
+	}
+	return versions, nil
+}
+
+// Search scores every passage of the namespaces
+func (m *MemoryStore) Search(ctx context.Context, namespaces []string, query string, limit int) ([]Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	queryWords := wordSet(query)
+	var results []Result
+	for _, ns := range namespaces {
+		for _, doc := range m.docs[ns] {
+			results = append(results, score(ns, doc.chunks, queryWords)...)
+		}
+	}
+	return best(results, limit), nil
+}
@@ -0,0 +1,128 @@
+package language
+
+import (
+	"strings"
+	"unicode"
+)
+
+// English is the code of the language the agent assumes when detection is inconclusive
+const English = "en"
+
+// names maps the supported ISO 639-1 codes to language names
+var names = map[string]string{
+	"en": "English",
+	"es": "Spanish",
+	"fr": "French",
+	"de": "German",
+	"pt": "Portuguese",
+	"it": "Italian",
+	"nl": "Dutch",
+	"ru": "Russian",
+	"el": "Greek",
+	"he": "Hebrew",
+	"ar": "Arabic",
+	"hi": "Hindi",
+	"th": "Thai",
+	"zh": "Chinese",
+	"ja": "Japanese",
+	"ko": "Korean",
+}
+
+// scripts identifies languages written in a script of their own
+var scripts = []struct {
+	code  string
+	table *unicode.RangeTable
+}{
+	// Kana must be checked before Han since Japanese text mixes both
+	{"ja", unicode.Hiragana},
+	{"ja", unicode.Katakana},
+	{"ko", unicode.Hangul},
+	{"zh", unicode.Han},
+	{"ru", unicode.Cyrillic},
+	{"el", unicode.Greek},
+	{"he", unicode.Hebrew},
+	{"ar", unicode.Arabic},
+	{"hi", unicode.Devanagari},
+	{"th", unicode.Thai},
+}
+
+// stopwords are frequent words used to tell Latin-script languages apart
+var stopwords = map[string][]string{
+	"en": {"the", "and", "is", "are", "you", "what", "how", "to", "of", "my", "can", "please", "with", "i", "it", "for", "this", "hello", "want"},
+	"es": {"el", "la", "los", "las", "que", "es", "y", "en", "de", "del", "un", "una", "por", "para", "como", "qué", "cómo", "mi", "está", "hola", "quiero", "gracias"},
+	"fr": {"le", "la", "les", "est", "et", "en", "de", "des", "du", "je", "vous", "pour", "que", "une", "mon", "suis", "bonjour", "merci", "avec"},
+	"de": {"der", "die", "das", "ist", "und", "ich", "sie", "nicht", "mit", "ein", "eine", "hallo", "bitte", "danke"},
+	"pt": {"o", "os", "as", "é", "e", "de", "em", "do", "da", "que", "não", "para", "com", "uma", "meu", "olá", "quero", "obrigado", "você"},
+	"it": {"il", "lo", "gli", "è", "di", "che", "non", "per", "una", "sono", "mio", "ciao", "vorrei", "grazie", "come", "della"},
+	"nl": {"de", "het", "een", "is", "en", "ik", "niet", "voor", "met", "mijn", "hallo", "dank", "hoe", "wat"},
+}
+
+// Detect returns the ISO 639-1 code of the language text is written in, or "" when unsure
+func Detect(text string) string {
+	counts := map[string]int{}
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		for _, s := range scripts {
+			if unicode.Is(s.table, r) {
+				counts[s.code]++
+				break
+			}
+		}
+	}
+	if letters == 0 {
+		return ""
+	}
+
+	best, bestCount := "", 0
+	for _, s := range scripts {
+		if counts[s.code] > bestCount {
+			best, bestCount = s.code, counts[s.code]
+		}
+	}
+	if bestCount*2 >= letters {
+		return best
+	}
+	return detectLatin(text)
+}
+
+// detectLatin scores Latin-script text by stopword hits
+func detectLatin(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+
+	best, bestScore, tie := "", 0, false
+	for _, code := range []string{"en", "es", "fr", "de", "pt", "it", "nl"} {
+		score := 0
+		for _, w := range words {
+			for _, stop := range stopwords[code] {
+				if w == stop {
+					score++
+					break
+				}
+			}
+		}
+		switch {
+		case score > bestScore:
+			best, bestScore, tie = code, score, false
+		case score == bestScore && score > 0:
+			tie = true
+		}
+	}
+	if tie {
+		return ""
+	}
+	return best
+}
+
+// Name returns the English name of a language code, or the code itself if unknown
+func Name(code string) string {
+	if name, ok := names[code]; ok {
+		return name
+	}
+	return code
+}
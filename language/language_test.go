@@ -0,0 +1,37 @@
+package language
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetect(t *testing.T) {
+	for _, tc := range []struct {
+		text, want string
+	}{
+		{"Hello, how are you?", "en"},
+		{"Hola, ¿cómo estás? Quiero ayuda", "es"},
+		{"Bonjour, je voudrais de l'aide", "fr"},
+		{"Guten Tag, ich brauche bitte Hilfe", "de"},
+		{"Ciao, vorrei un caffè", "it"},
+		{"Привет, как дела?", "ru"},
+		{"こんにちは世界", "ja"},
+		{"你好世界", "zh"},
+		{"안녕하세요", "ko"},
+		// A few words of another script do not outweigh the rest of the message
+		{"Please book the hotel in Москва", "en"},
+		// Inconclusive messages: no letters, no stopwords, or a tie between languages
+		{"42", ""},
+		{"", ""},
+		{"ok", ""},
+		{"de", ""},
+	} {
+		require.Equal(t, tc.want, Detect(tc.text), tc.text)
+	}
+}
+
+func TestName(t *testing.T) {
+	require.Equal(t, "Japanese", Name("ja"))
+	require.Equal(t, "sw", Name("sw"), "unknown codes are returned as is")
+}
@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	enumspb "go.temporal.io/api/enums/v1"
+	taskqueuepb "go.temporal.io/api/taskqueue/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+)
+
+// backlogTimeout bounds the task queue descriptions of a scrape
+const backlogTimeout = 5 * time.Second
+
+// backlogTypes are the kinds of tasks whose backlog is reported, by label value
+var backlogTypes = map[string]enumspb.TaskQueueType{
+	"workflow": enumspb.TASK_QUEUE_TYPE_WORKFLOW,
+	"activity": enumspb.TASK_QUEUE_TYPE_ACTIVITY,
+}
+
+// Backlog reports the backlog and pollers of task queues, as the Temporal server sees them
+// across all workers. The SDK only knows about its own worker, so the queues are described
+// on every scrape.
+type Backlog struct {
+	namespace  string
+	taskQueues []string
+	// client returns the current client, which changes when the connection fails over
+	client  func() client.Client
+	count   *prometheus.Desc
+	age     *prometheus.Desc
+	pollers *prometheus.Desc
+}
+
+// NewBacklog returns a collector of the backlog of the task queues of a namespace
+func NewBacklog(namespace string, current func() client.Client, taskQueues ...string) *Backlog {
+	labels := []string{"task_queue", "type"}
+	return &Backlog{
+		namespace:  namespace,
+		taskQueues: taskQueues,
+		client:     current,
+		count:      prometheus.NewDesc("agent_task_queue_backlog", "Approximate number of tasks waiting in the task queue", labels, nil),
+		age:        prometheus.NewDesc("agent_task_queue_backlog_age_seconds", "Approximate age of the oldest task waiting in the task queue", labels, nil),
+		pollers:    prometheus.NewDesc("agent_task_queue_pollers", "Workers that recently polled the task queue", labels, nil),
+	}
+}
+
+// Describe sends the descriptions of the backlog metrics
+func (b *Backlog) Describe(ch chan<- *prometheus.Desc) {
+	ch <- b.count
+	ch <- b.age
+	ch <- b.pollers
+}
+
+// Collect describes each task queue and sends its backlog. Queues the server fails to
+// describe are logged and left out of the scrape.
+func (b *Backlog) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), backlogTimeout)
+	defer cancel()
+
+	service := b.client().WorkflowService()
+	for _, taskQueue := range b.taskQueues {
+		for typeName, taskQueueType := range backlogTypes {
+			resp, err := service.DescribeTaskQueue(ctx, &workflowservice.DescribeTaskQueueRequest{
+				Namespace:     b.namespace,
+				TaskQueue:     &taskqueuepb.TaskQueue{Name: taskQueue, Kind: enumspb.TASK_QUEUE_KIND_NORMAL},
+				TaskQueueType: taskQueueType,
+				ReportStats:   true,
+			})
+			if err != nil {
+				log.Printf("Warning: unable to describe task queue %s: %v", taskQueue, err)
+				continue
+			}
+			stats := resp.GetStats()
+			ch <- prometheus.MustNewConstMetric(b.count, prometheus.GaugeValue, float64(stats.GetApproximateBacklogCount()), taskQueue, typeName)
+			ch <- prometheus.MustNewConstMetric(b.age, prometheus.GaugeValue, stats.GetApproximateBacklogAge().AsDuration().Seconds(), taskQueue, typeName)
+			ch <- prometheus.MustNewConstMetric(b.pollers, prometheus.GaugeValue, float64(len(resp.GetPollers())), taskQueue, typeName)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package metrics
+
+import (
+	"log"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.temporal.io/sdk/client"
+)
+
+// timerBuckets are the histogram buckets of the SDK's timers, in seconds: from 1ms to about
+// a minute, which covers long polls
+var timerBuckets = prometheus.ExponentialBuckets(0.001, 2, 17)
+
+// Temporal records the metrics of the Temporal SDK in a Prometheus registry: counters as
+// counters, gauges as gauges and timers as histograms in seconds, under the SDK's names.
+//
+// Prometheus fixes the labels of a metric, while the SDK tags a metric differently
+// depending on where it is emitted from. The labels of a metric are the tags it is first
+// emitted with; later tags it was not registered with are dropped, and missing ones are
+// left empty.
+type Temporal struct {
+	vectors *vectors
+	tags    map[string]string
+}
+
+// vectors are the metrics registered so far, shared by the handlers derived with WithTags
+type vectors struct {
+	registerer prometheus.Registerer
+	mu         sync.Mutex
+	counters   map[string]*prometheus.CounterVec
+	gauges     map[string]*prometheus.GaugeVec
+	timers     map[string]*prometheus.HistogramVec
+	labels     map[string][]string
+	// rejected are the names the registry refused, which are not recorded
+	rejected map[string]bool
+}
+
+// NewTemporal returns a handler recording the SDK's metrics in the registry. Create one
+// per registry and share it among clients: registering a metric twice fails.
+func NewTemporal(registerer prometheus.Registerer) *Temporal {
+	return &Temporal{vectors: &vectors{
+		registerer: registerer,
+		counters:   map[string]*prometheus.CounterVec{},
+		gauges:     map[string]*prometheus.GaugeVec{},
+		timers:     map[string]*prometheus.HistogramVec{},
+		labels:     map[string][]string{},
+		rejected:   map[string]bool{},
+	}}
+}
+
+// WithTags returns a handler adding the tags to the metrics it emits
+func (t *Temporal) WithTags(tags map[string]string) client.MetricsHandler {
+	merged := maps.Clone(t.tags)
+	if merged == nil {
+		merged = map[string]string{}
+	}
+	maps.Copy(merged, tags)
+	return &Temporal{vectors: t.vectors, tags: merged}
+}
+
+// Counter returns the counter of the given name
+func (t *Temporal) Counter(name string) client.MetricsCounter {
+	v := t.vectors
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.rejected[name] {
+		return client.MetricsNopHandler.Counter(name)
+	}
+	vec, ok := v.counters[name]
+	if !ok {
+		labels := t.labelNames()
+		vec = prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: "Temporal SDK counter " + name}, labels)
+		if !v.register(name, vec, labels) {
+			return client.MetricsNopHandler.Counter(name)
+		}
+		v.counters[name] = vec
+	}
+	counter := vec.WithLabelValues(t.labelValues(name)...)
+	return counterFunc(func(d int64) { counter.Add(float64(d)) })
+}
+
+// Gauge returns the gauge of the given name
+func (t *Temporal) Gauge(name string) client.MetricsGauge {
+	v := t.vectors
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.rejected[name] {
+		return client.MetricsNopHandler.Gauge(name)
+	}
+	vec, ok := v.gauges[name]
+	if !ok {
+		labels := t.labelNames()
+		vec = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: "Temporal SDK gauge " + name}, labels)
+		if !v.register(name, vec, labels) {
+			return client.MetricsNopHandler.Gauge(name)
+		}
+		v.gauges[name] = vec
+	}
+	return gaugeFunc(vec.WithLabelValues(t.labelValues(name)...).Set)
+}
+
+// Timer returns the timer of the given name
+func (t *Temporal) Timer(name string) client.MetricsTimer {
+	v := t.vectors
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.rejected[name] {
+		return client.MetricsNopHandler.Timer(name)
+	}
+	vec, ok := v.timers[name]
+	if !ok {
+		labels := t.labelNames()
+		vec = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    name,
+			Help:    "Temporal SDK timer " + name + ", in seconds",
+			Buckets: timerBuckets,
+		}, labels)
+		if !v.register(name, vec, labels) {
+			return client.MetricsNopHandler.Timer(name)
+		}
+		v.timers[name] = vec
+	}
+	histogram := vec.WithLabelValues(t.labelValues(name)...)
+	return timerFunc(func(d time.Duration) { histogram.Observe(d.Seconds()) })
+}
+
+// labelNames returns the handler's tag names, sorted, as the labels of a new metric
+func (t *Temporal) labelNames() []string {
+	return slices.Sorted(maps.Keys(t.tags))
+}
+
+// labelValues returns the values of the labels a metric was registered with
+func (t *Temporal) labelValues(name string) []string {
+	names := t.vectors.labels[name]
+	values := make([]string, len(names))
+	for i, label := range names {
+		values[i] = t.tags[label]
+	}
+	return values
+}
+
+// register registers a new metric and records its labels. A metric the registry rejects,
+// e.g. because another one has the name, is logged and not recorded.
+func (v *vectors) register(name string, collector prometheus.Collector, labels []string) bool {
+	if err := v.registerer.Register(collector); err != nil {
+		log.Printf("Warning: not recording Temporal metric %s: %v", name, err)
+		v.rejected[name] = true
+		return false
+	}
+	v.labels[name] = labels
+	return true
+}
+
+// counterFunc, gaugeFunc and timerFunc adapt Prometheus metrics to the SDK's interfaces
+type (
+	counterFunc func(int64)
+	gaugeFunc   func(float64)
+	timerFunc   func(time.Duration)
+)
+
+// Inc increments the counter
+func (f counterFunc) Inc(d int64) { f(d) }
+
+// Update sets the gauge
+func (f gaugeFunc) Update(value float64) { f(value) }
+
+// Record observes a duration
+func (f timerFunc) Record(d time.Duration) { f(d) }
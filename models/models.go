@@ -0,0 +1,108 @@
+// Package models holds the types shared by the API server and the worker, such as the input
+// conversations are started with, so both sides agree on them without depending on each other.
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Bounds of the metadata of a conversation
+const (
+	maxMetadataEntries    = 32
+	maxMetadataKeyBytes   = 64
+	maxMetadataValueBytes = 1024
+)
+
+// Bounds of the sampling settings of a conversation
+const (
+	MaxTemperature = 2
+	MaxReplyTokens = 32_768
+)
+
+// AgentInput configures a conversation (workflows.AgentGoalWorkflow) when it starts
+type AgentInput struct {
+	// Message is the first user message, if any
+	Message string `json:"message,omitempty"`
+	// UserID is the user the conversation is with; empty for anonymous conversations
+	UserID string `json:"user_id,omitempty"`
+	// ConversationID is the ID clients know the conversation by. The API server starts the
+	// workflow under it; when empty, the workflow ID is used.
+	ConversationID string `json:"conversation_id,omitempty"`
+	// SystemPrompt and Model replace the deployment's prompt template and model for this
+	// conversation, which then takes part in no A/B experiment
+	SystemPrompt string `json:"system_prompt,omitempty"`
+	Model        string `json:"model,omitempty"`
+	// Temperature and MaxTokens tune every completion of the conversation: Temperature from
+	// 0 to MaxTemperature (nil uses the provider's default), and MaxTokens caps the tokens of
+	// each reply (0 leaves it to the provider)
+	Temperature *float64 `json:"temperature,omitempty"`
+	MaxTokens   int      `json:"max_tokens,omitempty"`
+	// Goal is the use case of the conversation (see goals.Builtin); empty uses goals.Default
+	Goal string `json:"goal,omitempty"`
+	// Metadata are free-form labels kept with the conversation and its transcript (e.g. a
+	// CRM ticket ID); unlike Context, they are never shown to the model or tools
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// Persona overrides the goal's default persona
+	Persona string `json:"persona,omitempty"`
+	// Template starts the conversation from a guided flow (see templates.Load): the agent
+	// opens it with the template's greeting, and the template's goal and persona apply
+	// unless set here
+	Template string `json:"template,omitempty"`
+	// Context names the business records the conversation is about (account ID, order ID,
+	// locale, ...); see workflows.ValidateContext
+	Context map[string]string `json:"context,omitempty"`
+	// CoalesceMessages merges user messages that queued up while the agent was busy into one turn
+	CoalesceMessages bool `json:"coalesce_messages,omitempty"`
+	// SuggestReplies has the title model suggest a few replies after each of the agent's
+	// answers, returned with the turn and emitted as a quick_replies event
+	SuggestReplies bool `json:"suggest_replies,omitempty"`
+	// Account is the quota account messages and tokens are counted against; empty disables counting
+	Account string `json:"account,omitempty"`
+	// StreamEvents publishes progress events to the conversation's Redis stream
+	StreamEvents bool `json:"stream_events,omitempty"`
+	// FallbackMessage is the reply sent when the LLM is unavailable; empty uses a built-in message
+	FallbackMessage string `json:"fallback_message,omitempty"`
+	// LatencyBudget is how long the agent may spend on the completions of a turn before it
+	// answers with FallbackModel, a faster model; zero or no fallback model disables it
+	LatencyBudget time.Duration `json:"latency_budget,omitempty"`
+	FallbackModel string        `json:"fallback_model,omitempty"`
+	// IdleTimeout closes the conversation with a closing message once nothing happened in it
+	// for that long; zero keeps it open until the user ends it
+	IdleTimeout time.Duration `json:"idle_timeout,omitempty"`
+	// ConfirmTimeout is how long a tool call waits for the user's confirmation before it is
+	// given up and the agent tells the user; zero waits indefinitely
+	ConfirmTimeout time.Duration `json:"confirm_timeout,omitempty"`
+	// RenotifyAfter is how long an important message, such as a tool call to confirm, may
+	// stay unread before it is sent again; zero never sends reminders
+	RenotifyAfter time.Duration `json:"renotify_after,omitempty"`
+}
+
+// ValidateMetadata checks the metadata of a start request: at most 32 labels, keyed by at
+// most 64 bytes, of at most 1 KB each
+func ValidateMetadata(metadata map[string]string) error {
+	if len(metadata) > maxMetadataEntries {
+		return fmt.Errorf("metadata has %d labels, more than %d", len(metadata), maxMetadataEntries)
+	}
+	for key, value := range metadata {
+		if key == "" || len(key) > maxMetadataKeyBytes {
+			return fmt.Errorf("metadata key %q must be 1 to %d bytes", key, maxMetadataKeyBytes)
+		}
+		if len(value) > maxMetadataValueBytes {
+			return fmt.Errorf("metadata value %s is longer than %d bytes", key, maxMetadataValueBytes)
+		}
+	}
+	return nil
+}
+
+// ValidateSampling checks the temperature and reply token cap of a start request
+func ValidateSampling(temperature *float64, maxTokens int) error {
+	if temperature != nil && (*temperature < 0 || *temperature > MaxTemperature) {
+		return fmt.Errorf("temperature must be between 0 and %d", MaxTemperature)
+	}
+	if maxTokens < 0 || maxTokens > MaxReplyTokens {
+		return fmt.Errorf("max_tokens must be between 0 and %d", MaxReplyTokens)
+	}
+	return nil
+}
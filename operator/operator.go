@@ -0,0 +1,134 @@
+package operator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Notification kinds sent to the operator channel
+const (
+	KindHandoffRequested = "handoff_requested"
+	KindUserMessage      = "user_message"
+)
+
+// Notification tells human operators that a conversation needs them
+type Notification struct {
+	// ID stays the same when delivery is retried, so receivers can drop duplicates
+	ID         string `json:"id,omitempty"`
+	Kind       string `json:"kind"`
+	WorkflowID string `json:"workflow_id"`
+	Reason     string `json:"reason,omitempty"`
+	Message    string `json:"message,omitempty"`
+}
+
+// Text renders the notification as a one-line message for chat channels
+func (n Notification) Text() string {
+	switch n.Kind {
+	case KindHandoffRequested:
+		return fmt.Sprintf("Conversation %s needs an operator: %s", n.WorkflowID, n.Reason)
+	case KindUserMessage:
+		return fmt.Sprintf("New message in %s: %s", n.WorkflowID, n.Message)
+	default:
+		return fmt.Sprintf("%s in %s", n.Kind, n.WorkflowID)
+	}
+}
+
+// Notifier delivers notifications to the operator channel
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// New returns a Slack notifier when a Slack webhook URL is configured, a generic webhook
+// notifier when a webhook URL is, and a log notifier otherwise
+func New(slackWebhookURL, webhookURL string) Notifier {
+	client := &http.Client{Timeout: 10 * time.Second}
+	switch {
+	case slackWebhookURL != "":
+		return &Slack{WebhookURL: slackWebhookURL, Client: client}
+	case webhookURL != "":
+		return &Webhook{URL: webhookURL, Client: client}
+	default:
+		return Log{}
+	}
+}
+
+// Log writes notifications to the worker log, for local development
+type Log struct{}
+
+// Notify logs the notification
+func (Log) Notify(ctx context.Context, n Notification) error {
+	log.Printf("Operator notification: %s", n.Text())
+	return nil
+}
+
+// Slack posts notifications to a Slack incoming webhook
+type Slack struct {
+	WebhookURL string
+	Client     *http.Client
+}
+
+// Notify posts the notification text to the webhook
+func (s *Slack) Notify(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(map[string]string{"text": n.Text()})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("slack webhook returned %s: %s", resp.Status, msg)
+	}
+	return nil
+}
+
+// Webhook posts notifications as JSON to an HTTP endpoint, with the notification ID as the
+// Idempotency-Key header
+type Webhook struct {
+	URL    string
+	Client *http.Client
+}
+
+// Notify posts the notification to the endpoint
+func (h *Webhook) Notify(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.ID != "" {
+		req.Header.Set("Idempotency-Key", n.ID)
+	}
+
+	resp, err := h.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("notification webhook returned %s: %s", resp.Status, msg)
+	}
+	return nil
+}
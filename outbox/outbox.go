@@ -0,0 +1,87 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"temporal-ai-agent/operator"
+	"time"
+)
+
+// ErrNotFound is returned for dedup keys the outbox does not have
+var ErrNotFound = errors.New("notification not found")
+
+// Entry is a notification recorded for delivery
+type Entry struct {
+	// DedupKey identifies the notification: recording it again is a no-op, and receivers get
+	// it as the notification ID to drop the duplicates of at-least-once delivery
+	DedupKey     string                `json:"dedup_key"`
+	Notification operator.Notification `json:"notification"`
+	Attempts     int                   `json:"attempts"`
+	LastError    string                `json:"last_error,omitempty"`
+	CreatedAt    time.Time             `json:"created_at"`
+	// DeliveredAt is nil until a delivery attempt succeeds
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+}
+
+// Store persists the outbox
+type Store interface {
+	// Add records a pending entry unless one with the same dedup key exists, and reports
+	// whether it was added
+	Add(ctx context.Context, entry Entry) (bool, error)
+	// Get returns the entry with the given dedup key, or ErrNotFound
+	Get(ctx context.Context, dedupKey string) (Entry, error)
+	// RecordAttempt counts a delivery attempt; an empty errMsg marks the entry delivered
+	RecordAttempt(ctx context.Context, dedupKey, errMsg string, at time.Time) error
+}
+
+// MemoryStore is a Store kept in process memory. It only works with a single worker, as
+// in dev mode, and loses undelivered entries on restart.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]Entry
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: map[string]Entry{}}
+}
+
+// Add records a pending entry unless one with the same dedup key exists
+func (m *MemoryStore) Add(ctx context.Context, entry Entry) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.entries[entry.DedupKey]; ok {
+		return false, nil
+	}
+	m.entries[entry.DedupKey] = entry
+	return true, nil
+}
+
+// Get returns the entry with the given dedup key
+func (m *MemoryStore) Get(ctx context.Context, dedupKey string) (Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[dedupKey]
+	if !ok {
+		return Entry{}, ErrNotFound
+	}
+	return entry, nil
+}
+
+// RecordAttempt counts a delivery attempt
+func (m *MemoryStore) RecordAttempt(ctx context.Context, dedupKey, errMsg string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[dedupKey]
+	if !ok {
+		return ErrNotFound
+	}
+	entry.Attempts++
+	entry.LastError = errMsg
+	if errMsg == "" {
+		entry.DeliveredAt = &at
+	}
+	m.entries[dedupKey] = entry
+	return nil
+}
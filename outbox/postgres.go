@@ -0,0 +1,102 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS notification_outbox (
+	dedup_key    TEXT PRIMARY KEY,
+	notification JSONB NOT NULL,
+	attempts     INTEGER NOT NULL DEFAULT 0,
+	last_error   TEXT NOT NULL DEFAULT '',
+	created_at   TIMESTAMPTZ NOT NULL,
+	delivered_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS notification_outbox_pending_idx
+	ON notification_outbox (created_at) WHERE delivered_at IS NULL;
+`
+
+// PostgresStore is a Store backed by Postgres
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore connects to Postgres and creates the outbox table if needed
+func NewPostgresStore(ctx context.Context, databaseURL string) (*PostgresStore, error) {
+	db, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &PostgresStore{db: db}, nil
+}
+
+// Close closes the database connection
+func (p *PostgresStore) Close() error {
+	return p.db.Close()
+}
+
+// Add records a pending entry unless one with the same dedup key exists
+func (p *PostgresStore) Add(ctx context.Context, entry Entry) (bool, error) {
+	notification, err := json.Marshal(entry.Notification)
+	if err != nil {
+		return false, err
+	}
+	result, err := p.db.ExecContext(ctx, `
+		INSERT INTO notification_outbox (dedup_key, notification, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (dedup_key) DO NOTHING`,
+		entry.DedupKey, string(notification), entry.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+	added, err := result.RowsAffected()
+	return added > 0, err
+}
+
+// Get returns the entry with the given dedup key
+func (p *PostgresStore) Get(ctx context.Context, dedupKey string) (Entry, error) {
+	entry := Entry{DedupKey: dedupKey}
+	var notification []byte
+	err := p.db.QueryRowContext(ctx, `
+		SELECT notification, attempts, last_error, created_at, delivered_at
+		FROM notification_outbox WHERE dedup_key = $1`, dedupKey).
+		Scan(&notification, &entry.Attempts, &entry.LastError, &entry.CreatedAt, &entry.DeliveredAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Entry{}, ErrNotFound
+	}
+	if err != nil {
+		return Entry{}, err
+	}
+	return entry, json.Unmarshal(notification, &entry.Notification)
+}
+
+// RecordAttempt counts a delivery attempt
+func (p *PostgresStore) RecordAttempt(ctx context.Context, dedupKey, errMsg string, at time.Time) error {
+	var deliveredAt *time.Time
+	if errMsg == "" {
+		deliveredAt = &at
+	}
+	result, err := p.db.ExecContext(ctx, `
+		UPDATE notification_outbox
+		SET attempts = attempts + 1, last_error = $2, delivered_at = COALESCE(delivered_at, $3)
+		WHERE dedup_key = $1`,
+		dedupKey, errMsg, deliveredAt)
+	if err != nil {
+		return err
+	}
+	if updated, err := result.RowsAffected(); err == nil && updated == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package personas
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DefaultName is the persona used when neither the request nor the goal picks one
+const DefaultName = "default"
+
+// Persona describes the voice the agent speaks with. Empty fields leave the model's defaults.
+type Persona struct {
+	Name      string `json:"name"`
+	Tone      string `json:"tone,omitempty"`
+	Verbosity string `json:"verbosity,omitempty"`
+	Language  string `json:"language,omitempty"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// Catalog holds the configured personas and the default persona of each goal
+type Catalog struct {
+	Personas []Persona         `json:"personas"`
+	Goals    map[string]string `json:"goals,omitempty"`
+}
+
+// Load reads a catalog from a JSON file. An empty path returns the built-in catalog.
+func Load(path string) (Catalog, error) {
+	catalog := Catalog{}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Catalog{}, fmt.Errorf("reading personas file: %w", err)
+		}
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			return Catalog{}, fmt.Errorf("parsing personas file: %w", err)
+		}
+	}
+
+	if _, ok := catalog.find(DefaultName); !ok {
+		catalog.Personas = append(catalog.Personas, Persona{Name: DefaultName})
+	}
+	for goal, name := range catalog.Goals {
+		if _, ok := catalog.find(name); !ok {
+			return Catalog{}, fmt.Errorf("goal %q uses unknown persona %q", goal, name)
+		}
+	}
+	return catalog, nil
+}
+
+// Resolve returns the requested persona, falling back to the goal's persona and then the default
+func (c Catalog) Resolve(name, goal string) (Persona, error) {
+	if name == "" {
+		name = c.Goals[goal]
+	}
+	if name == "" {
+		name = DefaultName
+	}
+
+	p, ok := c.find(name)
+	if !ok {
+		return Persona{}, fmt.Errorf("unknown persona %q", name)
+	}
+	return p, nil
+}
+
+// find looks up a persona by name
+func (c Catalog) find(name string) (Persona, bool) {
+	for _, p := range c.Personas {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Persona{}, false
+}
+
+// Instructions renders the persona as system prompt instructions
+func (p Persona) Instructions() string {
+	var lines []string
+	if p.Tone != "" {
+		lines = append(lines, fmt.Sprintf("Use a %s tone.", p.Tone))
+	}
+	if p.Verbosity != "" {
+		lines = append(lines, fmt.Sprintf("Keep your answers %s.", p.Verbosity))
+	}
+	if p.Language != "" {
+		lines = append(lines, fmt.Sprintf("Always reply in %s.", p.Language))
+	}
+	if p.Signature != "" {
+		lines = append(lines, fmt.Sprintf("End every reply with the signature %q.", p.Signature))
+	}
+	return strings.Join(lines, " ")
+}
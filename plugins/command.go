@@ -0,0 +1,57 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"temporal-ai-agent/tools"
+	"time"
+)
+
+// waitDelay is how long a command killed at its deadline gets to close its output, in case
+// processes it started still hold it open
+const waitDelay = time.Second
+
+// runCommand runs a command plugin in a new process. ctx carries the call's deadline.
+func (p *Plugin) runCommand(ctx context.Context, input []byte) (string, error) {
+	stdout := &limitedBuffer{limit: maxOutputBytes}
+	stderr := &limitedBuffer{limit: maxErrorBytes}
+	cmd := exec.CommandContext(ctx, p.command, p.manifest.Args...)
+	cmd.Dir = p.manifest.dir
+	cmd.Env = p.environment()
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = waitDelay
+
+	err := cmd.Run()
+	if err == nil {
+		return p.output(stdout)
+	}
+	// Like modules, commands only get retried when they time out
+	var exit *exec.ExitError
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return "", fmt.Errorf("plugin %s timed out after %s", p.manifest.Name, p.manifest.timeout)
+	case ctx.Err() != nil:
+		return "", ctx.Err()
+	case errors.As(err, &exit):
+		return "", p.failure(exit.ExitCode(), stderr)
+	default:
+		return "", tools.Permanent(fmt.Errorf("running plugin %s: %w", p.manifest.Name, err))
+	}
+}
+
+// environment returns the variables a command plugin sees: PATH and those its manifest names
+func (p *Plugin) environment() []string {
+	env := []string{}
+	for _, name := range append([]string{"PATH"}, p.manifest.Env...) {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	return env
+}
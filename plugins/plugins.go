@@ -0,0 +1,159 @@
+// Package plugins runs custom tools outside the worker binary, so tools can be added to the
+// worker by dropping a program and its manifest in a directory instead of recompiling it.
+//
+// Every call runs the plugin afresh with the call's arguments as a JSON object on stdin, and
+// its stdout is the tool result. Exiting with a non-zero code fails the call, with stderr as
+// the message. A plugin is either a WASI command module (wasm32-wasip1, e.g. built with
+// GOOS=wasip1 GOARCH=wasm), sandboxed so it sees no files, environment variables or
+// network, only the clock and random numbers; or an executable run as a child process of
+// the worker, for tools that need the network, files or a language without WASI support.
+package plugins
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"temporal-ai-agent/goals"
+	"temporal-ai-agent/tools"
+	"time"
+)
+
+// Defaults of the limits of plugin calls
+const (
+	DefaultTimeout       = 10 * time.Second
+	DefaultMemoryLimitMB = 128
+)
+
+// Manifest declares a plugin tool, read from a .json file of the plugin directory
+type Manifest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Parameters is the JSON schema of the tool arguments
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	// Risk is one of the tools' Risk levels; empty is treated as irreversible
+	Risk                 string `json:"risk,omitempty"`
+	Summary              string `json:"summary,omitempty"`
+	RequiresConfirmation bool   `json:"requires_confirmation,omitempty"`
+	CompletesGoal        bool   `json:"completes_goal,omitempty"`
+	// Goals are the goals whose conversations may call the tool
+	Goals []string `json:"goals"`
+	// Module is the path of the WebAssembly module, relative to the manifest
+	Module string `json:"module,omitempty"`
+	// Command is the executable of a plugin run as a process instead of a module: a path
+	// relative to the manifest, or a program name looked up in PATH. It runs in the
+	// manifest's directory with Args, and sees only PATH and the worker's environment
+	// variables named in Env.
+	Command string   `json:"command,omitempty"`
+	Args    []string `json:"args,omitempty"`
+	Env     []string `json:"env,omitempty"`
+	// Timeout bounds a call, e.g. "5s" (DefaultTimeout when empty); MemoryLimitMB bounds
+	// the module's memory (DefaultMemoryLimitMB when 0), and does not apply to commands
+	Timeout       string `json:"timeout,omitempty"`
+	MemoryLimitMB int    `json:"memory_limit_mb,omitempty"`
+
+	// timeout is the parsed Timeout
+	timeout time.Duration
+	// dir is the directory of the manifest
+	dir string
+}
+
+// Definition returns the tool definition declared by the manifest
+func (m Manifest) Definition() tools.Definition {
+	return tools.Definition{
+		Name:                 m.Name,
+		Description:          m.Description,
+		Parameters:           m.Parameters,
+		Risk:                 m.Risk,
+		Summary:              m.Summary,
+		RequiresConfirmation: m.RequiresConfirmation,
+		CompletesGoal:        m.CompletesGoal,
+	}
+}
+
+// LoadManifests reads the manifests of the plugin directory. An empty directory path
+// disables plugins.
+func LoadManifests(dir string) ([]Manifest, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(paths)
+
+	manifests := make([]Manifest, 0, len(paths))
+	for _, path := range paths {
+		m, err := loadManifest(path)
+		if err != nil {
+			return nil, fmt.Errorf("plugin manifest %s: %w", filepath.Base(path), err)
+		}
+		if slices.ContainsFunc(manifests, func(other Manifest) bool { return other.Name == m.Name }) {
+			return nil, fmt.Errorf("plugin manifest %s: tool %q is declared twice", filepath.Base(path), m.Name)
+		}
+		manifests = append(manifests, m)
+	}
+	return manifests, nil
+}
+
+// loadManifest reads and checks a manifest, resolving its module path
+func loadManifest(path string) (Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Manifest{}, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return Manifest{}, fmt.Errorf("parsing: %w", err)
+	}
+
+	if m.Name == "" || m.Description == "" {
+		return Manifest{}, fmt.Errorf("name and description are required")
+	}
+	if (m.Module == "") == (m.Command == "") {
+		return Manifest{}, fmt.Errorf("exactly one of module and command is required")
+	}
+	switch m.Risk {
+	case "", tools.RiskReadOnly, tools.RiskReversible, tools.RiskIrreversible:
+	default:
+		return Manifest{}, fmt.Errorf("risk must be %s, %s or %s", tools.RiskReadOnly, tools.RiskReversible, tools.RiskIrreversible)
+	}
+	if len(m.Goals) == 0 {
+		return Manifest{}, fmt.Errorf("at least one goal is required")
+	}
+	if m.MemoryLimitMB < 0 {
+		return Manifest{}, fmt.Errorf("memory_limit_mb must not be negative")
+	}
+	m.MemoryLimitMB = cmp.Or(m.MemoryLimitMB, DefaultMemoryLimitMB)
+	m.timeout = DefaultTimeout
+	if m.Timeout != "" {
+		if m.timeout, err = time.ParseDuration(m.Timeout); err != nil || m.timeout <= 0 {
+			return Manifest{}, fmt.Errorf("timeout must be a positive duration such as 5s")
+		}
+	}
+	m.dir = filepath.Dir(path)
+	if m.Module != "" && !filepath.IsAbs(m.Module) {
+		m.Module = filepath.Join(m.dir, m.Module)
+	}
+	// Program names are left to PATH, which the worker resolves when it loads the plugin
+	if strings.ContainsRune(m.Command, filepath.Separator) && !filepath.IsAbs(m.Command) {
+		m.Command = filepath.Join(m.dir, m.Command)
+	}
+	return m, nil
+}
+
+// Grant lets the conversations of the goals declared by the manifests call their tools
+func Grant(catalog *goals.Catalog, manifests []Manifest) error {
+	for _, m := range manifests {
+		for _, goal := range m.Goals {
+			if err := catalog.Grant(goal, m.Name); err != nil {
+				return fmt.Errorf("plugin %s: %w", m.Name, err)
+			}
+		}
+	}
+	return nil
+}
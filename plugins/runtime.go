@@ -0,0 +1,176 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"temporal-ai-agent/tools"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// Bounds of what a call reads back from a plugin; results are then fitted to the tool
+// result limits like any other tool's
+const (
+	maxOutputBytes = 1 << 20
+	maxErrorBytes  = 4 << 10
+)
+
+// wasmPageMB is the number of 64 KiB WebAssembly memory pages in a megabyte
+const wasmPageMB = 16
+
+// Plugin is a loaded plugin: a compiled module, instantiated afresh for every call, or a
+// command, started afresh for every call
+type Plugin struct {
+	manifest Manifest
+	// runtime and module run module plugins; both are nil for commands
+	runtime wazero.Runtime
+	module  wazero.CompiledModule
+	// command is the resolved executable of command plugins
+	command string
+}
+
+// Compile compiles the module of a plugin, or checks that its command can be run.
+// Compilation happens once, when the worker starts, so calls only pay for instantiation.
+func Compile(ctx context.Context, m Manifest) (*Plugin, error) {
+	if m.Command != "" {
+		command, err := exec.LookPath(m.Command)
+		if err != nil {
+			return nil, fmt.Errorf("plugin %s: %w", m.Name, err)
+		}
+		return &Plugin{manifest: m, command: command}, nil
+	}
+
+	code, err := os.ReadFile(m.Module)
+	if err != nil {
+		return nil, fmt.Errorf("reading plugin %s: %w", m.Name, err)
+	}
+
+	runtime := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(uint32(m.MemoryLimitMB*wasmPageMB)).
+		WithCloseOnContextDone(true))
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, runtime); err != nil {
+		runtime.Close(ctx)
+		return nil, err
+	}
+	module, err := runtime.CompileModule(ctx, code)
+	if err != nil {
+		runtime.Close(ctx)
+		return nil, fmt.Errorf("compiling plugin %s: %w", m.Name, err)
+	}
+	return &Plugin{manifest: m, runtime: runtime, module: module}, nil
+}
+
+// Load compiles the plugins of the manifests
+func Load(ctx context.Context, manifests []Manifest) ([]*Plugin, error) {
+	plugins := make([]*Plugin, 0, len(manifests))
+	for _, m := range manifests {
+		p, err := Compile(ctx, m)
+		if err != nil {
+			for _, loaded := range plugins {
+				loaded.Close(ctx)
+			}
+			return nil, err
+		}
+		plugins = append(plugins, p)
+	}
+	return plugins, nil
+}
+
+// Close releases the compiled module
+func (p *Plugin) Close(ctx context.Context) error {
+	if p.runtime == nil {
+		return nil
+	}
+	return p.runtime.Close(ctx)
+}
+
+// Tool returns the plugin as a tool the agent can call
+func (p *Plugin) Tool() tools.Tool {
+	return tools.Tool{Definition: p.manifest.Definition(), Handler: p.Run}
+}
+
+// Run calls the plugin with the given arguments and returns what it wrote to stdout
+func (p *Plugin) Run(ctx context.Context, args map[string]interface{}) (string, error) {
+	input, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.manifest.timeout)
+	defer cancel()
+	if p.command != "" {
+		return p.runCommand(ctx, input)
+	}
+
+	stdout := &limitedBuffer{limit: maxOutputBytes}
+	stderr := &limitedBuffer{limit: maxErrorBytes}
+	config := wazero.NewModuleConfig().
+		WithName("").
+		WithArgs(p.manifest.Name).
+		WithStdin(bytes.NewReader(input)).
+		WithStdout(stdout).
+		WithStderr(stderr).
+		WithSysWalltime().
+		WithSysNanotime().
+		WithRandSource(rand.Reader)
+
+	mod, err := p.runtime.InstantiateModule(ctx, p.module, config)
+	if mod != nil {
+		mod.Close(ctx)
+	}
+	// Plugins are pure functions of their arguments, so only timeouts are worth retrying
+	var exit *sys.ExitError
+	switch {
+	case errors.As(err, &exit) && exit.ExitCode() == sys.ExitCodeDeadlineExceeded:
+		return "", fmt.Errorf("plugin %s timed out after %s", p.manifest.Name, p.manifest.timeout)
+	case errors.As(err, &exit) && exit.ExitCode() == sys.ExitCodeContextCanceled:
+		return "", ctx.Err()
+	case errors.As(err, &exit):
+		return "", p.failure(int(exit.ExitCode()), stderr)
+	case err != nil:
+		return "", tools.Permanent(fmt.Errorf("running plugin %s: %w", p.manifest.Name, err))
+	}
+	return p.output(stdout)
+}
+
+// output returns what the plugin wrote to stdout, failing calls whose result was cut
+func (p *Plugin) output(stdout *limitedBuffer) (string, error) {
+	if stdout.truncated {
+		return "", tools.Permanent(fmt.Errorf("plugin %s wrote more than %d bytes", p.manifest.Name, maxOutputBytes))
+	}
+	return stdout.String(), nil
+}
+
+// failure returns the error of a call the plugin ended with a non-zero exit code
+func (p *Plugin) failure(code int, stderr *limitedBuffer) error {
+	message := strings.TrimSpace(stderr.String())
+	if message == "" {
+		message = fmt.Sprintf("exit code %d", code)
+	}
+	return tools.Permanent(fmt.Errorf("plugin %s failed: %s", p.manifest.Name, message))
+}
+
+// limitedBuffer keeps the first limit bytes written to it and drops the rest
+type limitedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// Write keeps what fits under the limit; writes never fail, so the plugin is not stopped
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); len(p) > room {
+		b.truncated = true
+		b.Buffer.Write(p[:max(room, 0)])
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
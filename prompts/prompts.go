@@ -0,0 +1,31 @@
+package prompts
+
+import "fmt"
+
+// CurrentVersion is the template version new conversations are pinned to
+const CurrentVersion = "v1"
+
+// Template is a versioned system prompt. Published versions must never be edited:
+// running conversations keep using the version they started with, so changes go
+// into a new version that CurrentVersion is then pointed at.
+type Template struct {
+	Version string
+	System  string
+}
+
+var templates = map[string]Template{
+	"v1": {
+		Version: "v1",
+		System: "You are a helpful AI agent. Answer the user's questions clearly and concisely, " +
+			"and ask for clarification when a request is ambiguous.",
+	},
+}
+
+// Get returns the template with the given version
+func Get(version string) (Template, error) {
+	t, ok := templates[version]
+	if !ok {
+		return Template{}, fmt.Errorf("unknown prompt version %q", version)
+	}
+	return t, nil
+}
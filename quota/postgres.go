@@ -0,0 +1,94 @@
+package quota
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS quota_usage (
+	account  TEXT NOT NULL,
+	period   TEXT NOT NULL,
+	messages BIGINT NOT NULL DEFAULT 0,
+	tokens   BIGINT NOT NULL DEFAULT 0,
+	PRIMARY KEY (account, period)
+);
+`
+
+// PostgresStore is a Store shared by every API server and worker using the same database.
+// Messages are counted per day and tokens per month.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore connects to Postgres and creates the usage table if needed
+func NewPostgresStore(ctx context.Context, databaseURL string) (*PostgresStore, error) {
+	db, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &PostgresStore{db: db}, nil
+}
+
+// Close closes the database connection
+func (p *PostgresStore) Close() error {
+	return p.db.Close()
+}
+
+// Usage returns the usage of an account in the day and month of now
+func (p *PostgresStore) Usage(ctx context.Context, account string, now time.Time) (Usage, error) {
+	var usage Usage
+	err := p.db.QueryRowContext(ctx, `
+		SELECT
+			COALESCE(SUM(messages) FILTER (WHERE period = $2), 0),
+			COALESCE(SUM(tokens) FILTER (WHERE period = $3), 0)
+		FROM quota_usage
+		WHERE account = $1 AND period IN ($2, $3)`,
+		account, day(now), month(now)).Scan(&usage.MessagesToday, &usage.TokensThisMonth)
+	return usage, err
+}
+
+// Add adds usage to an account
+func (p *PostgresStore) Add(ctx context.Context, record Record) error {
+	_, err := p.db.ExecContext(ctx, `
+		INSERT INTO quota_usage (account, period, messages, tokens)
+		VALUES ($1, $2, $3, 0), ($1, $4, 0, $5)
+		ON CONFLICT (account, period) DO UPDATE
+		SET messages = quota_usage.messages + EXCLUDED.messages,
+			tokens = quota_usage.tokens + EXCLUDED.tokens`,
+		record.Account, day(record.Time), record.Messages, month(record.Time), record.Tokens)
+	return err
+}
+
+// MonthUsage returns the usage of every account in the month of t
+func (p *PostgresStore) MonthUsage(ctx context.Context, t time.Time) ([]AccountUsage, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT account,
+			COALESCE(SUM(messages) FILTER (WHERE period <> $1), 0),
+			COALESCE(SUM(tokens) FILTER (WHERE period = $1), 0)
+		FROM quota_usage
+		WHERE period = $1 OR period LIKE $1 || '-%'
+		GROUP BY account
+		ORDER BY account`, month(t))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	usage := []AccountUsage{}
+	for rows.Next() {
+		var u AccountUsage
+		if err := rows.Scan(&u.Account, &u.Messages, &u.Tokens); err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
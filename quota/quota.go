@@ -0,0 +1,212 @@
+package quota
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Plan limits the usage of the API keys subscribed to it. Zero limits are unlimited.
+type Plan struct {
+	MessagesPerDay int `json:"messages_per_day,omitempty"`
+	TokensPerMonth int `json:"tokens_per_month,omitempty"`
+	// Premium plans have their conversations run on the premium task queue, when one is
+	// configured, away from the load of the others
+	Premium bool `json:"premium,omitempty"`
+}
+
+// Plans maps API keys to the plans they are subscribed to
+type Plans struct {
+	Plans map[string]Plan `json:"plans"`
+	// Keys maps API keys to plan names
+	Keys map[string]string `json:"keys"`
+}
+
+// Load reads plans from a JSON file. An empty path returns no plans, which disables quotas.
+func Load(path string) (Plans, error) {
+	if path == "" {
+		return Plans{}, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Plans{}, fmt.Errorf("reading quota file: %w", err)
+	}
+	var plans Plans
+	if err := json.Unmarshal(data, &plans); err != nil {
+		return Plans{}, fmt.Errorf("parsing quota file: %w", err)
+	}
+	for key, name := range plans.Keys {
+		if _, ok := plans.Plans[name]; !ok {
+			return Plans{}, fmt.Errorf("API key %s... uses unknown plan %q", key[:min(len(key), 4)], name)
+		}
+	}
+	return plans, nil
+}
+
+// Enabled reports whether any API key is subject to a quota
+func (p Plans) Enabled() bool {
+	return len(p.Keys) > 0
+}
+
+// Lookup returns the plan name and plan of an API key
+func (p Plans) Lookup(apiKey string) (string, Plan, bool) {
+	name, ok := p.Keys[apiKey]
+	if !ok {
+		return "", Plan{}, false
+	}
+	return name, p.Plans[name], true
+}
+
+// PlanOf returns the plan name of the API key behind an account, or "" if no configured
+// key belongs to it
+func (p Plans) PlanOf(account string) string {
+	for key, name := range p.Keys {
+		if AccountID(key) == account {
+			return name
+		}
+	}
+	return ""
+}
+
+// AccountID identifies the usage account of an API key without revealing the key, so
+// it can be stored in workflow state
+func AccountID(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Usage is the usage of an account in the current day and month
+type Usage struct {
+	MessagesToday   int `json:"messages_today"`
+	TokensThisMonth int `json:"tokens_this_month"`
+}
+
+// Remaining is what is left of a plan's limits; nil means unlimited
+type Remaining struct {
+	Plan     string `json:"plan"`
+	Messages *int   `json:"messages_remaining_today"`
+	Tokens   *int   `json:"tokens_remaining_this_month"`
+}
+
+// Remaining returns what is left of the plan given the usage
+func (p Plan) Remaining(name string, usage Usage) Remaining {
+	remaining := Remaining{Plan: name}
+	if p.MessagesPerDay > 0 {
+		left := max(p.MessagesPerDay-usage.MessagesToday, 0)
+		remaining.Messages = &left
+	}
+	if p.TokensPerMonth > 0 {
+		left := max(p.TokensPerMonth-usage.TokensThisMonth, 0)
+		remaining.Tokens = &left
+	}
+	return remaining
+}
+
+// Check returns an error describing the exhausted limit, if any
+func (p Plan) Check(usage Usage) error {
+	if p.MessagesPerDay > 0 && usage.MessagesToday >= p.MessagesPerDay {
+		return fmt.Errorf("daily message quota of %d exhausted", p.MessagesPerDay)
+	}
+	if p.TokensPerMonth > 0 && usage.TokensThisMonth >= p.TokensPerMonth {
+		return fmt.Errorf("monthly token quota of %d exhausted", p.TokensPerMonth)
+	}
+	return nil
+}
+
+// Record is usage to add to an account
+type Record struct {
+	Account  string    `json:"account"`
+	Messages int       `json:"messages"`
+	Tokens   int       `json:"tokens"`
+	Time     time.Time `json:"time"`
+}
+
+// AccountUsage is the usage of an account over a month
+type AccountUsage struct {
+	Account  string `json:"account"`
+	Messages int    `json:"messages"`
+	Tokens   int    `json:"tokens"`
+}
+
+// Store keeps the usage of accounts per day and month
+type Store interface {
+	Usage(ctx context.Context, account string, now time.Time) (Usage, error)
+	Add(ctx context.Context, record Record) error
+	// MonthUsage returns the usage of every account in the month of t, ordered by account
+	MonthUsage(ctx context.Context, t time.Time) ([]AccountUsage, error)
+}
+
+// day and month return the usage periods a time falls in
+func day(t time.Time) string   { return t.UTC().Format("2006-01-02") }
+func month(t time.Time) string { return t.UTC().Format("2006-01") }
+
+// MemoryStore is a Store kept in process memory. It only works when the API server and
+// the worker run in the same process, as in dev mode.
+type MemoryStore struct {
+	mu       sync.Mutex
+	messages map[string]int
+	tokens   map[string]int
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{messages: map[string]int{}, tokens: map[string]int{}}
+}
+
+// Usage returns the usage of an account in the day and month of now
+func (m *MemoryStore) Usage(ctx context.Context, account string, now time.Time) (Usage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return Usage{
+		MessagesToday:   m.messages[account+"/"+day(now)],
+		TokensThisMonth: m.tokens[account+"/"+month(now)],
+	}, nil
+}
+
+// Add adds usage to an account
+func (m *MemoryStore) Add(ctx context.Context, record Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages[record.Account+"/"+day(record.Time)] += record.Messages
+	m.tokens[record.Account+"/"+month(record.Time)] += record.Tokens
+	return nil
+}
+
+// MonthUsage returns the usage of every account in the month of t
+func (m *MemoryStore) MonthUsage(ctx context.Context, t time.Time) ([]AccountUsage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	byAccount := map[string]*AccountUsage{}
+	get := func(account string) *AccountUsage {
+		if byAccount[account] == nil {
+			byAccount[account] = &AccountUsage{Account: account}
+		}
+		return byAccount[account]
+	}
+	for key, n := range m.messages {
+		account, period, _ := strings.Cut(key, "/")
+		if strings.HasPrefix(period, month(t)+"-") {
+			get(account).Messages += n
+		}
+	}
+	for key, n := range m.tokens {
+		if account, period, _ := strings.Cut(key, "/"); period == month(t) {
+			get(account).Tokens += n
+		}
+	}
+
+	usage := make([]AccountUsage, 0, len(byAccount))
+	for _, u := range byAccount {
+		usage = append(usage, *u)
+	}
+	sort.Slice(usage, func(i, j int) bool { return usage[i].Account < usage[j].Account })
+	return usage, nil
+}
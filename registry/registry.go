@@ -0,0 +1,416 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"temporal-ai-agent/activities"
+	"temporal-ai-agent/activities/llm"
+	"temporal-ai-agent/analysis"
+	"temporal-ai-agent/analytics"
+	"temporal-ai-agent/billing"
+	"temporal-ai-agent/chunking"
+	"temporal-ai-agent/config"
+	"temporal-ai-agent/deadletter"
+	"temporal-ai-agent/digest"
+	"temporal-ai-agent/evals"
+	"temporal-ai-agent/experiments"
+	"temporal-ai-agent/extract"
+	"temporal-ai-agent/fewshot"
+	"temporal-ai-agent/goals"
+	"temporal-ai-agent/grounding"
+	"temporal-ai-agent/k8s"
+	"temporal-ai-agent/knowledge"
+	"temporal-ai-agent/operator"
+	"temporal-ai-agent/outbox"
+	"temporal-ai-agent/personas"
+	"temporal-ai-agent/plugins"
+	"temporal-ai-agent/quota"
+	"temporal-ai-agent/retries"
+	"temporal-ai-agent/search"
+	"temporal-ai-agent/semcache"
+	"temporal-ai-agent/shadow"
+	"temporal-ai-agent/store"
+	"temporal-ai-agent/streaming"
+	"temporal-ai-agent/templates"
+	"temporal-ai-agent/tokens"
+	"temporal-ai-agent/tools"
+	"temporal-ai-agent/websearch"
+	"temporal-ai-agent/workflows"
+
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/worker"
+)
+
+// NewActivities builds the activity dependencies from the configuration
+func NewActivities(cfg config.Config) (*activities.Activities, error) {
+	provider, err := llm.New(cfg.LLMProvider, cfg.LLMOptions())
+	if err != nil {
+		return nil, fmt.Errorf("creating LLM provider: %w", err)
+	}
+
+	exps, err := experiments.Load(cfg.ExperimentsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog, err := personas.Load(cfg.PersonasFile)
+	if err != nil {
+		return nil, err
+	}
+
+	manifests, err := plugins.LoadManifests(cfg.PluginDir)
+	if err != nil {
+		return nil, err
+	}
+	goalCatalog := goals.Builtin()
+	if err := plugins.Grant(goalCatalog, manifests); err != nil {
+		return nil, err
+	}
+
+	flows, err := templates.Load(cfg.TemplatesFile)
+	if err != nil {
+		return nil, err
+	}
+	if err := flows.Validate(goalCatalog, catalog); err != nil {
+		return nil, err
+	}
+
+	policies, err := tools.LoadPolicies(cfg.ToolPolicyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	toolset := tools.Builtin()
+	strategies, err := chunking.ParseStrategies(cfg.ChunkStrategies)
+	if err != nil {
+		return nil, fmt.Errorf("configuring chunking: %w", err)
+	}
+	chunkers, err := chunking.NewSelector(strategies, chunking.Options{Size: cfg.ChunkSize, Overlap: cfg.ChunkOverlap})
+	if err != nil {
+		return nil, fmt.Errorf("configuring chunking: %w", err)
+	}
+	kb, err := knowledge.Load(cfg.KnowledgeFile, chunkers)
+	if err != nil {
+		return nil, err
+	}
+	if kb != nil {
+		toolset.Register(kb.Tool())
+	}
+
+	cluster, err := k8s.New(cfg.K8sCluster, k8s.Options{APIURL: cfg.K8sAPIURL, TokenFile: cfg.K8sTokenFile, CAFile: cfg.K8sCAFile})
+	if err != nil {
+		return nil, fmt.Errorf("creating Kubernetes cluster: %w", err)
+	}
+	access := k8s.Access{Read: cfg.K8sReadNamespaces, Write: cfg.K8sWriteNamespaces, MaxReplicas: cfg.K8sMaxReplicas}
+	if len(access.Read) == 0 {
+		// Reading is harmless enough to allow everywhere unless restricted
+		access.Read = []string{"*"}
+	}
+	for _, tool := range k8s.Tools(cluster, access, cfg.K8sCluster == "mock") {
+		toolset.Register(tool)
+	}
+
+	db, err := analysis.Open(context.Background(), cfg.DataSourceURL)
+	if err != nil {
+		return nil, fmt.Errorf("opening data source: %w", err)
+	}
+	interpreter, err := analysis.NewInterpreter(cfg.CodeInterpreter, cfg.CodeInterpreterURL, cfg.HTTPOptions())
+	if err != nil {
+		return nil, fmt.Errorf("creating code interpreter: %w", err)
+	}
+	artifacts, err := cfg.Artifacts()
+	if err != nil {
+		return nil, fmt.Errorf("creating artifact store: %w", err)
+	}
+	for _, tool := range analysis.Tools(db, interpreter, analysis.Artifacts{Store: artifacts, BaseURL: cfg.ArtifactBaseURL}) {
+		toolset.Register(tool)
+	}
+
+	loaded, err := plugins.Load(context.Background(), manifests)
+	if err != nil {
+		return nil, fmt.Errorf("loading plugins: %w", err)
+	}
+	for _, plugin := range loaded {
+		tool := plugin.Tool()
+		if _, ok := tools.Find(toolset.Definitions(), tool.Name); ok {
+			return nil, fmt.Errorf("plugin %s has the name of a built-in tool", tool.Name)
+		}
+		toolset.Register(tool)
+	}
+
+	searcher, err := websearch.New(cfg.WebSearchProvider, cfg.WebSearchAPIKey, cfg.HTTPOptions())
+	if err != nil {
+		return nil, fmt.Errorf("creating web search provider: %w", err)
+	}
+
+	acts := &activities.Activities{
+		LLM:                   provider,
+		TitleModel:            cfg.LLMTitleModel,
+		MaxContextTokens:      cfg.LLMMaxContextTokens,
+		Experiments:           exps,
+		ExamplesLimit:         cfg.FewShotLimit,
+		ExamplesUseEmbeddings: cfg.FewShotUseEmbeddings,
+		Personas:              catalog,
+		Templates:             flows,
+		Goals:                 goalCatalog,
+		Tools:                 toolset,
+		ToolPolicies:          policies,
+		ToolBreakers: tools.NewBreakers(tools.BreakerSettings{
+			FailureRate: cfg.ToolBreakerFailureRate,
+			Cooldown:    cfg.ToolBreakerCooldown,
+		}),
+		Environment: cfg.Environment,
+		Searcher:    searcher,
+		Operators:   operator.New(cfg.OperatorSlackWebhookURL, cfg.OperatorWebhookURL),
+	}
+	acts.Digests, err = digest.New(cfg.DigestChannel, cfg.DigestSlackWebhookURL, cfg.DigestWebhookURL)
+	if err != nil {
+		return nil, fmt.Errorf("creating digest channel: %w", err)
+	}
+	acts.Channels, err = cfg.Channels()
+	if err != nil {
+		return nil, fmt.Errorf("creating channel adapters: %w", err)
+	}
+	acts.Chunkers = chunkers
+	acts.IngestSource, err = cfg.IngestSource()
+	if err != nil {
+		return nil, fmt.Errorf("creating ingestion source: %w", err)
+	}
+	acts.OCR, err = extract.NewOCR(cfg.OCRBackend, cfg.OCREndpoint, cfg.HTTPOptions())
+	if err != nil {
+		return nil, fmt.Errorf("creating OCR backend: %w", err)
+	}
+	acts.EmbedBatchSize = cfg.EmbedBatchSize
+	acts.EmbedConcurrency = cfg.EmbedConcurrency
+	acts.QuotaPlans, err = quota.Load(cfg.QuotaFile)
+	if err != nil {
+		return nil, err
+	}
+	acts.Billing = billing.New(cfg.BillingExportDir, cfg.BillingAPIURL, cfg.BillingAPIKey)
+	acts.Analytics, err = analytics.New(analytics.Config{
+		Sink:            cfg.AnalyticsSink,
+		File:            cfg.AnalyticsFile,
+		SegmentWriteKey: cfg.AnalyticsSegmentWriteKey,
+		KafkaRESTURL:    cfg.AnalyticsKafkaRESTURL,
+		KafkaTopic:      cfg.AnalyticsKafkaTopic,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if cfg.LLMHedgeProvider != "" {
+		backup, err := llm.New(cfg.LLMHedgeProvider, cfg.LLMOptions())
+		if err != nil {
+			return nil, fmt.Errorf("creating hedge LLM provider: %w", err)
+		}
+		acts.HedgedLLM = &llm.Hedged{
+			Primary:     provider,
+			Backup:      backup,
+			BackupModel: cfg.LLMHedgeModel,
+			Delay:       cfg.LLMHedgeDelay,
+		}
+		acts.HedgeGoals = cfg.LLMHedgeGoals
+	}
+	tenantKeys, err := cfg.Secrets()
+	if err != nil {
+		return nil, fmt.Errorf("creating secrets backend: %w", err)
+	}
+	if tenantKeys != nil {
+		acts.TenantLLM = &llm.Tenants{Secrets: tenantKeys, Options: cfg.LLMOptions(), TTL: cfg.TenantKeyTTL}
+	}
+	acts.RiskPolicy, err = tools.ParseRiskPolicy(cfg.ToolConfirmation)
+	if err != nil {
+		return nil, fmt.Errorf("configuring tool confirmation: %w", err)
+	}
+	acts.ContextPolicy, err = tokens.ParsePolicy(cfg.ContextShares, cfg.ContextPriority)
+	if err != nil {
+		return nil, fmt.Errorf("configuring context budget: %w", err)
+	}
+	acts.EvalModel = cfg.EvalModel
+	acts.GroundingMode, err = grounding.ParseMode(cfg.GroundingMode)
+	if err != nil {
+		return nil, fmt.Errorf("configuring grounding: %w", err)
+	}
+	acts.GroundingModel = cfg.GroundingModel
+	acts.ToolResultLimits = tools.Limits{MaxBytes: cfg.ToolResultMaxBytes, MaxTokens: cfg.ToolResultMaxTokens}
+	acts.ExtractToolResults = cfg.ToolResultExtract
+	acts.SummarizeToolResults = cfg.ToolResultSummarize
+	acts.SemanticCacheGoals = cfg.SemanticCacheGoals
+	acts.SemanticCacheThreshold = cfg.SemanticCacheThreshold
+	acts.SemanticCacheTTL = cfg.SemanticCacheTTL
+	if cfg.RedisURL != "" {
+		acts.Streams, err = streaming.NewBridge(cfg.RedisURL)
+		if err != nil {
+			return nil, fmt.Errorf("connecting to Redis: %w", err)
+		}
+	}
+	if cfg.FewShotFile != "" {
+		acts.Examples = fewshot.NewFileStore(cfg.FewShotFile)
+	}
+	if cfg.DatabaseURL != "" {
+		index, err := search.NewPostgresIndex(context.Background(), cfg.DatabaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("connecting to search database: %w", err)
+		}
+		acts.Search = index
+
+		usage, err := quota.NewPostgresStore(context.Background(), cfg.DatabaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("connecting to quota database: %w", err)
+		}
+		acts.Usage = usage
+
+		conversations, err := store.NewPostgresStore(context.Background(), cfg.DatabaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("connecting to conversation database: %w", err)
+		}
+		acts.Conversations = conversations
+
+		notifications, err := outbox.NewPostgresStore(context.Background(), cfg.DatabaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("connecting to outbox database: %w", err)
+		}
+		acts.Outbox = notifications
+
+		deadLetters, err := deadletter.NewPostgresStore(context.Background(), cfg.DatabaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("connecting to dead-letter database: %w", err)
+		}
+		acts.DeadLetters = deadLetters
+
+		shadows, err := shadow.NewPostgresStore(context.Background(), cfg.DatabaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("connecting to shadow database: %w", err)
+		}
+		acts.Shadows = shadows
+
+		if cfg.EvalEnabled {
+			evaluations, err := evals.NewPostgresStore(context.Background(), cfg.DatabaseURL)
+			if err != nil {
+				return nil, fmt.Errorf("connecting to evaluations database: %w", err)
+			}
+			acts.Evaluations = evaluations
+		}
+
+		ingested, err := knowledge.NewPostgresStore(context.Background(), cfg.DatabaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("connecting to knowledge database: %w", err)
+		}
+		acts.Knowledge = ingested
+
+		if len(cfg.SemanticCacheGoals) > 0 {
+			cache, err := semcache.NewPostgresCache(context.Background(), cfg.DatabaseURL)
+			if err != nil {
+				return nil, fmt.Errorf("connecting to semantic cache database: %w", err)
+			}
+			acts.SemanticCache = cache
+		}
+	} else {
+		if cfg.SQLitePath != "" {
+			conversations, err := store.NewSQLiteStore(context.Background(), cfg.SQLitePath)
+			if err != nil {
+				return nil, fmt.Errorf("opening conversation database: %w", err)
+			}
+			acts.Conversations = conversations
+		}
+		acts.Search = search.NewMemoryIndex()
+		acts.Usage = quota.NewMemoryStore()
+		acts.Outbox = outbox.NewMemoryStore()
+		acts.DeadLetters = deadletter.NewMemoryStore()
+		acts.Shadows = shadow.NewMemoryStore()
+		acts.Knowledge = knowledge.NewMemoryStore()
+		if cfg.EvalEnabled {
+			acts.Evaluations = evals.NewMemoryStore()
+		}
+		if len(cfg.SemanticCacheGoals) > 0 {
+			acts.SemanticCache = semcache.NewMemoryCache()
+		}
+	}
+	if kb != nil {
+		kb.Ingested = acts.Knowledge
+		if embedder, ok := provider.(llm.Embedder); ok {
+			kb.Embedder = llm.BatchedEmbedder{Embedder: embedder, BatchSize: cfg.EmbedBatchSize, Concurrency: cfg.EmbedConcurrency}
+		}
+	}
+	acts.KnowledgeBase = kb
+	return acts, nil
+}
+
+// ConfigureWorkflows applies the configuration the workflows read, such as the retry
+// presets. It must be called before the worker starts.
+func ConfigureWorkflows(cfg config.Config) error {
+	presets, err := retries.Load(cfg.RetryPolicyFile)
+	if err != nil {
+		return err
+	}
+	workflows.SetRetryPolicies(presets)
+	return nil
+}
+
+// Lane is a task queue the worker polls, with the capacity of its worker
+type Lane struct {
+	TaskQueue string
+	Options   worker.Options
+}
+
+// Lanes returns the task queues the worker polls: the standard one, and the premium one
+// when premium plans have their own
+func Lanes(cfg config.Config) []Lane {
+	lanes := []Lane{{TaskQueue: cfg.TaskQueue, Options: worker.Options{
+		MaxConcurrentActivityExecutionSize: cfg.WorkerMaxActivities,
+		TaskQueueActivitiesPerSecond:       cfg.WorkerActivityRate,
+	}}}
+	if cfg.PremiumTaskQueue != "" {
+		lanes = append(lanes, Lane{TaskQueue: cfg.PremiumTaskQueue, Options: worker.Options{
+			MaxConcurrentActivityExecutionSize: cfg.PremiumMaxActivities,
+			TaskQueueActivitiesPerSecond:       cfg.PremiumActivityRate,
+		}})
+	}
+	return lanes
+}
+
+// TaskQueues returns the task queues of the lanes
+func TaskQueues(lanes []Lane) []string {
+	taskQueues := make([]string, len(lanes))
+	for i, lane := range lanes {
+		taskQueues[i] = lane.TaskQueue
+	}
+	return taskQueues
+}
+
+// Start starts a worker on each lane with every workflow and activity registered. If one
+// fails to start, those already started are stopped.
+func Start(c client.Client, lanes []Lane, acts *activities.Activities) ([]worker.Worker, error) {
+	var workers []worker.Worker
+	for _, lane := range lanes {
+		w := worker.New(c, lane.TaskQueue, lane.Options)
+		Register(w, acts)
+		if err := w.Start(); err != nil {
+			Stop(workers)
+			return nil, fmt.Errorf("starting worker on %s: %w", lane.TaskQueue, err)
+		}
+		workers = append(workers, w)
+	}
+	return workers, nil
+}
+
+// Stop stops the workers
+func Stop(workers []worker.Worker) {
+	for _, w := range workers {
+		w.Stop()
+	}
+}
+
+// Register registers every workflow and activity the agent needs on the given worker
+func Register(w worker.Registry, acts *activities.Activities) {
+	w.RegisterWorkflow(workflows.AgentGoalWorkflow)
+	w.RegisterWorkflow(workflows.BackgroundTaskWorkflow)
+	w.RegisterWorkflow(workflows.ScheduledTaskWorkflow)
+	w.RegisterWorkflow(workflows.DailyDigestWorkflow)
+	w.RegisterWorkflow(workflows.CrawlWorkflow)
+	w.RegisterWorkflow(workflows.BucketIngestWorkflow)
+	w.RegisterWorkflow(workflows.DebugRetrievalWorkflow)
+	w.RegisterWorkflow(workflows.DeepResearchWorkflow)
+	w.RegisterWorkflow(workflows.BillingExportWorkflow)
+	w.RegisterWorkflow(workflows.NotificationDeliveryWorkflow)
+	w.RegisterActivity(acts)
+}
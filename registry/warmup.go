@@ -0,0 +1,77 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"temporal-ai-agent/activities"
+	"temporal-ai-agent/activities/llm"
+	"time"
+)
+
+// warmupTarget is a provider and model the worker calls
+type warmupTarget struct {
+	name     string
+	provider llm.Provider
+	model    string
+}
+
+// Warmup sends a tiny completion to every provider and model the worker uses, and embeds a
+// word with the providers that embed, so connections are open and local backends have
+// loaded their models before the first user turn. Providers are warmed up in parallel
+// within timeout; failures are returned but the worker can still start.
+func Warmup(ctx context.Context, acts *activities.Activities, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	targets := []warmupTarget{{name: "primary", provider: acts.LLM}}
+	for _, model := range []string{acts.TitleModel, acts.EvalModel, acts.GroundingModel} {
+		if model != "" && !slices.ContainsFunc(targets, func(t warmupTarget) bool { return t.model == model }) {
+			targets = append(targets, warmupTarget{name: "primary", provider: acts.LLM, model: model})
+		}
+	}
+	embedders := []warmupTarget{{name: "primary", provider: acts.LLM}}
+	if hedged, ok := acts.HedgedLLM.(*llm.Hedged); ok {
+		targets = append(targets, warmupTarget{name: "hedge", provider: hedged.Backup, model: hedged.BackupModel})
+		embedders = append(embedders, warmupTarget{name: "hedge", provider: hedged.Backup})
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(targets)+len(embedders))
+	for i, t := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			started := time.Now()
+			_, err := t.provider.Complete(ctx, llm.Request{
+				Model:    t.model,
+				Messages: []llm.Message{{Role: llm.RoleUser, Content: "Reply with OK."}},
+			})
+			if err != nil {
+				errs[i] = fmt.Errorf("warming up %s provider (model %q): %w", t.name, t.model, err)
+				return
+			}
+			log.Printf("Warmed up %s LLM provider (model %q) in %s", t.name, t.model, time.Since(started).Round(time.Millisecond))
+		}()
+	}
+	for i, t := range embedders {
+		embedder, ok := t.provider.(llm.Embedder)
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := embedder.Embed(ctx, []string{"warmup"}); err != nil {
+				errs[len(targets)+i] = fmt.Errorf("warming up %s embeddings: %w", t.name, err)
+				return
+			}
+			log.Printf("Warmed up %s embeddings", t.name)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,127 @@
+package retries
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+// Categories group activities by how their failures should be retried
+const (
+	// LLM covers model completions, which fail transiently under load
+	LLM = "llm"
+	// ExternalAPI covers calls to third-party services, such as tools and exports
+	ExternalAPI = "external-api"
+	// Internal covers the agent's own stores and bookkeeping
+	Internal = "internal"
+	// Destructive covers irreversible side effects, which are never retried
+	Destructive = "destructive-no-retry"
+)
+
+// Presets maps each category to its retry policy
+type Presets map[string]*temporal.RetryPolicy
+
+// Defaults returns the built-in presets
+func Defaults() Presets {
+	return Presets{
+		LLM: {
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2,
+			MaximumInterval:    30 * time.Second,
+			MaximumAttempts:    5,
+		},
+		ExternalAPI: {
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2,
+			MaximumInterval:    time.Minute,
+			MaximumAttempts:    3,
+		},
+		Internal: {
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2,
+			MaximumInterval:    10 * time.Second,
+			MaximumAttempts:    3,
+		},
+		Destructive: {MaximumAttempts: 1},
+	}
+}
+
+// Policy is a retry policy as written in the presets file. Durations use Go syntax
+// (e.g. "500ms", "1m"); zero fields keep the SDK defaults.
+type Policy struct {
+	InitialInterval        string   `json:"initial_interval,omitempty"`
+	BackoffCoefficient     float64  `json:"backoff_coefficient,omitempty"`
+	MaximumInterval        string   `json:"maximum_interval,omitempty"`
+	MaximumAttempts        int32    `json:"maximum_attempts,omitempty"`
+	NonRetryableErrorTypes []string `json:"non_retryable_error_types,omitempty"`
+}
+
+// Load reads presets from a JSON file mapping categories to policies. Categories missing
+// from the file keep their built-in preset. An empty path returns the built-in presets.
+func Load(path string) (Presets, error) {
+	presets := Defaults()
+	if path == "" {
+		return presets, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading retry policy file: %w", err)
+	}
+	var policies map[string]Policy
+	if err := json.Unmarshal(data, &policies); err != nil {
+		return nil, fmt.Errorf("parsing retry policy file: %w", err)
+	}
+	for category, policy := range policies {
+		if _, ok := presets[category]; !ok {
+			return nil, fmt.Errorf("unknown retry category %q", category)
+		}
+		retryPolicy, err := policy.retryPolicy()
+		if err != nil {
+			return nil, fmt.Errorf("retry category %q: %w", category, err)
+		}
+		presets[category] = retryPolicy
+	}
+	return presets, nil
+}
+
+// Policy returns the retry policy of a category
+func (p Presets) Policy(category string) *temporal.RetryPolicy {
+	if policy, ok := p[category]; ok {
+		return policy
+	}
+	return Defaults()[category]
+}
+
+// retryPolicy converts the policy to the SDK's form
+func (p Policy) retryPolicy() (*temporal.RetryPolicy, error) {
+	policy := &temporal.RetryPolicy{
+		BackoffCoefficient:     p.BackoffCoefficient,
+		MaximumAttempts:        p.MaximumAttempts,
+		NonRetryableErrorTypes: p.NonRetryableErrorTypes,
+	}
+	var err error
+	if policy.InitialInterval, err = parseDuration(p.InitialInterval); err != nil {
+		return nil, fmt.Errorf("initial_interval: %w", err)
+	}
+	if policy.MaximumInterval, err = parseDuration(p.MaximumInterval); err != nil {
+		return nil, fmt.Errorf("maximum_interval: %w", err)
+	}
+	if policy.BackoffCoefficient != 0 && policy.BackoffCoefficient < 1 {
+		return nil, fmt.Errorf("backoff_coefficient must be at least 1")
+	}
+	if policy.MaximumAttempts < 0 {
+		return nil, fmt.Errorf("maximum_attempts must not be negative")
+	}
+	return policy, nil
+}
+
+// parseDuration parses an optional duration
+func parseDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(value)
+}
@@ -0,0 +1,190 @@
+package search
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// Transcript is the searchable text of a conversation
+type Transcript struct {
+	WorkflowID string `json:"workflow_id"`
+	Title      string `json:"title,omitempty"`
+	Content    string `json:"content"`
+	// Account is the quota account that started the conversation, the only one finding it
+	Account string `json:"account,omitempty"`
+}
+
+// Hit is a conversation matching a search query
+type Hit struct {
+	WorkflowID string    `json:"workflow_id"`
+	Title      string    `json:"title,omitempty"`
+	Snippet    string    `json:"snippet"`
+	Rank       float64   `json:"rank"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Index stores transcripts and searches them by content. Searches only see the
+// transcripts of one account; without quotas every transcript belongs to account "".
+type Index interface {
+	Index(ctx context.Context, transcript Transcript) error
+	Search(ctx context.Context, account, query string, limit int) ([]Hit, error)
+}
+
+const schema = `
+CREATE TABLE IF NOT EXISTS conversation_transcripts (
+	workflow_id TEXT PRIMARY KEY,
+	account     TEXT NOT NULL DEFAULT '',
+	title       TEXT NOT NULL DEFAULT '',
+	content     TEXT NOT NULL,
+	updated_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+	document    TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', title || ' ' || content)) STORED
+);
+CREATE INDEX IF NOT EXISTS conversation_transcripts_document_idx
+	ON conversation_transcripts USING GIN (document);
+ALTER TABLE conversation_transcripts ADD COLUMN IF NOT EXISTS account TEXT NOT NULL DEFAULT '';
+`
+
+// PostgresIndex is an Index backed by Postgres full-text search
+type PostgresIndex struct {
+	db *sql.DB
+}
+
+// NewPostgresIndex connects to Postgres and creates the transcripts table if needed
+func NewPostgresIndex(ctx context.Context, databaseURL string) (*PostgresIndex, error) {
+	db, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &PostgresIndex{db: db}, nil
+}
+
+// Close closes the database connection
+func (p *PostgresIndex) Close() error {
+	return p.db.Close()
+}
+
+// Index inserts or replaces the transcript of a conversation
+func (p *PostgresIndex) Index(ctx context.Context, transcript Transcript) error {
+	_, err := p.db.ExecContext(ctx, `
+		INSERT INTO conversation_transcripts (workflow_id, account, title, content, updated_at)
+		VALUES ($1, $2, $3, $4, now())
+		ON CONFLICT (workflow_id) DO UPDATE
+		SET account = EXCLUDED.account, title = EXCLUDED.title, content = EXCLUDED.content,
+			updated_at = EXCLUDED.updated_at`,
+		transcript.WorkflowID, transcript.Account, transcript.Title, transcript.Content)
+	return err
+}
+
+// Search returns the account's best matching conversations for a web-search style query
+func (p *PostgresIndex) Search(ctx context.Context, account, query string, limit int) ([]Hit, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT workflow_id, title,
+			ts_headline('simple', content, q, 'MaxFragments=2, MaxWords=20, MinWords=5'),
+			ts_rank(document, q) AS rank, updated_at
+		FROM conversation_transcripts, websearch_to_tsquery('simple', $1) q
+		WHERE document @@ q AND account = $2
+		ORDER BY rank DESC, updated_at DESC
+		LIMIT $3`, query, account, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hits := []Hit{}
+	for rows.Next() {
+		var hit Hit
+		if err := rows.Scan(&hit.WorkflowID, &hit.Title, &hit.Snippet, &hit.Rank, &hit.UpdatedAt); err != nil {
+			return nil, err
+		}
+		hits = append(hits, hit)
+	}
+	return hits, rows.Err()
+}
+
+// MemoryIndex is an Index kept in memory, for the dev binary. A transcript matches when it
+// holds every word of the query; query syntax is not supported.
+type MemoryIndex struct {
+	mu          sync.Mutex
+	transcripts map[string]memoryTranscript
+}
+
+type memoryTranscript struct {
+	Transcript
+	updatedAt time.Time
+}
+
+// NewMemoryIndex creates an empty MemoryIndex
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{transcripts: map[string]memoryTranscript{}}
+}
+
+// Index inserts or replaces the transcript of a conversation
+func (m *MemoryIndex) Index(ctx context.Context, transcript Transcript) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.transcripts[transcript.WorkflowID] = memoryTranscript{Transcript: transcript, updatedAt: time.Now()}
+	return nil
+}
+
+// Search returns the account's conversations holding every word of the query, those
+// mentioning them most first
+func (m *MemoryIndex) Search(ctx context.Context, account, query string, limit int) ([]Hit, error) {
+	words := strings.Fields(strings.ToLower(query))
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	hits := []Hit{}
+	for _, t := range m.transcripts {
+		if t.Account != account || len(words) == 0 {
+			continue
+		}
+		text := strings.ToLower(t.Title + " " + t.Content)
+		count := 0
+		for _, word := range words {
+			n := strings.Count(text, word)
+			if n == 0 {
+				count = 0
+				break
+			}
+			count += n
+		}
+		if count == 0 {
+			continue
+		}
+		hits = append(hits, Hit{
+			WorkflowID: t.WorkflowID,
+			Title:      t.Title,
+			Snippet:    snippet(t.Content, words[0]),
+			Rank:       float64(count),
+			UpdatedAt:  t.updatedAt,
+		})
+	}
+	slices.SortFunc(hits, func(a, b Hit) int {
+		return cmp.Or(cmp.Compare(b.Rank, a.Rank), b.UpdatedAt.Compare(a.UpdatedAt))
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// snippet returns the line of content mentioning word
+func snippet(content, word string) string {
+	for _, line := range strings.Split(content, "\n") {
+		if strings.Contains(strings.ToLower(line), word) {
+			return line
+		}
+	}
+	return ""
+}
@@ -0,0 +1,102 @@
+// Package secrets keeps credentials outside the workflow state and the configuration, such as
+// the LLM API keys tenants bring for their own conversations.
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned for secrets the store does not have
+var ErrNotFound = errors.New("secret not found")
+
+// Store keeps secrets by name. Names are paths such as tenants/<account>/llm-api-key.
+type Store interface {
+	// Get returns the secret stored under name, or ErrNotFound
+	Get(ctx context.Context, name string) (string, error)
+	// Put stores a secret, replacing the previous value
+	Put(ctx context.Context, name, value string) error
+	// Delete removes a secret; deleting a missing secret is not an error
+	Delete(ctx context.Context, name string) error
+}
+
+// Options locate the secrets backend
+type Options struct {
+	// Address is the URL of the Vault server, e.g. https://vault.example.com:8200
+	Address string
+	// Token authenticates against Vault
+	Token string
+	// Mount is the path of the KV version 2 secrets engine (default: secret), and Prefix is
+	// prepended to every secret name
+	Mount  string
+	Prefix string
+}
+
+// New returns the backend registered under the given name: vault, which uses a HashiCorp
+// Vault KV version 2 engine, or memory, which only works when the API server and the worker
+// run in the same process, as in dev mode
+func New(name string, opts Options) (Store, error) {
+	switch name {
+	case "memory":
+		return NewMemoryStore(), nil
+	case "vault":
+		if opts.Address == "" || opts.Token == "" {
+			return nil, fmt.Errorf("VAULT_ADDR and VAULT_TOKEN are required for the vault secrets backend")
+		}
+		if opts.Mount == "" {
+			opts.Mount = "secret"
+		}
+		return &Vault{
+			Address: strings.TrimSuffix(opts.Address, "/"),
+			Token:   opts.Token,
+			Mount:   strings.Trim(opts.Mount, "/"),
+			Prefix:  strings.Trim(opts.Prefix, "/"),
+			Client:  &http.Client{Timeout: 10 * time.Second},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown secrets backend %q", name)
+	}
+}
+
+// MemoryStore is a Store kept in process memory
+type MemoryStore struct {
+	mu      sync.Mutex
+	secrets map[string]string
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{secrets: map[string]string{}}
+}
+
+// Get returns the secret stored under name
+func (m *MemoryStore) Get(ctx context.Context, name string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, ok := m.secrets[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+// Put stores a secret
+func (m *MemoryStore) Put(ctx context.Context, name, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.secrets[name] = value
+	return nil
+}
+
+// Delete removes a secret
+func (m *MemoryStore) Delete(ctx context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.secrets, name)
+	return nil
+}
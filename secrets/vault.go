@@ -0,0 +1,101 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+)
+
+// valueField is the field of a Vault secret holding the value
+const valueField = "value"
+
+// Vault is a Store backed by a HashiCorp Vault KV version 2 secrets engine. Each secret is
+// a Vault secret with its value in a single field, so rotations keep Vault's versions.
+type Vault struct {
+	Address string
+	Token   string
+	Mount   string
+	Prefix  string
+	Client  *http.Client
+}
+
+// Get returns the latest version of the secret stored under name
+func (v *Vault) Get(ctx context.Context, name string) (string, error) {
+	var body struct {
+		Data struct {
+			Data map[string]string `json:"data"`
+		} `json:"data"`
+	}
+	if err := v.do(ctx, http.MethodGet, v.url("data", name), nil, &body); err != nil {
+		return "", err
+	}
+	// A deleted version reads as a secret without data
+	value, ok := body.Data.Data[valueField]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+// Put writes a new version of the secret
+func (v *Vault) Put(ctx context.Context, name, value string) error {
+	payload := map[string]interface{}{"data": map[string]string{valueField: value}}
+	return v.do(ctx, http.MethodPost, v.url("data", name), payload, nil)
+}
+
+// Delete removes the secret and all its versions
+func (v *Vault) Delete(ctx context.Context, name string) error {
+	err := v.do(ctx, http.MethodDelete, v.url("metadata", name), nil, nil)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// url returns the API URL of a secret under the engine's data or metadata endpoint
+func (v *Vault) url(endpoint, name string) string {
+	return v.Address + "/v1/" + path.Join(v.Mount, endpoint, v.Prefix, name)
+}
+
+// do sends a request to Vault and decodes the response into out, if any
+func (v *Vault) do(ctx context.Context, method, url string, payload, out interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", v.Token)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := v.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode >= 300 {
+		// Vault errors never echo secret values, so they are safe to return
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("vault returned %s: %s", resp.Status, bytes.TrimSpace(message))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
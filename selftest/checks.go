@@ -0,0 +1,167 @@
+package selftest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"temporal-ai-agent/activities/llm"
+	"temporal-ai-agent/analysis"
+	"temporal-ai-agent/config"
+	"temporal-ai-agent/k8s"
+	"temporal-ai-agent/knowledge"
+	"temporal-ai-agent/streaming"
+	"temporal-ai-agent/websearch"
+
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+	sdklog "go.temporal.io/sdk/log"
+)
+
+// API returns the checks of the API server's dependencies
+func API(cfg config.Config) []Check {
+	return append(temporal(cfg), vectorStore(cfg), redis(cfg))
+}
+
+// Worker returns the checks of the worker's dependencies
+func Worker(cfg config.Config) []Check {
+	checks := temporal(cfg)
+	checks = append(checks,
+		provider("llm", cfg.LLMProvider, cfg.LLMOptions(), true),
+		provider("llm hedge", cfg.LLMHedgeProvider, cfg.LLMOptions(), false),
+		vectorStore(cfg),
+		redis(cfg),
+		webSearch(cfg),
+		kubernetes(cfg),
+		dataSource(cfg))
+	return checks
+}
+
+// temporal checks the Temporal endpoints: the client dials them and describes the
+// namespace, which the credentials must be allowed to
+func temporal(cfg config.Config) []Check {
+	checks := []Check{temporalEndpoint("temporal", cfg.ClientOptionsFor(cfg.HostPort))}
+	if cfg.SecondaryHostPort != "" {
+		checks = append(checks, temporalEndpoint("temporal secondary", cfg.ClientOptionsFor(cfg.SecondaryHostPort)))
+	}
+	return checks
+}
+
+func temporalEndpoint(name string, options client.Options) Check {
+	// The SDK's logs would interleave with the report, which has the errors
+	options.Logger = sdklog.NewStructuredLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	return Check{Name: name, Run: func(ctx context.Context) error {
+		c, err := client.DialContext(ctx, options)
+		if err != nil {
+			return fmt.Errorf("dialing %s: %w", options.HostPort, err)
+		}
+		defer c.Close()
+		_, err = c.WorkflowService().DescribeNamespace(ctx, &workflowservice.DescribeNamespaceRequest{Namespace: options.Namespace})
+		if err != nil {
+			return fmt.Errorf("describing namespace %s: %w", options.Namespace, err)
+		}
+		return nil
+	}}
+}
+
+// provider checks an LLM provider's key with a one-word completion. An optional provider
+// may be left unset; the worker does not start without a required one.
+func provider(name, backend string, opts llm.Options, required bool) Check {
+	return Check{Name: name, Run: func(ctx context.Context) error {
+		switch {
+		case backend == "" && !required:
+			return skip("not configured")
+		case backend == "mock":
+			return skip("mock provider")
+		}
+		p, err := llm.New(backend, opts)
+		if err != nil {
+			return err
+		}
+		_, err = p.Complete(ctx, llm.Request{Messages: []llm.Message{{Role: llm.RoleUser, Content: "Reply with OK."}}})
+		return err
+	}}
+}
+
+// vectorStore checks the knowledge store holding the passages and their embeddings
+func vectorStore(cfg config.Config) Check {
+	return Check{Name: "vector store", Run: func(ctx context.Context) error {
+		if cfg.DatabaseURL == "" {
+			return skip("DATABASE_URL is not set, passages are kept in memory")
+		}
+		store, err := knowledge.NewPostgresStore(ctx, cfg.DatabaseURL)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+		_, err = store.Versions(ctx, "", "")
+		return err
+	}}
+}
+
+// redis checks the Redis streams replies are streamed through
+func redis(cfg config.Config) Check {
+	return Check{Name: "redis", Run: func(ctx context.Context) error {
+		if cfg.RedisURL == "" {
+			return skip("REDIS_URL is not set")
+		}
+		bridge, err := streaming.NewBridge(cfg.RedisURL)
+		if err != nil {
+			return err
+		}
+		defer bridge.Close()
+		return bridge.Ping(ctx)
+	}}
+}
+
+// webSearch checks the search provider's key with a one-result search
+func webSearch(cfg config.Config) Check {
+	return Check{Name: "web search", Run: func(ctx context.Context) error {
+		if cfg.WebSearchProvider == "mock" {
+			return skip("mock provider")
+		}
+		searcher, err := websearch.New(cfg.WebSearchProvider, cfg.WebSearchAPIKey, cfg.HTTPOptions())
+		if err != nil {
+			return err
+		}
+		_, err = searcher.Search(ctx, "Temporal", 1)
+		return err
+	}}
+}
+
+// kubernetes checks the cluster's token by listing the pods of the first namespace the
+// agent may read
+func kubernetes(cfg config.Config) Check {
+	return Check{Name: "kubernetes", Run: func(ctx context.Context) error {
+		if cfg.K8sCluster == "mock" {
+			return skip("mock cluster")
+		}
+		cluster, err := k8s.New(cfg.K8sCluster, k8s.Options{APIURL: cfg.K8sAPIURL, TokenFile: cfg.K8sTokenFile, CAFile: cfg.K8sCAFile})
+		if err != nil {
+			return err
+		}
+		namespace := "default"
+		for _, ns := range cfg.K8sReadNamespaces {
+			if ns != "*" {
+				namespace = ns
+				break
+			}
+		}
+		_, err = cluster.Pods(ctx, namespace, "")
+		return err
+	}}
+}
+
+// dataSource checks the database the data analysis tools query
+func dataSource(cfg config.Config) Check {
+	return Check{Name: "data source", Run: func(ctx context.Context) error {
+		if cfg.DataSourceURL == "" {
+			return skip("DATA_SOURCE_URL is not set, the demo database is used")
+		}
+		db, err := analysis.Open(ctx, cfg.DataSourceURL)
+		if err != nil {
+			return err
+		}
+		return db.Close()
+	}}
+}
@@ -0,0 +1,113 @@
+// Package selftest checks that a binary can reach what it depends on before it starts:
+// Temporal, the LLM providers, the vector store and the tools' backends. The binaries run
+// it with --selftest, e.g. from an init container, print the report and exit with a status
+// telling whether every check passed.
+package selftest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// checkTimeout bounds each check
+const checkTimeout = 30 * time.Second
+
+// Check is a named probe of a dependency
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Result is the outcome of a check
+type Result struct {
+	Name string
+	// Err is why the check failed, or a *SkipError when it did not apply
+	Err      error
+	Duration time.Duration
+}
+
+// SkipError is returned by checks of dependencies the configuration does not use
+type SkipError struct {
+	Reason string
+}
+
+func (e *SkipError) Error() string {
+	return e.Reason
+}
+
+// skip returns the error of a check that does not apply
+func skip(format string, args ...any) error {
+	return &SkipError{Reason: fmt.Sprintf(format, args...)}
+}
+
+// Skipped reports whether the check did not apply
+func (r Result) Skipped() bool {
+	var skipped *SkipError
+	return errors.As(r.Err, &skipped)
+}
+
+// Passed reports whether the check passed
+func (r Result) Passed() bool {
+	return r.Err == nil
+}
+
+// Run runs the checks concurrently and returns their results in order
+func Run(ctx context.Context, checks []Check) []Result {
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+			started := time.Now()
+			err := check.Run(ctx)
+			results[i] = Result{Name: check.Name, Err: err, Duration: time.Since(started)}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// Report writes one line per result and a summary, and reports whether no check failed
+func Report(w io.Writer, results []Result) bool {
+	var table strings.Builder
+	tw := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	passed, failed, skipped := 0, 0, 0
+	for _, r := range results {
+		switch {
+		case r.Passed():
+			passed++
+			fmt.Fprintf(tw, "PASS\t%s\t%s\t\n", r.Name, r.Duration.Round(time.Millisecond))
+		case r.Skipped():
+			skipped++
+			fmt.Fprintf(tw, "SKIP\t%s\t\t%v\n", r.Name, r.Err)
+		default:
+			failed++
+			fmt.Fprintf(tw, "FAIL\t%s\t%s\t%v\n", r.Name, r.Duration.Round(time.Millisecond), r.Err)
+		}
+	}
+	tw.Flush()
+	// Passed checks have no message to align, only padding to trim
+	for _, line := range strings.Split(strings.TrimSuffix(table.String(), "\n"), "\n") {
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+	fmt.Fprintf(w, "%d passed, %d failed, %d skipped\n", passed, failed, skipped)
+	return failed == 0
+}
+
+// Main runs the checks, prints the report and exits, with status 1 if any check failed
+func Main(checks []Check) {
+	if !Report(os.Stdout, Run(context.Background(), checks)) {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
@@ -0,0 +1,85 @@
+package semcache
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS semantic_cache (
+	id             BIGSERIAL PRIMARY KEY,
+	goal           TEXT NOT NULL,
+	prompt_version TEXT NOT NULL,
+	persona        TEXT NOT NULL DEFAULT '',
+	question       TEXT NOT NULL,
+	answer         TEXT NOT NULL,
+	embedding      DOUBLE PRECISION[] NOT NULL,
+	created_at     TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS semantic_cache_scope_idx
+	ON semantic_cache (goal, prompt_version, persona, created_at);
+`
+
+// PostgresCache is a Cache backed by Postgres, shared by all workers. Similarity is computed
+// in the worker over the scope's recent answers, which suits FAQ-sized caches.
+type PostgresCache struct {
+	db *sql.DB
+}
+
+// NewPostgresCache connects to Postgres and creates the cache table if needed
+func NewPostgresCache(ctx context.Context, databaseURL string) (*PostgresCache, error) {
+	db, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &PostgresCache{db: db}, nil
+}
+
+// Close closes the database connection
+func (p *PostgresCache) Close() error {
+	return p.db.Close()
+}
+
+// Lookup returns the most similar answer of the scope
+func (p *PostgresCache) Lookup(ctx context.Context, scope Scope, vector []float64, threshold float64, since time.Time) (Match, bool, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT question, answer, embedding, created_at
+		FROM semantic_cache
+		WHERE goal = $1 AND prompt_version = $2 AND persona = $3 AND created_at >= $4`,
+		scope.Goal, scope.PromptVersion, scope.Persona, since)
+	if err != nil {
+		return Match{}, false, err
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		e := Entry{Scope: scope}
+		if err := rows.Scan(&e.Question, &e.Answer, pq.Array(&e.Vector), &e.CreatedAt); err != nil {
+			return Match{}, false, err
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return Match{}, false, err
+	}
+	match, found := best(entries, vector, threshold, since)
+	return match, found, nil
+}
+
+// Add stores an answer
+func (p *PostgresCache) Add(ctx context.Context, entry Entry) error {
+	_, err := p.db.ExecContext(ctx, `
+		INSERT INTO semantic_cache (goal, prompt_version, persona, question, answer, embedding, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		entry.Scope.Goal, entry.Scope.PromptVersion, entry.Scope.Persona,
+		entry.Question, entry.Answer, pq.Array(entry.Vector), entry.CreatedAt)
+	return err
+}
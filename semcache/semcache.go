@@ -0,0 +1,109 @@
+package semcache
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// maxMemoryEntries bounds the answers a MemoryCache keeps per scope; the oldest are dropped
+const maxMemoryEntries = 1000
+
+// Scope separates cached answers that are not interchangeable: an answer only serves
+// questions of the same goal, under the same prompt version and persona
+type Scope struct {
+	Goal          string `json:"goal"`
+	PromptVersion string `json:"prompt_version"`
+	Persona       string `json:"persona,omitempty"`
+}
+
+// Entry is a validated answer to a question, with the question's embedding
+type Entry struct {
+	Scope     Scope     `json:"scope"`
+	Question  string    `json:"question"`
+	Answer    string    `json:"answer"`
+	Vector    []float64 `json:"vector"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Match is a cached answer found for a question
+type Match struct {
+	Entry
+	// Similarity is the cosine similarity of the two questions' embeddings
+	Similarity float64 `json:"similarity"`
+}
+
+// Cache stores answers by question embedding
+type Cache interface {
+	// Lookup returns the most similar answer of the scope created after since, if its
+	// similarity reaches threshold
+	Lookup(ctx context.Context, scope Scope, vector []float64, threshold float64, since time.Time) (Match, bool, error)
+	// Add stores an answer
+	Add(ctx context.Context, entry Entry) error
+}
+
+// best returns the entry most similar to vector among those created after since
+func best(entries []Entry, vector []float64, threshold float64, since time.Time) (Match, bool) {
+	var match Match
+	found := false
+	for _, e := range entries {
+		if e.CreatedAt.Before(since) {
+			continue
+		}
+		if similarity := Cosine(vector, e.Vector); similarity >= threshold && (!found || similarity > match.Similarity) {
+			match = Match{Entry: e, Similarity: similarity}
+			found = true
+		}
+	}
+	return match, found
+}
+
+// Cosine is the cosine similarity of two vectors
+func Cosine(a, b []float64) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		if i >= len(b) {
+			break
+		}
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// MemoryCache is a Cache kept in process memory. Each worker has its own, so answers are
+// only reused by conversations running on the worker that cached them.
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[Scope][]Entry
+}
+
+// NewMemoryCache creates an empty MemoryCache
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: map[Scope][]Entry{}}
+}
+
+// Lookup returns the most similar answer of the scope
+func (m *MemoryCache) Lookup(ctx context.Context, scope Scope, vector []float64, threshold float64, since time.Time) (Match, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	match, found := best(m.entries[scope], vector, threshold, since)
+	return match, found, nil
+}
+
+// Add stores an answer, dropping the oldest of its scope beyond maxMemoryEntries
+func (m *MemoryCache) Add(ctx context.Context, entry Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entries := append(m.entries[entry.Scope], entry)
+	if len(entries) > maxMemoryEntries {
+		entries = entries[len(entries)-maxMemoryEntries:]
+	}
+	m.entries[entry.Scope] = entries
+	return nil
+}
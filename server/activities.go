@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/temporal"
+)
+
+// CompleteActivityRequest represents the request body for the /activities/complete endpoint
+type CompleteActivityRequest struct {
+	// TaskToken is the completion token the tool handed to the system finishing its call
+	TaskToken string `json:"task_token"`
+	// Result is the result of the tool call, read by the agent
+	Result string `json:"result"`
+	// Error fails the tool call instead; the agent sees the message
+	Error string `json:"error,omitempty"`
+}
+
+// handleCompleteActivity handles POST /activities/complete requests, which finish the calls
+// of async tools. The task token identifies the call; the route takes the admin API key as
+// well, since tokens do not tell which account's conversation made the call.
+func (s *Server) handleCompleteActivity(w http.ResponseWriter, r *http.Request) {
+	var req CompleteActivityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	token, err := base64.URLEncoding.DecodeString(req.TaskToken)
+	if err != nil || len(token) == 0 {
+		http.Error(w, "Invalid task token", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	if req.Error != "" {
+		err = s.temporalClient().CompleteActivity(ctx, token, nil,
+			temporal.NewNonRetryableApplicationError(req.Error, "ToolFailed", nil))
+	} else {
+		err = s.temporalClient().CompleteActivity(ctx, token, req.Result, nil)
+	}
+	var notFound *serviceerror.NotFound
+	if errors.As(err, &notFound) {
+		// The call was already completed, or timed out and the agent moved on
+		http.Error(w, "Tool call not found or already completed", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error completing activity: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(SignalResponse{Success: false, Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SignalResponse{Success: true})
+}
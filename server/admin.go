@@ -0,0 +1,37 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// requireAdmin rejects requests that do not carry the admin API key as a bearer token.
+// Admin endpoints are disabled entirely when no key is configured.
+func (s *Server) requireAdmin(next http.Handler) http.Handler {
+	return requireBearer(s.adminAPIKey, "Admin API is disabled", next)
+}
+
+// requireOperator rejects requests that do not carry the operator API key as a bearer token.
+// Operator endpoints are disabled entirely when no key is configured.
+func (s *Server) requireOperator(next http.Handler) http.Handler {
+	return requireBearer(s.operatorAPIKey, "Operator API is disabled", next)
+}
+
+// requireBearer wraps next so it only runs for requests authenticated with key
+func requireBearer(key, disabledMessage string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key == "" {
+			http.Error(w, disabledMessage, http.StatusForbidden)
+			return
+		}
+
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(key)) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"temporal-ai-agent/workflows"
+
+	"github.com/gorilla/mux"
+)
+
+// ReactionRequest represents the request body for the /update/reaction endpoint
+type ReactionRequest struct {
+	WorkflowID   string `json:"workflow_id"`
+	RunID        string `json:"run_id,omitempty"`
+	MessageIndex int    `json:"message_index"`
+	// Reaction is a short value such as an emoji
+	Reaction string `json:"reaction"`
+}
+
+// AnnotationRequest represents the request body for the operator annotation endpoint
+type AnnotationRequest struct {
+	RunID        string `json:"run_id,omitempty"`
+	Operator     string `json:"operator"`
+	MessageIndex int    `json:"message_index"`
+	// Kind is label or note
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+// AnnotationResponse represents the response from the endpoints adding an annotation
+type AnnotationResponse struct {
+	Success    bool                  `json:"success"`
+	Annotation *workflows.Annotation `json:"annotation,omitempty"`
+	Error      string                `json:"error,omitempty"`
+}
+
+// AnnotationsResponse represents the response from the annotation list endpoint
+type AnnotationsResponse struct {
+	Annotations []workflows.Annotation `json:"annotations"`
+	Error       string                 `json:"error,omitempty"`
+}
+
+// handleReaction handles POST /update/reaction requests
+func (s *Server) handleReaction(w http.ResponseWriter, r *http.Request) {
+	var req ReactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.WorkflowID == "" {
+		http.Error(w, "WorkflowID is required", http.StatusBadRequest)
+		return
+	}
+	if !s.requireOwner(w, r, req.WorkflowID) {
+		return
+	}
+
+	s.annotate(r.Context(), w, req.WorkflowID, req.RunID, workflows.Annotation{
+		MessageIndex: req.MessageIndex,
+		Kind:         workflows.AnnotationReaction,
+		Value:        req.Reaction,
+	})
+}
+
+// handleAddAnnotation handles POST /operator/conversations/{id}/annotations requests
+func (s *Server) handleAddAnnotation(w http.ResponseWriter, r *http.Request) {
+	var req AnnotationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.Operator == "" {
+		http.Error(w, "Operator is required", http.StatusBadRequest)
+		return
+	}
+	// Reactions are the user's
+	if req.Kind != workflows.AnnotationLabel && req.Kind != workflows.AnnotationNote {
+		http.Error(w, "kind must be label or note", http.StatusBadRequest)
+		return
+	}
+
+	s.annotate(r.Context(), w, mux.Vars(r)["id"], req.RunID, workflows.Annotation{
+		MessageIndex: req.MessageIndex,
+		Kind:         req.Kind,
+		Value:        req.Value,
+		Author:       req.Operator,
+	})
+}
+
+// annotate sends the annotate_message update and writes the stored annotation
+func (s *Server) annotate(ctx context.Context, w http.ResponseWriter, workflowID, runID string, annotation workflows.Annotation) {
+	var stored workflows.Annotation
+	if err := s.update(ctx, workflowID, runID, workflows.UpdateAnnotateMessage, &stored, annotation); err != nil {
+		log.Printf("Error annotating message: %v", err)
+		writeUpdateError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(AnnotationResponse{Success: true, Annotation: &stored})
+}
+
+// handleListAnnotations handles GET /operator/conversations/{id}/annotations requests
+func (s *Server) handleListAnnotations(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	value, err := s.temporalClient().QueryWorkflow(ctx, mux.Vars(r)["id"], r.URL.Query().Get("run_id"), workflows.QueryAnnotations)
+	var annotations []workflows.Annotation
+	if err == nil {
+		err = value.Get(&annotations)
+	}
+	if err != nil {
+		log.Printf("Error querying annotations: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(AnnotationsResponse{Error: err.Error()})
+		return
+	}
+
+	if annotations == nil {
+		annotations = []workflows.Annotation{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(AnnotationsResponse{Annotations: annotations})
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"temporal-ai-agent/analysis"
+	"temporal-ai-agent/blobstore"
+
+	"github.com/gorilla/mux"
+)
+
+// handleGetArtifact handles GET /artifacts/{key} requests
+func (s *Server) handleGetArtifact(w http.ResponseWriter, r *http.Request) {
+	if s.artifacts == nil {
+		http.Error(w, "artifacts are not enabled", http.StatusNotFound)
+		return
+	}
+	key := mux.Vars(r)["key"]
+	if !analysis.ArtifactKey.MatchString(key) {
+		http.Error(w, "artifact not found", http.StatusNotFound)
+		return
+	}
+
+	account, ok := s.callerAccount(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	data, err := s.artifacts.Get(r.Context(), analysis.ArtifactPath(account, key))
+	if errors.Is(err, blobstore.ErrNotFound) {
+		http.Error(w, "artifact not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error reading artifact: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Keys are content hashes, so an artifact never changes. Shared caches must not serve an
+	// account's artifacts to others.
+	cacheControl := "public, max-age=31536000, immutable"
+	if account != "" {
+		cacheControl = "private, max-age=31536000, immutable"
+	}
+	w.Header().Set("Content-Type", analysis.ContentType(key))
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write(data)
+}
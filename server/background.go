@@ -0,0 +1,168 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"temporal-ai-agent/workflows"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.temporal.io/sdk/client"
+)
+
+// BackgroundTaskRequest represents the request body for the /update/background-task endpoint
+type BackgroundTaskRequest struct {
+	WorkflowID  string `json:"workflow_id"`
+	RunID       string `json:"run_id,omitempty"`
+	Description string `json:"description"`
+	// Interval is a Go duration such as "24h" or "30m" (default: 24h)
+	Interval string `json:"interval,omitempty"`
+	MaxRuns  int    `json:"max_runs,omitempty"`
+}
+
+// BackgroundTaskResponse represents the response from the /update/background-task endpoint
+type BackgroundTaskResponse struct {
+	Success bool                         `json:"success"`
+	Task    *workflows.BackgroundTaskRef `json:"task,omitempty"`
+	Error   string                       `json:"error,omitempty"`
+}
+
+// BackgroundTaskInfo combines a conversation's task reference with the task's own status
+type BackgroundTaskInfo struct {
+	workflows.BackgroundTaskRef
+	Status      *workflows.TaskStatus `json:"status,omitempty"`
+	StatusError string                `json:"status_error,omitempty"`
+}
+
+// BackgroundTasksResponse represents the response from the /workflow/{id}/background-tasks endpoint
+type BackgroundTasksResponse struct {
+	Tasks []BackgroundTaskInfo `json:"tasks"`
+	Error string               `json:"error,omitempty"`
+}
+
+// handleStartBackgroundTask handles POST /update/background-task requests
+func (s *Server) handleStartBackgroundTask(w http.ResponseWriter, r *http.Request) {
+	var req BackgroundTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.WorkflowID == "" {
+		http.Error(w, "WorkflowID is required", http.StatusBadRequest)
+		return
+	}
+	if !s.requireOwner(w, r, req.WorkflowID) {
+		return
+	}
+
+	task := workflows.BackgroundTask{
+		Description: req.Description,
+		MaxRuns:     req.MaxRuns,
+	}
+	if req.Interval != "" {
+		interval, err := time.ParseDuration(req.Interval)
+		if err != nil {
+			http.Error(w, "Invalid interval", http.StatusBadRequest)
+			return
+		}
+		task.Interval = interval
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), updateTimeout)
+	defer cancel()
+
+	handle, err := s.temporalClient().UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+		WorkflowID:   req.WorkflowID,
+		RunID:        req.RunID,
+		UpdateName:   workflows.UpdateStartBackgroundTask,
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+		Args:         []interface{}{task},
+	})
+
+	var ref workflows.BackgroundTaskRef
+	if err == nil {
+		err = handle.Get(ctx, &ref)
+	}
+	if err != nil {
+		log.Printf("Error starting background task: %v", err)
+		writeUpdateError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(BackgroundTaskResponse{Success: true, Task: &ref})
+}
+
+// handleListBackgroundTasks handles GET /workflow/{id}/background-tasks requests
+func (s *Server) handleListBackgroundTasks(w http.ResponseWriter, r *http.Request) {
+	workflowID := mux.Vars(r)["id"]
+	if !s.requireOwner(w, r, workflowID) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	value, err := s.temporalClient().QueryWorkflow(ctx, workflowID, r.URL.Query().Get("run_id"), workflows.QueryBackgroundTasks)
+	var refs []workflows.BackgroundTaskRef
+	if err == nil {
+		err = value.Get(&refs)
+	}
+	if err != nil {
+		log.Printf("Error querying background tasks: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(BackgroundTasksResponse{Error: err.Error()})
+		return
+	}
+
+	response := BackgroundTasksResponse{Tasks: make([]BackgroundTaskInfo, 0, len(refs))}
+	for _, ref := range refs {
+		info := BackgroundTaskInfo{BackgroundTaskRef: ref}
+		value, err := s.temporalClient().QueryWorkflow(ctx, ref.WorkflowID, ref.RunID, workflows.QueryTaskStatus)
+		var status workflows.TaskStatus
+		if err == nil {
+			err = value.Get(&status)
+		}
+		if err != nil {
+			info.StatusError = err.Error()
+		} else {
+			info.Status = &status
+		}
+		response.Tasks = append(response.Tasks, info)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleCancelBackgroundTask handles POST /background-tasks/{id}/cancel requests
+func (s *Server) handleCancelBackgroundTask(w http.ResponseWriter, r *http.Request) {
+	taskID := mux.Vars(r)["id"]
+	if !s.requireOwner(w, r, taskParentID(taskID)) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	err := s.temporalClient().SignalWorkflow(ctx, taskID, "", workflows.SignalCancelTask, nil)
+	if err != nil {
+		log.Printf("Error cancelling background task: %v", err)
+		s.metrics.signalFailed(workflows.SignalCancelTask)
+		response := SignalResponse{
+			Success: false,
+			Error:   err.Error(),
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	response := SignalResponse{Success: true}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
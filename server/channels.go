@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"temporal-ai-agent/channels"
+	"temporal-ai-agent/workflows"
+	"time"
+
+	"github.com/gorilla/mux"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+)
+
+// ChannelMessageRequest represents the request body of the /channels/{channel}/messages
+// endpoint: a message a channel bridge, such as a Slack bot, received from a user
+type ChannelMessageRequest struct {
+	// UserID is the user in the application's terms, resolved by the bridge from the
+	// channel's identity (e.g. the Slack user's email)
+	UserID string `json:"user_id"`
+	// Address is where the channel delivers the replies, e.g. a Slack channel and thread
+	// as "C0123456789/1700000000.000100"
+	Address string `json:"address"`
+	Message string `json:"message"`
+	// MessageID lets the workflow drop messages the bridge delivers twice
+	MessageID string `json:"message_id,omitempty"`
+}
+
+// handleChannelMessage handles POST /channels/{channel}/messages requests. The message goes
+// to the user's conversation, started if it is not running, which is the one their sticky
+// web sessions use: a user continues on the channel with the full history, and the replies
+// are delivered to the channel's address by its adapter in the worker. Messages must be
+// signed with the secret of the channel's bridge, since they choose where replies go.
+func (s *Server) handleChannelMessage(w http.ResponseWriter, r *http.Request) {
+	channel := mux.Vars(r)["channel"]
+	if !slices.Contains(s.channels, channel) {
+		http.Error(w, fmt.Sprintf("channel %s is not configured", channel), http.StatusNotFound)
+		return
+	}
+	secret, ok := s.channelSecrets[channel]
+	if !ok {
+		http.Error(w, fmt.Sprintf("channel %s has no secret configured", channel), http.StatusForbidden)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Unable to read the request", http.StatusBadRequest)
+		return
+	}
+	err = channels.Verify(secret, r.Header.Get(channels.TimestampHeader), r.Header.Get(channels.SignatureHeader), body, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var req ChannelMessageRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.UserID == "" || req.Address == "" {
+		http.Error(w, "user_id and address are required", http.StatusBadRequest)
+		return
+	}
+	if req.Message == "" {
+		http.Error(w, "Message is required", http.StatusBadRequest)
+		return
+	}
+
+	start := ChatRequest{UserID: req.UserID, Channel: channel}
+	options, err := s.startOptions(r, start)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// Conversations are keyed by user whether or not sticky sessions are enabled, so every
+	// channel of the user reaches the same one
+	options.ID = userWorkflowID(quotaAccount(r), req.UserID)
+	options.WorkflowIDConflictPolicy = enumspb.WORKFLOW_ID_CONFLICT_POLICY_USE_EXISTING
+	if s.overUserLimit(w, r, req.UserID, options.ID) {
+		return
+	}
+
+	input := s.agentInput(r, start, options.ID)
+	prompt := workflows.UserPrompt{
+		Message:   req.Message,
+		MessageID: req.MessageID,
+		From:      &workflows.ChannelLink{Channel: channel, Address: req.Address, Verified: true},
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	we, err := s.temporalClient().SignalWithStartWorkflow(ctx, options.ID, workflows.SignalUserPrompt, prompt,
+		options, workflows.AgentGoalWorkflow, input)
+	if err != nil {
+		log.Printf("Error sending %s message: %v", channel, err)
+		s.metrics.startFailed(workflowTypeName)
+		status := http.StatusInternalServerError
+		var alreadyStarted *serviceerror.WorkflowExecutionAlreadyStarted
+		if errors.As(err, &alreadyStarted) {
+			status = http.StatusConflict
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(ChatResponse{WorkflowID: options.ID, Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ChatResponse{WorkflowID: we.GetID(), RunID: we.GetRunID()})
+}
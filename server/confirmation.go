@@ -0,0 +1,44 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"temporal-ai-agent/workflows"
+
+	"github.com/gorilla/mux"
+)
+
+// PendingConfirmationResponse represents the response from the /workflow/{id}/pending-confirmation endpoint
+type PendingConfirmationResponse struct {
+	// Pending is null when no tool call awaits confirmation
+	Pending *workflows.PendingConfirmation `json:"pending"`
+	Error   string                         `json:"error,omitempty"`
+}
+
+// handlePendingConfirmation handles GET /workflow/{id}/pending-confirmation requests
+func (s *Server) handlePendingConfirmation(w http.ResponseWriter, r *http.Request) {
+	workflowID := mux.Vars(r)["id"]
+	if !s.requireOwner(w, r, workflowID) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	value, err := s.temporalClient().QueryWorkflow(ctx, workflowID, r.URL.Query().Get("run_id"), workflows.QueryPendingConfirmation)
+	var pending *workflows.PendingConfirmation
+	if err == nil {
+		err = value.Get(&pending)
+	}
+	if err != nil {
+		log.Printf("Error querying pending confirmation: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(PendingConfirmationResponse{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(PendingConfirmationResponse{Pending: pending})
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"temporal-ai-agent/store"
+
+	"github.com/gorilla/mux"
+)
+
+// ConversationResponse represents the response from the /conversations/{id} endpoint
+type ConversationResponse struct {
+	*store.Record
+	Error string `json:"error,omitempty"`
+}
+
+// handleGetConversation handles GET /conversations/{id} requests, serving a conversation
+// from the store so it stays available after Temporal's history retention
+func (s *Server) handleGetConversation(w http.ResponseWriter, r *http.Request) {
+	if s.conversations == nil {
+		http.Error(w, "Conversation storage is not configured", http.StatusNotFound)
+		return
+	}
+	if !s.requireOwner(w, r, mux.Vars(r)["id"]) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	record, err := s.conversations.Get(ctx, mux.Vars(r)["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error getting conversation: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ConversationResponse{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ConversationResponse{Record: &record})
+}
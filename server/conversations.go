@@ -0,0 +1,211 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"temporal-ai-agent/store"
+	"temporal-ai-agent/workflows"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.temporal.io/api/serviceerror"
+	workflowpb "go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/converter"
+)
+
+// defaultPageSize is used when the client does not request a page size, up to maxPageSize
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// ConversationSummary describes a single conversation in the list-conversations response
+type ConversationSummary struct {
+	WorkflowID string `json:"workflow_id"`
+	RunID      string `json:"run_id"`
+	Status     string `json:"status"`
+	Title      string `json:"title,omitempty"`
+	// PromptVersion is the prompt template version the conversation is pinned to
+	PromptVersion string     `json:"prompt_version,omitempty"`
+	Goal          string     `json:"goal,omitempty"`
+	ClientApp     string     `json:"client_app,omitempty"`
+	Channel       string     `json:"channel,omitempty"`
+	Locale        string     `json:"locale,omitempty"`
+	StartTime     time.Time  `json:"start_time"`
+	CloseTime     *time.Time `json:"close_time,omitempty"`
+	// Pinned and Archived are the settings of the user the list was requested for
+	Pinned   bool `json:"pinned,omitempty"`
+	Archived bool `json:"archived,omitempty"`
+}
+
+// ListConversationsResponse represents the response from the /conversations endpoint
+type ListConversationsResponse struct {
+	Conversations []ConversationSummary `json:"conversations"`
+	NextPageToken string                `json:"next_page_token,omitempty"`
+	Error         string                `json:"error,omitempty"`
+}
+
+// DescribeConversationResponse represents the response from the /workflow/{id} endpoint
+type DescribeConversationResponse struct {
+	*ConversationSummary
+	Error string `json:"error,omitempty"`
+}
+
+// handleListConversations handles GET /conversations requests
+func (s *Server) handleListConversations(w http.ResponseWriter, r *http.Request) {
+	pageSize := defaultPageSize
+	if value := r.URL.Query().Get("page_size"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > maxPageSize {
+			http.Error(w, fmt.Sprintf("page_size must be between 1 and %d", maxPageSize), http.StatusBadRequest)
+			return
+		}
+		pageSize = parsed
+	}
+
+	pageToken, err := base64.URLEncoding.DecodeString(r.URL.Query().Get("next_page_token"))
+	if err != nil {
+		http.Error(w, "Invalid next_page_token", http.StatusBadRequest)
+		return
+	}
+
+	filter, err := parseListFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if (filter.pinned || filter.archived) && s.conversations == nil {
+		http.Error(w, "Conversation storage is not configured", http.StatusBadRequest)
+		return
+	}
+
+	account, ok := s.callerAccount(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	// A conversation that continued as new is listed once, by its latest run
+	query := fmt.Sprintf("WorkflowType = '%s' AND ExecutionStatus != 'ContinuedAsNew'", workflowTypeName)
+	if account != "" {
+		// Account IDs are hex digests, safe to quote as is
+		query += fmt.Sprintf(" AND %s = '%s'", workflows.AccountKey.GetName(), account)
+	}
+	if filter.userID != "" {
+		query += fmt.Sprintf(" AND %s = '%s'", workflows.UserIDKey.GetName(), escapeQueryValue(filter.userID))
+	}
+	var preferences map[string]store.Preferences
+	if filter.userID != "" && s.conversations != nil {
+		preferences, err = s.userPreferences(ctx, filter.userID)
+		if err != nil {
+			log.Printf("Error getting conversation preferences: %v", err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ListConversationsResponse{Error: err.Error()})
+			return
+		}
+		clause, ok := filter.clause(preferences)
+		if !ok {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(ListConversationsResponse{Conversations: []ConversationSummary{}})
+			return
+		}
+		query += clause
+	}
+
+	resp, err := s.temporalClient().ListWorkflow(ctx, &workflowservice.ListWorkflowExecutionsRequest{
+		PageSize:      int32(pageSize),
+		NextPageToken: pageToken,
+		Query:         query,
+	})
+	if err != nil {
+		log.Printf("Error listing conversations: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ListConversationsResponse{Error: err.Error()})
+		return
+	}
+
+	response := ListConversationsResponse{
+		Conversations: make([]ConversationSummary, 0, len(resp.Executions)),
+		NextPageToken: base64.URLEncoding.EncodeToString(resp.NextPageToken),
+	}
+	for _, execution := range resp.Executions {
+		summary := conversationSummary(execution)
+		applyPreferences(&summary, preferences)
+		response.Conversations = append(response.Conversations, summary)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleDescribeConversation handles GET /workflow/{id} requests, describing a conversation
+// with the metadata kept in its memo
+func (s *Server) handleDescribeConversation(w http.ResponseWriter, r *http.Request) {
+	if !s.requireOwner(w, r, mux.Vars(r)["id"]) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	resp, err := s.temporalClient().DescribeWorkflowExecution(ctx, mux.Vars(r)["id"], r.URL.Query().Get("run_id"))
+	var notFound *serviceerror.NotFound
+	if errors.As(err, &notFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error describing conversation: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DescribeConversationResponse{Error: err.Error()})
+		return
+	}
+
+	summary := conversationSummary(resp.GetWorkflowExecutionInfo())
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(DescribeConversationResponse{ConversationSummary: &summary})
+}
+
+// conversationSummary converts a visibility record into a ConversationSummary
+func conversationSummary(execution *workflowpb.WorkflowExecutionInfo) ConversationSummary {
+	summary := ConversationSummary{
+		WorkflowID: execution.GetExecution().GetWorkflowId(),
+		RunID:      execution.GetExecution().GetRunId(),
+		Status:     execution.GetStatus().String(),
+		StartTime:  execution.GetStartTime().AsTime(),
+	}
+	if execution.GetCloseTime() != nil {
+		closeTime := execution.GetCloseTime().AsTime()
+		summary.CloseTime = &closeTime
+	}
+	decodeMemo(execution, workflows.MemoTitle, &summary.Title)
+	decodeMemo(execution, workflows.MemoPromptVersion, &summary.PromptVersion)
+	decodeMemo(execution, workflows.MemoGoal, &summary.Goal)
+	decodeMemo(execution, workflows.MemoClientApp, &summary.ClientApp)
+	decodeMemo(execution, workflows.MemoChannel, &summary.Channel)
+	decodeMemo(execution, workflows.MemoLocale, &summary.Locale)
+	return summary
+}
+
+// decodeMemo decodes a memo field into value, leaving it untouched if the field is missing
+func decodeMemo(execution *workflowpb.WorkflowExecutionInfo, key string, value interface{}) {
+	payload, ok := execution.GetMemo().GetFields()[key]
+	if !ok {
+		return
+	}
+	if err := converter.GetDefaultDataConverter().FromPayload(payload, value); err != nil {
+		log.Printf("Error decoding %s memo for %s: %v", key, execution.GetExecution().GetWorkflowId(), err)
+	}
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"temporal-ai-agent/deadletter"
+	"temporal-ai-agent/workflows"
+
+	"github.com/gorilla/mux"
+)
+
+// DeadLettersResponse represents the response from the GET /admin/dead-letters endpoint
+type DeadLettersResponse struct {
+	DeadLetters []deadletter.Entry `json:"dead_letters"`
+	Error       string             `json:"error,omitempty"`
+}
+
+// RetryDeadLetterResponse represents the response from the POST /admin/dead-letters/{id}/retry endpoint
+type RetryDeadLetterResponse struct {
+	// Reply is the agent's new reply to the turn
+	Reply string `json:"reply"`
+	// DeadLetter is the entry after the retry: resolved, or failed again with the new error
+	DeadLetter deadletter.Entry `json:"dead_letter"`
+}
+
+// handleListDeadLetters handles GET /admin/dead-letters requests
+func (s *Server) handleListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	if s.deadLetters == nil {
+		http.Error(w, "Dead-letter store is not configured", http.StatusNotFound)
+		return
+	}
+
+	entries, err := s.deadLetters.List(r.Context(), r.URL.Query().Get("include_resolved") == "true")
+	if err != nil {
+		log.Printf("Error listing dead letters: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DeadLettersResponse{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(DeadLettersResponse{DeadLetters: entries})
+}
+
+// handleGetDeadLetter handles GET /admin/dead-letters/{id} requests
+func (s *Server) handleGetDeadLetter(w http.ResponseWriter, r *http.Request) {
+	entry, ok := s.findDeadLetter(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entry)
+}
+
+// handleRetryDeadLetter handles POST /admin/dead-letters/{id}/retry requests. The turn is
+// reprocessed by the conversation's current run, with the code and configuration of the
+// workers running now, so a retry after a fix answers the turn.
+func (s *Server) handleRetryDeadLetter(w http.ResponseWriter, r *http.Request) {
+	entry, ok := s.findDeadLetter(w, r)
+	if !ok {
+		return
+	}
+	if entry.ResolvedAt != nil {
+		http.Error(w, "Turn was already retried successfully", http.StatusConflict)
+		return
+	}
+
+	var reply string
+	err := s.update(r.Context(), entry.WorkflowID, "", workflows.UpdateReprocessTurn, &reply,
+		workflows.ReprocessTurn{MessageIndex: &entry.MessageIndex})
+	if err != nil {
+		log.Printf("Error retrying dead letter %s: %v", entry.ID, err)
+		writeUpdateError(w, err)
+		return
+	}
+
+	id := entry.ID
+	entry, err = s.deadLetters.Get(r.Context(), id)
+	if err != nil {
+		log.Printf("Error reading dead letter %s: %v", id, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(RetryDeadLetterResponse{Reply: reply, DeadLetter: entry})
+}
+
+// findDeadLetter returns the entry named by the request's {id}, or writes the error
+func (s *Server) findDeadLetter(w http.ResponseWriter, r *http.Request) (deadletter.Entry, bool) {
+	if s.deadLetters == nil {
+		http.Error(w, "Dead-letter store is not configured", http.StatusNotFound)
+		return deadletter.Entry{}, false
+	}
+
+	entry, err := s.deadLetters.Get(r.Context(), mux.Vars(r)["id"])
+	if errors.Is(err, deadletter.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return deadletter.Entry{}, false
+	}
+	if err != nil {
+		log.Printf("Error reading dead letter: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return deadletter.Entry{}, false
+	}
+	return entry, true
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"temporal-ai-agent/workflows"
+
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+)
+
+// EditMessageRequest represents the request body for the /update/edit-message endpoint
+type EditMessageRequest struct {
+	WorkflowID     string `json:"workflow_id"`
+	RunID          string `json:"run_id,omitempty"`
+	MessageIndex   int    `json:"message_index"`
+	Message        string `json:"message"`
+	PreserveBranch bool   `json:"preserve_branch"`
+}
+
+// ReprocessTurnRequest represents the request body for the /update/reprocess-turn endpoint
+type ReprocessTurnRequest struct {
+	WorkflowID string `json:"workflow_id"`
+	RunID      string `json:"run_id,omitempty"`
+}
+
+// UpdateResponse represents the response from update endpoints
+type UpdateResponse struct {
+	Success bool   `json:"success"`
+	Result  string `json:"result,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// handleEditMessage handles POST /update/edit-message requests
+func (s *Server) handleEditMessage(w http.ResponseWriter, r *http.Request) {
+	var req EditMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.WorkflowID == "" {
+		http.Error(w, "WorkflowID is required", http.StatusBadRequest)
+		return
+	}
+	if !s.requireOwner(w, r, req.WorkflowID) {
+		return
+	}
+
+	if req.Message == "" {
+		http.Error(w, "Message is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), updateTimeout)
+	defer cancel()
+
+	handle, err := s.temporalClient().UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+		WorkflowID:   req.WorkflowID,
+		RunID:        req.RunID,
+		UpdateName:   workflows.UpdateEditMessage,
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+		Args: []interface{}{workflows.EditMessageRequest{
+			MessageIndex:   req.MessageIndex,
+			Content:        req.Message,
+			PreserveBranch: req.PreserveBranch,
+		}},
+	})
+
+	var result string
+	if err == nil {
+		err = handle.Get(ctx, &result)
+	}
+	if err != nil {
+		log.Printf("Error sending edit_message update: %v", err)
+		writeUpdateError(w, err)
+		return
+	}
+
+	response := UpdateResponse{Success: true, Result: result}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleReprocessTurn handles POST /update/reprocess-turn requests
+func (s *Server) handleReprocessTurn(w http.ResponseWriter, r *http.Request) {
+	var req ReprocessTurnRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.WorkflowID == "" {
+		http.Error(w, "WorkflowID is required", http.StatusBadRequest)
+		return
+	}
+	if !s.requireOwner(w, r, req.WorkflowID) {
+		return
+	}
+
+	var result string
+	if err := s.update(r.Context(), req.WorkflowID, req.RunID, workflows.UpdateReprocessTurn, &result); err != nil {
+		log.Printf("Error sending reprocess_turn update: %v", err)
+		writeUpdateError(w, err)
+		return
+	}
+
+	response := UpdateResponse{Success: true, Result: result}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// writeUpdateError writes a failed update response. Errors raised by the workflow
+// (including validator rejections) are reported as bad requests.
+func writeUpdateError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var appErr *temporal.ApplicationError
+	if errors.As(err, &appErr) {
+		status = http.StatusBadRequest
+	}
+
+	response := UpdateResponse{
+		Success: false,
+		Error:   err.Error(),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
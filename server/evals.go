@@ -0,0 +1,50 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"temporal-ai-agent/evals"
+	"time"
+)
+
+// EvalsResponse represents the response from the /evals endpoint
+type EvalsResponse struct {
+	Aggregates []evals.Aggregate `json:"aggregates"`
+	Error      string            `json:"error,omitempty"`
+}
+
+// handleEvals handles GET /evals requests, averaging the judge's scores of ended
+// conversations per goal and prompt version
+func (s *Server) handleEvals(w http.ResponseWriter, r *http.Request) {
+	if s.evaluations == nil {
+		http.Error(w, "Conversation evaluation is not configured", http.StatusNotFound)
+		return
+	}
+
+	filter := evals.Filter{Goal: r.URL.Query().Get("goal")}
+	if value := r.URL.Query().Get("since"); value != "" {
+		since, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			http.Error(w, "since must be an RFC 3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		filter.Since = since
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	aggregates, err := s.evaluations.Aggregate(ctx, filter)
+	if err != nil {
+		log.Printf("Error aggregating evaluations: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(EvalsResponse{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(EvalsResponse{Aggregates: aggregates})
+}
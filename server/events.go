@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"temporal-ai-agent/workflows"
+
+	"github.com/gorilla/mux"
+)
+
+// EventsResponse represents the response from the /workflow/{id}/events endpoint
+type EventsResponse struct {
+	Events []workflows.Event `json:"events"`
+	Error  string            `json:"error,omitempty"`
+}
+
+// handleEvents handles GET /workflow/{id}/events requests.
+// Clients poll with ?after=<last seen seq> to receive only new events.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	workflowID := mux.Vars(r)["id"]
+	if !s.requireOwner(w, r, workflowID) {
+		return
+	}
+
+	after := 0
+	if value := r.URL.Query().Get("after"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			http.Error(w, "after must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		after = parsed
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	events, err := s.queryEvents(ctx, workflowID, r.URL.Query().Get("run_id"), after)
+	if err != nil {
+		log.Printf("Error querying events: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(EventsResponse{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(EventsResponse{Events: events})
+}
+
+// queryEvents fetches the events emitted by a conversation after the given sequence number
+func (s *Server) queryEvents(ctx context.Context, workflowID, runID string, after int) ([]workflows.Event, error) {
+	value, err := s.temporalClient().QueryWorkflow(ctx, workflowID, runID, workflows.QueryEvents, after)
+	if err != nil {
+		return nil, err
+	}
+	var events []workflows.Event
+	if err := value.Get(&events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"temporal-ai-agent/fewshot"
+
+	"github.com/gorilla/mux"
+)
+
+// ExampleRequest represents the request body for the POST /admin/examples endpoint
+type ExampleRequest struct {
+	Goal      string `json:"goal"`
+	User      string `json:"user"`
+	Assistant string `json:"assistant"`
+}
+
+// ExamplesResponse represents the response from the GET /admin/examples endpoint
+type ExamplesResponse struct {
+	Examples []fewshot.Example `json:"examples"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// handleListExamples handles GET /admin/examples requests
+func (s *Server) handleListExamples(w http.ResponseWriter, r *http.Request) {
+	if s.examples == nil {
+		http.Error(w, "Few-shot examples are not configured", http.StatusNotFound)
+		return
+	}
+
+	examples, err := s.examples.List(r.URL.Query().Get("goal"))
+	if err != nil {
+		log.Printf("Error listing examples: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ExamplesResponse{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ExamplesResponse{Examples: examples})
+}
+
+// handleAddExample handles POST /admin/examples requests
+func (s *Server) handleAddExample(w http.ResponseWriter, r *http.Request) {
+	if s.examples == nil {
+		http.Error(w, "Few-shot examples are not configured", http.StatusNotFound)
+		return
+	}
+
+	var req ExampleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.Goal == "" || req.User == "" || req.Assistant == "" {
+		http.Error(w, "Goal, user and assistant are required", http.StatusBadRequest)
+		return
+	}
+
+	example, err := s.examples.Add(fewshot.Example{
+		Goal:      req.Goal,
+		User:      req.User,
+		Assistant: req.Assistant,
+	})
+	if err != nil {
+		log.Printf("Error adding example: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(example)
+}
+
+// handleRemoveExample handles DELETE /admin/examples/{id} requests
+func (s *Server) handleRemoveExample(w http.ResponseWriter, r *http.Request) {
+	if s.examples == nil {
+		http.Error(w, "Few-shot examples are not configured", http.StatusNotFound)
+		return
+	}
+
+	err := s.examples.Remove(mux.Vars(r)["id"])
+	if errors.Is(err, fewshot.ErrNotFound) {
+		http.Error(w, "Example not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error removing example: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := SignalResponse{Success: true}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"temporal-ai-agent/experiments"
+	"temporal-ai-agent/shadow"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/converter"
+)
+
+// VariantMetrics holds the outcome counts of a single experiment variant
+type VariantMetrics struct {
+	Variant  string           `json:"variant"`
+	Total    int64            `json:"total"`
+	ByStatus map[string]int64 `json:"by_status"`
+}
+
+// ExperimentMetricsResponse represents the response from the /experiments/{name}/metrics endpoint
+type ExperimentMetricsResponse struct {
+	Experiment string           `json:"experiment"`
+	Variants   []VariantMetrics `json:"variants"`
+	Error      string           `json:"error,omitempty"`
+}
+
+// ShadowResultsResponse represents the response from the /experiments/{name}/shadow endpoint
+type ShadowResultsResponse struct {
+	Experiment string           `json:"experiment"`
+	Variants   []shadow.Summary `json:"variants"`
+	// Turns are the most recent shadowed turns, for side-by-side review
+	Turns []shadow.Turn `json:"turns"`
+	Error string        `json:"error,omitempty"`
+}
+
+// handleExperimentMetrics handles GET /experiments/{name}/metrics requests
+func (s *Server) handleExperimentMetrics(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	exp, ok := experiments.Find(s.experiments, name)
+	if !ok {
+		http.Error(w, "Experiment not found", http.StatusNotFound)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	response := ExperimentMetricsResponse{Experiment: exp.Name}
+	for _, variant := range exp.Variants {
+		metrics, err := s.countVariant(ctx, exp.Name, variant.Name)
+		if err != nil {
+			log.Printf("Error counting variant %s/%s: %v", exp.Name, variant.Name, err)
+			response.Error = err.Error()
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(response)
+			return
+		}
+		response.Variants = append(response.Variants, metrics)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// countVariant counts the conversations of a variant grouped by execution status
+func (s *Server) countVariant(ctx context.Context, experiment, variant string) (VariantMetrics, error) {
+	resp, err := s.temporalClient().CountWorkflow(ctx, &workflowservice.CountWorkflowExecutionsRequest{
+		Query: fmt.Sprintf("%s = '%s' AND %s = '%s' AND ExecutionStatus != 'ContinuedAsNew' GROUP BY ExecutionStatus",
+			experiments.ExperimentKey.GetName(), experiment,
+			experiments.VariantKey.GetName(), variant),
+	})
+	if err != nil {
+		return VariantMetrics{}, err
+	}
+
+	metrics := VariantMetrics{
+		Variant:  variant,
+		Total:    resp.GetCount(),
+		ByStatus: map[string]int64{},
+	}
+	for _, group := range resp.GetGroups() {
+		var status string
+		if len(group.GetGroupValues()) > 0 {
+			if err := converter.GetDefaultDataConverter().FromPayload(group.GetGroupValues()[0], &status); err != nil {
+				return VariantMetrics{}, err
+			}
+		}
+		metrics.ByStatus[status] = group.GetCount()
+	}
+	return metrics, nil
+}
+
+// handleShadowResults handles GET /experiments/{name}/shadow requests, comparing the
+// candidates of a shadow experiment with the conversations they shadowed
+func (s *Server) handleShadowResults(w http.ResponseWriter, r *http.Request) {
+	if s.shadows == nil {
+		http.Error(w, "Shadow store is not configured", http.StatusNotFound)
+		return
+	}
+	exp, ok := experiments.Find(s.experiments, mux.Vars(r)["name"])
+	if !ok || !exp.Shadow {
+		http.Error(w, "Shadow experiment not found", http.StatusNotFound)
+		return
+	}
+
+	filter := shadow.Filter{Experiment: exp.Name, Variant: r.URL.Query().Get("variant"), Limit: defaultPageSize}
+	if value := r.URL.Query().Get("since"); value != "" {
+		since, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			http.Error(w, "since must be an RFC 3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		filter.Since = since
+	}
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = parsed
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	response := ShadowResultsResponse{Experiment: exp.Name}
+	var err error
+	if response.Variants, err = s.shadows.Summarize(ctx, filter); err == nil {
+		response.Turns, err = s.shadows.List(ctx, filter)
+	}
+	if err != nil {
+		log.Printf("Error reading shadow turns of %s: %v", exp.Name, err)
+		response.Error = err.Error()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
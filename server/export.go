@@ -0,0 +1,166 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"temporal-ai-agent/activities/llm"
+	"temporal-ai-agent/workflows"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// handleExport handles GET /workflow/{id}/export requests
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	workflowID := mux.Vars(r)["id"]
+	if !s.requireOwner(w, r, workflowID) {
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "md"
+	}
+	if format != "md" && format != "json" {
+		http.Error(w, "format must be md or json", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	value, err := s.temporalClient().QueryWorkflow(ctx, workflowID, r.URL.Query().Get("run_id"), workflows.QueryTranscript)
+	var transcript workflows.Transcript
+	if err == nil {
+		err = value.Get(&transcript)
+	}
+	if err != nil {
+		log.Printf("Error querying transcript: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", workflowID+"."+format))
+	if format == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(transcript)
+		return
+	}
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Write([]byte(renderMarkdown(transcript)))
+}
+
+// renderMarkdown renders a transcript as a Markdown document
+func renderMarkdown(t workflows.Transcript) string {
+	var b strings.Builder
+
+	title := t.Title
+	if title == "" {
+		title = "Conversation " + t.WorkflowID
+	}
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	fmt.Fprintf(&b, "- Workflow ID: `%s`\n", t.WorkflowID)
+	if t.UserID != "" {
+		fmt.Fprintf(&b, "- User: %s\n", t.UserID)
+	}
+	fmt.Fprintf(&b, "- Started: %s\n", t.StartTime.Format(time.RFC3339))
+	if t.PromptVersion != "" {
+		fmt.Fprintf(&b, "- Prompt version: %s\n", t.PromptVersion)
+	}
+	if t.Persona != "" {
+		fmt.Fprintf(&b, "- Persona: %s\n", t.Persona)
+	}
+	for _, key := range slices.Sorted(maps.Keys(t.Context)) {
+		fmt.Fprintf(&b, "- Context %s: %s\n", key, t.Context[key])
+	}
+	for _, key := range slices.Sorted(maps.Keys(t.Metadata)) {
+		fmt.Fprintf(&b, "- Metadata %s: %s\n", key, t.Metadata[key])
+	}
+
+	b.WriteString("\n## Messages\n\n")
+	writeMessages(&b, t.Messages)
+
+	if len(t.Confirmations) > 0 {
+		b.WriteString("## Confirmations\n\n")
+		for _, c := range t.Confirmations {
+			fmt.Fprintf(&b, "- %s — %s `%s`", c.Time.Format(time.RFC3339), c.Decision, c.Tool)
+			if c.Reason != "" {
+				fmt.Fprintf(&b, " (%s)", c.Reason)
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	if len(t.Annotations) > 0 {
+		b.WriteString("## Annotations\n\n")
+		for _, a := range t.Annotations {
+			fmt.Fprintf(&b, "- Message %d — %s: %s", a.MessageIndex, a.Kind, a.Value)
+			if a.Author != "" {
+				fmt.Fprintf(&b, " (%s)", a.Author)
+			}
+			fmt.Fprintf(&b, ", %s\n", a.CreatedAt.Format(time.RFC3339))
+		}
+		b.WriteString("\n")
+	}
+
+	if e := t.Evaluation; e != nil {
+		b.WriteString("## Evaluation\n\n")
+		fmt.Fprintf(&b, "- Helpfulness: %g/5\n- Goal completion: %g/5\n- Safety: %g/5\n", e.Helpfulness, e.GoalCompletion, e.Safety)
+		if e.Rationale != "" {
+			fmt.Fprintf(&b, "\n%s\n", e.Rationale)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(t.Grounding) > 0 {
+		b.WriteString("## Grounding\n\n")
+		for _, g := range t.Grounding {
+			status := "grounded"
+			if !g.Grounded {
+				status = "not grounded"
+			}
+			if g.Regenerated {
+				status += ", regenerated"
+			}
+			fmt.Fprintf(&b, "- Message %d: %s\n", g.MessageIndex, status)
+			for _, claim := range g.Unsupported {
+				fmt.Fprintf(&b, "  - Unsupported: %s\n", claim)
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	if len(t.Branches) > 0 {
+		b.WriteString("## Edited Branches\n\n")
+		for _, branch := range t.Branches {
+			fmt.Fprintf(&b, "### Replaced from message %d (edited %s)\n\n", branch.FromIndex, branch.EditedAt.Format(time.RFC3339))
+			writeMessages(&b, branch.Messages)
+		}
+	}
+
+	return b.String()
+}
+
+// writeMessages renders chat messages as bold speaker labels followed by their content
+func writeMessages(b *strings.Builder, messages []llm.Message) {
+	for _, m := range messages {
+		role := m.Role
+		if role != "" {
+			role = strings.ToUpper(role[:1]) + role[1:]
+		}
+		if m.Content != "" {
+			fmt.Fprintf(b, "**%s:** %s\n\n", role, m.Content)
+		}
+		if m.ToolCall != nil {
+			args, _ := json.Marshal(m.ToolCall.Args)
+			fmt.Fprintf(b, "**%s:** _calls `%s` with `%s`_\n\n", role, m.ToolCall.Name, args)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"temporal-ai-agent/workflows"
+)
+
+// FeedbackRequest represents the request body for the /signal/feedback endpoint
+type FeedbackRequest struct {
+	WorkflowID string `json:"workflow_id"`
+	RunID      string `json:"run_id,omitempty"`
+	workflows.Feedback
+}
+
+// handleFeedbackSignal handles POST /signal/feedback requests
+func (s *Server) handleFeedbackSignal(w http.ResponseWriter, r *http.Request) {
+	var req FeedbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.WorkflowID == "" {
+		http.Error(w, "WorkflowID is required", http.StatusBadRequest)
+		return
+	}
+	if !s.requireOwner(w, r, req.WorkflowID) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	err := s.temporalClient().SignalWorkflow(ctx, req.WorkflowID, req.RunID, workflows.SignalFeedback, req.Feedback)
+	if err != nil {
+		log.Printf("Error sending feedback signal: %v", err)
+		s.metrics.signalFailed(workflows.SignalFeedback)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(SignalResponse{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SignalResponse{Success: true})
+}
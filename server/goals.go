@@ -0,0 +1,18 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"temporal-ai-agent/goals"
+)
+
+// GoalsResponse represents the response from the GET /goals endpoint
+type GoalsResponse struct {
+	Goals []goals.Goal `json:"goals"`
+}
+
+// handleListGoals handles GET /goals requests
+func (s *Server) handleListGoals(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(GoalsResponse{Goals: s.goals.List()})
+}
@@ -0,0 +1,220 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"temporal-ai-agent/workflows"
+
+	"github.com/gorilla/mux"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+)
+
+// maxHandoffs bounds the number of handoffs listed by the operator console
+const maxHandoffs = 100
+
+// HandoffRequest represents the request body for the /update/handoff endpoint
+type HandoffRequest struct {
+	WorkflowID string `json:"workflow_id"`
+	RunID      string `json:"run_id,omitempty"`
+	Reason     string `json:"reason"`
+}
+
+// HandoffResponse represents the response from the /update/handoff endpoint
+type HandoffResponse struct {
+	Success bool               `json:"success"`
+	Handoff *workflows.Handoff `json:"handoff,omitempty"`
+	Error   string             `json:"error,omitempty"`
+}
+
+// OperatorRequest represents the request body for the operator console endpoints
+type OperatorRequest struct {
+	RunID    string `json:"run_id,omitempty"`
+	Operator string `json:"operator"`
+	// Message is the reply posted by the operator (messages endpoint only)
+	Message string `json:"message,omitempty"`
+}
+
+// HandoffSummary describes a conversation in the operator console's handoff list
+type HandoffSummary struct {
+	ConversationSummary
+	Handoff      *workflows.Handoff `json:"handoff,omitempty"`
+	HandoffError string             `json:"handoff_error,omitempty"`
+}
+
+// ListHandoffsResponse represents the response from the /operator/handoffs endpoint
+type ListHandoffsResponse struct {
+	Handoffs []HandoffSummary `json:"handoffs"`
+	Error    string           `json:"error,omitempty"`
+}
+
+// handleRequestHandoff handles POST /update/handoff requests
+func (s *Server) handleRequestHandoff(w http.ResponseWriter, r *http.Request) {
+	var req HandoffRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.WorkflowID == "" {
+		http.Error(w, "WorkflowID is required", http.StatusBadRequest)
+		return
+	}
+	if !s.requireOwner(w, r, req.WorkflowID) {
+		return
+	}
+
+	var handoff workflows.Handoff
+	if err := s.update(r.Context(), req.WorkflowID, req.RunID, workflows.UpdateRequestHandoff, &handoff, req.Reason); err != nil {
+		log.Printf("Error requesting handoff: %v", err)
+		writeUpdateError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(HandoffResponse{Success: true, Handoff: &handoff})
+}
+
+// handleListHandoffs handles GET /operator/handoffs requests. Pass ?status=pending to
+// list only the conversations nobody has claimed yet.
+func (s *Server) handleListHandoffs(w http.ResponseWriter, r *http.Request) {
+	statuses := fmt.Sprintf("('%s', '%s')", workflows.HandoffPending, workflows.HandoffClaimed)
+	switch status := r.URL.Query().Get("status"); status {
+	case "":
+	case workflows.HandoffPending, workflows.HandoffClaimed:
+		statuses = fmt.Sprintf("('%s')", status)
+	default:
+		http.Error(w, "status must be pending or claimed", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	resp, err := s.temporalClient().ListWorkflow(ctx, &workflowservice.ListWorkflowExecutionsRequest{
+		PageSize: maxHandoffs,
+		Query: fmt.Sprintf("WorkflowType = '%s' AND ExecutionStatus = 'Running' AND %s IN %s",
+			workflowTypeName, workflows.HandoffStatusKey.GetName(), statuses),
+	})
+	if err != nil {
+		log.Printf("Error listing handoffs: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ListHandoffsResponse{Error: err.Error()})
+		return
+	}
+
+	response := ListHandoffsResponse{Handoffs: make([]HandoffSummary, 0, len(resp.Executions))}
+	for _, execution := range resp.Executions {
+		summary := HandoffSummary{ConversationSummary: conversationSummary(execution)}
+		value, err := s.temporalClient().QueryWorkflow(ctx, summary.WorkflowID, summary.RunID, workflows.QueryHandoff)
+		var handoff *workflows.Handoff
+		if err == nil {
+			err = value.Get(&handoff)
+		}
+		if err != nil {
+			summary.HandoffError = err.Error()
+		} else {
+			summary.Handoff = handoff
+		}
+		response.Handoffs = append(response.Handoffs, summary)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleClaimHandoff handles POST /operator/handoffs/{id}/claim requests
+func (s *Server) handleClaimHandoff(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeOperatorRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var handoff workflows.Handoff
+	if err := s.update(r.Context(), mux.Vars(r)["id"], req.RunID, workflows.UpdateClaimHandoff, &handoff, req.Operator); err != nil {
+		log.Printf("Error claiming handoff: %v", err)
+		writeUpdateError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(HandoffResponse{Success: true, Handoff: &handoff})
+}
+
+// handleOperatorMessage handles POST /operator/handoffs/{id}/messages requests
+func (s *Server) handleOperatorMessage(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeOperatorRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if req.Message == "" {
+		http.Error(w, "Message is required", http.StatusBadRequest)
+		return
+	}
+
+	msg := workflows.OperatorMessage{Operator: req.Operator, Message: req.Message}
+	if err := s.update(r.Context(), mux.Vars(r)["id"], req.RunID, workflows.UpdateOperatorMessage, nil, msg); err != nil {
+		log.Printf("Error sending operator message: %v", err)
+		writeUpdateError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(UpdateResponse{Success: true})
+}
+
+// handleReleaseHandoff handles POST /operator/handoffs/{id}/release requests
+func (s *Server) handleReleaseHandoff(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeOperatorRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.update(r.Context(), mux.Vars(r)["id"], req.RunID, workflows.UpdateReturnToAgent, nil, req.Operator); err != nil {
+		log.Printf("Error returning control to the agent: %v", err)
+		writeUpdateError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(UpdateResponse{Success: true})
+}
+
+// decodeOperatorRequest decodes an operator console request body, writing a bad request
+// response when it is invalid
+func decodeOperatorRequest(w http.ResponseWriter, r *http.Request) (OperatorRequest, bool) {
+	var req OperatorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return req, false
+	}
+
+	if req.Operator == "" {
+		http.Error(w, "Operator is required", http.StatusBadRequest)
+		return req, false
+	}
+	return req, true
+}
+
+// update sends an update, waits for it to complete and decodes its result into valuePtr (if not nil)
+func (s *Server) update(ctx context.Context, workflowID, runID, name string, valuePtr interface{}, args ...interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, updateTimeout)
+	defer cancel()
+
+	handle, err := s.temporalClient().UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+		WorkflowID:   workflowID,
+		RunID:        runID,
+		UpdateName:   name,
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+		Args:         args,
+	})
+	if err != nil {
+		return err
+	}
+	return handle.Get(ctx, valuePtr)
+}
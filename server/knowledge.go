@@ -0,0 +1,194 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"temporal-ai-agent/activities"
+	"temporal-ai-agent/knowledge"
+	"temporal-ai-agent/workflows"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.temporal.io/sdk/client"
+)
+
+// defaultCrawlDepth is how many links away from the seeds a crawl goes when the request does not say
+const defaultCrawlDepth = 2
+
+// CrawlRequest represents the request body for the /admin/knowledge/crawl endpoint
+type CrawlRequest struct {
+	Namespace string   `json:"namespace"`
+	Seeds     []string `json:"seeds"`
+	// MaxDepth defaults to 2; 0 ingests the seeds only
+	MaxDepth          *int `json:"max_depth,omitempty"`
+	MaxPages          int  `json:"max_pages,omitempty"`
+	AllowOtherDomains bool `json:"allow_other_domains,omitempty"`
+	// Delay is a Go duration such as "500ms" (default: 1s)
+	Delay string `json:"delay,omitempty"`
+}
+
+// CrawlResponse represents the response from the /admin/knowledge/crawl endpoints
+type CrawlResponse struct {
+	WorkflowID string                   `json:"workflow_id,omitempty"`
+	RunID      string                   `json:"run_id,omitempty"`
+	Progress   *workflows.CrawlProgress `json:"progress,omitempty"`
+	Error      string                   `json:"error,omitempty"`
+}
+
+// DebugQueryRequest represents the request body for the /knowledge/debug-query endpoint
+type DebugQueryRequest struct {
+	Query string `json:"query"`
+	// Goal applies the goal's namespace bindings; without it, Namespace (or every
+	// namespace when empty) is searched
+	Goal      string `json:"goal,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// DebugQueryResponse represents the response from the /knowledge/debug-query endpoint
+type DebugQueryResponse struct {
+	Retrieval *knowledge.Retrieval `json:"retrieval,omitempty"`
+	Error     string               `json:"error,omitempty"`
+}
+
+// handleStartCrawl handles POST /admin/knowledge/crawl requests. Crawls take minutes, so
+// the response returns immediately and progress is polled with GET /admin/knowledge/crawl/{id}.
+func (s *Server) handleStartCrawl(w http.ResponseWriter, r *http.Request) {
+	var req CrawlRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.Namespace == "" {
+		http.Error(w, "Namespace is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Seeds) == 0 {
+		http.Error(w, "Seeds are required", http.StatusBadRequest)
+		return
+	}
+	for _, seed := range req.Seeds {
+		if u, err := url.Parse(seed); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			http.Error(w, fmt.Sprintf("Invalid seed URL %q", seed), http.StatusBadRequest)
+			return
+		}
+	}
+
+	crawl := workflows.CrawlRequest{
+		Namespace:         req.Namespace,
+		Seeds:             req.Seeds,
+		MaxDepth:          defaultCrawlDepth,
+		MaxPages:          req.MaxPages,
+		AllowOtherDomains: req.AllowOtherDomains,
+	}
+	if req.MaxDepth != nil {
+		if *req.MaxDepth < 0 {
+			http.Error(w, "max_depth must not be negative", http.StatusBadRequest)
+			return
+		}
+		crawl.MaxDepth = *req.MaxDepth
+	}
+	if req.Delay != "" {
+		delay, err := time.ParseDuration(req.Delay)
+		if err != nil {
+			http.Error(w, "Invalid delay", http.StatusBadRequest)
+			return
+		}
+		crawl.Delay = delay
+	}
+
+	options := client.StartWorkflowOptions{
+		ID:        fmt.Sprintf("crawl-workflow-%d", time.Now().UnixNano()),
+		TaskQueue: s.taskQueue,
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	we, err := s.temporalClient().ExecuteWorkflow(ctx, options, workflows.CrawlWorkflow, crawl)
+	if err != nil {
+		log.Printf("Unable to start crawl workflow: %v", err)
+		s.metrics.startFailed("CrawlWorkflow")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(CrawlResponse{Error: err.Error()})
+		return
+	}
+
+	log.Printf("Started crawl workflow: WorkflowID=%s, RunID=%s", we.GetID(), we.GetRunID())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(CrawlResponse{WorkflowID: we.GetID(), RunID: we.GetRunID()})
+}
+
+// handleGetCrawl handles GET /admin/knowledge/crawl/{id} requests
+func (s *Server) handleGetCrawl(w http.ResponseWriter, r *http.Request) {
+	workflowID := mux.Vars(r)["id"]
+	runID := r.URL.Query().Get("run_id")
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	response := CrawlResponse{WorkflowID: workflowID, RunID: runID}
+	value, err := s.temporalClient().QueryWorkflow(ctx, workflowID, runID, workflows.QueryCrawlProgress)
+	var progress workflows.CrawlProgress
+	if err == nil {
+		err = value.Get(&progress)
+	}
+	if err != nil {
+		log.Printf("Error querying crawl workflow: %v", err)
+		response.Error = err.Error()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	response.Progress = &progress
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleDebugQuery handles POST /knowledge/debug-query requests. The query runs
+// through the retrieval tool's pipeline on a worker, and the response traces it.
+func (s *Server) handleDebugQuery(w http.ResponseWriter, r *http.Request) {
+	var req DebugQueryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Query == "" {
+		http.Error(w, "Query is required", http.StatusBadRequest)
+		return
+	}
+
+	options := client.StartWorkflowOptions{
+		ID:        fmt.Sprintf("debug-retrieval-%d", time.Now().UnixNano()),
+		TaskQueue: s.taskQueue,
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), updateTimeout)
+	defer cancel()
+
+	var retrieval knowledge.Retrieval
+	we, err := s.temporalClient().ExecuteWorkflow(ctx, options, workflows.DebugRetrievalWorkflow, activities.DebugRetrievalRequest{
+		Query:     req.Query,
+		Goal:      req.Goal,
+		Namespace: req.Namespace,
+	})
+	if err == nil {
+		err = we.Get(ctx, &retrieval)
+	}
+	if err != nil {
+		log.Printf("Error running debug query: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DebugQueryResponse{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(DebugQueryResponse{Retrieval: &retrieval})
+}
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"temporal-ai-agent/workflows"
+
+	"go.temporal.io/api/workflowservice/v1"
+)
+
+// runningConversations lists the running conversations started by a user, up to limit
+func (s *Server) runningConversations(ctx context.Context, userID string, limit int) ([]ConversationSummary, error) {
+	ctx, cancel := context.WithTimeout(ctx, clientCallTimeout)
+	defer cancel()
+
+	resp, err := s.temporalClient().ListWorkflow(ctx, &workflowservice.ListWorkflowExecutionsRequest{
+		PageSize: int32(limit),
+		Query: fmt.Sprintf("WorkflowType = '%s' AND ExecutionStatus = 'Running' AND %s = '%s'",
+			workflowTypeName, workflows.UserIDKey.GetName(), escapeQueryValue(userID)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	conversations := make([]ConversationSummary, 0, len(resp.Executions))
+	for _, execution := range resp.Executions {
+		conversations = append(conversations, conversationSummary(execution))
+	}
+	return conversations, nil
+}
+
+// escapeQueryValue escapes a value for use inside a quoted visibility query string
+func escapeQueryValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value)
+}
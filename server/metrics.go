@@ -0,0 +1,117 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"temporal-ai-agent/shedding"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// httpMetrics are the API server's metrics, served by /metrics with those of the Temporal
+// client when they share the registry
+type httpMetrics struct {
+	requests *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+	// startFailures counts the workflows the server failed to start, by workflow type;
+	// signalErrors counts the signals it failed to send, by signal name
+	startFailures *prometheus.CounterVec
+	signalErrors  *prometheus.CounterVec
+	// shed counts the requests the load shedder turned away, by the limit they hit
+	shed *prometheus.CounterVec
+}
+
+// newHTTPMetrics registers the API server's metrics, with the requests in flight when
+// shedding is enabled
+func newHTTPMetrics(registerer prometheus.Registerer, shedder *shedding.Shedder) *httpMetrics {
+	m := &httpMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "agent_http_requests_total",
+			Help: "HTTP requests served by the API, by route, method and status code",
+		}, []string{"route", "method", "code"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "agent_http_request_duration_seconds",
+			Help:    "Time the API took to serve requests, by route and method",
+			Buckets: prometheus.ExponentialBuckets(0.005, 2, 14),
+		}, []string{"route", "method"}),
+		startFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "agent_workflow_start_failures_total",
+			Help: "Workflows the API failed to start, by workflow type",
+		}, []string{"workflow_type"}),
+		signalErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "agent_signal_errors_total",
+			Help: "Signals the API failed to send, by signal name",
+		}, []string{"signal"}),
+		shed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "agent_requests_shed_total",
+			Help: "Starts and updates the API turned away while overloaded, by the limit they hit",
+		}, []string{"limit"}),
+	}
+	registerer.MustRegister(m.requests, m.latency, m.startFailures, m.signalErrors, m.shed)
+	if shedder != nil {
+		registerer.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "agent_requests_in_flight",
+			Help: "Starts and updates the API is handling",
+		}, func() float64 { return float64(shedder.InFlight()) }))
+	}
+	return m
+}
+
+// instrument counts the requests of each route and times them. Routes are labeled with
+// their path template, so conversation IDs do not multiply the series.
+func (m *httpMetrics) instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := "unmatched"
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		started := time.Now()
+		next.ServeHTTP(recorder, r)
+		m.latency.WithLabelValues(route, r.Method).Observe(time.Since(started).Seconds())
+		m.requests.WithLabelValues(route, r.Method, strconv.Itoa(recorder.status)).Inc()
+	})
+}
+
+// startFailed counts a workflow the server failed to start
+func (m *httpMetrics) startFailed(workflowType string) {
+	m.startFailures.WithLabelValues(workflowType).Inc()
+}
+
+// signalFailed counts a signal the server failed to send
+func (m *httpMetrics) signalFailed(signal string) {
+	m.signalErrors.WithLabelValues(signal).Inc()
+}
+
+// requestShed counts a request turned away by the load shedder
+func (m *httpMetrics) requestShed(limit string) {
+	m.shed.WithLabelValues(limit).Inc()
+}
+
+// statusRecorder remembers the status code written to a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status code
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Flush lets event streams flush through the recorder
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"temporal-ai-agent/quota"
+	"temporal-ai-agent/workflows"
+
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/converter"
+)
+
+// callerAccount returns the quota account of the request's API key, which requireQuota
+// already resolved on the endpoints it guards. It reports false when quotas are enabled and
+// the key is unknown; without quotas the caller has no account.
+func (s *Server) callerAccount(r *http.Request) (string, bool) {
+	if account := quotaAccount(r); account != "" {
+		return account, true
+	}
+	if !s.quotas.Enabled() {
+		return "", true
+	}
+	apiKey := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if _, _, ok := s.quotas.Lookup(apiKey); !ok {
+		return "", false
+	}
+	return quota.AccountID(apiKey), true
+}
+
+// requireOwner writes the error of a request addressing a conversation the caller's API key
+// did not start, and reports whether the request may go on. Other accounts get 404, so they
+// cannot tell which conversations exist. Without quotas conversations have no owner, and a
+// conversation that does not exist is left for the call that follows to report.
+func (s *Server) requireOwner(w http.ResponseWriter, r *http.Request, workflowID string) bool {
+	account, ok := s.callerAccount(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	if account == "" {
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	resp, err := s.temporalClient().DescribeWorkflowExecution(ctx, workflowID, "")
+	var notFound *serviceerror.NotFound
+	if errors.As(err, &notFound) {
+		return true
+	}
+	if err != nil {
+		log.Printf("Error describing conversation %s: %v", workflowID, err)
+		http.Error(w, "Unable to check the conversation's owner", http.StatusInternalServerError)
+		return false
+	}
+
+	var owner string
+	decodeMemo(resp.GetWorkflowExecutionInfo(), workflows.MemoAccount, &owner)
+	if owner != account {
+		http.Error(w, "Conversation not found", http.StatusNotFound)
+		return false
+	}
+	return true
+}
+
+// requireUser writes the error of a request acting for a user on a conversation that user
+// did not start, and reports whether the request may go on. Conversations record their
+// user in the AgentUserID search attribute; those started without one belong to no user.
+func (s *Server) requireUser(w http.ResponseWriter, r *http.Request, workflowID, userID string) bool {
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	resp, err := s.temporalClient().DescribeWorkflowExecution(ctx, workflowID, "")
+	var notFound *serviceerror.NotFound
+	if errors.As(err, &notFound) {
+		http.Error(w, "Conversation not found", http.StatusNotFound)
+		return false
+	}
+	if err != nil {
+		log.Printf("Error describing conversation %s: %v", workflowID, err)
+		http.Error(w, "Unable to check the conversation's user", http.StatusInternalServerError)
+		return false
+	}
+
+	var user string
+	if payload, ok := resp.GetWorkflowExecutionInfo().GetSearchAttributes().GetIndexedFields()[workflows.UserIDKey.GetName()]; ok {
+		if err := converter.GetDefaultDataConverter().FromPayload(payload, &user); err != nil {
+			log.Printf("Error decoding the user of conversation %s: %v", workflowID, err)
+		}
+	}
+	if user != userID {
+		http.Error(w, "Conversation not found", http.StatusNotFound)
+		return false
+	}
+	return true
+}
+
+// taskParentID returns the ID of the conversation that started a background task
+func taskParentID(taskID string) string {
+	if i := strings.LastIndex(taskID, "-task-"); i >= 0 {
+		return taskID[:i]
+	}
+	return taskID
+}
@@ -0,0 +1,221 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"temporal-ai-agent/quota"
+	"temporal-ai-agent/search"
+	"temporal-ai-agent/workflows"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	commonpb "go.temporal.io/api/common/v1"
+	workflowpb "go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/mocks"
+)
+
+// Two tenants on the same plan
+var testPlans = quota.Plans{
+	Plans: map[string]quota.Plan{"free": {}},
+	Keys:  map[string]string{"sk-alice": "free", "sk-bob": "free"},
+}
+
+// ownedConversation returns a client whose conversation workflowID was started with apiKey
+func ownedConversation(t *testing.T, workflowID, apiKey string) *mocks.Client {
+	account, err := converter.GetDefaultDataConverter().ToPayload(quota.AccountID(apiKey))
+	require.NoError(t, err)
+	c := &mocks.Client{}
+	c.On("DescribeWorkflowExecution", mock.Anything, workflowID, "").Return(&workflowservice.DescribeWorkflowExecutionResponse{
+		WorkflowExecutionInfo: &workflowpb.WorkflowExecutionInfo{
+			Memo: &commonpb.Memo{Fields: map[string]*commonpb.Payload{workflows.MemoAccount: account}},
+		},
+	}, nil)
+	return c
+}
+
+func TestRequireOwner(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		apiKey string
+		status int
+	}{
+		{"owner", "sk-alice", http.StatusOK},
+		{"other account", "sk-bob", http.StatusNotFound},
+		{"unknown key", "sk-mallory", http.StatusUnauthorized},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := New(ownedConversation(t, "chat-1", "sk-alice"), Options{Quotas: testPlans, Usage: quota.NewMemoryStore()})
+			req := httptest.NewRequest(http.MethodGet, "/workflow/chat-1/pending-confirmation", nil)
+			req.Header.Set("Authorization", "Bearer "+tc.apiKey)
+			rec := httptest.NewRecorder()
+
+			ok := s.requireOwner(rec, req, "chat-1")
+			require.Equal(t, tc.status == http.StatusOK, ok)
+			if !ok {
+				require.Equal(t, tc.status, rec.Code)
+			}
+		})
+	}
+}
+
+// Signals and queries of another account's conversation never reach the workflow
+func TestOtherAccountCannotSignalOrQuery(t *testing.T) {
+	c := ownedConversation(t, "chat-1", "sk-alice")
+	router := New(c, Options{Quotas: testPlans, Usage: quota.NewMemoryStore()}).Router()
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodPost, "/signal/end-chat", strings.NewReader(`{"workflow_id":"chat-1"}`)),
+		httptest.NewRequest(http.MethodPost, "/signal/feedback", strings.NewReader(`{"workflow_id":"chat-1","satisfied":true}`)),
+		httptest.NewRequest(http.MethodGet, "/workflow/chat-1/events", nil),
+		httptest.NewRequest(http.MethodGet, "/workflow/chat-1/export", nil),
+	} {
+		req.Header.Set("Authorization", "Bearer sk-bob")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusNotFound, rec.Code, req.URL.Path)
+	}
+	c.AssertNotCalled(t, "SignalWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	c.AssertNotCalled(t, "QueryWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// A tenant's LLM key answers the turns of its own conversations, so another tenant must
+// not be able to send them messages, directly or by joining them with a start
+func TestOtherTenantCannotSendTurns(t *testing.T) {
+	c := ownedConversation(t, "chat-1", "sk-alice")
+	router := New(c, Options{TaskQueue: "agent", Quotas: testPlans, Usage: quota.NewMemoryStore()}).Router()
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodPost, "/update/user-prompt", strings.NewReader(`{"workflow_id":"chat-1","message":"hi"}`)),
+		httptest.NewRequest(http.MethodPost, "/signal/user-prompt", strings.NewReader(`{"workflow_id":"chat-1","message":"hi"}`)),
+		httptest.NewRequest(http.MethodPost, "/signal-with-start/user-prompt", strings.NewReader(`{"workflow_id":"chat-1","message":"hi"}`)),
+		httptest.NewRequest(http.MethodPost, "/start-workflow", strings.NewReader(`{"workflow_id":"chat-1","id_conflict_policy":"UseExisting","message":"hi","wait_for_reply":true}`)),
+	} {
+		req.Header.Set("Authorization", "Bearer sk-bob")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusNotFound, rec.Code, req.URL.Path)
+	}
+	c.AssertNotCalled(t, "UpdateWorkflow", mock.Anything, mock.Anything)
+	c.AssertNotCalled(t, "SignalWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	c.AssertNotCalled(t, "SignalWithStartWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything, mock.Anything, mock.Anything)
+	c.AssertNotCalled(t, "UpdateWithStartWorkflow", mock.Anything, mock.Anything)
+	c.AssertNotCalled(t, "ExecuteWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// The list holds only the caller's conversations, and research runs answer their owner only
+func TestListAndResearchAreScopedByAccount(t *testing.T) {
+	c := ownedConversation(t, "research-1", "sk-alice")
+	c.On("ListWorkflow", mock.Anything, mock.Anything).Return(&workflowservice.ListWorkflowExecutionsResponse{}, nil)
+	router := New(c, Options{Quotas: testPlans, Usage: quota.NewMemoryStore()}).Router()
+
+	req := httptest.NewRequest(http.MethodGet, "/conversations", nil)
+	req.Header.Set("Authorization", "Bearer sk-bob")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	list := c.Calls[len(c.Calls)-1].Arguments.Get(1).(*workflowservice.ListWorkflowExecutionsRequest)
+	require.Contains(t, list.Query, "AgentAccount = '"+quota.AccountID("sk-bob")+"'")
+
+	req = httptest.NewRequest(http.MethodGet, "/research/research-1", nil)
+	req.Header.Set("Authorization", "Bearer sk-bob")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	c.AssertNotCalled(t, "QueryWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	req = httptest.NewRequest(http.MethodGet, "/conversations", nil)
+	req.Header.Set("Authorization", "Bearer sk-mallory")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+// Two accounts searching for the same words only find their own conversations
+func TestSearchIsScopedByAccount(t *testing.T) {
+	index := search.NewMemoryIndex()
+	ctx := context.Background()
+	require.NoError(t, index.Index(ctx, search.Transcript{WorkflowID: "chat-alice", Account: quota.AccountID("sk-alice"), Content: "user: refund my order"}))
+	require.NoError(t, index.Index(ctx, search.Transcript{WorkflowID: "chat-bob", Account: quota.AccountID("sk-bob"), Content: "user: refund my order"}))
+	router := New(&mocks.Client{}, Options{Quotas: testPlans, Usage: quota.NewMemoryStore(), Search: index}).Router()
+
+	for apiKey, want := range map[string]string{"sk-alice": "chat-alice", "sk-bob": "chat-bob"} {
+		req := httptest.NewRequest(http.MethodGet, "/conversations/search?q=refund", nil)
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp SearchResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		require.Len(t, resp.Results, 1, apiKey)
+		require.Equal(t, want, resp.Results[0].WorkflowID)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/conversations/search?q=refund", nil)
+	req.Header.Set("Authorization", "Bearer sk-mallory")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+// Preferences are changed only by the user the conversation was started for
+func TestRequireUser(t *testing.T) {
+	user, err := converter.GetDefaultDataConverter().ToPayload("u-42")
+	require.NoError(t, err)
+	c := &mocks.Client{}
+	c.On("DescribeWorkflowExecution", mock.Anything, "chat-1", "").Return(&workflowservice.DescribeWorkflowExecutionResponse{
+		WorkflowExecutionInfo: &workflowpb.WorkflowExecutionInfo{
+			SearchAttributes: &commonpb.SearchAttributes{IndexedFields: map[string]*commonpb.Payload{workflows.UserIDKey.GetName(): user}},
+		},
+	}, nil)
+	c.On("DescribeWorkflowExecution", mock.Anything, "chat-2", "").Return(&workflowservice.DescribeWorkflowExecutionResponse{
+		WorkflowExecutionInfo: &workflowpb.WorkflowExecutionInfo{},
+	}, nil)
+	s := New(c, Options{})
+
+	for _, tc := range []struct {
+		workflowID, userID string
+		ok                 bool
+	}{
+		{"chat-1", "u-42", true},
+		{"chat-1", "u-7", false},
+		{"chat-2", "u-42", false},
+	} {
+		rec := httptest.NewRecorder()
+		ok := s.requireUser(rec, httptest.NewRequest(http.MethodPatch, "/conversations/"+tc.workflowID, nil), tc.workflowID, tc.userID)
+		require.Equal(t, tc.ok, ok, tc)
+		if !ok {
+			require.Equal(t, http.StatusNotFound, rec.Code)
+		}
+	}
+}
+
+func TestListFiltersByUserAndCapsPageSize(t *testing.T) {
+	c := &mocks.Client{}
+	c.On("ListWorkflow", mock.Anything, mock.Anything).Return(&workflowservice.ListWorkflowExecutionsResponse{}, nil)
+	router := New(c, Options{}).Router()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/conversations?user_id=u-42", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	list := c.Calls[0].Arguments.Get(1).(*workflowservice.ListWorkflowExecutionsRequest)
+	require.Contains(t, list.Query, "AgentUserID = 'u-42'")
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/conversations?page_size=101", nil))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	c.AssertNumberOfCalls(t, "ListWorkflow", 1)
+}
+
+func TestStickyIDsAreScopedByAccount(t *testing.T) {
+	alice, bob := quota.AccountID("sk-alice"), quota.AccountID("sk-bob")
+	require.NotEqual(t, userWorkflowID(alice, "42"), userWorkflowID(bob, "42"))
+	require.Equal(t, "chat-user-42", userWorkflowID("", "42"))
+}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"temporal-ai-agent/store"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+)
+
+// maxTitleLength bounds the titles users give their conversations
+const maxTitleLength = 200
+
+// PreferencesRequest represents the request body for PATCH /conversations/{id}. Fields left
+// out keep their current value.
+type PreferencesRequest struct {
+	UserID   string  `json:"user_id"`
+	Pinned   *bool   `json:"pinned,omitempty"`
+	Archived *bool   `json:"archived,omitempty"`
+	Title    *string `json:"title,omitempty"`
+}
+
+// PreferencesResponse represents the response from PATCH /conversations/{id}
+type PreferencesResponse struct {
+	Preferences *store.Preferences `json:"preferences,omitempty"`
+	Error       string             `json:"error,omitempty"`
+}
+
+// handleUpdatePreferences handles PATCH /conversations/{id} requests, pinning, archiving or
+// renaming a conversation for a user
+func (s *Server) handleUpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	if s.conversations == nil {
+		http.Error(w, "Conversation storage is not configured", http.StatusNotFound)
+		return
+	}
+
+	var req PreferencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.UserID == "" {
+		http.Error(w, "UserID is required", http.StatusBadRequest)
+		return
+	}
+	if req.Title != nil && utf8.RuneCountInString(*req.Title) > maxTitleLength {
+		http.Error(w, fmt.Sprintf("title must be at most %d characters", maxTitleLength), http.StatusBadRequest)
+		return
+	}
+	if !s.requireOwner(w, r, mux.Vars(r)["id"]) || !s.requireUser(w, r, mux.Vars(r)["id"], req.UserID) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	workflowID := mux.Vars(r)["id"]
+	preferences, err := s.userPreferences(ctx, req.UserID)
+	if err != nil {
+		log.Printf("Error getting conversation preferences: %v", err)
+		writePreferencesError(w, err)
+		return
+	}
+	p, ok := preferences[workflowID]
+	if !ok {
+		p = store.Preferences{UserID: req.UserID, WorkflowID: workflowID}
+	}
+	if req.Pinned != nil {
+		p.Pinned = *req.Pinned
+	}
+	if req.Archived != nil {
+		p.Archived = *req.Archived
+	}
+	if req.Title != nil {
+		p.Title = strings.TrimSpace(*req.Title)
+	}
+	p.UpdatedAt = time.Now().UTC()
+
+	if err := s.conversations.SetPreferences(ctx, p); err != nil {
+		log.Printf("Error saving conversation preferences: %v", err)
+		writePreferencesError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(PreferencesResponse{Preferences: &p})
+}
+
+// writePreferencesError writes a failed preferences response
+func writePreferencesError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(PreferencesResponse{Error: err.Error()})
+}
+
+// userPreferences returns a user's conversation settings by workflow ID
+func (s *Server) userPreferences(ctx context.Context, userID string) (map[string]store.Preferences, error) {
+	list, err := s.conversations.Preferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	preferences := make(map[string]store.Preferences, len(list))
+	for _, p := range list {
+		preferences[p.WorkflowID] = p
+	}
+	return preferences, nil
+}
+
+// listFilter narrows the conversation list with a user's preferences: it lists only the
+// pinned or archived conversations when asked to, and hides archived ones otherwise
+type listFilter struct {
+	userID   string
+	pinned   bool
+	archived bool
+}
+
+// parseListFilter reads the user_id, pinned and archived parameters of a list request
+func parseListFilter(r *http.Request) (listFilter, error) {
+	query := r.URL.Query()
+	filter := listFilter{userID: query.Get("user_id")}
+	flags := []struct {
+		name  string
+		value *bool
+	}{{"pinned", &filter.pinned}, {"archived", &filter.archived}}
+	for _, flag := range flags {
+		switch query.Get(flag.name) {
+		case "", "false":
+		case "true":
+			*flag.value = true
+		default:
+			return listFilter{}, fmt.Errorf("%s must be true or false", flag.name)
+		}
+	}
+	if filter.userID == "" && (filter.pinned || filter.archived) {
+		return listFilter{}, fmt.Errorf("user_id is required to filter by pinned or archived")
+	}
+	return filter, nil
+}
+
+// clause returns the visibility query condition selecting the conversations the filter
+// keeps, or false when none can match
+func (f listFilter) clause(preferences map[string]store.Preferences) (string, bool) {
+	var ids []string
+	for id, p := range preferences {
+		if f.pinned || f.archived {
+			if p.Archived == f.archived && (p.Pinned || !f.pinned) {
+				ids = append(ids, id)
+			}
+		} else if p.Archived {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return "", !f.pinned && !f.archived
+	}
+
+	slices.Sort(ids)
+	quoted := make([]string, len(ids))
+	for i, id := range ids {
+		quoted[i] = "'" + escapeQueryValue(id) + "'"
+	}
+	operator := "IN"
+	if !f.pinned && !f.archived {
+		operator = "NOT IN"
+	}
+	return fmt.Sprintf(" AND WorkflowId %s (%s)", operator, strings.Join(quoted, ", ")), true
+}
+
+// applyPreferences marks a listed conversation with the user's settings
+func applyPreferences(summary *ConversationSummary, preferences map[string]store.Preferences) {
+	p, ok := preferences[summary.WorkflowID]
+	if !ok {
+		return
+	}
+	summary.Pinned = p.Pinned
+	summary.Archived = p.Archived
+	if p.Title != "" {
+		summary.Title = p.Title
+	}
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"temporal-ai-agent/workflows"
+
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+)
+
+// PromptUpdateResponse represents the response from the /update/user-prompt endpoint
+type PromptUpdateResponse struct {
+	Success     bool   `json:"success"`
+	Reply       string `json:"reply,omitempty"`
+	EventsAfter int    `json:"events_after"`
+	// QuickReplies are suggested for the user's next message, when the conversation suggests replies
+	QuickReplies []string `json:"quick_replies,omitempty"`
+	// Downgraded is set when the fallback model answered because the turn ran out of its latency budget
+	Downgraded bool   `json:"downgraded,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// handleUserPromptUpdate handles POST /update/user-prompt requests.
+// Unlike the signal endpoint it waits for the turn to finish and returns the agent's reply.
+func (s *Server) handleUserPromptUpdate(w http.ResponseWriter, r *http.Request) {
+	var req SignalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.WorkflowID == "" {
+		http.Error(w, "WorkflowID is required", http.StatusBadRequest)
+		return
+	}
+	if !s.requireOwner(w, r, req.WorkflowID) {
+		return
+	}
+
+	if req.Message == "" && len(req.Attachments) == 0 {
+		http.Error(w, "Message or attachments are required", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), updateTimeout)
+	defer cancel()
+
+	handle, err := s.temporalClient().UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+		WorkflowID: req.WorkflowID,
+		RunID:      req.RunID,
+		// Reusing the message ID as update ID makes retried requests return the original turn
+		UpdateID:     req.MessageID,
+		UpdateName:   workflows.UpdateUserPrompt,
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+		Args:         []interface{}{workflows.UserPrompt{Message: req.Message, MessageID: req.MessageID, Attachments: req.Attachments}},
+	})
+
+	var turn workflows.TurnResult
+	if err == nil {
+		err = handle.Get(ctx, &turn)
+	}
+	if err != nil {
+		log.Printf("Error sending user_prompt update: %v", err)
+		writeUpdateError(w, err)
+		return
+	}
+
+	response := PromptUpdateResponse{
+		Success:      true,
+		Reply:        turn.Reply,
+		EventsAfter:  turn.EventsAfter,
+		QuickReplies: turn.QuickReplies,
+		Downgraded:   turn.Downgraded,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// startWithPrompt starts a conversation with its first message sent as a user_prompt
+// update in the same call, and responds with the agent's reply once the turn is done
+func (s *Server) startWithPrompt(w http.ResponseWriter, r *http.Request, options client.StartWorkflowOptions, input workflows.ConversationInput) {
+	// Update-with-start needs an explicit conflict policy; Fail is what a plain start does
+	if options.WorkflowIDConflictPolicy == enumspb.WORKFLOW_ID_CONFLICT_POLICY_UNSPECIFIED {
+		options.WorkflowIDConflictPolicy = enumspb.WORKFLOW_ID_CONFLICT_POLICY_FAIL
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), updateTimeout)
+	defer cancel()
+
+	prompt := workflows.UserPrompt{Message: input.Message}
+	input.Message = ""
+	c := s.temporalClient()
+	start := c.NewWithStartWorkflowOperation(options, workflows.AgentGoalWorkflow, input)
+	handle, err := c.UpdateWithStartWorkflow(ctx, client.UpdateWithStartWorkflowOptions{
+		StartWorkflowOperation: start,
+		UpdateOptions: client.UpdateWorkflowOptions{
+			UpdateName:   workflows.UpdateUserPrompt,
+			WaitForStage: client.WorkflowUpdateStageCompleted,
+			Args:         []interface{}{prompt},
+		},
+	})
+
+	var turn workflows.TurnResult
+	if err == nil {
+		err = handle.Get(ctx, &turn)
+	}
+	var run client.WorkflowRun
+	if err == nil {
+		run, err = start.Get(ctx)
+	}
+	if err != nil {
+		log.Printf("Unable to start workflow with user_prompt update: %v", err)
+		status := http.StatusInternalServerError
+		var alreadyStarted *serviceerror.WorkflowExecutionAlreadyStarted
+		var appErr *temporal.ApplicationError
+		switch {
+		case errors.As(err, &alreadyStarted):
+			status = http.StatusConflict
+		case errors.As(err, &appErr):
+			status = http.StatusBadRequest
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(ChatResponse{Error: err.Error()})
+		return
+	}
+
+	log.Printf("Started workflow with user_prompt update: WorkflowID=%s, RunID=%s", run.GetID(), run.GetRunID())
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ChatResponse{
+		WorkflowID:   run.GetID(),
+		RunID:        run.GetRunID(),
+		Reply:        turn.Reply,
+		QuickReplies: turn.QuickReplies,
+	})
+}
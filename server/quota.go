@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"temporal-ai-agent/quota"
+	"time"
+)
+
+// accountKey and planKey are the request context keys of the quota account and its plan
+type (
+	accountKey struct{}
+	planKey    struct{}
+)
+
+// QuotaResponse represents the response from the /quota endpoint
+type QuotaResponse struct {
+	quota.Remaining
+	Error string `json:"error,omitempty"`
+}
+
+// requireQuota rejects requests whose API key is unknown or has exhausted its plan, and
+// passes the key's quota account on to the handler. It lets every request through when
+// no quotas are configured.
+func (s *Server) requireQuota(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.quotas.Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		apiKey := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		_, plan, ok := s.quotas.Lookup(apiKey)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		account := quota.AccountID(apiKey)
+		usage, err := s.usage.Usage(r.Context(), account, time.Now())
+		if err != nil {
+			log.Printf("Error reading quota usage: %v", err)
+			http.Error(w, "Unable to check quota", http.StatusInternalServerError)
+			return
+		}
+		if err := plan.Check(usage); err != nil {
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), accountKey{}, account)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, planKey{}, plan)))
+	})
+}
+
+// quotaAccount returns the quota account of a request that passed requireQuota
+func quotaAccount(r *http.Request) string {
+	account, _ := r.Context().Value(accountKey{}).(string)
+	return account
+}
+
+// quotaPlan returns the plan of a request that passed requireQuota; requests are on no
+// plan when quotas are disabled
+func quotaPlan(r *http.Request) quota.Plan {
+	plan, _ := r.Context().Value(planKey{}).(quota.Plan)
+	return plan
+}
+
+// handleGetQuota handles GET /quota requests, returning what is left of the caller's plan
+func (s *Server) handleGetQuota(w http.ResponseWriter, r *http.Request) {
+	if !s.quotas.Enabled() {
+		http.Error(w, "Quotas are not configured", http.StatusNotFound)
+		return
+	}
+
+	apiKey := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	name, plan, ok := s.quotas.Lookup(apiKey)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	usage, err := s.usage.Usage(r.Context(), quota.AccountID(apiKey), time.Now())
+	if err != nil {
+		log.Printf("Error reading quota usage: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(QuotaResponse{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(QuotaResponse{Remaining: plan.Remaining(name, usage)})
+}
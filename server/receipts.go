@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"temporal-ai-agent/channels"
+	"temporal-ai-agent/workflows"
+
+	"github.com/gorilla/mux"
+)
+
+// ReceiptRequest represents the request body for the /signal/receipt endpoint
+type ReceiptRequest struct {
+	WorkflowID string `json:"workflow_id"`
+	RunID      string `json:"run_id,omitempty"`
+	workflows.ReceiptAck
+}
+
+// ReceiptsResponse represents the response from the /workflow/{id}/receipts endpoint
+type ReceiptsResponse struct {
+	Receipts []workflows.Receipt `json:"receipts"`
+	Error    string              `json:"error,omitempty"`
+}
+
+// handleReceiptSignal handles POST /signal/receipt requests: web clients and channel
+// bridges acknowledging that the agent's messages were delivered or read
+func (s *Server) handleReceiptSignal(w http.ResponseWriter, r *http.Request) {
+	var req ReceiptRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.WorkflowID == "" {
+		http.Error(w, "WorkflowID is required", http.StatusBadRequest)
+		return
+	}
+	if !s.requireOwner(w, r, req.WorkflowID) {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Channel != "" && req.Channel != channels.Web && !slices.Contains(s.channels, req.Channel) {
+		http.Error(w, fmt.Sprintf("channel %s is not configured", req.Channel), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	err := s.temporalClient().SignalWorkflow(ctx, req.WorkflowID, req.RunID, workflows.SignalReceipt, req.ReceiptAck)
+	if err != nil {
+		log.Printf("Error sending receipt signal: %v", err)
+		s.metrics.signalFailed(workflows.SignalReceipt)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(SignalResponse{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SignalResponse{Success: true})
+}
+
+// handleListReceipts handles GET /workflow/{id}/receipts requests
+func (s *Server) handleListReceipts(w http.ResponseWriter, r *http.Request) {
+	if !s.requireOwner(w, r, mux.Vars(r)["id"]) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	value, err := s.temporalClient().QueryWorkflow(ctx, mux.Vars(r)["id"], r.URL.Query().Get("run_id"), workflows.QueryReceipts)
+	var receipts []workflows.Receipt
+	if err == nil {
+		err = value.Get(&receipts)
+	}
+	if err != nil {
+		log.Printf("Error querying receipts: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ReceiptsResponse{Error: err.Error()})
+		return
+	}
+
+	if receipts == nil {
+		receipts = []workflows.Receipt{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ReceiptsResponse{Receipts: receipts})
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"temporal-ai-agent/workflows"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.temporal.io/sdk/client"
+)
+
+// ResearchResponse represents the response from the /research endpoints
+type ResearchResponse struct {
+	WorkflowID string                      `json:"workflow_id,omitempty"`
+	RunID      string                      `json:"run_id,omitempty"`
+	Progress   *workflows.ResearchProgress `json:"progress,omitempty"`
+	Result     *workflows.ResearchReport   `json:"result,omitempty"`
+	Error      string                      `json:"error,omitempty"`
+}
+
+// handleStartResearch handles POST /research requests. The research runs for minutes,
+// so the response returns immediately and progress is polled with GET /research/{id}.
+func (s *Server) handleStartResearch(w http.ResponseWriter, r *http.Request) {
+	var req workflows.ResearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.Question == "" {
+		http.Error(w, "Question is required", http.StatusBadRequest)
+		return
+	}
+	account, ok := s.callerAccount(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	req.Account = account
+
+	options := client.StartWorkflowOptions{
+		ID:        fmt.Sprintf("research-workflow-%d", time.Now().UnixNano()),
+		TaskQueue: s.taskQueue,
+	}
+	if account != "" {
+		// Like conversations, a research belongs to the API key that started it
+		options.Memo = map[string]interface{}{workflows.MemoAccount: account}
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	we, err := s.temporalClient().ExecuteWorkflow(ctx, options, workflows.DeepResearchWorkflow, req)
+	if err != nil {
+		log.Printf("Unable to start research workflow: %v", err)
+		s.metrics.startFailed("DeepResearchWorkflow")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ResearchResponse{Error: err.Error()})
+		return
+	}
+
+	log.Printf("Started research workflow: WorkflowID=%s, RunID=%s", we.GetID(), we.GetRunID())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(ResearchResponse{WorkflowID: we.GetID(), RunID: we.GetRunID()})
+}
+
+// handleGetResearch handles GET /research/{id} requests
+func (s *Server) handleGetResearch(w http.ResponseWriter, r *http.Request) {
+	workflowID := mux.Vars(r)["id"]
+	if !s.requireOwner(w, r, workflowID) {
+		return
+	}
+	runID := r.URL.Query().Get("run_id")
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	response := ResearchResponse{WorkflowID: workflowID, RunID: runID}
+	value, err := s.temporalClient().QueryWorkflow(ctx, workflowID, runID, workflows.QueryResearchProgress)
+	var progress workflows.ResearchProgress
+	if err == nil {
+		err = value.Get(&progress)
+	}
+	if err == nil && progress.Stage == workflows.StageDone {
+		var report workflows.ResearchReport
+		if err = s.temporalClient().GetWorkflow(ctx, workflowID, runID).Get(ctx, &report); err == nil {
+			response.Result = &report
+		}
+	}
+	if err != nil {
+		log.Printf("Error querying research workflow: %v", err)
+		response.Error = err.Error()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	response.Progress = &progress
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+)
+
+// WorkflowResultResponse represents the response from the GET /workflow/{id}/result endpoint
+type WorkflowResultResponse struct {
+	WorkflowID string `json:"workflow_id"`
+	RunID      string `json:"run_id"`
+	// Status is the run's execution status: Running until the conversation ends, then
+	// Completed, Failed, Canceled, Terminated, TimedOut or ContinuedAsNew
+	Status string `json:"status,omitempty"`
+	// Result is the conversation's result once it completed
+	Result string `json:"result,omitempty"`
+	// Error is why the conversation did not complete, or why its result could not be read
+	Error string `json:"error,omitempty"`
+}
+
+// handleWorkflowResult handles GET /workflow/{id}/result requests, which return the result
+// of a conversation started with mode=async without waiting for it
+func (s *Server) handleWorkflowResult(w http.ResponseWriter, r *http.Request) {
+	if !s.requireOwner(w, r, mux.Vars(r)["id"]) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	resp, err := s.temporalClient().DescribeWorkflowExecution(ctx, mux.Vars(r)["id"], r.URL.Query().Get("run_id"))
+	var notFound *serviceerror.NotFound
+	if errors.As(err, &notFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error describing conversation: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(WorkflowResultResponse{Error: err.Error()})
+		return
+	}
+
+	execution := resp.GetWorkflowExecutionInfo()
+	response := WorkflowResultResponse{
+		WorkflowID: execution.GetExecution().GetWorkflowId(),
+		RunID:      execution.GetExecution().GetRunId(),
+		Status:     execution.GetStatus().String(),
+	}
+	// A running conversation has no result yet. Neither has a run that continued as new:
+	// the result is that of the latest run, which requests without run_id describe.
+	switch execution.GetStatus() {
+	case enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING, enumspb.WORKFLOW_EXECUTION_STATUS_CONTINUED_AS_NEW:
+	default:
+		// The run is closed, so this returns right away and does not follow other runs
+		err = s.temporalClient().GetWorkflow(ctx, response.WorkflowID, response.RunID).Get(ctx, &response.Result)
+		if err != nil {
+			response.Error = err.Error()
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
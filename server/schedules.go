@@ -0,0 +1,388 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"temporal-ai-agent/workflows"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+)
+
+// minScheduleInterval is the shortest interval between the runs of a scheduled task
+const minScheduleInterval = time.Minute
+
+// ScheduleRequest represents the request body for the POST /schedules endpoint
+type ScheduleRequest struct {
+	Description string `json:"description"`
+	UserID      string `json:"user_id,omitempty"`
+	// Cron is a cron expression in UTC such as "0 9 * * MON-FRI", and Interval a Go duration
+	// such as "6h"; exactly one of them is required
+	Cron     string `json:"cron,omitempty"`
+	Interval string `json:"interval,omitempty"`
+	// Paused creates the schedule without running it until it is unpaused
+	Paused bool `json:"paused,omitempty"`
+}
+
+// ScheduleInfo describes a scheduled agent task
+type ScheduleInfo struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	UserID      string `json:"user_id,omitempty"`
+	Cron        string `json:"cron,omitempty"`
+	Interval    string `json:"interval,omitempty"`
+	Paused      bool   `json:"paused"`
+	// Note explains the last pause or unpause
+	Note       string         `json:"note,omitempty"`
+	NextRuns   []time.Time    `json:"next_runs,omitempty"`
+	RecentRuns []ScheduledRun `json:"recent_runs,omitempty"`
+
+	// account owns the schedule; it is not shown
+	account string
+}
+
+// ScheduledRun is a run of a scheduled task, scheduled or triggered by hand
+type ScheduledRun struct {
+	WorkflowID string    `json:"workflow_id"`
+	RunID      string    `json:"run_id"`
+	Time       time.Time `json:"time"`
+}
+
+// ScheduleResponse represents the response from the /schedules/{id} endpoints
+type ScheduleResponse struct {
+	*ScheduleInfo
+	Error string `json:"error,omitempty"`
+}
+
+// SchedulesResponse represents the response from the GET /schedules endpoint
+type SchedulesResponse struct {
+	Schedules []ScheduleInfo `json:"schedules"`
+	Error     string         `json:"error,omitempty"`
+}
+
+// handleCreateSchedule handles POST /schedules requests
+func (s *Server) handleCreateSchedule(w http.ResponseWriter, r *http.Request) {
+	var req ScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.Description == "" {
+		http.Error(w, "Description is required", http.StatusBadRequest)
+		return
+	}
+	spec, err := scheduleSpec(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	account, ok := s.callerAccount(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id := workflows.ScheduledTaskPrefix + uuid.NewString()
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	handle, err := s.temporalClient().ScheduleClient().Create(ctx, client.ScheduleOptions{
+		ID:     id,
+		Spec:   spec,
+		Paused: req.Paused,
+		Action: &client.ScheduleWorkflowAction{
+			ID:        id,
+			Workflow:  workflows.ScheduledTaskWorkflow,
+			TaskQueue: s.taskQueue,
+			Args: []interface{}{workflows.ScheduledTask{
+				Description: req.Description,
+				UserID:      req.UserID,
+				Account:     account,
+			}},
+		},
+		Memo: map[string]interface{}{
+			workflows.ScheduleMemoDescription: req.Description,
+			workflows.ScheduleMemoUserID:      req.UserID,
+			workflows.ScheduleMemoCron:        req.Cron,
+			workflows.ScheduleMemoInterval:    req.Interval,
+			workflows.ScheduleMemoAccount:     account,
+		},
+	})
+	var info *ScheduleInfo
+	if err == nil {
+		info, err = describeSchedule(ctx, handle)
+	}
+	if err != nil {
+		log.Printf("Error creating schedule: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ScheduleResponse{Error: err.Error()})
+		return
+	}
+
+	log.Printf("Created schedule %s", id)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ScheduleResponse{ScheduleInfo: info})
+}
+
+// scheduleSpec builds the schedule spec from the request's cron expression or interval
+func scheduleSpec(req ScheduleRequest) (client.ScheduleSpec, error) {
+	switch {
+	case (req.Cron == "") == (req.Interval == ""):
+		return client.ScheduleSpec{}, errors.New("exactly one of cron and interval is required")
+	case req.Cron != "":
+		return client.ScheduleSpec{CronExpressions: []string{req.Cron}}, nil
+	}
+	interval, err := time.ParseDuration(req.Interval)
+	if err != nil {
+		return client.ScheduleSpec{}, errors.New("invalid interval")
+	}
+	if interval < minScheduleInterval {
+		return client.ScheduleSpec{}, fmt.Errorf("interval must be at least %s", minScheduleInterval)
+	}
+	return client.ScheduleSpec{Intervals: []client.ScheduleIntervalSpec{{Every: interval}}}, nil
+}
+
+// handleListSchedules handles GET /schedules requests, listing the schedules of the caller's
+// account. Pass ?user_id= to list the schedules of one user.
+func (s *Server) handleListSchedules(w http.ResponseWriter, r *http.Request) {
+	account, ok := s.callerAccount(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := r.URL.Query().Get("user_id")
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	response := SchedulesResponse{Schedules: []ScheduleInfo{}}
+	iter, err := s.temporalClient().ScheduleClient().List(ctx, client.ScheduleListOptions{})
+	for err == nil && iter.HasNext() {
+		var entry *client.ScheduleListEntry
+		if entry, err = iter.Next(); err != nil {
+			break
+		}
+		if !strings.HasPrefix(entry.ID, workflows.ScheduledTaskPrefix) {
+			continue
+		}
+		info := ScheduleInfo{
+			ID:       entry.ID,
+			Paused:   entry.Paused,
+			Note:     entry.Note,
+			NextRuns: entry.NextActionTimes,
+		}
+		decodeScheduleMemo(&info, entry.Memo)
+		if account != "" && info.account != account {
+			continue
+		}
+		if userID != "" && info.UserID != userID {
+			continue
+		}
+		info.RecentRuns = scheduledRuns(entry.RecentActions)
+		response.Schedules = append(response.Schedules, info)
+	}
+	if err != nil {
+		log.Printf("Error listing schedules: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(SchedulesResponse{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleGetSchedule handles GET /schedules/{id} requests
+func (s *Server) handleGetSchedule(w http.ResponseWriter, r *http.Request) {
+	s.handleSchedule(w, r, "describing", func(ctx context.Context, handle client.ScheduleHandle) error {
+		return nil
+	})
+}
+
+// handlePauseSchedule handles POST /schedules/{id}/pause requests
+func (s *Server) handlePauseSchedule(w http.ResponseWriter, r *http.Request) {
+	s.handleSchedule(w, r, "pausing", func(ctx context.Context, handle client.ScheduleHandle) error {
+		return handle.Pause(ctx, client.SchedulePauseOptions{Note: "Paused through the API"})
+	})
+}
+
+// handleUnpauseSchedule handles POST /schedules/{id}/unpause requests
+func (s *Server) handleUnpauseSchedule(w http.ResponseWriter, r *http.Request) {
+	s.handleSchedule(w, r, "unpausing", func(ctx context.Context, handle client.ScheduleHandle) error {
+		return handle.Unpause(ctx, client.ScheduleUnpauseOptions{Note: "Unpaused through the API"})
+	})
+}
+
+// handleTriggerSchedule handles POST /schedules/{id}/trigger requests, running the task
+// now in addition to its scheduled runs
+func (s *Server) handleTriggerSchedule(w http.ResponseWriter, r *http.Request) {
+	s.handleSchedule(w, r, "triggering", func(ctx context.Context, handle client.ScheduleHandle) error {
+		return handle.Trigger(ctx, client.ScheduleTriggerOptions{})
+	})
+}
+
+// handleSchedule applies an action to the schedule named in the path and responds with the
+// schedule's description. Only the schedules running agent tasks can be managed, by the
+// account that created them.
+func (s *Server) handleSchedule(w http.ResponseWriter, r *http.Request, verb string, action func(context.Context, client.ScheduleHandle) error) {
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	handle, ok := s.ownedSchedule(ctx, w, r)
+	if !ok {
+		return
+	}
+	err := action(ctx, handle)
+	var info *ScheduleInfo
+	if err == nil {
+		info, err = describeSchedule(ctx, handle)
+	}
+	var notFound *serviceerror.NotFound
+	if errors.As(err, &notFound) {
+		http.Error(w, "Schedule not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error %s schedule: %v", verb, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ScheduleResponse{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ScheduleResponse{ScheduleInfo: info})
+}
+
+// handleDeleteSchedule handles DELETE /schedules/{id} requests. Runs already started are
+// left to finish.
+func (s *Server) handleDeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	handle, ok := s.ownedSchedule(ctx, w, r)
+	if !ok {
+		return
+	}
+	id := handle.GetID()
+	err := handle.Delete(ctx)
+	var notFound *serviceerror.NotFound
+	if errors.As(err, &notFound) {
+		http.Error(w, "Schedule not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error deleting schedule: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(SignalResponse{Error: err.Error()})
+		return
+	}
+
+	log.Printf("Deleted schedule %s", id)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SignalResponse{Success: true})
+}
+
+// ownedSchedule returns the handle of the schedule named in the path, writing the error of
+// a request addressing a schedule that does not run agent tasks or that another account
+// created. Like conversations, other accounts' schedules are reported as not found.
+func (s *Server) ownedSchedule(ctx context.Context, w http.ResponseWriter, r *http.Request) (client.ScheduleHandle, bool) {
+	id := mux.Vars(r)["id"]
+	if !strings.HasPrefix(id, workflows.ScheduledTaskPrefix) {
+		http.Error(w, "Schedule not found", http.StatusNotFound)
+		return nil, false
+	}
+	account, ok := s.callerAccount(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	handle := s.temporalClient().ScheduleClient().GetHandle(ctx, id)
+	if account == "" {
+		return handle, true
+	}
+	info, err := describeSchedule(ctx, handle)
+	var notFound *serviceerror.NotFound
+	if errors.As(err, &notFound) || (err == nil && info.account != account) {
+		http.Error(w, "Schedule not found", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("Error describing schedule %s: %v", id, err)
+		http.Error(w, "Unable to check the schedule's owner", http.StatusInternalServerError)
+		return nil, false
+	}
+	return handle, true
+}
+
+// describeSchedule fetches the current description of a schedule
+func describeSchedule(ctx context.Context, handle client.ScheduleHandle) (*ScheduleInfo, error) {
+	desc, err := handle.Describe(ctx)
+	if err != nil {
+		return nil, err
+	}
+	info := &ScheduleInfo{
+		ID:         handle.GetID(),
+		NextRuns:   desc.Info.NextActionTimes,
+		RecentRuns: scheduledRuns(desc.Info.RecentActions),
+	}
+	if state := desc.Schedule.State; state != nil {
+		info.Paused = state.Paused
+		info.Note = state.Note
+	}
+	decodeScheduleMemo(info, desc.Memo)
+	return info, nil
+}
+
+// scheduledRuns lists the workflows started by a schedule's recent actions
+func scheduledRuns(actions []client.ScheduleActionResult) []ScheduledRun {
+	var runs []ScheduledRun
+	for _, action := range actions {
+		if action.StartWorkflowResult == nil {
+			continue
+		}
+		runs = append(runs, ScheduledRun{
+			WorkflowID: action.StartWorkflowResult.WorkflowID,
+			RunID:      action.StartWorkflowResult.FirstExecutionRunID,
+			Time:       action.ActualTime,
+		})
+	}
+	return runs
+}
+
+// decodeScheduleMemo fills the fields of info kept in the schedule's memo. The server
+// translates cron expressions into calendars, so the memo keeps the spec as written.
+func decodeScheduleMemo(info *ScheduleInfo, memo *commonpb.Memo) {
+	fields := map[string]*string{
+		workflows.ScheduleMemoDescription: &info.Description,
+		workflows.ScheduleMemoUserID:      &info.UserID,
+		workflows.ScheduleMemoCron:        &info.Cron,
+		workflows.ScheduleMemoInterval:    &info.Interval,
+		workflows.ScheduleMemoAccount:     &info.account,
+	}
+	for key, value := range fields {
+		payload, ok := memo.GetFields()[key]
+		if !ok {
+			continue
+		}
+		if err := converter.GetDefaultDataConverter().FromPayload(payload, value); err != nil {
+			log.Printf("Error decoding %s memo for schedule %s: %v", key, info.ID, err)
+		}
+	}
+}
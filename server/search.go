@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"temporal-ai-agent/search"
+)
+
+// maxSearchResults caps the limit parameter of the search endpoint
+const maxSearchResults = 100
+
+// SearchResponse represents the response from the /conversations/search endpoint
+type SearchResponse struct {
+	Results []search.Hit `json:"results"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// handleSearchConversations handles GET /conversations/search requests, searching the
+// conversations of the caller's account
+func (s *Server) handleSearchConversations(w http.ResponseWriter, r *http.Request) {
+	if s.search == nil {
+		http.Error(w, "Conversation search is not configured", http.StatusNotFound)
+		return
+	}
+
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		http.Error(w, "q is required", http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultPageSize
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > maxSearchResults {
+			http.Error(w, "limit must be between 1 and 100", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	account, ok := s.callerAccount(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	hits, err := s.search.Search(ctx, account, query, limit)
+	if err != nil {
+		log.Printf("Error searching conversations: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(SearchResponse{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SearchResponse{Results: hits})
+}
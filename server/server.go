@@ -0,0 +1,839 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"sync/atomic"
+	"temporal-ai-agent/blobstore"
+	"temporal-ai-agent/config"
+	"temporal-ai-agent/deadletter"
+	"temporal-ai-agent/evals"
+	"temporal-ai-agent/experiments"
+	"temporal-ai-agent/fewshot"
+	"temporal-ai-agent/goals"
+	"temporal-ai-agent/metrics"
+	"temporal-ai-agent/models"
+	"temporal-ai-agent/personas"
+	"temporal-ai-agent/quota"
+	"temporal-ai-agent/search"
+	"temporal-ai-agent/secrets"
+	"temporal-ai-agent/shadow"
+	"temporal-ai-agent/shedding"
+	"temporal-ai-agent/store"
+	"temporal-ai-agent/streaming"
+	"temporal-ai-agent/templates"
+	"temporal-ai-agent/tracing"
+	"temporal-ai-agent/workflows"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+)
+
+// ChatRequest represents the request body for the /start-workflow endpoint
+type ChatRequest struct {
+	Message string `json:"message"`
+	// UserID selects the user's ongoing conversation when sticky sessions are enabled and
+	// counts the conversation against the user's limit
+	UserID string `json:"user_id,omitempty"`
+	// WorkflowID, the ID policies and the execution timeout override the server defaults
+	WorkflowID       string `json:"workflow_id,omitempty"`
+	IDReusePolicy    string `json:"id_reuse_policy,omitempty"`
+	IDConflictPolicy string `json:"id_conflict_policy,omitempty"`
+	// ExecutionTimeout is a Go duration such as "2h"
+	ExecutionTimeout string `json:"execution_timeout,omitempty"`
+	// Goal is one of the goals listed by /goals; empty uses the default goal
+	Goal             string `json:"goal,omitempty"`
+	Persona          string `json:"persona,omitempty"`
+	CoalesceMessages bool   `json:"coalesce_messages,omitempty"`
+	// SuggestReplies has the agent suggest a few replies after each answer
+	SuggestReplies bool `json:"suggest_replies,omitempty"`
+	// ClientApp, Channel and Locale describe where the conversation comes from (e.g. "ios",
+	// "whatsapp", "pt-BR"); they are stored in the workflow memo for dashboards
+	ClientApp string `json:"client_app,omitempty"`
+	Channel   string `json:"channel,omitempty"`
+	Locale    string `json:"locale,omitempty"`
+	// Context names the business records the conversation is about, e.g. {"account_id":
+	// "A-1042"}; the agent passes them to tools and fills them into its prompt
+	Context map[string]string `json:"context,omitempty"`
+	// Template is one of the templates listed by /templates; the agent opens the
+	// conversation with its greeting, so Message is optional
+	Template string `json:"template,omitempty"`
+	// Model and SystemPrompt replace the deployment's model and prompt template for this
+	// conversation; the model must be one of ALLOWED_MODELS
+	Model        string `json:"model,omitempty"`
+	SystemPrompt string `json:"system_prompt,omitempty"`
+	// Temperature and MaxTokens tune the completions of the conversation
+	Temperature *float64 `json:"temperature,omitempty"`
+	MaxTokens   int      `json:"max_tokens,omitempty"`
+	// Metadata are free-form labels kept with the conversation and its transcript
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// WaitForReply sends Message as a user_prompt update with the start and returns the
+	// agent's reply once the turn is done, instead of the conversation's result
+	WaitForReply bool `json:"wait_for_reply,omitempty"`
+}
+
+// ChatResponse represents the response from the /start-workflow endpoint
+type ChatResponse struct {
+	WorkflowID string `json:"workflow_id"`
+	RunID      string `json:"run_id"`
+	Result     string `json:"result,omitempty"`
+	// Reply and QuickReplies answer the first message of requests waiting for the reply
+	Reply        string   `json:"reply,omitempty"`
+	QuickReplies []string `json:"quick_replies,omitempty"`
+	Error        string   `json:"error,omitempty"`
+	// ActiveConversations are the user's running conversations when the per-user limit is reached
+	ActiveConversations []ConversationSummary `json:"active_conversations,omitempty"`
+}
+
+// SignalRequest represents the request body for signal endpoints
+type SignalRequest struct {
+	WorkflowID string `json:"workflow_id"`
+	RunID      string `json:"run_id,omitempty"`
+	Message    string `json:"message"`
+	// MessageID lets the workflow drop retried user prompts it already processed
+	MessageID string `json:"message_id,omitempty"`
+	// Seq orders user prompt signals; see workflows.UserPrompt
+	Seq int `json:"seq,omitempty"`
+	// Attachments are documents whose text the agent reads along with the message
+	Attachments []workflows.Attachment `json:"attachments,omitempty"`
+}
+
+// ConfirmRequest represents the request body for the /signal/confirm endpoint
+type ConfirmRequest struct {
+	WorkflowID string `json:"workflow_id"`
+	RunID      string `json:"run_id,omitempty"`
+	workflows.ConfirmRequest
+}
+
+// SignalResponse represents the response from signal endpoints
+type SignalResponse struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Timeouts of the Temporal client calls made while serving a request. Updates wait for the
+// agent to finish a turn, so they get longer.
+const (
+	clientCallTimeout = 10 * time.Second
+	updateTimeout     = 2 * time.Minute
+)
+
+// workflowTypeName is the registered name of the conversation workflow
+const workflowTypeName = "AgentGoalWorkflow"
+
+// Options configures the HTTP server
+type Options struct {
+	TaskQueue   string
+	Experiments []experiments.Experiment
+	// PremiumTaskQueue runs the conversations of premium plans; empty runs them on TaskQueue
+	PremiumTaskQueue string
+	// Examples is the few-shot example store managed through the admin API
+	Examples fewshot.Store
+	// AdminAPIKey protects the admin endpoints; they are disabled when it is empty
+	AdminAPIKey string
+	// OperatorAPIKey protects the operator console endpoints; they are disabled when it is empty
+	OperatorAPIKey string
+	// IDReusePolicy and IDConflictPolicy are the default workflow ID policies by shorthand name
+	IDReusePolicy    string
+	IDConflictPolicy string
+	// ExecutionTimeout is the default conversation execution timeout; 0 means unlimited
+	ExecutionTimeout time.Duration
+	// StickySessions derives conversation IDs from user IDs so each user keeps one conversation
+	StickySessions bool
+	// MaxConversationsPerUser caps the running conversations of a user; 0 means unlimited
+	MaxConversationsPerUser int
+	// Personas validates the persona requested when starting a conversation
+	Personas personas.Catalog
+	// Goals validates the goal requested when starting a conversation and is listed by /goals
+	Goals *goals.Catalog
+	// Templates validates the template requested when starting a conversation and is listed by /templates
+	Templates templates.Catalog
+	// FallbackMessage is the reply conversations send when the LLM is unavailable; empty uses the built-in one
+	FallbackMessage string
+	// TurnLatencyBudget is how long conversations spend on the completions of a turn before
+	// TurnFallbackModel answers; zero disables the budget
+	TurnLatencyBudget time.Duration
+	TurnFallbackModel string
+	// ConfirmationTimeout is how long conversations wait for the user to confirm a tool call
+	// before giving it up; zero waits indefinitely
+	ConfirmationTimeout time.Duration
+	// ChatIdleTimeout closes conversations nothing happened in for that long; zero keeps
+	// them open until ended
+	ChatIdleTimeout time.Duration
+	// ReceiptRenotifyAfter is how long important agent messages may stay unread before
+	// conversations send them again; zero disables reminders
+	ReceiptRenotifyAfter time.Duration
+	// Search serves full-text search over transcripts; nil disables the endpoint
+	Search search.Index
+	// Quotas limit the usage of API keys; no plans disables quotas
+	Quotas quota.Plans
+	// Usage is the quota usage store shared with the worker; required when Quotas are enabled
+	Usage quota.Store
+	// Evaluations serves the aggregated conversation scores; nil disables the endpoint
+	Evaluations evals.Store
+	// Streams serves the conversation streams published by the worker; nil streams progress
+	// events by querying the workflow every StreamPollInterval instead
+	Streams            *streaming.Bridge
+	StreamPollInterval time.Duration
+	// Conversations serves the conversations persisted by the worker; nil disables the endpoint
+	Conversations store.Store
+	// Artifacts serves the files written by the analysis goal's code; nil disables the endpoint
+	Artifacts blobstore.Store
+	// DeadLetters serves the failed turns recorded by the worker; nil disables the endpoints
+	DeadLetters deadletter.Store
+	// Shadows serves the turns answered by shadow experiments; nil disables the endpoint
+	Shadows shadow.Store
+	// TenantKeys stores the LLM API keys tenants bring; nil disables the /llm-key endpoints
+	TenantKeys secrets.Store
+	// AllowedModels are the models clients may pick for their conversations; empty accepts any
+	AllowedModels []string
+	// Metrics is the registry /metrics serves, where the server records its request and
+	// error metrics; share it with the Temporal client's metrics.Temporal handler to serve
+	// the SDK's metrics too. Nil uses a registry of the server's own.
+	Metrics *prometheus.Registry
+	// Shedder sheds starts and updates when the server is overloaded; nil admits them all
+	Shedder *shedding.Shedder
+	// Channels are the channels whose messages /channels/{channel}/messages accepts, as
+	// configured with adapters in the worker
+	Channels []string
+	// ChannelSecrets are the secrets the channels' bridges sign their messages with, by
+	// channel; messages of a channel without one are rejected
+	ChannelSecrets map[string]string
+}
+
+// Server holds the HTTP server dependencies
+type Server struct {
+	// current holds the Temporal client; it is replaced when the connection fails over
+	current          atomic.Pointer[client.Client]
+	taskQueue        string
+	premiumTaskQueue string
+	experiments      []experiments.Experiment
+	examples         fewshot.Store
+	adminAPIKey      string
+	operatorAPIKey   string
+	idReusePolicy    string
+	idConflictPolicy string
+	executionTimeout time.Duration
+	stickySessions   bool
+	maxPerUser       int
+	personas         personas.Catalog
+	goals            *goals.Catalog
+	templates        templates.Catalog
+	fallbackMessage  string
+	latencyBudget    time.Duration
+	fallbackModel    string
+	confirmTimeout   time.Duration
+	idleTimeout      time.Duration
+	renotifyAfter    time.Duration
+	search           search.Index
+	quotas           quota.Plans
+	usage            quota.Store
+	evaluations      evals.Store
+	streams          *streaming.Bridge
+	pollInterval     time.Duration
+	conversations    store.Store
+	artifacts        blobstore.Store
+	deadLetters      deadletter.Store
+	shadows          shadow.Store
+	tenantKeys       secrets.Store
+	allowedModels    []string
+	channels         []string
+	channelSecrets   map[string]string
+	registry         *prometheus.Registry
+	metrics          *httpMetrics
+	shedder          *shedding.Shedder
+}
+
+// New creates a Server that starts workflows on the configured task queue
+func New(c client.Client, opts Options) *Server {
+	s := &Server{
+		taskQueue:        opts.TaskQueue,
+		premiumTaskQueue: opts.PremiumTaskQueue,
+		experiments:      opts.Experiments,
+		examples:         opts.Examples,
+		adminAPIKey:      opts.AdminAPIKey,
+		operatorAPIKey:   opts.OperatorAPIKey,
+		idReusePolicy:    opts.IDReusePolicy,
+		idConflictPolicy: opts.IDConflictPolicy,
+		executionTimeout: opts.ExecutionTimeout,
+		stickySessions:   opts.StickySessions,
+		maxPerUser:       opts.MaxConversationsPerUser,
+		personas:         opts.Personas,
+		goals:            opts.Goals,
+		templates:        opts.Templates,
+		fallbackMessage:  opts.FallbackMessage,
+		latencyBudget:    opts.TurnLatencyBudget,
+		fallbackModel:    opts.TurnFallbackModel,
+		confirmTimeout:   opts.ConfirmationTimeout,
+		idleTimeout:      opts.ChatIdleTimeout,
+		renotifyAfter:    opts.ReceiptRenotifyAfter,
+		search:           opts.Search,
+		quotas:           opts.Quotas,
+		usage:            opts.Usage,
+		evaluations:      opts.Evaluations,
+		streams:          opts.Streams,
+		pollInterval:     cmp.Or(opts.StreamPollInterval, time.Second),
+		conversations:    opts.Conversations,
+		artifacts:        opts.Artifacts,
+		deadLetters:      opts.DeadLetters,
+		shadows:          opts.Shadows,
+		tenantKeys:       opts.TenantKeys,
+		allowedModels:    opts.AllowedModels,
+		channels:         opts.Channels,
+		channelSecrets:   opts.ChannelSecrets,
+		registry:         opts.Metrics,
+		shedder:          opts.Shedder,
+	}
+	if s.registry == nil {
+		s.registry = metrics.NewRegistry()
+	}
+	s.metrics = newHTTPMetrics(s.registry, s.shedder)
+	s.SetClient(c)
+	return s
+}
+
+// SetClient replaces the Temporal client, e.g. after a failover to another endpoint
+func (s *Server) SetClient(c client.Client) {
+	s.current.Store(&c)
+}
+
+// temporalClient returns the current Temporal client
+func (s *Server) temporalClient() client.Client {
+	return *s.current.Load()
+}
+
+// Router returns the HTTP routes served by the API
+func (s *Server) Router() *mux.Router {
+	r := mux.NewRouter()
+	r.Use(tracing.Middleware, s.metrics.instrument)
+	r.Handle("/start-workflow", s.requireQuota(s.shed(http.HandlerFunc(s.handleStartWorkflow)))).Methods("POST")
+	r.Handle("/signal/user-prompt", s.requireQuota(http.HandlerFunc(s.handleUserPromptSignal))).Methods("POST")
+	r.Handle("/signal-with-start/user-prompt", s.requireQuota(s.shed(http.HandlerFunc(s.handleSignalWithStart)))).Methods("POST")
+	r.Handle("/channels/{channel}/messages", s.requireQuota(s.shed(http.HandlerFunc(s.handleChannelMessage)))).Methods("POST")
+	r.HandleFunc("/signal/confirm", s.handleConfirmSignal).Methods("POST")
+	r.HandleFunc("/signal/end-chat", s.handleEndChatSignal).Methods("POST")
+	r.HandleFunc("/signal/feedback", s.handleFeedbackSignal).Methods("POST")
+	r.HandleFunc("/signal/receipt", s.handleReceiptSignal).Methods("POST")
+	r.Handle("/update/user-prompt", s.requireQuota(s.shed(http.HandlerFunc(s.handleUserPromptUpdate)))).Methods("POST")
+	r.Handle("/update/edit-message", s.requireQuota(s.shed(http.HandlerFunc(s.handleEditMessage)))).Methods("POST")
+	r.Handle("/update/reprocess-turn", s.requireQuota(s.shed(http.HandlerFunc(s.handleReprocessTurn)))).Methods("POST")
+	r.HandleFunc("/update/background-task", s.handleStartBackgroundTask).Methods("POST")
+	r.HandleFunc("/update/handoff", s.handleRequestHandoff).Methods("POST")
+	r.HandleFunc("/update/reaction", s.handleReaction).Methods("POST")
+	r.HandleFunc("/background-tasks/{id}/cancel", s.handleCancelBackgroundTask).Methods("POST")
+	// Async tool calls are completed by back-office systems, which hold the admin API key
+	r.Handle("/activities/complete", s.requireAdmin(http.HandlerFunc(s.handleCompleteActivity))).Methods("POST")
+	r.HandleFunc("/conversations", s.handleListConversations).Methods("GET")
+	r.HandleFunc("/conversations/search", s.handleSearchConversations).Methods("GET")
+	r.HandleFunc("/conversations/{id}", s.handleGetConversation).Methods("GET")
+	r.HandleFunc("/conversations/{id}", s.handleUpdatePreferences).Methods("PATCH")
+	r.HandleFunc("/workflow/{id}", s.handleDescribeConversation).Methods("GET")
+	r.HandleFunc("/workflow/{id}/events", s.handleEvents).Methods("GET")
+	r.HandleFunc("/workflow/{id}/stream", s.handleStream).Methods("GET")
+	r.HandleFunc("/workflow/{id}/result", s.handleWorkflowResult).Methods("GET")
+	r.HandleFunc("/workflow/{id}/export", s.handleExport).Methods("GET")
+	r.HandleFunc("/workflow/{id}/receipts", s.handleListReceipts).Methods("GET")
+	r.HandleFunc("/workflow/{id}/background-tasks", s.handleListBackgroundTasks).Methods("GET")
+	r.HandleFunc("/workflow/{id}/pending-confirmation", s.handlePendingConfirmation).Methods("GET")
+	r.HandleFunc("/schedules", s.handleCreateSchedule).Methods("POST")
+	r.HandleFunc("/schedules", s.handleListSchedules).Methods("GET")
+	r.HandleFunc("/schedules/{id}", s.handleGetSchedule).Methods("GET")
+	r.HandleFunc("/schedules/{id}", s.handleDeleteSchedule).Methods("DELETE")
+	r.HandleFunc("/schedules/{id}/pause", s.handlePauseSchedule).Methods("POST")
+	r.HandleFunc("/schedules/{id}/unpause", s.handleUnpauseSchedule).Methods("POST")
+	r.HandleFunc("/schedules/{id}/trigger", s.handleTriggerSchedule).Methods("POST")
+	r.HandleFunc("/research", s.handleStartResearch).Methods("POST")
+	r.HandleFunc("/research/{id}", s.handleGetResearch).Methods("GET")
+	// Experiment and evaluation results cover every account's conversations
+	r.Handle("/experiments/{name}/metrics", s.requireAdmin(http.HandlerFunc(s.handleExperimentMetrics))).Methods("GET")
+	r.Handle("/experiments/{name}/shadow", s.requireAdmin(http.HandlerFunc(s.handleShadowResults))).Methods("GET")
+	r.HandleFunc("/quota", s.handleGetQuota).Methods("GET")
+	r.Handle("/llm-key", s.requireQuota(http.HandlerFunc(s.handlePutTenantKey))).Methods("PUT")
+	r.Handle("/llm-key", s.requireQuota(http.HandlerFunc(s.handleDeleteTenantKey))).Methods("DELETE")
+	r.Handle("/evals", s.requireAdmin(http.HandlerFunc(s.handleEvals))).Methods("GET")
+	r.HandleFunc("/goals", s.handleListGoals).Methods("GET")
+	r.HandleFunc("/templates", s.handleListTemplates).Methods("GET")
+	// The debug query shows every namespace's passages, so it takes the admin API key
+	r.Handle("/knowledge/debug-query", s.requireAdmin(http.HandlerFunc(s.handleDebugQuery))).Methods("POST")
+	r.HandleFunc("/artifacts/{key}", s.handleGetArtifact).Methods("GET")
+	r.HandleFunc("/health", s.handleHealth).Methods("GET")
+	r.Handle("/metrics", metrics.Handler(s.registry)).Methods("GET")
+
+	admin := r.PathPrefix("/admin").Subrouter()
+	admin.Use(s.requireAdmin)
+	admin.HandleFunc("/examples", s.handleListExamples).Methods("GET")
+	admin.HandleFunc("/examples", s.handleAddExample).Methods("POST")
+	admin.HandleFunc("/examples/{id}", s.handleRemoveExample).Methods("DELETE")
+	admin.HandleFunc("/knowledge/crawl", s.handleStartCrawl).Methods("POST")
+	admin.HandleFunc("/knowledge/crawl/{id}", s.handleGetCrawl).Methods("GET")
+	admin.HandleFunc("/dead-letters", s.handleListDeadLetters).Methods("GET")
+	admin.HandleFunc("/dead-letters/{id}", s.handleGetDeadLetter).Methods("GET")
+	admin.HandleFunc("/dead-letters/{id}/retry", s.handleRetryDeadLetter).Methods("POST")
+
+	ops := r.PathPrefix("/operator").Subrouter()
+	ops.Use(s.requireOperator)
+	ops.HandleFunc("/handoffs", s.handleListHandoffs).Methods("GET")
+	ops.HandleFunc("/handoffs/{id}/claim", s.handleClaimHandoff).Methods("POST")
+	ops.HandleFunc("/handoffs/{id}/messages", s.handleOperatorMessage).Methods("POST")
+	ops.HandleFunc("/handoffs/{id}/release", s.handleReleaseHandoff).Methods("POST")
+	ops.HandleFunc("/conversations/{id}/annotations", s.handleListAnnotations).Methods("GET")
+	ops.HandleFunc("/conversations/{id}/annotations", s.handleAddAnnotation).Methods("POST")
+	return r
+}
+
+// Modes of /start-workflow: sync waits for the conversation to end and returns its
+// result, async returns the workflow and run IDs as soon as it starts
+const (
+	startModeSync  = "sync"
+	startModeAsync = "async"
+)
+
+// handleStartWorkflow handles POST /start-workflow requests
+func (s *Server) handleStartWorkflow(w http.ResponseWriter, r *http.Request) {
+	var req ChatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.Message == "" && req.Template == "" {
+		http.Error(w, "Message is required", http.StatusBadRequest)
+		return
+	}
+	mode := r.URL.Query().Get("mode")
+	if mode != "" && mode != startModeAsync && mode != startModeSync {
+		http.Error(w, fmt.Sprintf("mode must be %s or %s", startModeSync, startModeAsync), http.StatusBadRequest)
+		return
+	}
+	if err := s.validateChatRequest(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Start workflow
+	options, err := s.startOptions(r, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sticky := s.stickySessions && req.UserID != ""
+	// A start may join or replace a running conversation, which only its owner can do
+	if (req.WorkflowID != "" || sticky) && !s.requireOwner(w, r, options.ID) {
+		return
+	}
+
+	// A sticky request joins the user's conversation instead of starting another one
+	if !sticky && s.overUserLimit(w, r, req.UserID, "") {
+		return
+	}
+
+	if req.WaitForReply && req.Message != "" {
+		s.startWithPrompt(w, r, options, s.agentInput(r, req, options.ID))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	input := s.agentInput(r, req, options.ID)
+	var we client.WorkflowRun
+	if sticky && req.Message != "" {
+		// The user's conversation may already be running, and a running conversation ignores
+		// the input of a start it joins, so the message goes as a signal. A new conversation
+		// answers it once set up, as with /signal-with-start/user-prompt.
+		input.Message = ""
+		we, err = s.temporalClient().SignalWithStartWorkflow(ctx, options.ID, workflows.SignalUserPrompt,
+			workflows.UserPrompt{Message: req.Message}, options, workflows.AgentGoalWorkflow, input)
+	} else {
+		we, err = s.temporalClient().ExecuteWorkflow(ctx, options, workflows.AgentGoalWorkflow, input)
+	}
+	if err != nil {
+		log.Printf("Unable to execute workflow: %v", err)
+		s.metrics.startFailed(workflowTypeName)
+		response := ChatResponse{
+			Error: err.Error(),
+		}
+		status := http.StatusInternalServerError
+		var alreadyStarted *serviceerror.WorkflowExecutionAlreadyStarted
+		if errors.As(err, &alreadyStarted) {
+			status = http.StatusConflict
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	log.Printf("Started workflow: WorkflowID=%s, RunID=%s", we.GetID(), we.GetRunID())
+
+	// A sticky conversation may have been running for days, so return its IDs right away;
+	// async clients poll /workflow/{id}/result instead of holding the request open
+	if sticky || mode == startModeAsync {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ChatResponse{WorkflowID: we.GetID(), RunID: we.GetRunID()})
+		return
+	}
+
+	// Get workflow result
+	var result string
+	// The conversation can run for as long as the user chats, so this waits without a
+	// deadline until the client goes away
+	err = we.Get(r.Context(), &result)
+	if err != nil {
+		log.Printf("Unable to get workflow result: %v", err)
+		response := ChatResponse{
+			WorkflowID: we.GetID(),
+			RunID:      we.GetRunID(),
+			Error:      err.Error(),
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	// Return successful response
+	response := ChatResponse{
+		WorkflowID: we.GetID(),
+		RunID:      we.GetRunID(),
+		Result:     result,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleUserPromptSignal handles POST /signal/user-prompt requests
+func (s *Server) handleUserPromptSignal(w http.ResponseWriter, r *http.Request) {
+	var req SignalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.WorkflowID == "" {
+		http.Error(w, "WorkflowID is required", http.StatusBadRequest)
+		return
+	}
+	if !s.requireOwner(w, r, req.WorkflowID) {
+		return
+	}
+
+	if req.Seq < 0 {
+		http.Error(w, "Seq must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	prompt := workflows.UserPrompt{Message: req.Message, MessageID: req.MessageID, Seq: req.Seq, Attachments: req.Attachments}
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	err := s.temporalClient().SignalWorkflow(ctx, req.WorkflowID, req.RunID, workflows.SignalUserPrompt, prompt)
+	if err != nil {
+		log.Printf("Error sending user_prompt signal: %v", err)
+		s.metrics.signalFailed(workflows.SignalUserPrompt)
+		response := SignalResponse{
+			Success: false,
+			Error:   err.Error(),
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	response := SignalResponse{Success: true}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleConfirmSignal handles POST /signal/confirm requests
+func (s *Server) handleConfirmSignal(w http.ResponseWriter, r *http.Request) {
+	var req ConfirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.WorkflowID == "" {
+		http.Error(w, "WorkflowID is required", http.StatusBadRequest)
+		return
+	}
+	if !s.requireOwner(w, r, req.WorkflowID) {
+		return
+	}
+
+	if err := req.ConfirmRequest.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	// Answers are checked against the pending call, so a stale approval card cannot approve
+	// another call and a missing phrase is reported to the caller; the workflow checks again
+	// when the signal arrives
+	value, err := s.temporalClient().QueryWorkflow(ctx, req.WorkflowID, req.RunID, workflows.QueryPendingConfirmation)
+	var pending *workflows.PendingConfirmation
+	if err == nil {
+		err = value.Get(&pending)
+	}
+	if err == nil {
+		status, reason := 0, ""
+		switch {
+		case pending == nil:
+			status, reason = http.StatusConflict, "no tool call is awaiting confirmation"
+		case req.CallID != "" && req.CallID != pending.CallID:
+			status, reason = http.StatusConflict, fmt.Sprintf("tool call %s is not awaiting confirmation", req.CallID)
+		default:
+			if err := pending.Accepts(req.ConfirmRequest); err != nil {
+				status, reason = http.StatusBadRequest, err.Error()
+			}
+		}
+		if status != 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(SignalResponse{Error: reason})
+			return
+		}
+	}
+
+	err = s.temporalClient().SignalWorkflow(ctx, req.WorkflowID, req.RunID, workflows.SignalConfirm, req.ConfirmRequest)
+	if err != nil {
+		log.Printf("Error sending confirm signal: %v", err)
+		s.metrics.signalFailed(workflows.SignalConfirm)
+		response := SignalResponse{
+			Success: false,
+			Error:   err.Error(),
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	response := SignalResponse{Success: true}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleEndChatSignal handles POST /signal/end-chat requests
+func (s *Server) handleEndChatSignal(w http.ResponseWriter, r *http.Request) {
+	var req SignalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.WorkflowID == "" {
+		http.Error(w, "WorkflowID is required", http.StatusBadRequest)
+		return
+	}
+	if !s.requireOwner(w, r, req.WorkflowID) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	err := s.temporalClient().SignalWorkflow(ctx, req.WorkflowID, req.RunID, "end_chat", req.Message)
+	if err != nil {
+		log.Printf("Error sending end_chat signal: %v", err)
+		s.metrics.signalFailed("end_chat")
+		response := SignalResponse{
+			Success: false,
+			Error:   err.Error(),
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	response := SignalResponse{Success: true}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleHealth handles GET /health requests
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "healthy"})
+}
+
+// validateChatRequest checks the template, goal, persona, model, sampling settings,
+// metadata and context a conversation is started with
+func (s *Server) validateChatRequest(req ChatRequest) error {
+	if req.Template != "" {
+		if _, err := s.templates.Find(req.Template); err != nil {
+			return err
+		}
+	}
+	if req.Goal != "" {
+		if _, err := s.goals.Find(req.Goal); err != nil {
+			return err
+		}
+	}
+	if req.Persona != "" {
+		if _, err := s.personas.Resolve(req.Persona, ""); err != nil {
+			return err
+		}
+	}
+	if req.Model != "" && len(s.allowedModels) > 0 && !slices.Contains(s.allowedModels, req.Model) {
+		return fmt.Errorf("model %q is not allowed", req.Model)
+	}
+	if err := models.ValidateSampling(req.Temperature, req.MaxTokens); err != nil {
+		return err
+	}
+	if err := models.ValidateMetadata(req.Metadata); err != nil {
+		return err
+	}
+	return workflows.ValidateContext(req.Context)
+}
+
+// overUserLimit writes the error of a request starting a conversation for a user who
+// already has MAX_CONVERSATIONS_PER_USER running, unless one of them is workflowID, which
+// the request joins. It reports whether the request was rejected.
+func (s *Server) overUserLimit(w http.ResponseWriter, r *http.Request, userID, workflowID string) bool {
+	if s.maxPerUser <= 0 || userID == "" {
+		return false
+	}
+	active, err := s.runningConversations(r.Context(), userID, s.maxPerUser)
+	if err != nil {
+		log.Printf("Error counting conversations of user %s: %v", userID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ChatResponse{Error: err.Error()})
+		return true
+	}
+	joined := slices.ContainsFunc(active, func(c ConversationSummary) bool { return c.WorkflowID == workflowID })
+	if len(active) < s.maxPerUser || joined {
+		return false
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusTooManyRequests)
+	json.NewEncoder(w).Encode(ChatResponse{
+		Error:               fmt.Sprintf("user already has %d running conversations", len(active)),
+		ActiveConversations: active,
+	})
+	return true
+}
+
+// agentInput returns the input of the conversation with the given workflow ID started by
+// the request
+func (s *Server) agentInput(r *http.Request, req ChatRequest, workflowID string) workflows.ConversationInput {
+	return workflows.ConversationInput{AgentInput: models.AgentInput{
+		Message:          req.Message,
+		UserID:           req.UserID,
+		ConversationID:   workflowID,
+		SystemPrompt:     req.SystemPrompt,
+		Model:            req.Model,
+		Temperature:      req.Temperature,
+		MaxTokens:        req.MaxTokens,
+		Metadata:         req.Metadata,
+		Goal:             req.Goal,
+		Persona:          req.Persona,
+		CoalesceMessages: req.CoalesceMessages,
+		FallbackMessage:  s.fallbackMessage,
+		LatencyBudget:    s.latencyBudget,
+		FallbackModel:    s.fallbackModel,
+		ConfirmTimeout:   s.confirmTimeout,
+		IdleTimeout:      s.idleTimeout,
+		RenotifyAfter:    s.renotifyAfter,
+		Account:          quotaAccount(r),
+		StreamEvents:     s.streams != nil,
+		Context:          req.Context,
+		Template:         req.Template,
+		SuggestReplies:   req.SuggestReplies,
+	}}
+}
+
+// startOptions builds the start options of a conversation from the request and the server
+// defaults. Conversations of premium plans run on the premium task queue, when configured.
+func (s *Server) startOptions(r *http.Request, req ChatRequest) (client.StartWorkflowOptions, error) {
+	options := client.StartWorkflowOptions{
+		ID:        req.WorkflowID,
+		TaskQueue: s.taskQueue,
+	}
+	if s.premiumTaskQueue != "" && quotaPlan(r).Premium {
+		options.TaskQueue = s.premiumTaskQueue
+	}
+	if options.ID == "" {
+		options.ID = "chat-workflow-" + uuid.NewString()
+	}
+
+	var err error
+	options.WorkflowIDReusePolicy, err = config.ParseIDReusePolicy(cmp.Or(req.IDReusePolicy, s.idReusePolicy))
+	if err != nil {
+		return options, err
+	}
+	options.WorkflowIDConflictPolicy, err = config.ParseIDConflictPolicy(cmp.Or(req.IDConflictPolicy, s.idConflictPolicy))
+	if err != nil {
+		return options, err
+	}
+
+	options.WorkflowExecutionTimeout = s.executionTimeout
+	if req.ExecutionTimeout != "" {
+		timeout, err := time.ParseDuration(req.ExecutionTimeout)
+		if err != nil || timeout < 0 {
+			return options, fmt.Errorf("invalid execution_timeout %q", req.ExecutionTimeout)
+		}
+		options.WorkflowExecutionTimeout = timeout
+	}
+
+	options.Memo = startMemo(req, quotaAccount(r))
+	var attributes []temporal.SearchAttributeUpdate
+	if req.UserID != "" {
+		attributes = append(attributes, workflows.UserIDKey.ValueSet(req.UserID))
+	}
+	if account := quotaAccount(r); account != "" {
+		attributes = append(attributes, workflows.AccountKey.ValueSet(account))
+	}
+	options.TypedSearchAttributes = temporal.NewSearchAttributes(attributes...)
+	if s.stickySessions && req.UserID != "" {
+		// Join the user's running conversation if there is one, from any channel or device
+		options.ID = userWorkflowID(quotaAccount(r), req.UserID)
+		options.WorkflowIDConflictPolicy = enumspb.WORKFLOW_ID_CONFLICT_POLICY_USE_EXISTING
+	}
+	return options, nil
+}
+
+// startMemo returns the memo fields describing where a conversation comes from and the
+// account owning it, leaving out the ones that are not set
+func startMemo(req ChatRequest, account string) map[string]interface{} {
+	memo := map[string]interface{}{}
+	for key, value := range map[string]string{
+		workflows.MemoClientApp: req.ClientApp,
+		workflows.MemoChannel:   req.Channel,
+		workflows.MemoLocale:    req.Locale,
+		workflows.MemoAccount:   account,
+	} {
+		if value != "" {
+			memo[key] = value
+		}
+	}
+	return memo
+}
+
+// userWorkflowID returns the conversation workflow ID of a user in sticky-session mode.
+// With quotas, user IDs are scoped by the caller's account, so two tenants' users with the
+// same ID never share a conversation.
+func userWorkflowID(account, userID string) string {
+	if account != "" {
+		return "chat-user-" + account + "-" + userID
+	}
+	return "chat-user-" + userID
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"temporal-ai-agent/channels"
+	"temporal-ai-agent/workflows"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/mocks"
+)
+
+// A sticky start joining the user's running conversation must deliver its message, which
+// the running conversation would not read from the start input
+func TestStickyStartSignalsRunningConversation(t *testing.T) {
+	c := &mocks.Client{}
+	run := &mocks.WorkflowRun{}
+	run.On("GetID").Return("chat-user-u-42")
+	run.On("GetRunID").Return("run-1")
+
+	var prompt workflows.UserPrompt
+	var input workflows.ConversationInput
+	c.On("SignalWithStartWorkflow", mock.Anything, "chat-user-u-42", workflows.SignalUserPrompt,
+		mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			prompt = args.Get(3).(workflows.UserPrompt)
+			input = args.Get(6).(workflows.ConversationInput)
+		}).
+		Return(run, nil)
+
+	s := New(c, Options{TaskQueue: "agent", StickySessions: true})
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/start-workflow",
+		strings.NewReader(`{"message":"where is my order?","user_id":"u-42"}`))
+	s.handleStartWorkflow(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	c.AssertNotCalled(t, "ExecuteWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	require.Equal(t, "where is my order?", prompt.Message)
+	require.Empty(t, input.Message, "a new conversation answers the signal, not its input")
+
+	var resp ChatResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	require.Equal(t, "chat-user-u-42", resp.WorkflowID)
+	require.Equal(t, "run-1", resp.RunID)
+}
+
+// Sticky starts keep joining the user's conversation rather than failing on the running one
+func TestStickyStartJoinsExistingConversation(t *testing.T) {
+	c := &mocks.Client{}
+	run := &mocks.WorkflowRun{}
+	run.On("GetID").Return("chat-user-u-42")
+	run.On("GetRunID").Return("run-1")
+
+	var options client.StartWorkflowOptions
+	c.On("SignalWithStartWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { options = args.Get(4).(client.StartWorkflowOptions) }).
+		Return(run, nil)
+
+	s := New(c, Options{TaskQueue: "agent", StickySessions: true})
+	req := httptest.NewRequest(http.MethodPost, "/start-workflow",
+		strings.NewReader(`{"message":"hi","user_id":"u-42","workflow_id":"other","id_conflict_policy":"Fail"}`))
+	s.handleStartWorkflow(httptest.NewRecorder(), req)
+
+	require.Equal(t, "chat-user-u-42", options.ID)
+	require.Equal(t, enumspb.WORKFLOW_ID_CONFLICT_POLICY_USE_EXISTING, options.WorkflowIDConflictPolicy)
+}
+
+// Channel messages choose where replies go, so only those the bridge signed are accepted
+func TestChannelMessagesMustBeSigned(t *testing.T) {
+	c := &mocks.Client{}
+	run := &mocks.WorkflowRun{}
+	run.On("GetID").Return("chat-user-u-42")
+	run.On("GetRunID").Return("run-1")
+	var prompt workflows.UserPrompt
+	c.On("SignalWithStartWorkflow", mock.Anything, "chat-user-u-42", workflows.SignalUserPrompt,
+		mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { prompt = args.Get(3).(workflows.UserPrompt) }).
+		Return(run, nil)
+	router := New(c, Options{
+		TaskQueue:      "agent",
+		Channels:       []string{"slack", "sms"},
+		ChannelSecrets: map[string]string{"slack": "s3cret"},
+	}).Router()
+
+	body := `{"user_id":"u-42","address":"C01/1700000000.000100","message":"hi"}`
+	now := time.Now()
+	for _, tc := range []struct {
+		name      string
+		channel   string
+		signature string
+		timestamp time.Time
+		status    int
+	}{
+		{"unsigned", "slack", "", now, http.StatusUnauthorized},
+		{"wrong secret", "slack", channels.Sign("guess", now, []byte(body)), now, http.StatusUnauthorized},
+		{"replayed", "slack", channels.Sign("s3cret", now.Add(-time.Hour), []byte(body)), now.Add(-time.Hour), http.StatusUnauthorized},
+		{"channel without secret", "sms", channels.Sign("s3cret", now, []byte(body)), now, http.StatusForbidden},
+		{"signed", "slack", channels.Sign("s3cret", now, []byte(body)), now, http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/channels/"+tc.channel+"/messages", strings.NewReader(body))
+			req.Header.Set(channels.TimestampHeader, strconv.FormatInt(tc.timestamp.Unix(), 10))
+			req.Header.Set(channels.SignatureHeader, tc.signature)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			require.Equal(t, tc.status, rec.Code, rec.Body.String())
+		})
+	}
+	c.AssertNumberOfCalls(t, "SignalWithStartWorkflow", 1)
+	require.True(t, prompt.From.Verified)
+}
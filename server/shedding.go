@@ -0,0 +1,36 @@
+package server
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"temporal-ai-agent/shedding"
+)
+
+// shed admits starts and updates through the load shedder, which turns them away with 503
+// and a Retry-After header when the server is overloaded. Requests of premium plans are
+// shed last. It must run inside requireQuota, which resolves the plan.
+func (s *Server) shed(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.shedder == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		priority := shedding.Low
+		if quotaPlan(r).Premium {
+			priority = shedding.High
+		}
+		release, err := s.shedder.Acquire(priority)
+		var overload *shedding.OverloadError
+		if errors.As(err, &overload) {
+			s.metrics.requestShed(overload.Limit)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(overload.RetryAfter.Seconds()))))
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		defer release()
+		next.ServeHTTP(w, r)
+	})
+}
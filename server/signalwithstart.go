@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"temporal-ai-agent/workflows"
+
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+)
+
+// SignalWithStartRequest represents the request body of the /signal-with-start/user-prompt
+// endpoint: a message, and the conversation to start if it is not running
+type SignalWithStartRequest struct {
+	ChatRequest
+	// MessageID lets the workflow drop retried messages it already processed
+	MessageID string `json:"message_id,omitempty"`
+	// Seq orders the messages of the conversation; see workflows.UserPrompt
+	Seq int `json:"seq,omitempty"`
+	// Attachments are documents whose text the agent reads along with the message
+	Attachments []workflows.Attachment `json:"attachments,omitempty"`
+}
+
+// handleSignalWithStart handles POST /signal-with-start/user-prompt requests, which send a
+// message to the conversation with the client's ID, starting it first if it is not running.
+// Retried requests therefore never create a second conversation, and clients can send
+// messages without knowing whether the conversation started yet.
+func (s *Server) handleSignalWithStart(w http.ResponseWriter, r *http.Request) {
+	var req SignalWithStartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.WorkflowID == "" {
+		http.Error(w, "WorkflowID is required", http.StatusBadRequest)
+		return
+	}
+	if req.Message == "" && len(req.Attachments) == 0 {
+		http.Error(w, "Message is required", http.StatusBadRequest)
+		return
+	}
+	if req.Seq < 0 {
+		http.Error(w, "Seq must not be negative", http.StatusBadRequest)
+		return
+	}
+	if err := s.validateChatRequest(req.ChatRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	options, err := s.startOptions(r, req.ChatRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// The message goes to the running conversation with the client's ID, even for sticky
+	// users: the other conflict policies would fail or terminate it
+	options.ID = req.WorkflowID
+	options.WorkflowIDConflictPolicy = enumspb.WORKFLOW_ID_CONFLICT_POLICY_USE_EXISTING
+	if !s.requireOwner(w, r, options.ID) {
+		return
+	}
+	if s.overUserLimit(w, r, req.UserID, options.ID) {
+		return
+	}
+
+	// The message is the first signal of a new conversation, which answers it once set up
+	input := s.agentInput(r, req.ChatRequest, options.ID)
+	input.Message = ""
+	prompt := workflows.UserPrompt{Message: req.Message, MessageID: req.MessageID, Seq: req.Seq, Attachments: req.Attachments}
+	ctx, cancel := context.WithTimeout(r.Context(), clientCallTimeout)
+	defer cancel()
+
+	we, err := s.temporalClient().SignalWithStartWorkflow(ctx, options.ID, workflows.SignalUserPrompt, prompt,
+		options, workflows.AgentGoalWorkflow, input)
+	if err != nil {
+		log.Printf("Error sending user_prompt signal with start: %v", err)
+		s.metrics.startFailed(workflowTypeName)
+		status := http.StatusInternalServerError
+		var alreadyStarted *serviceerror.WorkflowExecutionAlreadyStarted
+		if errors.As(err, &alreadyStarted) {
+			status = http.StatusConflict
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(ChatResponse{WorkflowID: options.ID, Error: err.Error()})
+		return
+	}
+
+	log.Printf("Signaled workflow with start: WorkflowID=%s, RunID=%s", we.GetID(), we.GetRunID())
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ChatResponse{WorkflowID: we.GetID(), RunID: we.GetRunID()})
+}
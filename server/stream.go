@@ -0,0 +1,141 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"temporal-ai-agent/streaming"
+	"temporal-ai-agent/workflows"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// handleStream handles GET /workflow/{id}/stream requests with Server-Sent Events. Each
+// stream message is sent as an event named after its kind, with the stream entry ID as the
+// event ID so reconnecting clients resume through Last-Event-ID. Without the Redis bridge,
+// the progress events are polled from the workflow instead.
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	workflowID := mux.Vars(r)["id"]
+	if !s.requireOwner(w, r, workflowID) {
+		return
+	}
+	after := r.Header.Get("Last-Event-ID")
+	if after == "" {
+		after = r.URL.Query().Get("after")
+	}
+	if s.streams == nil {
+		s.pollStream(w, r, flusher, workflowID, after)
+		return
+	}
+	if after == "" {
+		after = "$"
+	}
+
+	startStream(w, flusher)
+	err := s.streams.Subscribe(r.Context(), workflowID, after, func(m streaming.Message) error {
+		writeStreamEvent(w, flusher, m.ID, m.Kind, m.Data)
+		return nil
+	})
+	if err != nil && r.Context().Err() == nil {
+		log.Printf("Error streaming conversation: %v", err)
+	}
+}
+
+// pollStream streams the progress events of a conversation by querying the workflow for new
+// events every pollInterval. Events are sent as event messages with their sequence number
+// as the event ID. Replies arrive whole, in message events: chunks are only published
+// through the Redis bridge. Polling stops once the conversation has ended.
+func (s *Server) pollStream(w http.ResponseWriter, r *http.Request, flusher http.Flusher, workflowID, after string) {
+	ctx := r.Context()
+	var seq int
+	var err error
+	if after != "" {
+		if seq, err = strconv.Atoi(after); err != nil || seq < 0 {
+			http.Error(w, "after must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+	} else if seq, err = s.latestEventSeq(ctx, workflowID); err != nil {
+		log.Printf("Error querying events: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	startStream(w, flusher)
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	for {
+		queryCtx, cancel := context.WithTimeout(ctx, clientCallTimeout)
+		events, err := s.queryEvents(queryCtx, workflowID, "", seq)
+		cancel()
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Error streaming conversation: %v", err)
+			}
+			return
+		}
+		for _, event := range events {
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			writeStreamEvent(w, flusher, strconv.Itoa(event.Seq), streaming.KindEvent, string(data))
+			seq = event.Seq
+			if event.Type == workflows.EventEnded {
+				// Closing the response would make EventSource reconnect; clients close on ended
+				<-ctx.Done()
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// latestEventSeq returns the sequence number of the latest event of a conversation, after
+// which a polled stream starts when the client has seen none
+func (s *Server) latestEventSeq(ctx context.Context, workflowID string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, clientCallTimeout)
+	defer cancel()
+	events, err := s.queryEvents(ctx, workflowID, "", 0)
+	if err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+	return events[len(events)-1].Seq, nil
+}
+
+// startStream sends the headers of a Server-Sent Events response
+func startStream(w http.ResponseWriter, flusher http.Flusher) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+}
+
+// writeStreamEvent sends a Server-Sent Event, with one data line per line of data
+func writeStreamEvent(w http.ResponseWriter, flusher http.Flusher, id, name, data string) {
+	fmt.Fprintf(w, "id: %s\nevent: %s\n", id, name)
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(w, "data: %s\n", line)
+	}
+	fmt.Fprint(w, "\n")
+	flusher.Flush()
+}
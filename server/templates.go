@@ -0,0 +1,18 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"temporal-ai-agent/templates"
+)
+
+// TemplatesResponse represents the response from the GET /templates endpoint
+type TemplatesResponse struct {
+	Templates []templates.Template `json:"templates"`
+}
+
+// handleListTemplates handles GET /templates requests
+func (s *Server) handleListTemplates(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(TemplatesResponse{Templates: s.templates.Templates})
+}
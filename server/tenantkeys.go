@@ -0,0 +1,75 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"temporal-ai-agent/activities/llm"
+)
+
+// TenantKeyRequest represents the request body of the PUT /llm-key endpoint
+type TenantKeyRequest struct {
+	// APIKey is the tenant's OpenAI API key
+	APIKey string `json:"api_key"`
+}
+
+// tenant returns the tenant whose key a /llm-key request manages, or writes the error.
+// Tenants are quota accounts, so keys are tied to the API key the caller authenticates with.
+func (s *Server) tenant(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if s.tenantKeys == nil {
+		http.Error(w, "Tenant LLM keys are not configured", http.StatusNotFound)
+		return "", false
+	}
+	account := quotaAccount(r)
+	if account == "" {
+		http.Error(w, "Tenant LLM keys require quotas, which identify tenants", http.StatusNotFound)
+		return "", false
+	}
+	return account, true
+}
+
+// handlePutTenantKey handles PUT /llm-key requests, which store the LLM API key the
+// caller's conversations are answered with
+func (s *Server) handlePutTenantKey(w http.ResponseWriter, r *http.Request) {
+	account, ok := s.tenant(w, r)
+	if !ok {
+		return
+	}
+	var req TenantKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	req.APIKey = strings.TrimSpace(req.APIKey)
+	if req.APIKey == "" {
+		http.Error(w, "api_key is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.tenantKeys.Put(r.Context(), llm.TenantKeySecret(account), req.APIKey); err != nil {
+		log.Printf("Error storing the LLM key of tenant %s: %v", account, err)
+		http.Error(w, "Unable to store the key", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Stored the LLM key of tenant %s", account)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SignalResponse{Success: true})
+}
+
+// handleDeleteTenantKey handles DELETE /llm-key requests, after which the caller's
+// conversations are answered with the server's key again
+func (s *Server) handleDeleteTenantKey(w http.ResponseWriter, r *http.Request) {
+	account, ok := s.tenant(w, r)
+	if !ok {
+		return
+	}
+	if err := s.tenantKeys.Delete(r.Context(), llm.TenantKeySecret(account)); err != nil {
+		log.Printf("Error deleting the LLM key of tenant %s: %v", account, err)
+		http.Error(w, "Unable to delete the key", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Deleted the LLM key of tenant %s", account)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SignalResponse{Success: true})
+}
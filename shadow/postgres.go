@@ -0,0 +1,141 @@
+package shadow
+
+import (
+	"context"
+	"database/sql"
+
+	_ "github.com/lib/pq"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS shadow_turns (
+	id                TEXT PRIMARY KEY,
+	workflow_id       TEXT NOT NULL,
+	goal              TEXT NOT NULL DEFAULT '',
+	experiment        TEXT NOT NULL,
+	variant           TEXT NOT NULL,
+	message_index     INTEGER NOT NULL,
+	user_message      TEXT NOT NULL,
+	model             TEXT NOT NULL DEFAULT '',
+	reply             TEXT NOT NULL DEFAULT '',
+	tool              TEXT NOT NULL DEFAULT '',
+	latency_ms        BIGINT NOT NULL,
+	tokens            INTEGER NOT NULL,
+	replied_at        TIMESTAMPTZ NOT NULL,
+	shadow_model      TEXT NOT NULL DEFAULT '',
+	shadow_reply      TEXT NOT NULL DEFAULT '',
+	shadow_tool       TEXT NOT NULL DEFAULT '',
+	shadow_latency_ms BIGINT NOT NULL,
+	shadow_tokens     INTEGER NOT NULL,
+	error             TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS shadow_turns_experiment_idx ON shadow_turns (experiment, variant, replied_at);
+`
+
+// columns are the columns scanned into a Turn, in List's order
+const columns = `id, workflow_id, goal, experiment, variant, message_index, user_message,
+	model, reply, tool, latency_ms, tokens, replied_at,
+	shadow_model, shadow_reply, shadow_tool, shadow_latency_ms, shadow_tokens, error`
+
+// PostgresStore is a Store shared by every API server and worker using the same database
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore connects to Postgres and creates the shadow table if needed
+func NewPostgresStore(ctx context.Context, databaseURL string) (*PostgresStore, error) {
+	db, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &PostgresStore{db: db}, nil
+}
+
+// Close closes the database connection
+func (p *PostgresStore) Close() error {
+	return p.db.Close()
+}
+
+// Save records a shadow turn
+func (p *PostgresStore) Save(ctx context.Context, t Turn) error {
+	_, err := p.db.ExecContext(ctx, `
+		INSERT INTO shadow_turns (`+columns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+		ON CONFLICT (id) DO UPDATE
+		SET workflow_id = EXCLUDED.workflow_id, goal = EXCLUDED.goal, experiment = EXCLUDED.experiment,
+			variant = EXCLUDED.variant, message_index = EXCLUDED.message_index,
+			user_message = EXCLUDED.user_message, model = EXCLUDED.model, reply = EXCLUDED.reply,
+			tool = EXCLUDED.tool, latency_ms = EXCLUDED.latency_ms, tokens = EXCLUDED.tokens,
+			replied_at = EXCLUDED.replied_at, shadow_model = EXCLUDED.shadow_model,
+			shadow_reply = EXCLUDED.shadow_reply, shadow_tool = EXCLUDED.shadow_tool,
+			shadow_latency_ms = EXCLUDED.shadow_latency_ms, shadow_tokens = EXCLUDED.shadow_tokens,
+			error = EXCLUDED.error`,
+		t.ID, t.WorkflowID, t.Goal, t.Experiment, t.Variant, t.MessageIndex, t.UserMessage,
+		t.Model, t.Reply, t.Tool, t.LatencyMs, t.Tokens, t.RepliedAt,
+		t.ShadowModel, t.ShadowReply, t.ShadowTool, t.ShadowLatencyMs, t.ShadowTokens, t.Error)
+	return err
+}
+
+// List returns the selected turns, most recent first
+func (p *PostgresStore) List(ctx context.Context, f Filter) ([]Turn, error) {
+	query := `
+		SELECT ` + columns + ` FROM shadow_turns
+		WHERE experiment = $1 AND ($2 = '' OR variant = $2) AND replied_at >= $3
+		ORDER BY replied_at DESC`
+	args := []any{f.Experiment, f.Variant, f.Since}
+	if f.Limit > 0 {
+		query += ` LIMIT $4`
+		args = append(args, f.Limit)
+	}
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	turns := []Turn{}
+	for rows.Next() {
+		var t Turn
+		if err := rows.Scan(&t.ID, &t.WorkflowID, &t.Goal, &t.Experiment, &t.Variant, &t.MessageIndex,
+			&t.UserMessage, &t.Model, &t.Reply, &t.Tool, &t.LatencyMs, &t.Tokens, &t.RepliedAt,
+			&t.ShadowModel, &t.ShadowReply, &t.ShadowTool, &t.ShadowLatencyMs, &t.ShadowTokens, &t.Error); err != nil {
+			return nil, err
+		}
+		turns = append(turns, t)
+	}
+	return turns, rows.Err()
+}
+
+// Summarize compares each variant of the selected turns with the conversations it shadowed
+func (p *PostgresStore) Summarize(ctx context.Context, f Filter) ([]Summary, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT variant, COUNT(*), COUNT(*) FILTER (WHERE error <> ''),
+			COALESCE(AVG(CASE WHEN tool = shadow_tool THEN 1.0 ELSE 0.0 END) FILTER (WHERE error = ''), 0),
+			COALESCE(AVG(latency_ms) FILTER (WHERE error = ''), 0),
+			COALESCE(AVG(shadow_latency_ms) FILTER (WHERE error = ''), 0),
+			COALESCE(AVG(tokens) FILTER (WHERE error = ''), 0),
+			COALESCE(AVG(shadow_tokens) FILTER (WHERE error = ''), 0)
+		FROM shadow_turns
+		WHERE experiment = $1 AND ($2 = '' OR variant = $2) AND replied_at >= $3
+		GROUP BY variant
+		ORDER BY variant`, f.Experiment, f.Variant, f.Since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := []Summary{}
+	for rows.Next() {
+		var s Summary
+		if err := rows.Scan(&s.Variant, &s.Turns, &s.Errors, &s.Agreement,
+			&s.LatencyMs, &s.ShadowLatencyMs, &s.Tokens, &s.ShadowTokens); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries, rows.Err()
+}
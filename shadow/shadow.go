@@ -0,0 +1,182 @@
+// Package shadow keeps the replies of shadow experiments: candidate models and prompts that
+// answer real turns alongside the conversation's own model, without their replies ever
+// reaching users, so an upgrade can be compared on production traffic before cutover.
+package shadow
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Turn is the first completion of a turn as answered by the conversation's model and by the
+// shadow candidate, from the same history
+type Turn struct {
+	// ID identifies the turn: the conversation and the position of its user message
+	ID         string `json:"id"`
+	WorkflowID string `json:"workflow_id"`
+	Goal       string `json:"goal"`
+	Experiment string `json:"experiment"`
+	Variant    string `json:"variant"`
+	// MessageIndex is the position in the history of the turn's user message
+	MessageIndex int    `json:"message_index"`
+	UserMessage  string `json:"user_message"`
+
+	// Model, Reply and Tool are the conversation's completion: its reply, or the tool it
+	// called instead of answering
+	Model     string    `json:"model,omitempty"`
+	Reply     string    `json:"reply,omitempty"`
+	Tool      string    `json:"tool,omitempty"`
+	LatencyMs int64     `json:"latency_ms"`
+	Tokens    int       `json:"tokens"`
+	RepliedAt time.Time `json:"replied_at"`
+
+	// The shadow candidate's completion; Error is set when it failed
+	ShadowModel     string `json:"shadow_model,omitempty"`
+	ShadowReply     string `json:"shadow_reply,omitempty"`
+	ShadowTool      string `json:"shadow_tool,omitempty"`
+	ShadowLatencyMs int64  `json:"shadow_latency_ms"`
+	ShadowTokens    int    `json:"shadow_tokens"`
+	Error           string `json:"error,omitempty"`
+}
+
+// ID returns the ID of the turn of a conversation whose user message is at the given position
+func ID(workflowID string, messageIndex int) string {
+	return fmt.Sprintf("%s:%d", workflowID, messageIndex)
+}
+
+// Agrees reports whether the candidate took the same action as the conversation's model:
+// both answered, or both called the same tool
+func (t Turn) Agrees() bool {
+	return t.Error == "" && t.Tool == t.ShadowTool
+}
+
+// Summary compares a shadow variant with the conversations it shadowed
+type Summary struct {
+	Variant string `json:"variant"`
+	Turns   int    `json:"turns"`
+	Errors  int    `json:"errors"`
+	// Agreement is the share of the turns the candidate answered where it took the same
+	// action as the conversation's model (see Turn.Agrees)
+	Agreement       float64 `json:"agreement"`
+	LatencyMs       float64 `json:"latency_ms"`
+	ShadowLatencyMs float64 `json:"shadow_latency_ms"`
+	Tokens          float64 `json:"tokens"`
+	ShadowTokens    float64 `json:"shadow_tokens"`
+}
+
+// summarize compares the turns of each variant; the averages cover the turns the candidate answered
+func summarize(turns []Turn) []Summary {
+	summaries := []Summary{}
+	index := map[string]int{}
+	for _, t := range turns {
+		i, ok := index[t.Variant]
+		if !ok {
+			i = len(summaries)
+			index[t.Variant] = i
+			summaries = append(summaries, Summary{Variant: t.Variant})
+		}
+		s := &summaries[i]
+		s.Turns++
+		if t.Error != "" {
+			s.Errors++
+			continue
+		}
+		if t.Agrees() {
+			s.Agreement++
+		}
+		s.LatencyMs += float64(t.LatencyMs)
+		s.ShadowLatencyMs += float64(t.ShadowLatencyMs)
+		s.Tokens += float64(t.Tokens)
+		s.ShadowTokens += float64(t.ShadowTokens)
+	}
+	for i := range summaries {
+		s := &summaries[i]
+		if answered := float64(s.Turns - s.Errors); answered > 0 {
+			s.Agreement /= answered
+			s.LatencyMs /= answered
+			s.ShadowLatencyMs /= answered
+			s.Tokens /= answered
+			s.ShadowTokens /= answered
+		}
+	}
+	slices.SortFunc(summaries, func(a, b Summary) int { return cmp.Compare(a.Variant, b.Variant) })
+	return summaries
+}
+
+// Filter selects shadow turns; zero fields match everything but the experiment
+type Filter struct {
+	Experiment string
+	Variant    string
+	Since      time.Time
+	// Limit caps the turns returned, most recent first; 0 returns them all
+	Limit int
+}
+
+// matches reports whether the filter selects a turn
+func (f Filter) matches(t Turn) bool {
+	return t.Experiment == f.Experiment && (f.Variant == "" || t.Variant == f.Variant) &&
+		!t.RepliedAt.Before(f.Since)
+}
+
+// Store persists shadow turns
+type Store interface {
+	// Save records a shadow turn, replacing an earlier one with the same ID (a turn
+	// reprocessed after an edit or a failure)
+	Save(ctx context.Context, turn Turn) error
+	// List returns the selected turns, most recent first
+	List(ctx context.Context, filter Filter) ([]Turn, error)
+	// Summarize compares each variant of the selected turns with the conversations it
+	// shadowed; the filter's limit is ignored
+	Summarize(ctx context.Context, filter Filter) ([]Summary, error)
+}
+
+// MemoryStore is a Store kept in process memory. It only works when the API server and
+// the worker run in the same process, as in dev mode.
+type MemoryStore struct {
+	mu    sync.Mutex
+	turns map[string]Turn
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{turns: map[string]Turn{}}
+}
+
+// Save records a shadow turn
+func (m *MemoryStore) Save(ctx context.Context, turn Turn) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.turns[turn.ID] = turn
+	return nil
+}
+
+// List returns the selected turns, most recent first
+func (m *MemoryStore) List(ctx context.Context, filter Filter) ([]Turn, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	turns := []Turn{}
+	for _, t := range m.turns {
+		if filter.matches(t) {
+			turns = append(turns, t)
+		}
+	}
+	slices.SortFunc(turns, func(a, b Turn) int { return b.RepliedAt.Compare(a.RepliedAt) })
+	if filter.Limit > 0 && len(turns) > filter.Limit {
+		turns = turns[:filter.Limit]
+	}
+	return turns, nil
+}
+
+// Summarize compares each variant of the selected turns with the conversations it shadowed
+func (m *MemoryStore) Summarize(ctx context.Context, filter Filter) ([]Summary, error) {
+	filter.Limit = 0
+	turns, err := m.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return summarize(turns), nil
+}
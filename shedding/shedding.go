@@ -0,0 +1,196 @@
+// Package shedding protects the API server from overload. It bounds the starts and updates
+// in flight and watches the error rate of the Temporal client; when either says Temporal
+// cannot keep up, requests are turned away with a hint of when to retry instead of piling
+// up. Low-priority requests are shed first.
+package shedding
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Priority is how important a request is to serve under load
+type Priority int
+
+// Priorities of requests. Low-priority requests are shed as soon as the server is under
+// load; high-priority ones only once it is at its limit.
+const (
+	Low Priority = iota
+	High
+)
+
+// Error rate window: calls are counted in buckets, and the rate covers the latest
+// errorWindow of them once it holds minCalls
+const (
+	errorWindow  = 30 * time.Second
+	errorBuckets = 10
+	minCalls     = 20
+)
+
+// lowPriorityShare is the share of MaxInFlight that low-priority requests may fill
+const lowPriorityShare = 0.8
+
+// Retry hints: a full server frees slots quickly, an unhealthy Temporal takes longer
+const (
+	inFlightRetryAfter  = time.Second
+	errorRateRetryAfter = errorWindow / errorBuckets
+)
+
+// Options configures a Shedder. Zero values disable the corresponding check.
+type Options struct {
+	// MaxInFlight bounds the requests admitted at once
+	MaxInFlight int
+	// ErrorRate is the share of failed Temporal calls past which low-priority requests are shed
+	ErrorRate float64
+}
+
+// Limits a request can be shed by
+const (
+	LimitInFlight  = "in_flight"
+	LimitErrorRate = "error_rate"
+)
+
+// OverloadError is returned for shed requests
+type OverloadError struct {
+	// Limit is the limit the server is past, and Reason describes it
+	Limit  string
+	Reason string
+	// RetryAfter is how long the client should wait before retrying
+	RetryAfter time.Duration
+}
+
+func (e *OverloadError) Error() string {
+	return fmt.Sprintf("server overloaded: %s", e.Reason)
+}
+
+// Shedder admits or sheds requests. Its methods are safe for concurrent use.
+type Shedder struct {
+	opts     Options
+	mu       sync.Mutex
+	inFlight int
+	// buckets count calls and errors per slice of the window; current is the slice of now
+	buckets [errorBuckets]bucket
+	current int
+}
+
+// bucket counts the Temporal calls of a slice of the error window
+type bucket struct {
+	start  time.Time
+	calls  int
+	errors int
+}
+
+// New returns a shedder with the given limits
+func New(opts Options) *Shedder {
+	return &Shedder{opts: opts}
+}
+
+// Acquire admits a request of the given priority, returning the function releasing its
+// slot once done, or an *OverloadError when it is shed
+func (s *Shedder) Acquire(priority Priority) (func(), error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if limit := s.opts.MaxInFlight; limit > 0 {
+		if priority == Low {
+			limit = max(int(float64(limit)*lowPriorityShare), 1)
+		}
+		if s.inFlight >= limit {
+			return nil, &OverloadError{
+				Limit:      LimitInFlight,
+				Reason:     fmt.Sprintf("%d requests in flight", s.inFlight),
+				RetryAfter: inFlightRetryAfter,
+			}
+		}
+	}
+	if priority == Low && s.opts.ErrorRate > 0 {
+		if rate, ok := s.errorRate(); ok && rate > s.opts.ErrorRate {
+			return nil, &OverloadError{
+				Limit:      LimitErrorRate,
+				Reason:     fmt.Sprintf("Temporal error rate is %.0f%%", rate*100),
+				RetryAfter: errorRateRetryAfter,
+			}
+		}
+	}
+
+	s.inFlight++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			s.inFlight--
+			s.mu.Unlock()
+		})
+	}, nil
+}
+
+// InFlight returns the number of requests admitted and not yet released
+func (s *Shedder) InFlight() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inFlight
+}
+
+// Record counts the outcome of a Temporal call. Errors telling that Temporal is unavailable
+// or overloaded count as failures; others, such as an unknown workflow, are the caller's.
+func (s *Shedder) Record(err error) {
+	failed := false
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted, codes.DeadlineExceeded:
+		failed = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := s.bucket()
+	b.calls++
+	if failed {
+		b.errors++
+	}
+}
+
+// Interceptor returns a gRPC interceptor recording the outcome of every call of the
+// Temporal client it is dialed with
+func (s *Shedder) Interceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		s.Record(err)
+		return err
+	}
+}
+
+// bucket returns the bucket of the current slice of the window, recycling the oldest one
+// when a new slice starts. It must be called with the lock held.
+func (s *Shedder) bucket() *bucket {
+	now := time.Now()
+	b := &s.buckets[s.current]
+	if now.Sub(b.start) < errorWindow/errorBuckets {
+		return b
+	}
+	s.current = (s.current + 1) % errorBuckets
+	s.buckets[s.current] = bucket{start: now}
+	return &s.buckets[s.current]
+}
+
+// errorRate returns the share of failed calls in the window, if it holds enough calls. It
+// must be called with the lock held.
+func (s *Shedder) errorRate() (float64, bool) {
+	now := time.Now()
+	calls, errors := 0, 0
+	for _, b := range s.buckets {
+		if now.Sub(b.start) < errorWindow {
+			calls += b.calls
+			errors += b.errors
+		}
+	}
+	if calls < minCalls {
+		return 0, false
+	}
+	return float64(errors) / float64(calls), true
+}
@@ -0,0 +1,79 @@
+package shedding
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// requireShed checks that a request is shed by the given limit
+func requireShed(t *testing.T, s *Shedder, priority Priority, limit string) {
+	t.Helper()
+	release, err := s.Acquire(priority)
+	var overload *OverloadError
+	require.True(t, errors.As(err, &overload), "request is shed")
+	require.Equal(t, limit, overload.Limit)
+	require.Positive(t, overload.RetryAfter)
+	require.Nil(t, release)
+}
+
+func TestShedByInFlight(t *testing.T) {
+	s := New(Options{MaxInFlight: 5})
+	var releases []func()
+	for i := 0; i < 4; i++ {
+		release, err := s.Acquire(Low)
+		require.NoError(t, err)
+		releases = append(releases, release)
+	}
+	requireShed(t, s, Low, LimitInFlight)
+
+	// High-priority requests have the last slots
+	release, err := s.Acquire(High)
+	require.NoError(t, err)
+	releases = append(releases, release)
+	requireShed(t, s, High, LimitInFlight)
+	require.Equal(t, 5, s.InFlight())
+
+	// Releasing twice frees one slot
+	releases[4]()
+	releases[4]()
+	require.Equal(t, 4, s.InFlight())
+	requireShed(t, s, Low, LimitInFlight)
+	releases[0]()
+	_, err = s.Acquire(Low)
+	require.NoError(t, err)
+}
+
+func TestShedByErrorRate(t *testing.T) {
+	s := New(Options{ErrorRate: 0.5})
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	for i := 0; i < minCalls-1; i++ {
+		s.Record(unavailable)
+	}
+	_, err := s.Acquire(Low)
+	require.NoError(t, err, "too few calls to judge the error rate")
+
+	s.Record(unavailable)
+	requireShed(t, s, Low, LimitErrorRate)
+	_, err = s.Acquire(High)
+	require.NoError(t, err, "high-priority requests are not shed by the error rate")
+
+	// Enough successful calls bring the rate back under the threshold
+	for i := 0; i < minCalls; i++ {
+		s.Record(nil)
+	}
+	_, err = s.Acquire(Low)
+	require.NoError(t, err)
+}
+
+func TestCallerErrorsAreNotFailures(t *testing.T) {
+	s := New(Options{ErrorRate: 0.1})
+	for i := 0; i < minCalls; i++ {
+		s.Record(status.Error(codes.NotFound, "workflow not found"))
+	}
+	_, err := s.Acquire(Low)
+	require.NoError(t, err)
+}
@@ -0,0 +1,67 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	_ "github.com/lib/pq"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS conversations (
+	workflow_id    TEXT PRIMARY KEY,
+	title          TEXT NOT NULL DEFAULT '',
+	goal           TEXT NOT NULL,
+	persona        TEXT NOT NULL DEFAULT '',
+	prompt_version TEXT NOT NULL DEFAULT '',
+	started_at     TIMESTAMPTZ NOT NULL,
+	updated_at     TIMESTAMPTZ NOT NULL,
+	ended_at       TIMESTAMPTZ
+);
+CREATE TABLE IF NOT EXISTS conversation_messages (
+	workflow_id  TEXT NOT NULL REFERENCES conversations ON DELETE CASCADE,
+	position     INTEGER NOT NULL,
+	role         TEXT NOT NULL,
+	content      TEXT NOT NULL DEFAULT '',
+	tool_name    TEXT,
+	tool_call    JSONB,
+	tool_call_id TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (workflow_id, position)
+);
+CREATE INDEX IF NOT EXISTS conversation_messages_tool_name_idx
+	ON conversation_messages (tool_name) WHERE tool_name IS NOT NULL;
+CREATE TABLE IF NOT EXISTS conversation_feedback (
+	id          BIGSERIAL PRIMARY KEY,
+	workflow_id TEXT NOT NULL,
+	satisfied   BOOLEAN NOT NULL,
+	comment     TEXT NOT NULL DEFAULT '',
+	created_at  TIMESTAMPTZ NOT NULL
+);
+CREATE TABLE IF NOT EXISTS conversation_preferences (
+	user_id     TEXT NOT NULL,
+	workflow_id TEXT NOT NULL,
+	pinned      BOOLEAN NOT NULL DEFAULT FALSE,
+	archived    BOOLEAN NOT NULL DEFAULT FALSE,
+	title       TEXT NOT NULL DEFAULT '',
+	updated_at  TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (user_id, workflow_id)
+);
+`
+
+// PostgresStore is a Store backed by Postgres
+type PostgresStore struct {
+	sqlStore
+}
+
+// NewPostgresStore connects to Postgres and creates the conversation tables if needed
+func NewPostgresStore(ctx context.Context, databaseURL string) (*PostgresStore, error) {
+	db, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &PostgresStore{sqlStore{db: db}}, nil
+}
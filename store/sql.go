@@ -0,0 +1,157 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+)
+
+// sqlStore implements Store over the tables shared by the Postgres and SQLite schemas.
+// Queries use $n placeholders in order, which both databases accept.
+type sqlStore struct {
+	db *sql.DB
+}
+
+// Close closes the database connection
+func (s *sqlStore) Close() error {
+	return s.db.Close()
+}
+
+// SaveConversation upserts a conversation and replaces its messages from position from on
+func (s *sqlStore) SaveConversation(ctx context.Context, c Conversation, from int, messages []Message) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO conversations (workflow_id, title, goal, persona, prompt_version, started_at, updated_at, ended_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (workflow_id) DO UPDATE
+		SET title = EXCLUDED.title, goal = EXCLUDED.goal, persona = EXCLUDED.persona,
+			prompt_version = EXCLUDED.prompt_version, updated_at = EXCLUDED.updated_at, ended_at = EXCLUDED.ended_at`,
+		c.WorkflowID, c.Title, c.Goal, c.Persona, c.PromptVersion, c.StartedAt, c.UpdatedAt, c.EndedAt)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `DELETE FROM conversation_messages WHERE workflow_id = $1 AND position >= $2`, c.WorkflowID, from)
+	if err != nil {
+		return err
+	}
+	for _, m := range messages {
+		var toolName, toolCall interface{}
+		if m.ToolCall != nil {
+			data, err := json.Marshal(m.ToolCall)
+			if err != nil {
+				return err
+			}
+			toolName, toolCall = m.ToolCall.Name, string(data)
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO conversation_messages (workflow_id, position, role, content, tool_name, tool_call, tool_call_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			c.WorkflowID, m.Index, m.Role, m.Content, toolName, toolCall, m.ToolCallID)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// AddFeedback records a user's rating
+func (s *sqlStore) AddFeedback(ctx context.Context, f Feedback) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO conversation_feedback (workflow_id, satisfied, comment, created_at)
+		VALUES ($1, $2, $3, $4)`,
+		f.WorkflowID, f.Satisfied, f.Comment, f.Time)
+	return err
+}
+
+// Get returns a conversation with its messages and feedback
+func (s *sqlStore) Get(ctx context.Context, workflowID string) (Record, error) {
+	record := Record{Messages: []Message{}, Feedback: []Feedback{}}
+	c := &record.Conversation
+	err := s.db.QueryRowContext(ctx, `
+		SELECT workflow_id, title, goal, persona, prompt_version, started_at, updated_at, ended_at
+		FROM conversations WHERE workflow_id = $1`, workflowID).
+		Scan(&c.WorkflowID, &c.Title, &c.Goal, &c.Persona, &c.PromptVersion, &c.StartedAt, &c.UpdatedAt, &c.EndedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Record{}, ErrNotFound
+	}
+	if err != nil {
+		return Record{}, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT position, role, content, tool_call, tool_call_id
+		FROM conversation_messages WHERE workflow_id = $1 ORDER BY position`, workflowID)
+	if err != nil {
+		return Record{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var m Message
+		var toolCall []byte
+		if err := rows.Scan(&m.Index, &m.Role, &m.Content, &toolCall, &m.ToolCallID); err != nil {
+			return Record{}, err
+		}
+		if toolCall != nil {
+			if err := json.Unmarshal(toolCall, &m.ToolCall); err != nil {
+				return Record{}, err
+			}
+		}
+		record.Messages = append(record.Messages, m)
+	}
+	if err := rows.Err(); err != nil {
+		return Record{}, err
+	}
+
+	feedback, err := s.db.QueryContext(ctx, `
+		SELECT workflow_id, satisfied, comment, created_at
+		FROM conversation_feedback WHERE workflow_id = $1 ORDER BY created_at`, workflowID)
+	if err != nil {
+		return Record{}, err
+	}
+	defer feedback.Close()
+	for feedback.Next() {
+		var f Feedback
+		if err := feedback.Scan(&f.WorkflowID, &f.Satisfied, &f.Comment, &f.Time); err != nil {
+			return Record{}, err
+		}
+		record.Feedback = append(record.Feedback, f)
+	}
+	return record, feedback.Err()
+}
+
+// SetPreferences upserts a user's settings for a conversation
+func (s *sqlStore) SetPreferences(ctx context.Context, p Preferences) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO conversation_preferences (user_id, workflow_id, pinned, archived, title, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, workflow_id) DO UPDATE
+		SET pinned = EXCLUDED.pinned, archived = EXCLUDED.archived, title = EXCLUDED.title, updated_at = EXCLUDED.updated_at`,
+		p.UserID, p.WorkflowID, p.Pinned, p.Archived, p.Title, p.UpdatedAt)
+	return err
+}
+
+// Preferences returns a user's settings for the conversations they changed any of
+func (s *sqlStore) Preferences(ctx context.Context, userID string) ([]Preferences, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT user_id, workflow_id, pinned, archived, title, updated_at
+		FROM conversation_preferences WHERE user_id = $1 ORDER BY workflow_id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var preferences []Preferences
+	for rows.Next() {
+		var p Preferences
+		if err := rows.Scan(&p.UserID, &p.WorkflowID, &p.Pinned, &p.Archived, &p.Title, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		preferences = append(preferences, p)
+	}
+	return preferences, rows.Err()
+}
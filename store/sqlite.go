@@ -0,0 +1,73 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteSchema mirrors the Postgres schema with SQLite types. DATETIME columns are
+// read back as times by the driver.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS conversations (
+	workflow_id    TEXT PRIMARY KEY,
+	title          TEXT NOT NULL DEFAULT '',
+	goal           TEXT NOT NULL,
+	persona        TEXT NOT NULL DEFAULT '',
+	prompt_version TEXT NOT NULL DEFAULT '',
+	started_at     DATETIME NOT NULL,
+	updated_at     DATETIME NOT NULL,
+	ended_at       DATETIME
+);
+CREATE TABLE IF NOT EXISTS conversation_messages (
+	workflow_id  TEXT NOT NULL REFERENCES conversations ON DELETE CASCADE,
+	position     INTEGER NOT NULL,
+	role         TEXT NOT NULL,
+	content      TEXT NOT NULL DEFAULT '',
+	tool_name    TEXT,
+	tool_call    TEXT,
+	tool_call_id TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (workflow_id, position)
+);
+CREATE INDEX IF NOT EXISTS conversation_messages_tool_name_idx
+	ON conversation_messages (tool_name) WHERE tool_name IS NOT NULL;
+CREATE TABLE IF NOT EXISTS conversation_feedback (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	workflow_id TEXT NOT NULL,
+	satisfied   BOOLEAN NOT NULL,
+	comment     TEXT NOT NULL DEFAULT '',
+	created_at  DATETIME NOT NULL
+);
+CREATE TABLE IF NOT EXISTS conversation_preferences (
+	user_id     TEXT NOT NULL,
+	workflow_id TEXT NOT NULL,
+	pinned      BOOLEAN NOT NULL DEFAULT FALSE,
+	archived    BOOLEAN NOT NULL DEFAULT FALSE,
+	title       TEXT NOT NULL DEFAULT '',
+	updated_at  DATETIME NOT NULL,
+	PRIMARY KEY (user_id, workflow_id)
+);
+`
+
+// SQLiteStore is a Store backed by a SQLite file, for single-node deployments without Postgres
+type SQLiteStore struct {
+	sqlStore
+}
+
+// NewSQLiteStore opens (or creates) the SQLite database at path and creates the conversation
+// tables if needed. The worker and the API server may open the same file: it uses WAL mode
+// and waits for locks held by the other process.
+func NewSQLiteStore(ctx context.Context, path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite3", "file:"+path+"?_journal_mode=WAL&_busy_timeout=5000&_foreign_keys=on")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer, so one connection avoids lock errors between goroutines
+	db.SetMaxOpenConns(1)
+	if _, err := db.ExecContext(ctx, sqliteSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLiteStore{sqlStore{db: db}}, nil
+}
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"temporal-ai-agent/activities/llm"
+	"temporal-ai-agent/tools"
+	"time"
+)
+
+// ErrNotFound is returned for conversations the store does not have
+var ErrNotFound = errors.New("conversation not found")
+
+// Conversation describes a stored conversation
+type Conversation struct {
+	WorkflowID    string    `json:"workflow_id"`
+	Title         string    `json:"title,omitempty"`
+	Goal          string    `json:"goal"`
+	Persona       string    `json:"persona,omitempty"`
+	PromptVersion string    `json:"prompt_version,omitempty"`
+	StartedAt     time.Time `json:"started_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	// EndedAt is nil while the conversation is running
+	EndedAt *time.Time `json:"ended_at,omitempty"`
+}
+
+// Message is a message of a stored conversation, at its position in the history
+type Message struct {
+	Index      int         `json:"index"`
+	Role       string      `json:"role"`
+	Content    string      `json:"content,omitempty"`
+	ToolCall   *tools.Call `json:"tool_call,omitempty"`
+	ToolCallID string      `json:"tool_call_id,omitempty"`
+}
+
+// Messages converts history messages starting at the given position
+func Messages(from int, history []llm.Message) []Message {
+	messages := make([]Message, len(history))
+	for i, m := range history {
+		messages[i] = Message{
+			Index:      from + i,
+			Role:       m.Role,
+			Content:    m.Content,
+			ToolCall:   m.ToolCall,
+			ToolCallID: m.ToolCallID,
+		}
+	}
+	return messages
+}
+
+// Feedback is a user's rating of a conversation
+type Feedback struct {
+	WorkflowID string    `json:"workflow_id"`
+	Satisfied  bool      `json:"satisfied"`
+	Comment    string    `json:"comment,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+// Preferences are a user's settings for one of their conversations
+type Preferences struct {
+	UserID     string `json:"user_id"`
+	WorkflowID string `json:"workflow_id"`
+	// Pinned conversations are kept at hand; archived ones are hidden from the user's list
+	Pinned   bool `json:"pinned"`
+	Archived bool `json:"archived"`
+	// Title replaces the generated title; empty keeps it
+	Title     string    `json:"title,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Record is everything stored about a conversation
+type Record struct {
+	Conversation Conversation `json:"conversation"`
+	Messages     []Message    `json:"messages"`
+	Feedback     []Feedback   `json:"feedback"`
+}
+
+// Store persists conversations outside of Temporal, so they outlive the namespace's
+// history retention
+type Store interface {
+	// SaveConversation upserts a conversation and replaces its messages from position from
+	// on, which drops the messages discarded by edits
+	SaveConversation(ctx context.Context, conversation Conversation, from int, messages []Message) error
+	// AddFeedback records a user's rating
+	AddFeedback(ctx context.Context, feedback Feedback) error
+	// Get returns a conversation with its messages and feedback, or ErrNotFound
+	Get(ctx context.Context, workflowID string) (Record, error)
+	// SetPreferences upserts a user's settings for a conversation
+	SetPreferences(ctx context.Context, preferences Preferences) error
+	// Preferences returns a user's settings for the conversations they changed any of
+	Preferences(ctx context.Context, userID string) ([]Preferences, error)
+}
@@ -0,0 +1,113 @@
+package streaming
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Kinds of stream messages
+const (
+	// KindToken carries a chunk of the reply being generated
+	KindToken = "token"
+	// KindReset tells clients to discard the chunks of the current reply, which is
+	// generated again after a failed attempt
+	KindReset = "reset"
+	// KindEvent carries a JSON-encoded progress event of the conversation
+	KindEvent = "event"
+)
+
+const (
+	// maxLen bounds the entries kept per conversation stream (approximately)
+	maxLen = 1000
+	// ttl expires the streams of idle conversations
+	ttl = 24 * time.Hour
+	// blockTimeout bounds each blocking read, so subscribers notice their client is gone
+	// within that time
+	blockTimeout = 15 * time.Second
+)
+
+// Message is an entry of a conversation's stream
+type Message struct {
+	// ID is the Redis stream entry ID, usable to resume after it
+	ID   string `json:"id"`
+	Kind string `json:"kind"`
+	Data string `json:"data"`
+}
+
+// Bridge publishes conversation output to Redis Streams, one stream per workflow ID, and
+// lets API servers subscribe to them without polling the workflow
+type Bridge struct {
+	client *redis.Client
+}
+
+// NewBridge connects to Redis, e.g. redis://localhost:6379/0
+func NewBridge(redisURL string) (*Bridge, error) {
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, err
+	}
+	return &Bridge{client: redis.NewClient(opts)}, nil
+}
+
+// Close closes the Redis connections
+func (b *Bridge) Close() error {
+	return b.client.Close()
+}
+
+// Ping checks that Redis answers
+func (b *Bridge) Ping(ctx context.Context) error {
+	return b.client.Ping(ctx).Err()
+}
+
+// key returns the stream key of a conversation
+func key(workflowID string) string {
+	return "agent:stream:" + workflowID
+}
+
+// Publish appends a message to the stream of a conversation
+func (b *Bridge) Publish(ctx context.Context, workflowID, kind, data string) error {
+	pipe := b.client.Pipeline()
+	pipe.XAdd(ctx, &redis.XAddArgs{
+		Stream: key(workflowID),
+		MaxLen: maxLen,
+		Approx: true,
+		Values: map[string]interface{}{"kind": kind, "data": data},
+	})
+	pipe.Expire(ctx, key(workflowID), ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Subscribe calls fn with the messages of a conversation's stream that follow the entry ID
+// after ("$" for new messages only, "0" for the whole stream) until ctx is done or fn fails
+func (b *Bridge) Subscribe(ctx context.Context, workflowID, after string, fn func(Message) error) error {
+	for {
+		streams, err := b.client.XRead(ctx, &redis.XReadArgs{
+			Streams: []string{key(workflowID), after},
+			Count:   100,
+			Block:   blockTimeout,
+		}).Result()
+		switch {
+		case errors.Is(err, redis.Nil):
+			continue
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case err != nil:
+			return err
+		}
+
+		for _, stream := range streams {
+			for _, entry := range stream.Messages {
+				kind, _ := entry.Values["kind"].(string)
+				data, _ := entry.Values["data"].(string)
+				if err := fn(Message{ID: entry.ID, Kind: kind, Data: data}); err != nil {
+					return err
+				}
+				after = entry.ID
+			}
+		}
+	}
+}
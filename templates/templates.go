@@ -0,0 +1,107 @@
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"temporal-ai-agent/goals"
+	"temporal-ai-agent/personas"
+)
+
+// maxQuickReplies bounds the quick replies a template offers
+const maxQuickReplies = 5
+
+// Template starts a guided conversation: the agent opens it with a greeting and suggested
+// quick replies instead of waiting for the user, following the template's instructions
+type Template struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Goal and Persona are used when the request does not pick them
+	Goal    string `json:"goal,omitempty"`
+	Persona string `json:"persona,omitempty"`
+	// SystemPrompt replaces the base system prompt of the conversation; the goal's and
+	// persona's instructions are still added to it
+	SystemPrompt string `json:"system_prompt,omitempty"`
+	// Greeting is the agent's first message; {key} placeholders are filled from the
+	// conversation's context values
+	Greeting     string   `json:"greeting"`
+	QuickReplies []string `json:"quick_replies,omitempty"`
+}
+
+// Catalog holds the templates conversations can be started from
+type Catalog struct {
+	Templates []Template `json:"templates"`
+}
+
+// onboarding walks a new user through what the default assistant can do
+var onboarding = Template{
+	Name:        "onboarding",
+	Description: "Welcomes a new user and walks them through what the assistant can do.",
+	Goal:        goals.Default,
+	SystemPrompt: `You are onboarding a new user of a travel and productivity assistant.
+Find out what they want to get done first, show them how you do it with a single example, and keep every answer short.
+Offer to search flights, check their calendar, list their support tickets or read their inbox.`,
+	Greeting:     "Welcome! I can search and book flights, check your calendar and keep an eye on your tickets and inbox. What would you like to start with?",
+	QuickReplies: []string{"Find me a flight", "What's on my calendar today?", "Show my open tickets"},
+}
+
+// Load reads a catalog from a JSON file and adds the built-in templates it does not
+// override. An empty path returns the built-in catalog.
+func Load(path string) (Catalog, error) {
+	catalog := Catalog{}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Catalog{}, fmt.Errorf("reading templates file: %w", err)
+		}
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			return Catalog{}, fmt.Errorf("parsing templates file: %w", err)
+		}
+	}
+
+	if _, err := catalog.Find(onboarding.Name); err != nil {
+		catalog.Templates = append(catalog.Templates, onboarding)
+	}
+	seen := map[string]bool{}
+	for _, t := range catalog.Templates {
+		switch {
+		case t.Name == "":
+			return Catalog{}, fmt.Errorf("a template has no name")
+		case seen[t.Name]:
+			return Catalog{}, fmt.Errorf("template %q is defined twice", t.Name)
+		case t.Greeting == "":
+			return Catalog{}, fmt.Errorf("template %q has no greeting", t.Name)
+		case len(t.QuickReplies) > maxQuickReplies:
+			return Catalog{}, fmt.Errorf("template %q has more than %d quick replies", t.Name, maxQuickReplies)
+		}
+		seen[t.Name] = true
+	}
+	return catalog, nil
+}
+
+// Validate checks that the goals and personas the templates use exist
+func (c Catalog) Validate(goalCatalog *goals.Catalog, personaCatalog personas.Catalog) error {
+	for _, t := range c.Templates {
+		if t.Goal != "" {
+			if _, err := goalCatalog.Find(t.Goal); err != nil {
+				return fmt.Errorf("template %q: %w", t.Name, err)
+			}
+		}
+		if t.Persona != "" {
+			if _, err := personaCatalog.Resolve(t.Persona, ""); err != nil {
+				return fmt.Errorf("template %q: %w", t.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Find returns the named template
+func (c Catalog) Find(name string) (Template, error) {
+	i := slices.IndexFunc(c.Templates, func(t Template) bool { return t.Name == name })
+	if i < 0 {
+		return Template{}, fmt.Errorf("unknown template %q", name)
+	}
+	return c.Templates[i], nil
+}
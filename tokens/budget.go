@@ -0,0 +1,252 @@
+package tokens
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"temporal-ai-agent/activities/llm"
+)
+
+// Sections of a prompt the context budget is allocated across
+const (
+	SectionSystem = "system"
+	// SectionMemories holds recalled context such as few-shot examples
+	SectionMemories  = "memories"
+	SectionRetrieved = "retrieved"
+	SectionHistory   = "history"
+)
+
+// sections lists the sections in their default priority order
+var sections = []string{SectionSystem, SectionRetrieved, SectionHistory, SectionMemories}
+
+// trimmedPassages replaces retrieved passages left out of the prompt, so the tool call
+// they answer keeps a result
+const trimmedPassages = "[Passages left out to fit the context window]"
+
+// truncatedSuffix ends a passage cut to fit the context window
+const truncatedSuffix = "\n[truncated]"
+
+// Policy allocates a prompt token budget across the sections of the prompt. Each section
+// is guaranteed its share of the budget; what a section does not use goes to the others
+// by priority. Sections over their allocation are trimmed: the oldest history messages
+// are dropped (the latest message is always kept), the oldest retrieved passages are
+// left out and the latest cut, and the last memories are dropped. The system prompt is
+// never trimmed; when it outgrows its share, the difference is taken from the least
+// important sections.
+type Policy struct {
+	// Shares are the fractions of the budget reserved for each section
+	Shares map[string]float64
+	// Priority orders the sections from the most important
+	Priority []string
+}
+
+// DefaultPolicy returns the allocation used unless configured otherwise
+func DefaultPolicy() Policy {
+	return Policy{
+		Shares: map[string]float64{
+			SectionSystem:    0.2,
+			SectionMemories:  0.1,
+			SectionRetrieved: 0.3,
+			SectionHistory:   0.4,
+		},
+		Priority: slices.Clone(sections),
+	}
+}
+
+// ParsePolicy builds a policy from section=share items and a priority list. Sections
+// without a share keep their default one, and sections missing from the priority list
+// follow the listed ones in the default order.
+func ParsePolicy(shares, priority []string) (Policy, error) {
+	p := DefaultPolicy()
+	for _, item := range shares {
+		section, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return Policy{}, fmt.Errorf("invalid context share %q, expected section=share", item)
+		}
+		if !slices.Contains(sections, section) {
+			return Policy{}, fmt.Errorf("unknown context section %q", section)
+		}
+		share, err := strconv.ParseFloat(value, 64)
+		if err != nil || share < 0 || share > 1 {
+			return Policy{}, fmt.Errorf("invalid share %q of context section %s, expected 0 to 1", value, section)
+		}
+		p.Shares[section] = share
+	}
+	total := 0.0
+	for _, share := range p.Shares {
+		total += share
+	}
+	if total > 1.0001 {
+		return Policy{}, fmt.Errorf("context shares add up to %.2f, more than 1", total)
+	}
+
+	if len(priority) > 0 {
+		p.Priority = nil
+		for _, section := range priority {
+			if !slices.Contains(sections, section) {
+				return Policy{}, fmt.Errorf("unknown context section %q", section)
+			}
+			if slices.Contains(p.Priority, section) {
+				return Policy{}, fmt.Errorf("context section %q is listed twice", section)
+			}
+			p.Priority = append(p.Priority, section)
+		}
+		for _, section := range sections {
+			if !slices.Contains(p.Priority, section) {
+				p.Priority = append(p.Priority, section)
+			}
+		}
+	}
+	return p, nil
+}
+
+// SectionOf returns the section of a request message: its own, or the system prompt for
+// system messages and the history for the others
+func SectionOf(m llm.Message) string {
+	switch {
+	case m.Section != "":
+		return m.Section
+	case m.Role == llm.RoleSystem:
+		return SectionSystem
+	default:
+		return SectionHistory
+	}
+}
+
+// Fit trims the messages so the prompt fits in budget tokens, allocating the budget by
+// the policy. Messages that fit are returned unchanged; trimming the same messages always
+// gives the same result.
+func (p Policy) Fit(c *Counter, messages []llm.Message, budget int) []llm.Message {
+	if c.CountMessages(messages) <= budget {
+		return messages
+	}
+	if len(p.Priority) == 0 {
+		p = DefaultPolicy()
+	}
+	available := budget - tokensPerReply
+
+	fitted := slices.Clone(messages)
+	kept := make([]bool, len(fitted))
+	costs := make([]int, len(fitted))
+	need := map[string]int{}
+	for i, m := range fitted {
+		kept[i] = true
+		costs[i] = c.messageCost(m)
+		need[SectionOf(m)] += costs[i]
+	}
+	alloc := p.allocate(need, available)
+
+	// Trim each section to its allocation
+	for _, section := range sections {
+		var indexes []int
+		used := 0
+		for i, m := range fitted {
+			if SectionOf(m) == section {
+				indexes = append(indexes, i)
+				used += costs[i]
+			}
+		}
+		switch section {
+		case SectionHistory:
+			// Oldest first, always keeping the latest message
+			for n, i := range indexes {
+				if used <= alloc[section] || n == len(indexes)-1 {
+					break
+				}
+				kept[i] = false
+				used -= costs[i]
+			}
+		case SectionRetrieved:
+			// Oldest first; the latest passages are cut to what is left
+			for n, i := range indexes {
+				if used <= alloc[section] {
+					break
+				}
+				used -= costs[i]
+				if n == len(indexes)-1 {
+					room := alloc[section] - used - c.messageCost(llm.Message{Role: fitted[i].Role}) - c.Count(truncatedSuffix)
+					fitted[i].Content = c.Truncate(fitted[i].Content, max(room, 0)) + truncatedSuffix
+				} else {
+					fitted[i].Content = trimmedPassages
+				}
+				costs[i] = c.messageCost(fitted[i])
+				used += costs[i]
+			}
+		case SectionMemories:
+			// Last first, without leaving a question of an example unanswered
+			for n := len(indexes) - 1; n >= 0 && used > alloc[section]; n-- {
+				kept[indexes[n]] = false
+				used -= costs[indexes[n]]
+				if n > 0 && fitted[indexes[n-1]].Role == llm.RoleUser && fitted[indexes[n]].Role == llm.RoleAssistant {
+					n--
+					kept[indexes[n]] = false
+					used -= costs[indexes[n]]
+				}
+			}
+		}
+	}
+
+	result := fitted[:0]
+	for i, m := range fitted {
+		if kept[i] {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// allocate splits the available tokens across the sections needing them. Each section
+// gets its share; shares a section does not use, and shares of sections the prompt does
+// not have, go to the sections needing more by priority. The system prompt's overflow is
+// taken from the least important sections.
+func (p Policy) allocate(need map[string]int, available int) map[string]int {
+	alloc := map[string]int{}
+	spare := available
+	for _, section := range p.Priority {
+		alloc[section] = int(p.Shares[section] * float64(available))
+		spare -= alloc[section]
+	}
+	for _, section := range p.Priority {
+		if need[section] < alloc[section] {
+			spare += alloc[section] - need[section]
+			alloc[section] = need[section]
+		}
+	}
+	for _, section := range p.Priority {
+		if spare <= 0 {
+			break
+		}
+		give := min(spare, need[section]-alloc[section])
+		alloc[section] += give
+		spare -= give
+	}
+
+	deficit := need[SectionSystem] - alloc[SectionSystem]
+	alloc[SectionSystem] = need[SectionSystem]
+	for i := len(p.Priority) - 1; i >= 0 && deficit > 0; i-- {
+		if section := p.Priority[i]; section != SectionSystem {
+			take := min(deficit, alloc[section])
+			alloc[section] -= take
+			deficit -= take
+		}
+	}
+	return alloc
+}
+
+// messageCost is the number of prompt tokens a message uses
+func (c *Counter) messageCost(m llm.Message) int {
+	return tokensPerMessage + c.Count(m.Role) + c.Count(m.Content)
+}
+
+// Truncate returns the beginning of text that fits in n tokens
+func (c *Counter) Truncate(text string, n int) string {
+	if c.Count(text) <= n {
+		return text
+	}
+	// A cut may split a multi-byte character
+	if c.encoding == nil {
+		return strings.ToValidUTF8(text[:min(len(text), n*4)], "")
+	}
+	return strings.ToValidUTF8(c.encoding.Decode(c.encoding.EncodeOrdinary(text)[:n]), "")
+}
@@ -0,0 +1,76 @@
+package tokens
+
+import (
+	"strings"
+	"temporal-ai-agent/activities/llm"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// estimating counts four characters per token, so the allocations below are exact
+var estimating = &Counter{}
+
+func message(role, section string, chars int) llm.Message {
+	return llm.Message{Role: role, Section: section, Content: strings.Repeat("x", chars)}
+}
+
+func TestFitKeepsMessagesThatFit(t *testing.T) {
+	messages := []llm.Message{message(llm.RoleSystem, "", 40), message(llm.RoleUser, "", 40)}
+	require.Equal(t, messages, DefaultPolicy().Fit(estimating, messages, 100))
+}
+
+func TestFitDropsOldestHistory(t *testing.T) {
+	messages := []llm.Message{message(llm.RoleSystem, "", 40)}
+	for i := 0; i < 10; i++ {
+		messages = append(messages, llm.Message{Role: llm.RoleUser, Content: strings.Repeat(string(rune('a'+i)), 40)})
+	}
+
+	fitted := DefaultPolicy().Fit(estimating, messages, 60)
+	require.Equal(t, append([]llm.Message{messages[0]}, messages[8:]...), fitted)
+	require.LessOrEqual(t, estimating.CountMessages(fitted), 60)
+}
+
+func TestFitAlwaysKeepsLatestMessage(t *testing.T) {
+	messages := []llm.Message{message(llm.RoleUser, "", 40), message(llm.RoleUser, "", 400)}
+	fitted := DefaultPolicy().Fit(estimating, messages, 10)
+	require.Equal(t, messages[1:], fitted, "the budget is exhausted, the latest message stays")
+}
+
+func TestFitTrimsRetrievedPassages(t *testing.T) {
+	messages := []llm.Message{
+		message(llm.RoleSystem, "", 9),
+		message(llm.RoleUser, "", 9),
+		message(llm.RoleTool, SectionRetrieved, 400),
+		message(llm.RoleTool, SectionRetrieved, 400),
+		message(llm.RoleTool, SectionRetrieved, 400),
+	}
+
+	fitted := DefaultPolicy().Fit(estimating, messages, 150)
+	require.Len(t, fitted, 5, "passages are replaced, never dropped")
+	require.Equal(t, trimmedPassages, fitted[2].Content)
+	require.Equal(t, trimmedPassages, fitted[3].Content)
+	require.Equal(t, strings.Repeat("x", 372)+truncatedSuffix, fitted[4].Content, "the latest passage is cut")
+	require.LessOrEqual(t, estimating.CountMessages(fitted), 150)
+	require.Len(t, messages[4].Content, 400, "the messages are not modified")
+}
+
+func TestFitDropsLastExamplesInPairs(t *testing.T) {
+	messages := []llm.Message{
+		message(llm.RoleSystem, "", 9),
+		message(llm.RoleUser, SectionMemories, 40),
+		message(llm.RoleAssistant, SectionMemories, 40),
+		message(llm.RoleUser, SectionMemories, 41),
+		message(llm.RoleAssistant, SectionMemories, 41),
+		message(llm.RoleUser, "", 9),
+	}
+
+	fitted := DefaultPolicy().Fit(estimating, messages, 53)
+	require.Equal(t, []llm.Message{messages[0], messages[1], messages[2], messages[5]}, fitted)
+}
+
+func TestFitNeverTrimsSystemPrompt(t *testing.T) {
+	messages := []llm.Message{message(llm.RoleSystem, "", 400), message(llm.RoleUser, "", 40), message(llm.RoleUser, "", 40)}
+	fitted := DefaultPolicy().Fit(estimating, messages, 120)
+	require.Equal(t, []llm.Message{messages[0], messages[2]}, fitted, "the system prompt's overflow is taken from the history")
+}
@@ -0,0 +1,74 @@
+package tokens
+
+import (
+	"log"
+	"sync"
+	"temporal-ai-agent/activities/llm"
+
+	"github.com/pkoukk/tiktoken-go"
+)
+
+// DefaultEncoding is used for models tiktoken does not know, including local models
+const DefaultEncoding = "cl100k_base"
+
+// Chat formatting overhead, following OpenAI's guidance for counting chat tokens
+const (
+	tokensPerMessage = 3
+	tokensPerReply   = 3
+)
+
+// Counter counts tokens for one model
+type Counter struct {
+	encoding *tiktoken.Tiktoken
+}
+
+var (
+	mu       sync.Mutex
+	counters = map[string]*Counter{}
+)
+
+// ForModel returns the counter for a model, loading its encoding on first use.
+// Encodings are downloaded once and cached in TIKTOKEN_CACHE_DIR. When the encoding
+// cannot be loaded (e.g. offline) the counter falls back to an estimate.
+func ForModel(model string) *Counter {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if c, ok := counters[model]; ok {
+		return c
+	}
+
+	encoding, err := tiktoken.EncodingForModel(model)
+	if err != nil {
+		encoding, err = tiktoken.GetEncoding(DefaultEncoding)
+	}
+	if err != nil {
+		log.Printf("Warning: unable to load token encoding for %q, estimating token counts: %v", model, err)
+	}
+
+	c := &Counter{encoding: encoding}
+	counters[model] = c
+	return c
+}
+
+// Count returns the number of tokens in text
+func (c *Counter) Count(text string) int {
+	if c.encoding == nil {
+		return estimate(text)
+	}
+	return len(c.encoding.EncodeOrdinary(text))
+}
+
+// CountMessages returns the number of prompt tokens a chat request with these messages uses
+func (c *Counter) CountMessages(messages []llm.Message) int {
+	total := tokensPerReply
+	for _, m := range messages {
+		total += c.messageCost(m)
+	}
+	return total
+}
+
+// estimate approximates the token count when no encoding is available
+func estimate(text string) int {
+	return (len(text) + 3) / 4
+}
@@ -0,0 +1,24 @@
+package tools
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrPending is returned by the handler of an Async tool once it handed the call's
+// completion token to the system that finishes the call
+var ErrPending = errors.New("tool call completes asynchronously")
+
+type completionTokenKey struct{}
+
+// WithCompletionToken returns a context carrying the token that completes a tool call
+func WithCompletionToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, completionTokenKey{}, token)
+}
+
+// CompletionToken returns the token an external system posts to /activities/complete, with
+// the call's result, to finish an Async tool call. It is empty outside of the tool activity.
+func CompletionToken(ctx context.Context) string {
+	token, _ := ctx.Value(completionTokenKey{}).(string)
+	return token
+}
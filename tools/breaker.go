@@ -0,0 +1,117 @@
+package tools
+
+import (
+	"sync"
+	"time"
+)
+
+// Circuit breaker defaults
+const (
+	breakerWindow   = 20
+	breakerMinCalls = 5
+)
+
+// BreakerSettings configures the per-tool circuit breakers
+type BreakerSettings struct {
+	// FailureRate opens the breaker when this share of recent calls failed (0 disables breakers)
+	FailureRate float64
+	// Cooldown is how long an open breaker rejects calls before letting a trial call through
+	Cooldown time.Duration
+}
+
+// Breakers tracks the health of each tool and short-circuits calls to failing ones.
+// It is safe for concurrent use by activities.
+type Breakers struct {
+	settings BreakerSettings
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+// breaker is the state of one tool's circuit
+type breaker struct {
+	// results holds the outcomes of the most recent calls, true meaning failure
+	results   []bool
+	openUntil time.Time
+	// trialUntil is set while the single half-open call is in flight; a trial whose outcome
+	// is never recorded stops holding other calls back once it passes
+	trialUntil time.Time
+}
+
+// NewBreakers creates circuit breakers with the given settings
+func NewBreakers(settings BreakerSettings) *Breakers {
+	return &Breakers{settings: settings, breakers: map[string]*breaker{}}
+}
+
+// Allow reports whether a call to the tool may proceed. When it may not, it returns
+// how long the circuit stays open.
+func (b *Breakers) Allow(tool string, now time.Time) (bool, time.Duration) {
+	if b == nil || b.settings.FailureRate <= 0 {
+		return true, 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	br := b.get(tool)
+	if br.openUntil.IsZero() {
+		return true, 0
+	}
+	if now.Before(br.openUntil) {
+		return false, br.openUntil.Sub(now)
+	}
+	// Half-open: let one trial call through at a time
+	if now.Before(br.trialUntil) {
+		return false, br.trialUntil.Sub(now)
+	}
+	br.trialUntil = now.Add(b.settings.Cooldown)
+	return true, 0
+}
+
+// Record stores the outcome of a call and opens or closes the circuit accordingly
+func (b *Breakers) Record(tool string, failed bool, now time.Time) {
+	if b == nil || b.settings.FailureRate <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	br := b.get(tool)
+	if !br.trialUntil.IsZero() {
+		br.trialUntil = time.Time{}
+		if failed {
+			br.openUntil = now.Add(b.settings.Cooldown)
+			return
+		}
+		br.openUntil = time.Time{}
+		br.results = nil
+	}
+
+	br.results = append(br.results, failed)
+	if len(br.results) > breakerWindow {
+		br.results = br.results[1:]
+	}
+	if len(br.results) >= breakerMinCalls && failureRate(br.results) >= b.settings.FailureRate {
+		br.openUntil = now.Add(b.settings.Cooldown)
+		br.results = nil
+	}
+}
+
+// get returns the breaker of a tool, creating it if needed. The caller holds the lock.
+func (b *Breakers) get(tool string) *breaker {
+	br, ok := b.breakers[tool]
+	if !ok {
+		br = &breaker{}
+		b.breakers[tool] = br
+	}
+	return br
+}
+
+// failureRate returns the share of failed results
+func failureRate(results []bool) float64 {
+	failures := 0
+	for _, failed := range results {
+		if failed {
+			failures++
+		}
+	}
+	return float64(failures) / float64(len(results))
+}
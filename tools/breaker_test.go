@@ -0,0 +1,78 @@
+package tools
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var breakerStart = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// openBreakers returns breakers whose circuit for the tool just opened
+func openBreakers(t *testing.T, tool string) *Breakers {
+	b := NewBreakers(BreakerSettings{FailureRate: 0.5, Cooldown: time.Minute})
+	for i := 0; i < breakerMinCalls; i++ {
+		ok, _ := b.Allow(tool, breakerStart)
+		require.True(t, ok, "closed circuit")
+		b.Record(tool, true, breakerStart)
+	}
+	return b
+}
+
+func TestBreakerCycle(t *testing.T) {
+	b := openBreakers(t, "search")
+
+	ok, wait := b.Allow("search", breakerStart.Add(10*time.Second))
+	require.False(t, ok, "open circuit")
+	require.Equal(t, 50*time.Second, wait)
+	ok, _ = b.Allow("other", breakerStart)
+	require.True(t, ok, "breakers are per tool")
+
+	// Half-open: one trial at a time
+	halfOpen := breakerStart.Add(time.Minute)
+	ok, _ = b.Allow("search", halfOpen)
+	require.True(t, ok, "trial call")
+	ok, _ = b.Allow("search", halfOpen)
+	require.False(t, ok, "second call during the trial")
+
+	b.Record("search", false, halfOpen)
+	for i := 0; i < 3; i++ {
+		ok, _ = b.Allow("search", halfOpen)
+		require.True(t, ok, "closed again after a successful trial")
+	}
+}
+
+func TestBreakerFailedTrialReopens(t *testing.T) {
+	b := openBreakers(t, "search")
+	halfOpen := breakerStart.Add(time.Minute)
+	ok, _ := b.Allow("search", halfOpen)
+	require.True(t, ok)
+
+	b.Record("search", true, halfOpen)
+	ok, wait := b.Allow("search", halfOpen)
+	require.False(t, ok)
+	require.Equal(t, time.Minute, wait)
+}
+
+// A trial whose outcome is never recorded does not keep the circuit open forever
+func TestBreakerUnrecordedTrialExpires(t *testing.T) {
+	b := openBreakers(t, "search")
+	halfOpen := breakerStart.Add(time.Minute)
+	ok, _ := b.Allow("search", halfOpen)
+	require.True(t, ok)
+
+	ok, _ = b.Allow("search", halfOpen.Add(30*time.Second))
+	require.False(t, ok, "trial still in flight")
+	ok, _ = b.Allow("search", halfOpen.Add(time.Minute))
+	require.True(t, ok, "new trial once the lost one expired")
+}
+
+func TestBreakersDisabled(t *testing.T) {
+	b := NewBreakers(BreakerSettings{})
+	for i := 0; i < 2*breakerMinCalls; i++ {
+		b.Record("search", true, breakerStart)
+	}
+	ok, _ := b.Allow("search", breakerStart)
+	require.True(t, ok)
+}
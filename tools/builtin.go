@@ -0,0 +1,187 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Builtin returns the demo tools available without any external API, including those of
+// the goal packs
+func Builtin() *Registry {
+	r := NewRegistry(
+		Tool{
+			Definition: Definition{
+				Name:        "current_time",
+				Description: "Returns the current date and time in UTC.",
+				Parameters:  map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+				Mock:        true,
+				Risk:        RiskReadOnly,
+			},
+			Handler: currentTime,
+		},
+		Tool{
+			Definition: Definition{
+				Name:        "search_flights",
+				Description: "Searches flights between two airports on a date.",
+				Parameters: objectSchema([]string{"origin", "destination", "date"}, map[string]interface{}{
+					"origin":      stringSchema("IATA code of the departure airport"),
+					"destination": stringSchema("IATA code of the arrival airport"),
+					"date":        stringSchema("Travel date (YYYY-MM-DD)"),
+				}),
+				Mock:    true,
+				Risk:    RiskReadOnly,
+				Summary: "Search flights from {origin} to {destination} on {date}",
+			},
+			Handler: searchFlights,
+		},
+		Tool{
+			Definition: Definition{
+				Name:        "book_flight",
+				Description: "Books a seat on a flight for the user.",
+				Parameters: objectSchema([]string{"flight", "date"}, map[string]interface{}{
+					"flight": stringSchema("Flight number, e.g. AI-101"),
+					"date":   stringSchema("Travel date (YYYY-MM-DD)"),
+				}),
+				RequiresConfirmation: true,
+				Mock:                 true,
+				Risk:                 RiskIrreversible,
+				CompletesGoal:        true,
+				Summary:              "Book flight {flight} on {date}",
+			},
+			Handler: bookFlight,
+		},
+		Tool{
+			Definition: Definition{
+				Name:        "list_calendar_events",
+				Description: "Lists the user's calendar events on a date.",
+				Parameters: objectSchema([]string{"date"}, map[string]interface{}{
+					"date": stringSchema("Date (YYYY-MM-DD)"),
+				}),
+				Mock:    true,
+				Risk:    RiskReadOnly,
+				Summary: "List calendar events on {date}",
+			},
+			Handler: listCalendarEvents,
+		},
+		Tool{
+			Definition: Definition{
+				Name:        "list_tickets",
+				Description: "Lists the open support tickets assigned to the user.",
+				Parameters:  map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+				Mock:        true,
+				Risk:        RiskReadOnly,
+			},
+			Pages: listTickets,
+		},
+		Tool{
+			Definition: Definition{
+				Name:        "list_inbox",
+				Description: "Lists the unread messages in the user's inbox.",
+				Parameters:  map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+				Mock:        true,
+				Risk:        RiskReadOnly,
+			},
+			Handler: listInbox,
+		},
+	)
+	for _, t := range supportTools() {
+		r.Register(t)
+	}
+	return r
+}
+
+// currentTime returns the current UTC time
+func currentTime(ctx context.Context, args map[string]interface{}) (string, error) {
+	return time.Now().UTC().Format(time.RFC3339), nil
+}
+
+// searchFlights returns mock flights
+func searchFlights(ctx context.Context, args map[string]interface{}) (string, error) {
+	origin, err := StringArg(args, "origin")
+	if err != nil {
+		return "", err
+	}
+	destination, err := StringArg(args, "destination")
+	if err != nil {
+		return "", err
+	}
+	date, err := StringArg(args, "date")
+	if err != nil {
+		return "", err
+	}
+
+	code := strings.ToUpper(origin[:min(2, len(origin))])
+	return fmt.Sprintf("Flights %s → %s on %s: %s-101 06:10 (₹5400), %s-205 13:45 (₹4900), %s-319 21:30 (₹4600)",
+		strings.ToUpper(origin), strings.ToUpper(destination), date, code, code, code), nil
+}
+
+// bookFlight returns a mock booking reference derived from the flight and date
+func bookFlight(ctx context.Context, args map[string]interface{}) (string, error) {
+	flight, err := StringArg(args, "flight")
+	if err != nil {
+		return "", err
+	}
+	date, err := StringArg(args, "date")
+	if err != nil {
+		return "", err
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(flight + date))
+	return fmt.Sprintf("Booked %s on %s. Booking reference: PNR%06d", strings.ToUpper(flight), date, h.Sum32()%1000000), nil
+}
+
+// listCalendarEvents returns mock calendar events
+func listCalendarEvents(ctx context.Context, args map[string]interface{}) (string, error) {
+	date, err := StringArg(args, "date")
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Events on %s: 09:30 Team stand-up (15 min), 13:00 Lunch with the Mumbai partners, 16:00 Quarterly route review (1 h)", date), nil
+}
+
+// listTickets returns mock support tickets
+func listTickets(ctx context.Context, args map[string]interface{}, pageToken string) (Page, error) {
+	pages := []string{
+		"Open tickets: SUP-1042 Refund not received after cancellation (high, 3 days old), " +
+			"SUP-1047 Seat change on AI-205 (normal, 1 day old)",
+		"SUP-1051 Bag delayed at DEL (high, opened today), SUP-1053 Invoice missing GST number (low, opened today)",
+	}
+	i := 0
+	if pageToken != "" {
+		n, err := strconv.Atoi(pageToken)
+		if err != nil || n < 0 || n >= len(pages) {
+			return Page{}, Permanent(fmt.Errorf("invalid page token %q", pageToken))
+		}
+		i = n
+	}
+	page := Page{Result: pages[i]}
+	if i+1 < len(pages) {
+		page.NextPageToken = strconv.Itoa(i + 1)
+	}
+	return page, nil
+}
+
+// listInbox returns mock unread messages
+func listInbox(ctx context.Context, args map[string]interface{}) (string, error) {
+	return "Unread messages: finance@ asks for the October travel expenses by Friday; " +
+		"the ops lead moved the route review to 16:00; a customer thanks the team for a quick rebooking", nil
+}
+
+// objectSchema builds the JSON schema of an object with the given properties
+func objectSchema(required []string, properties map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// stringSchema builds the JSON schema of a string property
+func stringSchema(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "description": description}
+}
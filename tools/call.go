@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"context"
+	"errors"
+)
+
+type (
+	goalKey    struct{}
+	contextKey struct{}
+	accountKey struct{}
+)
+
+// WithGoal returns a context carrying the goal of the conversation making a tool call
+func WithGoal(ctx context.Context, goal string) context.Context {
+	return context.WithValue(ctx, goalKey{}, goal)
+}
+
+// GoalFrom returns the goal of the conversation making the tool call, or "" when unknown
+func GoalFrom(ctx context.Context) string {
+	goal, _ := ctx.Value(goalKey{}).(string)
+	return goal
+}
+
+// WithAccount returns a context carrying the quota account of the conversation making a
+// tool call
+func WithAccount(ctx context.Context, account string) context.Context {
+	return context.WithValue(ctx, accountKey{}, account)
+}
+
+// AccountFrom returns the quota account of the conversation making the tool call, or "" when
+// quotas are disabled
+func AccountFrom(ctx context.Context) string {
+	account, _ := ctx.Value(accountKey{}).(string)
+	return account
+}
+
+// WithConversationContext returns a context carrying the context values of the
+// conversation making a tool call (account ID, order ID, ...)
+func WithConversationContext(ctx context.Context, values map[string]string) context.Context {
+	return context.WithValue(ctx, contextKey{}, values)
+}
+
+// ContextValue returns a context value of the conversation making the tool call, or "" when
+// it was not started with one
+func ContextValue(ctx context.Context, key string) string {
+	values, _ := ctx.Value(contextKey{}).(map[string]string)
+	return values[key]
+}
+
+// permanentError marks a tool failure that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as a failure that retrying cannot fix, such as a call the
+// conversation is not allowed to make, so the agent sees it straight away
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent
+func IsPermanent(err error) bool {
+	return errors.As(err, &permanentError{})
+}
@@ -0,0 +1,36 @@
+package tools
+
+import "context"
+
+// Checkpoint stores the progress of a long tool, so a retried call resumes where the
+// previous attempt stopped instead of starting over
+type Checkpoint interface {
+	// Load decodes the progress saved by a previous attempt into v and reports whether
+	// there was any
+	Load(v interface{}) (bool, error)
+	// Save records progress. Long tools must save at least once per heartbeat timeout, or
+	// the attempt is considered lost and retried.
+	Save(v interface{})
+}
+
+type checkpointKey struct{}
+
+// WithCheckpoint returns a context carrying the checkpoint of a tool call
+func WithCheckpoint(ctx context.Context, cp Checkpoint) context.Context {
+	return context.WithValue(ctx, checkpointKey{}, cp)
+}
+
+// CheckpointFrom returns the checkpoint of the tool call. Outside of the tool activity
+// progress is not kept, so every call starts from scratch.
+func CheckpointFrom(ctx context.Context) Checkpoint {
+	if cp, ok := ctx.Value(checkpointKey{}).(Checkpoint); ok {
+		return cp
+	}
+	return noCheckpoint{}
+}
+
+// noCheckpoint discards progress
+type noCheckpoint struct{}
+
+func (noCheckpoint) Load(v interface{}) (bool, error) { return false, nil }
+func (noCheckpoint) Save(v interface{})               {}
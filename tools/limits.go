@@ -0,0 +1,41 @@
+package tools
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Limits bounds the size of a tool result kept in the conversation history. Zero values
+// leave that dimension unbounded.
+type Limits struct {
+	MaxBytes  int `json:"max_bytes,omitempty"`
+	MaxTokens int `json:"max_tokens,omitempty"`
+}
+
+// ResultLimits returns the limits of the tool's results: its own where it declares them,
+// the defaults otherwise
+func (d Definition) ResultLimits(defaults Limits) Limits {
+	limits := defaults
+	if d.MaxResultBytes > 0 {
+		limits.MaxBytes = d.MaxResultBytes
+	}
+	if d.MaxResultTokens > 0 {
+		limits.MaxTokens = d.MaxResultTokens
+	}
+	return limits
+}
+
+// Truncate cuts text to at most maxBytes bytes, at a character boundary, and notes how much
+// was cut so the model knows the result is incomplete
+func Truncate(text string, maxBytes int) string {
+	if maxBytes <= 0 || len(text) <= maxBytes {
+		return text
+	}
+	// The note is sized for the longest count it can show
+	note := fmt.Sprintf("\n[truncated: %d of %d bytes shown]", len(text), len(text))
+	keep := max(maxBytes-len(note), 0)
+	for keep > 0 && !utf8.RuneStart(text[keep]) {
+		keep--
+	}
+	return text[:keep] + fmt.Sprintf("\n[truncated: %d of %d bytes shown]", keep, len(text))
+}
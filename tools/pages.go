@@ -0,0 +1,76 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// DefaultMaxPages is how many pages of results a call to a paginated tool fetches when its
+// definition sets no MaxPages
+const DefaultMaxPages = 5
+
+// Page is one page of the results of a paginated tool
+type Page struct {
+	Result string `json:"result"`
+	// NextPageToken fetches the next page; it is empty on the last page
+	NextPageToken string `json:"next_page_token,omitempty"`
+}
+
+// PageHandler fetches the page of results a token designates, the first page for an empty
+// token. Tools backed by paginated APIs implement it instead of a Handler.
+type PageHandler func(ctx context.Context, args map[string]interface{}, pageToken string) (Page, error)
+
+// PageLimit returns the most pages a call to the tool fetches
+func (d Definition) PageLimit() int {
+	if d.MaxPages > 0 {
+		return d.MaxPages
+	}
+	return DefaultMaxPages
+}
+
+// MergePages joins the results of the pages fetched for a call, in order. When the API had
+// more pages than the limit, the result says so, so the model knows it is incomplete.
+func MergePages(pages []Page) string {
+	results := make([]string, 0, len(pages)+1)
+	for _, p := range pages {
+		if p.Result != "" {
+			results = append(results, p.Result)
+		}
+	}
+	if n := len(pages); n > 0 && pages[n-1].NextPageToken != "" {
+		results = append(results, fmt.Sprintf("[more results available beyond page %d]", n))
+	}
+	return strings.Join(results, "\n")
+}
+
+// ExecutePage fetches a page of a call's results. Tools without pages return their whole
+// result as a single page.
+func (r *Registry) ExecutePage(ctx context.Context, call Call, pageToken string) (Page, error) {
+	t, ok := r.tools[call.Name]
+	if !ok {
+		return Page{}, fmt.Errorf("unknown tool %q", call.Name)
+	}
+	if t.Pages == nil {
+		result, err := t.Handler(ctx, call.Args)
+		return Page{Result: result}, err
+	}
+	return t.Pages(ctx, call.Args, pageToken)
+}
+
+// executePages fetches the pages of a call up to the tool's page limit and merges them
+func executePages(ctx context.Context, t Tool, args map[string]interface{}) (string, error) {
+	var pages []Page
+	var token string
+	for len(pages) < t.PageLimit() {
+		page, err := t.Pages(ctx, args, token)
+		if err != nil {
+			return "", err
+		}
+		pages = append(pages, page)
+		if token = page.NextPageToken; token == "" {
+			break
+		}
+	}
+	return MergePages(pages), nil
+}
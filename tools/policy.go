@@ -0,0 +1,71 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// AnyGoal is the goal key of a policy applying to every goal without its own entry
+const AnyGoal = "*"
+
+// Policy restricts the tools available to a goal. The zero Policy allows every tool.
+type Policy struct {
+	// Allow lists the permitted tools; empty permits all tools not denied
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+	// MockOnly only permits tools that do not reach real systems
+	MockOnly bool `json:"mock_only,omitempty"`
+}
+
+// Policies maps environment names to per-goal policies
+type Policies map[string]map[string]Policy
+
+// LoadPolicies reads tool policies from a JSON file. An empty path returns no policies.
+func LoadPolicies(path string) (Policies, error) {
+	if path == "" {
+		return Policies{}, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading tool policy file: %w", err)
+	}
+	var policies Policies
+	if err := json.Unmarshal(data, &policies); err != nil {
+		return nil, fmt.Errorf("parsing tool policy file: %w", err)
+	}
+	return policies, nil
+}
+
+// Resolve returns the policy of a goal in an environment, falling back to the
+// environment's AnyGoal policy and then to allowing everything
+func (p Policies) Resolve(environment, goal string) Policy {
+	goals := p[environment]
+	if policy, ok := goals[goal]; ok {
+		return policy
+	}
+	return goals[AnyGoal]
+}
+
+// Permits reports whether the policy allows a tool
+func (p Policy) Permits(def Definition) bool {
+	if len(p.Allow) > 0 && !slices.Contains(p.Allow, def.Name) {
+		return false
+	}
+	if slices.Contains(p.Deny, def.Name) {
+		return false
+	}
+	return !p.MockOnly || def.Mock
+}
+
+// Filter returns the definitions the policy allows
+func (p Policy) Filter(defs []Definition) []Definition {
+	allowed := make([]Definition, 0, len(defs))
+	for _, def := range defs {
+		if p.Permits(def) {
+			allowed = append(allowed, def)
+		}
+	}
+	return allowed
+}
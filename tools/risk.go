@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Confirmation levels, from running a call straight away to making the user type a phrase
+const (
+	ConfirmNone    = "none"
+	ConfirmApprove = "approve"
+	ConfirmPhrase  = "phrase"
+)
+
+// confirmationLevels lists the confirmation levels from the weakest to the strongest
+var confirmationLevels = []string{ConfirmNone, ConfirmApprove, ConfirmPhrase}
+
+// RiskPolicy maps the risk levels of tools to the confirmation their calls require
+type RiskPolicy map[string]string
+
+// DefaultRiskPolicy runs read-only tools straight away and asks for approval of the others
+func DefaultRiskPolicy() RiskPolicy {
+	return RiskPolicy{
+		RiskReadOnly:     ConfirmNone,
+		RiskReversible:   ConfirmApprove,
+		RiskIrreversible: ConfirmApprove,
+	}
+}
+
+// ParseRiskPolicy overrides the default policy with risk=level items, e.g.
+// irreversible=phrase
+func ParseRiskPolicy(items []string) (RiskPolicy, error) {
+	p := DefaultRiskPolicy()
+	for _, item := range items {
+		risk, level, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid tool confirmation %q, expected risk=level", item)
+		}
+		if _, known := p[risk]; !known {
+			return nil, fmt.Errorf("unknown tool risk level %q", risk)
+		}
+		if !slices.Contains(confirmationLevels, level) {
+			return nil, fmt.Errorf("unknown confirmation level %q for %s tools, expected %s, %s or %s",
+				level, risk, ConfirmNone, ConfirmApprove, ConfirmPhrase)
+		}
+		p[risk] = level
+	}
+	return p, nil
+}
+
+// Apply sets the confirmation of each definition from its risk level. A tool marked
+// RequiresConfirmation is approved at least, whatever its risk.
+func (p RiskPolicy) Apply(defs []Definition) []Definition {
+	applied := make([]Definition, len(defs))
+	for i, def := range defs {
+		level, ok := p[def.RiskLevel()]
+		if !ok {
+			level = DefaultRiskPolicy()[def.RiskLevel()]
+		}
+		if def.RequiresConfirmation && level == ConfirmNone {
+			level = ConfirmApprove
+		}
+		def.Confirmation = level
+		applied[i] = def
+	}
+	return applied
+}
+
+// ConfirmationLevel returns the confirmation calls to the tool require: the one set by the
+// risk policy, or approval when RequiresConfirmation is set without a policy
+func (d Definition) ConfirmationLevel() string {
+	switch {
+	case d.Confirmation != "":
+		return d.Confirmation
+	case d.RequiresConfirmation:
+		return ConfirmApprove
+	default:
+		return ConfirmNone
+	}
+}
+
+// ConfirmationPhrase returns the phrase the user types to approve a call to the tool when
+// it requires ConfirmPhrase
+func (d Definition) ConfirmationPhrase() string {
+	return "confirm " + d.Name
+}
+
+// MatchesPhrase reports whether a typed phrase matches a confirmation phrase, ignoring case
+// and surrounding or repeated spaces
+func MatchesPhrase(phrase, typed string) bool {
+	return strings.EqualFold(strings.Join(strings.Fields(typed), " "), phrase)
+}
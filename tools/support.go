@@ -0,0 +1,148 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"strings"
+)
+
+// mockOrder is an order of the mock order API
+type mockOrder struct {
+	id     string
+	item   string
+	status string
+	total  int
+}
+
+// Catalog of the mock order API
+var (
+	mockItems    = []string{"Noise-cancelling headphones", "Trail running shoes", "Espresso machine", "Carry-on suitcase", "Smart watch"}
+	mockStatuses = []string{"processing", "shipped", "delivered", "delivered", "cancelled"}
+)
+
+// supportTools returns the mock tools of the support goal
+func supportTools() []Tool {
+	orderID := stringSchema("Order number; defaults to the order the conversation is about")
+	return []Tool{
+		{
+			Definition: Definition{
+				Name:        "lookup_order",
+				Description: "Looks up an order: the item, its status and the amount paid.",
+				Parameters: objectSchema([]string{}, map[string]interface{}{
+					"order_id": orderID,
+				}),
+				Mock:    true,
+				Risk:    RiskReadOnly,
+				Summary: "Look up order {order_id}",
+			},
+			Handler: lookupOrder,
+		},
+		{
+			Definition: Definition{
+				Name:        "initiate_refund",
+				Description: "Refunds the amount paid for an order to the customer's original payment method.",
+				Parameters: objectSchema([]string{"reason"}, map[string]interface{}{
+					"order_id": orderID,
+					"reason":   stringSchema("Why the customer is refunded"),
+				}),
+				RequiresConfirmation: true,
+				Mock:                 true,
+				Risk:                 RiskIrreversible,
+				CompletesGoal:        true,
+				Summary:              "Refund order {order_id} ({reason})",
+			},
+			Handler: initiateRefund,
+		},
+		{
+			Definition: Definition{
+				Name: "request_refund_exception",
+				Description: "Asks a supervisor to approve a refund the return policy does not allow. " +
+					"The supervisor answers from the approvals queue, which can take a while.",
+				Parameters: objectSchema([]string{"reason"}, map[string]interface{}{
+					"order_id": orderID,
+					"reason":   stringSchema("Why the customer should be refunded despite the policy"),
+				}),
+				Mock:    true,
+				Risk:    RiskReversible,
+				Async:   true,
+				Summary: "Ask a supervisor to approve refunding order {order_id} ({reason})",
+			},
+			Handler: requestRefundException,
+		},
+	}
+}
+
+// orderArg returns the order a call is about: its order_id argument, or the order_id
+// context value of the conversation
+func orderArg(ctx context.Context, args map[string]interface{}) (mockOrder, error) {
+	id, _ := args["order_id"].(string)
+	if id == "" {
+		id = ContextValue(ctx, "order_id")
+	}
+	if id == "" {
+		return mockOrder{}, Permanent(fmt.Errorf("argument %q is required: ask the customer for their order number", "order_id"))
+	}
+	return findOrder(id), nil
+}
+
+// findOrder returns the mock order with the given ID, derived from the ID so that lookups
+// are stable
+func findOrder(id string) mockOrder {
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToUpper(id)))
+	sum := h.Sum32()
+	return mockOrder{
+		id:     id,
+		item:   mockItems[sum%uint32(len(mockItems))],
+		status: mockStatuses[(sum/7)%uint32(len(mockStatuses))],
+		total:  int(999 + (sum/49)%15000),
+	}
+}
+
+// lookupOrder returns a mock order
+func lookupOrder(ctx context.Context, args map[string]interface{}) (string, error) {
+	order, err := orderArg(ctx, args)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Order %s: %s, %s, paid ₹%d", order.id, order.item, order.status, order.total), nil
+}
+
+// initiateRefund returns a mock refund reference. Orders that were cancelled have already
+// been refunded.
+func initiateRefund(ctx context.Context, args map[string]interface{}) (string, error) {
+	order, err := orderArg(ctx, args)
+	if err != nil {
+		return "", err
+	}
+	reason, err := StringArg(args, "reason")
+	if err != nil {
+		return "", err
+	}
+	if order.status == "cancelled" {
+		return "", Permanent(fmt.Errorf("order %s was cancelled and has already been refunded", order.id))
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(order.id + reason))
+	return fmt.Sprintf("Refund of ₹%d for order %s initiated (%s). Refund reference: RF%06d. It reaches the original payment method within 5 to 7 business days.",
+		order.total, order.id, reason, h.Sum32()%1000000), nil
+}
+
+// requestRefundException files a mock approval request. The approvals queue is the log:
+// the request is finished by posting its completion token to /activities/complete.
+func requestRefundException(ctx context.Context, args map[string]interface{}) (string, error) {
+	order, err := orderArg(ctx, args)
+	if err != nil {
+		return "", err
+	}
+	reason, err := StringArg(args, "reason")
+	if err != nil {
+		return "", err
+	}
+	log.Printf("Refund exception requested for order %s (₹%d, %s): complete it with task token %s",
+		order.id, order.total, reason, CompletionToken(ctx))
+	return "", ErrPending
+}
@@ -0,0 +1,183 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Risk levels of tools, from harmless to impossible to undo
+const (
+	RiskReadOnly     = "read_only"
+	RiskReversible   = "reversible"
+	RiskIrreversible = "irreversible"
+)
+
+// Definition describes a tool the agent can call
+type Definition struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Parameters is the JSON schema of the tool arguments
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	// RequiresConfirmation pauses the agent until the user approves, denies or modifies the
+	// call, even when the risk policy would run it straight away
+	RequiresConfirmation bool `json:"requires_confirmation,omitempty"`
+	// Confirmation is the Confirm level calls require, set from the deployment's RiskPolicy
+	// when the tools of a conversation are listed
+	Confirmation string `json:"confirmation,omitempty"`
+	// Mock marks tools that return canned data instead of reaching real systems
+	Mock bool `json:"mock,omitempty"`
+	// Risk is one of the Risk levels; empty is treated as irreversible
+	Risk string `json:"risk,omitempty"`
+	// CompletesGoal marks tools whose successful call achieves the user's goal (e.g. a
+	// booking), tracked as the goal_completed analytics event
+	CompletesGoal bool `json:"completes_goal,omitempty"`
+	// Long tools (ingestion, browser tasks) may run for up to 30 minutes. They must save
+	// progress through their Checkpoint at least every 30 seconds and resume from it.
+	Long bool `json:"long,omitempty"`
+	// Async tools finish outside the worker, e.g. once a person approves in another system:
+	// the handler hands CompletionToken to that system and returns ErrPending, and the
+	// system posts the result to /activities/complete within 24 hours
+	Async bool `json:"async,omitempty"`
+	// Summary describes a call for humans, with {arg} placeholders for its arguments,
+	// e.g. "Book flight {flight} on {date}"
+	Summary string `json:"summary,omitempty"`
+	// MaxResultBytes and MaxResultTokens override the worker's limits on the size of this
+	// tool's results (see Limits); 0 keeps the worker's
+	MaxResultBytes  int `json:"max_result_bytes,omitempty"`
+	MaxResultTokens int `json:"max_result_tokens,omitempty"`
+	// Paginated is set for tools implementing Pages: calls fetch up to MaxPages pages of
+	// results (DefaultMaxPages when 0), merged into one result
+	Paginated bool `json:"paginated,omitempty"`
+	MaxPages  int  `json:"max_pages,omitempty"`
+}
+
+// RiskLevel returns the risk of the tool, assuming the worst when it is not declared
+func (d Definition) RiskLevel() string {
+	if d.Risk == "" {
+		return RiskIrreversible
+	}
+	return d.Risk
+}
+
+// placeholder matches the {arg} placeholders of a summary
+var placeholder = regexp.MustCompile(`\{(\w+)\}`)
+
+// Summarize renders a human-readable description of a call to the tool. Placeholders of
+// missing arguments are kept as they are.
+func (d Definition) Summarize(args map[string]interface{}) string {
+	if d.Summary == "" {
+		return fmt.Sprintf("Run %s with %v", d.Name, args)
+	}
+	return placeholder.ReplaceAllStringFunc(d.Summary, func(match string) string {
+		if value, ok := args[match[1:len(match)-1]]; ok {
+			return fmt.Sprint(value)
+		}
+		return match
+	})
+}
+
+// Call is a tool invocation proposed by the model
+type Call struct {
+	ID   string                 `json:"id"`
+	Name string                 `json:"name"`
+	Args map[string]interface{} `json:"args,omitempty"`
+}
+
+// Handler executes a tool with the given arguments and returns its result as text
+type Handler func(ctx context.Context, args map[string]interface{}) (string, error)
+
+// Tool is a tool definition together with its implementation: a Handler, or Pages for
+// tools whose results come in pages
+type Tool struct {
+	Definition
+	Handler Handler
+	Pages   PageHandler
+}
+
+// Registry holds the tools available to the agent
+type Registry struct {
+	tools map[string]Tool
+	order []string
+}
+
+// NewRegistry creates a registry holding the given tools
+func NewRegistry(tools ...Tool) *Registry {
+	r := &Registry{tools: map[string]Tool{}}
+	for _, t := range tools {
+		r.Register(t)
+	}
+	return r
+}
+
+// Register adds a tool, replacing any tool with the same name
+func (r *Registry) Register(t Tool) {
+	if _, ok := r.tools[t.Name]; !ok {
+		r.order = append(r.order, t.Name)
+	}
+	t.Paginated = t.Pages != nil
+	r.tools[t.Name] = t
+}
+
+// Definitions returns the definitions of all tools in registration order
+func (r *Registry) Definitions() []Definition {
+	defs := make([]Definition, 0, len(r.order))
+	for _, name := range r.order {
+		defs = append(defs, r.tools[name].Definition)
+	}
+	return defs
+}
+
+// Execute runs a tool call. Paginated tools fetch their pages up to their limit.
+func (r *Registry) Execute(ctx context.Context, call Call) (string, error) {
+	t, ok := r.tools[call.Name]
+	if !ok {
+		return "", fmt.Errorf("unknown tool %q", call.Name)
+	}
+	if t.Pages != nil {
+		return executePages(ctx, t, call.Args)
+	}
+	return t.Handler(ctx, call.Args)
+}
+
+// Find returns the definition of the named tool
+func Find(defs []Definition, name string) (Definition, bool) {
+	for _, d := range defs {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return Definition{}, false
+}
+
+// StringArg returns a string argument, or an error if it is missing
+func StringArg(args map[string]interface{}, name string) (string, error) {
+	value, ok := args[name].(string)
+	if !ok || value == "" {
+		return "", fmt.Errorf("argument %q is required", name)
+	}
+	return value, nil
+}
+
+// IntArg returns an integer argument, or def when it is missing. Models sometimes send
+// numbers as strings, so those are accepted too.
+func IntArg(args map[string]interface{}, name string, def int) (int, error) {
+	switch value := args[name].(type) {
+	case nil:
+		return def, nil
+	case float64:
+		if value == math.Trunc(value) {
+			return int(value), nil
+		}
+	case int:
+		return value, nil
+	case string:
+		if n, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("argument %q must be an integer", name)
+}
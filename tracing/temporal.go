@@ -0,0 +1,109 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.temporal.io/sdk/interceptor"
+)
+
+// headerKey is the Temporal header carrying the trace to workflows and activities
+const headerKey = "_tracer-data"
+
+// spanContextKey keys the current span in workflow contexts
+type spanContextKey struct{}
+
+// Interceptor returns the Temporal interceptor tracing the client's calls and, on workers,
+// the workflows and activities they run. Set it on the client options: workers created
+// from the client use it too.
+func Interceptor() interceptor.Interceptor {
+	return interceptor.NewTracingInterceptor(temporalTracer{})
+}
+
+// temporalTracer adapts OpenTelemetry to the SDK's tracing interceptor. Traces cross
+// Temporal headers as W3C trace context, whatever the global propagator is, so the API
+// server and the workers agree on it.
+type temporalTracer struct {
+	interceptor.BaseTracer
+}
+
+// temporalSpan is a span the tracer started or found in a context
+type temporalSpan struct {
+	trace.Span
+}
+
+// temporalSpanRef is a span read from a Temporal header, started by another process
+type temporalSpanRef struct {
+	trace.SpanContext
+}
+
+func (temporalTracer) Options() interceptor.TracerOptions {
+	return interceptor.TracerOptions{
+		SpanContextKey: spanContextKey{},
+		HeaderKey:      headerKey,
+		// Queries are frequent (stream polls, dashboards) and change nothing
+		DisableQueryTracing: true,
+	}
+}
+
+func (temporalTracer) UnmarshalSpan(m map[string]string) (interceptor.TracerSpanRef, error) {
+	ctx := propagation.TraceContext{}.Extract(context.Background(), propagation.MapCarrier(m))
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return nil, fmt.Errorf("invalid span context in the Temporal header")
+	}
+	return temporalSpanRef{spanContext}, nil
+}
+
+func (temporalTracer) MarshalSpan(span interceptor.TracerSpan) (map[string]string, error) {
+	m := map[string]string{}
+	ctx := trace.ContextWithSpan(context.Background(), span.(temporalSpan).Span)
+	propagation.TraceContext{}.Inject(ctx, propagation.MapCarrier(m))
+	return m, nil
+}
+
+func (temporalTracer) SpanFromContext(ctx context.Context) interceptor.TracerSpan {
+	span := trace.SpanFromContext(ctx)
+	if !span.SpanContext().IsValid() {
+		return nil
+	}
+	return temporalSpan{span}
+}
+
+func (temporalTracer) ContextWithSpan(ctx context.Context, span interceptor.TracerSpan) context.Context {
+	return trace.ContextWithSpan(ctx, span.(temporalSpan).Span)
+}
+
+func (temporalTracer) StartSpan(opts *interceptor.TracerStartSpanOptions) (interceptor.TracerSpan, error) {
+	ctx := context.Background()
+	switch parent := opts.Parent.(type) {
+	case temporalSpan:
+		ctx = trace.ContextWithSpan(ctx, parent.Span)
+	case temporalSpanRef:
+		ctx = trace.ContextWithRemoteSpanContext(ctx, parent.SpanContext)
+	}
+
+	attrs := make([]attribute.KeyValue, 0, len(opts.Tags))
+	for k, v := range opts.Tags {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+	spanOpts := []trace.SpanStartOption{trace.WithAttributes(attrs...)}
+	if !opts.Time.IsZero() {
+		spanOpts = append(spanOpts, trace.WithTimestamp(opts.Time))
+	}
+	_, span := tracer().Start(ctx, opts.Operation+":"+opts.Name, spanOpts...)
+	return temporalSpan{span}, nil
+}
+
+// Finish ends the span, marking it failed when the traced code returned an error
+func (s temporalSpan) Finish(opts *interceptor.TracerFinishSpanOptions) {
+	if opts.Error != nil {
+		s.RecordError(opts.Error)
+		s.SetStatus(codes.Error, opts.Error.Error())
+	}
+	s.End()
+}
@@ -0,0 +1,76 @@
+// Package tracing traces conversations with OpenTelemetry. The API server's requests, the
+// Temporal calls they make, the workflows and activities those run and the worker's calls
+// to LLM and tool providers share one trace: the API server starts it, Temporal headers
+// carry it to the workflow and its activities, and the providers' HTTP clients continue it.
+// Spans are exported over OTLP; without an endpoint they are created but dropped.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentation names the tracer of the agent's own spans
+const instrumentation = "temporal-ai-agent"
+
+// Setup installs the global tracer provider, exporting spans in batches to the OTLP/gRPC
+// endpoint (e.g. http://localhost:4317) as the given service. OTEL_SERVICE_NAME, the
+// OTEL_EXPORTER_OTLP_* and OTEL_TRACES_SAMPLER variables are honored. It returns the
+// function flushing the spans left on shutdown; an empty endpoint leaves tracing disabled.
+func Setup(ctx context.Context, service, endpoint string) (func(context.Context) error, error) {
+	if endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("creating the OTLP exporter: %w", err)
+	}
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(service)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK())
+	if err != nil {
+		return nil, fmt.Errorf("describing the service: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// Middleware traces the requests of a mux router, continuing the trace of the caller when
+// it sent one. Spans are named after the route's path template, like the API's metrics.
+func Middleware(next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "", otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				return r.Method + " " + template
+			}
+		}
+		return r.Method
+	}))
+}
+
+// Transport traces the requests sent through a transport as client spans, and sends the
+// trace along to the server
+func Transport(base http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(base)
+}
+
+// tracer returns the tracer of the agent's spans, from the global provider
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentation)
+}
@@ -0,0 +1,164 @@
+package websearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"temporal-ai-agent/httpclient"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Result is a single web search hit
+type Result struct {
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Snippet string `json:"snippet"`
+}
+
+// Searcher is implemented by every web search backend
+type Searcher interface {
+	Search(ctx context.Context, query string, limit int) ([]Result, error)
+}
+
+// New returns the searcher registered under the given name; opts tunes the connection
+// pool of its client
+func New(name, apiKey string, opts httpclient.Options) (Searcher, error) {
+	switch name {
+	case "mock":
+		return &Mock{}, nil
+	case "brave":
+		if apiKey == "" {
+			return nil, fmt.Errorf("WEB_SEARCH_API_KEY is required for the brave search provider")
+		}
+		return &Brave{APIKey: apiKey, Client: httpclient.New(30*time.Second, opts)}, nil
+	default:
+		return nil, fmt.Errorf("unknown web search provider %q", name)
+	}
+}
+
+// Mock returns canned results so research can run without a search API
+type Mock struct{}
+
+// Search returns placeholder results whose snippets echo the query
+func (m *Mock) Search(ctx context.Context, query string, limit int) ([]Result, error) {
+	results := make([]Result, 0, limit)
+	for i := 1; i <= limit && i <= 2; i++ {
+		results = append(results, Result{
+			Title:   fmt.Sprintf("Mock result %d for %q", i, query),
+			URL:     fmt.Sprintf("https://example.com/search?q=%s&r=%d", url.QueryEscape(query), i),
+			Snippet: fmt.Sprintf("(mock) Placeholder information about %s.", query),
+		})
+	}
+	return results, nil
+}
+
+// Brave searches the web with the Brave Search API
+type Brave struct {
+	APIKey string
+	Client *http.Client
+}
+
+// Search calls the Brave web search endpoint
+func (b *Brave) Search(ctx context.Context, query string, limit int) ([]Result, error) {
+	endpoint := "https://api.search.brave.com/res/v1/web/search?" + url.Values{
+		"q":     {query},
+		"count": {fmt.Sprint(limit)},
+	}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Subscription-Token", b.APIKey)
+
+	resp, err := b.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("brave search returned %s: %s", resp.Status, body)
+	}
+
+	var payload struct {
+		Web struct {
+			Results []struct {
+				Title       string `json:"title"`
+				URL         string `json:"url"`
+				Description string `json:"description"`
+			} `json:"results"`
+		} `json:"web"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0, len(payload.Web.Results))
+	for _, r := range payload.Web.Results {
+		results = append(results, Result{Title: r.Title, URL: r.URL, Snippet: r.Description})
+	}
+	return results, nil
+}
+
+// FetchText downloads a page and returns its visible text, truncated to maxBytes
+func FetchText(ctx context.Context, client *http.Client, pageURL string, maxBytes int) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", "temporal-ai-agent/1.0")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetching %s: %s", pageURL, resp.Status)
+	}
+
+	// Read a bounded amount of markup; text is usually a fraction of it
+	doc, err := html.Parse(io.LimitReader(resp.Body, int64(maxBytes)*10))
+	if err != nil {
+		return "", err
+	}
+
+	text := ExtractText(doc)
+	if len(text) > maxBytes {
+		text = text[:maxBytes]
+	}
+	return text, nil
+}
+
+// ExtractText returns the visible text of an HTML document, skipping scripts and styles
+func ExtractText(doc *html.Node) string {
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "script", "style", "noscript", "head", "nav", "footer":
+				return
+			}
+		}
+		if n.Type == html.TextNode {
+			if text := strings.TrimSpace(n.Data); text != "" {
+				b.WriteString(text)
+				b.WriteString(" ")
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
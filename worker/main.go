@@ -1,83 +1,130 @@
 package main
 
 import (
-	"crypto/tls"
+	"context"
+	"flag"
 	"log"
-	"os"
-	"strconv"
-	"temporal-ai-agent/activities"
-	"temporal-ai-agent/workflows"
+	"net/http"
+	"temporal-ai-agent/bootstrap"
+	"temporal-ai-agent/config"
+	"temporal-ai-agent/failover"
+	"temporal-ai-agent/metrics"
+	"temporal-ai-agent/registry"
+	"temporal-ai-agent/selftest"
+	"temporal-ai-agent/tracing"
 
-	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/worker"
 )
 
 func main() {
-	// Load environment variables from .env file
-	err := godotenv.Load()
-	if err != nil {
+	selfTest := flag.Bool("selftest", false, "check that the dependencies are reachable, print a report and exit")
+	flag.Parse()
+
+	// Load configuration from .env file and environment variables
+	cfg, found := config.Load()
+	if !found {
 		log.Println("Warning: .env file not found, using system environment variables")
 	}
 
-	// Get configuration from environment variables
-	hostPort := getEnv("TEMPORAL_HOST_PORT", "localhost:7233")
-	namespace := getEnv("TEMPORAL_NAMESPACE", "default")
-	apiKey := getEnv("TEMPORAL_API_KEY", "")
-	taskQueue := getEnv("TEMPORAL_TASK_QUEUE", "my-task-queue")
-	tlsEnabled := getEnvBool("TEMPORAL_TLS_ENABLED", false)
-
 	// Validate required environment variables
-	if apiKey == "" {
-		log.Fatal("TEMPORAL_API_KEY environment variable is required")
+	if err := cfg.Validate(); err != nil {
+		log.Fatal(err)
 	}
-
-	// Configure client options
-	clientOptions := client.Options{
-		HostPort:  hostPort,
-		Namespace: namespace,
+	if *selfTest {
+		selftest.Main(selftest.Worker(cfg))
 	}
 
-	// Configure TLS if enabled
-	if tlsEnabled {
-		clientOptions.ConnectionOptions = client.ConnectionOptions{TLS: &tls.Config{}}
+	acts, err := registry.NewActivities(cfg)
+	if err != nil {
+		log.Fatalln("Unable to create activities", err)
+	}
+	if err := registry.ConfigureWorkflows(cfg); err != nil {
+		log.Fatalln("Unable to configure workflows", err)
 	}
 
-	// Configure credentials
-	clientOptions.Credentials = client.NewAPIKeyStaticCredentials(apiKey)
+	// Traces are exported to the collector, if any, and flushed on exit
+	shutdownTracing, err := tracing.Setup(context.Background(), "temporal-ai-agent-worker", cfg.OTLPEndpoint)
+	if err != nil {
+		log.Fatalln("Unable to set up tracing", err)
+	}
+	defer shutdownTracing(context.Background())
 
-	c, err := client.Dial(clientOptions)
+	// The SDK's worker metrics (task slots, poll and activity latencies) are served by
+	// /metrics; every client the failover dials records them through the same handler, and
+	// its workers trace the workflows and activities they run
+	metricsRegistry := metrics.NewRegistry()
+	temporalMetrics := metrics.NewTemporal(metricsRegistry)
+	fc, err := failover.Dial(cfg.HostPort, cfg.SecondaryHostPort, func(hostPort string) client.Options {
+		options := cfg.ClientOptionsFor(hostPort)
+		options.MetricsHandler = temporalMetrics
+		options.Interceptors = append(options.Interceptors, tracing.Interceptor())
+		return options
+	})
 	if err != nil {
 		log.Fatalln("Unable to create client", err)
 	}
-	defer c.Close()
+	defer fc.Close()
 
-	w := worker.New(c, taskQueue, worker.Options{})
+	lanes := registry.Lanes(cfg)
+	if cfg.WorkerMetricsPort != "" {
+		metricsRegistry.MustRegister(metrics.NewBacklog(cfg.Namespace, fc.Current, registry.TaskQueues(lanes)...))
+		go serveMetrics(":"+cfg.WorkerMetricsPort, metricsRegistry)
+	}
 
-	w.RegisterWorkflow(workflows.SayHelloWorkflow)
-	w.RegisterActivity(activities.Greet)
+	// Provision the namespace on first use; the agent still runs without search attributes,
+	// only the features listing conversations by them fail
+	if cfg.BootstrapNamespace {
+		if err := bootstrap.Run(context.Background(), fc.Current(), cfg); err != nil {
+			log.Printf("Warning: namespace bootstrap incomplete: %v", err)
+		}
+	}
 
-	err = w.Run(worker.InterruptCh())
-	if err != nil {
-		log.Fatalln("Unable to start worker", err)
+	// Pay the providers' cold start before the first turn does
+	if cfg.LLMWarmup {
+		if err := registry.Warmup(context.Background(), acts, cfg.LLMWarmupTimeout); err != nil {
+			log.Printf("Warning: LLM warmup incomplete: %v", err)
+		}
 	}
 
-}
+	// Workers are bound to their client, so a failover restarts them on the new client. The
+	// previous client is only closed once its workers have stopped.
+	failovers := make(chan client.Client)
+	stopped := make(chan struct{})
+	fc.OnFailover(func(c client.Client) {
+		failovers <- c
+		<-stopped
+	})
+	go fc.Watch(context.Background(), cfg.FailoverCheckInterval)
 
-// getEnv gets an environment variable with a fallback default value
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+	interrupt := worker.InterruptCh()
+	c := fc.Current()
+	for {
+		workers, err := registry.Start(c, lanes, acts)
+		if err != nil {
+			log.Fatalln("Unable to start worker", err)
+		}
+
+		select {
+		case <-interrupt:
+			registry.Stop(workers)
+			return
+		case c = <-failovers:
+			log.Printf("Restarting worker on Temporal at %s", fc.Endpoint())
+			registry.Stop(workers)
+			stopped <- struct{}{}
+		}
 	}
-	return defaultValue
 }
 
-// getEnvBool gets a boolean environment variable with a fallback default value
-func getEnvBool(key string, defaultValue bool) bool {
-	if value := os.Getenv(key); value != "" {
-		if parsed, err := strconv.ParseBool(value); err == nil {
-			return parsed
-		}
+// serveMetrics serves the worker's metrics on /metrics. The worker keeps running without
+// them if the port is taken.
+func serveMetrics(addr string, metricsRegistry *prometheus.Registry) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler(metricsRegistry))
+	log.Printf("Serving worker metrics on %s/metrics", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Printf("Warning: unable to serve worker metrics: %v", err)
 	}
-	return defaultValue
 }
@@ -0,0 +1,75 @@
+package workflows
+
+import (
+	"fmt"
+	"temporal-ai-agent/activities"
+	"temporal-ai-agent/analytics"
+	"temporal-ai-agent/retries"
+	"temporal-ai-agent/workflowutil"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// SignalFeedback tells whether the user is satisfied with the conversation
+const SignalFeedback = "feedback"
+
+// Feedback is the payload of the feedback signal
+type Feedback struct {
+	Satisfied bool   `json:"satisfied"`
+	Comment   string `json:"comment,omitempty"`
+}
+
+// track queues an analytics event; queued events are sent when the turn completes
+func (c *conversation) track(ctx workflow.Context, name string, properties map[string]interface{}) {
+	info := workflow.GetInfo(ctx)
+	c.analyticsSeq++
+	c.analytics = append(c.analytics, analytics.Event{
+		ID:             fmt.Sprintf("%s/%d", info.WorkflowExecution.RunID, c.analyticsSeq),
+		Name:           name,
+		ConversationID: c.conversationID,
+		Goal:           c.goal,
+		Properties:     properties,
+		Time:           workflowutil.Now(ctx),
+	})
+}
+
+// completeTurn tracks the end of a turn started at the given time and sends the queued
+// analytics events. Sending is best effort: lost events must not fail the turn.
+func (c *conversation) completeTurn(ctx workflow.Context, started time.Time, degraded bool, err error) {
+	if temporal.IsCanceledError(err) {
+		return
+	}
+	c.track(ctx, analytics.EventTurnCompleted, map[string]interface{}{
+		"duration_ms": workflowutil.Now(ctx).Sub(started).Milliseconds(),
+		"degraded":    degraded,
+		"failed":      err != nil,
+	})
+	c.sendAnalytics(ctx)
+}
+
+// sendAnalytics sends the queued analytics events
+func (c *conversation) sendAnalytics(ctx workflow.Context) {
+	if len(c.analytics) == 0 {
+		return
+	}
+	events := c.analytics
+	c.analytics = nil
+
+	var a *activities.Activities
+	ctx = withRetries(ctx, retries.Internal)
+	if err := workflow.ExecuteActivity(ctx, a.TrackEvents, events).Get(ctx, nil); err != nil {
+		workflow.GetLogger(ctx).Error("Error sending analytics events", "error", err)
+	}
+}
+
+// handleFeedback tracks and stores whether the user is satisfied
+func (c *conversation) handleFeedback(ctx workflow.Context, feedback Feedback) {
+	c.track(ctx, analytics.EventUserSatisfied, map[string]interface{}{
+		"satisfied": feedback.Satisfied,
+		"comment":   feedback.Comment,
+	})
+	c.sendAnalytics(ctx)
+	c.saveFeedback(ctx, feedback)
+}
@@ -0,0 +1,99 @@
+package workflows
+
+import (
+	"fmt"
+	"slices"
+	"temporal-ai-agent/workflowutil"
+	"time"
+	"unicode/utf8"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// Names used by message annotations
+const (
+	UpdateAnnotateMessage = "annotate_message"
+	QueryAnnotations      = "annotations"
+)
+
+// Kinds of annotations. Users react to the agent's messages; operators label messages and
+// leave internal notes on them.
+const (
+	AnnotationReaction = "reaction"
+	AnnotationLabel    = "label"
+	AnnotationNote     = "note"
+)
+
+// Bounds of the annotations kept in the workflow state
+const (
+	maxAnnotations    = 500
+	maxReactionLength = 32
+	maxLabelLength    = 64
+	maxNoteLength     = 2000
+)
+
+// Annotation is a reaction, label or note attached to a message of the history. Annotations
+// are kept with the transcript but never sent to the LLM.
+type Annotation struct {
+	// MessageIndex is the position of the message in the history
+	MessageIndex int    `json:"message_index"`
+	Kind         string `json:"kind"`
+	Value        string `json:"value"`
+	// Author is the operator who added a label or note; reactions come from the user
+	Author    string    `json:"author,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// validateAnnotation rejects annotations of unknown kinds, out of range messages and
+// duplicates
+func (c *conversation) validateAnnotation(ctx workflow.Context, a Annotation) error {
+	limit := 0
+	switch a.Kind {
+	case AnnotationReaction:
+		limit = maxReactionLength
+	case AnnotationLabel:
+		limit = maxLabelLength
+	case AnnotationNote:
+		limit = maxNoteLength
+	default:
+		return fmt.Errorf("kind must be %s, %s or %s", AnnotationReaction, AnnotationLabel, AnnotationNote)
+	}
+	if a.Value == "" {
+		return fmt.Errorf("value is required")
+	}
+	if utf8.RuneCountInString(a.Value) > limit {
+		return fmt.Errorf("a %s is at most %d characters", a.Kind, limit)
+	}
+	if a.Kind != AnnotationReaction && a.Author == "" {
+		return fmt.Errorf("a %s needs an author", a.Kind)
+	}
+	if a.MessageIndex < 0 || a.MessageIndex >= len(c.history) {
+		return fmt.Errorf("message_index %d out of range", a.MessageIndex)
+	}
+	if len(c.annotations) >= maxAnnotations {
+		return fmt.Errorf("conversation already has %d annotations", maxAnnotations)
+	}
+	if slices.ContainsFunc(c.annotations, func(existing Annotation) bool {
+		return existing.MessageIndex == a.MessageIndex && existing.Kind == a.Kind &&
+			existing.Value == a.Value && existing.Author == a.Author
+	}) {
+		return fmt.Errorf("message %d already has this %s", a.MessageIndex, a.Kind)
+	}
+	return nil
+}
+
+// annotateMessage attaches an annotation to a message. It does not wait for the turn in
+// flight: annotations only point at messages already in the history.
+func (c *conversation) annotateMessage(ctx workflow.Context, a Annotation) (Annotation, error) {
+	a.CreatedAt = workflowutil.Now(ctx)
+	c.annotations = append(c.annotations, a)
+	return a, nil
+}
+
+// truncateAnnotations forgets the annotations of the messages from the given history
+// position on, after that part of the history was discarded
+func (c *conversation) truncateAnnotations(index int) {
+	c.annotations = slices.DeleteFunc(c.annotations, func(a Annotation) bool {
+		return a.MessageIndex >= index
+	})
+}
@@ -0,0 +1,64 @@
+package workflows
+
+import (
+	"fmt"
+	"strings"
+	"temporal-ai-agent/activities"
+	"temporal-ai-agent/extract"
+	"temporal-ai-agent/retries"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// maxAttachmentChars bounds the text of one attachment added to a user message
+const maxAttachmentChars = 20000
+
+// extractTimeout leaves room for OCR of long scans
+const extractTimeout = 5 * time.Minute
+
+// Attachment is a document sent with a user message (PDF, DOCX, HTML, text or an image)
+type Attachment struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type,omitempty"`
+	// Data is the document itself, base64-encoded in JSON
+	Data []byte `json:"data"`
+}
+
+// withAttachments returns the prompt's message followed by the text of its attachments.
+// Attachments that cannot be read are reported in their place so the agent can tell the user.
+func (c *conversation) withAttachments(ctx workflow.Context, prompt UserPrompt) string {
+	if len(prompt.Attachments) == 0 {
+		return prompt.Message
+	}
+	var a *activities.Activities
+	ao := workflow.GetActivityOptions(ctx)
+	ao.StartToCloseTimeout = extractTimeout
+	ctx = withRetries(workflow.WithActivityOptions(ctx, ao), retries.ExternalAPI)
+
+	futures := make([]workflow.Future, len(prompt.Attachments))
+	for i, att := range prompt.Attachments {
+		futures[i] = workflow.ExecuteActivity(ctx, a.ExtractText, activities.ExtractTextRequest{
+			Name:        att.Name,
+			ContentType: att.ContentType,
+			Data:        att.Data,
+		})
+	}
+
+	var b strings.Builder
+	b.WriteString(prompt.Message)
+	for i, att := range prompt.Attachments {
+		var res extract.Result
+		if err := futures[i].Get(ctx, &res); err != nil {
+			workflow.GetLogger(ctx).Warn("Unreadable attachment", "name", att.Name, "error", err)
+			fmt.Fprintf(&b, "\n\n[Attachment %s could not be read: %s]", att.Name, failureReason(err))
+			continue
+		}
+		text := res.Text
+		if len(text) > maxAttachmentChars {
+			text = text[:maxAttachmentChars] + "\n[truncated]"
+		}
+		fmt.Fprintf(&b, "\n\n[Attachment %s]\n%s", att.Name, text)
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,209 @@
+package workflows
+
+import (
+	"fmt"
+	"strings"
+	"temporal-ai-agent/activities"
+	"temporal-ai-agent/workflowutil"
+	"time"
+
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/workflow"
+)
+
+// Names used by background tasks
+const (
+	UpdateStartBackgroundTask = "start_background_task"
+	QueryBackgroundTasks      = "background_tasks"
+	QueryTaskStatus           = "status"
+	SignalCancelTask          = "cancel"
+	signalTaskReport          = "background_task_report"
+)
+
+// Background task limits
+const (
+	defaultTaskInterval = 24 * time.Hour
+	minTaskInterval     = time.Minute
+	defaultTaskMaxRuns  = 30
+	maxTaskResults      = 10
+)
+
+// BackgroundTask describes a recurring job the agent runs on the user's behalf
+type BackgroundTask struct {
+	Description string        `json:"description"`
+	Interval    time.Duration `json:"interval"`
+	MaxRuns     int           `json:"max_runs"`
+	// ParentWorkflowID is the conversation that receives progress reports
+	ParentWorkflowID string `json:"parent_workflow_id,omitempty"`
+	// Account is the quota account of that conversation, whose own LLM key runs the task
+	Account string `json:"account,omitempty"`
+}
+
+// TaskRun is the outcome of one run of a background task
+type TaskRun struct {
+	Time   time.Time `json:"time"`
+	Result string    `json:"result,omitempty"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// TaskStatus is the state of a background task returned by its status query
+type TaskStatus struct {
+	Description string    `json:"description"`
+	Runs        int       `json:"runs"`
+	Done        bool      `json:"done"`
+	Recent      []TaskRun `json:"recent"`
+}
+
+// BackgroundTaskRef tracks a background task in the conversation that started it
+type BackgroundTaskRef struct {
+	WorkflowID  string    `json:"workflow_id"`
+	RunID       string    `json:"run_id"`
+	Description string    `json:"description"`
+	StartedAt   time.Time `json:"started_at"`
+	LastReport  *TaskRun  `json:"last_report,omitempty"`
+}
+
+// taskReport is the signal a background task sends to its conversation after each run
+type taskReport struct {
+	WorkflowID string  `json:"workflow_id"`
+	Run        TaskRun `json:"run"`
+}
+
+// BackgroundTaskWorkflow runs a task periodically until it reaches MaxRuns or is cancelled.
+// It is started as an abandoned child, so it outlives the conversation that created it.
+func BackgroundTaskWorkflow(ctx workflow.Context, task BackgroundTask) (TaskStatus, error) {
+	ctx = withLLM(ctx)
+
+	status := TaskStatus{Description: task.Description}
+	if err := workflow.SetQueryHandler(ctx, QueryTaskStatus, func() (TaskStatus, error) {
+		return status, nil
+	}); err != nil {
+		return status, err
+	}
+
+	cancelled := false
+	cancelChan := workflow.GetSignalChannel(ctx, SignalCancelTask)
+
+	var a *activities.Activities
+	for !cancelled && status.Runs < task.MaxRuns {
+		run := TaskRun{Time: workflowutil.Now(ctx)}
+		if err := workflow.ExecuteActivity(ctx, a.RunBackgroundTask, activities.RunBackgroundTaskRequest{
+			Description: task.Description,
+			Tenant:      task.Account,
+		}).Get(ctx, &run.Result); err != nil {
+			run.Error = err.Error()
+		}
+		status.Runs++
+		status.Recent = append(status.Recent, run)
+		if len(status.Recent) > maxTaskResults {
+			status.Recent = status.Recent[1:]
+		}
+		reportToParent(ctx, task, run)
+
+		if status.Runs >= task.MaxRuns {
+			break
+		}
+		selector := workflow.NewSelector(ctx)
+		selector.AddFuture(workflow.NewTimer(ctx, task.Interval), func(f workflow.Future) {})
+		selector.AddReceive(cancelChan, func(c workflow.ReceiveChannel, more bool) {
+			c.Receive(ctx, nil)
+			cancelled = true
+		})
+		selector.Select(ctx)
+	}
+
+	status.Done = true
+	return status, nil
+}
+
+// reportToParent signals the latest run to the conversation. The conversation may have
+// ended, in which case the report is dropped.
+func reportToParent(ctx workflow.Context, task BackgroundTask, run TaskRun) {
+	if task.ParentWorkflowID == "" {
+		return
+	}
+	report := taskReport{WorkflowID: workflow.GetInfo(ctx).WorkflowExecution.ID, Run: run}
+	err := workflow.SignalExternalWorkflow(ctx, task.ParentWorkflowID, "", signalTaskReport, report).Get(ctx, nil)
+	if err != nil {
+		workflow.GetLogger(ctx).Info("Unable to report to conversation", "error", err)
+	}
+}
+
+// validateBackgroundTask rejects tasks that cannot be scheduled
+func (c *conversation) validateBackgroundTask(ctx workflow.Context, task BackgroundTask) error {
+	if task.Description == "" {
+		return fmt.Errorf("description is required")
+	}
+	if task.Interval != 0 && task.Interval < minTaskInterval {
+		return fmt.Errorf("interval must be at least %s", minTaskInterval)
+	}
+	if task.MaxRuns < 0 {
+		return fmt.Errorf("max_runs must not be negative")
+	}
+	return nil
+}
+
+// startBackgroundTask starts a task as a detached child workflow and tracks it in state
+func (c *conversation) startBackgroundTask(ctx workflow.Context, task BackgroundTask) (BackgroundTaskRef, error) {
+	if task.Interval == 0 {
+		task.Interval = defaultTaskInterval
+	}
+	if task.MaxRuns == 0 {
+		task.MaxRuns = defaultTaskMaxRuns
+	}
+	parentID := workflow.GetInfo(ctx).WorkflowExecution.ID
+	task.ParentWorkflowID = parentID
+	task.Account = c.account
+
+	cwo := workflow.ChildWorkflowOptions{
+		WorkflowID:        fmt.Sprintf("%s-task-%d", parentID, len(c.backgroundTasks)+1),
+		ParentClosePolicy: enumspb.PARENT_CLOSE_POLICY_ABANDON,
+	}
+	child := workflow.ExecuteChildWorkflow(workflow.WithChildOptions(ctx, cwo), BackgroundTaskWorkflow, task)
+
+	var execution workflow.Execution
+	if err := child.GetChildWorkflowExecution().Get(ctx, &execution); err != nil {
+		return BackgroundTaskRef{}, err
+	}
+
+	ref := BackgroundTaskRef{
+		WorkflowID:  execution.ID,
+		RunID:       execution.RunID,
+		Description: task.Description,
+		StartedAt:   workflowutil.Now(ctx),
+	}
+	c.backgroundTasks = append(c.backgroundTasks, ref)
+	return ref, nil
+}
+
+// recordTaskReport stores the latest report of a background task
+func (c *conversation) recordTaskReport(report taskReport) {
+	for i := range c.backgroundTasks {
+		if c.backgroundTasks[i].WorkflowID == report.WorkflowID {
+			run := report.Run
+			c.backgroundTasks[i].LastReport = &run
+			return
+		}
+	}
+}
+
+// backgroundTasksPrompt summarizes the background tasks so the agent can report on them
+func (c *conversation) backgroundTasksPrompt() string {
+	if len(c.backgroundTasks) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("Background tasks you are running for the user:")
+	for _, t := range c.backgroundTasks {
+		fmt.Fprintf(&b, "\n- %s (id %s, started %s)", t.Description, t.WorkflowID, t.StartedAt.Format(time.RFC3339))
+		if r := t.LastReport; r != nil {
+			if r.Error != "" {
+				fmt.Fprintf(&b, "; last run at %s failed: %s", r.Time.Format(time.RFC3339), r.Error)
+			} else {
+				fmt.Fprintf(&b, "; last run at %s: %s", r.Time.Format(time.RFC3339), r.Result)
+			}
+		}
+	}
+	return b.String()
+}
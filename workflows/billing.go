@@ -0,0 +1,68 @@
+package workflows
+
+import (
+	"fmt"
+	"temporal-ai-agent/activities"
+	"temporal-ai-agent/billing"
+	"temporal-ai-agent/retries"
+	"temporal-ai-agent/workflowutil"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// BillingScheduleID is the ID of the schedule running BillingExportWorkflow
+const BillingScheduleID = "billing-export"
+
+// BillingExportRequest is the input of BillingExportWorkflow
+type BillingExportRequest struct {
+	// Period is the billing month, e.g. "2025-10"; empty bills the month before the run
+	Period string `json:"period,omitempty"`
+	// Format is billing.FormatCSV (the default) or billing.FormatJSON
+	Format string `json:"format,omitempty"`
+}
+
+// BillingExportResult is the result of BillingExportWorkflow
+type BillingExportResult struct {
+	Period   string `json:"period"`
+	Accounts int    `json:"accounts"`
+	Location string `json:"location"`
+}
+
+// BillingExportWorkflow aggregates the quota usage of a billing month per account and exports
+// the statement. It runs on a schedule shortly after each month ends and can be started by
+// hand to re-export a month.
+func BillingExportWorkflow(ctx workflow.Context, req BillingExportRequest) (BillingExportResult, error) {
+	ao := workflow.ActivityOptions{
+		StartToCloseTimeout:    time.Minute,
+		ScheduleToCloseTimeout: time.Hour,
+		RetryPolicy:            retryPolicy(retries.Internal),
+	}
+	ctx = workflow.WithActivityOptions(ctx, ao)
+
+	if req.Period == "" {
+		// Going back as many days as have passed this month lands on the last day of the previous one
+		now := workflowutil.Now(ctx)
+		req.Period = now.AddDate(0, 0, -now.Day()).Format("2006-01")
+	}
+	switch req.Format {
+	case "":
+		req.Format = billing.FormatCSV
+	case billing.FormatCSV, billing.FormatJSON:
+	default:
+		return BillingExportResult{}, fmt.Errorf("unknown billing export format %q", req.Format)
+	}
+
+	var a *activities.Activities
+	var statement billing.Statement
+	if err := workflow.ExecuteActivity(ctx, a.BillingStatement, req.Period).Get(ctx, &statement); err != nil {
+		return BillingExportResult{}, err
+	}
+
+	result := BillingExportResult{Period: req.Period, Accounts: len(statement.Lines)}
+	err := workflow.ExecuteActivity(withRetries(ctx, retries.ExternalAPI), a.ExportBilling, activities.ExportBillingRequest{
+		Statement: statement,
+		Format:    req.Format,
+	}).Get(ctx, &result.Location)
+	return result, err
+}
@@ -0,0 +1,92 @@
+package workflows
+
+import (
+	"temporal-ai-agent/activities"
+	"temporal-ai-agent/activities/llm"
+	"temporal-ai-agent/workflowutil"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// QueryDowngrades is the query listing the completions answered by the fallback model
+const QueryDowngrades = "downgrades"
+
+// completionHeartbeatTimeout is how long a budgeted completion may go without heartbeating.
+// Heartbeats deliver the cancellation of a completion that outlasts the budget, so the
+// provider call stops instead of running on unseen.
+const completionHeartbeatTimeout = 10 * time.Second
+
+// Downgrade is a completion answered by the fallback model because the turn's latency
+// budget ran out before the conversation's model answered
+type Downgrade struct {
+	// MessageIndex is the position in the history of the fallback model's reply or tool call
+	MessageIndex  int       `json:"message_index"`
+	Model         string    `json:"model,omitempty"`
+	FallbackModel string    `json:"fallback_model"`
+	Time          time.Time `json:"time"`
+}
+
+// complete runs a completion of the turn started at started. With a latency budget, a
+// completion still running when the turn's budget is spent is cancelled and run again on
+// the faster fallback model, and the turn's later completions go straight to it.
+func (c *conversation) complete(ctx workflow.Context, req llm.Request, started time.Time) (llm.Response, error) {
+	var a *activities.Activities
+	ctx = withLLM(ctx)
+
+	var resp llm.Response
+	if c.latencyBudget <= 0 || c.fallbackModel == "" || req.Model == c.fallbackModel {
+		err := workflow.ExecuteActivity(ctx, a.Complete, req).Get(ctx, &resp)
+		return resp, err
+	}
+
+	if remaining := c.latencyBudget - workflowutil.Now(ctx).Sub(started); remaining > 0 {
+		ao := workflow.GetActivityOptions(ctx)
+		ao.HeartbeatTimeout = completionHeartbeatTimeout
+		// The timer cancels the completion, retries included, once the budget is spent. Its
+		// timeouts stop it a heartbeat later should the cancellation not get through.
+		ao.StartToCloseTimeout = remaining + completionHeartbeatTimeout
+		ao.ScheduleToCloseTimeout = remaining + completionHeartbeatTimeout
+		completionCtx, cancelCompletion := workflow.WithCancel(workflow.WithActivityOptions(ctx, ao))
+		timerCtx, cancelTimer := workflow.WithCancel(ctx)
+
+		var err error
+		answered := false
+		selector := workflow.NewSelector(ctx)
+		selector.AddFuture(workflow.ExecuteActivity(completionCtx, a.Complete, req), func(f workflow.Future) {
+			answered = true
+			err = f.Get(ctx, &resp)
+			cancelTimer()
+		})
+		selector.AddFuture(workflow.NewTimer(timerCtx, remaining), func(workflow.Future) {
+			cancelCompletion()
+		})
+		selector.Select(ctx)
+		if answered {
+			return resp, err
+		}
+		workflow.GetLogger(ctx).Warn("Completion outlasted the turn's latency budget, switching to the fallback model",
+			"model", req.Model, "fallback_model", c.fallbackModel, "budget", c.latencyBudget)
+	}
+
+	c.downgrades = append(c.downgrades, Downgrade{
+		MessageIndex:  len(c.history),
+		Model:         req.Model,
+		FallbackModel: c.fallbackModel,
+		Time:          workflowutil.Now(ctx),
+	})
+	req.Model = c.fallbackModel
+	err := workflow.ExecuteActivity(ctx, a.Complete, req).Get(ctx, &resp)
+	return resp, err
+}
+
+// truncateDowngrades forgets the downgrades from the given history position on, which
+// an edit or a reprocessed turn discards
+func (c *conversation) truncateDowngrades(index int) {
+	for i, d := range c.downgrades {
+		if d.MessageIndex >= index {
+			c.downgrades = c.downgrades[:i]
+			return
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package workflows
+
+import (
+	"fmt"
+	"slices"
+	"temporal-ai-agent/activities"
+	"temporal-ai-agent/activities/llm"
+	"temporal-ai-agent/channels"
+	"temporal-ai-agent/retries"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// ChannelLink is a channel the conversation is continued on besides the API's own clients,
+// and where its adapter delivers there, e.g. a Slack thread
+type ChannelLink struct {
+	Channel string `json:"channel"`
+	Address string `json:"address"`
+	// Verified is set by the API server on messages the channel's bridge signed; only those
+	// may move the channel to a new address
+	Verified bool `json:"verified,omitempty"`
+}
+
+// linkChannel records the channel a user message came from, which the next replies are
+// delivered to, and marks the replies sent there as read. Messages from the API's own
+// clients carry none: they read the replies from the API, so nothing is delivered for them.
+func (c *conversation) linkChannel(ctx workflow.Context, from *ChannelLink) {
+	if from == nil {
+		c.replyChannel = ""
+		c.readReceipts(ctx, "")
+		return
+	}
+	c.replyChannel = from.Channel
+	c.readReceipts(ctx, from.Channel)
+	i := slices.IndexFunc(c.channels, func(l ChannelLink) bool { return l.Channel == from.Channel })
+	if i < 0 {
+		c.channels = append(c.channels, ChannelLink{Channel: from.Channel, Address: from.Address})
+		return
+	}
+	if c.channels[i].Address == from.Address {
+		return
+	}
+	// A new Slack thread or phone number replaces the previous one, unless the message was
+	// not verified: it would redirect the user's replies to whoever sent it
+	if !from.Verified {
+		workflow.GetLogger(ctx).Warn("Keeping the channel's address for an unverified message", "channel", from.Channel)
+		return
+	}
+	c.channels[i].Address = from.Address
+}
+
+// replyLink returns the channel replies are delivered to, nil for the API's own clients
+func (c *conversation) replyLink() *ChannelLink {
+	i := slices.IndexFunc(c.channels, func(l ChannelLink) bool { return l.Channel == c.replyChannel })
+	if c.replyChannel == "" || i < 0 {
+		return nil
+	}
+	link := c.channels[i]
+	return &link
+}
+
+// deliverReply sends an agent message to the channel the user wrote from last, if it is
+// not the API's own, and tracks its receipt. Delivery is best effort: failures are logged,
+// and the message stays in the history, which every channel continuing the conversation
+// shares. Important messages are sent again when left unread.
+func (c *conversation) deliverReply(ctx workflow.Context, message string, important bool) {
+	index := c.messageIndex(message)
+	if message == "" || index < 0 {
+		return
+	}
+	if c.replyChannel == "" {
+		c.addReceipt(ctx, index, "", ReceiptSent, important)
+		return
+	}
+	status := ReceiptSent
+	if c.deliver(ctx, c.replyChannel, index, message, "reply") != nil {
+		status = ReceiptFailed
+	}
+	c.addReceipt(ctx, index, c.replyChannel, status, important)
+}
+
+// deliver sends a message to a linked channel through its adapter. The delivery ID derives
+// from the message and the kind of delivery, so retries are dropped by receivers.
+func (c *conversation) deliver(ctx workflow.Context, channel string, index int, message, kind string) error {
+	i := slices.IndexFunc(c.channels, func(l ChannelLink) bool { return l.Channel == channel })
+	if i < 0 {
+		return fmt.Errorf("conversation is not linked to channel %s", channel)
+	}
+	var a *activities.Activities
+	workflowID := workflow.GetInfo(ctx).WorkflowExecution.ID
+	ctx = withRetries(ctx, retries.ExternalAPI)
+	err := workflow.ExecuteActivity(ctx, a.DeliverToChannel, channels.Delivery{
+		ID:           fmt.Sprintf("%s:%d:%s", workflowID, index, kind),
+		WorkflowID:   workflowID,
+		Channel:      channel,
+		Address:      c.channels[i].Address,
+		Message:      message,
+		MessageIndex: index,
+	}).Get(ctx, nil)
+	if err != nil {
+		workflow.GetLogger(ctx).Error("Error delivering message to channel", "channel", channel, "error", err)
+	}
+	return err
+}
+
+// messageIndex returns the position of the latest agent message with the given content, or -1
+func (c *conversation) messageIndex(message string) int {
+	for i := len(c.history) - 1; i >= 0; i-- {
+		if c.history[i].Role == llm.RoleAssistant && c.history[i].Content == message {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,85 @@
+package workflows
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// QueryContext returns the context values the conversation was started with
+const QueryContext = "context"
+
+// Context limits
+const (
+	maxContextValues     = 32
+	maxContextValueBytes = 1024
+)
+
+// contextKeyPattern matches valid context keys, which double as {key} prompt placeholders
+var contextKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// contextPlaceholder matches the {key} placeholders of prompts
+var contextPlaceholder = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// ValidateContext checks the context values of a start request: at most 32 values keyed by
+// identifiers, of at most 1 KB each
+func ValidateContext(values map[string]string) error {
+	if len(values) > maxContextValues {
+		return fmt.Errorf("context has %d values, more than %d", len(values), maxContextValues)
+	}
+	for key, value := range values {
+		if !contextKeyPattern.MatchString(key) {
+			return fmt.Errorf("context key %q must be letters, digits and underscores, not starting with a digit", key)
+		}
+		if len(value) > maxContextValueBytes {
+			return fmt.Errorf("context value %s is longer than %d bytes", key, maxContextValueBytes)
+		}
+	}
+	return nil
+}
+
+// expandContext replaces the {key} placeholders of a prompt with the conversation's context
+// values. Placeholders without a value are kept as they are.
+func (c *conversation) expandContext(text string) string {
+	if len(c.context) == 0 {
+		return text
+	}
+	return contextPlaceholder.ReplaceAllStringFunc(text, func(match string) string {
+		if value, ok := c.context[match[1:len(match)-1]]; ok {
+			return value
+		}
+		return match
+	})
+}
+
+// contextPrompt lists the context values for the model, in key order so the prompt is the
+// same on every turn
+func (c *conversation) contextPrompt() string {
+	if len(c.context) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("This conversation is about the following records. Use these values when calling tools instead of asking the user for them:")
+	for _, key := range slices.Sorted(maps.Keys(c.context)) {
+		fmt.Fprintf(&b, "\n- %s: %s", key, c.context[key])
+	}
+	return b.String()
+}
+
+// contextDefaults returns the arguments of a call completed with the context values it
+// does not set, since tools may fall back to them
+func (c *conversation) contextDefaults(args map[string]interface{}) map[string]interface{} {
+	if len(c.context) == 0 {
+		return args
+	}
+	completed := make(map[string]interface{}, len(args)+len(c.context))
+	for key, value := range c.context {
+		completed[key] = value
+	}
+	for key, value := range args {
+		completed[key] = value
+	}
+	return completed
+}
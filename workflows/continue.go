@@ -0,0 +1,197 @@
+package workflows
+
+import (
+	"temporal-ai-agent/activities/llm"
+	"temporal-ai-agent/analytics"
+	"temporal-ai-agent/experiments"
+	"temporal-ai-agent/personas"
+	"temporal-ai-agent/templates"
+	"temporal-ai-agent/tools"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// Thresholds past which a conversation continues as a new run. Temporal bounds the events
+// and size of a workflow history; continuing long before the hard limits keeps replays of
+// the conversation fast.
+const (
+	continueAsNewEvents   = 10_000
+	continueAsNewMessages = 1_000
+)
+
+// ConversationState is the state a conversation carries over to its next run when it
+// continues as new. The workflow ID stays the same, so clients addressing conversations by
+// ID do not notice; clients pinning a run ID must drop it.
+type ConversationState struct {
+	Goal             string                  `json:"goal"`
+	GoalPrompt       string                  `json:"goal_prompt,omitempty"`
+	PromptVersion    string                  `json:"prompt_version"`
+	Model            string                  `json:"model,omitempty"`
+	SystemPrompt     string                  `json:"system_prompt,omitempty"`
+	Temperature      *float64                `json:"temperature,omitempty"`
+	MaxTokens        int                     `json:"max_tokens,omitempty"`
+	Shadow           *experiments.Assignment `json:"shadow,omitempty"`
+	Template         templates.Template      `json:"template"`
+	QuickReplies     []string                `json:"quick_replies,omitempty"`
+	Persona          personas.Persona        `json:"persona"`
+	Language         string                  `json:"language,omitempty"`
+	History          []llm.Message           `json:"history"`
+	Branches         []Branch                `json:"branches,omitempty"`
+	Confirmations    []Confirmation          `json:"confirmations,omitempty"`
+	Annotations      []Annotation            `json:"annotations,omitempty"`
+	Receipts         []Receipt               `json:"receipts,omitempty"`
+	Tools            []tools.Definition      `json:"tools"`
+	PendingTool      *tools.Call             `json:"pending_tool,omitempty"`
+	PendingToolAt    time.Time               `json:"pending_tool_at"`
+	Channels         []ChannelLink           `json:"channels,omitempty"`
+	ReplyChannel     string                  `json:"reply_channel,omitempty"`
+	BackgroundTasks  []BackgroundTaskRef     `json:"background_tasks,omitempty"`
+	Handoff          *Handoff                `json:"handoff,omitempty"`
+	DegradedTurns    []DegradedTurn          `json:"degraded_turns,omitempty"`
+	TurnFailed       bool                    `json:"turn_failed,omitempty"`
+	Downgrades       []Downgrade             `json:"downgrades,omitempty"`
+	UnrecordedTokens int                     `json:"unrecorded_tokens,omitempty"`
+	SavedMessages    int                     `json:"saved_messages"`
+	Notifications    int                     `json:"notifications,omitempty"`
+	SemanticCacheOff bool                    `json:"semantic_cache_off,omitempty"`
+	GroundingChecks  []GroundingCheck        `json:"grounding_checks,omitempty"`
+	GroundingOff     bool                    `json:"grounding_off,omitempty"`
+	Analytics        []analytics.Event       `json:"analytics,omitempty"`
+	AnalyticsSeq     int                     `json:"analytics_seq"`
+	MessageIDs       []string                `json:"message_ids,omitempty"`
+	NextSeq          int                     `json:"next_seq,omitempty"`
+	PendingSeqs      map[int]string          `json:"pending_seqs,omitempty"`
+	WaitingSince     time.Time               `json:"waiting_since"`
+	LastActivityAt   time.Time               `json:"last_activity_at"`
+	Title            string                  `json:"title,omitempty"`
+	UserTurns        int                     `json:"user_turns"`
+	Events           []Event                 `json:"events,omitempty"`
+	NextEventSeq     int                     `json:"next_event_seq"`
+	Result           string                  `json:"result,omitempty"`
+}
+
+// historyTooLong reports whether the conversation should continue as new: Temporal
+// suggests it, or the run's history passed the event or message threshold
+func (c *conversation) historyTooLong(ctx workflow.Context) bool {
+	info := workflow.GetInfo(ctx)
+	return info.GetContinueAsNewSuggested() ||
+		info.GetCurrentHistoryLength() >= continueAsNewEvents ||
+		len(c.history)-c.runStartMessages >= continueAsNewMessages
+}
+
+// state captures the conversation for its next run
+func (c *conversation) state(result string) *ConversationState {
+	return &ConversationState{
+		Goal:             c.goal,
+		GoalPrompt:       c.goalPrompt,
+		PromptVersion:    c.promptVersion,
+		Model:            c.model,
+		SystemPrompt:     c.systemPrompt,
+		Temperature:      c.temperature,
+		MaxTokens:        c.maxTokens,
+		Shadow:           c.shadow,
+		Template:         c.template,
+		QuickReplies:     c.quickReplies,
+		Persona:          c.persona,
+		Language:         c.language,
+		History:          c.history,
+		Branches:         c.branches,
+		Confirmations:    c.confirmations,
+		Annotations:      c.annotations,
+		Receipts:         c.receipts,
+		Tools:            c.tools,
+		PendingTool:      c.pendingTool,
+		PendingToolAt:    c.pendingToolAt,
+		Channels:         c.channels,
+		ReplyChannel:     c.replyChannel,
+		BackgroundTasks:  c.backgroundTasks,
+		Handoff:          c.handoff,
+		DegradedTurns:    c.degradedTurns,
+		TurnFailed:       c.turnFailed,
+		Downgrades:       c.downgrades,
+		UnrecordedTokens: c.unrecordedTokens,
+		SavedMessages:    c.savedMessages,
+		Notifications:    c.notifications,
+		SemanticCacheOff: c.semanticCacheOff,
+		GroundingChecks:  c.groundingChecks,
+		GroundingOff:     c.groundingOff,
+		Analytics:        c.analytics,
+		AnalyticsSeq:     c.analyticsSeq,
+		MessageIDs:       c.messageIDs.order,
+		NextSeq:          c.sequencer.next,
+		PendingSeqs:      c.sequencer.pending,
+		WaitingSince:     c.sequencer.waitingSince,
+		LastActivityAt:   c.lastActivityAt,
+		Title:            c.title,
+		UserTurns:        c.userTurns,
+		Events:           c.events.events,
+		NextEventSeq:     c.events.nextSeq,
+		Result:           result,
+	}
+}
+
+// restore resumes the conversation from the state of its previous run and returns the
+// previous run's result
+func (c *conversation) restore(s *ConversationState) string {
+	c.goal = s.Goal
+	c.goalPrompt = s.GoalPrompt
+	c.promptVersion = s.PromptVersion
+	c.model = s.Model
+	c.systemPrompt = s.SystemPrompt
+	c.temperature = s.Temperature
+	c.maxTokens = s.MaxTokens
+	c.shadow = s.Shadow
+	c.template = s.Template
+	c.quickReplies = s.QuickReplies
+	c.persona = s.Persona
+	c.language = s.Language
+	c.history = s.History
+	c.branches = s.Branches
+	c.confirmations = s.Confirmations
+	c.annotations = s.Annotations
+	c.receipts = s.Receipts
+	c.tools = s.Tools
+	c.pendingTool = s.PendingTool
+	c.pendingToolAt = s.PendingToolAt
+	c.channels = s.Channels
+	c.replyChannel = s.ReplyChannel
+	c.backgroundTasks = s.BackgroundTasks
+	c.handoff = s.Handoff
+	c.degradedTurns = s.DegradedTurns
+	c.turnFailed = s.TurnFailed
+	c.downgrades = s.Downgrades
+	c.unrecordedTokens = s.UnrecordedTokens
+	c.savedMessages = s.SavedMessages
+	c.notifications = s.Notifications
+	c.semanticCacheOff = s.SemanticCacheOff
+	c.groundingChecks = s.GroundingChecks
+	c.groundingOff = s.GroundingOff
+	c.analytics = s.Analytics
+	c.analyticsSeq = s.AnalyticsSeq
+	for _, id := range s.MessageIDs {
+		c.messageIDs.add(id)
+	}
+	c.sequencer = messageSequencer{next: s.NextSeq, pending: s.PendingSeqs, waitingSince: s.WaitingSince}
+	c.lastActivityAt = s.LastActivityAt
+	c.title = s.Title
+	c.userTurns = s.UserTurns
+	c.events.events = s.Events
+	c.events.nextSeq = s.NextEventSeq
+	c.runStartMessages = len(c.history)
+	return s.Result
+}
+
+// readyToContinue waits for the updates in flight, then reports whether the run can end:
+// signals already received must be handled first, or they would be lost
+func (c *conversation) readyToContinue(ctx workflow.Context, channels ...workflow.ReceiveChannel) (bool, error) {
+	if err := workflow.Await(ctx, func() bool { return c.idle(ctx) }); err != nil {
+		return false, err
+	}
+	for _, ch := range channels {
+		if ch.Len() > 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,70 @@
+package workflows
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fill sets every exported field reachable from v to a value other than its zero value.
+// Recursive types are filled once: a type already being filled is left empty below itself.
+func fill(v reflect.Value, filling ...reflect.Type) {
+	for _, t := range filling {
+		if t == v.Type() {
+			return
+		}
+	}
+	filling = append(filling, v.Type())
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString("x")
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(0.5)
+	case reflect.Pointer:
+		v.Set(reflect.New(v.Type().Elem()))
+		fill(v.Elem(), filling...)
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+		fill(v.Index(0), filling...)
+	case reflect.Map:
+		key, elem := reflect.New(v.Type().Key()).Elem(), reflect.New(v.Type().Elem()).Elem()
+		fill(key, filling...)
+		fill(elem, filling...)
+		v.Set(reflect.MakeMap(v.Type()))
+		v.SetMapIndex(key, elem)
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(time.Time{}) {
+			v.Set(reflect.ValueOf(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)))
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				fill(v.Field(i), filling...)
+			}
+		}
+	}
+}
+
+// Everything a conversation carries over when it continues as new comes back in the next run
+func TestConversationStateRoundTrip(t *testing.T) {
+	var want ConversationState
+	fill(reflect.ValueOf(&want).Elem())
+	fields := reflect.ValueOf(want)
+	for i := 0; i < fields.NumField(); i++ {
+		require.False(t, fields.Field(i).IsZero(), "%s is not filled", fields.Type().Field(i).Name)
+	}
+
+	c := &conversation{events: &eventLog{}}
+	result := c.restore(&want)
+	require.Equal(t, want.Result, result)
+	require.Equal(t, &want, c.state(result))
+	require.Equal(t, len(want.History), c.runStartMessages)
+}
@@ -0,0 +1,175 @@
+package workflows
+
+import (
+	"net/url"
+	"strings"
+	"temporal-ai-agent/activities"
+	"temporal-ai-agent/retries"
+	"temporal-ai-agent/workflowutil"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// QueryCrawlProgress returns the CrawlProgress of a crawl workflow
+const QueryCrawlProgress = "crawl_progress"
+
+// Crawl limits
+const (
+	defaultCrawlPages = 100
+	maxCrawlPages     = 1000
+	defaultCrawlDelay = time.Second
+	maxCrawlFailures  = 50
+	crawlPageTimeout  = 5 * time.Minute
+)
+
+// CrawlRequest is the input of CrawlWorkflow
+type CrawlRequest struct {
+	// Namespace is the knowledge namespace the pages are ingested into
+	Namespace string   `json:"namespace"`
+	Seeds     []string `json:"seeds"`
+	// MaxDepth is how many links away from a seed pages are followed; 0 ingests the seeds only
+	MaxDepth int `json:"max_depth"`
+	// MaxPages bounds the pages ingested
+	MaxPages int `json:"max_pages,omitempty"`
+	// AllowOtherDomains follows links leaving the domains of the seeds
+	AllowOtherDomains bool `json:"allow_other_domains,omitempty"`
+	// Delay is the pause between two requests to the same host
+	Delay time.Duration `json:"delay,omitempty"`
+}
+
+// CrawlFailure is a page that could not be ingested
+type CrawlFailure struct {
+	URL   string `json:"url"`
+	Error string `json:"error"`
+}
+
+// CrawlProgress is the state of a crawl, returned by its progress query and as its result
+type CrawlProgress struct {
+	Namespace string `json:"namespace"`
+	// Pages and Chunks count the pages ingested and the passages they were split into
+	Pages  int `json:"pages"`
+	Chunks int `json:"chunks"`
+	// Queued is the number of pages found but not crawled yet
+	Queued int `json:"queued"`
+	// Failures counts the pages that could not be ingested; Failed lists the first of them
+	Failures int            `json:"failures"`
+	Failed   []CrawlFailure `json:"failed,omitempty"`
+	Done     bool           `json:"done"`
+}
+
+// crawlPage is a page waiting to be crawled
+type crawlPage struct {
+	url   string
+	depth int
+}
+
+// CrawlWorkflow ingests a site into a knowledge namespace, following links breadth-first
+// from the seed URLs up to the depth and page limits. Pages are fetched one at a time, and
+// consecutive requests to a host are spaced by the politeness delay.
+func CrawlWorkflow(ctx workflow.Context, req CrawlRequest) (CrawlProgress, error) {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: crawlPageTimeout,
+		RetryPolicy:         retryPolicy(retries.ExternalAPI),
+	})
+	if req.MaxPages <= 0 {
+		req.MaxPages = defaultCrawlPages
+	}
+	req.MaxPages = min(req.MaxPages, maxCrawlPages)
+	if req.Delay <= 0 {
+		req.Delay = defaultCrawlDelay
+	}
+
+	progress := CrawlProgress{Namespace: req.Namespace}
+	if err := workflow.SetQueryHandler(ctx, QueryCrawlProgress, func() (CrawlProgress, error) {
+		return progress, nil
+	}); err != nil {
+		return CrawlProgress{}, err
+	}
+
+	domains := map[string]bool{}
+	for _, seed := range req.Seeds {
+		if u, err := url.Parse(seed); err == nil {
+			domains[crawlDomain(u)] = true
+		}
+	}
+	var queue []crawlPage
+	seen := map[string]bool{}
+	enqueue := func(link string, depth int) {
+		u, err := url.Parse(link)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return
+		}
+		u.Fragment = ""
+		if seen[u.String()] || (!req.AllowOtherDomains && !domains[crawlDomain(u)]) {
+			return
+		}
+		seen[u.String()] = true
+		queue = append(queue, crawlPage{url: u.String(), depth: depth})
+	}
+	for _, seed := range req.Seeds {
+		enqueue(seed, 0)
+	}
+
+	var a *activities.Activities
+	lastRequest := map[string]time.Time{}
+	for len(queue) > 0 && progress.Pages < req.MaxPages {
+		page := queue[0]
+		queue = queue[1:]
+		progress.Queued = len(queue)
+
+		host := crawlHost(page.url)
+		if last, ok := lastRequest[host]; ok {
+			if wait := req.Delay - workflowutil.Now(ctx).Sub(last); wait > 0 {
+				if err := workflow.Sleep(ctx, wait); err != nil {
+					return progress, err
+				}
+			}
+		}
+
+		var res activities.IngestURLResult
+		err := workflow.ExecuteActivity(ctx, a.IngestURL, activities.IngestURLRequest{
+			Namespace: req.Namespace,
+			URL:       page.url,
+		}).Get(ctx, &res)
+		lastRequest[host] = workflowutil.Now(ctx)
+		if err != nil {
+			workflow.GetLogger(ctx).Warn("Page not ingested", "url", page.url, "error", err)
+			progress.Failures++
+			if len(progress.Failed) < maxCrawlFailures {
+				progress.Failed = append(progress.Failed, CrawlFailure{URL: page.url, Error: failureReason(err)})
+			}
+			continue
+		}
+
+		// A redirect target is the same page under another address
+		seen[res.URL] = true
+		progress.Pages++
+		progress.Chunks += res.Chunks
+		if page.depth < req.MaxDepth {
+			for _, link := range res.Links {
+				enqueue(link, page.depth+1)
+			}
+		}
+		progress.Queued = len(queue)
+	}
+
+	progress.Done = true
+	workflow.GetLogger(ctx).Info("Crawl finished", "namespace", req.Namespace,
+		"pages", progress.Pages, "failures", progress.Failures, "left", len(queue))
+	return progress, nil
+}
+
+// crawlDomain is the host of a URL without a leading www., so both forms of a site match
+func crawlDomain(u *url.URL) string {
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// crawlHost is the host the politeness delay applies to
+func crawlHost(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	return strings.ToLower(u.Host)
+}
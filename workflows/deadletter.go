@@ -0,0 +1,43 @@
+package workflows
+
+import (
+	"temporal-ai-agent/activities"
+	"temporal-ai-agent/deadletter"
+	"temporal-ai-agent/retries"
+	"temporal-ai-agent/workflowutil"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// deadLetter records the current turn in the dead-letter store after it failed or was
+// answered with the fallback message, with the history an admin needs to understand it.
+// The turn is identified by its user message, so failing again updates the same entry.
+func (c *conversation) deadLetter(ctx workflow.Context, kind string, err error) {
+	var a *activities.Activities
+	info := workflow.GetInfo(ctx)
+	entry := deadletter.Entry{
+		ID:            deadletter.ID(info.WorkflowExecution.ID, c.lastUserIndex()),
+		WorkflowID:    info.WorkflowExecution.ID,
+		RunID:         info.WorkflowExecution.RunID,
+		Goal:          c.goal,
+		PromptVersion: c.promptVersion,
+		Model:         c.model,
+		Kind:          kind,
+		MessageIndex:  c.lastUserIndex(),
+		Messages:      c.history,
+		Error:         err.Error(),
+		FailedAt:      workflowutil.Now(ctx),
+	}
+	if err := workflow.ExecuteActivity(withRetries(ctx, retries.Internal), a.RecordDeadLetter, entry).Get(ctx, nil); err != nil {
+		workflow.GetLogger(ctx).Error("Error recording dead letter", "error", err)
+	}
+}
+
+// resolveDeadLetter marks the current turn as successfully retried in the dead-letter store
+func (c *conversation) resolveDeadLetter(ctx workflow.Context) {
+	var a *activities.Activities
+	id := deadletter.ID(workflow.GetInfo(ctx).WorkflowExecution.ID, c.lastUserIndex())
+	if err := workflow.ExecuteActivity(withRetries(ctx, retries.Internal), a.ResolveDeadLetter, id).Get(ctx, nil); err != nil {
+		workflow.GetLogger(ctx).Error("Error resolving dead letter", "error", err)
+	}
+}
@@ -0,0 +1,108 @@
+package workflows
+
+import (
+	"fmt"
+	"temporal-ai-agent/activities"
+	"temporal-ai-agent/digest"
+	"temporal-ai-agent/retries"
+	"temporal-ai-agent/tools"
+	"temporal-ai-agent/workflowutil"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// DigestScheduleID is the ID of the schedule running DailyDigestWorkflow
+const DigestScheduleID = "daily-digest"
+
+// digestGoal is the goal digests resolve their tools under, so tool policies can restrict them
+const digestGoal = "digest"
+
+// DefaultDigestTools are the sources a digest covers when the request names none
+var DefaultDigestTools = []string{"list_calendar_events", "list_tickets", "list_inbox"}
+
+// DigestRequest is the input of DailyDigestWorkflow
+type DigestRequest struct {
+	UserID string `json:"user_id,omitempty"`
+	// Tools are the tools whose results the digest covers; empty uses DefaultDigestTools.
+	// They are called with the digest's date as their only argument where they take one.
+	Tools []string `json:"tools,omitempty"`
+}
+
+// DigestResult is the outcome of DailyDigestWorkflow
+type DigestResult struct {
+	Date     string                     `json:"date"`
+	Sections []activities.DigestSection `json:"sections"`
+	Text     string                     `json:"text"`
+}
+
+// DailyDigestWorkflow gathers the user's day from several tools in parallel, has the LLM
+// compose a digest and delivers it to the configured channel. A failing tool does not fail
+// the digest: the digest says the source was unavailable.
+func DailyDigestWorkflow(ctx workflow.Context, req DigestRequest) (DigestResult, error) {
+	ao := workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy:         retryPolicy(retries.Internal),
+	}
+	ctx = workflow.WithActivityOptions(ctx, ao)
+
+	if len(req.Tools) == 0 {
+		req.Tools = DefaultDigestTools
+	}
+	result := DigestResult{Date: workflowutil.Now(ctx).Format("2006-01-02")}
+
+	var a *activities.Activities
+	var available []tools.Definition
+	if err := workflow.ExecuteActivity(ctx, a.ListTools, digestGoal).Get(ctx, &available); err != nil {
+		return result, err
+	}
+
+	futures := make([]workflow.Future, len(req.Tools))
+	result.Sections = make([]activities.DigestSection, len(req.Tools))
+	for i, name := range req.Tools {
+		result.Sections[i].Tool = name
+		def, ok := tools.Find(available, name)
+		if !ok {
+			result.Sections[i].Error = fmt.Sprintf("tool %q is not available", name)
+			continue
+		}
+		call := tools.Call{ID: fmt.Sprintf("digest-%d", i), Name: name, Args: digestArgs(def, result.Date)}
+		futures[i] = workflow.ExecuteActivity(withRetries(ctx, toolRetryCategory(def)), a.ExecuteTool,
+			activities.ExecuteToolRequest{Call: call, Goal: digestGoal})
+	}
+	for i, future := range futures {
+		if future == nil {
+			continue
+		}
+		if err := future.Get(ctx, &result.Sections[i].Result); err != nil {
+			workflow.GetLogger(ctx).Warn("Digest source failed", "tool", req.Tools[i], "error", err)
+			result.Sections[i].Error = toolError(err)
+		}
+	}
+
+	err := workflow.ExecuteActivity(withLLM(ctx), a.ComposeDigest, activities.ComposeDigestRequest{
+		Date:     result.Date,
+		Sections: result.Sections,
+	}).Get(ctx, &result.Text)
+	if err != nil {
+		return result, err
+	}
+
+	info := workflow.GetInfo(ctx)
+	err = workflow.ExecuteActivity(withRetries(ctx, retries.ExternalAPI), a.DeliverDigest, digest.Digest{
+		ID:     info.WorkflowExecution.ID + ":" + info.WorkflowExecution.RunID,
+		UserID: req.UserID,
+		Date:   result.Date,
+		Text:   result.Text,
+	}).Get(ctx, nil)
+	return result, err
+}
+
+// digestArgs passes the digest's date to tools that take a date argument
+func digestArgs(def tools.Definition, date string) map[string]interface{} {
+	properties, _ := def.Parameters["properties"].(map[string]interface{})
+	if _, ok := properties["date"]; ok {
+		return map[string]interface{}{"date": date}
+	}
+	return nil
+}
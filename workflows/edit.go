@@ -0,0 +1,91 @@
+package workflows
+
+import (
+	"fmt"
+	"temporal-ai-agent/activities/llm"
+	"temporal-ai-agent/workflowutil"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// UpdateEditMessage is the update that rewrites a user message and regenerates from there
+const UpdateEditMessage = "edit_message"
+
+// QueryHistory is the query returning the current conversation history
+const QueryHistory = "history"
+
+// EditMessageRequest is the argument of the edit_message update
+type EditMessageRequest struct {
+	// MessageIndex is the position of the user message in the history
+	MessageIndex int `json:"message_index"`
+	// Content replaces the text of the user message
+	Content string `json:"content"`
+	// PreserveBranch keeps the discarded messages so they can be exported later
+	PreserveBranch bool `json:"preserve_branch"`
+}
+
+// Branch is a part of the history that was replaced by an edit
+type Branch struct {
+	// FromIndex is the history position the branch started at
+	FromIndex int           `json:"from_index"`
+	Messages  []llm.Message `json:"messages"`
+	EditedAt  time.Time     `json:"edited_at"`
+}
+
+// validateEdit rejects edits that do not point at a user message
+func (c *conversation) validateEdit(ctx workflow.Context, req EditMessageRequest) error {
+	if req.Content == "" {
+		return fmt.Errorf("content is required")
+	}
+	if c.handoff != nil {
+		return fmt.Errorf("conversation is handed off to an operator")
+	}
+	if req.MessageIndex < 0 || req.MessageIndex >= len(c.history) {
+		return fmt.Errorf("message_index %d out of range", req.MessageIndex)
+	}
+	if c.history[req.MessageIndex].Role != llm.RoleUser {
+		return fmt.Errorf("message %d is not a user message", req.MessageIndex)
+	}
+	return nil
+}
+
+// editMessage truncates the history after the edited user message and regenerates the reply
+func (c *conversation) editMessage(ctx workflow.Context, req EditMessageRequest) (string, error) {
+	ctx, err := c.lockTurn(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer c.turnLock.Unlock()
+
+	// The history may have changed while waiting for the lock
+	if err := c.validateEdit(ctx, req); err != nil {
+		return "", err
+	}
+
+	if req.PreserveBranch {
+		discarded := make([]llm.Message, len(c.history)-req.MessageIndex)
+		copy(discarded, c.history[req.MessageIndex:])
+		c.branches = append(c.branches, Branch{
+			FromIndex: req.MessageIndex,
+			Messages:  discarded,
+			EditedAt:  workflowutil.Now(ctx),
+		})
+	}
+
+	c.history = append(c.history[:req.MessageIndex], llm.Message{Role: llm.RoleUser, Content: req.Content})
+	c.truncateDegradedTurns(req.MessageIndex)
+	c.truncateDowngrades(req.MessageIndex)
+	c.truncateGroundingChecks(req.MessageIndex)
+	c.truncateAnnotations(req.MessageIndex)
+	c.truncateReceipts(req.MessageIndex)
+	c.forgetSavedMessages(req.MessageIndex)
+	// A tool call awaiting confirmation belonged to the discarded part of the history
+	c.pendingTool = nil
+	c.detectLanguage(req.Content)
+	reply, err := c.respond(ctx)
+	c.indexTranscript(ctx)
+	c.saveConversation(ctx)
+	c.recordUsage(ctx, 0)
+	return reply, err
+}
@@ -0,0 +1,29 @@
+package workflows
+
+import (
+	"temporal-ai-agent/activities"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// evaluate has the judge model score the ended conversation and keeps the scores with the
+// transcript. Evaluation is best effort: a failed evaluation must not fail the conversation.
+func (c *conversation) evaluate(ctx workflow.Context) {
+	if c.userTurns == 0 {
+		return
+	}
+
+	var a *activities.Activities
+	ctx = withLLM(ctx)
+
+	err := workflow.ExecuteActivity(ctx, a.EvaluateConversation, activities.EvaluateRequest{
+		WorkflowID:    workflow.GetInfo(ctx).WorkflowExecution.ID,
+		Goal:          c.goal,
+		PromptVersion: c.promptVersion,
+		Messages:      c.history,
+		Tenant:        c.account,
+	}).Get(ctx, &c.evaluation)
+	if err != nil {
+		workflow.GetLogger(ctx).Error("Error evaluating conversation", "error", err)
+	}
+}
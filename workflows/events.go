@@ -0,0 +1,114 @@
+package workflows
+
+import (
+	"encoding/json"
+	"temporal-ai-agent/activities"
+	"temporal-ai-agent/workflowutil"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// Event types emitted while the agent works on a turn
+const (
+	EventThinking             = "thinking"
+	EventToolStarted          = "tool_started"
+	EventToolFinished         = "tool_finished"
+	EventAwaitingConfirmation = "awaiting_confirmation"
+	EventMessage              = "message"
+	EventHandoffStarted       = "handoff_started"
+	EventOperatorMessage      = "operator_message"
+	EventHandoffEnded         = "handoff_ended"
+	EventQuickReplies         = "quick_replies"
+	// EventReminder repeats an important message the user left unread
+	EventReminder = "reminder"
+	// EventEnded is the last event of a conversation, with its result as the message
+	EventEnded = "ended"
+)
+
+// QueryEvents is the query returning events emitted after a given sequence number
+const QueryEvents = "events"
+
+// maxEvents bounds the number of events kept in workflow state
+const maxEvents = 200
+
+// Event is an intermediate progress event that streaming clients can forward
+type Event struct {
+	Seq     int       `json:"seq"`
+	Type    string    `json:"type"`
+	Time    time.Time `json:"time"`
+	Tool    string    `json:"tool,omitempty"`
+	Message string    `json:"message,omitempty"`
+	// QuickReplies are the replies suggested to the user along with a message
+	QuickReplies []string `json:"quick_replies,omitempty"`
+}
+
+// publishOptions bound the local activity publishing an event; a lost event only
+// affects clients of the stream, which can still query the log
+var publishOptions = workflow.LocalActivityOptions{
+	StartToCloseTimeout: 5 * time.Second,
+	RetryPolicy:         &temporal.RetryPolicy{MaximumAttempts: 2},
+}
+
+// eventLog is an append-only, bounded log of events exposed through QueryEvents
+type eventLog struct {
+	events  []Event
+	nextSeq int
+	// publish also sends every event to the conversation's stream
+	publish bool
+}
+
+// newEventLog creates an event log and registers its query handler
+func newEventLog(ctx workflow.Context) (*eventLog, error) {
+	l := &eventLog{nextSeq: 1}
+	err := workflow.SetQueryHandler(ctx, QueryEvents, func(after int) ([]Event, error) {
+		return l.since(after), nil
+	})
+	return l, err
+}
+
+// emit appends an event to the log, dropping the oldest events beyond maxEvents
+func (l *eventLog) emit(ctx workflow.Context, event Event) {
+	event.Seq = l.nextSeq
+	event.Time = workflowutil.Now(ctx)
+	l.nextSeq++
+
+	l.events = append(l.events, event)
+	if len(l.events) > maxEvents {
+		l.events = l.events[len(l.events)-maxEvents:]
+	}
+
+	if l.publish {
+		l.publishEvent(ctx, event)
+	}
+}
+
+// publishEvent sends an event to the conversation's stream
+func (l *eventLog) publishEvent(ctx workflow.Context, event Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	var a *activities.Activities
+	ctx = workflow.WithLocalActivityOptions(ctx, publishOptions)
+	workflowID := workflow.GetInfo(ctx).WorkflowExecution.ID
+	if err := workflow.ExecuteLocalActivity(ctx, a.PublishEvent, workflowID, string(data)).Get(ctx, nil); err != nil {
+		workflow.GetLogger(ctx).Warn("Unable to publish event", "error", err)
+	}
+}
+
+// since returns the events with a sequence number greater than after
+func (l *eventLog) since(after int) []Event {
+	for i, event := range l.events {
+		if event.Seq > after {
+			return l.events[i:]
+		}
+	}
+	return []Event{}
+}
+
+// lastSeq returns the sequence number of the most recent event, or 0 if there is none
+func (l *eventLog) lastSeq() int {
+	return l.nextSeq - 1
+}
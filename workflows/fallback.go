@@ -0,0 +1,121 @@
+package workflows
+
+import (
+	"fmt"
+	"temporal-ai-agent/activities/llm"
+	"temporal-ai-agent/deadletter"
+	"temporal-ai-agent/workflowutil"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// Names used by the degraded mode
+const (
+	UpdateReprocessTurn = "reprocess_turn"
+	QueryDegradedTurns  = "degraded_turns"
+)
+
+// defaultFallbackMessage is the reply sent when the LLM is unavailable and no fallback is configured
+const defaultFallbackMessage = "Sorry, I can't answer right now. Please bear with me, I'll get back to this as soon as I can."
+
+// DegradedTurn is a turn answered with the fallback message because the LLM was unavailable
+type DegradedTurn struct {
+	// MessageIndex is the position of the fallback reply in the history
+	MessageIndex int       `json:"message_index"`
+	Error        string    `json:"error"`
+	Time         time.Time `json:"time"`
+}
+
+// fallback answers the turn with the fallback message and marks it for reprocessing
+func (c *conversation) fallback(ctx workflow.Context, err error) string {
+	workflow.GetLogger(ctx).Error("LLM unavailable, replying with the fallback message", "error", err)
+
+	message := c.fallbackMessage
+	if message == "" {
+		message = defaultFallbackMessage
+	}
+	c.history = append(c.history, llm.Message{Role: llm.RoleAssistant, Content: message})
+	c.degradedTurns = append(c.degradedTurns, DegradedTurn{
+		MessageIndex: len(c.history) - 1,
+		Error:        err.Error(),
+		Time:         workflowutil.Now(ctx),
+	})
+	c.events.emit(ctx, Event{Type: EventMessage, Message: message})
+	c.deadLetter(ctx, deadletter.KindDegraded, err)
+	return message
+}
+
+// truncateDegradedTurns forgets the degraded turns from the given history position on,
+// after that part of the history was discarded
+func (c *conversation) truncateDegradedTurns(index int) {
+	for i, turn := range c.degradedTurns {
+		if turn.MessageIndex >= index {
+			c.degradedTurns = c.degradedTurns[:i]
+			return
+		}
+	}
+}
+
+// ReprocessTurn is the optional input of the reprocess_turn update
+type ReprocessTurn struct {
+	// MessageIndex, when set, is the position of the user message of the turn to reprocess,
+	// as recorded in the dead-letter store; the update is rejected if it is not the latest turn
+	MessageIndex *int `json:"message_index,omitempty"`
+}
+
+// validateReprocessTurn only allows reprocessing the latest turn, when it failed or its
+// degraded reply is still the latest
+func (c *conversation) validateReprocessTurn(ctx workflow.Context, req ReprocessTurn) error {
+	if c.handoff != nil {
+		return fmt.Errorf("conversation is handed off to an operator")
+	}
+	if req.MessageIndex != nil && *req.MessageIndex != c.lastUserIndex() {
+		return fmt.Errorf("the conversation has moved on since the turn of message %d", *req.MessageIndex)
+	}
+	if c.turnFailed {
+		return nil
+	}
+	if len(c.degradedTurns) == 0 {
+		return fmt.Errorf("no degraded turn to reprocess")
+	}
+	if c.degradedTurns[len(c.degradedTurns)-1].MessageIndex != len(c.history)-1 {
+		return fmt.Errorf("the conversation has moved on since the degraded turn")
+	}
+	return nil
+}
+
+// reprocessTurn replaces the fallback reply of the latest degraded turn with a fresh one,
+// or resumes the latest turn from where it failed. If the turn fails or degrades again, it
+// is recorded again in the dead-letter store; otherwise its entry there is resolved.
+func (c *conversation) reprocessTurn(ctx workflow.Context, req ReprocessTurn) (string, error) {
+	ctx, err := c.lockTurn(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer c.turnLock.Unlock()
+
+	if err := c.validateReprocessTurn(ctx, req); err != nil {
+		return "", err
+	}
+
+	if !c.turnFailed {
+		index := c.degradedTurns[len(c.degradedTurns)-1].MessageIndex
+		c.history = c.history[:index]
+		c.truncateDegradedTurns(index)
+		c.truncateDowngrades(index)
+		c.truncateGroundingChecks(index)
+		c.truncateAnnotations(index)
+		c.truncateReceipts(index)
+		c.forgetSavedMessages(index)
+	}
+	degraded := len(c.degradedTurns)
+	reply, err := c.respond(ctx)
+	if err == nil && len(c.degradedTurns) == degraded {
+		c.resolveDeadLetter(ctx)
+	}
+	c.indexTranscript(ctx)
+	c.saveConversation(ctx)
+	c.recordUsage(ctx, 0)
+	return reply, err
+}
@@ -0,0 +1,122 @@
+package workflows
+
+import (
+	"strings"
+	"temporal-ai-agent/activities"
+	"temporal-ai-agent/activities/llm"
+	"temporal-ai-agent/grounding"
+	"temporal-ai-agent/knowledge"
+	"temporal-ai-agent/workflowutil"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// QueryGroundingChecks returns the grounding checks of the conversation's answers
+const QueryGroundingChecks = "grounding_checks"
+
+// GroundingCheck is the verification of an answer written from retrieved passages
+type GroundingCheck struct {
+	// MessageIndex is the position of the answer in the history
+	MessageIndex int  `json:"message_index"`
+	Grounded     bool `json:"grounded"`
+	// Unsupported are the claims of the answer the passages do not support
+	Unsupported []string `json:"unsupported,omitempty"`
+	// Regenerated is set when the model rewrote an ungrounded answer; the check is that
+	// of the rewritten answer
+	Regenerated bool      `json:"regenerated,omitempty"`
+	Time        time.Time `json:"time"`
+}
+
+// turnSources returns the retrieval tool's results since the last user message
+func (c *conversation) turnSources() []string {
+	retrievals := map[string]bool{}
+	var sources []string
+	for _, m := range c.history[c.lastUserIndex()+1:] {
+		switch {
+		case m.ToolCall != nil && m.ToolCall.Name == knowledge.ToolName:
+			retrievals[m.ToolCall.ID] = true
+		case m.Role == llm.RoleTool && retrievals[m.ToolCallID] &&
+			!strings.HasPrefix(m.Content, "Error:") && m.Content != knowledge.NoResults:
+			sources = append(sources, m.Content)
+		}
+	}
+	return sources
+}
+
+// groundAnswer verifies an answer written from retrieved passages before it is appended to
+// the history. Unless the worker only flags them, an answer with unsupported claims is
+// regenerated once from the request that produced it. Verification failures keep the
+// answer as it is.
+func (c *conversation) groundAnswer(ctx workflow.Context, req llm.Request, answer string) string {
+	sources := c.turnSources()
+	if len(sources) == 0 || c.groundingOff {
+		return answer
+	}
+	result, err := c.verifyGrounding(ctx, answer, sources)
+	if err != nil {
+		return answer
+	}
+	// The worker configuration decides once per conversation, like the tool list
+	if result.Mode == grounding.ModeOff {
+		c.groundingOff = true
+		return answer
+	}
+
+	check := GroundingCheck{Grounded: result.Verdict.Grounded(), Unsupported: result.Verdict.Unsupported()}
+	if !check.Grounded && result.Mode == grounding.ModeRegenerate {
+		var a *activities.Activities
+		req.Messages = append(req.Messages,
+			llm.Message{Role: llm.RoleAssistant, Content: answer},
+			llm.Message{Role: llm.RoleUser, Content: grounding.Revision(check.Unsupported)},
+		)
+		// The rewrite must come from the passages already retrieved
+		req.Tools = nil
+		var resp llm.Response
+		if err := workflow.ExecuteActivity(withLLM(ctx), a.Complete, req).Get(ctx, &resp); err != nil {
+			workflow.GetLogger(ctx).Error("Error regenerating ungrounded answer", "error", err)
+		} else if resp.Content != "" {
+			c.unrecordedTokens += resp.Usage.Total()
+			answer = resp.Content
+			check.Regenerated = true
+			// A rewrite that could not be verified keeps the verdict of the first answer
+			if result, err := c.verifyGrounding(ctx, answer, sources); err == nil {
+				check.Grounded, check.Unsupported = result.Verdict.Grounded(), result.Verdict.Unsupported()
+			}
+		}
+	}
+	if !check.Grounded {
+		workflow.GetLogger(ctx).Warn("Answer is not grounded in the retrieved passages", "unsupported", check.Unsupported)
+	}
+	check.MessageIndex = len(c.history)
+	check.Time = workflowutil.Now(ctx)
+	c.groundingChecks = append(c.groundingChecks, check)
+	return answer
+}
+
+// verifyGrounding runs the VerifyGrounding activity, logging failures
+func (c *conversation) verifyGrounding(ctx workflow.Context, answer string, sources []string) (activities.GroundingResult, error) {
+	var a *activities.Activities
+	var result activities.GroundingResult
+	err := workflow.ExecuteActivity(withLLM(ctx), a.VerifyGrounding, activities.VerifyGroundingRequest{
+		Question: c.lastUserMessage(),
+		Answer:   answer,
+		Sources:  sources,
+		Tenant:   c.account,
+	}).Get(ctx, &result)
+	if err != nil {
+		workflow.GetLogger(ctx).Error("Error verifying grounding", "error", err)
+	}
+	return result, err
+}
+
+// truncateGroundingChecks forgets the checks of answers from the given history position
+// on, after that part of the history was discarded
+func (c *conversation) truncateGroundingChecks(index int) {
+	for i, check := range c.groundingChecks {
+		if check.MessageIndex >= index {
+			c.groundingChecks = c.groundingChecks[:i]
+			return
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package workflows
+
+import (
+	"fmt"
+	"temporal-ai-agent/activities"
+	"temporal-ai-agent/activities/llm"
+	"temporal-ai-agent/operator"
+	"temporal-ai-agent/outbox"
+	"temporal-ai-agent/retries"
+	"temporal-ai-agent/workflowutil"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// Names used by the human operator handoff
+const (
+	UpdateRequestHandoff  = "request_handoff"
+	UpdateClaimHandoff    = "claim_handoff"
+	UpdateOperatorMessage = "operator_message"
+	UpdateReturnToAgent   = "return_to_agent"
+	QueryHandoff          = "handoff"
+)
+
+// Values of the handoff status search attribute
+const (
+	HandoffPending = "pending"
+	HandoffClaimed = "claimed"
+)
+
+// HandoffStatusKey records whether a conversation awaits or is served by an operator, so
+// the operator console can list them. It must exist as a Keyword attribute in the namespace.
+var HandoffStatusKey = temporal.NewSearchAttributeKeyKeyword("AgentHandoffStatus")
+
+// Handoff is the state of a conversation handed off to a human operator
+type Handoff struct {
+	Reason    string    `json:"reason"`
+	StartedAt time.Time `json:"started_at"`
+	// Operator is the operator who claimed the conversation; empty while it awaits one
+	Operator  string     `json:"operator,omitempty"`
+	ClaimedAt *time.Time `json:"claimed_at,omitempty"`
+}
+
+// OperatorMessage is the argument of the operator_message update
+type OperatorMessage struct {
+	Operator string `json:"operator"`
+	Message  string `json:"message"`
+}
+
+// validateRequestHandoff rejects a handoff while one is already active
+func (c *conversation) validateRequestHandoff(ctx workflow.Context, reason string) error {
+	if c.handoff != nil {
+		return fmt.Errorf("conversation is already handed off to an operator")
+	}
+	return nil
+}
+
+// requestHandoff pauses agent generation and notifies the operator channel. A turn in
+// flight finishes first so its reply is not lost.
+func (c *conversation) requestHandoff(ctx workflow.Context, reason string) (Handoff, error) {
+	ctx, err := c.lockTurn(ctx)
+	if err != nil {
+		return Handoff{}, err
+	}
+	defer c.turnLock.Unlock()
+
+	if err := c.validateRequestHandoff(ctx, reason); err != nil {
+		return Handoff{}, err
+	}
+
+	c.handoff = &Handoff{Reason: reason, StartedAt: workflowutil.Now(ctx)}
+	if err := workflow.UpsertTypedSearchAttributes(ctx, HandoffStatusKey.ValueSet(HandoffPending)); err != nil {
+		return Handoff{}, err
+	}
+	c.events.emit(ctx, Event{Type: EventHandoffStarted, Message: reason})
+	c.notifyOperator(ctx, operator.Notification{Kind: operator.KindHandoffRequested, Reason: reason})
+	return *c.handoff, nil
+}
+
+// validateClaimHandoff lets an operator claim a handoff nobody else has claimed
+func (c *conversation) validateClaimHandoff(ctx workflow.Context, operatorName string) error {
+	if operatorName == "" {
+		return fmt.Errorf("operator is required")
+	}
+	if c.handoff == nil {
+		return fmt.Errorf("conversation is not handed off to an operator")
+	}
+	if c.handoff.Operator != "" && c.handoff.Operator != operatorName {
+		return fmt.Errorf("conversation is already claimed by %s", c.handoff.Operator)
+	}
+	return nil
+}
+
+// claimHandoff assigns the handoff to an operator, who alone may then reply and release it
+func (c *conversation) claimHandoff(ctx workflow.Context, operatorName string) (Handoff, error) {
+	ctx, err := c.lockTurn(ctx)
+	if err != nil {
+		return Handoff{}, err
+	}
+	defer c.turnLock.Unlock()
+
+	if err := c.validateClaimHandoff(ctx, operatorName); err != nil {
+		return Handoff{}, err
+	}
+	if c.handoff.Operator == operatorName {
+		return *c.handoff, nil
+	}
+
+	now := workflowutil.Now(ctx)
+	c.handoff.Operator = operatorName
+	c.handoff.ClaimedAt = &now
+	if err := workflow.UpsertTypedSearchAttributes(ctx, HandoffStatusKey.ValueSet(HandoffClaimed)); err != nil {
+		return Handoff{}, err
+	}
+	return *c.handoff, nil
+}
+
+// validateOperator checks that the named operator holds the handoff
+func (c *conversation) validateOperator(operatorName string) error {
+	if c.handoff == nil {
+		return fmt.Errorf("conversation is not handed off to an operator")
+	}
+	if c.handoff.Operator == "" {
+		return fmt.Errorf("conversation must be claimed first")
+	}
+	if c.handoff.Operator != operatorName {
+		return fmt.Errorf("conversation is claimed by %s", c.handoff.Operator)
+	}
+	return nil
+}
+
+// validateOperatorMessage only accepts messages from the operator holding the handoff
+func (c *conversation) validateOperatorMessage(ctx workflow.Context, msg OperatorMessage) error {
+	if msg.Message == "" {
+		return fmt.Errorf("message is required")
+	}
+	return c.validateOperator(msg.Operator)
+}
+
+// operatorMessage relays an operator-typed message to the user. It is recorded as an
+// assistant message so the agent sees it once it takes over again.
+func (c *conversation) operatorMessage(ctx workflow.Context, msg OperatorMessage) error {
+	ctx, err := c.lockTurn(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.turnLock.Unlock()
+
+	if err := c.validateOperatorMessage(ctx, msg); err != nil {
+		return err
+	}
+
+	c.history = append(c.history, llm.Message{Role: llm.RoleAssistant, Content: msg.Message})
+	c.events.emit(ctx, Event{Type: EventOperatorMessage, Message: msg.Message})
+	c.deliverReply(ctx, msg.Message, true)
+	c.indexTranscript(ctx)
+	c.saveConversation(ctx)
+	return nil
+}
+
+// validateReturnToAgent only lets the operator holding the handoff release it
+func (c *conversation) validateReturnToAgent(ctx workflow.Context, operatorName string) error {
+	return c.validateOperator(operatorName)
+}
+
+// returnToAgent ends the handoff so the agent answers the next user message again
+func (c *conversation) returnToAgent(ctx workflow.Context, operatorName string) error {
+	ctx, err := c.lockTurn(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.turnLock.Unlock()
+
+	if err := c.validateReturnToAgent(ctx, operatorName); err != nil {
+		return err
+	}
+
+	workflow.GetLogger(ctx).Info("Operator returned control to the agent", "operator", operatorName)
+	c.handoff = nil
+	if err := workflow.UpsertTypedSearchAttributes(ctx, HandoffStatusKey.ValueUnset()); err != nil {
+		return err
+	}
+	c.events.emit(ctx, Event{Type: EventHandoffEnded})
+	return nil
+}
+
+// notifyOperator records a notification in the outbox and starts its delivery. If it cannot
+// be recorded, operators can still find the conversation through the handoff query.
+func (c *conversation) notifyOperator(ctx workflow.Context, n operator.Notification) {
+	info := workflow.GetInfo(ctx)
+	n.WorkflowID = info.WorkflowExecution.ID
+	c.notifications++
+	entry := outbox.Entry{
+		DedupKey:     fmt.Sprintf("%s:%s:%d", info.WorkflowExecution.ID, info.WorkflowExecution.RunID, c.notifications),
+		Notification: n,
+		CreatedAt:    workflowutil.Now(ctx),
+	}
+
+	var a *activities.Activities
+	err := workflow.ExecuteActivity(withRetries(ctx, retries.Internal), a.EnqueueNotification, entry).Get(ctx, nil)
+	if err != nil {
+		workflow.GetLogger(ctx).Error("Error recording operator notification", "error", err)
+		return
+	}
+	if err := startNotificationDelivery(ctx, entry); err != nil {
+		workflow.GetLogger(ctx).Error("Error starting notification delivery", "error", err)
+	}
+}
@@ -0,0 +1,51 @@
+package workflows
+
+import (
+	"temporal-ai-agent/activities/llm"
+	"temporal-ai-agent/workflowutil"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// idleMessage closes conversations nobody took part in for their idle timeout
+const idleMessage = "I've closed this conversation since it has been inactive for a while. " +
+	"Start a new one whenever you need me."
+
+// lastActivity returns when the user, an operator or the agent last did something in the
+// conversation: a turn, an answer to a confirmation, an update or an event
+func (c *conversation) lastActivity() time.Time {
+	last := c.lastActivityAt
+	if n := len(c.events.events); n > 0 && c.events.events[n-1].Time.After(last) {
+		last = c.events.events[n-1].Time
+	}
+	return last
+}
+
+// idleDeadline returns when the conversation closes unless something happens before, if it
+// has an idle timeout. Conversations handed off to an operator stay open until returned.
+func (c *conversation) idleDeadline() (time.Time, bool) {
+	if c.idleTimeout <= 0 || c.handoff != nil {
+		return time.Time{}, false
+	}
+	return c.lastActivity().Add(c.idleTimeout), true
+}
+
+// closeIdle records the closing message of a conversation that stayed inactive past its
+// idle deadline. It reports false, leaving the conversation open, when something happened
+// since the deadline was set. Work still in flight counts as activity, so the deadline is
+// pushed back a whole timeout.
+func (c *conversation) closeIdle(ctx workflow.Context) (string, bool) {
+	now := workflowutil.Now(ctx)
+	if deadline, ok := c.idleDeadline(); !ok || now.Before(deadline) {
+		return "", false
+	}
+	if c.turnLock.IsLocked() || !c.idle(ctx) {
+		c.lastActivityAt = now
+		return "", false
+	}
+	workflow.GetLogger(ctx).Info("Closing idle conversation", "last_activity", c.lastActivity())
+	c.history = append(c.history, llm.Message{Role: llm.RoleAssistant, Content: idleMessage})
+	c.events.emit(ctx, Event{Type: EventMessage, Message: idleMessage})
+	return idleMessage, true
+}
@@ -0,0 +1,131 @@
+package workflows
+
+import (
+	"temporal-ai-agent/activities"
+	"temporal-ai-agent/blobstore"
+	"temporal-ai-agent/retries"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// BucketIngestScheduleID is the ID of the schedule running BucketIngestWorkflow
+const BucketIngestScheduleID = "bucket-ingest"
+
+// Bucket ingestion limits
+const (
+	// maxBucketObjects bounds the objects ingested per run; the others are left for the
+	// next runs, as their ETags are not recorded yet
+	maxBucketObjects        = 500
+	bucketIngestConcurrency = 4
+	bucketObjectTimeout     = 5 * time.Minute
+	maxIngestFailures       = 50
+)
+
+// BucketIngestRequest is the input of BucketIngestWorkflow
+type BucketIngestRequest struct {
+	// Namespace is the knowledge namespace the bucket's documents are ingested into
+	Namespace string `json:"namespace"`
+}
+
+// IngestFailure is an object that could not be ingested
+type IngestFailure struct {
+	Key   string `json:"key"`
+	Error string `json:"error"`
+}
+
+// BucketIngestResult is the outcome of BucketIngestWorkflow
+type BucketIngestResult struct {
+	Namespace string `json:"namespace"`
+	// Listed is the number of objects in the bucket, Unchanged those already ingested
+	// with their current ETag
+	Listed    int `json:"listed"`
+	Unchanged int `json:"unchanged"`
+	// Ingested and Chunks count the objects ingested and the passages they were split into
+	Ingested int `json:"ingested"`
+	Chunks   int `json:"chunks"`
+	// Removed counts the documents whose object was deleted
+	Removed int `json:"removed"`
+	// Deferred counts the changed objects beyond this run's limit
+	Deferred int `json:"deferred,omitempty"`
+	// Failures counts the objects that could not be ingested; Failed lists the first of them
+	Failures int             `json:"failures"`
+	Failed   []IngestFailure `json:"failed,omitempty"`
+}
+
+// BucketIngestWorkflow incrementally indexes a bucket into a knowledge namespace: objects
+// that are new or whose ETag changed since they were ingested are ingested again, a few at
+// a time, and the documents of deleted objects are removed. It runs on a schedule; a run
+// only does the work of the changes since the previous one.
+func BucketIngestWorkflow(ctx workflow.Context, req BucketIngestRequest) (BucketIngestResult, error) {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: bucketObjectTimeout,
+		RetryPolicy:         retryPolicy(retries.ExternalAPI),
+	})
+	result := BucketIngestResult{Namespace: req.Namespace}
+
+	var a *activities.Activities
+	var changes activities.BucketChanges
+	if err := workflow.ExecuteActivity(ctx, a.ListBucketChanges, activities.BucketChangesRequest{
+		Namespace: req.Namespace,
+	}).Get(ctx, &changes); err != nil {
+		return result, err
+	}
+	result.Listed = changes.Listed
+	result.Unchanged = changes.Listed - len(changes.Changed)
+
+	if len(changes.Removed) > 0 {
+		if err := workflow.ExecuteActivity(withRetries(ctx, retries.Internal), a.RemoveDocuments, activities.RemoveDocumentsRequest{
+			Namespace:   req.Namespace,
+			DocumentIDs: changes.Removed,
+		}).Get(ctx, nil); err != nil {
+			return result, err
+		}
+		result.Removed = len(changes.Removed)
+	}
+
+	objects := changes.Changed
+	if len(objects) > maxBucketObjects {
+		result.Deferred = len(objects) - maxBucketObjects
+		objects = objects[:maxBucketObjects]
+	}
+	type pendingObject struct {
+		object blobstore.Object
+		future workflow.Future
+	}
+	var pending []pendingObject
+	wait := func() {
+		next := pending[0]
+		pending = pending[1:]
+		var chunks int
+		if err := next.future.Get(ctx, &chunks); err != nil {
+			workflow.GetLogger(ctx).Warn("Object not ingested", "key", next.object.Key, "error", err)
+			result.Failures++
+			if len(result.Failed) < maxIngestFailures {
+				result.Failed = append(result.Failed, IngestFailure{Key: next.object.Key, Error: failureReason(err)})
+			}
+			return
+		}
+		result.Ingested++
+		result.Chunks += chunks
+	}
+	for _, obj := range objects {
+		if len(pending) == bucketIngestConcurrency {
+			wait()
+		}
+		pending = append(pending, pendingObject{
+			object: obj,
+			future: workflow.ExecuteActivity(ctx, a.IngestObject, activities.IngestObjectRequest{
+				Namespace: req.Namespace,
+				Object:    obj,
+			}),
+		})
+	}
+	for len(pending) > 0 {
+		wait()
+	}
+
+	workflow.GetLogger(ctx).Info("Bucket ingested", "namespace", req.Namespace, "ingested", result.Ingested,
+		"removed", result.Removed, "failures", result.Failures, "deferred", result.Deferred)
+	return result, nil
+}
@@ -0,0 +1,24 @@
+package workflows
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Messages without a clear language keep the one detected before
+func TestDetectLanguageKeepsPrevious(t *testing.T) {
+	var c conversation
+	c.detectLanguage("ok")
+	require.Empty(t, c.language)
+
+	c.detectLanguage("Hola, ¿cómo estás? Quiero ayuda")
+	require.Equal(t, "es", c.language)
+	for _, message := range []string{"ok", "42", "de"} {
+		c.detectLanguage(message)
+		require.Equal(t, "es", c.language, message)
+	}
+
+	c.detectLanguage("Bonjour, je voudrais de l'aide")
+	require.Equal(t, "fr", c.language)
+}
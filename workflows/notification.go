@@ -0,0 +1,49 @@
+package workflows
+
+import (
+	"temporal-ai-agent/activities"
+	"temporal-ai-agent/outbox"
+	"time"
+
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// notificationDeliveryWindow is how long a notification is retried before it is given up
+const notificationDeliveryWindow = 24 * time.Hour
+
+// notificationRetryPolicy backs off up to one attempt every ten minutes, so an outage of the
+// receiving channel does not hammer it
+var notificationRetryPolicy = &temporal.RetryPolicy{
+	InitialInterval:    5 * time.Second,
+	BackoffCoefficient: 2,
+	MaximumInterval:    10 * time.Minute,
+}
+
+// NotificationDeliveryWorkflow delivers an outbox entry, retrying until it succeeds or the
+// delivery window has passed. Delivery is at least once: receivers drop duplicates by the
+// notification ID, which is the entry's dedup key.
+func NotificationDeliveryWorkflow(ctx workflow.Context, entry outbox.Entry) error {
+	ao := workflow.ActivityOptions{
+		StartToCloseTimeout:    30 * time.Second,
+		ScheduleToCloseTimeout: notificationDeliveryWindow,
+		RetryPolicy:            notificationRetryPolicy,
+	}
+	ctx = workflow.WithActivityOptions(ctx, ao)
+
+	var a *activities.Activities
+	return workflow.ExecuteActivity(ctx, a.DeliverNotification, entry).Get(ctx, nil)
+}
+
+// startNotificationDelivery starts the delivery of an outbox entry as a detached child
+// workflow, so it outlives the conversation. The workflow ID derives from the dedup key, so a
+// notification is never delivered by two workflows.
+func startNotificationDelivery(ctx workflow.Context, entry outbox.Entry) error {
+	cwo := workflow.ChildWorkflowOptions{
+		WorkflowID:        "notification:" + entry.DedupKey,
+		ParentClosePolicy: enumspb.PARENT_CLOSE_POLICY_ABANDON,
+	}
+	child := workflow.ExecuteChildWorkflow(workflow.WithChildOptions(ctx, cwo), NotificationDeliveryWorkflow, entry)
+	return child.GetChildWorkflowExecution().Get(ctx, nil)
+}
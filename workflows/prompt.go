@@ -0,0 +1,134 @@
+package workflows
+
+import (
+	"fmt"
+	"temporal-ai-agent/workflowutil"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// UpdateUserPrompt is the update that sends a user message and returns the agent's reply
+const UpdateUserPrompt = "user_prompt"
+
+// SignalUserPrompt is the signal that sends a user message without waiting for the reply
+const SignalUserPrompt = "user_prompt"
+
+// maxMessageIDs bounds the number of processed message IDs remembered for deduplication
+const maxMessageIDs = 1000
+
+// UserPrompt is a user message sent through the user_prompt signal or update
+type UserPrompt struct {
+	Message string `json:"message"`
+	// MessageID is a client-supplied ID; messages repeating a processed ID are dropped
+	MessageID string `json:"message_id,omitempty"`
+	// Seq is an optional per-conversation sequence number starting at 1; signals are
+	// processed in sequence order even if they arrive out of order
+	Seq int `json:"seq,omitempty"`
+	// Attachments are documents whose text is added to the message
+	Attachments []Attachment `json:"attachments,omitempty"`
+	// From is the channel the message was sent from, when it is not the API's own
+	// clients; the reply is delivered there
+	From *ChannelLink `json:"from,omitempty"`
+}
+
+// TurnResult is the result of a user-prompt update
+type TurnResult struct {
+	Reply string `json:"reply"`
+	// EventsAfter is the event sequence number preceding this turn; query events after it
+	// (or stream them) to replay the turn's progress
+	EventsAfter int `json:"events_after"`
+	// QuickReplies are the replies suggested for the user's next message, when the
+	// conversation suggests replies
+	QuickReplies []string `json:"quick_replies,omitempty"`
+	// Downgraded is set when the turn's latency budget ran out and the fallback model answered
+	Downgraded bool `json:"downgraded,omitempty"`
+}
+
+// validateUserPrompt rejects empty and already processed user messages before they are recorded in history
+func (c *conversation) validateUserPrompt(ctx workflow.Context, prompt UserPrompt) error {
+	if prompt.Message == "" && len(prompt.Attachments) == 0 {
+		return fmt.Errorf("message or attachments are required")
+	}
+	if c.messageIDs.seen(prompt.MessageID) {
+		return fmt.Errorf("message %s was already processed", prompt.MessageID)
+	}
+	return nil
+}
+
+// userPromptUpdate handles a user message sent as an update and returns the reply synchronously
+func (c *conversation) userPromptUpdate(ctx workflow.Context, prompt UserPrompt) (TurnResult, error) {
+	workflow.GetLogger(ctx).Info("Received user_prompt update", "message", prompt.Message, "message_id", prompt.MessageID)
+	c.messageIDs.add(prompt.MessageID)
+	return c.handleUserPrompt(ctx, prompt.From, c.withAttachments(ctx, prompt))
+}
+
+// acceptPrompt deduplicates and orders a user_prompt signal, returning the messages
+// that are ready to be answered
+func (c *conversation) acceptPrompt(ctx workflow.Context, prompt UserPrompt) []string {
+	// Client retries resend the same message ID; answer each message once
+	if !c.messageIDs.add(prompt.MessageID) {
+		workflow.GetLogger(ctx).Info("Dropped duplicate user_prompt signal", "message_id", prompt.MessageID)
+		return nil
+	}
+	return c.sequencer.accept(workflowutil.Now(ctx), prompt.Seq, c.withAttachments(ctx, prompt))
+}
+
+// processPrompts answers ready messages sent from the given channel, in one turn when
+// coalescing and one turn each otherwise. It returns the latest reply, or previous if
+// there was none.
+func (c *conversation) processPrompts(ctx workflow.Context, messages []string, from *ChannelLink, coalesce bool, previous string) string {
+	if len(messages) == 0 {
+		return previous
+	}
+
+	turns := [][]string{messages}
+	if !coalesce {
+		turns = turns[:0]
+		for _, m := range messages {
+			turns = append(turns, []string{m})
+		}
+	}
+
+	reply := previous
+	for _, turn := range turns {
+		result, err := c.handleUserPrompt(ctx, from, turn...)
+		if err != nil {
+			workflow.GetLogger(ctx).Error("Error processing user prompt", "error", err)
+			continue
+		}
+		reply = result.Reply
+	}
+	return reply
+}
+
+// messageIDSet remembers the most recent processed message IDs
+type messageIDSet struct {
+	ids   map[string]bool
+	order []string
+}
+
+// seen reports whether a message ID was already processed. Empty IDs are never duplicates.
+func (s *messageIDSet) seen(id string) bool {
+	return id != "" && s.ids[id]
+}
+
+// add records a message ID, forgetting the oldest beyond maxMessageIDs. It reports false
+// if the ID was already recorded.
+func (s *messageIDSet) add(id string) bool {
+	if id == "" {
+		return true
+	}
+	if s.ids[id] {
+		return false
+	}
+	if s.ids == nil {
+		s.ids = map[string]bool{}
+	}
+	s.ids[id] = true
+	s.order = append(s.order, id)
+	if len(s.order) > maxMessageIDs {
+		delete(s.ids, s.order[0])
+		s.order = s.order[1:]
+	}
+	return true
+}
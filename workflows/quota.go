@@ -0,0 +1,32 @@
+package workflows
+
+import (
+	"temporal-ai-agent/activities"
+	"temporal-ai-agent/quota"
+	"temporal-ai-agent/retries"
+	"temporal-ai-agent/workflowutil"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// recordUsage counts user messages and the tokens used since the last call against the
+// account's quota. Recording is best effort: a lost record must not fail the turn.
+func (c *conversation) recordUsage(ctx workflow.Context, messages int) {
+	if c.account == "" || (messages == 0 && c.unrecordedTokens == 0) {
+		return
+	}
+
+	var a *activities.Activities
+	ctx = withRetries(ctx, retries.Internal)
+	err := workflow.ExecuteActivity(ctx, a.RecordUsage, quota.Record{
+		Account:  c.account,
+		Messages: messages,
+		Tokens:   c.unrecordedTokens,
+		Time:     workflowutil.Now(ctx),
+	}).Get(ctx, nil)
+	if err != nil {
+		workflow.GetLogger(ctx).Error("Error recording usage", "error", err)
+		return
+	}
+	c.unrecordedTokens = 0
+}
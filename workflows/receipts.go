@@ -0,0 +1,203 @@
+package workflows
+
+import (
+	"fmt"
+	"slices"
+	"temporal-ai-agent/activities/llm"
+	"temporal-ai-agent/channels"
+	"temporal-ai-agent/workflowutil"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// Names used by read receipts
+const (
+	SignalReceipt = "receipt"
+	QueryReceipts = "receipts"
+)
+
+// Statuses of an agent message on a channel. A message is sent once the channel's adapter
+// accepted it (or, on the web, once the agent wrote it), then acknowledged as delivered and
+// read by the channel's clients; failed deliveries are never acknowledged.
+const (
+	ReceiptFailed    = "failed"
+	ReceiptSent      = "sent"
+	ReceiptDelivered = "delivered"
+	ReceiptRead      = "read"
+)
+
+// receiptRanks orders the statuses: a receipt never goes back to an earlier one
+var receiptRanks = map[string]int{
+	ReceiptFailed:    0,
+	ReceiptSent:      1,
+	ReceiptDelivered: 2,
+	ReceiptRead:      3,
+}
+
+// maxReceipts bounds the receipts kept in the workflow state; the oldest are dropped first
+const maxReceipts = 500
+
+// reminderPrefix introduces an important message sent again because it was left unread
+const reminderPrefix = "Reminder: "
+
+// Receipt tracks the delivery of an agent message on one channel
+type Receipt struct {
+	// MessageIndex is the position of the message in the history
+	MessageIndex int    `json:"message_index"`
+	Channel      string `json:"channel"`
+	Status       string `json:"status"`
+	// Important messages wait for the user, such as a tool call to confirm or an
+	// operator's message; they are sent again once when left unread
+	Important   bool       `json:"important,omitempty"`
+	SentAt      time.Time  `json:"sent_at"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+	ReadAt      *time.Time `json:"read_at,omitempty"`
+	// RenotifiedAt is when the reminder of an unread important message was sent
+	RenotifiedAt *time.Time `json:"renotified_at,omitempty"`
+}
+
+// ReceiptAck is the payload of the receipt signal: a channel's client acknowledging that an
+// agent message was delivered or read. An ack covers the earlier messages of the channel
+// too, as chat clients acknowledge the latest message they show.
+type ReceiptAck struct {
+	MessageIndex int `json:"message_index"`
+	// Channel is the channel acknowledging; empty is the API's own clients
+	Channel string `json:"channel,omitempty"`
+	Status  string `json:"status"`
+}
+
+// Validate checks the status and message index of an ack
+func (a ReceiptAck) Validate() error {
+	if a.Status != ReceiptDelivered && a.Status != ReceiptRead {
+		return fmt.Errorf("status must be %s or %s", ReceiptDelivered, ReceiptRead)
+	}
+	if a.MessageIndex < 0 {
+		return fmt.Errorf("message_index must not be negative")
+	}
+	return nil
+}
+
+// receiptChannel returns the channel receipts are kept under: the API's own clients are web
+func receiptChannel(channel string) string {
+	if channel == "" {
+		return channels.Web
+	}
+	return channel
+}
+
+// addReceipt records an agent message sent on a channel
+func (c *conversation) addReceipt(ctx workflow.Context, index int, channel, status string, important bool) {
+	c.receipts = append(c.receipts, Receipt{
+		MessageIndex: index,
+		Channel:      receiptChannel(channel),
+		Status:       status,
+		Important:    important,
+		SentAt:       workflowutil.Now(ctx),
+	})
+	if len(c.receipts) > maxReceipts {
+		c.receipts = slices.Delete(c.receipts, 0, len(c.receipts)-maxReceipts)
+	}
+}
+
+// acknowledge applies an ack from a channel's client. Messages the web shows are tracked
+// from their first ack; other channels only acknowledge the messages delivered to them.
+func (c *conversation) acknowledge(ctx workflow.Context, ack ReceiptAck) {
+	if err := ack.Validate(); err != nil {
+		workflow.GetLogger(ctx).Warn("Dropped invalid receipt", "error", err)
+		return
+	}
+	if ack.MessageIndex >= len(c.history) || c.history[ack.MessageIndex].Role != llm.RoleAssistant {
+		workflow.GetLogger(ctx).Warn("Dropped receipt of a message that is not the agent's", "message_index", ack.MessageIndex)
+		return
+	}
+	channel := receiptChannel(ack.Channel)
+	if channel == channels.Web && !slices.ContainsFunc(c.receipts, func(r Receipt) bool {
+		return r.MessageIndex == ack.MessageIndex && r.Channel == channel
+	}) {
+		c.addReceipt(ctx, ack.MessageIndex, channel, ReceiptSent, false)
+	}
+	c.advanceReceipts(ctx, channel, ack.MessageIndex, ack.Status)
+}
+
+// readReceipts marks the messages of a channel as read when the user writes from it: they
+// have seen the replies before answering
+func (c *conversation) readReceipts(ctx workflow.Context, channel string) {
+	c.advanceReceipts(ctx, receiptChannel(channel), len(c.history), ReceiptRead)
+}
+
+// advanceReceipts moves the receipts of a channel up to a message index to the status,
+// unless they are past it already or their delivery failed
+func (c *conversation) advanceReceipts(ctx workflow.Context, channel string, index int, status string) {
+	now := workflowutil.Now(ctx)
+	for i := range c.receipts {
+		r := &c.receipts[i]
+		if r.Channel != channel || r.MessageIndex > index || r.Status == ReceiptFailed ||
+			receiptRanks[r.Status] >= receiptRanks[status] {
+			continue
+		}
+		r.Status = status
+		if r.DeliveredAt == nil {
+			r.DeliveredAt = &now
+		}
+		if status == ReceiptRead {
+			r.ReadAt = &now
+		}
+	}
+}
+
+// renotifyDeadline returns when the earliest unread important message is due a reminder, if
+// reminders are enabled and one is waiting
+func (c *conversation) renotifyDeadline() (time.Time, bool) {
+	var deadline time.Time
+	found := false
+	for _, r := range c.receipts {
+		if !c.awaitsReminder(r) {
+			continue
+		}
+		if due := r.SentAt.Add(c.renotifyAfter); !found || due.Before(deadline) {
+			deadline, found = due, true
+		}
+	}
+	return deadline, found
+}
+
+// awaitsReminder reports whether a receipt is of an important message still unread that no
+// reminder was sent for
+func (c *conversation) awaitsReminder(r Receipt) bool {
+	return c.renotifyAfter > 0 && r.Important && r.Status != ReceiptRead && r.RenotifiedAt == nil
+}
+
+// renotify sends a reminder of the important messages left unread past the renotify delay:
+// through the channel's adapter again, or as a reminder event to the web's streams
+func (c *conversation) renotify(ctx workflow.Context) {
+	now := workflowutil.Now(ctx)
+	// Reminders are picked before any is sent, as deliveries yield to handlers changing the
+	// receipts and the history
+	var due []Receipt
+	var messages []string
+	for i := range c.receipts {
+		r := &c.receipts[i]
+		if c.awaitsReminder(*r) && !now.Before(r.SentAt.Add(c.renotifyAfter)) && r.MessageIndex < len(c.history) {
+			r.RenotifiedAt = &now
+			due = append(due, *r)
+			messages = append(messages, c.history[r.MessageIndex].Content)
+		}
+	}
+	for i, r := range due {
+		workflow.GetLogger(ctx).Info("Reminding the user of an unread message", "channel", r.Channel, "message_index", r.MessageIndex)
+		if r.Channel == channels.Web {
+			c.events.emit(ctx, Event{Type: EventReminder, Message: messages[i]})
+			continue
+		}
+		c.deliver(ctx, r.Channel, r.MessageIndex, reminderPrefix+messages[i], "reminder")
+	}
+}
+
+// truncateReceipts forgets the receipts of the messages from the given history position on,
+// after that part of the history was discarded
+func (c *conversation) truncateReceipts(index int) {
+	c.receipts = slices.DeleteFunc(c.receipts, func(r Receipt) bool {
+		return r.MessageIndex >= index
+	})
+}
@@ -0,0 +1,30 @@
+package workflows
+
+import (
+	"temporal-ai-agent/activities"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// QueryQuickReplies returns the replies suggested to the user for their next message
+const QueryQuickReplies = "quick_replies"
+
+// suggestReplies generates the replies suggested after the agent's answer and emits them.
+// Suggestions are best effort: without them the user simply types.
+func (c *conversation) suggestReplies(ctx workflow.Context) []string {
+	var a *activities.Activities
+	var replies []string
+	err := workflow.ExecuteActivity(withLLM(ctx), a.SuggestReplies, activities.SuggestRepliesRequest{
+		Messages: c.history,
+		Tenant:   c.account,
+	}).Get(ctx, &replies)
+	if err != nil {
+		workflow.GetLogger(ctx).Error("Error suggesting replies", "error", err)
+		return nil
+	}
+	if len(replies) > 0 {
+		c.quickReplies = replies
+		c.events.emit(ctx, Event{Type: EventQuickReplies, QuickReplies: replies})
+	}
+	return replies
+}
@@ -0,0 +1,255 @@
+package workflows
+
+import (
+	"fmt"
+	"strings"
+	"temporal-ai-agent/activities"
+	"temporal-ai-agent/retries"
+	"temporal-ai-agent/websearch"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// GoalResearch is the goal served by DeepResearchWorkflow
+const GoalResearch = "research"
+
+// QueryResearchProgress returns the ResearchProgress of a research workflow
+const QueryResearchProgress = "progress"
+
+// Research stages reported by the progress query
+const (
+	StagePlanning     = "planning"
+	StageSearching    = "searching"
+	StageReading      = "reading"
+	StageRefining     = "refining"
+	StageSynthesizing = "synthesizing"
+	StageDone         = "done"
+)
+
+// Research limits
+const (
+	defaultResearchIterations = 3
+	maxResearchIterations     = 10
+	defaultResearchSources    = 20
+	maxResearchSources        = 50
+	resultsPerQuery           = 3
+	followUpQueries           = 3
+	maxProgressLog            = 100
+)
+
+// ResearchRequest is the input of DeepResearchWorkflow
+type ResearchRequest struct {
+	Question string `json:"question"`
+	// MaxIterations bounds the search → read → refine rounds
+	MaxIterations int `json:"max_iterations,omitempty"`
+	// MaxSources bounds the number of pages read
+	MaxSources int `json:"max_sources,omitempty"`
+	// Account is the quota account of the API key that started the research, set by the
+	// server; its own LLM key is used if it has one
+	Account string `json:"account,omitempty"`
+}
+
+// ResearchProgress is the state of a research workflow returned by its progress query
+type ResearchProgress struct {
+	Question  string   `json:"question"`
+	Stage     string   `json:"stage"`
+	Iteration int      `json:"iteration"`
+	Queries   []string `json:"queries"`
+	Sources   int      `json:"sources"`
+	Log       []string `json:"log"`
+}
+
+// ResearchReport is the result of DeepResearchWorkflow
+type ResearchReport struct {
+	Question string              `json:"question"`
+	Report   string              `json:"report"`
+	Sources  []activities.Source `json:"sources"`
+}
+
+// research holds the state of a running research workflow
+type research struct {
+	req      ResearchRequest
+	progress ResearchProgress
+	sources  []activities.Source
+	seen     map[string]bool
+}
+
+// DeepResearchWorkflow answers a question by planning search queries, reading the results,
+// refining the queries until the notes suffice and synthesizing a report that cites its sources.
+func DeepResearchWorkflow(ctx workflow.Context, req ResearchRequest) (ResearchReport, error) {
+	ctx = withLLM(ctx)
+
+	if req.MaxIterations <= 0 {
+		req.MaxIterations = defaultResearchIterations
+	}
+	req.MaxIterations = min(req.MaxIterations, maxResearchIterations)
+	if req.MaxSources <= 0 {
+		req.MaxSources = defaultResearchSources
+	}
+	req.MaxSources = min(req.MaxSources, maxResearchSources)
+
+	r := &research{
+		req:      req,
+		progress: ResearchProgress{Question: req.Question},
+		seen:     map[string]bool{},
+	}
+	if err := workflow.SetQueryHandler(ctx, QueryResearchProgress, func() (ResearchProgress, error) {
+		return r.progress, nil
+	}); err != nil {
+		return ResearchReport{}, err
+	}
+
+	var a *activities.Activities
+
+	r.setStage(ctx, StagePlanning, "Planning search queries")
+	var queries []string
+	if err := workflow.ExecuteActivity(ctx, a.PlanResearch, activities.PlanResearchRequest{
+		Question: req.Question,
+		Tenant:   req.Account,
+	}).Get(ctx, &queries); err != nil {
+		return ResearchReport{}, err
+	}
+	if len(queries) == 0 {
+		queries = []string{req.Question}
+	}
+
+	for len(queries) > 0 && len(r.sources) < req.MaxSources {
+		r.progress.Iteration++
+		r.progress.Queries = append(r.progress.Queries, queries...)
+
+		results := r.search(ctx, queries)
+		r.read(ctx, results)
+
+		if r.progress.Iteration >= req.MaxIterations || len(r.sources) >= req.MaxSources {
+			break
+		}
+
+		r.setStage(ctx, StageRefining, "Checking for gaps in the notes")
+		var next []string
+		refine := activities.RefineResearchRequest{
+			Question: req.Question,
+			Asked:    r.progress.Queries,
+			Notes:    r.notes(),
+			Limit:    followUpQueries,
+			Tenant:   req.Account,
+		}
+		if err := workflow.ExecuteActivity(ctx, a.RefineResearch, refine).Get(ctx, &next); err != nil {
+			r.logf(ctx, "Refining failed, stopping early: %v", err)
+			break
+		}
+		queries = r.newQueries(next)
+	}
+
+	r.setStage(ctx, StageSynthesizing, fmt.Sprintf("Writing the report from %d sources", len(r.sources)))
+	report := ResearchReport{Question: req.Question, Sources: r.sources}
+	synth := activities.SynthesizeReportRequest{Question: req.Question, Sources: r.sources, Tenant: req.Account}
+	if err := workflow.ExecuteActivity(ctx, a.SynthesizeReport, synth).Get(ctx, &report.Report); err != nil {
+		return ResearchReport{}, err
+	}
+
+	r.setStage(ctx, StageDone, "Research complete")
+	return report, nil
+}
+
+// search runs the queries in parallel and returns the unseen results that fit in the source budget
+func (r *research) search(ctx workflow.Context, queries []string) []websearch.Result {
+	r.setStage(ctx, StageSearching, fmt.Sprintf("Searching %d queries", len(queries)))
+
+	var a *activities.Activities
+	futures := make([]workflow.Future, len(queries))
+	for i, q := range queries {
+		futures[i] = workflow.ExecuteActivity(withRetries(ctx, retries.ExternalAPI), a.WebSearch, activities.WebSearchRequest{Query: q, Limit: resultsPerQuery})
+	}
+
+	var results []websearch.Result
+	for i, f := range futures {
+		var found []websearch.Result
+		if err := f.Get(ctx, &found); err != nil {
+			r.logf(ctx, "Search %q failed: %v", queries[i], err)
+			continue
+		}
+		for _, res := range found {
+			if r.seen[res.URL] || len(r.sources)+len(results) >= r.req.MaxSources {
+				continue
+			}
+			r.seen[res.URL] = true
+			results = append(results, res)
+		}
+	}
+	return results
+}
+
+// read takes notes from the results in parallel and keeps the relevant ones as sources
+func (r *research) read(ctx workflow.Context, results []websearch.Result) {
+	r.setStage(ctx, StageReading, fmt.Sprintf("Reading %d sources", len(results)))
+
+	var a *activities.Activities
+	futures := make([]workflow.Future, len(results))
+	for i, res := range results {
+		futures[i] = workflow.ExecuteActivity(ctx, a.ReadSource, activities.ReadSourceRequest{
+			Question: r.req.Question,
+			Result:   res,
+			Tenant:   r.req.Account,
+		})
+	}
+
+	for i, f := range futures {
+		var notes string
+		if err := f.Get(ctx, &notes); err != nil {
+			r.logf(ctx, "Reading %s failed: %v", results[i].URL, err)
+			continue
+		}
+		notes = strings.TrimSpace(notes)
+		if notes == "" || strings.EqualFold(notes, "NONE") {
+			continue
+		}
+		r.sources = append(r.sources, activities.Source{
+			ID:    len(r.sources) + 1,
+			Title: results[i].Title,
+			URL:   results[i].URL,
+			Notes: notes,
+		})
+	}
+	r.progress.Sources = len(r.sources)
+}
+
+// newQueries drops follow-up queries that were already run
+func (r *research) newQueries(queries []string) []string {
+	asked := make(map[string]bool, len(r.progress.Queries))
+	for _, q := range r.progress.Queries {
+		asked[strings.ToLower(q)] = true
+	}
+
+	var fresh []string
+	for _, q := range queries {
+		if !asked[strings.ToLower(q)] {
+			fresh = append(fresh, q)
+		}
+	}
+	return fresh
+}
+
+// notes returns the notes of every source, numbered like the citations
+func (r *research) notes() []string {
+	notes := make([]string, len(r.sources))
+	for i, s := range r.sources {
+		notes[i] = fmt.Sprintf("[%d] %s", s.ID, s.Notes)
+	}
+	return notes
+}
+
+// setStage records the current stage and logs it
+func (r *research) setStage(ctx workflow.Context, stage, message string) {
+	r.progress.Stage = stage
+	r.logf(ctx, "%s", message)
+}
+
+// logf appends a line to the progress log, keeping the most recent entries
+func (r *research) logf(ctx workflow.Context, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	workflow.GetLogger(ctx).Info("Research progress", "stage", r.progress.Stage, "message", msg)
+	r.progress.Log = append(r.progress.Log, msg)
+	if len(r.progress.Log) > maxProgressLog {
+		r.progress.Log = r.progress.Log[1:]
+	}
+}
@@ -0,0 +1,23 @@
+package workflows
+
+import (
+	"temporal-ai-agent/activities"
+	"temporal-ai-agent/knowledge"
+	"temporal-ai-agent/retries"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// DebugRetrievalWorkflow traces how the retrieval tool answers a query. The knowledge base
+// lives in the workers, so the API reaches it through this workflow.
+func DebugRetrievalWorkflow(ctx workflow.Context, req activities.DebugRetrievalRequest) (knowledge.Retrieval, error) {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy:         retryPolicy(retries.ExternalAPI),
+	})
+	var a *activities.Activities
+	var retrieval knowledge.Retrieval
+	err := workflow.ExecuteActivity(ctx, a.DebugRetrieval, req).Get(ctx, &retrieval)
+	return retrieval, err
+}
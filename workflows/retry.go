@@ -0,0 +1,40 @@
+package workflows
+
+import (
+	"temporal-ai-agent/retries"
+	"temporal-ai-agent/tools"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// retryPresets are the retry policies of the activity categories. A policy is recorded when
+// an activity is scheduled, so changing the presets only affects activities scheduled afterwards.
+var retryPresets = retries.Defaults()
+
+// SetRetryPolicies replaces the retry presets. The worker calls it once, before it starts.
+func SetRetryPolicies(presets retries.Presets) {
+	retryPresets = presets
+}
+
+// retryPolicy returns the retry policy of an activity category
+func retryPolicy(category string) *temporal.RetryPolicy {
+	return retryPresets.Policy(category)
+}
+
+// withRetries applies the retry policy of a category to the activities started with the
+// returned context
+func withRetries(ctx workflow.Context, category string) workflow.Context {
+	ao := workflow.GetActivityOptions(ctx)
+	ao.RetryPolicy = retryPolicy(category)
+	return workflow.WithActivityOptions(ctx, ao)
+}
+
+// toolRetryCategory returns the retry category of a tool: irreversible tools must not run
+// twice, others call external services
+func toolRetryCategory(def tools.Definition) string {
+	if def.RiskLevel() == tools.RiskIrreversible {
+		return retries.Destructive
+	}
+	return retries.ExternalAPI
+}
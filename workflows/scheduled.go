@@ -0,0 +1,52 @@
+package workflows
+
+import (
+	"temporal-ai-agent/activities"
+	"temporal-ai-agent/retries"
+	"temporal-ai-agent/workflowutil"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// ScheduledTaskPrefix starts the IDs of the schedules running agent tasks, which sets them
+// apart from the agent's own schedules such as the billing export
+const ScheduledTaskPrefix = "agent-task-"
+
+// Memo keys of the schedules running agent tasks
+const (
+	ScheduleMemoDescription = "description"
+	ScheduleMemoUserID      = "user_id"
+	ScheduleMemoCron        = "cron"
+	ScheduleMemoInterval    = "interval"
+	// ScheduleMemoAccount is the quota account of the API key that created the schedule, the
+	// only one allowed to see and manage it
+	ScheduleMemoAccount = "account"
+)
+
+// ScheduledTask is a recurring job a user scheduled for the agent
+type ScheduledTask struct {
+	Description string `json:"description"`
+	UserID      string `json:"user_id,omitempty"`
+	// Account is the quota account that scheduled the task, whose own LLM key runs it
+	Account string `json:"account,omitempty"`
+}
+
+// ScheduledTaskWorkflow runs one occurrence of a scheduled task. Unlike background tasks,
+// which loop in a single workflow, each occurrence is started by a Temporal schedule, so
+// the schedule can be paused, triggered or deleted independently of any conversation.
+func ScheduledTaskWorkflow(ctx workflow.Context, task ScheduledTask) (TaskRun, error) {
+	ao := workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy:         retryPolicy(retries.LLM),
+	}
+	ctx = workflow.WithActivityOptions(ctx, ao)
+
+	var a *activities.Activities
+	run := TaskRun{Time: workflowutil.Now(ctx)}
+	err := workflow.ExecuteActivity(ctx, a.RunBackgroundTask, activities.RunBackgroundTaskRequest{
+		Description: task.Description,
+		Tenant:      task.Account,
+	}).Get(ctx, &run.Result)
+	return run, err
+}
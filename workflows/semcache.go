@@ -0,0 +1,71 @@
+package workflows
+
+import (
+	"temporal-ai-agent/activities"
+	"temporal-ai-agent/activities/llm"
+	"temporal-ai-agent/retries"
+	"temporal-ai-agent/semcache"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// openQuestion returns the user message the agent is about to answer, or "" when the turn
+// continues from something else, such as a tool result after a confirmation
+func (c *conversation) openQuestion() string {
+	if len(c.history) == 0 || c.history[len(c.history)-1].Role != llm.RoleUser {
+		return ""
+	}
+	return c.history[len(c.history)-1].Content
+}
+
+// cacheScope returns the semantic cache scope of the conversation
+func (c *conversation) cacheScope() semcache.Scope {
+	return semcache.Scope{Goal: c.goal, PromptVersion: c.promptVersion, Persona: c.persona.Name}
+}
+
+// bypassesCache reports whether the conversation's answers may depend on more than its
+// cache scope: the context values it was started with, or its template's system prompt
+func (c *conversation) bypassesCache() bool {
+	return len(c.context) > 0 || c.template.SystemPrompt != ""
+}
+
+// cachedAnswer looks up a validated answer to a near-duplicate of the question. A failed
+// lookup is a miss, so the turn falls back to the model.
+func (c *conversation) cachedAnswer(ctx workflow.Context, question string) (string, bool) {
+	if question == "" || c.semanticCacheOff || c.bypassesCache() {
+		return "", false
+	}
+	var a *activities.Activities
+	var cached activities.CachedAnswer
+	err := workflow.ExecuteActivity(withRetries(ctx, retries.Internal), a.LookupCachedAnswer, activities.CachedAnswerRequest{
+		Scope:    c.cacheScope(),
+		Question: question,
+	}).Get(ctx, &cached)
+	if err != nil {
+		workflow.GetLogger(ctx).Error("Error looking up cached answer", "error", err)
+		return "", false
+	}
+	// The worker configuration decides once per conversation, like the tool list
+	c.semanticCacheOff = !cached.Enabled
+	if cached.Match == nil {
+		return "", false
+	}
+	return cached.Match.Answer, true
+}
+
+// cacheAnswer stores the model's answer to the question. Only answers given straight away,
+// without tool calls or the fallback message, are cached.
+func (c *conversation) cacheAnswer(ctx workflow.Context, question, answer string) {
+	if question == "" || c.semanticCacheOff || c.bypassesCache() {
+		return
+	}
+	var a *activities.Activities
+	err := workflow.ExecuteActivity(withRetries(ctx, retries.Internal), a.CacheAnswer, activities.CachedAnswerRequest{
+		Scope:    c.cacheScope(),
+		Question: question,
+		Answer:   answer,
+	}).Get(ctx, nil)
+	if err != nil {
+		workflow.GetLogger(ctx).Error("Error caching answer", "error", err)
+	}
+}
@@ -0,0 +1,85 @@
+package workflows
+
+import (
+	"sort"
+	"time"
+)
+
+// sequenceGapTimeout is how long buffered messages wait for a missing sequence number
+// before the gap is skipped
+const sequenceGapTimeout = 30 * time.Second
+
+// messageSequencer releases sequenced user messages in order, buffering early arrivals.
+// Sequence numbers start at 1; messages without one (0) are released immediately.
+type messageSequencer struct {
+	next    int
+	pending map[int]string
+	// waitingSince is when the oldest buffered message arrived
+	waitingSince time.Time
+}
+
+// accept records a message and returns the messages that are ready, in order. Messages
+// whose sequence number was already released are dropped.
+func (s *messageSequencer) accept(now time.Time, seq int, message string) []string {
+	if seq == 0 {
+		return []string{message}
+	}
+	if s.next == 0 {
+		s.next = 1
+	}
+	if seq < s.next {
+		return nil
+	}
+	if s.pending == nil {
+		s.pending = map[int]string{}
+	}
+	if _, ok := s.pending[seq]; ok {
+		return nil
+	}
+	if len(s.pending) == 0 {
+		s.waitingSince = now
+	}
+	s.pending[seq] = message
+	return s.release(now)
+}
+
+// skipGap gives up on the missing sequence numbers before the oldest buffered message
+// and returns the messages that become ready
+func (s *messageSequencer) skipGap(now time.Time) []string {
+	if len(s.pending) == 0 {
+		return nil
+	}
+	seqs := make([]int, 0, len(s.pending))
+	for seq := range s.pending {
+		seqs = append(seqs, seq)
+	}
+	sort.Ints(seqs)
+	s.next = seqs[0]
+	return s.release(now)
+}
+
+// release returns the consecutive buffered messages starting at next
+func (s *messageSequencer) release(now time.Time) []string {
+	var ready []string
+	for {
+		message, ok := s.pending[s.next]
+		if !ok {
+			break
+		}
+		ready = append(ready, message)
+		delete(s.pending, s.next)
+		s.next++
+	}
+	if len(ready) > 0 && len(s.pending) > 0 {
+		s.waitingSince = now
+	}
+	return ready
+}
+
+// gapDeadline reports when the current gap is skipped, if messages are buffered
+func (s *messageSequencer) gapDeadline() (time.Time, bool) {
+	if len(s.pending) == 0 {
+		return time.Time{}, false
+	}
+	return s.waitingSince.Add(sequenceGapTimeout), true
+}
@@ -0,0 +1,51 @@
+package workflows
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var sequenceStart = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestSequencerReordersMessages(t *testing.T) {
+	var s messageSequencer
+
+	require.Empty(t, s.accept(sequenceStart, 2, "second"), "early message is buffered")
+	require.Empty(t, s.accept(sequenceStart, 3, "third"))
+	require.Equal(t, []string{"first", "second", "third"}, s.accept(sequenceStart, 1, "first"))
+	_, waiting := s.gapDeadline()
+	require.False(t, waiting)
+
+	require.Empty(t, s.accept(sequenceStart, 2, "second again"), "released numbers are dropped")
+	require.Empty(t, s.accept(sequenceStart, 5, "fifth"))
+	require.Empty(t, s.accept(sequenceStart, 5, "fifth again"), "buffered duplicates are dropped")
+	require.Equal(t, []string{"unsequenced"}, s.accept(sequenceStart, 0, "unsequenced"))
+	require.Equal(t, []string{"fourth", "fifth"}, s.accept(sequenceStart, 4, "fourth"))
+}
+
+func TestSequencerSkipsGapAfterTimeout(t *testing.T) {
+	var s messageSequencer
+	require.Equal(t, []string{"first"}, s.accept(sequenceStart, 1, "first"))
+
+	require.Empty(t, s.accept(sequenceStart, 3, "third"))
+	later := sequenceStart.Add(10 * time.Second)
+	require.Empty(t, s.accept(later, 5, "fifth"))
+	deadline, waiting := s.gapDeadline()
+	require.True(t, waiting)
+	require.Equal(t, sequenceStart.Add(sequenceGapTimeout), deadline, "the oldest buffered message sets the deadline")
+
+	// Skipping gives up on 2 only; 4 is still awaited, from the time of the skip
+	skipped := deadline
+	require.Equal(t, []string{"third"}, s.skipGap(skipped))
+	deadline, waiting = s.gapDeadline()
+	require.True(t, waiting)
+	require.Equal(t, skipped.Add(sequenceGapTimeout), deadline)
+
+	require.Equal(t, []string{"fifth"}, s.skipGap(deadline))
+	_, waiting = s.gapDeadline()
+	require.False(t, waiting)
+	require.Empty(t, s.accept(deadline, 2, "second, too late"))
+	require.Empty(t, s.skipGap(deadline), "nothing buffered")
+}
@@ -0,0 +1,71 @@
+package workflows
+
+import (
+	"cmp"
+	"temporal-ai-agent/activities"
+	"temporal-ai-agent/activities/llm"
+	"temporal-ai-agent/fewshot"
+	"temporal-ai-agent/retries"
+	"temporal-ai-agent/shadow"
+	"temporal-ai-agent/workflowutil"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// shadowTurn has the shadow candidate answer the turn's first completion request, built
+// from the same history with the candidate's model and prompt, and records both answers.
+// resp is the conversation's own answer. The candidate runs alongside the rest of the turn
+// and is never waited for: its reply only ends up in the shadow store.
+func (c *conversation) shadowTurn(ctx workflow.Context, examples []fewshot.Example, resp llm.Response, latency time.Duration) {
+	index := c.lastUserIndex()
+	if c.shadow == nil || index < 0 {
+		return
+	}
+	variant := c.shadow.Variant
+	req, err := c.buildRequest(examples, cmp.Or(variant.Model, c.model), cmp.Or(variant.SystemPrompt, c.systemPrompt))
+	if err != nil {
+		workflow.GetLogger(ctx).Error("Error building shadow request", "error", err)
+		return
+	}
+
+	workflowID := workflow.GetInfo(ctx).WorkflowExecution.ID
+	turn := shadow.Turn{
+		ID:           shadow.ID(workflowID, index),
+		WorkflowID:   workflowID,
+		Goal:         c.goal,
+		Experiment:   c.shadow.Experiment,
+		Variant:      variant.Name,
+		MessageIndex: index,
+		UserMessage:  c.history[index].Content,
+		Model:        cmp.Or(resp.Model, c.model),
+		Reply:        resp.Content,
+		LatencyMs:    latency.Milliseconds(),
+		Tokens:       resp.Usage.Total(),
+		RepliedAt:    workflowutil.Now(ctx),
+	}
+	if resp.ToolCall != nil {
+		turn.Tool = resp.ToolCall.Name
+	}
+
+	var a *activities.Activities
+	ctx = withRetries(withLLM(ctx), retries.Internal)
+
+	c.shadowsInFlight++
+	workflow.Go(ctx, func(ctx workflow.Context) {
+		defer func() { c.shadowsInFlight-- }()
+		err := workflow.ExecuteActivity(ctx, a.ShadowTurn, activities.ShadowTurnRequest{
+			Turn:    turn,
+			Request: req,
+		}).Get(ctx, nil)
+		if err != nil {
+			workflow.GetLogger(ctx).Warn("Error recording shadow turn", "error", err)
+		}
+	})
+}
+
+// idle reports whether nothing runs on behalf of the conversation anymore: updates have
+// replied and shadow completions are recorded
+func (c *conversation) idle(ctx workflow.Context) bool {
+	return workflow.AllHandlersFinished(ctx) && c.shadowsInFlight == 0
+}
@@ -0,0 +1,61 @@
+package workflows
+
+import (
+	"temporal-ai-agent/activities"
+	"temporal-ai-agent/retries"
+	"temporal-ai-agent/store"
+	"temporal-ai-agent/workflowutil"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// saveConversation persists the conversation with the messages added since the last save,
+// so it outlives the namespace's history retention. Saving is best effort: after a
+// failure the next save catches up.
+func (c *conversation) saveConversation(ctx workflow.Context) {
+	info := workflow.GetInfo(ctx)
+	from := min(c.savedMessages, len(c.history))
+
+	var a *activities.Activities
+	ctx = withRetries(ctx, retries.Internal)
+	err := workflow.ExecuteActivity(ctx, a.SaveConversation, activities.SaveConversationRequest{
+		Conversation: store.Conversation{
+			WorkflowID:    info.WorkflowExecution.ID,
+			Title:         c.title,
+			Goal:          c.goal,
+			Persona:       c.persona.Name,
+			PromptVersion: c.promptVersion,
+			StartedAt:     info.WorkflowStartTime,
+			UpdatedAt:     workflowutil.Now(ctx),
+			EndedAt:       c.endedAt,
+		},
+		From:     from,
+		Messages: store.Messages(from, c.history[from:]),
+	}).Get(ctx, nil)
+	if err != nil {
+		workflow.GetLogger(ctx).Error("Error saving conversation", "error", err)
+		return
+	}
+	c.savedMessages = len(c.history)
+}
+
+// forgetSavedMessages marks the messages from the given history position on as not saved,
+// after that part of the history was replaced
+func (c *conversation) forgetSavedMessages(index int) {
+	c.savedMessages = min(c.savedMessages, index)
+}
+
+// saveFeedback persists the user's rating of the conversation
+func (c *conversation) saveFeedback(ctx workflow.Context, feedback Feedback) {
+	var a *activities.Activities
+	ctx = withRetries(ctx, retries.Internal)
+	err := workflow.ExecuteActivity(ctx, a.SaveFeedback, store.Feedback{
+		WorkflowID: workflow.GetInfo(ctx).WorkflowExecution.ID,
+		Satisfied:  feedback.Satisfied,
+		Comment:    feedback.Comment,
+		Time:       workflowutil.Now(ctx),
+	}).Get(ctx, nil)
+	if err != nil {
+		workflow.GetLogger(ctx).Error("Error saving feedback", "error", err)
+	}
+}
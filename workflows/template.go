@@ -0,0 +1,31 @@
+package workflows
+
+import (
+	"slices"
+	"temporal-ai-agent/activities"
+	"temporal-ai-agent/activities/llm"
+	"temporal-ai-agent/templates"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// resolveTemplate looks up the template the conversation is started from
+func (c *conversation) resolveTemplate(ctx workflow.Context, name string) error {
+	var a *activities.Activities
+	var t templates.Template
+	if err := workflow.ExecuteActivity(ctx, a.ResolveTemplate, name).Get(ctx, &t); err != nil {
+		return err
+	}
+	c.template = t
+	return nil
+}
+
+// greet opens the conversation with the template's greeting and quick replies, which stay
+// suggested until the user sends a message
+func (c *conversation) greet(ctx workflow.Context) string {
+	greeting := c.expandContext(c.template.Greeting)
+	c.history = append(c.history, llm.Message{Role: llm.RoleAssistant, Content: greeting})
+	c.quickReplies = slices.Clone(c.template.QuickReplies)
+	c.events.emit(ctx, Event{Type: EventMessage, Message: greeting, QuickReplies: c.quickReplies})
+	return greeting
+}
@@ -0,0 +1,393 @@
+package workflows
+
+import (
+	"errors"
+	"fmt"
+	"temporal-ai-agent/activities"
+	"temporal-ai-agent/activities/llm"
+	"temporal-ai-agent/analytics"
+	"temporal-ai-agent/tools"
+	"temporal-ai-agent/workflowutil"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// SignalConfirm is the signal answering a tool call that awaits confirmation
+const SignalConfirm = "confirm"
+
+// QueryPendingConfirmation describes the tool call awaiting confirmation, if any
+const QueryPendingConfirmation = "pending_confirmation"
+
+// Confirmation decisions
+const (
+	DecisionApprove = "approve"
+	DecisionDeny    = "deny"
+	DecisionModify  = "modify"
+	// DecisionExpired records calls given up because the user did not answer in time
+	DecisionExpired = "expired"
+)
+
+// maxToolSteps bounds the tool calls the agent can chain in a single turn
+const maxToolSteps = 5
+
+// Long tools get more time, and are considered lost when they stop heartbeating. Async
+// tools wait up to a day for the system finishing their calls.
+const (
+	longToolTimeout          = 30 * time.Minute
+	longToolHeartbeatTimeout = 30 * time.Second
+	asyncToolTimeout         = 24 * time.Hour
+)
+
+// ConfirmRequest is the payload of the confirm signal
+type ConfirmRequest struct {
+	// CallID names the tool call being answered; when set, the answer is rejected unless
+	// that call is the one awaiting confirmation
+	CallID   string `json:"call_id,omitempty"`
+	Decision string `json:"decision"`
+	// ModifiedArgs replace the matching arguments of the proposed call (modify only)
+	ModifiedArgs map[string]interface{} `json:"modified_args,omitempty"`
+	Reason       string                 `json:"reason,omitempty"`
+	// Phrase is the confirmation phrase typed by the user, required to approve or modify
+	// calls to tools whose risk calls for one
+	Phrase string `json:"phrase,omitempty"`
+}
+
+// Validate checks that the decision is known and that modifications carry arguments
+func (r ConfirmRequest) Validate() error {
+	switch r.Decision {
+	case DecisionApprove, DecisionDeny:
+		if len(r.ModifiedArgs) > 0 {
+			return fmt.Errorf("modified_args is only allowed with the %s decision", DecisionModify)
+		}
+	case DecisionModify:
+		if len(r.ModifiedArgs) == 0 {
+			return fmt.Errorf("modified_args is required with the %s decision", DecisionModify)
+		}
+	default:
+		return fmt.Errorf("decision must be %s, %s or %s", DecisionApprove, DecisionDeny, DecisionModify)
+	}
+	return nil
+}
+
+// Confirmation records how the user answered a tool call awaiting confirmation
+type Confirmation struct {
+	Decision string      `json:"decision"`
+	Tool     string      `json:"tool"`
+	Call     *tools.Call `json:"call,omitempty"`
+	Reason   string      `json:"reason,omitempty"`
+	Time     time.Time   `json:"time"`
+}
+
+// PendingConfirmation describes a tool call awaiting the user's answer, for approval UIs
+type PendingConfirmation struct {
+	CallID string `json:"call_id"`
+	Tool   string `json:"tool"`
+	// Summary is the human-readable description of the call
+	Summary string                 `json:"summary"`
+	Args    map[string]interface{} `json:"args"`
+	Risk    string                 `json:"risk"`
+	// Confirmation is the confirmation level of the tool, approve or phrase; Phrase is what
+	// the user must type to approve the call when it is phrase
+	Confirmation string    `json:"confirmation"`
+	Phrase       string    `json:"phrase,omitempty"`
+	RequestedAt  time.Time `json:"requested_at"`
+	// ExpiresAt is when the call is given up without an answer; nil when it waits indefinitely
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Accepts checks that an answer applies to the pending call: it names this call, if any,
+// and carries the confirmation phrase when approving or modifying a call that requires one
+func (p *PendingConfirmation) Accepts(req ConfirmRequest) error {
+	if req.CallID != "" && req.CallID != p.CallID {
+		return fmt.Errorf("tool call %s is not awaiting confirmation; %s is", req.CallID, p.CallID)
+	}
+	if req.Decision != DecisionDeny && p.Phrase != "" && !tools.MatchesPhrase(p.Phrase, req.Phrase) {
+		return fmt.Errorf("type %q to confirm this call", p.Phrase)
+	}
+	return nil
+}
+
+// pendingConfirmation describes the pending tool call, or returns nil when there is none
+func (c *conversation) pendingConfirmation() *PendingConfirmation {
+	if c.pendingTool == nil {
+		return nil
+	}
+	call := *c.pendingTool
+	// The tool list is fixed at start, so the definition is still there
+	def, _ := tools.Find(c.tools, call.Name)
+	pending := &PendingConfirmation{
+		CallID:       call.ID,
+		Tool:         call.Name,
+		Summary:      def.Summarize(c.contextDefaults(call.Args)),
+		Args:         call.Args,
+		Risk:         def.RiskLevel(),
+		Confirmation: def.ConfirmationLevel(),
+		RequestedAt:  c.pendingToolAt,
+	}
+	if pending.Confirmation == tools.ConfirmPhrase {
+		pending.Phrase = def.ConfirmationPhrase()
+	}
+	if deadline, ok := c.confirmationDeadline(); ok {
+		pending.ExpiresAt = &deadline
+	}
+	return pending
+}
+
+// confirmationDeadline returns when the pending tool call is given up, if it is pending and
+// the conversation has a confirmation timeout
+func (c *conversation) confirmationDeadline() (time.Time, bool) {
+	if c.pendingTool == nil || c.confirmTimeout <= 0 {
+		return time.Time{}, false
+	}
+	return c.pendingToolAt.Add(c.confirmTimeout), true
+}
+
+// loadTools resolves the tools the agent may call in this conversation. The list is fixed
+// at start, so policy changes only apply to new conversations.
+func (c *conversation) loadTools(ctx workflow.Context) error {
+	var a *activities.Activities
+	return workflow.ExecuteActivity(ctx, a.ListTools, c.goal).Get(ctx, &c.tools)
+}
+
+// handleToolCall records a tool call requested by the model. Calls their tool's risk makes
+// need confirmation are parked until the user answers, and rejected while another call is
+// parked; others run immediately. It reports whether the agent should continue the turn.
+func (c *conversation) handleToolCall(ctx workflow.Context, call tools.Call) (string, bool) {
+	if call.ID == "" {
+		call.ID = fmt.Sprintf("call-%d", len(c.history))
+	}
+	// Only tools allowed when the conversation started can run
+	def, ok := tools.Find(c.tools, call.Name)
+	if !ok {
+		c.history = append(c.history, llm.Message{Role: llm.RoleAssistant, ToolCall: &call})
+		c.addToolResult(call, fmt.Sprintf("Error: tool %q is not available in this conversation", call.Name))
+		return "", true
+	}
+
+	if level := def.ConfirmationLevel(); level != tools.ConfirmNone {
+		// One call awaits confirmation at a time, so an answer can never apply to the wrong one
+		if c.pendingTool != nil {
+			c.history = append(c.history, llm.Message{Role: llm.RoleAssistant, ToolCall: &call})
+			c.addToolResult(call, fmt.Sprintf("Error: %s is still awaiting the user's confirmation; "+
+				"propose %s once the user has answered it", c.pendingTool.Name, call.Name))
+			return "", true
+		}
+		prompt := fmt.Sprintf("I'd like to run %s with %s. Do you approve?", call.Name, formatArgs(call.Args))
+		if level == tools.ConfirmPhrase {
+			prompt = fmt.Sprintf("I'd like to run %s with %s. To approve, type %q.",
+				call.Name, formatArgs(call.Args), def.ConfirmationPhrase())
+		}
+		// Only the question joins the history for now. Providers require a call to be
+		// directly followed by its result, so the call is added with its result once the
+		// user answered, after any messages sent in the meantime.
+		c.history = append(c.history, llm.Message{Role: llm.RoleAssistant, Content: prompt})
+		c.pendingTool = &call
+		c.pendingToolAt = workflowutil.Now(ctx)
+		c.events.emit(ctx, Event{Type: EventAwaitingConfirmation, Tool: call.Name})
+		c.events.emit(ctx, Event{Type: EventMessage, Message: prompt})
+		return prompt, false
+	}
+
+	c.history = append(c.history, llm.Message{Role: llm.RoleAssistant, ToolCall: &call})
+
+	c.runTool(ctx, call)
+	return "", true
+}
+
+// runTool executes a tool call and records its result (or error) for the model
+func (c *conversation) runTool(ctx workflow.Context, call tools.Call) {
+	var a *activities.Activities
+	c.events.emit(ctx, Event{Type: EventToolStarted, Tool: call.Name})
+
+	ao := workflow.GetActivityOptions(ctx)
+	ao.StartToCloseTimeout = time.Minute
+	def, _ := tools.Find(c.tools, call.Name)
+	ao.RetryPolicy = retryPolicy(toolRetryCategory(def))
+	if def.Long {
+		ao.StartToCloseTimeout = longToolTimeout
+		ao.HeartbeatTimeout = longToolHeartbeatTimeout
+	}
+	if def.Async {
+		ao.StartToCloseTimeout = asyncToolTimeout
+	}
+	ctx = workflow.WithActivityOptions(ctx, ao)
+
+	req := activities.ExecuteToolRequest{
+		Call:     call,
+		Goal:     c.goal,
+		Context:  c.context,
+		Question: c.lastUserMessage(),
+		Tenant:   c.account,
+	}
+	var result string
+	var err error
+	if def.Paginated {
+		result, err = c.fetchPages(ctx, def, req)
+	} else {
+		err = workflow.ExecuteActivity(ctx, a.ExecuteTool, req).Get(ctx, &result)
+	}
+	if err != nil {
+		workflow.GetLogger(ctx).Error("Tool failed", "tool", call.Name, "error", err)
+		result = "Error: " + toolError(err)
+	}
+	c.track(ctx, analytics.EventToolUsed, map[string]interface{}{"tool": call.Name, "success": err == nil})
+	if err == nil && def.CompletesGoal {
+		c.track(ctx, analytics.EventGoalCompleted, map[string]interface{}{"tool": call.Name})
+	}
+
+	c.events.emit(ctx, Event{Type: EventToolFinished, Tool: call.Name})
+	c.addToolResult(call, result)
+}
+
+// fetchPages fetches the pages of a call to a paginated tool, one activity per page, up to
+// the tool's page limit, and fits their merged results to the tool's limits
+func (c *conversation) fetchPages(ctx workflow.Context, def tools.Definition, req activities.ExecuteToolRequest) (string, error) {
+	var a *activities.Activities
+	var pages []tools.Page
+	for len(pages) < def.PageLimit() {
+		var page tools.Page
+		if err := workflow.ExecuteActivity(ctx, a.ExecuteToolPage, req).Get(ctx, &page); err != nil {
+			return "", err
+		}
+		pages = append(pages, page)
+		if req.PageToken = page.NextPageToken; req.PageToken == "" {
+			break
+		}
+	}
+
+	// Fitting an oversized result summarizes it with the LLM
+	var result string
+	err := workflow.ExecuteActivity(withLLM(ctx), a.FitToolResult, activities.FitToolResultRequest{
+		Call:     req.Call,
+		Result:   tools.MergePages(pages),
+		Question: req.Question,
+		Tenant:   req.Tenant,
+	}).Get(ctx, &result)
+	return result, err
+}
+
+// addToolResult appends the result of a tool call to the history
+func (c *conversation) addToolResult(call tools.Call, result string) {
+	c.history = append(c.history, llm.Message{Role: llm.RoleTool, Content: result, ToolCallID: call.ID})
+}
+
+// handleConfirmation applies the user's decision to the pending tool call and lets the
+// agent continue from the outcome
+func (c *conversation) handleConfirmation(ctx workflow.Context, req ConfirmRequest) (string, error) {
+	ctx, err := c.lockTurn(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer c.turnLock.Unlock()
+
+	if err := req.Validate(); err != nil {
+		return "", err
+	}
+	pending := c.pendingConfirmation()
+	if pending == nil {
+		return "", fmt.Errorf("no tool call is awaiting confirmation")
+	}
+	if err := pending.Accepts(req); err != nil {
+		return "", err
+	}
+
+	call := *c.pendingTool
+	c.pendingTool = nil
+
+	if req.Decision == DecisionModify {
+		args := make(map[string]interface{}, len(call.Args)+len(req.ModifiedArgs))
+		for k, v := range call.Args {
+			args[k] = v
+		}
+		for k, v := range req.ModifiedArgs {
+			args[k] = v
+		}
+		call.Args = args
+	}
+	c.history = append(c.history, llm.Message{Role: llm.RoleAssistant, ToolCall: &call})
+	switch req.Decision {
+	case DecisionApprove, DecisionModify:
+		c.runTool(ctx, call)
+	case DecisionDeny:
+		result := "The user denied this tool call."
+		if req.Reason != "" {
+			result += " Reason: " + req.Reason
+		}
+		c.addToolResult(call, result)
+	}
+	c.confirmations = append(c.confirmations, Confirmation{
+		Decision: req.Decision,
+		Tool:     call.Name,
+		Call:     &call,
+		Reason:   req.Reason,
+		Time:     workflowutil.Now(ctx),
+	})
+
+	reply, err := c.respond(ctx)
+	c.deliverReply(ctx, reply, c.pendingTool != nil)
+	c.saveConversation(ctx)
+	return reply, err
+}
+
+// expireConfirmation gives up the pending tool call once its confirmation deadline passed
+// without an answer, and lets the agent tell the user the call did not run. It reports
+// whether a call expired: the user may have answered it in the meantime.
+func (c *conversation) expireConfirmation(ctx workflow.Context) (string, bool, error) {
+	ctx, err := c.lockTurn(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	defer c.turnLock.Unlock()
+
+	now := workflowutil.Now(ctx)
+	if deadline, ok := c.confirmationDeadline(); !ok || now.Before(deadline) {
+		return "", false, nil
+	}
+
+	call := *c.pendingTool
+	c.pendingTool = nil
+	workflow.GetLogger(ctx).Info("Tool call confirmation expired", "tool", call.Name, "call_id", call.ID)
+	c.history = append(c.history, llm.Message{Role: llm.RoleAssistant, ToolCall: &call})
+	c.addToolResult(call, fmt.Sprintf("The user did not answer within %s, so this tool call was not run. "+
+		"Propose it again if the user still wants it.", c.confirmTimeout))
+	c.confirmations = append(c.confirmations, Confirmation{
+		Decision: DecisionExpired,
+		Tool:     call.Name,
+		Call:     &call,
+		Time:     now,
+	})
+
+	reply, err := c.respond(ctx)
+	c.saveConversation(ctx)
+	return reply, true, err
+}
+
+// toolError returns the message of a tool failure without the activity error wrapping,
+// so the model sees what went wrong and can adapt
+func toolError(err error) string {
+	var appErr *temporal.ApplicationError
+	if errors.As(err, &appErr) {
+		return appErr.Error()
+	}
+	return err.Error()
+}
+
+// failureReason returns the message of an activity failure without its error type, for
+// reports read by people
+func failureReason(err error) string {
+	var appErr *temporal.ApplicationError
+	if errors.As(err, &appErr) {
+		return appErr.Message()
+	}
+	return err.Error()
+}
+
+// formatArgs renders tool arguments for the confirmation prompt
+func formatArgs(args map[string]interface{}) string {
+	if len(args) == 0 {
+		return "no arguments"
+	}
+	return fmt.Sprintf("%v", args)
+}
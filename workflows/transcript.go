@@ -0,0 +1,64 @@
+package workflows
+
+import (
+	"temporal-ai-agent/activities/llm"
+	"temporal-ai-agent/evals"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// QueryTranscript is the query returning the full conversation transcript for export
+const QueryTranscript = "transcript"
+
+// Transcript is everything needed to render or archive a conversation
+type Transcript struct {
+	WorkflowID string `json:"workflow_id"`
+	// ConversationID, UserID and Metadata are those the conversation was started with
+	ConversationID string            `json:"conversation_id,omitempty"`
+	UserID         string            `json:"user_id,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	Title          string            `json:"title,omitempty"`
+	PromptVersion  string            `json:"prompt_version,omitempty"`
+	Persona        string            `json:"persona,omitempty"`
+	// Template is the guided flow the conversation was started from
+	Template string `json:"template,omitempty"`
+	// Context holds the values the conversation was started with
+	Context       map[string]string `json:"context,omitempty"`
+	StartTime     time.Time         `json:"start_time"`
+	Messages      []llm.Message     `json:"messages"`
+	Branches      []Branch          `json:"branches,omitempty"`
+	Confirmations []Confirmation    `json:"confirmations,omitempty"`
+	// Annotations are the reactions, labels and operator notes attached to messages
+	Annotations []Annotation `json:"annotations,omitempty"`
+	// Receipts track the delivery and reading of the agent's messages on each channel
+	Receipts []Receipt `json:"receipts,omitempty"`
+	// Evaluation holds the judge's scores once the conversation has ended and been evaluated
+	Evaluation *evals.Scores `json:"evaluation,omitempty"`
+	// Grounding holds the verification of the answers written from the knowledge base
+	Grounding []GroundingCheck `json:"grounding,omitempty"`
+}
+
+// transcript builds the exportable transcript of the conversation
+func (c *conversation) transcript(ctx workflow.Context) Transcript {
+	info := workflow.GetInfo(ctx)
+	return Transcript{
+		WorkflowID:     info.WorkflowExecution.ID,
+		ConversationID: c.conversationID,
+		UserID:         c.userID,
+		Metadata:       c.metadata,
+		Title:          c.title,
+		PromptVersion:  c.promptVersion,
+		Persona:        c.persona.Name,
+		Template:       c.template.Name,
+		Context:        c.context,
+		StartTime:      info.WorkflowStartTime,
+		Messages:       c.history,
+		Branches:       c.branches,
+		Confirmations:  c.confirmations,
+		Annotations:    c.annotations,
+		Receipts:       c.receipts,
+		Evaluation:     c.evaluation,
+		Grounding:      c.groundingChecks,
+	}
+}
@@ -1,83 +1,863 @@
 package workflows
 
 import (
+	"cmp"
+	"fmt"
+	"strings"
 	"temporal-ai-agent/activities"
+	"temporal-ai-agent/activities/llm"
+	"temporal-ai-agent/analytics"
+	"temporal-ai-agent/deadletter"
+	"temporal-ai-agent/evals"
+	"temporal-ai-agent/experiments"
+	"temporal-ai-agent/fewshot"
+	"temporal-ai-agent/goals"
+	"temporal-ai-agent/knowledge"
+	"temporal-ai-agent/language"
+	"temporal-ai-agent/models"
+	"temporal-ai-agent/operator"
+	"temporal-ai-agent/personas"
+	"temporal-ai-agent/prompts"
+	"temporal-ai-agent/retries"
+	"temporal-ai-agent/search"
+	"temporal-ai-agent/templates"
+	"temporal-ai-agent/tokens"
+	"temporal-ai-agent/tools"
+	"temporal-ai-agent/workflowutil"
 	"time"
 
+	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
 )
 
-func SayHelloWorkflow(ctx workflow.Context, name string) (string, error) {
-	ao := workflow.ActivityOptions{
-		StartToCloseTimeout: time.Second * 10,
-	}
-	ctx = workflow.WithActivityOptions(ctx, ao)
+// Memo keys describing the conversation. The client app, channel, locale and account are
+// set by the API server on start; the workflow sets the others.
+const (
+	MemoTitle         = "title"
+	MemoPromptVersion = "prompt_version"
+	MemoGoal          = "goal"
+	MemoClientApp     = "client_app"
+	MemoChannel       = "channel"
+	MemoLocale        = "locale"
+	// MemoAccount is the quota account of the API key that started the conversation, the
+	// only one allowed to message or query it
+	MemoAccount = "account"
+)
+
+// UserIDKey records the user a conversation was started for, so per-user limits can count
+// their running conversations. It must exist as a Keyword attribute in the namespace.
+var UserIDKey = temporal.NewSearchAttributeKeyKeyword("AgentUserID")
+
+// AccountKey records the quota account that started a conversation, so it lists only its
+// own conversations. It must exist as a Keyword attribute in the namespace.
+var AccountKey = temporal.NewSearchAttributeKeyKeyword("AgentAccount")
+
+// titleAfterTurns is the number of user turns after which a title is generated
+const titleAfterTurns = 2
+
+// activityOptions are the default options of the conversation's activities, which are
+// retried with the internal policy unless they belong to another category
+var activityOptions = workflow.ActivityOptions{
+	StartToCloseTimeout: time.Second * 10,
+}
+
+// llmActivityTimeout bounds the activities calling an LLM provider. It outlasts the
+// providers' HTTP clients (2 minutes for OpenAI, 5 for Ollama) so a slow completion fails
+// with the client's error, while llmHeartbeatTimeout notices a lost worker much sooner.
+const (
+	llmActivityTimeout  = 6 * time.Minute
+	llmHeartbeatTimeout = 30 * time.Second
+)
+
+// withLLM applies the timeouts and the retry policy of activities calling an LLM provider
+func withLLM(ctx workflow.Context) workflow.Context {
+	ao := workflow.GetActivityOptions(ctx)
+	ao.StartToCloseTimeout = llmActivityTimeout
+	ao.HeartbeatTimeout = llmHeartbeatTimeout
+	ao.RetryPolicy = retryPolicy(retries.LLM)
+	return workflow.WithActivityOptions(ctx, ao)
+}
+
+// ConversationInput is the input of AgentGoalWorkflow: the configuration the conversation
+// was started with, carried over when it continues as new along with its state
+type ConversationInput struct {
+	models.AgentInput
+	// State is set by the conversation itself when it continues as new, to resume from
+	State *ConversationState `json:"state,omitempty"`
+}
+
+// AgentGoalWorkflow runs a conversation with the agent. Each user message starts a turn in
+// which the LLM reasons over the history and either answers or calls a tool, whose result
+// is fed back until it answers or a call needs the user's confirmation. The conversation
+// runs until the user ends the chat; the input's message is its first user message, if any.
+func AgentGoalWorkflow(ctx workflow.Context, input ConversationInput) (string, error) {
+	ctx = withRetries(workflow.WithActivityOptions(ctx, activityOptions), retries.Internal)
 
 	// Set up signal channels
-	userPromptChan := workflow.GetSignalChannel(ctx, "user_prompt")
-	confirmChan := workflow.GetSignalChannel(ctx, "confirm")
+	userPromptChan := workflow.GetSignalChannel(ctx, SignalUserPrompt)
+	confirmChan := workflow.GetSignalChannel(ctx, SignalConfirm)
 	endChatChan := workflow.GetSignalChannel(ctx, "end_chat")
+	taskReportChan := workflow.GetSignalChannel(ctx, signalTaskReport)
+	feedbackChan := workflow.GetSignalChannel(ctx, SignalFeedback)
+	receiptChan := workflow.GetSignalChannel(ctx, SignalReceipt)
 
-	// Initial greeting
-	var result string
-	err := workflow.ExecuteActivity(ctx, activities.Greet, name).Get(ctx, &result)
+	conv, err := newConversation(ctx)
 	if err != nil {
 		return "", err
 	}
+	conv.userID = input.UserID
+	conv.conversationID = cmp.Or(input.ConversationID, workflow.GetInfo(ctx).WorkflowExecution.ID)
+	conv.metadata = input.Metadata
+	conv.fallbackMessage = input.FallbackMessage
+	conv.account = input.Account
+	conv.events.publish = input.StreamEvents
+	conv.context = input.Context
+	conv.suggestQuickReplies = input.SuggestReplies
+	conv.latencyBudget = input.LatencyBudget
+	conv.fallbackModel = input.FallbackModel
+	conv.confirmTimeout = input.ConfirmTimeout
+	conv.idleTimeout = input.IdleTimeout
+	conv.renotifyAfter = input.RenotifyAfter
+	conv.lastActivityAt = workflowutil.Now(ctx)
+
+	// A conversation continued as new resumes where its previous run stopped
+	var result string
+	if input.State != nil {
+		result = conv.restore(input.State)
+	} else {
+		if err := conv.setUp(ctx, input.AgentInput); err != nil {
+			return "", err
+		}
+		// A template's greeting opens the conversation, before the first message is answered
+		if conv.template.Name != "" {
+			result = conv.greet(ctx)
+		}
+	}
+	conv.turnLock.Unlock()
+	if input.Message != "" {
+		result = conv.processPrompts(ctx, []string{input.Message}, nil, false, result)
+	}
 
 	// Wait for signals in a loop
-	for {
+	ended := false
+	for !ended {
+		// Long conversations continue as new with their state, once nothing is in flight
+		if conv.historyTooLong(ctx) {
+			ready, err := conv.readyToContinue(ctx, userPromptChan, confirmChan, endChatChan, taskReportChan, feedbackChan, receiptChan)
+			if err != nil {
+				return "", err
+			}
+			if ready {
+				workflow.GetLogger(ctx).Info("Continuing the conversation as new",
+					"history_events", workflow.GetInfo(ctx).GetCurrentHistoryLength(), "messages", len(conv.history))
+				input.Message = ""
+				input.State = conv.state(result)
+				return "", workflow.NewContinueAsNewError(ctx, AgentGoalWorkflow, input)
+			}
+		}
+
 		selector := workflow.NewSelector(ctx)
-		
+
 		// Add signal channels to selector
+		// Messages sent while a turn is in flight stay buffered in the channel and are
+		// handled in order afterwards, one per turn unless coalescing is enabled
 		selector.AddReceive(userPromptChan, func(c workflow.ReceiveChannel, more bool) {
-			var userMessage string
-			c.Receive(ctx, &userMessage)
-			workflow.GetLogger(ctx).Info("Received user_prompt signal", "message", userMessage)
-			
-			// Process user prompt
-			var promptResult string
-			err := workflow.ExecuteActivity(ctx, activities.Greet, userMessage).Get(ctx, &promptResult)
-			if err != nil {
-				workflow.GetLogger(ctx).Error("Error processing user prompt", "error", err)
-			} else {
-				result = promptResult
+			var prompt UserPrompt
+			c.Receive(ctx, &prompt)
+			workflow.GetLogger(ctx).Info("Received user_prompt signal", "message", prompt.Message, "message_id", prompt.MessageID, "seq", prompt.Seq)
+
+			messages := conv.acceptPrompt(ctx, prompt)
+			if input.CoalesceMessages {
+				for c.ReceiveAsync(&prompt) {
+					messages = append(messages, conv.acceptPrompt(ctx, prompt)...)
+				}
 			}
+			result = conv.processPrompts(ctx, messages, prompt.From, input.CoalesceMessages, result)
 		})
-		
+
+		// Stop waiting for a missing sequence number after a while
+		timerCtx, cancelTimer := workflow.WithCancel(ctx)
+		if deadline, ok := conv.sequencer.gapDeadline(); ok {
+			timer := workflow.NewTimer(timerCtx, max(deadline.Sub(workflowutil.Now(ctx)), 0))
+			selector.AddFuture(timer, func(f workflow.Future) {
+				if f.Get(ctx, nil) != nil {
+					return
+				}
+				workflow.GetLogger(ctx).Warn("Skipping missing user_prompt sequence numbers")
+				messages := conv.sequencer.skipGap(workflowutil.Now(ctx))
+				result = conv.processPrompts(ctx, messages, conv.replyLink(), input.CoalesceMessages, result)
+			})
+		}
+
+		// Give up a tool call left unconfirmed past the confirmation timeout
+		if deadline, ok := conv.confirmationDeadline(); ok {
+			timer := workflow.NewTimer(timerCtx, max(deadline.Sub(workflowutil.Now(ctx)), 0))
+			selector.AddFuture(timer, func(f workflow.Future) {
+				if f.Get(ctx, nil) != nil {
+					return
+				}
+				reply, expired, err := conv.expireConfirmation(ctx)
+				if err != nil {
+					workflow.GetLogger(ctx).Error("Error expiring confirmation", "error", err)
+				} else if expired {
+					result = reply
+				}
+			})
+		}
+
+		// Close the conversation once it stayed inactive for its idle timeout
+		if deadline, ok := conv.idleDeadline(); ok {
+			timer := workflow.NewTimer(timerCtx, max(deadline.Sub(workflowutil.Now(ctx)), 0))
+			selector.AddFuture(timer, func(f workflow.Future) {
+				if f.Get(ctx, nil) != nil {
+					return
+				}
+				if message, closed := conv.closeIdle(ctx); closed {
+					result = message
+					ended = true
+				}
+			})
+		}
+
+		// Remind the user of important messages they left unread
+		if deadline, ok := conv.renotifyDeadline(); ok {
+			timer := workflow.NewTimer(timerCtx, max(deadline.Sub(workflowutil.Now(ctx)), 0))
+			selector.AddFuture(timer, func(f workflow.Future) {
+				if f.Get(ctx, nil) != nil {
+					return
+				}
+				conv.renotify(ctx)
+			})
+		}
+
 		selector.AddReceive(confirmChan, func(c workflow.ReceiveChannel, more bool) {
-			var confirmMessage string
-			c.Receive(ctx, &confirmMessage)
-			workflow.GetLogger(ctx).Info("Received confirm signal", "message", confirmMessage)
-			
+			var req ConfirmRequest
+			c.Receive(ctx, &req)
+			workflow.GetLogger(ctx).Info("Received confirm signal", "decision", req.Decision)
+
 			// Process confirmation
-			var confirmResult string
-			err := workflow.ExecuteActivity(ctx, activities.Greet, "Confirmed: "+confirmMessage).Get(ctx, &confirmResult)
+			reply, err := conv.handleConfirmation(ctx, req)
 			if err != nil {
 				workflow.GetLogger(ctx).Error("Error processing confirmation", "error", err)
 			} else {
-				result = confirmResult
+				result = reply
 			}
 		})
-		
+
 		selector.AddReceive(endChatChan, func(c workflow.ReceiveChannel, more bool) {
 			var endMessage string
 			c.Receive(ctx, &endMessage)
 			workflow.GetLogger(ctx).Info("Received end_chat signal", "message", endMessage)
-			
+
 			// End the workflow
 			result = "Chat ended: " + endMessage
-			return
+			ended = true
+		})
+
+		selector.AddReceive(taskReportChan, func(c workflow.ReceiveChannel, more bool) {
+			var report taskReport
+			c.Receive(ctx, &report)
+			conv.recordTaskReport(report)
+		})
+
+		selector.AddReceive(feedbackChan, func(c workflow.ReceiveChannel, more bool) {
+			var feedback Feedback
+			c.Receive(ctx, &feedback)
+			conv.handleFeedback(ctx, feedback)
+		})
+
+		selector.AddReceive(receiptChan, func(c workflow.ReceiveChannel, more bool) {
+			var ack ReceiptAck
+			c.Receive(ctx, &ack)
+			conv.acknowledge(ctx, ack)
 		})
-		
+
 		// Wait for any signal
 		selector.Select(ctx)
-		
-		// Check if we should end the workflow
-		if endChatChan.ReceiveAsync(&result) {
-			break
-		}
+		cancelTimer()
+	}
+
+	// Let in-flight updates reply, and shadow completions be recorded, before the workflow completes
+	if err := workflow.Await(ctx, func() bool { return conv.idle(ctx) }); err != nil {
+		return "", err
 	}
+	endedAt := workflowutil.Now(ctx)
+	conv.endedAt = &endedAt
+	conv.events.emit(ctx, Event{Type: EventEnded, Message: result})
+	conv.saveConversation(ctx)
+	conv.evaluate(ctx)
 
 	return result, nil
 }
+
+// setUp resolves the template, goal, prompt version, experiment variant, persona and tools
+// of a new conversation
+func (c *conversation) setUp(ctx workflow.Context, input models.AgentInput) error {
+	if input.Template != "" {
+		if err := c.resolveTemplate(ctx, input.Template); err != nil {
+			return err
+		}
+	}
+	if goal := cmp.Or(input.Goal, c.template.Goal); goal != "" {
+		c.goal = goal
+	}
+
+	if err := c.resolveGoal(ctx); err != nil {
+		return err
+	}
+	if err := c.pinPromptVersion(ctx); err != nil {
+		return err
+	}
+	c.model = input.Model
+	c.systemPrompt = input.SystemPrompt
+	c.temperature = input.Temperature
+	c.maxTokens = input.MaxTokens
+	if err := c.assignVariant(ctx); err != nil {
+		return err
+	}
+	if err := c.resolvePersona(ctx, cmp.Or(input.Persona, c.template.Persona)); err != nil {
+		return err
+	}
+	return c.loadTools(ctx)
+}
+
+// conversation holds the chat state shared by the signal, query and update handlers
+type conversation struct {
+	// goal is the use case of the conversation; goalPrompt is added to its system prompt
+	goal       string
+	goalPrompt string
+	// promptVersion is the prompt template version pinned at start
+	promptVersion string
+	// model and systemPrompt override the deployment defaults when set
+	model        string
+	systemPrompt string
+	// temperature and maxTokens tune every completion of the conversation when set
+	temperature *float64
+	maxTokens   int
+	// userID, conversationID and metadata identify the conversation for clients; they are
+	// kept with its transcript
+	userID         string
+	conversationID string
+	metadata       map[string]string
+	// shadow is the candidate of the shadow experiment answering the turns alongside the
+	// conversation; shadowsInFlight counts its completions still running
+	shadow          *experiments.Assignment
+	shadowsInFlight int
+	// template is the guided flow the conversation was started from, if any; its system
+	// prompt replaces the base one
+	template templates.Template
+	// quickReplies are suggested to the user for their next message, generated after each
+	// answer when suggestQuickReplies is set
+	quickReplies        []string
+	suggestQuickReplies bool
+	persona             personas.Persona
+	// language is the language detected in the latest user message that had a clear one
+	language string
+	// context holds the values the conversation was started with, passed to tools and
+	// filled into the prompt
+	context map[string]string
+
+	history       []llm.Message
+	branches      []Branch
+	confirmations []Confirmation
+	// annotations are the reactions, labels and notes attached to messages of the history
+	annotations []Annotation
+	// receipts track the delivery and reading of the agent's messages on each channel;
+	// important ones left unread for renotifyAfter, when set, are sent again
+	receipts      []Receipt
+	renotifyAfter time.Duration
+	// tools are the tools the agent may call; pendingTool awaits the user's confirmation
+	// since pendingToolAt, for up to confirmTimeout when set
+	tools          []tools.Definition
+	pendingTool    *tools.Call
+	pendingToolAt  time.Time
+	confirmTimeout time.Duration
+	// idleTimeout closes the conversation once nothing happened since lastActivityAt (or
+	// the last event) for that long
+	idleTimeout    time.Duration
+	lastActivityAt time.Time
+	// channels are where the conversation is continued besides the API's own clients;
+	// replyChannel is the one the latest user message came from, empty for the API's
+	channels     []ChannelLink
+	replyChannel string
+	// backgroundTasks are the detached child workflows started from this conversation
+	backgroundTasks []BackgroundTaskRef
+	// handoff is set while a human operator answers instead of the agent
+	handoff *Handoff
+	// fallbackMessage replies to turns the LLM could not answer, which are kept in degradedTurns
+	fallbackMessage string
+	degradedTurns   []DegradedTurn
+	// turnFailed is set while the latest turn ended with an error, leaving the user without
+	// a reply; reprocessing resumes it
+	turnFailed bool
+	// latencyBudget bounds the completions of a turn, after which fallbackModel answers;
+	// downgrades are the completions it answered
+	latencyBudget time.Duration
+	fallbackModel string
+	downgrades    []Downgrade
+	// account is the quota account; unrecordedTokens were used but not yet counted against it
+	account          string
+	unrecordedTokens int
+	// evaluation holds the judge's scores once the conversation has ended
+	evaluation *evals.Scores
+	// savedMessages is the number of history messages persisted to the store; endedAt is
+	// set once the conversation has ended
+	savedMessages int
+	endedAt       *time.Time
+	// notifications counts the operator notifications, numbering their dedup keys
+	notifications int
+	// semanticCacheOff is set once the worker reports that the goal does not use the semantic cache
+	semanticCacheOff bool
+	// groundingChecks verify the answers written from retrieved passages; groundingOff is
+	// set once the worker reports that verification is disabled
+	groundingChecks []GroundingCheck
+	groundingOff    bool
+	// analytics are the analytics events not sent yet; analyticsSeq numbers them
+	analytics    []analytics.Event
+	analyticsSeq int
+	// messageIDs are the client message IDs already processed
+	messageIDs messageIDSet
+	// sequencer orders user_prompt signals that carry sequence numbers
+	sequencer messageSequencer
+	title     string
+	userTurns int
+	events    *eventLog
+	// runStartMessages is the number of history messages when the current run started
+	runStartMessages int
+	// turnLock serializes turns so update handlers never interleave with a turn in flight
+	turnLock workflow.Mutex
+}
+
+// newConversation creates the conversation state and registers its handlers
+func newConversation(ctx workflow.Context) (*conversation, error) {
+	events, err := newEventLog(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conv := &conversation{goal: goals.Default, events: events, turnLock: workflow.NewMutex(ctx)}
+	// Updates delivered with the start, such as a first message sent with update-with-start,
+	// wait for the workflow to set the conversation up and unlock the turn
+	conv.turnLock.TryLock(ctx)
+
+	if err := workflow.SetQueryHandler(ctx, QueryHistory, func() ([]llm.Message, error) {
+		return conv.history, nil
+	}); err != nil {
+		return nil, err
+	}
+	if err := workflow.SetQueryHandler(ctx, QueryContext, func() (map[string]string, error) {
+		return conv.context, nil
+	}); err != nil {
+		return nil, err
+	}
+	if err := workflow.SetQueryHandler(ctx, QueryQuickReplies, func() ([]string, error) {
+		return conv.quickReplies, nil
+	}); err != nil {
+		return nil, err
+	}
+	if err := workflow.SetQueryHandler(ctx, QueryTranscript, func() (Transcript, error) {
+		return conv.transcript(ctx), nil
+	}); err != nil {
+		return nil, err
+	}
+	if err := workflow.SetUpdateHandlerWithOptions(ctx, UpdateUserPrompt, conv.userPromptUpdate, workflow.UpdateHandlerOptions{
+		Validator: conv.validateUserPrompt,
+	}); err != nil {
+		return nil, err
+	}
+	if err := workflow.SetQueryHandler(ctx, QueryBackgroundTasks, func() ([]BackgroundTaskRef, error) {
+		return conv.backgroundTasks, nil
+	}); err != nil {
+		return nil, err
+	}
+	if err := workflow.SetUpdateHandlerWithOptions(ctx, UpdateStartBackgroundTask, conv.startBackgroundTask, workflow.UpdateHandlerOptions{
+		Validator: conv.validateBackgroundTask,
+	}); err != nil {
+		return nil, err
+	}
+	if err := workflow.SetUpdateHandlerWithOptions(ctx, UpdateEditMessage, conv.editMessage, workflow.UpdateHandlerOptions{
+		Validator: conv.validateEdit,
+	}); err != nil {
+		return nil, err
+	}
+	if err := workflow.SetQueryHandler(ctx, QueryAnnotations, func() ([]Annotation, error) {
+		return conv.annotations, nil
+	}); err != nil {
+		return nil, err
+	}
+	if err := workflow.SetUpdateHandlerWithOptions(ctx, UpdateAnnotateMessage, conv.annotateMessage, workflow.UpdateHandlerOptions{
+		Validator: conv.validateAnnotation,
+	}); err != nil {
+		return nil, err
+	}
+	if err := workflow.SetQueryHandler(ctx, QueryReceipts, func() ([]Receipt, error) {
+		return conv.receipts, nil
+	}); err != nil {
+		return nil, err
+	}
+	if err := workflow.SetQueryHandler(ctx, QueryHandoff, func() (*Handoff, error) {
+		return conv.handoff, nil
+	}); err != nil {
+		return nil, err
+	}
+	if err := workflow.SetUpdateHandlerWithOptions(ctx, UpdateRequestHandoff, conv.requestHandoff, workflow.UpdateHandlerOptions{
+		Validator: conv.validateRequestHandoff,
+	}); err != nil {
+		return nil, err
+	}
+	if err := workflow.SetUpdateHandlerWithOptions(ctx, UpdateClaimHandoff, conv.claimHandoff, workflow.UpdateHandlerOptions{
+		Validator: conv.validateClaimHandoff,
+	}); err != nil {
+		return nil, err
+	}
+	if err := workflow.SetUpdateHandlerWithOptions(ctx, UpdateOperatorMessage, conv.operatorMessage, workflow.UpdateHandlerOptions{
+		Validator: conv.validateOperatorMessage,
+	}); err != nil {
+		return nil, err
+	}
+	if err := workflow.SetUpdateHandlerWithOptions(ctx, UpdateReturnToAgent, conv.returnToAgent, workflow.UpdateHandlerOptions{
+		Validator: conv.validateReturnToAgent,
+	}); err != nil {
+		return nil, err
+	}
+	if err := workflow.SetQueryHandler(ctx, QueryDegradedTurns, func() ([]DegradedTurn, error) {
+		return conv.degradedTurns, nil
+	}); err != nil {
+		return nil, err
+	}
+	if err := workflow.SetQueryHandler(ctx, QueryDowngrades, func() ([]Downgrade, error) {
+		return conv.downgrades, nil
+	}); err != nil {
+		return nil, err
+	}
+	if err := workflow.SetQueryHandler(ctx, QueryPendingConfirmation, func() (*PendingConfirmation, error) {
+		return conv.pendingConfirmation(), nil
+	}); err != nil {
+		return nil, err
+	}
+	if err := workflow.SetQueryHandler(ctx, QueryGroundingChecks, func() ([]GroundingCheck, error) {
+		return conv.groundingChecks, nil
+	}); err != nil {
+		return nil, err
+	}
+	if err := workflow.SetUpdateHandlerWithOptions(ctx, UpdateReprocessTurn, conv.reprocessTurn, workflow.UpdateHandlerOptions{
+		Validator: conv.validateReprocessTurn,
+	}); err != nil {
+		return nil, err
+	}
+	return conv, nil
+}
+
+// lockTurn waits for the turn in flight to finish before handling a message or update.
+// Update handlers run on the root workflow context, so the returned context carries the
+// conversation's activity options.
+func (c *conversation) lockTurn(ctx workflow.Context) (workflow.Context, error) {
+	if err := c.turnLock.Lock(ctx); err != nil {
+		return nil, err
+	}
+	c.lastActivityAt = workflowutil.Now(ctx)
+	return workflow.WithActivityOptions(ctx, activityOptions), nil
+}
+
+// handleUserPrompt records one or more user messages sent from the given channel and
+// generates a single assistant reply. During a handoff the messages are forwarded to the
+// operator and the reply is left empty.
+func (c *conversation) handleUserPrompt(ctx workflow.Context, from *ChannelLink, messages ...string) (TurnResult, error) {
+	ctx, err := c.lockTurn(ctx)
+	if err != nil {
+		return TurnResult{}, err
+	}
+	defer c.turnLock.Unlock()
+	// Linked under the lock, so the reply of a turn in flight still goes to its own channel
+	c.linkChannel(ctx, from)
+
+	turn := TurnResult{EventsAfter: c.events.lastSeq()}
+	downgrades := len(c.downgrades)
+	c.quickReplies = nil
+	for _, message := range messages {
+		c.history = append(c.history, llm.Message{Role: llm.RoleUser, Content: message})
+		c.detectLanguage(message)
+	}
+
+	if c.handoff != nil {
+		for _, message := range messages {
+			c.notifyOperator(ctx, operator.Notification{Kind: operator.KindUserMessage, Message: message})
+		}
+	} else {
+		turn.Reply, err = c.respond(ctx)
+		turn.Downgraded = len(c.downgrades) > downgrades
+	}
+	// No suggestions while a tool call awaits confirmation: the user answers it instead
+	if c.suggestQuickReplies && err == nil && turn.Reply != "" && c.pendingTool == nil {
+		turn.QuickReplies = c.suggestReplies(ctx)
+	}
+	c.deliverReply(ctx, turn.Reply, c.pendingTool != nil)
+
+	c.userTurns++
+	if c.title == "" && c.userTurns >= titleAfterTurns {
+		c.title = c.generateTitle(ctx)
+	}
+	c.indexTranscript(ctx)
+	c.saveConversation(ctx)
+	c.recordUsage(ctx, len(messages))
+	return turn, err
+}
+
+// respond asks the LLM for a reply to the current history and appends it. Tool calls the
+// model requests are run and fed back until it replies or a call needs confirmation.
+func (c *conversation) respond(ctx workflow.Context) (reply string, err error) {
+	var a *activities.Activities
+	started, degraded := workflowutil.Now(ctx), len(c.degradedTurns)
+	defer func() {
+		c.completeTurn(ctx, started, len(c.degradedTurns) > degraded, err)
+		c.turnFailed = err != nil && !temporal.IsCanceledError(err)
+		if c.turnFailed {
+			c.deadLetter(ctx, deadletter.KindFailed, err)
+		}
+	}()
+
+	c.events.emit(ctx, Event{Type: EventThinking})
+
+	question := c.openQuestion()
+	if answer, ok := c.cachedAnswer(ctx, question); ok {
+		c.history = append(c.history, llm.Message{Role: llm.RoleAssistant, Content: answer})
+		c.events.emit(ctx, Event{Type: EventMessage, Message: answer})
+		return answer, nil
+	}
+
+	var examples []fewshot.Example
+	err = workflow.ExecuteActivity(withLLM(ctx), a.SelectExamples, activities.SelectExamplesRequest{
+		Goal:  c.goal,
+		Query: c.lastUserMessage(),
+	}).Get(ctx, &examples)
+	if err != nil {
+		return "", err
+	}
+
+	for step := 0; step < maxToolSteps; step++ {
+		req, err := c.request(examples)
+		if err != nil {
+			return "", err
+		}
+
+		completionStarted := workflowutil.Now(ctx)
+		resp, err := c.complete(ctx, req, started)
+		if err != nil {
+			if temporal.IsCanceledError(err) {
+				return "", err
+			}
+			return c.fallback(ctx, err), nil
+		}
+		c.unrecordedTokens += resp.Usage.Total()
+		if step == 0 {
+			c.shadowTurn(ctx, examples, resp, workflowutil.Now(ctx).Sub(completionStarted))
+		}
+
+		if resp.ToolCall != nil {
+			reply, proceed := c.handleToolCall(ctx, *resp.ToolCall)
+			if !proceed {
+				return reply, nil
+			}
+			continue
+		}
+
+		content := c.groundAnswer(ctx, req, resp.Content)
+		c.history = append(c.history, llm.Message{Role: llm.RoleAssistant, Content: content})
+		c.events.emit(ctx, Event{Type: EventMessage, Message: content})
+		if step == 0 {
+			c.cacheAnswer(ctx, question, content)
+		}
+		return content, nil
+	}
+	return "", fmt.Errorf("agent exceeded %d tool calls in one turn", maxToolSteps)
+}
+
+// detectLanguage updates the conversation language from a user message.
+// Short or ambiguous messages ("ok", "42") keep the previously detected language.
+func (c *conversation) detectLanguage(message string) {
+	if detected := language.Detect(message); detected != "" {
+		c.language = detected
+	}
+}
+
+// lastUserMessage returns the most recent user message in the history
+func (c *conversation) lastUserMessage() string {
+	if i := c.lastUserIndex(); i >= 0 {
+		return c.history[i].Content
+	}
+	return ""
+}
+
+// lastUserIndex returns the position of the most recent user message, or -1
+func (c *conversation) lastUserIndex() int {
+	for i := len(c.history) - 1; i >= 0; i-- {
+		if c.history[i].Role == llm.RoleUser {
+			return i
+		}
+	}
+	return -1
+}
+
+// request builds the completion request for the current history, placing the
+// few-shot examples between the system prompt and the conversation. Messages are tagged
+// with their section of the prompt, which the context budget is allocated across.
+func (c *conversation) request(examples []fewshot.Example) (llm.Request, error) {
+	return c.buildRequest(examples, c.model, c.systemPrompt)
+}
+
+// buildRequest builds the completion request of the current history for a model and base
+// system prompt, empty for the deployment defaults
+func (c *conversation) buildRequest(examples []fewshot.Example, model, systemPrompt string) (llm.Request, error) {
+	system := cmp.Or(c.template.SystemPrompt, systemPrompt)
+	if system == "" {
+		template, err := prompts.Get(c.promptVersion)
+		if err != nil {
+			return llm.Request{}, err
+		}
+		system = template.System
+	}
+	if c.goalPrompt != "" {
+		system += "\n\n" + c.goalPrompt
+	}
+	if instructions := c.persona.Instructions(); instructions != "" {
+		system += "\n\n" + instructions
+	}
+	system = c.expandContext(system)
+	if values := c.contextPrompt(); values != "" {
+		system += "\n\n" + values
+	}
+	if tasks := c.backgroundTasksPrompt(); tasks != "" {
+		system += "\n\n" + tasks
+	}
+	// A persona with a fixed language wins over the user's language
+	if c.persona.Language == "" && c.language != "" && c.language != language.English {
+		system += fmt.Sprintf("\n\nThe user writes in %s. Reply in %s.", language.Name(c.language), language.Name(c.language))
+	}
+
+	messages := []llm.Message{{Role: llm.RoleSystem, Content: system, Section: tokens.SectionSystem}}
+	for _, e := range examples {
+		messages = append(messages,
+			llm.Message{Role: llm.RoleUser, Content: e.User, Section: tokens.SectionMemories},
+			llm.Message{Role: llm.RoleAssistant, Content: e.Assistant, Section: tokens.SectionMemories},
+		)
+	}
+	// Retrieved passages are budgeted apart from the rest of the history
+	retrievals := map[string]bool{}
+	for _, m := range c.history {
+		if m.ToolCall != nil && m.ToolCall.Name == knowledge.ToolName {
+			retrievals[m.ToolCall.ID] = true
+		}
+		if m.Role == llm.RoleTool && retrievals[m.ToolCallID] {
+			m.Section = tokens.SectionRetrieved
+		}
+		messages = append(messages, m)
+	}
+	return llm.Request{
+		Model:       model,
+		Messages:    messages,
+		Tools:       c.tools,
+		Goal:        c.goal,
+		Tenant:      c.account,
+		Temperature: c.temperature,
+		MaxTokens:   c.maxTokens,
+	}, nil
+}
+
+// pinPromptVersion records the current prompt template version so the conversation keeps
+// using it even if a newer version is deployed while it is running. The memo also gets
+// the goal, so dashboards can group conversations without querying them.
+func (c *conversation) pinPromptVersion(ctx workflow.Context) error {
+	if c.promptVersion == "" {
+		version, err := workflowutil.Record(ctx, func() string { return prompts.CurrentVersion })
+		if err != nil {
+			return err
+		}
+		c.promptVersion = version
+	}
+	return workflow.UpsertMemo(ctx, map[string]interface{}{
+		MemoPromptVersion: c.promptVersion,
+		MemoGoal:          c.goal,
+	})
+}
+
+// assignVariant enrolls the conversation in the active experiment, if any, and records
+// the variant in search attributes so outcomes can be compared per variant
+func (c *conversation) assignVariant(ctx workflow.Context) error {
+	var a *activities.Activities
+	var assignment experiments.Assignment
+	workflowID := workflow.GetInfo(ctx).WorkflowExecution.ID
+	if err := workflow.ExecuteActivity(ctx, a.AssignVariant, workflowID).Get(ctx, &assignment); err != nil {
+		return err
+	}
+	c.shadow = assignment.Shadow
+	// A conversation started with its own model or prompt would skew the variant's results
+	if assignment.Experiment == "" || c.model != "" || c.systemPrompt != "" {
+		return nil
+	}
+
+	c.model = assignment.Variant.Model
+	c.systemPrompt = assignment.Variant.SystemPrompt
+	return workflow.UpsertTypedSearchAttributes(ctx,
+		experiments.ExperimentKey.ValueSet(assignment.Experiment),
+		experiments.VariantKey.ValueSet(assignment.Variant.Name),
+	)
+}
+
+// resolveGoal looks up the goal of the conversation. The goal's prompt is fixed at start,
+// like its tools.
+func (c *conversation) resolveGoal(ctx workflow.Context) error {
+	var a *activities.Activities
+	var g goals.Goal
+	if err := workflow.ExecuteActivity(ctx, a.ResolveGoal, c.goal).Get(ctx, &g); err != nil {
+		return err
+	}
+	c.goalPrompt = g.Prompt
+	return nil
+}
+
+// resolvePersona looks up the persona the conversation speaks with
+func (c *conversation) resolvePersona(ctx workflow.Context, name string) error {
+	var a *activities.Activities
+	return workflow.ExecuteActivity(ctx, a.ResolvePersona, activities.ResolvePersonaRequest{
+		Name: name,
+		Goal: c.goal,
+	}).Get(ctx, &c.persona)
+}
+
+// indexTranscript stores the transcript for full-text search. Indexing is best effort:
+// failures are logged and the next turn re-indexes the whole transcript.
+func (c *conversation) indexTranscript(ctx workflow.Context) {
+	var a *activities.Activities
+	var content strings.Builder
+	for _, m := range c.history {
+		fmt.Fprintf(&content, "%s: %s\n", m.Role, m.Content)
+	}
+
+	ctx = withRetries(ctx, retries.Internal)
+	err := workflow.ExecuteActivity(ctx, a.IndexTranscript, search.Transcript{
+		WorkflowID: workflow.GetInfo(ctx).WorkflowExecution.ID,
+		Account:    c.account,
+		Title:      c.title,
+		Content:    content.String(),
+	}).Get(ctx, nil)
+	if err != nil {
+		workflow.GetLogger(ctx).Error("Error indexing transcript", "error", err)
+	}
+}
+
+// generateTitle summarizes the conversation into a title and stores it in the workflow memo.
+// Failures are logged and leave the conversation untitled so a later turn can retry.
+func (c *conversation) generateTitle(ctx workflow.Context) string {
+	var a *activities.Activities
+	var title string
+	ctx = withLLM(ctx)
+	req := activities.GenerateTitleRequest{Messages: c.history, Tenant: c.account}
+	if err := workflow.ExecuteActivity(ctx, a.GenerateTitle, req).Get(ctx, &title); err != nil {
+		workflow.GetLogger(ctx).Error("Error generating title", "error", err)
+		return ""
+	}
+	if err := workflow.UpsertMemo(ctx, map[string]interface{}{MemoTitle: title}); err != nil {
+		workflow.GetLogger(ctx).Error("Error storing title", "error", err)
+		return ""
+	}
+	return title
+}
@@ -0,0 +1,341 @@
+package workflows_test
+
+import (
+	"context"
+	"fmt"
+	"temporal-ai-agent/activities"
+	"temporal-ai-agent/activities/llm"
+	"temporal-ai-agent/config"
+	"temporal-ai-agent/models"
+	"temporal-ai-agent/registry"
+	"temporal-ai-agent/workflows"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+// conversationEnv is a test environment running conversations
+type conversationEnv struct {
+	*testsuite.TestWorkflowEnvironment
+	// updates counts the updates sent, which gives each its own ID
+	updates int
+}
+
+// newConversationEnv returns a test environment running conversations with the mock LLM
+// provider and the in-memory stores
+func newConversationEnv(t *testing.T) (*conversationEnv, *activities.Activities) {
+	cfg := config.FromEnv()
+	cfg.LLMProvider, cfg.DatabaseURL, cfg.SQLitePath, cfg.RedisURL = "mock", "", "", ""
+	acts, err := registry.NewActivities(cfg)
+	require.NoError(t, err)
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	registry.Register(env, acts)
+	return &conversationEnv{TestWorkflowEnvironment: env}, acts
+}
+
+// Messages sent while a turn is in flight wait for it, so turns never interleave in history
+func TestTurnLockSerializesTurns(t *testing.T) {
+	env, acts := newConversationEnv(t)
+	// Completions take a minute, so the second message arrives while the first turn is in flight
+	env.OnActivity(acts.Complete, mock.Anything, mock.Anything).After(time.Minute).Return(
+		func(ctx context.Context, req llm.Request) (llm.Response, error) { return acts.Complete(ctx, req) })
+
+	var replies []string
+	for i, message := range []string{"first", "second"} {
+		env.RegisterDelayedCallback(func() {
+			env.UpdateWorkflow(workflows.UpdateUserPrompt, message, &testsuite.TestUpdateCallback{
+				OnReject: func(err error) { require.Fail(t, "rejected", err.Error()) },
+				OnComplete: func(value interface{}, err error) {
+					require.NoError(t, err)
+					replies = append(replies, value.(workflows.TurnResult).Reply)
+				},
+			}, workflows.UserPrompt{Message: message})
+		}, time.Duration(i+1)*time.Second)
+	}
+	var history []llm.Message
+	env.RegisterDelayedCallback(func() {
+		history = queryHistory(t, env)
+		env.SignalWorkflow("end_chat", "done")
+	}, time.Hour)
+
+	env.ExecuteWorkflow(workflows.AgentGoalWorkflow, workflows.ConversationInput{})
+	require.NoError(t, env.GetWorkflowError())
+
+	require.Equal(t, []string{"(mock) You said: first", "(mock) You said: second"}, replies)
+	var turns []string
+	for _, m := range history {
+		if m.Role == llm.RoleUser || m.Role == llm.RoleAssistant {
+			turns = append(turns, m.Role+": "+m.Content)
+		}
+	}
+	require.Equal(t, []string{
+		"user: first", "assistant: (mock) You said: first",
+		"user: second", "assistant: (mock) You said: second",
+	}, turns)
+}
+
+// queryHistory returns the conversation's history
+func queryHistory(t *testing.T, env *conversationEnv) []llm.Message {
+	value, err := env.QueryWorkflow(workflows.QueryHistory)
+	require.NoError(t, err)
+	var history []llm.Message
+	require.NoError(t, value.Get(&history))
+	return history
+}
+
+// A conversation continued as new resumes with its history in the next run
+func TestContinueAsNewCarriesState(t *testing.T) {
+	env, _ := newConversationEnv(t)
+	env.SetContinueAsNewSuggested(true)
+	env.ExecuteWorkflow(workflows.AgentGoalWorkflow, workflows.ConversationInput{
+		AgentInput: models.AgentInput{Message: "hello"},
+	})
+
+	var continued *workflow.ContinueAsNewError
+	require.ErrorAs(t, env.GetWorkflowError(), &continued)
+	var input workflows.ConversationInput
+	require.NoError(t, converter.GetDefaultDataConverter().FromPayloads(continued.Input, &input))
+	require.Empty(t, input.Message, "the first message must not be answered again")
+	require.NotNil(t, input.State)
+	require.Len(t, input.State.History, 2)
+
+	next, _ := newConversationEnv(t)
+	var before, after []llm.Message
+	next.RegisterDelayedCallback(func() {
+		before = queryHistory(t, next)
+		next.UpdateWorkflow(workflows.UpdateUserPrompt, "again", &testsuite.TestUpdateCallback{
+			OnReject:   func(err error) { require.Fail(t, "rejected", err.Error()) },
+			OnComplete: func(interface{}, error) { after = queryHistory(t, next) },
+		}, workflows.UserPrompt{Message: "again"})
+	}, time.Second)
+	next.RegisterDelayedCallback(func() { next.SignalWorkflow("end_chat", "done") }, time.Hour)
+	next.ExecuteWorkflow(workflows.AgentGoalWorkflow, input)
+	require.NoError(t, next.GetWorkflowError())
+
+	require.Equal(t, input.State.History, before)
+	require.Len(t, after, 4)
+	require.Equal(t, "(mock) You said: again", after[3].Content)
+}
+
+// updateOutcome is the outcome of an update: the error it was rejected with, or its result
+type updateOutcome struct {
+	rejected  error
+	completed bool
+	result    interface{}
+	err       error
+}
+
+// sendUpdate sends an update whose outcome is recorded once the workflow handles it
+func (env *conversationEnv) sendUpdate(name string, arg interface{}) *updateOutcome {
+	env.updates++
+	outcome := &updateOutcome{}
+	env.UpdateWorkflow(name, fmt.Sprintf("%s-%d", name, env.updates), &testsuite.TestUpdateCallback{
+		OnReject: func(err error) { outcome.rejected = err },
+		OnComplete: func(result interface{}, err error) {
+			outcome.completed, outcome.result, outcome.err = true, result, err
+		},
+	}, arg)
+	return outcome
+}
+
+// runConversation runs a conversation opened with a first message, running each step a
+// second after the previous one, and ends it
+func runConversation(t *testing.T, env *conversationEnv, steps ...func()) {
+	for i, step := range steps {
+		env.RegisterDelayedCallback(step, time.Duration(i+1)*time.Second)
+	}
+	env.RegisterDelayedCallback(func() { env.SignalWorkflow("end_chat", "done") }, time.Hour)
+	env.ExecuteWorkflow(workflows.AgentGoalWorkflow, workflows.ConversationInput{
+		AgentInput: models.AgentInput{Message: "hello"},
+	})
+	require.NoError(t, env.GetWorkflowError())
+}
+
+// A message sent while a tool call awaits confirmation is answered without the pending
+// call in the request: providers reject a call that is not directly followed by its result
+func TestPendingToolCallStaysOutOfRequests(t *testing.T) {
+	env, acts := newConversationEnv(t)
+	var requests []llm.Request
+	env.OnActivity(acts.Complete, mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, req llm.Request) (llm.Response, error) {
+			requests = append(requests, req)
+			return acts.Complete(ctx, req)
+		})
+
+	var pending *updateOutcome
+	env.RegisterDelayedCallback(func() {
+		pending = env.sendUpdate(workflows.UpdateUserPrompt, workflows.UserPrompt{Message: "is it refundable?"})
+	}, time.Second)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(workflows.SignalConfirm, workflows.ConfirmRequest{
+			Decision: workflows.DecisionApprove, Phrase: "confirm book_flight",
+		})
+	}, 2*time.Second)
+	env.RegisterDelayedCallback(func() {
+		env.sendUpdate(workflows.UpdateUserPrompt, workflows.UserPrompt{Message: "thanks"})
+	}, 3*time.Second)
+	env.RegisterDelayedCallback(func() { env.SignalWorkflow("end_chat", "done") }, time.Hour)
+	env.ExecuteWorkflow(workflows.AgentGoalWorkflow, workflows.ConversationInput{
+		AgentInput: models.AgentInput{Message: `/book_flight {"flight": "AI-101", "date": "2025-06-01"}`},
+	})
+	require.NoError(t, env.GetWorkflowError())
+	require.True(t, pending.completed)
+	require.NoError(t, pending.err)
+
+	var answered bool
+	for _, req := range requests {
+		for i, m := range req.Messages {
+			if m.ToolCall == nil {
+				continue
+			}
+			require.Less(t, i+1, len(req.Messages), "tool call %s has no result", m.ToolCall.ID)
+			require.Equal(t, llm.RoleTool, req.Messages[i+1].Role, "tool call %s is not followed by its result", m.ToolCall.ID)
+			require.Equal(t, m.ToolCall.ID, req.Messages[i+1].ToolCallID)
+			answered = true
+		}
+	}
+	require.True(t, answered, "the approved call is sent with its result")
+}
+
+// update is an update sent by TestUpdateValidators and how it must be handled
+type update struct {
+	name string
+	arg  interface{}
+	// rejected is the expected rejection, empty when the update is accepted
+	rejected string
+	// result is the expected result of an accepted update, nil when it is not checked
+	result interface{}
+}
+
+// Updates are validated before they are accepted: invalid ones are rejected without
+// reaching the history. Each case opens a conversation with a first message and sends its
+// steps of updates a second apart.
+func TestUpdateValidators(t *testing.T) {
+	first := 0
+	for _, tc := range []struct {
+		name  string
+		steps [][]update
+		// llmDown is the step, counting from 1, during which the LLM is unavailable
+		llmDown int
+	}{
+		{
+			name: "user prompt",
+			steps: [][]update{
+				{{name: workflows.UpdateUserPrompt, arg: workflows.UserPrompt{}, rejected: "message or attachments are required"}},
+				{{name: workflows.UpdateUserPrompt, arg: workflows.UserPrompt{Message: "hi", MessageID: "m-1"}}},
+				{{name: workflows.UpdateUserPrompt, arg: workflows.UserPrompt{Message: "hi", MessageID: "m-1"}, rejected: "message m-1 was already processed"}},
+			},
+		},
+		{
+			name: "background task",
+			steps: [][]update{{
+				{name: workflows.UpdateStartBackgroundTask, arg: workflows.BackgroundTask{Interval: time.Hour}, rejected: "description is required"},
+				{name: workflows.UpdateStartBackgroundTask, arg: workflows.BackgroundTask{Description: "watch the order", Interval: time.Second}, rejected: "interval must be at least 1m0s"},
+				{name: workflows.UpdateStartBackgroundTask, arg: workflows.BackgroundTask{Description: "watch the order", MaxRuns: -1}, rejected: "max_runs must not be negative"},
+			}},
+		},
+		{
+			// The history holds the first message and its reply
+			name: "edit",
+			steps: [][]update{{
+				{name: workflows.UpdateEditMessage, arg: workflows.EditMessageRequest{MessageIndex: 0}, rejected: "content is required"},
+				{name: workflows.UpdateEditMessage, arg: workflows.EditMessageRequest{MessageIndex: 2, Content: "hi"}, rejected: "message_index 2 out of range"},
+				{name: workflows.UpdateEditMessage, arg: workflows.EditMessageRequest{MessageIndex: 1, Content: "hi"}, rejected: "message 1 is not a user message"},
+				{name: workflows.UpdateEditMessage, arg: workflows.EditMessageRequest{MessageIndex: 0, Content: "hi"}, result: "(mock) You said: hi"},
+			}},
+		},
+		{
+			name: "annotation",
+			steps: [][]update{{
+				{name: workflows.UpdateAnnotateMessage, arg: workflows.Annotation{MessageIndex: 1, Kind: "rating", Value: "5"}, rejected: "kind must be reaction, label or note"},
+				{name: workflows.UpdateAnnotateMessage, arg: workflows.Annotation{MessageIndex: 1, Kind: workflows.AnnotationReaction}, rejected: "value is required"},
+				{name: workflows.UpdateAnnotateMessage, arg: workflows.Annotation{MessageIndex: 1, Kind: workflows.AnnotationLabel, Value: "billing"}, rejected: "a label needs an author"},
+				{name: workflows.UpdateAnnotateMessage, arg: workflows.Annotation{MessageIndex: 2, Kind: workflows.AnnotationReaction, Value: "👍"}, rejected: "message_index 2 out of range"},
+				{name: workflows.UpdateAnnotateMessage, arg: workflows.Annotation{MessageIndex: 1, Kind: workflows.AnnotationReaction, Value: "👍"}},
+				{name: workflows.UpdateAnnotateMessage, arg: workflows.Annotation{MessageIndex: 1, Kind: workflows.AnnotationReaction, Value: "👍"}, rejected: "message 1 already has this reaction"},
+			}},
+		},
+		{
+			name: "handoff",
+			steps: [][]update{
+				{{name: workflows.UpdateClaimHandoff, arg: "alice", rejected: "conversation is not handed off to an operator"}},
+				{{name: workflows.UpdateRequestHandoff, arg: "refund dispute"}},
+				{
+					{name: workflows.UpdateRequestHandoff, arg: "refund dispute", rejected: "conversation is already handed off to an operator"},
+					{name: workflows.UpdateOperatorMessage, arg: workflows.OperatorMessage{Operator: "alice", Message: "hi"}, rejected: "conversation must be claimed first"},
+					{name: workflows.UpdateClaimHandoff, arg: "alice"},
+				},
+				{
+					{name: workflows.UpdateClaimHandoff, arg: "bob", rejected: "conversation is already claimed by alice"},
+					{name: workflows.UpdateOperatorMessage, arg: workflows.OperatorMessage{Operator: "bob", Message: "hi"}, rejected: "conversation is claimed by alice"},
+					{name: workflows.UpdateReturnToAgent, arg: "bob", rejected: "conversation is claimed by alice"},
+					{name: workflows.UpdateOperatorMessage, arg: workflows.OperatorMessage{Operator: "alice"}, rejected: "message is required"},
+					{name: workflows.UpdateOperatorMessage, arg: workflows.OperatorMessage{Operator: "alice", Message: "hi"}},
+				},
+				{{name: workflows.UpdateReturnToAgent, arg: "alice"}},
+			},
+		},
+		{
+			// The LLM is unavailable for the second message, which gets the fallback reply
+			name: "reprocess turn",
+			steps: [][]update{
+				{{name: workflows.UpdateReprocessTurn, arg: workflows.ReprocessTurn{}, rejected: "no degraded turn to reprocess"}},
+				{{name: workflows.UpdateUserPrompt, arg: workflows.UserPrompt{Message: "where is my order?"}}},
+				{
+					{name: workflows.UpdateReprocessTurn, arg: workflows.ReprocessTurn{MessageIndex: &first}, rejected: "the conversation has moved on since the turn of message 0"},
+					{name: workflows.UpdateReprocessTurn, arg: workflows.ReprocessTurn{}, result: "(mock) You said: where is my order?"},
+				},
+			},
+			llmDown: 2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			env, acts := newConversationEnv(t)
+			step := 0
+			env.OnActivity(acts.Complete, mock.Anything, mock.Anything).Return(
+				func(ctx context.Context, req llm.Request) (llm.Response, error) {
+					if step == tc.llmDown {
+						return llm.Response{}, temporal.NewNonRetryableApplicationError("LLM unavailable", "Unavailable", nil)
+					}
+					return acts.Complete(ctx, req)
+				})
+
+			outcomes := make([][]*updateOutcome, len(tc.steps))
+			var steps []func()
+			for i, updates := range tc.steps {
+				steps = append(steps, func() {
+					step = i + 1
+					for _, u := range updates {
+						outcomes[i] = append(outcomes[i], env.sendUpdate(u.name, u.arg))
+					}
+				})
+			}
+			runConversation(t, env, steps...)
+
+			for i, updates := range tc.steps {
+				for j, u := range updates {
+					outcome := outcomes[i][j]
+					if u.rejected != "" {
+						require.ErrorContains(t, outcome.rejected, u.rejected, "%s %d.%d", u.name, i, j)
+						require.False(t, outcome.completed)
+						continue
+					}
+					require.NoError(t, outcome.rejected, "%s %d.%d", u.name, i, j)
+					require.True(t, outcome.completed, "%s %d.%d", u.name, i, j)
+					require.NoError(t, outcome.err, "%s %d.%d", u.name, i, j)
+					if u.result != nil {
+						require.Equal(t, u.result, outcome.result)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+// Package workflowutil provides IDs, random choices and timestamps that are safe to use in
+// workflow code. Workflows are replayed from their history, so any value that can differ
+// between executions must be recorded in it; calling uuid, math/rand or time.Now directly
+// makes a replay take another path and fail with a nondeterminism error.
+package workflowutil
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/google/uuid"
+	"go.temporal.io/sdk/workflow"
+)
+
+// Now returns the current workflow time in UTC. It is the time of the workflow task being
+// processed, so it is the same on replay.
+func Now(ctx workflow.Context) time.Time {
+	return workflow.Now(ctx).UTC()
+}
+
+// Record runs fn once and records its result in the workflow history; replays return the
+// recorded result without running fn again
+func Record[T any](ctx workflow.Context, fn func() T) (T, error) {
+	var value T
+	err := workflow.SideEffect(ctx, func(ctx workflow.Context) interface{} {
+		return fn()
+	}).Get(&value)
+	return value, err
+}
+
+// NewID returns a random UUID
+func NewID(ctx workflow.Context) (string, error) {
+	return Record(ctx, uuid.NewString)
+}
+
+// Intn returns a random number in [0, n)
+func Intn(ctx workflow.Context, n int) (int, error) {
+	if n <= 0 {
+		return 0, fmt.Errorf("invalid range %d", n)
+	}
+	return Record(ctx, func() int { return rand.IntN(n) })
+}
+
+// Choose returns a random item of a non-empty list
+func Choose[T any](ctx workflow.Context, items []T) (T, error) {
+	var zero T
+	if len(items) == 0 {
+		return zero, fmt.Errorf("nothing to choose from")
+	}
+	i, err := Intn(ctx, len(items))
+	if err != nil {
+		return zero, err
+	}
+	return items[i], nil
+}